@@ -2,23 +2,49 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/i-sevostyanov/NanoDB/internal/shell"
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/engine"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/lexer"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/parser"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/planner"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
 	"github.com/i-sevostyanov/NanoDB/internal/storage/memory"
 )
 
 func main() {
+	storage := flag.String("storage", "memory", "storage engine: memory or disk")
+	dataDir := flag.String("data-dir", "data", "data directory of the disk storage")
+	bufferPages := flag.Int("buffer-pages", disk.DefaultBufferPoolSize, "number of pages cached by the disk storage")
+	eviction := flag.String("eviction", "clock", "page eviction policy of the disk storage: lru or clock")
+	tableEngine := flag.String("engine", disk.EngineHeap,
+		"engine of new disk databases unless USING is given: heap, lsm or columnar")
+	flag.Parse()
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	sqlCatalog, closeCatalog, err := openCatalog(*storage, *dataDir, *bufferPages, *eviction, *tableEngine)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "open catalog: %v\n", err)
+		os.Exit(1)
+	}
+
+	defer func() {
+		if err = closeCatalog(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "close catalog: %v\n", err)
+		}
+	}()
+
 	sqlParser := engine.ParseFn(func(sql string) (ast.Node, error) {
 		lx := lexer.New(sql)
 		pr := parser.New(lx)
@@ -26,11 +52,44 @@ func main() {
 		return pr.Parse()
 	})
 
-	sqlCatalog := memory.NewCatalog()
-	sqlPlanner := planner.New(sqlCatalog)
+	txManager := transaction.NewManager(sqlCatalog)
+	sqlPlanner := planner.New(txManager)
 	sqlEngine := engine.New(sqlParser, sqlPlanner)
 	tableWriter := shell.NewTableWriter()
 
-	sh := shell.New(os.Stdin, os.Stdout, sqlCatalog, sqlEngine, tableWriter)
+	sh := shell.New(os.Stdin, os.Stdout, txManager, sqlEngine, tableWriter)
 	sh.Run(ctx)
 }
+
+func openCatalog(
+	storage, dataDir string,
+	bufferPages int,
+	eviction, tableEngine string,
+) (sql.Catalog, func() error, error) {
+	switch storage {
+	case "memory":
+		return memory.NewCatalog(), func() error { return nil }, nil
+	case "disk":
+		var policy buffer.Policy
+
+		switch eviction {
+		case "lru":
+			policy = buffer.NewLRU()
+		case "clock":
+			policy = buffer.NewClock()
+		default:
+			return nil, nil, fmt.Errorf("unknown eviction policy %q", eviction)
+		}
+
+		pool := buffer.New(bufferPages, disk.PageSize, policy)
+
+		catalog, err := disk.Open(dataDir, disk.WithBufferPool(pool), disk.WithEngine(tableEngine))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return catalog, catalog.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
 )
 
 const prompt = "#> "
@@ -21,7 +23,7 @@ type TableWriter interface {
 }
 
 type Engine interface {
-	Exec(database, sql string) (columns []string, iter sql.RowIter, err error)
+	Exec(session *transaction.Session, database, sql string) (columns []string, iter sql.RowIter, err error)
 }
 
 // Shell is terminal-based front-end to NanoDB.
@@ -31,17 +33,19 @@ type Shell struct {
 	catalog  sql.Catalog
 	database sql.Database
 	engine   Engine
+	session  *transaction.Session
 	tw       TableWriter
 	prompt   string
 	closeCh  chan struct{}
 }
 
-func New(in io.Reader, out io.Writer, catalog sql.Catalog, engine Engine, tw TableWriter) *Shell {
+func New(in io.Reader, out io.Writer, manager *transaction.Manager, engine Engine, tw TableWriter) *Shell {
 	return &Shell{
 		input:   in,
 		output:  out,
-		catalog: catalog,
+		catalog: manager,
 		engine:  engine,
+		session: transaction.NewSession(manager),
 		tw:      tw,
 		prompt:  prompt,
 		closeCh: make(chan struct{}),
@@ -101,8 +105,12 @@ func (s *Shell) execCommand(input string) (string, error) {
 		return s.listDatabases()
 	case `\tables`:
 		return s.listTables()
+	case `\sequences`:
+		return s.listSequences()
 	case `\describe`:
 		return s.describeTable(params)
+	case `\statistics`:
+		return s.showStatistics(params)
 	case `\import`:
 		return s.importFile(params)
 	case `\help`:
@@ -164,6 +172,39 @@ func (s *Shell) listTables() (string, error) {
 	return table, nil
 }
 
+func (s *Shell) listSequences() (string, error) {
+	if s.database == nil {
+		return "", errors.New("connect to database first")
+	}
+
+	sequences := s.database.ListSequences()
+	slices.SortFunc(sequences, func(a, b sql.NamedSequence) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	data := make([][]string, 0, len(sequences))
+
+	for i := range sequences {
+		var current string
+
+		if value, ok := sequences[i].Current(); ok {
+			current = strconv.FormatInt(value, 10)
+		}
+
+		options := sequences[i].Options()
+		data = append(data, []string{
+			sequences[i].Name(),
+			strconv.FormatInt(options.Start, 10),
+			strconv.FormatInt(options.Increment, 10),
+			current,
+		})
+	}
+
+	table := s.tw.WriteTable([]string{"Sequence", "Start", "Increment", "Current"}, data, true)
+
+	return table, nil
+}
+
 func (s *Shell) describeTable(params []string) (string, error) {
 	if s.database == nil {
 		return "", errors.New("connect to database first")
@@ -190,6 +231,9 @@ func (s *Shell) describeTable(params []string) (string, error) {
 			PrimaryKey: scheme[name].PrimaryKey,
 			Nullable:   scheme[name].Nullable,
 			Default:    scheme[name].Default,
+			Hidden:     scheme[name].Hidden,
+			Sequence:   scheme[name].Sequence,
+			Identity:   scheme[name].Identity,
 		}
 	}
 
@@ -199,7 +243,16 @@ func (s *Shell) describeTable(params []string) (string, error) {
 	for i := range columns {
 		var defaultValue string
 
-		if columns[i].Default != nil {
+		if columns[i].Hidden {
+			continue
+		}
+
+		switch {
+		case columns[i].Identity:
+			defaultValue = "generated always as identity"
+		case columns[i].Sequence != "":
+			defaultValue = fmt.Sprintf("nextval('%s')", columns[i].Sequence)
+		case columns[i].Default != nil:
 			defaultValue = columns[i].Default.String()
 		}
 
@@ -216,7 +269,98 @@ func (s *Shell) describeTable(params []string) (string, error) {
 	tb := s.tw.WriteTable([]string{"Column", "Type", "Nullable", "Default"}, data, false)
 	buf.WriteString(tb)
 	buf.WriteString("Indexes:\n")
-	buf.WriteString(fmt.Sprintf("   PRIMARY KEY (%s) autoincrement\n\n", primaryKey.Name))
+
+	if len(primaryKey) > 0 && !primaryKey[0].Hidden {
+		names := make([]string, 0, len(primaryKey))
+
+		for _, column := range primaryKey {
+			names = append(names, column.Name)
+		}
+
+		buf.WriteString(fmt.Sprintf("   PRIMARY KEY (%s)", strings.Join(names, ", ")))
+
+		if len(primaryKey) == 1 && primaryKey[0].DataType == sql.Integer {
+			buf.WriteString(" autoincrement")
+		}
+
+		buf.WriteString("\n")
+	}
+
+	indexes := table.Indexes()
+	slices.SortFunc(indexes, func(a, b sql.Index) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	for _, index := range indexes {
+		kind := "INDEX"
+
+		if index.Unique() {
+			kind = "UNIQUE INDEX"
+		}
+
+		buf.WriteString(fmt.Sprintf("   %s %s (%s)\n", kind, index.Name(), strings.Join(index.Columns(), ", ")))
+	}
+
+	buf.WriteString("\n")
+
+	return buf.String(), nil
+}
+
+func (s *Shell) showStatistics(params []string) (string, error) {
+	if s.database == nil {
+		return "", errors.New("connect to database first")
+	}
+
+	if len(params) < 2 {
+		return "", errors.New("table name not specified")
+	}
+
+	table, err := s.database.GetTable(params[1])
+	if err != nil {
+		return "", err
+	}
+
+	stats, ok := s.database.Statistics(table.Name())
+	if !ok {
+		return fmt.Sprintf("table %q has not been analyzed\n", table.Name()), nil
+	}
+
+	scheme := table.Scheme()
+	columns := make([]sql.Column, len(scheme))
+
+	for _, column := range scheme {
+		columns[column.Position] = column
+	}
+
+	data := make([][]string, 0, len(columns))
+
+	for _, column := range columns {
+		if column.Hidden {
+			continue
+		}
+
+		var low, high string
+
+		columnStats := stats.Columns[column.Name]
+
+		if bounds := columnStats.Histogram; len(bounds) > 0 {
+			low, high = bounds[0].String(), bounds[len(bounds)-1].String()
+		}
+
+		data = append(data, []string{
+			column.Name,
+			strconv.FormatFloat(columnStats.NullFraction, 'f', 3, 64),
+			strconv.FormatInt(columnStats.Distinct, 10),
+			low,
+			high,
+			strconv.Itoa(max(len(columnStats.Histogram)-1, 0)),
+		})
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("Rows: %d\n", stats.Rows))
+	buf.WriteString(s.tw.WriteTable([]string{"Column", "Null fraction", "Distinct", "Min", "Max", "Buckets"}, data, false))
+	buf.WriteString("\n")
 
 	return buf.String(), nil
 }
@@ -254,7 +398,9 @@ Commands:
   \use <database>                  Use specified database
   \databases                       List databases
   \tables                          List tables
+  \sequences                       List sequences
   \describe <table>                Show table definition
+  \statistics <table>              Show table statistics collected by ANALYZE
   \import <absolute path to file>  Import from file
   \help                            Show help
   \quit                            Quit
@@ -276,7 +422,7 @@ func (s *Shell) execQuery(input string) (string, error) {
 		database = s.database.Name()
 	}
 
-	columns, rowIter, err := s.engine.Exec(database, input)
+	columns, rowIter, err := s.engine.Exec(s.session, database, input)
 	if err != nil {
 		return "", fmt.Errorf("execute query: %w", err)
 	}
@@ -0,0 +1,35 @@
+package sql
+
+// KeyRange converts bounds of the leading primary key column to the range of keys [low, high),
+// the empty high key means the range is unbounded. False is returned if no key falls into the range.
+func KeyRange(from, to *Bound) (low, high Key, ok bool, err error) {
+	if from != nil {
+		if low, err = NewKey(from.Value); err != nil {
+			return "", "", false, err
+		}
+
+		// Keys that start with an excluded value go before the range.
+		if !from.Inclusive {
+			if low = low.PrefixEnd(); low == "" {
+				return "", "", false, nil
+			}
+		}
+	}
+
+	if to != nil {
+		if high, err = NewKey(to.Value); err != nil {
+			return "", "", false, err
+		}
+
+		if to.Inclusive {
+			high = high.PrefixEnd()
+		}
+	}
+
+	return low, high, high == "" || low < high, nil
+}
+
+// InRange reports whether the key falls into the range [low, high), the empty high key is unbounded.
+func InRange(key, low, high Key) bool {
+	return key >= low && (high == "" || key < high)
+}
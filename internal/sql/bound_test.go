@@ -0,0 +1,119 @@
+package sql_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+func TestKeyRange(t *testing.T) {
+	t.Parallel()
+
+	bound := func(key int64, inclusive bool) *sql.Bound {
+		return &sql.Bound{Value: datatype.NewInteger(key), Inclusive: inclusive}
+	}
+
+	keys := []int64{math.MinInt64, 1, 2, 5, 6, 9, 10, math.MaxInt64}
+
+	tests := []struct {
+		name     string
+		from     *sql.Bound
+		to       *sql.Bound
+		expected []int64
+		ok       bool
+	}{
+		{
+			name:     "unbounded",
+			expected: keys,
+			ok:       true,
+		},
+		{
+			name:     "inclusive bounds",
+			from:     bound(1, true),
+			to:       bound(10, true),
+			expected: []int64{1, 2, 5, 6, 9, 10},
+			ok:       true,
+		},
+		{
+			name:     "exclusive bounds",
+			from:     bound(1, false),
+			to:       bound(10, false),
+			expected: []int64{2, 5, 6, 9},
+			ok:       true,
+		},
+		{
+			name: "empty range",
+			from: bound(6, false),
+			to:   bound(6, false),
+			ok:   false,
+		},
+		{
+			name:     "inclusive maximum",
+			from:     bound(math.MaxInt64, true),
+			expected: []int64{math.MaxInt64},
+			ok:       true,
+		},
+		{
+			name:     "exclusive minimum",
+			to:       bound(math.MinInt64, false),
+			expected: []int64{},
+			ok:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			low, high, ok, err := sql.KeyRange(test.from, test.to)
+			require.NoError(t, err)
+			require.Equal(t, test.ok, ok)
+
+			if !ok {
+				return
+			}
+
+			found := make([]int64, 0)
+
+			for _, key := range keys {
+				if sql.InRange(sql.IntegerKey(key), low, high) {
+					found = append(found, key)
+				}
+			}
+
+			assert.Equal(t, test.expected, found)
+		})
+	}
+
+	t.Run("bounds apply to the leading column of composite keys", func(t *testing.T) {
+		t.Parallel()
+
+		low, high, ok, err := sql.KeyRange(bound(1, false), bound(2, true))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		inside, err := sql.NewKey(datatype.NewInteger(2), datatype.NewText("z"))
+		require.NoError(t, err)
+		assert.True(t, sql.InRange(inside, low, high))
+
+		before, err := sql.NewKey(datatype.NewInteger(1), datatype.NewText("z"))
+		require.NoError(t, err)
+		assert.False(t, sql.InRange(before, low, high))
+
+		after, err := sql.NewKey(datatype.NewInteger(3), datatype.NewText(""))
+		require.NoError(t, err)
+		assert.False(t, sql.InRange(after, low, high))
+	})
+
+	t.Run("returns error on null bound", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, _, err := sql.KeyRange(&sql.Bound{Value: datatype.NewNull()}, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+package datatype
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// HashKey encodes the values so that values that are not distinct get the same key: integers and floats
+// are encoded as floats, so values equal by the = operator share a key too. Values whose keys are the same aren't
+// always the same, big integers may share a float, so they are still compared by NotDistinct.
+func HashKey(values ...sql.Value) string {
+	var b strings.Builder
+
+	for _, value := range values {
+		switch raw := value.Raw().(type) {
+		case int64:
+			b.WriteByte('n')
+			b.WriteString(strconv.FormatFloat(float64(raw), 'g', -1, 64))
+		case float64:
+			// -0 is equal to 0.
+			if raw == 0 {
+				raw = 0
+			}
+
+			b.WriteByte('n')
+			b.WriteString(strconv.FormatFloat(raw, 'g', -1, 64))
+		case string:
+			b.WriteByte('t')
+			b.WriteString(strconv.Itoa(len(raw)))
+			b.WriteByte(':')
+			b.WriteString(raw)
+		case bool:
+			b.WriteByte('b')
+			b.WriteString(strconv.FormatBool(raw))
+		default:
+			b.WriteByte('z')
+		}
+
+		b.WriteByte(';')
+	}
+
+	return b.String()
+}
+
+// NotDistinct reports whether the values are the same for DISTINCT and GROUP BY. NULL is the same as NULL,
+// an integer and a float are the same if they are equal as numbers, values of other different types are distinct.
+func NotDistinct(a, b sql.Value) bool {
+	switch left := a.Raw().(type) {
+	case int64:
+		switch right := b.Raw().(type) {
+		case int64:
+			return left == right
+		case float64:
+			return float64(left) == right
+		}
+	case float64:
+		switch right := b.Raw().(type) {
+		case int64:
+			return left == float64(right)
+		case float64:
+			return left == right
+		}
+	default:
+		return a.DataType() == b.DataType() && a.Raw() == b.Raw()
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package datatype_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+func TestNotDistinct(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		a        sql.Value
+		b        sql.Value
+		expected bool
+	}{
+		{name: "equal integers", a: datatype.NewInteger(10), b: datatype.NewInteger(10), expected: true},
+		{name: "different integers", a: datatype.NewInteger(10), b: datatype.NewInteger(11), expected: false},
+		{name: "integer and equal float", a: datatype.NewInteger(10), b: datatype.NewFloat(10), expected: true},
+		{name: "float and equal integer", a: datatype.NewFloat(-3), b: datatype.NewInteger(-3), expected: true},
+		{name: "integer and other float", a: datatype.NewInteger(10), b: datatype.NewFloat(10.5), expected: false},
+		{name: "zero and negative zero", a: datatype.NewFloat(0), b: datatype.NewFloat(math.Copysign(0, -1)), expected: true},
+		{name: "equal texts", a: datatype.NewText("abc"), b: datatype.NewText("abc"), expected: true},
+		{name: "different texts", a: datatype.NewText("abc"), b: datatype.NewText("abd"), expected: false},
+		{name: "equal booleans", a: datatype.NewBoolean(true), b: datatype.NewBoolean(true), expected: true},
+		{name: "nulls", a: datatype.NewNull(), b: datatype.NewNull(), expected: true},
+		{name: "null and value", a: datatype.NewNull(), b: datatype.NewInteger(0), expected: false},
+		{name: "value and null", a: datatype.NewText(""), b: datatype.NewNull(), expected: false},
+		{name: "text and integer", a: datatype.NewText("1"), b: datatype.NewInteger(1), expected: false},
+		{name: "boolean and integer", a: datatype.NewBoolean(true), b: datatype.NewInteger(1), expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, datatype.NotDistinct(test.a, test.b))
+			assert.Equal(t, test.expected, datatype.NotDistinct(test.b, test.a))
+
+			// Values that are not distinct share a key.
+			if test.expected {
+				assert.Equal(t, datatype.HashKey(test.a), datatype.HashKey(test.b))
+			}
+		})
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		datatype.HashKey(datatype.NewInteger(1), datatype.NewText("a")),
+		datatype.HashKey(datatype.NewFloat(1), datatype.NewText("a")),
+	)
+
+	// Texts are length-prefixed, so values don't run into each other.
+	assert.NotEqual(t,
+		datatype.HashKey(datatype.NewText("a;"), datatype.NewText("b")),
+		datatype.HashKey(datatype.NewText("a"), datatype.NewText(";b")),
+	)
+
+	assert.NotEqual(t, datatype.HashKey(datatype.NewNull()), datatype.HashKey(datatype.NewBoolean(false)))
+	assert.NotEqual(t, datatype.HashKey(datatype.NewText("1")), datatype.HashKey(datatype.NewInteger(1)))
+	assert.Equal(t, datatype.HashKey(), datatype.HashKey())
+}
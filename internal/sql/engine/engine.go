@@ -1,11 +1,14 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
 )
 
 //go:generate go run go.uber.org/mock/mockgen -typed -source=engine.go -destination ./engine_mock_test.go -package engine_test
@@ -15,7 +18,7 @@ type Parser interface {
 }
 
 type Planner interface {
-	Plan(database string, node ast.Node) (plan.Node, error)
+	Plan(session *transaction.Session, database string, node ast.Node) (plan.Node, error)
 }
 
 type ParseFn func(sql string) (ast.Node, error)
@@ -36,21 +39,224 @@ func New(parser Parser, planner Planner) *Engine {
 	}
 }
 
-func (e *Engine) Exec(database, input string) (columns []string, iter sql.RowIter, err error) {
+// implicitRetries is how many times a statement that runs in a transaction of its own is run again
+// if its changes conflict with changes committed by other sessions in the meantime.
+const implicitRetries = 3
+
+// Exec runs the statement in the session. The session keeps the open transaction between calls.
+// If there's no open transaction, a statement that reads or changes many rows runs in a transaction of its own:
+// a query reads the snapshot the statement has started with, the transaction ends when the returned iterator
+// is exhausted or closed. A statement that changes rows is run and committed on the first call of Next,
+// it's run again in a new transaction if another session has changed the same rows in the meantime.
+func (e *Engine) Exec(
+	session *transaction.Session,
+	database, input string,
+) (columns []string, iter sql.RowIter, err error) {
 	astNode, err := e.parser.Parse(input)
 	if err != nil {
 		return nil, nil, fmt.Errorf("parse sql query: %w", err)
 	}
 
-	planNode, err := e.planner.Plan(database, astNode)
+	implicit := session.Tx() == nil && (changesRows(astNode) || readsRows(astNode))
+	if implicit {
+		if err = session.Begin(); err != nil {
+			return nil, nil, fmt.Errorf("begin transaction: %w", err)
+		}
+
+		defer func() {
+			if err != nil {
+				_ = session.Rollback()
+			}
+		}()
+	}
+
+	planNode, iter, err := e.run(session, database, astNode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case implicit && changesRows(astNode):
+		iter = &implicitIter{
+			iter:    iter,
+			session: session,
+			retry: func() (sql.RowIter, error) {
+				if err := session.Begin(); err != nil {
+					return nil, fmt.Errorf("begin transaction: %w", err)
+				}
+
+				_, iter, err := e.run(session, database, astNode)
+				if err != nil {
+					_ = session.Rollback()
+				}
+
+				return iter, err
+			},
+		}
+	case implicit:
+		iter = &snapshotIter{iter: iter, session: session}
+	}
+
+	return planNode.Columns(), iter, nil
+}
+
+// run plans the statement and starts its execution.
+func (e *Engine) run(session *transaction.Session, database string, node ast.Node) (plan.Node, sql.RowIter, error) {
+	planNode, err := e.planner.Plan(session, database, node)
 	if err != nil {
 		return nil, nil, fmt.Errorf("build query plan: %w", err)
 	}
 
-	iter, err = planNode.RowIter()
+	iter, err := planNode.RowIter()
 	if err != nil {
 		return nil, nil, fmt.Errorf("get rows iter: %w", err)
 	}
 
-	return planNode.Columns(), iter, nil
+	return planNode, iter, nil
+}
+
+// changesRows reports whether the statement may change more than one row.
+func changesRows(node ast.Node) bool {
+	switch stmt := node.(type) {
+	case *ast.UpdateStatement, *ast.DeleteStatement:
+		return true
+	case *ast.ExplainStatement:
+		return stmt.Analyze && changesRows(stmt.Statement)
+	default:
+		return false
+	}
+}
+
+// readsRows reports whether the statement is a query that reads rows of tables.
+func readsRows(node ast.Node) bool {
+	switch stmt := node.(type) {
+	case *ast.SelectStatement:
+		return true
+	case *ast.ExplainStatement:
+		return stmt.Analyze && readsRows(stmt.Statement)
+	default:
+		return false
+	}
+}
+
+// implicitIter runs a statement that changes rows in a transaction of its own. The statement is run to the end
+// and the transaction is committed before the first row is returned, so the statement can be run again
+// if the commit conflicts with other sessions. The transaction is rolled back if the statement fails
+// or is closed before it's run.
+type implicitIter struct {
+	iter    sql.RowIter
+	session *transaction.Session
+	// retry begins a new transaction and starts the statement again.
+	retry func() (sql.RowIter, error)
+	rows  []sql.Row
+	done  bool
+}
+
+func (i *implicitIter) Next() (sql.Row, error) {
+	if !i.done {
+		i.done = true
+
+		if err := i.exec(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(i.rows) == 0 {
+		return nil, io.EOF
+	}
+
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+
+	return row, nil
+}
+
+func (i *implicitIter) exec() error {
+	for attempt := 0; ; attempt++ {
+		rows, err := drain(i.iter)
+		i.iter = nil
+
+		if err != nil {
+			_ = i.session.Rollback()
+
+			return err
+		}
+
+		err = i.session.Commit()
+		if err == nil {
+			i.rows = rows
+
+			return nil
+		}
+
+		if !errors.Is(err, transaction.ErrConflict) || attempt == implicitRetries {
+			return err
+		}
+
+		if i.iter, err = i.retry(); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *implicitIter) Close() error {
+	if !i.done {
+		i.done = true
+		_ = i.session.Rollback()
+	}
+
+	if i.iter != nil {
+		return i.iter.Close()
+	}
+
+	return nil
+}
+
+// snapshotIter ends the transaction of a query when its rows are over or it's closed.
+// The transaction has nothing to commit, so it's rolled back.
+type snapshotIter struct {
+	iter    sql.RowIter
+	session *transaction.Session
+	done    bool
+}
+
+func (i *snapshotIter) Next() (sql.Row, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+
+	row, err := i.iter.Next()
+	if err != nil {
+		i.done = true
+		_ = i.session.Rollback()
+	}
+
+	return row, err
+}
+
+func (i *snapshotIter) Close() error {
+	if !i.done {
+		i.done = true
+		_ = i.session.Rollback()
+	}
+
+	return i.iter.Close()
+}
+
+// drain reads all rows of the iterator and closes it.
+func drain(iter sql.RowIter) ([]sql.Row, error) {
+	var rows []sql.Row
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, iter.Close()
+		}
+
+		if err != nil {
+			return nil, errors.Join(err, iter.Close())
+		}
+
+		rows = append(rows, row)
+	}
 }
@@ -14,6 +14,7 @@ import (
 
 	ast "github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
 	plan "github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	transaction "github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -103,18 +104,18 @@ func (m *MockPlanner) EXPECT() *MockPlannerMockRecorder {
 }
 
 // Plan mocks base method.
-func (m *MockPlanner) Plan(database string, node ast.Node) (plan.Node, error) {
+func (m *MockPlanner) Plan(session *transaction.Session, database string, node ast.Node) (plan.Node, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Plan", database, node)
+	ret := m.ctrl.Call(m, "Plan", session, database, node)
 	ret0, _ := ret[0].(plan.Node)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Plan indicates an expected call of Plan.
-func (mr *MockPlannerMockRecorder) Plan(database, node any) *MockPlannerPlanCall {
+func (mr *MockPlannerMockRecorder) Plan(session, database, node any) *MockPlannerPlanCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockPlanner)(nil).Plan), database, node)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockPlanner)(nil).Plan), session, database, node)
 	return &MockPlannerPlanCall{Call: call}
 }
 
@@ -130,13 +131,13 @@ func (c *MockPlannerPlanCall) Return(arg0 plan.Node, arg1 error) *MockPlannerPla
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockPlannerPlanCall) Do(f func(string, ast.Node) (plan.Node, error)) *MockPlannerPlanCall {
+func (c *MockPlannerPlanCall) Do(f func(*transaction.Session, string, ast.Node) (plan.Node, error)) *MockPlannerPlanCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockPlannerPlanCall) DoAndReturn(f func(string, ast.Node) (plan.Node, error)) *MockPlannerPlanCall {
+func (c *MockPlannerPlanCall) DoAndReturn(f func(*transaction.Session, string, ast.Node) (plan.Node, error)) *MockPlannerPlanCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -1,7 +1,12 @@
 package engine_test
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,8 +16,13 @@ import (
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/engine"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/lexer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/parser"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/planner"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/memory"
 )
 
 func TestEngine_Query(t *testing.T) {
@@ -26,6 +36,7 @@ func TestEngine_Query(t *testing.T) {
 
 		input := "select true"
 		database := "playground"
+		session := transaction.NewSession(transaction.NewManager(sql.NewMockCatalog(ctrl)))
 		expected := []string{"true"}
 		astNode := &ast.SelectStatement{
 			Result: []ast.ResultStatement{
@@ -45,12 +56,12 @@ func TestEngine_Query(t *testing.T) {
 		planNode := plan.NewMockNode(ctrl)
 
 		parser.EXPECT().Parse(input).Return(astNode, nil)
-		planner.EXPECT().Plan(database, astNode).Return(planNode, nil)
+		planner.EXPECT().Plan(session, database, astNode).Return(planNode, nil)
 		planNode.EXPECT().RowIter().Return(rowIter, nil)
 		planNode.EXPECT().Columns().Return(expected)
 
 		ng := engine.New(parser, planner)
-		columns, iter, err := ng.Exec(database, input)
+		columns, iter, err := ng.Exec(session, database, input)
 		require.NoError(t, err)
 		require.NotNil(t, iter)
 		assert.Equal(t, expected, columns)
@@ -64,6 +75,7 @@ func TestEngine_Query(t *testing.T) {
 
 		input := "select true"
 		database := "playground"
+		session := transaction.NewSession(transaction.NewManager(sql.NewMockCatalog(ctrl)))
 		expected := []string{"true"}
 		astNode := &ast.SelectStatement{
 			Result: []ast.ResultStatement{
@@ -83,13 +95,13 @@ func TestEngine_Query(t *testing.T) {
 		planNode := plan.NewMockNode(ctrl)
 
 		parser.EXPECT().Parse(input).Return(astNode, nil)
-		planner.EXPECT().Plan(database, astNode).Return(planNode, nil)
+		planner.EXPECT().Plan(session, database, astNode).Return(planNode, nil)
 		planNode.EXPECT().RowIter().Return(rowIter, nil)
 		planNode.EXPECT().Columns().Return(expected)
 
 		parserFn := engine.ParseFn(parser.Parse)
 		ng := engine.New(parserFn, planner)
-		columns, iter, err := ng.Exec(database, input)
+		columns, iter, err := ng.Exec(session, database, input)
 		require.NoError(t, err)
 		require.NotNil(t, iter)
 		assert.Equal(t, expected, columns)
@@ -103,6 +115,7 @@ func TestEngine_Query(t *testing.T) {
 
 		input := "select true"
 		database := "playground"
+		session := transaction.NewSession(transaction.NewManager(sql.NewMockCatalog(ctrl)))
 		expectedErr := errors.New("something went wrong")
 
 		parser := NewMockParser(ctrl)
@@ -111,7 +124,7 @@ func TestEngine_Query(t *testing.T) {
 		parser.EXPECT().Parse(input).Return(nil, expectedErr)
 
 		ng := engine.New(parser, planner)
-		columns, iter, err := ng.Exec(database, input)
+		columns, iter, err := ng.Exec(session, database, input)
 		require.ErrorIs(t, err, expectedErr)
 		require.Nil(t, iter)
 		require.Nil(t, columns)
@@ -125,6 +138,7 @@ func TestEngine_Query(t *testing.T) {
 
 		input := "select true"
 		database := "playground"
+		session := transaction.NewSession(transaction.NewManager(sql.NewMockCatalog(ctrl)))
 		expectedErr := errors.New("something went wrong")
 		astNode := &ast.SelectStatement{
 			Result: []ast.ResultStatement{
@@ -142,10 +156,10 @@ func TestEngine_Query(t *testing.T) {
 		planner := NewMockPlanner(ctrl)
 
 		parser.EXPECT().Parse(input).Return(astNode, nil)
-		planner.EXPECT().Plan(database, astNode).Return(nil, expectedErr)
+		planner.EXPECT().Plan(session, database, astNode).Return(nil, expectedErr)
 
 		ng := engine.New(parser, planner)
-		columns, iter, err := ng.Exec(database, input)
+		columns, iter, err := ng.Exec(session, database, input)
 		require.ErrorIs(t, err, expectedErr)
 		require.Nil(t, iter)
 		require.Nil(t, columns)
@@ -159,6 +173,7 @@ func TestEngine_Query(t *testing.T) {
 
 		input := "select true"
 		database := "playground"
+		session := transaction.NewSession(transaction.NewManager(sql.NewMockCatalog(ctrl)))
 		expectedErr := errors.New("something went wrong")
 		astNode := &ast.SelectStatement{
 			Result: []ast.ResultStatement{
@@ -177,13 +192,1001 @@ func TestEngine_Query(t *testing.T) {
 		planNode := plan.NewMockNode(ctrl)
 
 		parser.EXPECT().Parse(input).Return(astNode, nil)
-		planner.EXPECT().Plan(database, astNode).Return(planNode, nil)
+		planner.EXPECT().Plan(session, database, astNode).Return(planNode, nil)
 		planNode.EXPECT().RowIter().Return(nil, expectedErr)
 
 		ng := engine.New(parser, planner)
-		columns, iter, err := ng.Exec(database, input)
+		columns, iter, err := ng.Exec(session, database, input)
 		require.ErrorIs(t, err, expectedErr)
 		require.Nil(t, iter)
 		require.Nil(t, columns)
 	})
 }
+
+func TestEngine_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 8
+		rounds  = 50
+	)
+
+	parse := engine.ParseFn(func(input string) (ast.Node, error) {
+		return parser.New(lexer.New(input)).Parse()
+	})
+
+	catalog := memory.NewCatalog()
+	_, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	manager := transaction.NewManager(catalog)
+	sqlEngine := engine.New(parse, planner.New(manager))
+
+	exec := func(session *transaction.Session, input string) error {
+		_, iter, err := sqlEngine.Exec(session, "playground", input)
+		if err != nil {
+			return err
+		}
+
+		for {
+			if _, err = iter.Next(); err != nil {
+				break
+			}
+		}
+
+		if !errors.Is(err, io.EOF) {
+			return errors.Join(err, iter.Close())
+		}
+
+		return iter.Close()
+	}
+
+	require.NoError(t, exec(
+		transaction.NewSession(manager),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+	))
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			session := transaction.NewSession(manager)
+			table := fmt.Sprintf("scratch%d", w%2)
+
+			for i := 0; i < rounds; i++ {
+				id := w*rounds + i
+
+				assert.NoError(t, exec(session, fmt.Sprintf("INSERT INTO users (id, name) VALUES (%d, 'bob')", id)))
+				assert.NoError(t, exec(session, fmt.Sprintf("UPDATE users SET name = 'alice' WHERE id = %d", id)))
+				assert.NoError(t, exec(session, "SELECT id, name FROM users WHERE name = 'alice'"))
+
+				// DDL races with DML of other sessions, so errors like "table already exist" are expected.
+				_ = exec(session, fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY)", table))
+				_ = exec(session, fmt.Sprintf("INSERT INTO %s (id) VALUES (%d)", table, id))
+				_ = exec(session, fmt.Sprintf("CREATE INDEX %s_name ON users (name)", table))
+				_ = exec(session, fmt.Sprintf("DROP INDEX %s_name", table))
+				_ = exec(session, fmt.Sprintf("DROP TABLE %s", table))
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	db, err := catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	users, err := db.GetTable("users")
+	require.NoError(t, err)
+
+	iter, err := users.Scan()
+	require.NoError(t, err)
+
+	count := 0
+
+	for {
+		if _, err = iter.Next(); err != nil {
+			break
+		}
+
+		count++
+	}
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, workers*rounds, count)
+}
+
+func TestEngine_Sequences(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	_, err := query("SELECT currval('orders_seq')")
+	require.Error(t, err, "sequence doesn't exist")
+
+	values(t, "CREATE SEQUENCE orders_seq START 100 INCREMENT 10")
+
+	_, err = query("CREATE SEQUENCE orders_seq")
+	require.Error(t, err)
+
+	_, err = query("SELECT currval('orders_seq')")
+	require.Error(t, err, "currval is not defined before nextval")
+
+	assert.Equal(t, [][]any{{int64(100), int64(100)}}, values(t, "SELECT nextval('orders_seq'), currval('orders_seq')"))
+	assert.Equal(t, [][]any{{int64(500)}}, values(t, "SELECT setval('orders_seq', 500)"))
+	assert.Equal(t, [][]any{{int64(510)}}, values(t, "SELECT nextval('orders_seq')"))
+
+	values(t, "CREATE TABLE orders ("+
+		"id INTEGER PRIMARY KEY, "+
+		"number INTEGER DEFAULT nextval('orders_seq'), "+
+		"code INTEGER GENERATED ALWAYS AS IDENTITY (START WITH 10 INCREMENT BY -1), "+
+		"note TEXT NULL)")
+
+	values(t, "INSERT INTO orders (note) VALUES ('first')")
+	values(t, "INSERT INTO orders (number, note) VALUES (1, 'explicit')")
+	values(t, "INSERT INTO orders (note) VALUES ('third')")
+
+	assert.Equal(t, [][]any{
+		{int64(1), int64(520), int64(10), "first"},
+		{int64(2), int64(1), int64(9), "explicit"},
+		{int64(3), int64(530), int64(8), "third"},
+	}, values(t, "SELECT id, number, code, note FROM orders ORDER BY id"))
+
+	_, err = query("INSERT INTO orders (code) VALUES (1)")
+	require.Error(t, err, "identity column can't be set")
+
+	_, err = query("UPDATE orders SET code = 1")
+	require.Error(t, err, "identity column can't be updated")
+
+	values(t, "UPDATE orders SET number = nextval('orders_seq') WHERE id = 2")
+	assert.Equal(t, [][]any{{int64(540)}}, values(t, "SELECT number FROM orders WHERE id = 2"))
+
+	_, err = query("DROP SEQUENCE orders_seq")
+	require.Error(t, err, "sequence is used by a column")
+
+	_, err = query("DROP SEQUENCE orders_code_seq")
+	require.Error(t, err, "sequence is owned by an identity column")
+
+	_, err = query("CREATE TABLE broken (id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY)")
+	require.Error(t, err, "primary key can't be an identity column")
+
+	_, err = query("CREATE TABLE broken (id INTEGER PRIMARY KEY, n INTEGER DEFAULT currval('orders_seq'))")
+	require.Error(t, err, "only nextval is allowed in defaults")
+
+	values(t, "DROP TABLE orders")
+	values(t, "DROP SEQUENCE orders_seq")
+
+	_, err = query("SELECT nextval('orders_code_seq')")
+	require.Error(t, err, "identity sequence is dropped with the table")
+	assert.NotContains(t, err.Error(), "grouping", "the statement isn't grouped")
+
+	require.NoError(t, s.session.Begin())
+
+	_, err = query("CREATE SEQUENCE tx_seq")
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+
+	require.NoError(t, s.session.Rollback())
+}
+
+func TestEngine_InsertKeys(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE SEQUENCE codes")
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, code INTEGER DEFAULT nextval('codes'))")
+	values(t, "CREATE UNIQUE INDEX users_name ON users (name)")
+
+	values(t, "INSERT INTO users (name) VALUES ('bob')")
+
+	_, err := query("INSERT INTO users (name, missing) VALUES ('alice', 1)")
+	require.Error(t, err, "unknown column")
+
+	_, err = query("INSERT INTO users (name) VALUES (1)")
+	require.Error(t, err, "invalid value")
+
+	_, err = query("INSERT INTO users (name, code) VALUES ('alice', nextval('codes') + 'x')")
+	require.Error(t, err, "invalid expression")
+
+	_, err = query("INSERT INTO users (name) VALUES ('bob')")
+	require.Error(t, err, "duplicate name")
+
+	values(t, "INSERT INTO users (name) VALUES ('alice')")
+
+	values(t, "INSERT INTO users (id, name) VALUES (10, 'tom')")
+	values(t, "INSERT INTO users (name) VALUES ('max')")
+
+	_, err = query("INSERT INTO users (id, name) VALUES (11, 'max')")
+	require.Error(t, err, "duplicate key")
+
+	require.NoError(t, s.session.Begin())
+	values(t, "INSERT INTO users (name) VALUES ('kate')")
+	values(t, "INSERT INTO users (id, name) VALUES (13, 'john')")
+	values(t, "INSERT INTO users (name) VALUES ('ann')")
+	require.NoError(t, s.session.Rollback())
+
+	values(t, "INSERT INTO users (name) VALUES ('kate')")
+
+	// Invalid statements consume neither keys nor sequence values. A row that fails to be stored gives its key back,
+	// but not the values of named sequences, as does a failure after nextval is called. Rolled back keys leave a gap.
+	assert.Equal(t, [][]any{
+		{int64(1), "bob", int64(1)},
+		{int64(2), "alice", int64(4)},
+		{int64(10), "tom", int64(5)},
+		{int64(11), "max", int64(6)},
+		{int64(15), "kate", int64(11)},
+	}, values(t, "SELECT id, name, code FROM users ORDER BY id"))
+}
+
+func TestEngine_OmittedColumn(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE t (id INTEGER PRIMARY KEY, n INTEGER NULL)")
+	values(t, "INSERT INTO t (id) VALUES (1)")
+	values(t, "INSERT INTO t (id, n) VALUES (2, 5)")
+
+	// A column left out of INSERT is NULL, the shell shows rows the same way.
+	rows, err := query("SELECT * FROM t")
+	require.NoError(t, err)
+
+	shown := make([][]string, 0, len(rows))
+
+	for _, row := range rows {
+		shown = append(shown, []string{row[0].String(), row[1].String()})
+	}
+
+	assert.Equal(t, [][]string{{"1", "null"}, {"2", "5"}}, shown)
+
+	assert.Equal(t, [][]any{{int64(2)}}, values(t, "SELECT id FROM t WHERE n = 5"))
+	assert.Equal(t, [][]any{{nil}, {int64(6)}}, values(t, "SELECT n + 1 FROM t ORDER BY n"))
+	assert.Equal(t, [][]any{{int64(2), int64(1), int64(5), int64(5)}},
+		values(t, "SELECT count(*), count(n), sum(n), min(n) FROM t"))
+	assert.Len(t, values(t, "SELECT n, count(*) FROM t GROUP BY n"), 2)
+	assert.Len(t, values(t, "SELECT DISTINCT n FROM t"), 2)
+	assert.Equal(t, [][]any{{int64(2)}}, values(t, "SELECT a.id FROM t a JOIN t b ON a.n = b.n"))
+}
+
+func TestEngine_IndexedNullColumn(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	values := s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, city TEXT NULL)")
+	values(t, "INSERT INTO users (id, name) VALUES (1, 'bob')")
+
+	// Rows inserted without the indexed column are indexed as NULL.
+	values(t, "CREATE UNIQUE INDEX users_city ON users (city)")
+	values(t, "INSERT INTO users (id, name) VALUES (2, 'alice')")
+	values(t, "INSERT INTO users (id, name, city) VALUES (3, 'tom', 'paris')")
+
+	assert.Equal(t, [][]any{{"tom"}}, values(t, "SELECT name FROM users WHERE city = 'paris'"))
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}, {int64(3)}}, values(t, "SELECT id FROM users ORDER BY id"))
+
+	values(t, "UPDATE users SET city = 'rome' WHERE id = 2")
+	assert.Equal(t, [][]any{{"alice"}}, values(t, "SELECT name FROM users WHERE city = 'rome'"))
+
+	assert.Equal(t, [][]any{
+		{int64(1), "bob", nil},
+		{int64(2), "alice", "rome"},
+		{int64(3), "tom", "paris"},
+	}, values(t, "SELECT * FROM users"))
+}
+
+func TestEngine_UpdateKey(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	values(t, "CREATE INDEX users_name ON users (name)")
+	values(t, "INSERT INTO users (id, name) VALUES (1, 'bob')")
+	values(t, "INSERT INTO users (id, name) VALUES (2, 'alice')")
+
+	// The row is moved to the new key.
+	values(t, "UPDATE users SET id = 7 WHERE id = 1")
+	assert.Empty(t, values(t, "SELECT name FROM users WHERE id = 1"))
+	assert.Equal(t, [][]any{{"bob"}}, values(t, "SELECT name FROM users WHERE id = 7"))
+	assert.Equal(t, [][]any{{int64(7)}}, values(t, "SELECT id FROM users WHERE name = 'bob'"))
+
+	// A row may take the key another row gives up.
+	values(t, "UPDATE users SET id = id + 5")
+	assert.Equal(t, [][]any{{int64(7), "alice"}, {int64(12), "bob"}}, values(t, "SELECT id, name FROM users"))
+
+	_, err := query("UPDATE users SET id = 12 WHERE id = 7")
+	require.ErrorContains(t, err, "duplicate primary key: 12")
+
+	_, err = query("UPDATE users SET id = 1")
+	require.ErrorContains(t, err, "duplicate primary key: 1")
+
+	assert.Equal(t, [][]any{{int64(7), "alice"}, {int64(12), "bob"}}, values(t, "SELECT id, name FROM users"))
+
+	// The key sequence is moved past the new keys.
+	values(t, "INSERT INTO users (name) VALUES ('tom')")
+	assert.Equal(t, [][]any{{int64(13)}}, values(t, "SELECT id FROM users WHERE name = 'tom'"))
+}
+
+func TestEngine_StatementAtomicity(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)")
+	values(t, "CREATE UNIQUE INDEX users_email ON users (email)")
+	values(t, "INSERT INTO users (id, email) VALUES (1, 'a')")
+	values(t, "INSERT INTO users (id, email) VALUES (2, 'b')")
+	values(t, "INSERT INTO users (id, email) VALUES (3, 'c')")
+
+	// The second row breaks the unique index, the statement doesn't change the first one either.
+	_, err := query("UPDATE users SET email = 'x'")
+	require.Error(t, err)
+	assert.Nil(t, s.session.Tx())
+	assert.Equal(t, [][]any{{"a"}, {"b"}, {"c"}}, values(t, "SELECT email FROM users ORDER BY id"))
+
+	values(t, "DELETE FROM users WHERE id > 1")
+	assert.Nil(t, s.session.Tx())
+	assert.Equal(t, [][]any{{int64(1)}}, values(t, "SELECT id FROM users"))
+
+	// Inside an open transaction the statement is a part of it.
+	values(t, "BEGIN")
+	values(t, "UPDATE users SET email = 'z'")
+	values(t, "ROLLBACK")
+	assert.Equal(t, [][]any{{"a"}}, values(t, "SELECT email FROM users"))
+}
+
+func TestEngine_QuerySnapshot(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	other := s.fork()
+
+	s.values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, n INTEGER)")
+
+	for id := 1; id <= 200; id++ {
+		s.values(t, fmt.Sprintf("INSERT INTO users (id, n) VALUES (%d, 0)", id))
+	}
+
+	_, iter, err := s.engine.Exec(s.session, "playground", "SELECT n FROM users")
+	require.NoError(t, err)
+
+	row, err := iter.Next()
+	require.NoError(t, err)
+
+	// Changes committed while the query runs are not visible to it.
+	other.values(t, "UPDATE users SET n = 1")
+	other.values(t, "DELETE FROM users WHERE id = 200")
+
+	rows, err := drain(iter)
+	require.NoError(t, err)
+	assert.Nil(t, s.session.Tx())
+
+	rows = append(rows, row)
+	require.Len(t, rows, 200)
+
+	for _, row := range rows {
+		assert.Equal(t, int64(0), row[0].Raw())
+	}
+
+	assert.Equal(t, [][]any{{int64(199), int64(199)}}, s.values(t, "SELECT count(*), sum(n) FROM users"))
+}
+
+func TestEngine_ConflictRetry(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	other := s.fork()
+
+	s.values(t, "CREATE TABLE counters (id INTEGER PRIMARY KEY, n INTEGER)")
+	s.values(t, "INSERT INTO counters (id, n) VALUES (1, 0)")
+
+	_, iter, err := s.engine.Exec(s.session, "playground", "UPDATE counters SET n = n + 1")
+	require.NoError(t, err)
+
+	// The row is changed after the statement has taken its snapshot, so the statement is run again.
+	other.values(t, "UPDATE counters SET n = n + 1")
+
+	_, err = drain(iter)
+	require.NoError(t, err)
+	assert.Nil(t, s.session.Tx())
+	assert.Equal(t, [][]any{{int64(2)}}, s.values(t, "SELECT n FROM counters"))
+}
+
+func TestEngine_Analyze(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, city TEXT NULL)")
+	values(t, "CREATE TABLE orders (id INTEGER PRIMARY KEY, amount FLOAT)")
+	values(t, "INSERT INTO users (id, city) VALUES (1, 'Paris')")
+	values(t, "INSERT INTO users (id, city) VALUES (2, 'Berlin')")
+	values(t, "INSERT INTO users (id, city) VALUES (3, NULL)")
+	values(t, "INSERT INTO users (id, city) VALUES (4, 'Paris')")
+
+	_, err := query("ANALYZE missing")
+	require.Error(t, err)
+
+	values(t, "ANALYZE users")
+
+	database, err := s.catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	stats, ok := database.Statistics("users")
+	require.True(t, ok)
+	assert.Equal(t, int64(4), stats.Rows)
+	assert.InDelta(t, 0.25, stats.Columns["city"].NullFraction, 1e-9)
+	assert.Equal(t, int64(2), stats.Columns["city"].Distinct)
+
+	_, ok = database.Statistics("orders")
+	require.False(t, ok)
+
+	values(t, "INSERT INTO orders (id, amount) VALUES (1, 9.5)")
+	values(t, "ANALYZE")
+
+	stats, ok = database.Statistics("orders")
+	require.True(t, ok)
+	assert.Equal(t, int64(1), stats.Rows)
+}
+
+func TestEngine_Explain(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, city TEXT)")
+	values(t, "CREATE INDEX users_city ON users (city)")
+
+	for i := 1; i <= 20; i++ {
+		city := "Paris"
+		if i%10 == 0 {
+			city = "Berlin"
+		}
+
+		values(t, fmt.Sprintf("INSERT INTO users (id, city) VALUES (%d, '%s')", i, city))
+	}
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	// Without statistics an equality is assumed to be selective, so the index is read.
+	assert.Contains(t, explain("SELECT id FROM users WHERE city = 'Paris'"), "Index scan on users using users_city")
+
+	// Most of the rows are in Paris, reading the whole table is cheaper.
+	values(t, "ANALYZE users")
+	assert.Contains(t, explain("SELECT id FROM users WHERE city = 'Paris'"), "-> Scan on users")
+	assert.Len(t, values(t, "SELECT id FROM users WHERE city = 'Paris'"), 18)
+
+	// Rows are read in the order of the primary key, there is nothing to sort.
+	assert.NotContains(t, explain("SELECT id FROM users ORDER BY id"), "Sort")
+	assert.Equal(t, [][]any{{int64(10), "Berlin"}, {int64(20), "Berlin"}},
+		values(t, "SELECT id, city FROM users WHERE city = 'Berlin' ORDER BY id"))
+
+	_, err := query("EXPLAIN INSERT INTO users (id, city) VALUES (21, 'Rome')")
+	require.Error(t, err)
+}
+
+func TestEngine_ExplainAnalyze(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	values := s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+
+	for i := 1; i <= 5; i++ {
+		values(t, fmt.Sprintf("INSERT INTO users (id, name) VALUES (%d, 'user%d')", i, i))
+	}
+
+	explain := func(input string) []string {
+		var lines []string
+
+		for _, row := range values(t, input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return lines
+	}
+
+	lines := explain("EXPLAIN ANALYZE SELECT name FROM users WHERE id > 2")
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^Project: name  \(cost=.*\) \(actual time=.* rows=3 loops=1\)$`, lines[0])
+	assert.Regexp(t, `^-> Filter: \(id > 2\)  \(cost=.*\) \(actual time=.* rows=3 loops=1\)$`, lines[1])
+	assert.Regexp(t, `^   -> Range scan on users  \(cost=.*\) \(actual time=.* rows=3 loops=1\)$`, lines[2])
+	assert.Regexp(t, `^Execution time: `, lines[3])
+
+	// The plan is shown in JSON as well.
+	var document struct {
+		Plan struct {
+			Type  string `json:"Node Type"`
+			Plans []struct {
+				Description string `json:"Description"`
+			} `json:"Plans"`
+		} `json:"Plan"`
+	}
+
+	lines = explain("EXPLAIN FORMAT JSON UPDATE users SET name = 'bob' WHERE id = 1")
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(lines, "\n")), &document))
+	assert.Equal(t, "Update", document.Plan.Type)
+	require.Len(t, document.Plan.Plans, 1)
+	assert.Equal(t, "Filter: (id = 1)", document.Plan.Plans[0].Description)
+	assert.Equal(t, [][]any{{"user1"}}, values(t, "SELECT name FROM users WHERE id = 1"))
+
+	// EXPLAIN ANALYZE runs the statement.
+	lines = explain("EXPLAIN ANALYZE DELETE FROM users WHERE id <= 2")
+	assert.Regexp(t, `^Delete on users  \(cost=.*\) \(actual time=.* rows=0 loops=1\)$`, lines[0])
+	assert.Len(t, values(t, "SELECT id FROM users"), 3)
+}
+
+func TestEngine_Optimize(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	values := s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+
+	for i := 1; i <= 5; i++ {
+		values(t, fmt.Sprintf("INSERT INTO users (id, name, age) VALUES (%d, 'user%d', %d)", i, i, 20+i))
+	}
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	// The condition that is always true is dropped, the one that is never true reads nothing.
+	assert.NotContains(t, explain("SELECT id FROM users WHERE 1 = 1"), "Filter")
+	assert.Len(t, values(t, "SELECT id FROM users WHERE 1 = 1"), 5)
+	assert.NotContains(t, explain("SELECT id FROM users WHERE 1 = 2 AND age > 0"), "Scan")
+	assert.Empty(t, values(t, "SELECT id FROM users WHERE 1 = 2 AND age > 0"))
+
+	// Constant parts of conditions are evaluated once.
+	assert.Contains(t, explain("SELECT id FROM users WHERE age > 2 * 11"), "Filter: (age > 22)")
+	assert.Equal(t, [][]any{{int64(3)}, {int64(4)}, {int64(5)}},
+		values(t, "SELECT id FROM users WHERE age > 2 * 11 ORDER BY id"))
+
+	// Only the top rows of a sort are kept.
+	assert.Contains(t, explain("SELECT name FROM users ORDER BY age DESC LIMIT 2 OFFSET 1"), "Top 3 by age desc")
+	assert.Equal(t, [][]any{{"user4"}, {"user3"}},
+		values(t, "SELECT name FROM users ORDER BY age DESC LIMIT 2 OFFSET 1"))
+
+	// Folded projections keep their names.
+	columns, iter, err := s.engine.Exec(s.session, "playground", "SELECT 2 * 3, id FROM users")
+	require.NoError(t, err)
+	require.NoError(t, iter.Close())
+	assert.Equal(t, []string{"(2 * 3)", "id"}, columns)
+}
+
+func TestEngine_Join(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	values(t, "CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NULL, title TEXT)")
+	values(t, "CREATE TABLE profiles (id INTEGER PRIMARY KEY, bio TEXT)")
+
+	values(t, "INSERT INTO users (id, name) VALUES (1, 'ann')")
+	values(t, "INSERT INTO users (id, name) VALUES (2, 'bob')")
+	values(t, "INSERT INTO users (id, name) VALUES (3, 'eve')")
+	values(t, "INSERT INTO posts (id, user_id, title) VALUES (10, 1, 'hello')")
+	values(t, "INSERT INTO posts (id, user_id, title) VALUES (11, 1, 'again')")
+	values(t, "INSERT INTO posts (id, user_id, title) VALUES (12, 2, 'bye')")
+	values(t, "INSERT INTO posts (id, user_id, title) VALUES (13, NULL, 'lost')")
+	values(t, "INSERT INTO profiles (id, bio) VALUES (1, 'first')")
+	values(t, "INSERT INTO profiles (id, bio) VALUES (3, 'third')")
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	inner := "SELECT u.name, p.title FROM users AS u JOIN posts p ON u.id = p.user_id ORDER BY p.id"
+	assert.Equal(t, [][]any{{"ann", "hello"}, {"ann", "again"}, {"bob", "bye"}}, values(t, inner))
+	assert.Contains(t, explain(inner), "Hash Join: (u.id = p.user_id)")
+
+	assert.ElementsMatch(t, [][]any{{"ann", "hello"}, {"ann", "again"}, {"bob", "bye"}, {"eve", nil}},
+		values(t, "SELECT users.name, title FROM users LEFT JOIN posts ON users.id = posts.user_id"))
+	assert.ElementsMatch(t, [][]any{{"ann", "hello"}, {"ann", "again"}, {"bob", "bye"}, {nil, "lost"}},
+		values(t, "SELECT name, title FROM users RIGHT OUTER JOIN posts ON users.id = posts.user_id"))
+	assert.ElementsMatch(t,
+		[][]any{{"ann", "hello"}, {"ann", "again"}, {"bob", "bye"}, {"eve", nil}, {nil, "lost"}},
+		values(t, "SELECT name, title FROM users FULL JOIN posts ON users.id = posts.user_id"))
+
+	// Rows of both tables are read in the order of their primary keys, so they are merged.
+	merged := "SELECT name, bio FROM users JOIN profiles ON users.id = profiles.id"
+	assert.Equal(t, [][]any{{"ann", "first"}, {"eve", "third"}}, values(t, merged))
+	assert.Contains(t, explain(merged), "Merge Join: (users.id = profiles.id)")
+
+	// Conditions on a single table are checked before the join.
+	filtered := "SELECT title FROM users, posts WHERE users.id = posts.user_id AND users.name = 'bob'"
+	assert.Equal(t, [][]any{{"bye"}}, values(t, filtered))
+	assert.Contains(t, explain(filtered), "Filter: (users.name = bob)")
+
+	// The smaller users table is put in the hash table, but columns keep the written order.
+	for id := 20; id < 60; id++ {
+		values(t, fmt.Sprintf("INSERT INTO posts (id, user_id, title) VALUES (%d, 3, 'more')", id))
+	}
+
+	values(t, "ANALYZE users")
+	values(t, "ANALYZE posts")
+
+	swapped := "SELECT * FROM users JOIN posts ON posts.user_id = users.id"
+	assert.Contains(t, explain(swapped), "-> Scan on posts  (cost=0.00..44.00 rows=44)\n      -> Scan on users")
+
+	rows := values(t, swapped)
+	assert.Len(t, rows, 43)
+	assert.Contains(t, rows, []any{int64(1), "ann", int64(10), int64(1), "hello"})
+	assert.Contains(t, rows, []any{int64(3), "eve", int64(59), int64(3), "more"})
+
+	assert.Len(t, values(t, "SELECT users.id, profiles.id FROM users CROSS JOIN profiles"), 6)
+	assert.Len(t, values(t, "SELECT * FROM users, profiles"), 6)
+	assert.Equal(t, [][]any{{"bob", "bye", "first"}},
+		values(t, "SELECT u.name, p.title, f.bio FROM users u JOIN posts p ON u.id = p.user_id "+
+			"JOIN profiles f ON p.id = f.id + 11"))
+
+	_, err := query("SELECT id FROM users JOIN posts ON users.id = posts.user_id")
+	require.ErrorContains(t, err, "ambiguous")
+	assert.NotContains(t, err.Error(), "grouping", "the statement isn't grouped")
+
+	_, err = query("SELECT u.id FROM users JOIN users u ON users.id = u.id")
+	require.NoError(t, err)
+
+	_, err = query("SELECT id FROM users JOIN users ON users.id = users.id")
+	require.Error(t, err)
+
+	_, err = query("SELECT missing.id FROM users")
+	require.Error(t, err)
+}
+
+func TestEngine_Aggregate(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT NULL, amount INTEGER NULL, price FLOAT)")
+	values(t, "INSERT INTO orders (id, customer, amount, price) VALUES (1, 'ann', 10, 1.5)")
+	values(t, "INSERT INTO orders (id, customer, amount, price) VALUES (2, 'ann', 20, 2.5)")
+	values(t, "INSERT INTO orders (id, customer, amount, price) VALUES (3, 'bob', 10, 3.0)")
+	values(t, "INSERT INTO orders (id, customer, amount, price) VALUES (4, NULL, NULL, 4.0)")
+	values(t, "INSERT INTO orders (id, customer, amount, price) VALUES (5, NULL, 30, 5.0)")
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	assert.Equal(t, [][]any{{int64(5), int64(4), int64(3), int64(70), 17.5, int64(10), int64(30)}},
+		values(t, "SELECT COUNT(*), COUNT(amount), COUNT(DISTINCT amount), SUM(amount), AVG(amount), "+
+			"MIN(amount), MAX(amount) FROM orders"))
+
+	// Aggregates of no rows: COUNT is 0, the others are NULL.
+	assert.Equal(t, [][]any{{int64(0), nil, nil, nil}},
+		values(t, "SELECT count(*), sum(amount), avg(price), max(customer) FROM orders WHERE id > 10"))
+	assert.Equal(t, [][]any{{int64(0), nil}},
+		values(t, "SELECT count(amount), sum(amount) FROM orders WHERE id = 4"))
+
+	// NULL values of a group expression form a single group.
+	grouped := "SELECT customer, count(*) AS orders, sum(amount) FROM orders GROUP BY customer ORDER BY customer"
+	assert.Equal(t, [][]any{{nil, int64(2), int64(30)}, {"ann", int64(2), int64(30)}, {"bob", int64(1), int64(10)}},
+		values(t, grouped))
+	assert.Contains(t, explain(grouped), "Hash Aggregate by customer: count(*), sum(amount)")
+
+	assert.Equal(t, [][]any{{"ann", 4.0}},
+		values(t, "SELECT orders.customer, sum(price) FROM orders GROUP BY customer HAVING count(*) > 1 "+
+			"AND min(id) < 3"))
+	assert.ElementsMatch(t, [][]any{{int64(10), int64(2)}, {int64(20), int64(1)}, {int64(30), int64(1)}},
+		values(t, "SELECT amount / 10 * 10, count(*) FROM orders WHERE amount > 0 GROUP BY amount / 10"))
+	assert.Equal(t, [][]any{{int64(5)}}, values(t, "SELECT count(*) FROM orders HAVING count(*) > 1"))
+	assert.Empty(t, values(t, "SELECT count(*) FROM orders HAVING count(*) > 5"))
+
+	// Rows read in the order of the primary key are aggregated one group after another.
+	sorted := "SELECT id, max(price) FROM orders GROUP BY id"
+	assert.Len(t, values(t, sorted), 5)
+	assert.Contains(t, explain(sorted), "Sort Aggregate by id: max(price)")
+
+	_, err := query("SELECT customer, count(*) FROM orders")
+	require.ErrorContains(t, err, `column "customer" must appear in the GROUP BY clause`)
+
+	_, err = query("SELECT id FROM orders GROUP BY customer")
+	require.ErrorContains(t, err, "must appear in the GROUP BY clause")
+
+	_, err = query("SELECT id FROM orders WHERE count(*) > 1")
+	require.ErrorContains(t, err, "aggregate functions are not allowed here")
+
+	_, err = query("SELECT count(*) FROM orders GROUP BY count(*)")
+	require.ErrorContains(t, err, "aggregate functions are not allowed here")
+
+	_, err = query("SELECT sum(customer) FROM orders")
+	require.ErrorContains(t, err, "sum of text values is not supported")
+
+	_, err = query("SELECT max(count(*)) FROM orders")
+	require.ErrorContains(t, err, "aggregate function calls cannot be nested")
+}
+
+func TestEngine_Distinct(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE aircrafts (id INTEGER PRIMARY KEY, model TEXT NULL, range INTEGER, weight FLOAT NULL)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (1, 'airbus', 3000, 1.5)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (2, 'boeing', 5000, 2.5)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (3, 'airbus', 3000, 3000.0)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (4, NULL, 6000, NULL)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (5, NULL, 6000, NULL)")
+	values(t, "INSERT INTO aircrafts (id, model, range, weight) VALUES (6, 'airbus', 4000, 4.0)")
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	// NULL values are the same.
+	distinct := "SELECT DISTINCT model, range FROM aircrafts ORDER BY range"
+	assert.Equal(t, [][]any{{"airbus", int64(3000)}, {"airbus", int64(4000)}, {"boeing", int64(5000)}, {nil, int64(6000)}},
+		values(t, distinct))
+	assert.Contains(t, explain(distinct), "Hash Distinct")
+
+	assert.Equal(t, [][]any{{nil}, {"airbus"}, {"boeing"}}, values(t, "SELECT DISTINCT model FROM aircrafts ORDER BY model"))
+
+	// An integer and a float that are equal as numbers are the same.
+	assert.Len(t, values(t, "SELECT DISTINCT range FROM aircrafts WHERE id < 3"), 2)
+	assert.Equal(t, [][]any{{int64(3000)}}, values(t, "SELECT DISTINCT range FROM aircrafts WHERE id = 1 OR id = 3"))
+
+	// The first row of each model in the order of ORDER BY is kept, ORDER BY sorts by one of the expressions.
+	on := "SELECT DISTINCT ON (model) model FROM aircrafts ORDER BY model DESC"
+	assert.Equal(t, [][]any{{"boeing"}, {"airbus"}, {nil}}, values(t, on))
+	assert.Contains(t, explain(on), "Hash Distinct on model")
+
+	assert.Equal(t, [][]any{{int64(3000)}, {int64(4000)}, {int64(5000)}, {int64(6000)}},
+		values(t, "SELECT DISTINCT ON (range / 1000 > 3, range) range FROM aircrafts ORDER BY range"))
+	assert.Equal(t, [][]any{{int64(3000)}, {int64(4000)}},
+		values(t, "SELECT DISTINCT ON (range) range FROM aircrafts ORDER BY range LIMIT 2"))
+
+	assert.Equal(t, [][]any{{nil, int64(2)}, {"airbus", int64(3)}, {"boeing", int64(1)}},
+		values(t, "SELECT DISTINCT model, count(*) FROM aircrafts GROUP BY model ORDER BY model"))
+	assert.Len(t, values(t, "SELECT DISTINCT * FROM aircrafts ORDER BY id"), 6)
+
+	_, err := query("SELECT DISTINCT ON (missing) id FROM aircrafts")
+	require.Error(t, err)
+
+	_, err = query("SELECT DISTINCT ON (count(*)) id FROM aircrafts")
+	require.ErrorContains(t, err, "must appear in the GROUP BY clause")
+
+	_, err = query("SELECT DISTINCT model FROM aircrafts ORDER BY id")
+	require.ErrorContains(t, err, "for SELECT DISTINCT, ORDER BY expressions must appear in select list")
+
+	_, err = query("SELECT DISTINCT ON (model) model, range FROM aircrafts ORDER BY range DESC")
+	require.ErrorContains(t, err, "SELECT DISTINCT ON expressions must match initial ORDER BY expressions")
+}
+
+func TestEngine_Subquery(t *testing.T) {
+	t.Parallel()
+
+	s := newTestSession(t)
+	query, values := s.query, s.values
+
+	values(t, "CREATE TABLE aircrafts (id INTEGER PRIMARY KEY, model TEXT, range INTEGER)")
+	values(t, "CREATE TABLE flights (id INTEGER PRIMARY KEY, aircraft_id INTEGER NULL, seats INTEGER)")
+	values(t, "INSERT INTO aircrafts (id, model, range) VALUES (1, 'airbus', 3000)")
+	values(t, "INSERT INTO aircrafts (id, model, range) VALUES (2, 'boeing', 5000)")
+	values(t, "INSERT INTO aircrafts (id, model, range) VALUES (3, 'cessna', 1000)")
+	values(t, "INSERT INTO flights (id, aircraft_id, seats) VALUES (10, 1, 100)")
+	values(t, "INSERT INTO flights (id, aircraft_id, seats) VALUES (11, 1, 120)")
+	values(t, "INSERT INTO flights (id, aircraft_id, seats) VALUES (12, 2, 300)")
+
+	explain := func(input string) string {
+		var lines []string
+
+		for _, row := range values(t, "EXPLAIN "+input) {
+			lines = append(lines, row[0].(string))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	// Scalar subqueries: no rows is NULL, columns of the outer query are parameters.
+	assert.Equal(t, [][]any{{int64(1), int64(5000)}},
+		values(t, "SELECT id, (SELECT max(range) FROM aircrafts) FROM aircrafts WHERE range = 3000"))
+	assert.Equal(t, [][]any{{"airbus", int64(220)}, {"boeing", int64(300)}, {"cessna", nil}},
+		values(t, "SELECT model, (SELECT sum(seats) FROM flights f WHERE f.aircraft_id = a.id) FROM aircrafts a "+
+			"ORDER BY id"))
+	assert.Equal(t, [][]any{{int64(2)}},
+		values(t, "SELECT id FROM aircrafts WHERE range > (SELECT avg(range) FROM aircrafts)"))
+
+	// EXISTS and IN of a subquery over a table are joins.
+	exists := "SELECT model FROM aircrafts a WHERE EXISTS (SELECT * FROM flights WHERE aircraft_id = a.id) ORDER BY id"
+	assert.Equal(t, [][]any{{"airbus"}, {"boeing"}}, values(t, exists))
+	assert.Contains(t, explain(exists), "Hash Semi Join")
+
+	notExists := "SELECT model FROM aircrafts a WHERE NOT EXISTS (SELECT 1 FROM flights f WHERE f.aircraft_id = a.id)"
+	assert.Equal(t, [][]any{{"cessna"}}, values(t, notExists))
+	assert.Contains(t, explain(notExists), "Hash Anti Join")
+
+	in := "SELECT model FROM aircrafts WHERE id IN (SELECT aircraft_id FROM flights WHERE seats > 110) ORDER BY id"
+	assert.Equal(t, [][]any{{"airbus"}, {"boeing"}}, values(t, in))
+	assert.Contains(t, explain(in), "Hash Semi Join")
+
+	// NOT IN of a subquery that returns NULL is never true.
+	assert.Equal(t, [][]any{{"cessna"}},
+		values(t, "SELECT model FROM aircrafts WHERE id NOT IN (SELECT aircraft_id FROM flights)"))
+	values(t, "INSERT INTO flights (id, aircraft_id, seats) VALUES (13, NULL, 50)")
+	assert.Empty(t, values(t, "SELECT model FROM aircrafts WHERE id NOT IN (SELECT aircraft_id FROM flights)"))
+	assert.Equal(t, [][]any{{true}, {true}, {nil}},
+		values(t, "SELECT id IN (SELECT aircraft_id FROM flights) FROM aircrafts ORDER BY id"))
+
+	// An uncorrelated EXISTS is read once.
+	assert.Len(t, values(t, "SELECT id FROM aircrafts WHERE EXISTS (SELECT 1 FROM flights WHERE seats > 200)"), 3)
+	assert.Empty(t, values(t, "SELECT id FROM aircrafts WHERE EXISTS (SELECT 1 FROM flights WHERE seats > 500)"))
+
+	// EXPLAIN shows plans of subqueries, a correlated one is read for every aircraft.
+	correlated := "SELECT model, (SELECT sum(seats) FROM flights f WHERE f.aircraft_id = a.id) FROM aircrafts a"
+	assert.Contains(t, explain(correlated), "-> SubPlan 1: (subquery)\n   -> Project: sum(seats)")
+	assert.Regexp(t, `\n   -> Project: sum\(seats\) .* rows=3 loops=3\)\n`, explain("ANALYZE "+correlated))
+	assert.Regexp(t, `\n   -> InitPlan 1: \(subquery\)\n      -> Project: avg\(range\) .* rows=1 loops=1\)\n`,
+		explain("ANALYZE SELECT id FROM aircrafts WHERE range > (SELECT avg(range) FROM aircrafts)"))
+
+	// Derived tables.
+	derived := "SELECT a.model, f.total FROM aircrafts a " +
+		"JOIN (SELECT aircraft_id, sum(seats) AS total FROM flights GROUP BY aircraft_id) AS f ON f.aircraft_id = a.id " +
+		"ORDER BY f.total"
+	assert.Equal(t, [][]any{{"airbus", int64(220)}, {"boeing", int64(300)}}, values(t, derived))
+	assert.Equal(t, [][]any{{"boeing"}},
+		values(t, "SELECT model FROM (SELECT model, range * 2 AS double FROM aircrafts) AS t WHERE double > 8000"))
+
+	// Subqueries in UPDATE and DELETE.
+	values(t, "UPDATE aircrafts SET range = (SELECT max(seats) FROM flights WHERE aircraft_id = aircrafts.id) "+
+		"WHERE id < 3")
+	assert.Equal(t, [][]any{{int64(120)}, {int64(300)}, {int64(1000)}},
+		values(t, "SELECT range FROM aircrafts ORDER BY id"))
+	values(t, "DELETE FROM flights WHERE aircraft_id IN (SELECT id FROM aircrafts WHERE model = 'airbus')")
+	assert.Len(t, values(t, "SELECT id FROM flights"), 2)
+
+	_, err := query("SELECT (SELECT id FROM aircrafts) FROM aircrafts")
+	require.ErrorContains(t, err, "more than one row returned by a subquery used as an expression")
+
+	_, err = query("SELECT (SELECT id, model FROM aircrafts) FROM aircrafts")
+	require.ErrorContains(t, err, "subquery must return only one column")
+
+	_, err = query("SELECT id FROM aircrafts WHERE id IN (SELECT id, model FROM aircrafts)")
+	require.ErrorContains(t, err, "subquery has too many columns")
+
+	_, err = query("SELECT * FROM (SELECT id, id FROM aircrafts) AS t")
+	require.ErrorContains(t, err, `column "id" specified more than once`)
+
+	_, err = query("SELECT * FROM (SELECT id FROM aircrafts)")
+	require.ErrorContains(t, err, "subquery in FROM must have an alias")
+}
+
+// testSession runs statements in a session of a memory catalog with the "playground" database.
+type testSession struct {
+	engine  *engine.Engine
+	manager *transaction.Manager
+	session *transaction.Session
+	catalog sql.Catalog
+}
+
+func newTestSession(t *testing.T) *testSession {
+	t.Helper()
+
+	parse := engine.ParseFn(func(input string) (ast.Node, error) {
+		return parser.New(lexer.New(input)).Parse()
+	})
+
+	catalog := memory.NewCatalog()
+	_, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	manager := transaction.NewManager(catalog)
+
+	return &testSession{
+		engine:  engine.New(parse, planner.New(manager)),
+		manager: manager,
+		session: transaction.NewSession(manager),
+		catalog: catalog,
+	}
+}
+
+// fork returns another session of the same catalog.
+func (s *testSession) fork() *testSession {
+	return &testSession{
+		engine:  s.engine,
+		manager: s.manager,
+		session: transaction.NewSession(s.manager),
+		catalog: s.catalog,
+	}
+}
+
+func (s *testSession) query(input string) ([]sql.Row, error) {
+	_, iter, err := s.engine.Exec(s.session, "playground", input)
+	if err != nil {
+		return nil, err
+	}
+
+	return drain(iter)
+}
+
+// drain reads all rows of the iterator and closes it.
+func drain(iter sql.RowIter) ([]sql.Row, error) {
+	var rows []sql.Row
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Join(err, iter.Close())
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, iter.Close()
+}
+
+// values runs the statement and returns raw values of its rows.
+func (s *testSession) values(t *testing.T, input string) [][]any {
+	t.Helper()
+
+	rows, err := s.query(input)
+	require.NoError(t, err)
+
+	raw := make([][]any, 0, len(rows))
+
+	for _, row := range rows {
+		values := make([]any, 0, len(row))
+
+		for _, value := range row {
+			values = append(values, value.Raw())
+		}
+
+		raw = append(raw, values)
+	}
+
+	return raw
+}
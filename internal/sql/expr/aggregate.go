@@ -0,0 +1,100 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+)
+
+// Names of aggregate functions.
+const (
+	CountFunc = "count"
+	SumFunc   = "sum"
+	AvgFunc   = "avg"
+	MinFunc   = "min"
+	MaxFunc   = "max"
+)
+
+// Aggregate is a call of an aggregate function. It's computed over a group of rows by the aggregate node
+// of the plan, so it can't be evaluated for a single row. Arg is nil for count(*), distinct values of the argument
+// are aggregated once if Distinct is set.
+type Aggregate struct {
+	Func     string
+	Arg      Node
+	Distinct bool
+}
+
+func (e *Aggregate) String() string {
+	switch {
+	case e.Arg == nil:
+		return e.Func + "(*)"
+	case e.Distinct:
+		return fmt.Sprintf("%s(DISTINCT %s)", e.Func, e.Arg.String())
+	default:
+		return fmt.Sprintf("%s(%s)", e.Func, e.Arg.String())
+	}
+}
+
+func (e *Aggregate) Eval(_ sql.Row) (sql.Value, error) {
+	return nil, fmt.Errorf("aggregate function %s is not allowed here", e.Func)
+}
+
+// IsAggregateFunc reports whether the function of the name is an aggregate one.
+func IsAggregateFunc(name string) bool {
+	switch strings.ToLower(name) {
+	case CountFunc, SumFunc, AvgFunc, MinFunc, MaxFunc:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasAggregate reports whether the expression calls aggregate functions.
+func HasAggregate(node Node) bool {
+	found := false
+
+	Inspect(node, func(node Node) {
+		if _, ok := node.(*Aggregate); ok {
+			found = true
+		}
+	})
+
+	return found
+}
+
+func aggregateExpr(expr *ast.CallExpr, scope *Scope, sequences Sequences) (Node, error) {
+	name := strings.ToLower(expr.Name)
+
+	if len(expr.Args) != 1 {
+		return nil, fmt.Errorf("%s expects 1 argument, got %d", name, len(expr.Args))
+	}
+
+	aggregate := &Aggregate{
+		Func:     name,
+		Distinct: expr.Distinct,
+	}
+
+	if _, ok := expr.Args[0].(*ast.AsteriskExpr); ok {
+		if name != CountFunc || expr.Distinct {
+			return nil, fmt.Errorf("%s(*) is not allowed", name)
+		}
+
+		return aggregate, nil
+	}
+
+	arg, err := walk(expr.Args[0], scope, sequences)
+	if err != nil {
+		return nil, fmt.Errorf("walk argument of %s: %w", name, err)
+	}
+
+	if HasAggregate(arg) {
+		return nil, errors.New("aggregate function calls cannot be nested")
+	}
+
+	aggregate.Arg = arg
+
+	return aggregate, nil
+}
@@ -0,0 +1,97 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+)
+
+func TestNewWithAggregates(t *testing.T) {
+	t.Parallel()
+
+	scope := expr.NewScope("aircrafts", sql.Scheme{
+		"model": sql.Column{Position: 0, Name: "model", DataType: sql.Text},
+		"range": sql.Column{Position: 1, Name: "range", DataType: sql.Integer},
+	})
+
+	call := func(name string, distinct bool, args ...ast.Expression) *ast.CallExpr {
+		return &ast.CallExpr{Name: name, Args: args, Distinct: distinct}
+	}
+
+	rng := &ast.IdentExpr{Name: "range"}
+
+	t.Run("returns aggregates", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			call     *ast.CallExpr
+			expected *expr.Aggregate
+			str      string
+		}{
+			{
+				call:     call("COUNT", false, &ast.AsteriskExpr{}),
+				expected: &expr.Aggregate{Func: expr.CountFunc},
+				str:      "count(*)",
+			},
+			{
+				call:     call("count", true, rng),
+				expected: &expr.Aggregate{Func: expr.CountFunc, Arg: expr.Column{Name: "range", Position: 1}, Distinct: true},
+				str:      "count(DISTINCT range)",
+			},
+			{
+				call:     call("Sum", false, rng),
+				expected: &expr.Aggregate{Func: expr.SumFunc, Arg: expr.Column{Name: "range", Position: 1}},
+				str:      "sum(range)",
+			},
+		}
+
+		for _, test := range tests {
+			node, err := expr.NewWithAggregates(test.call, scope, nil)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, node)
+			assert.Equal(t, test.str, node.String())
+			assert.True(t, expr.HasAggregate(node))
+
+			_, err = node.Eval(sql.Row{})
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		calls := []*ast.CallExpr{
+			call("sum", false),
+			call("max", false, rng, rng),
+			call("sum", false, &ast.AsteriskExpr{}),
+			call("count", true, &ast.AsteriskExpr{}),
+			call("min", false, call("max", false, rng)),
+			call("avg", false, &ast.IdentExpr{Name: "missing"}),
+			call("random", true),
+		}
+
+		for _, c := range calls {
+			_, err := expr.NewWithAggregates(c, scope, nil)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("are not allowed in other expressions", func(t *testing.T) {
+		t.Parallel()
+
+		cond := &ast.BinaryExpr{
+			Left:     call("max", false, rng),
+			Operator: token.GreaterThan,
+			Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "10"},
+		}
+
+		_, err := expr.NewInScope(cond, scope, nil)
+		require.ErrorContains(t, err, "aggregate functions are not allowed here")
+	})
+}
@@ -7,12 +7,18 @@ import (
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 )
 
+// Column is the value of a column of the row. Table is the name the column is qualified by, empty if it isn't.
 type Column struct {
+	Table    string
 	Name     string
 	Position uint8
 }
 
 func (c Column) String() string {
+	if c.Table != "" {
+		return c.Table + "." + c.Name
+	}
+
 	return c.Name
 }
 
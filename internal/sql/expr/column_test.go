@@ -21,6 +21,9 @@ func TestColumn_String(t *testing.T) {
 	}
 
 	assert.Equal(t, name, column.String())
+
+	column.Table = "users"
+	assert.Equal(t, "users.id", column.String())
 }
 
 func TestColumn_Eval(t *testing.T) {
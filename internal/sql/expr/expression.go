@@ -17,36 +17,90 @@ type Node interface {
 	Eval(row sql.Row) (sql.Value, error)
 }
 
+// New returns the expression that can't call sequence functions.
 func New(node ast.Expression, scheme sql.Scheme) (Node, error) {
-	return walk(node, scheme)
+	return build(node, tableScope(scheme), nil)
 }
 
-func walk(node ast.Expression, scheme sql.Scheme) (Node, error) {
+// NewWithSequences returns the expression whose sequence functions take sequences from the source.
+func NewWithSequences(node ast.Expression, scheme sql.Scheme, sequences Sequences) (Node, error) {
+	return build(node, tableScope(scheme), sequences)
+}
+
+// NewInScope returns the expression whose columns are resolved in the scope, it may refer to columns of
+// several tables. Sequence functions take sequences from the source, nil if they can't be called.
+func NewInScope(node ast.Expression, scope *Scope, sequences Sequences) (Node, error) {
+	return build(node, scope, sequences)
+}
+
+// NewWithAggregates returns the expression in the scope that may call aggregate functions. Such an expression
+// is evaluated for groups of rows: aggregates are computed by the aggregate node of the plan.
+func NewWithAggregates(node ast.Expression, scope *Scope, sequences Sequences) (Node, error) {
+	return walk(node, scope, sequences)
+}
+
+// build returns the expression that is evaluated for a single row, so it can't call aggregate functions.
+func build(node ast.Expression, scope *Scope, sequences Sequences) (Node, error) {
+	expr, err := walk(node, scope, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	if HasAggregate(expr) {
+		return nil, errors.New("aggregate functions are not allowed here")
+	}
+
+	return expr, nil
+}
+
+// tableScope returns the scope of a table whose columns can't be qualified, nil if there is no scheme.
+func tableScope(scheme sql.Scheme) *Scope {
+	if scheme == nil {
+		return nil
+	}
+
+	return NewScope("", scheme)
+}
+
+func walk(node ast.Expression, scope *Scope, sequences Sequences) (Node, error) {
 	switch expr := node.(type) {
 	case *ast.IdentExpr:
-		return columnExpr(expr, scheme)
+		return columnExpr(expr, scope)
 	case *ast.BinaryExpr:
-		return binaryExpr(expr, scheme)
+		return binaryExpr(expr, scope, sequences)
 	case *ast.UnaryExpr:
-		return unaryExpr(expr, scheme)
+		return unaryExpr(expr, scope, sequences)
 	case *ast.ScalarExpr:
 		return scalarExpr(expr)
+	case *ast.CallExpr:
+		return callExpr(expr, scope, sequences)
+	case *ast.SubqueryExpr:
+		return subqueryExpr(expr, scope, sequences)
+	case *ast.ExistsExpr:
+		return existsExpr(expr, scope, sequences)
+	case *ast.InExpr:
+		return inExpr(expr, scope, sequences)
 	default:
 		return nil, fmt.Errorf("unknown expression: %v", expr)
 	}
 }
 
-func columnExpr(expr *ast.IdentExpr, scheme sql.Scheme) (Node, error) {
-	if scheme == nil {
+func columnExpr(expr *ast.IdentExpr, scope *Scope) (Node, error) {
+	if scope == nil {
 		return nil, errors.New("schema not provided")
 	}
 
-	definition, ok := scheme[expr.Name]
-	if !ok {
-		return nil, fmt.Errorf("column %q not exists", expr.Name)
+	if scope.params != nil && scope.params.outer != nil && !scope.resolves(expr.Table, expr.Name) {
+		return scope.params.param(expr)
+	}
+
+	definition, err := scope.Column(expr.Table, expr.Name)
+	if err != nil {
+		return nil, err
 	}
 
 	column := Column{
+		Table:    expr.Table,
 		Name:     definition.Name,
 		Position: definition.Position,
 	}
@@ -54,13 +108,13 @@ func columnExpr(expr *ast.IdentExpr, scheme sql.Scheme) (Node, error) {
 	return column, nil
 }
 
-func binaryExpr(expr *ast.BinaryExpr, scheme sql.Scheme) (Node, error) {
-	left, err := walk(expr.Left, scheme)
+func binaryExpr(expr *ast.BinaryExpr, scope *Scope, sequences Sequences) (Node, error) {
+	left, err := walk(expr.Left, scope, sequences)
 	if err != nil {
 		return nil, fmt.Errorf("walk left arg of binary expr: %w", err)
 	}
 
-	right, err := walk(expr.Right, scheme)
+	right, err := walk(expr.Right, scope, sequences)
 	if err != nil {
 		return nil, fmt.Errorf("walk right arg of binary expr: %w", err)
 	}
@@ -74,7 +128,7 @@ func binaryExpr(expr *ast.BinaryExpr, scheme sql.Scheme) (Node, error) {
 	return binary, nil
 }
 
-func unaryExpr(expr *ast.UnaryExpr, scheme sql.Scheme) (Node, error) {
+func unaryExpr(expr *ast.UnaryExpr, scope *Scope, sequences Sequences) (Node, error) {
 	var operator UnaryOp
 
 	switch expr.Operator {
@@ -86,7 +140,7 @@ func unaryExpr(expr *ast.UnaryExpr, scheme sql.Scheme) (Node, error) {
 		return nil, fmt.Errorf("unexpected unary operator: %s", expr.Operator)
 	}
 
-	operand, err := walk(expr.Right, scheme)
+	operand, err := walk(expr.Right, scope, sequences)
 	if err != nil {
 		return nil, fmt.Errorf("walk left arg of unary expr: %w", err)
 	}
@@ -0,0 +1,152 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// Scope is the set of columns of rows an expression is evaluated on: the columns of a table or of tables joined
+// together. Rows of joined tables are rows of the tables put one after another, so columns of a table follow
+// the columns of the tables before it.
+type Scope struct {
+	tables []scopeTable
+	width  int
+	params *Params // parameters of the subquery the scope is of, nil if it isn't correlated
+}
+
+type scopeTable struct {
+	name   string // the alias of the table or its name
+	scheme sql.Scheme
+	offset int // the position of the first column of the table in rows
+}
+
+// NewScope returns the scope of rows of the table, name is the name columns of the table are qualified by.
+func NewScope(name string, scheme sql.Scheme) *Scope {
+	return &Scope{
+		tables: []scopeTable{{name: name, scheme: scheme}},
+		width:  len(scheme),
+	}
+}
+
+// Join returns the scope of rows of this scope followed by rows of the other one.
+func (s *Scope) Join(other *Scope) (*Scope, error) {
+	if s.width+other.width > math.MaxUint8+1 {
+		return nil, errors.New("too many columns in joined tables")
+	}
+
+	joined := &Scope{
+		tables: make([]scopeTable, 0, len(s.tables)+len(other.tables)),
+		width:  s.width + other.width,
+		params: s.params,
+	}
+
+	joined.tables = append(joined.tables, s.tables...)
+
+	for _, table := range other.tables {
+		for _, existing := range s.tables {
+			if existing.name == table.name {
+				return nil, fmt.Errorf("table name %q specified more than once", table.name)
+			}
+		}
+
+		table.offset += s.width
+		joined.tables = append(joined.tables, table)
+	}
+
+	return joined, nil
+}
+
+// Correlated returns the scope of a subquery that may refer to columns of the outer query: names that refer
+// to no column of the scope are resolved in the outer scope of the parameters and become parameters.
+func (s *Scope) Correlated(params *Params) *Scope {
+	correlated := *s
+	correlated.params = params
+
+	return &correlated
+}
+
+// Width returns the number of columns of rows.
+func (s *Scope) Width() int {
+	return s.width
+}
+
+// Columns returns all columns of rows in their order, hidden ones included. Positions of columns are
+// their positions in rows.
+func (s *Scope) Columns() []sql.Column {
+	columns := make([]sql.Column, s.width)
+
+	for _, table := range s.tables {
+		for _, column := range table.scheme {
+			column.Position += uint8(table.offset)
+			columns[column.Position] = column
+		}
+	}
+
+	return columns
+}
+
+// Column returns the column the name refers to, its position is the position in rows. The table is empty
+// if the name isn't qualified, then the column must belong to a single table.
+func (s *Scope) Column(table, name string) (sql.Column, error) {
+	var (
+		found  sql.Column
+		tables int
+	)
+
+	for _, t := range s.tables {
+		if table != "" && t.name != table {
+			continue
+		}
+
+		column, ok := t.scheme[name]
+		if !ok {
+			continue
+		}
+
+		column.Position += uint8(t.offset)
+		found = column
+		tables++
+	}
+
+	switch {
+	case tables > 1:
+		return sql.Column{}, fmt.Errorf("column reference %q is ambiguous", name)
+	case tables == 1:
+		return found, nil
+	case table != "" && !s.hasTable(table):
+		return sql.Column{}, fmt.Errorf("missing FROM-clause entry for table %q", table)
+	case table != "":
+		return sql.Column{}, fmt.Errorf("column %q not exists", table+"."+name)
+	default:
+		return sql.Column{}, fmt.Errorf("column %q not exists", name)
+	}
+}
+
+// resolves reports whether the name refers to a column of the scope rather than to a column of the outer query.
+// A qualified name does if the scope has the table, even if the table has no such column.
+func (s *Scope) resolves(table, name string) bool {
+	if table != "" {
+		return s.hasTable(table)
+	}
+
+	for _, t := range s.tables {
+		if _, ok := t.scheme[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Scope) hasTable(name string) bool {
+	for _, table := range s.tables {
+		if table.name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+
+	users := expr.NewScope("users", sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+	})
+
+	posts := expr.NewScope("p", sql.Scheme{
+		"id":      sql.Column{Position: 0, Name: "id", DataType: sql.Integer},
+		"user_id": sql.Column{Position: 1, Name: "user_id", DataType: sql.Integer},
+		"rowid":   sql.Column{Position: 2, Name: "rowid", DataType: sql.Integer, Hidden: true},
+	})
+
+	joined, err := users.Join(posts)
+	require.NoError(t, err)
+	assert.Equal(t, 5, joined.Width())
+
+	t.Run("columns in the order of rows", func(t *testing.T) {
+		t.Parallel()
+
+		var names []string
+
+		for i, column := range joined.Columns() {
+			assert.Equal(t, uint8(i), column.Position)
+			names = append(names, column.Name)
+		}
+
+		assert.Equal(t, []string{"id", "name", "id", "user_id", "rowid"}, names)
+	})
+
+	t.Run("resolves columns", func(t *testing.T) {
+		t.Parallel()
+
+		column, err := joined.Column("", "user_id")
+		require.NoError(t, err)
+		assert.Equal(t, uint8(3), column.Position)
+
+		column, err = joined.Column("p", "id")
+		require.NoError(t, err)
+		assert.Equal(t, uint8(2), column.Position)
+
+		column, err = joined.Column("users", "id")
+		require.NoError(t, err)
+		assert.Equal(t, uint8(0), column.Position)
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := joined.Column("", "id")
+		require.ErrorContains(t, err, "ambiguous")
+
+		_, err = joined.Column("posts", "id")
+		require.ErrorContains(t, err, "missing FROM-clause entry")
+
+		_, err = joined.Column("users", "user_id")
+		require.ErrorContains(t, err, "not exists")
+
+		_, err = joined.Join(expr.NewScope("users", nil))
+		require.ErrorContains(t, err, "more than once")
+	})
+}
@@ -0,0 +1,148 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+)
+
+// Sequences resolves names of sequences passed to sequence functions, sql.Database is one.
+type Sequences interface {
+	GetSequence(name string) (sql.NamedSequence, error)
+}
+
+// Names of sequence functions.
+const (
+	NextvalFunc = "nextval"
+	CurrvalFunc = "currval"
+	SetvalFunc  = "setval"
+)
+
+// Nextval advances the sequence and returns its new value.
+type Nextval struct {
+	Sequence sql.NamedSequence
+}
+
+func (e *Nextval) String() string {
+	return fmt.Sprintf("%s('%s')", NextvalFunc, e.Sequence.Name())
+}
+
+func (e *Nextval) Eval(_ sql.Row) (sql.Value, error) {
+	value, err := e.Sequence.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewInteger(value), nil
+}
+
+// Currval returns the value last returned by nextval or set by setval for the sequence.
+type Currval struct {
+	Sequence sql.NamedSequence
+}
+
+func (e *Currval) String() string {
+	return fmt.Sprintf("%s('%s')", CurrvalFunc, e.Sequence.Name())
+}
+
+func (e *Currval) Eval(_ sql.Row) (sql.Value, error) {
+	value, ok := e.Sequence.Current()
+	if !ok {
+		return nil, fmt.Errorf("currval of sequence %q is not yet defined", e.Sequence.Name())
+	}
+
+	return datatype.NewInteger(value), nil
+}
+
+// Setval makes the value the current value of the sequence and returns it.
+type Setval struct {
+	Sequence sql.NamedSequence
+	Value    Node
+}
+
+func (e *Setval) String() string {
+	return fmt.Sprintf("%s('%s', %s)", SetvalFunc, e.Sequence.Name(), e.Value.String())
+}
+
+func (e *Setval) Eval(row sql.Row) (sql.Value, error) {
+	value, err := e.Value.Eval(row)
+	if err != nil {
+		return nil, fmt.Errorf("setval: eval value: %w", err)
+	}
+
+	v, ok := value.Raw().(int64)
+	if !ok {
+		return nil, fmt.Errorf("setval: expected %s value, got %s", sql.Integer, value.DataType())
+	}
+
+	if err = e.Sequence.SetValue(v); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func callExpr(expr *ast.CallExpr, scope *Scope, sequences Sequences) (Node, error) {
+	name := strings.ToLower(expr.Name)
+
+	if IsAggregateFunc(name) {
+		return aggregateExpr(expr, scope, sequences)
+	}
+
+	if expr.Distinct {
+		return nil, fmt.Errorf("DISTINCT specified, but %s is not an aggregate function", name)
+	}
+
+	arity := map[string]int{
+		NextvalFunc: 1,
+		CurrvalFunc: 1,
+		SetvalFunc:  2,
+	}
+
+	expected, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", expr.Name)
+	}
+
+	if len(expr.Args) != expected {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, expected, len(expr.Args))
+	}
+
+	if sequences == nil {
+		return nil, fmt.Errorf("%s is not allowed here", name)
+	}
+
+	sequence, err := sequenceArg(expr.Args[0], sequences)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	switch name {
+	case NextvalFunc:
+		return &Nextval{Sequence: sequence}, nil
+	case CurrvalFunc:
+		return &Currval{Sequence: sequence}, nil
+	default:
+		value, err := walk(expr.Args[1], scope, sequences)
+		if err != nil {
+			return nil, fmt.Errorf("walk value of setval: %w", err)
+		}
+
+		return &Setval{Sequence: sequence, Value: value}, nil
+	}
+}
+
+// sequenceArg resolves the sequence named by the argument, the name must be a text literal.
+func sequenceArg(arg ast.Expression, sequences Sequences) (sql.NamedSequence, error) {
+	scalar, ok := arg.(*ast.ScalarExpr)
+	if !ok || scalar.Type != token.Text {
+		return nil, errors.New("sequence name must be a text literal")
+	}
+
+	return sequences.GetSequence(scalar.Literal)
+}
@@ -0,0 +1,140 @@
+package expr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+)
+
+func TestNewWithSequences(t *testing.T) {
+	t.Parallel()
+
+	name := &ast.ScalarExpr{Type: token.Text, Literal: "orders_seq"}
+
+	t.Run("returns sequence functions", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		database.EXPECT().GetSequence("orders_seq").Return(sequence, nil).Times(3)
+
+		node, err := expr.NewWithSequences(&ast.CallExpr{Name: "nextval", Args: []ast.Expression{name}}, nil, database)
+		require.NoError(t, err)
+		assert.Equal(t, &expr.Nextval{Sequence: sequence}, node)
+
+		node, err = expr.NewWithSequences(&ast.CallExpr{Name: "CURRVAL", Args: []ast.Expression{name}}, nil, database)
+		require.NoError(t, err)
+		assert.Equal(t, &expr.Currval{Sequence: sequence}, node)
+
+		value := &ast.ScalarExpr{Type: token.Integer, Literal: "10"}
+		node, err = expr.NewWithSequences(&ast.CallExpr{Name: "setval", Args: []ast.Expression{name, value}}, nil, database)
+		require.NoError(t, err)
+
+		ten, err := expr.NewInteger("10")
+		require.NoError(t, err)
+		assert.Equal(t, &expr.Setval{Sequence: sequence, Value: ten}, node)
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		database := sql.NewMockDatabase(ctrl)
+		database.EXPECT().GetSequence("orders_seq").Return(nil, errors.New("sequence not found"))
+
+		calls := []*ast.CallExpr{
+			{Name: "random"},
+			{Name: "nextval"},
+			{Name: "setval", Args: []ast.Expression{name}},
+			{Name: "nextval", Args: []ast.Expression{&ast.IdentExpr{Name: "orders_seq"}}},
+			{Name: "nextval", Args: []ast.Expression{name}},
+		}
+
+		for _, call := range calls {
+			_, err := expr.NewWithSequences(call, nil, database)
+			require.Error(t, err)
+		}
+
+		_, err := expr.New(&ast.CallExpr{Name: "nextval", Args: []ast.Expression{name}}, nil)
+		require.Error(t, err, "sequence functions are not allowed without sequences")
+	})
+}
+
+func TestSequenceFunctions_Eval(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nextval", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		sequence.EXPECT().Name().Return("orders_seq")
+		sequence.EXPECT().Next().Return(int64(100), nil)
+		sequence.EXPECT().Next().Return(int64(0), errors.New("sequence reached its limit"))
+
+		node := &expr.Nextval{Sequence: sequence}
+		assert.Equal(t, "nextval('orders_seq')", node.String())
+
+		value, err := node.Eval(nil)
+		require.NoError(t, err)
+		assert.Equal(t, datatype.NewInteger(100), value)
+
+		_, err = node.Eval(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("currval", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		sequence.EXPECT().Name().Return("orders_seq").AnyTimes()
+		sequence.EXPECT().Current().Return(int64(0), false)
+		sequence.EXPECT().Current().Return(int64(110), true)
+
+		node := &expr.Currval{Sequence: sequence}
+		assert.Equal(t, "currval('orders_seq')", node.String())
+
+		_, err := node.Eval(nil)
+		require.Error(t, err, "currval is not defined before nextval")
+
+		value, err := node.Eval(nil)
+		require.NoError(t, err)
+		assert.Equal(t, datatype.NewInteger(110), value)
+	})
+
+	t.Run("setval", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		sequence.EXPECT().Name().Return("orders_seq")
+		sequence.EXPECT().SetValue(int64(10)).Return(nil)
+
+		ten, err := expr.NewInteger("10")
+		require.NoError(t, err)
+
+		node := &expr.Setval{Sequence: sequence, Value: ten}
+		assert.Equal(t, "setval('orders_seq', 10)", node.String())
+
+		value, err := node.Eval(nil)
+		require.NoError(t, err)
+		assert.Equal(t, datatype.NewInteger(10), value)
+
+		text, err := expr.NewString("10")
+		require.NoError(t, err)
+
+		_, err = (&expr.Setval{Sequence: sequence, Value: text}).Eval(nil)
+		require.Error(t, err)
+	})
+}
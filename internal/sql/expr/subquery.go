@@ -0,0 +1,434 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+)
+
+// Query is the plan of a subquery, nodes of plans are queries.
+type Query interface {
+	Columns() []string
+	RowIter() (sql.RowIter, error)
+}
+
+// Subqueries plans SELECT statements nested in expressions, the planner is one. Columns the statement doesn't
+// resolve itself are resolved in the outer scope of the parameters and become parameters of the plan.
+// A source of sequences that is also Subqueries lets expressions built with it have subqueries.
+type Subqueries interface {
+	PlanSubquery(stmt *ast.SelectStatement, params *Params) (Query, error)
+}
+
+// Params are columns of the outer query a correlated subquery refers to. The subquery expression evaluates them
+// for the current row of the outer query before its plan is read, nodes of the plan read their values.
+type Params struct {
+	outer  *Scope
+	refs   []Node
+	values []sql.Value
+}
+
+// NewParams returns the parameters of a subquery of an expression in the outer scope, there are none yet.
+func NewParams(outer *Scope) *Params {
+	return &Params{outer: outer}
+}
+
+// Refs returns the expressions over rows of the outer query that give values of the parameters, in their order.
+// A reference to a query more than one level up is a parameter of the outer query itself.
+func (p *Params) Refs() []Node {
+	return p.refs
+}
+
+// param returns the parameter of the column of the outer query, the same column is the same parameter.
+func (p *Params) param(ident *ast.IdentExpr) (Node, error) {
+	ref, err := columnExpr(ident, p.outer)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ident.Name
+	if ident.Table != "" {
+		name = ident.Table + "." + ident.Name
+	}
+
+	for i := range p.refs {
+		if p.refs[i] == ref {
+			return Param{Name: name, Index: i, params: p}, nil
+		}
+	}
+
+	p.refs = append(p.refs, ref)
+
+	return Param{Name: name, Index: len(p.refs) - 1, params: p}, nil
+}
+
+// Param is a column of the outer query a correlated subquery refers to, its value is the value of the column
+// in the current row of the outer query.
+type Param struct {
+	Name   string // the column as it's written
+	Index  int    // the position of the parameter in Refs
+	params *Params
+}
+
+func (e Param) String() string {
+	return e.Name
+}
+
+func (e Param) Eval(_ sql.Row) (sql.Value, error) {
+	if e.Index >= len(e.params.values) {
+		return nil, fmt.Errorf("parameter %s is not bound", e.Name)
+	}
+
+	return e.params.values[e.Index], nil
+}
+
+// Of reports whether the parameter is one of the parameters.
+func (e Param) Of(params *Params) bool {
+	return e.params == params
+}
+
+// SubqueryNode is a subquery expression: a scalar subquery, EXISTS or IN.
+type SubqueryNode interface {
+	Node
+	// Query returns the plan of the subquery.
+	Query() Query
+	// Outer returns the expressions that give values of parameters of the plan, none if it's uncorrelated.
+	Outer() []Node
+	// WithQuery returns the copy of the expression that reads rows of the plan instead.
+	WithQuery(query Query) Node
+}
+
+// subquery is what subquery expressions have in common. Results of the plan are kept by values of the parameters,
+// so an uncorrelated subquery is read once and a correlated one once for every distinct set of values.
+type subquery struct {
+	query   Query
+	params  *Params
+	outer   []Node // expressions over rows of the outer query that give values of the parameters
+	results map[string][]result
+}
+
+// result is what the subquery expression got from rows of the plan read for the values of the parameters.
+type result struct {
+	values []sql.Value
+	value  any
+}
+
+func newSubquery(query Query, params *Params, outer []Node) subquery {
+	return subquery{
+		query:   query,
+		params:  params,
+		outer:   outer,
+		results: make(map[string][]result),
+	}
+}
+
+// Query returns the plan of the subquery.
+func (s *subquery) Query() Query {
+	return s.query
+}
+
+// Outer returns the expressions over rows of the outer query that give values of parameters of the plan,
+// there are none if the subquery is uncorrelated.
+func (s *subquery) Outer() []Node {
+	return s.outer
+}
+
+// with returns the copy of the subquery whose parameters are given by other expressions.
+func (s *subquery) with(outer []Node) subquery {
+	return newSubquery(s.query, s.params, outer)
+}
+
+// withQuery returns the copy of the subquery that reads rows of the plan instead.
+func (s *subquery) withQuery(query Query) subquery {
+	return newSubquery(query, s.params, s.outer)
+}
+
+// result returns what read gets from rows of the plan read for values of the parameters for the row
+// of the outer query.
+func (s *subquery) result(row sql.Row, read func(iter sql.RowIter) (any, error)) (any, error) {
+	values := make([]sql.Value, 0, len(s.outer))
+
+	for _, node := range s.outer {
+		value, err := node.Eval(row)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	key := datatype.HashKey(values...)
+
+	for _, r := range s.results[key] {
+		if notDistinct(r.values, values) {
+			return r.value, nil
+		}
+	}
+
+	s.params.values = values
+
+	iter, err := s.query.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter of subquery: %w", err)
+	}
+
+	value, err := read(iter)
+	if err != nil {
+		return nil, errors.Join(err, iter.Close())
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, fmt.Errorf("close row iter of subquery: %w", err)
+	}
+
+	s.results[key] = append(s.results[key], result{values: values, value: value})
+
+	return value, nil
+}
+
+func notDistinct(a, b []sql.Value) bool {
+	for i := range a {
+		if !datatype.NotDistinct(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subquery is a scalar subquery: the value of the single column of the single row of its plan, NULL if the plan
+// returns no rows.
+type Subquery struct {
+	subquery
+}
+
+// NewSubquery returns the scalar subquery of the plan whose parameters are given by the outer expressions.
+func NewSubquery(query Query, params *Params, outer []Node) *Subquery {
+	return &Subquery{subquery: newSubquery(query, params, outer)}
+}
+
+func (e *Subquery) String() string {
+	return "(subquery)"
+}
+
+func (e *Subquery) WithQuery(query Query) Node {
+	return &Subquery{subquery: e.withQuery(query)}
+}
+
+func (e *Subquery) Eval(row sql.Row) (sql.Value, error) {
+	value, err := e.result(row, func(iter sql.RowIter) (any, error) {
+		first, err := iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return datatype.NewNull(), nil
+		case err != nil:
+			return nil, fmt.Errorf("get next row of subquery: %w", err)
+		}
+
+		_, err = iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return first[0], nil
+		case err != nil:
+			return nil, fmt.Errorf("get next row of subquery: %w", err)
+		default:
+			return nil, errors.New("more than one row returned by a subquery used as an expression")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(sql.Value), nil
+}
+
+// Exists tells whether the plan of the subquery returns any rows, Not negates it.
+type Exists struct {
+	subquery
+	Not bool
+}
+
+// NewExists returns the test of rows of the plan whose parameters are given by the outer expressions.
+func NewExists(query Query, params *Params, outer []Node, not bool) *Exists {
+	return &Exists{subquery: newSubquery(query, params, outer), Not: not}
+}
+
+func (e *Exists) String() string {
+	if e.Not {
+		return "NOT EXISTS (subquery)"
+	}
+
+	return "EXISTS (subquery)"
+}
+
+func (e *Exists) WithQuery(query Query) Node {
+	return &Exists{subquery: e.withQuery(query), Not: e.Not}
+}
+
+func (e *Exists) Eval(row sql.Row) (sql.Value, error) {
+	exists, err := e.result(row, func(iter sql.RowIter) (any, error) {
+		_, err := iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return false, nil
+		case err != nil:
+			return nil, fmt.Errorf("get next row of subquery: %w", err)
+		default:
+			return true, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewBoolean(exists.(bool) != e.Not), nil
+}
+
+// In tells whether the value of the left expression is among values of the single column of the plan, Not negates
+// it. The result is NULL if the value is NULL and the plan returns rows, or if the value isn't found and the plan
+// returns NULL: the value may be equal to it.
+type In struct {
+	subquery
+	Left Node
+	Not  bool
+}
+
+// NewIn returns the test of the value against rows of the plan whose parameters are given by the outer expressions.
+func NewIn(left Node, query Query, params *Params, outer []Node, not bool) *In {
+	return &In{subquery: newSubquery(query, params, outer), Left: left, Not: not}
+}
+
+// valueSet is the set of values of the column of rows of a subquery.
+type valueSet struct {
+	values  map[string][]sql.Value
+	hasNull bool
+}
+
+func (e *In) String() string {
+	if e.Not {
+		return e.Left.String() + " NOT IN (subquery)"
+	}
+
+	return e.Left.String() + " IN (subquery)"
+}
+
+func (e *In) WithQuery(query Query) Node {
+	return &In{subquery: e.withQuery(query), Left: e.Left, Not: e.Not}
+}
+
+func (e *In) Eval(row sql.Row) (sql.Value, error) {
+	left, err := e.Left.Eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.result(row, func(iter sql.RowIter) (any, error) {
+		set := valueSet{values: make(map[string][]sql.Value)}
+
+		for {
+			next, err := iter.Next()
+			switch {
+			case errors.Is(err, io.EOF):
+				return &set, nil
+			case err != nil:
+				return nil, fmt.Errorf("get next row of subquery: %w", err)
+			}
+
+			if next[0].DataType() == sql.Null {
+				set.hasNull = true
+				continue
+			}
+
+			key := datatype.HashKey(next[0])
+			set.values[key] = append(set.values[key], next[0])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	set := result.(*valueSet)
+
+	if len(set.values) == 0 && !set.hasNull {
+		return datatype.NewBoolean(e.Not), nil
+	}
+
+	if left.DataType() == sql.Null {
+		return datatype.NewNull(), nil
+	}
+
+	for _, value := range set.values[datatype.HashKey(left)] {
+		if datatype.NotDistinct(left, value) {
+			return datatype.NewBoolean(!e.Not), nil
+		}
+	}
+
+	if set.hasNull {
+		return datatype.NewNull(), nil
+	}
+
+	return datatype.NewBoolean(e.Not), nil
+}
+
+// subqueryExpr returns the scalar subquery of the statement, it must return a single column.
+func subqueryExpr(expr *ast.SubqueryExpr, scope *Scope, sequences Sequences) (Node, error) {
+	query, params, outer, err := planSubquery(expr.Select, scope, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(query.Columns()) != 1 {
+		return nil, errors.New("subquery must return only one column")
+	}
+
+	return NewSubquery(query, params, outer), nil
+}
+
+func existsExpr(expr *ast.ExistsExpr, scope *Scope, sequences Sequences) (Node, error) {
+	query, params, outer, err := planSubquery(expr.Select, scope, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExists(query, params, outer, expr.Not), nil
+}
+
+// inExpr returns the test of the value against results of the subquery, it must return a single column.
+func inExpr(expr *ast.InExpr, scope *Scope, sequences Sequences) (Node, error) {
+	left, err := walk(expr.Left, scope, sequences)
+	if err != nil {
+		return nil, fmt.Errorf("walk left arg of in expr: %w", err)
+	}
+
+	query, params, outer, err := planSubquery(expr.Select, scope, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(query.Columns()) != 1 {
+		return nil, errors.New("subquery has too many columns")
+	}
+
+	return NewIn(left, query, params, outer, expr.Not), nil
+}
+
+// planSubquery plans the statement by the source of sequences, the scope is the outer scope of the subquery.
+// Expressions that give values of parameters of the plan are returned with it.
+func planSubquery(stmt *ast.SelectStatement, scope *Scope, sequences Sequences) (Query, *Params, []Node, error) {
+	subqueries, ok := sequences.(Subqueries)
+	if !ok {
+		return nil, nil, nil, errors.New("subqueries are not allowed here")
+	}
+
+	params := NewParams(scope)
+
+	query, err := subqueries.PlanSubquery(stmt, params)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("plan subquery: %w", err)
+	}
+
+	return query, params, params.Refs(), nil
+}
@@ -0,0 +1,367 @@
+package expr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+)
+
+// flights plans subqueries that read the table of flights: rows that satisfy WHERE projected to the single result.
+type flights struct {
+	rows    []sql.Row
+	planned []*flightsQuery
+}
+
+var flightsScheme = sql.Scheme{
+	"id":       sql.Column{Position: 0, Name: "id", DataType: sql.Integer},
+	"aircraft": sql.Column{Position: 1, Name: "aircraft", DataType: sql.Integer, Nullable: true},
+}
+
+func (f *flights) GetSequence(name string) (sql.NamedSequence, error) {
+	return nil, errors.New("sequence not found")
+}
+
+func (f *flights) PlanSubquery(stmt *ast.SelectStatement, params *expr.Params) (expr.Query, error) {
+	scope := expr.NewScope("f", flightsScheme).Correlated(params)
+	query := &flightsQuery{rows: f.rows}
+
+	var err error
+
+	if stmt.Where != nil {
+		if query.cond, err = expr.NewInScope(stmt.Where.Expr, scope, f); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, result := range stmt.Result {
+		node, err := expr.NewInScope(result.Expr, scope, f)
+		if err != nil {
+			return nil, err
+		}
+
+		query.results = append(query.results, node)
+	}
+
+	f.planned = append(f.planned, query)
+
+	return query, nil
+}
+
+type flightsQuery struct {
+	rows    []sql.Row
+	cond    expr.Node
+	results []expr.Node
+	reads   int
+}
+
+func (q *flightsQuery) Columns() []string {
+	columns := make([]string, 0, len(q.results))
+
+	for _, result := range q.results {
+		columns = append(columns, result.String())
+	}
+
+	return columns
+}
+
+func (q *flightsQuery) RowIter() (sql.RowIter, error) {
+	q.reads++
+
+	var rows []sql.Row
+
+	for _, row := range q.rows {
+		if q.cond != nil {
+			value, err := q.cond.Eval(row)
+			if err != nil {
+				return nil, err
+			}
+
+			if value.DataType() == sql.Null || !value.Raw().(bool) {
+				continue
+			}
+		}
+
+		result := make(sql.Row, 0, len(q.results))
+
+		for _, node := range q.results {
+			value, err := node.Eval(row)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, value)
+		}
+
+		rows = append(rows, result)
+	}
+
+	return sql.RowsIter(rows...), nil
+}
+
+func TestSubqueries(t *testing.T) {
+	t.Parallel()
+
+	aircrafts := expr.NewScope("a", sql.Scheme{
+		"id":    sql.Column{Position: 0, Name: "id", DataType: sql.Integer},
+		"range": sql.Column{Position: 1, Name: "range", DataType: sql.Integer},
+	})
+
+	rows := []sql.Row{
+		{datatype.NewInteger(1), datatype.NewInteger(1)},
+		{datatype.NewInteger(2), datatype.NewInteger(1)},
+		{datatype.NewInteger(3), datatype.NewInteger(2)},
+		{datatype.NewInteger(4), datatype.NewNull()},
+	}
+
+	// SELECT <result> FROM flights f [WHERE f.aircraft = a.id]
+	subquery := func(result ast.Expression, correlated bool) *ast.SelectStatement {
+		stmt := &ast.SelectStatement{
+			Result: []ast.ResultStatement{{Expr: result}},
+			From:   &ast.FromStatement{Table: "flights", Alias: "f"},
+		}
+
+		if correlated {
+			stmt.Where = &ast.WhereStatement{
+				Expr: &ast.BinaryExpr{
+					Left:     &ast.IdentExpr{Table: "f", Name: "aircraft"},
+					Operator: token.Equal,
+					Right:    &ast.IdentExpr{Table: "a", Name: "id"},
+				},
+			}
+		}
+
+		return stmt
+	}
+
+	aircraft := func(id int64) sql.Row {
+		return sql.Row{datatype.NewInteger(id), datatype.NewInteger(id * 1000)}
+	}
+
+	eval := func(t *testing.T, node expr.Node, rows ...sql.Row) []any {
+		t.Helper()
+
+		values := make([]any, 0, len(rows))
+
+		for _, row := range rows {
+			value, err := node.Eval(row)
+			require.NoError(t, err)
+
+			values = append(values, value.Raw())
+		}
+
+		return values
+	}
+
+	t.Run("scalar subquery is read once if uncorrelated", func(t *testing.T) {
+		t.Parallel()
+
+		source := &flights{rows: rows[:1]}
+		node, err := expr.NewInScope(&ast.SubqueryExpr{Select: subquery(&ast.IdentExpr{Name: "id"}, false)}, aircrafts, source)
+		require.NoError(t, err)
+
+		assert.Equal(t, "(subquery)", node.String())
+		assert.False(t, expr.IsConstant(node))
+		assert.Equal(t, []any{int64(1), int64(1)}, eval(t, node, aircraft(1), aircraft(2)))
+		assert.Equal(t, 1, source.planned[0].reads)
+	})
+
+	t.Run("correlated subquery is read once per value of outer columns", func(t *testing.T) {
+		t.Parallel()
+
+		source := &flights{rows: rows}
+		node, err := expr.NewInScope(&ast.SubqueryExpr{Select: subquery(&ast.IdentExpr{Name: "id"}, true)}, aircrafts, source)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{0}, toInts(expr.Columns(node)))
+		assert.Equal(t, []any{int64(3), nil, int64(3)}, eval(t, node, aircraft(2), aircraft(3), aircraft(2)))
+		assert.Equal(t, 2, source.planned[0].reads)
+
+		_, err = node.Eval(aircraft(1))
+		require.ErrorContains(t, err, "more than one row returned by a subquery used as an expression")
+	})
+
+	t.Run("columns of the outer query become parameters", func(t *testing.T) {
+		t.Parallel()
+
+		// SELECT f.id + range + a.range FROM flights f WHERE f.aircraft = a.id: range isn't a column of flights.
+		result := &ast.BinaryExpr{
+			Left: &ast.BinaryExpr{
+				Left:     &ast.IdentExpr{Table: "f", Name: "id"},
+				Operator: token.Add,
+				Right:    &ast.IdentExpr{Name: "range"},
+			},
+			Operator: token.Add,
+			Right:    &ast.IdentExpr{Table: "a", Name: "range"},
+		}
+
+		source := &flights{rows: rows}
+		node, err := expr.NewInScope(&ast.SubqueryExpr{Select: subquery(result, true)}, aircrafts, source)
+		require.NoError(t, err)
+
+		subquery, ok := node.(*expr.Subquery)
+		require.True(t, ok)
+		assert.Equal(t, []expr.Node{
+			expr.Column{Table: "a", Name: "id", Position: 0},
+			expr.Column{Name: "range", Position: 1},
+			expr.Column{Table: "a", Name: "range", Position: 1},
+		}, subquery.Outer())
+		assert.Equal(t, []any{int64(4003)}, eval(t, node, aircraft(2)))
+
+		// Shifting columns moves the outer columns, the plan reads the same parameters.
+		shifted := expr.ShiftColumns(node, 2)
+		assert.Equal(t, []int{2, 3, 3}, toInts(expr.Columns(shifted)))
+		assert.Equal(t, []any{int64(4003)}, eval(t, shifted, append(aircraft(1), aircraft(2)...)))
+	})
+
+	t.Run("names of the subquery hide names of the outer query", func(t *testing.T) {
+		t.Parallel()
+
+		source := &flights{rows: rows}
+		node, err := expr.NewInScope(&ast.SubqueryExpr{Select: subquery(&ast.IdentExpr{Name: "id"}, false)}, aircrafts, source)
+		require.NoError(t, err)
+
+		assert.Empty(t, node.(*expr.Subquery).Outer())
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		t.Parallel()
+
+		source := &flights{rows: rows}
+		exists := &ast.ExistsExpr{Select: subquery(&ast.ScalarExpr{Type: token.Integer, Literal: "1"}, true)}
+
+		node, err := expr.NewInScope(exists, aircrafts, source)
+		require.NoError(t, err)
+
+		assert.Equal(t, "EXISTS (subquery)", node.String())
+		assert.Equal(t, []any{true, true, false}, eval(t, node, aircraft(1), aircraft(2), aircraft(3)))
+
+		exists.Not = true
+
+		node, err = expr.NewInScope(exists, aircrafts, source)
+		require.NoError(t, err)
+
+		assert.Equal(t, "NOT EXISTS (subquery)", node.String())
+		assert.Equal(t, []any{false, false, true}, eval(t, node, aircraft(1), aircraft(2), aircraft(3)))
+	})
+
+	t.Run("in", func(t *testing.T) {
+		t.Parallel()
+
+		// a.id IN (SELECT aircraft FROM flights f [WHERE f.id < 4])
+		in := func(not bool, withNull bool) expr.Node {
+			stmt := subquery(&ast.IdentExpr{Name: "aircraft"}, false)
+
+			if !withNull {
+				stmt.Where = &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Table: "f", Name: "id"},
+						Operator: token.LessThan,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "4"},
+					},
+				}
+			}
+
+			node, err := expr.NewInScope(&ast.InExpr{
+				Left:   &ast.IdentExpr{Table: "a", Name: "id"},
+				Select: stmt,
+				Not:    not,
+			}, aircrafts, &flights{rows: rows})
+			require.NoError(t, err)
+
+			return node
+		}
+
+		null := sql.Row{datatype.NewNull(), datatype.NewNull()}
+
+		assert.Equal(t, "a.id IN (subquery)", in(false, false).String())
+		assert.Equal(t, []any{true, false, nil}, eval(t, in(false, false), aircraft(1), aircraft(3), null))
+		assert.Equal(t, []any{false, true, nil}, eval(t, in(true, false), aircraft(1), aircraft(3), null))
+
+		// The value that isn't found may be equal to NULL.
+		assert.Equal(t, []any{true, nil}, eval(t, in(false, true), aircraft(2), aircraft(3)))
+		assert.Equal(t, []any{false, nil}, eval(t, in(true, true), aircraft(2), aircraft(3)))
+
+		// A float equal to an integer is found.
+		assert.Equal(t, []any{true}, eval(t, in(false, false), sql.Row{datatype.NewFloat(2), datatype.NewNull()}))
+	})
+
+	t.Run("in of no rows is false", func(t *testing.T) {
+		t.Parallel()
+
+		node, err := expr.NewInScope(&ast.InExpr{
+			Left:   &ast.ScalarExpr{Type: token.Null},
+			Select: subquery(&ast.IdentExpr{Name: "aircraft"}, false),
+		}, aircrafts, &flights{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []any{false}, eval(t, node, aircraft(1)))
+	})
+
+	t.Run("with query reads rows of the other plan", func(t *testing.T) {
+		t.Parallel()
+
+		source := &flights{rows: rows}
+		other := &flights{rows: rows[2:3]}
+
+		node, err := expr.NewInScope(&ast.InExpr{
+			Left:   &ast.IdentExpr{Table: "a", Name: "id"},
+			Select: subquery(&ast.IdentExpr{Name: "aircraft"}, false),
+			Not:    true,
+		}, aircrafts, source)
+		require.NoError(t, err)
+
+		_, err = expr.NewInScope(&ast.InExpr{
+			Left:   &ast.IdentExpr{Table: "a", Name: "id"},
+			Select: subquery(&ast.IdentExpr{Name: "aircraft"}, false),
+		}, aircrafts, other)
+		require.NoError(t, err)
+
+		in, ok := node.(expr.SubqueryNode)
+		require.True(t, ok)
+
+		replaced := in.WithQuery(other.planned[0])
+		assert.Equal(t, "a.id NOT IN (subquery)", replaced.String())
+		assert.Equal(t, []any{true, false}, eval(t, replaced, aircraft(1), aircraft(2)))
+		assert.Equal(t, 0, source.planned[0].reads)
+		assert.Equal(t, 1, other.planned[0].reads)
+		assert.Equal(t, other.planned[0], replaced.(expr.SubqueryNode).Query())
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		two := subquery(&ast.IdentExpr{Name: "id"}, false)
+		two.Result = append(two.Result, ast.ResultStatement{Expr: &ast.IdentExpr{Name: "aircraft"}})
+
+		_, err := expr.NewInScope(&ast.SubqueryExpr{Select: two}, aircrafts, &flights{})
+		require.ErrorContains(t, err, "subquery must return only one column")
+
+		_, err = expr.NewInScope(&ast.InExpr{Left: &ast.IdentExpr{Name: "id"}, Select: two}, aircrafts, &flights{})
+		require.ErrorContains(t, err, "subquery has too many columns")
+
+		_, err = expr.NewInScope(&ast.SubqueryExpr{Select: subquery(&ast.IdentExpr{Name: "missing"}, false)},
+			aircrafts, &flights{})
+		require.ErrorContains(t, err, `column "missing" not exists`)
+
+		_, err = expr.NewInScope(&ast.SubqueryExpr{Select: subquery(&ast.IdentExpr{Name: "id"}, false)}, aircrafts, nil)
+		require.ErrorContains(t, err, "subqueries are not allowed here")
+	})
+}
+
+func toInts(positions []uint8) []int {
+	ints := make([]int, 0, len(positions))
+
+	for _, position := range positions {
+		ints = append(ints, int(position))
+	}
+
+	return ints
+}
@@ -0,0 +1,324 @@
+package expr
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+// Transform rebuilds the expression bottom-up: fn is called for every node after its operands are transformed
+// and returns the node to use instead of it, false if the node is kept. Nodes whose operands are kept
+// aren't copied.
+func Transform(node Node, fn func(Node) (Node, bool)) Node {
+	node, _ = transform(node, fn)
+
+	return node
+}
+
+func transform(node Node, fn func(Node) (Node, bool)) (Node, bool) {
+	var changed bool
+
+	switch e := node.(type) {
+	case *Binary:
+		left, l := transform(e.Left, fn)
+		right, r := transform(e.Right, fn)
+
+		if changed = l || r; changed {
+			node = &Binary{Operator: e.Operator, Left: left, Right: right}
+		}
+	case Binary:
+		left, l := transform(e.Left, fn)
+		right, r := transform(e.Right, fn)
+
+		if changed = l || r; changed {
+			node = Binary{Operator: e.Operator, Left: left, Right: right}
+		}
+	case *Unary:
+		var operand Node
+
+		if operand, changed = transform(e.Operand, fn); changed {
+			node = &Unary{Operator: e.Operator, Operand: operand}
+		}
+	case *Setval:
+		var value Node
+
+		if value, changed = transform(e.Value, fn); changed {
+			node = &Setval{Sequence: e.Sequence, Value: value}
+		}
+	case *Aggregate:
+		var arg Node
+
+		if e.Arg == nil {
+			break
+		}
+
+		if arg, changed = transform(e.Arg, fn); changed {
+			node = &Aggregate{Func: e.Func, Arg: arg, Distinct: e.Distinct}
+		}
+	case *Subquery:
+		var outer []Node
+
+		if outer, changed = transformAll(e.outer, fn); changed {
+			node = &Subquery{subquery: e.with(outer)}
+		}
+	case *Exists:
+		var outer []Node
+
+		if outer, changed = transformAll(e.outer, fn); changed {
+			node = &Exists{subquery: e.with(outer), Not: e.Not}
+		}
+	case *In:
+		left, l := transform(e.Left, fn)
+		outer, o := transformAll(e.outer, fn)
+
+		if changed = l || o; changed {
+			node = &In{subquery: e.with(outer), Left: left, Not: e.Not}
+		}
+	}
+
+	if replaced, ok := fn(node); ok {
+		return replaced, true
+	}
+
+	return node, changed
+}
+
+// transformAll transforms the expressions, the slice is kept if all of them are.
+func transformAll(nodes []Node, fn func(Node) (Node, bool)) ([]Node, bool) {
+	var transformed []Node
+
+	for i, node := range nodes {
+		next, changed := transform(node, fn)
+		if changed && transformed == nil {
+			transformed = append(make([]Node, 0, len(nodes)), nodes[:i]...)
+		}
+
+		if transformed != nil {
+			transformed = append(transformed, next)
+		}
+	}
+
+	if transformed == nil {
+		return nodes, false
+	}
+
+	return transformed, true
+}
+
+// Replace rebuilds the expression top-down: fn is called for every node before its operands and returns the node
+// to use instead of it, false if the node is kept. Operands of a replaced node aren't visited.
+func Replace(node Node, fn func(Node) (Node, bool)) Node {
+	if replaced, ok := fn(node); ok {
+		return replaced
+	}
+
+	switch e := node.(type) {
+	case *Binary:
+		return &Binary{Operator: e.Operator, Left: Replace(e.Left, fn), Right: Replace(e.Right, fn)}
+	case Binary:
+		return Binary{Operator: e.Operator, Left: Replace(e.Left, fn), Right: Replace(e.Right, fn)}
+	case *Unary:
+		return &Unary{Operator: e.Operator, Operand: Replace(e.Operand, fn)}
+	case *Setval:
+		return &Setval{Sequence: e.Sequence, Value: Replace(e.Value, fn)}
+	case *Aggregate:
+		if e.Arg == nil {
+			return e
+		}
+
+		return &Aggregate{Func: e.Func, Arg: Replace(e.Arg, fn), Distinct: e.Distinct}
+	case *Subquery:
+		return &Subquery{subquery: e.with(replaceAll(e.outer, fn))}
+	case *Exists:
+		return &Exists{subquery: e.with(replaceAll(e.outer, fn)), Not: e.Not}
+	case *In:
+		return &In{subquery: e.with(replaceAll(e.outer, fn)), Left: Replace(e.Left, fn), Not: e.Not}
+	default:
+		return node
+	}
+}
+
+func replaceAll(nodes []Node, fn func(Node) (Node, bool)) []Node {
+	replaced := make([]Node, len(nodes))
+
+	for i, node := range nodes {
+		replaced[i] = Replace(node, fn)
+	}
+
+	return replaced
+}
+
+// Inspect calls fn for every node of the expression, the node goes before its operands. Expressions that give
+// values of parameters of a subquery are its operands, the plan of the subquery isn't inspected.
+func Inspect(node Node, fn func(Node)) {
+	fn(node)
+
+	switch e := node.(type) {
+	case *Binary:
+		Inspect(e.Left, fn)
+		Inspect(e.Right, fn)
+	case Binary:
+		Inspect(e.Left, fn)
+		Inspect(e.Right, fn)
+	case *Unary:
+		Inspect(e.Operand, fn)
+	case *Setval:
+		Inspect(e.Value, fn)
+	case *Aggregate:
+		if e.Arg != nil {
+			Inspect(e.Arg, fn)
+		}
+	case *Subquery:
+		inspectAll(e.outer, fn)
+	case *Exists:
+		inspectAll(e.outer, fn)
+	case *In:
+		Inspect(e.Left, fn)
+		inspectAll(e.outer, fn)
+	}
+}
+
+func inspectAll(nodes []Node, fn func(Node)) {
+	for _, node := range nodes {
+		Inspect(node, fn)
+	}
+}
+
+// IsConstant reports whether the expression gives the same value for every row: it refers to no columns,
+// calls no sequence functions and has no subqueries, which are read only when the plan is run.
+func IsConstant(node Node) bool {
+	constant := true
+
+	Inspect(node, func(node Node) {
+		switch node.(type) {
+		case Column, Param, *Nextval, *Currval, *Setval, *Aggregate, *Subquery, *Exists, *In:
+			constant = false
+		}
+	})
+
+	return constant
+}
+
+// IsVolatile reports whether evaluating the expression has side effects, i.e. it calls sequence functions.
+// Such expressions must be evaluated exactly where and as many times as the query says.
+func IsVolatile(node Node) bool {
+	volatile := false
+
+	Inspect(node, func(node Node) {
+		switch node.(type) {
+		case *Nextval, *Setval:
+			volatile = true
+		}
+	})
+
+	return volatile
+}
+
+// Columns returns positions of columns the expression refers to, in the order of references.
+func Columns(node Node) []uint8 {
+	var positions []uint8
+
+	Inspect(node, func(node Node) {
+		if column, ok := node.(Column); ok {
+			positions = append(positions, column.Position)
+		}
+	})
+
+	return positions
+}
+
+// ShiftColumns returns the expression whose columns are moved by the offset in rows: columns of a joined table
+// are at the positions of the joined row, shifting them by minus the width of the tables before it gives
+// their positions in rows of the table.
+func ShiftColumns(node Node, offset int) Node {
+	return Transform(node, func(node Node) (Node, bool) {
+		column, ok := node.(Column)
+		if !ok || offset == 0 {
+			return nil, false
+		}
+
+		column.Position = uint8(int(column.Position) + offset)
+
+		return column, true
+	})
+}
+
+// Conjuncts splits the condition into the conditions combined by AND.
+func Conjuncts(cond Node) []Node {
+	if binary, ok := AsBinary(cond); ok && binary.Operator == And {
+		return append(Conjuncts(binary.Left), Conjuncts(binary.Right)...)
+	}
+
+	return []Node{cond}
+}
+
+// Conjunction combines the conditions by AND, nil is returned if there are none.
+func Conjunction(conds ...Node) Node {
+	var cond Node
+
+	for _, next := range conds {
+		if cond == nil {
+			cond = next
+			continue
+		}
+
+		cond = &Binary{
+			Operator: And,
+			Left:     cond,
+			Right:    next,
+		}
+	}
+
+	return cond
+}
+
+// NewConstant returns the literal of the value, false if values of its type have no literal.
+func NewConstant(value sql.Value) (Node, bool) {
+	switch value.DataType() {
+	case sql.Integer:
+		v, ok := value.Raw().(int64)
+		return Integer{value: v}, ok
+	case sql.Float:
+		v, ok := value.Raw().(float64)
+		return Float{value: v}, ok
+	case sql.Text:
+		v, ok := value.Raw().(string)
+		return String{value: v}, ok
+	case sql.Boolean:
+		v, ok := value.Raw().(bool)
+		return Boolean{value: v}, ok
+	case sql.Null:
+		return Null{}, true
+	default:
+		return nil, false
+	}
+}
+
+// AsBinary returns the binary expression whether the node is a pointer to it or a value.
+func AsBinary(node Node) (Binary, bool) {
+	switch e := node.(type) {
+	case *Binary:
+		return *e, true
+	case Binary:
+		return e, true
+	default:
+		return Binary{}, false
+	}
+}
+
+// IsLiteral reports whether the node is a literal, the value is returned if so.
+func IsLiteral(node Node) (sql.Value, bool) {
+	switch e := node.(type) {
+	case Integer:
+		return datatype.NewInteger(e.value), true
+	case Float:
+		return datatype.NewFloat(e.value), true
+	case String:
+		return datatype.NewText(e.value), true
+	case Boolean:
+		return datatype.NewBoolean(e.value), true
+	case Null:
+		return datatype.NewNull(), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,279 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+func constant(t *testing.T, value sql.Value) expr.Node {
+	t.Helper()
+
+	node, ok := expr.NewConstant(value)
+	require.True(t, ok)
+
+	return node
+}
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replaces nodes bottom-up", func(t *testing.T) {
+		t.Parallel()
+
+		// -(age + 1) * 2 with age replaced by 10
+		node := &expr.Binary{
+			Operator: expr.Mul,
+			Left: &expr.Unary{
+				Operator: expr.UnaryMinus,
+				Operand: expr.Binary{
+					Operator: expr.Add,
+					Left:     expr.Column{Name: "age", Position: 1},
+					Right:    constant(t, datatype.NewInteger(1)),
+				},
+			},
+			Right: constant(t, datatype.NewInteger(2)),
+		}
+
+		var visited []string
+
+		transformed := expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+			visited = append(visited, node.String())
+
+			if _, ok := node.(expr.Column); ok {
+				return constant(t, datatype.NewInteger(10)), true
+			}
+
+			return nil, false
+		})
+
+		assert.Equal(t, []string{"age", "1", "(10 + 1)", "(-(10 + 1))", "2", "((-(10 + 1)) * 2)"}, visited)
+		assert.Equal(t, "((-(10 + 1)) * 2)", transformed.String())
+		assert.Equal(t, "((-(age + 1)) * 2)", node.String())
+
+		value, err := transformed.Eval(nil)
+		require.NoError(t, err)
+		assert.Equal(t, datatype.NewInteger(-22), value)
+	})
+
+	t.Run("keeps unchanged nodes", func(t *testing.T) {
+		t.Parallel()
+
+		node := &expr.Binary{
+			Operator: expr.Equal,
+			Left:     expr.Column{Name: "id", Position: 0},
+			Right:    constant(t, datatype.NewInteger(1)),
+		}
+
+		transformed := expr.Transform(node, func(expr.Node) (expr.Node, bool) {
+			return nil, false
+		})
+
+		assert.Same(t, node, transformed)
+	})
+
+	t.Run("transforms the value of setval", func(t *testing.T) {
+		t.Parallel()
+
+		node := &expr.Setval{Value: expr.Column{Name: "id", Position: 0}}
+
+		transformed := expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+			if _, ok := node.(expr.Column); ok {
+				return constant(t, datatype.NewInteger(5)), true
+			}
+
+			return nil, false
+		})
+
+		assert.Equal(t, &expr.Setval{Value: constant(t, datatype.NewInteger(5))}, transformed)
+		assert.Equal(t, &expr.Setval{Value: expr.Column{Name: "id", Position: 0}}, node)
+	})
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	// max(age) + age with max(age) replaced by 10 and age by 1
+	aggregate := &expr.Aggregate{Func: expr.MaxFunc, Arg: expr.Column{Name: "age", Position: 1}}
+	node := &expr.Binary{
+		Operator: expr.Add,
+		Left:     aggregate,
+		Right:    expr.Column{Name: "age", Position: 1},
+	}
+
+	var visited []string
+
+	replaced := expr.Replace(node, func(node expr.Node) (expr.Node, bool) {
+		visited = append(visited, node.String())
+
+		switch node.(type) {
+		case *expr.Aggregate:
+			return constant(t, datatype.NewInteger(10)), true
+		case expr.Column:
+			return constant(t, datatype.NewInteger(1)), true
+		default:
+			return nil, false
+		}
+	})
+
+	assert.Equal(t, []string{"(max(age) + age)", "max(age)", "age"}, visited)
+	assert.Equal(t, "(10 + 1)", replaced.String())
+	assert.Equal(t, "(max(age) + age)", node.String())
+}
+
+func TestIsConstant(t *testing.T) {
+	t.Parallel()
+
+	one := constant(t, datatype.NewInteger(1))
+
+	tests := []struct {
+		name     string
+		node     expr.Node
+		expected bool
+	}{
+		{
+			name:     "literal",
+			node:     one,
+			expected: true,
+		},
+		{
+			name: "expression of literals",
+			node: &expr.Binary{
+				Operator: expr.Add,
+				Left:     one,
+				Right:    &expr.Unary{Operator: expr.UnaryMinus, Operand: one},
+			},
+			expected: true,
+		},
+		{
+			name:     "column",
+			node:     &expr.Binary{Operator: expr.Add, Left: one, Right: expr.Column{Name: "id", Position: 0}},
+			expected: false,
+		},
+		{
+			name:     "nextval",
+			node:     &expr.Nextval{},
+			expected: false,
+		},
+		{
+			name:     "currval",
+			node:     &expr.Currval{},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, expr.IsConstant(test.node))
+		})
+	}
+}
+
+func TestIsVolatile(t *testing.T) {
+	t.Parallel()
+
+	one := constant(t, datatype.NewInteger(1))
+
+	assert.False(t, expr.IsVolatile(&expr.Binary{Operator: expr.Add, Left: one, Right: expr.Column{Name: "id"}}))
+	assert.False(t, expr.IsVolatile(&expr.Currval{}))
+	assert.True(t, expr.IsVolatile(&expr.Binary{Operator: expr.Add, Left: one, Right: &expr.Nextval{}}))
+	assert.True(t, expr.IsVolatile(&expr.Setval{Value: one}))
+}
+
+func TestColumns(t *testing.T) {
+	t.Parallel()
+
+	node := &expr.Binary{
+		Operator: expr.And,
+		Left: expr.Binary{
+			Operator: expr.GreaterThan,
+			Left:     expr.Column{Name: "age", Position: 2},
+			Right:    constant(t, datatype.NewInteger(18)),
+		},
+		Right: &expr.Binary{
+			Operator: expr.Equal,
+			Left:     expr.Column{Name: "id", Position: 0},
+			Right:    expr.Column{Name: "age", Position: 2},
+		},
+	}
+
+	assert.Equal(t, []uint8{2, 0, 2}, expr.Columns(node))
+	assert.Empty(t, expr.Columns(constant(t, datatype.NewInteger(1))))
+}
+
+func TestShiftColumns(t *testing.T) {
+	t.Parallel()
+
+	node := &expr.Binary{
+		Operator: expr.Equal,
+		Left:     expr.Column{Table: "posts", Name: "user_id", Position: 3},
+		Right:    constant(t, datatype.NewInteger(1)),
+	}
+
+	shifted := expr.ShiftColumns(node, -2)
+	assert.Equal(t, []uint8{1}, expr.Columns(shifted))
+	assert.Equal(t, []uint8{3}, expr.Columns(node))
+	assert.Equal(t, node, expr.ShiftColumns(node, 0))
+}
+
+func TestConjuncts(t *testing.T) {
+	t.Parallel()
+
+	compare := func(operator expr.BinaryOp, column string, value sql.Value) expr.Node {
+		return &expr.Binary{Operator: operator, Left: expr.Column{Name: column}, Right: constant(t, value)}
+	}
+
+	id := compare(expr.Equal, "id", datatype.NewInteger(1))
+	age := compare(expr.GreaterThan, "age", datatype.NewInteger(18))
+	name := compare(expr.Equal, "name", datatype.NewText("bob"))
+
+	conjunction := expr.Conjunction(id, age, name)
+	assert.Equal(t, []expr.Node{id, age, name}, expr.Conjuncts(conjunction))
+	assert.Equal(t, id, expr.Conjunction(id))
+	assert.Nil(t, expr.Conjunction())
+
+	or := &expr.Binary{Operator: expr.Or, Left: id, Right: age}
+	assert.Equal(t, []expr.Node{or}, expr.Conjuncts(or))
+}
+
+func TestNewConstant(t *testing.T) {
+	t.Parallel()
+
+	values := []sql.Value{
+		datatype.NewInteger(42),
+		datatype.NewFloat(1.5),
+		datatype.NewText("bob"),
+		datatype.NewBoolean(true),
+		datatype.NewNull(),
+	}
+
+	for _, value := range values {
+		t.Run(value.DataType().String(), func(t *testing.T) {
+			t.Parallel()
+
+			node, ok := expr.NewConstant(value)
+			require.True(t, ok)
+
+			literal, ok := expr.IsLiteral(node)
+			require.True(t, ok)
+			assert.Equal(t, value, literal)
+
+			evaluated, err := node.Eval(nil)
+			require.NoError(t, err)
+			assert.Equal(t, value, evaluated)
+		})
+	}
+
+	t.Run("not a literal", func(t *testing.T) {
+		t.Parallel()
+
+		_, ok := expr.IsLiteral(expr.Column{Name: "id", Position: 0})
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,227 @@
+package sql
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Key is the primary key of a row: values of the primary key columns encoded so that keys compare as byte strings
+// in the order of their values, column by column. Every value is its data type followed by the encoded value,
+// text is terminated, so the encoding of a value is never a prefix of the encoding of another value.
+type Key string
+
+// text terminator and escape of the zero byte within text.
+const (
+	keyEscape     = 0x00
+	keyTerminator = 0x01
+	keyEscaped    = 0xff
+)
+
+// NewKey encodes the values of the primary key columns, NULL can't be a part of a key.
+func NewKey(values ...Value) (Key, error) {
+	buf := make([]byte, 0, 9*len(values))
+
+	for _, value := range values {
+		var err error
+
+		if buf, err = appendKey(buf, value); err != nil {
+			return "", err
+		}
+	}
+
+	return Key(buf), nil
+}
+
+// IntegerKey returns the key of a table with an integer primary key.
+func IntegerKey(v int64) Key {
+	buf := make([]byte, 0, 9)
+	buf = append(buf, byte(Integer))
+
+	return Key(binary.BigEndian.AppendUint64(buf, uint64(v)^1<<63))
+}
+
+// RowKey returns the key of the row, positions are positions of the primary key columns.
+func RowKey(row Row, positions []uint8) (Key, error) {
+	buf := make([]byte, 0, 9*len(positions))
+
+	for _, position := range positions {
+		if int(position) >= len(row) {
+			return "", fmt.Errorf("row has no column at position %d", position)
+		}
+
+		var err error
+
+		if buf, err = appendKey(buf, row[position]); err != nil {
+			return "", err
+		}
+	}
+
+	return Key(buf), nil
+}
+
+func appendKey(buf []byte, value Value) ([]byte, error) {
+	switch raw := value.Raw().(type) {
+	case int64:
+		buf = append(buf, byte(Integer))
+
+		return binary.BigEndian.AppendUint64(buf, uint64(raw)^1<<63), nil
+	case float64:
+		// Negative floats have all bits flipped, positive ones the sign bit, so bits compare as the floats.
+		bits := math.Float64bits(raw)
+		if bits&(1<<63) != 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+
+		buf = append(buf, byte(Float))
+
+		return binary.BigEndian.AppendUint64(buf, bits), nil
+	case string:
+		buf = append(buf, byte(Text))
+
+		for i := 0; i < len(raw); i++ {
+			buf = append(buf, raw[i])
+
+			if raw[i] == keyEscape {
+				buf = append(buf, keyEscaped)
+			}
+		}
+
+		return append(buf, keyEscape, keyTerminator), nil
+	case bool:
+		buf = append(buf, byte(Boolean), 0)
+
+		if raw {
+			buf[len(buf)-1] = 1
+		}
+
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", value.DataType())
+	}
+}
+
+// Integer returns the value of a key that consists of a single integer.
+func (k Key) Integer() (int64, bool) {
+	if len(k) != 9 || k[0] != byte(Integer) {
+		return 0, false
+	}
+
+	return int64(binary.BigEndian.Uint64([]byte(k[1:])) ^ 1<<63), true
+}
+
+// Raw returns decoded values of the key as Value.Raw returns them.
+func (k Key) Raw() ([]any, error) {
+	var (
+		values = make([]any, 0, 1)
+		buf    = []byte(k)
+	)
+
+	for len(buf) > 0 {
+		dataType := DataType(buf[0])
+		buf = buf[1:]
+
+		switch dataType {
+		case Integer, Float:
+			if len(buf) < 8 {
+				return nil, errors.New("unexpected end of key")
+			}
+
+			bits := binary.BigEndian.Uint64(buf)
+			buf = buf[8:]
+
+			if dataType == Integer {
+				values = append(values, int64(bits^1<<63))
+
+				continue
+			}
+
+			if bits&(1<<63) != 0 {
+				bits &^= 1 << 63
+			} else {
+				bits = ^bits
+			}
+
+			values = append(values, math.Float64frombits(bits))
+		case Text:
+			var s []byte
+
+			for {
+				if len(buf) < 2 {
+					return nil, errors.New("unexpected end of key")
+				}
+
+				if buf[0] != keyEscape {
+					s = append(s, buf[0])
+					buf = buf[1:]
+
+					continue
+				}
+
+				escaped := buf[1]
+				buf = buf[2:]
+
+				if escaped == keyTerminator {
+					break
+				}
+
+				s = append(s, keyEscape)
+			}
+
+			values = append(values, string(s))
+		case Boolean:
+			if len(buf) < 1 {
+				return nil, errors.New("unexpected end of key")
+			}
+
+			values = append(values, buf[0] == 1)
+			buf = buf[1:]
+		default:
+			return nil, fmt.Errorf("unexpected data type %s in key", dataType)
+		}
+	}
+
+	return values, nil
+}
+
+// String returns values of the key separated by commas, it's meant for messages.
+func (k Key) String() string {
+	values, err := k.Raw()
+	if err != nil {
+		return strconv.Quote(string(k))
+	}
+
+	parts := make([]string, 0, len(values))
+
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			parts = append(parts, strconv.Quote(v))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// PrefixEnd returns the least key greater than every key that starts with k.
+// The empty key is returned if there is no such key.
+func (k Key) PrefixEnd() Key {
+	end := []byte(k)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+
+			return Key(end[:i+1])
+		}
+	}
+
+	return ""
+}
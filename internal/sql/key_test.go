@@ -0,0 +1,159 @@
+package sql_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+func TestKey_Order(t *testing.T) {
+	t.Parallel()
+
+	// Every group of values is in ascending order, so their keys must be as well.
+	groups := [][][]sql.Value{
+		{
+			{datatype.NewInteger(math.MinInt64)},
+			{datatype.NewInteger(-1)},
+			{datatype.NewInteger(0)},
+			{datatype.NewInteger(1)},
+			{datatype.NewInteger(256)},
+			{datatype.NewInteger(math.MaxInt64)},
+		},
+		{
+			{datatype.NewFloat(math.Inf(-1))},
+			{datatype.NewFloat(-2.5)},
+			{datatype.NewFloat(-0.1)},
+			{datatype.NewFloat(0)},
+			{datatype.NewFloat(0.1)},
+			{datatype.NewFloat(2.5)},
+			{datatype.NewFloat(math.Inf(1))},
+		},
+		{
+			{datatype.NewText("")},
+			{datatype.NewText("\x00")},
+			{datatype.NewText("\x00\x00")},
+			{datatype.NewText("\x01")},
+			{datatype.NewText("a")},
+			{datatype.NewText("a\x00b")},
+			{datatype.NewText("ab")},
+			{datatype.NewText("b")},
+		},
+		{
+			{datatype.NewBoolean(false)},
+			{datatype.NewBoolean(true)},
+		},
+		{
+			{datatype.NewInteger(1), datatype.NewText("b")},
+			{datatype.NewInteger(1), datatype.NewText("ba")},
+			{datatype.NewInteger(2), datatype.NewText("a")},
+			{datatype.NewInteger(10), datatype.NewText("")},
+		},
+		{
+			{datatype.NewText("a"), datatype.NewInteger(10)},
+			{datatype.NewText("ab"), datatype.NewInteger(-10)},
+			{datatype.NewText("b"), datatype.NewInteger(-20)},
+		},
+	}
+
+	for _, group := range groups {
+		keys := make([]sql.Key, 0, len(group))
+
+		for _, values := range group {
+			key, err := sql.NewKey(values...)
+			require.NoError(t, err)
+
+			keys = append(keys, key)
+		}
+
+		for i := 1; i < len(keys); i++ {
+			assert.Less(t, keys[i-1], keys[i], "%s should go before %s", keys[i-1], keys[i])
+		}
+	}
+}
+
+func TestKey_Raw(t *testing.T) {
+	t.Parallel()
+
+	values := []sql.Value{
+		datatype.NewInteger(-42),
+		datatype.NewText("a\x00b"),
+		datatype.NewFloat(-1.5),
+		datatype.NewBoolean(true),
+		datatype.NewText(""),
+	}
+
+	key, err := sql.NewKey(values...)
+	require.NoError(t, err)
+
+	raw, err := key.Raw()
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(-42), "a\x00b", -1.5, true, ""}, raw)
+	assert.Equal(t, `-42, "a\x00b", -1.5, true, ""`, key.String())
+
+	_, err = sql.Key(key[:len(key)-1]).Raw()
+	require.Error(t, err)
+}
+
+func TestKey_Integer(t *testing.T) {
+	t.Parallel()
+
+	key, err := sql.NewKey(datatype.NewInteger(7))
+	require.NoError(t, err)
+	assert.Equal(t, sql.IntegerKey(7), key)
+
+	value, ok := key.Integer()
+	require.True(t, ok)
+	assert.Equal(t, int64(7), value)
+
+	key, err = sql.NewKey(datatype.NewText("7"))
+	require.NoError(t, err)
+
+	_, ok = key.Integer()
+	assert.False(t, ok)
+}
+
+func TestRowKey(t *testing.T) {
+	t.Parallel()
+
+	row := sql.Row{
+		datatype.NewText("Max"),
+		datatype.NewInteger(2),
+		datatype.NewInteger(1),
+	}
+
+	key, err := sql.RowKey(row, []uint8{2, 1})
+	require.NoError(t, err)
+
+	expected, err := sql.NewKey(datatype.NewInteger(1), datatype.NewInteger(2))
+	require.NoError(t, err)
+	assert.Equal(t, expected, key)
+
+	_, err = sql.RowKey(row, []uint8{3})
+	require.Error(t, err)
+
+	_, err = sql.RowKey(sql.Row{datatype.NewNull()}, []uint8{0})
+	require.Error(t, err)
+}
+
+func TestKey_PrefixEnd(t *testing.T) {
+	t.Parallel()
+
+	prefix, err := sql.NewKey(datatype.NewText("a"))
+	require.NoError(t, err)
+
+	inside, err := sql.NewKey(datatype.NewText("a"), datatype.NewInteger(math.MaxInt64))
+	require.NoError(t, err)
+
+	after, err := sql.NewKey(datatype.NewText("a\x00"))
+	require.NoError(t, err)
+
+	end := prefix.PrefixEnd()
+	assert.Less(t, inside, end)
+	assert.LessOrEqual(t, end, after)
+	assert.Equal(t, sql.Key(""), sql.Key("\xff\xff").PrefixEnd())
+}
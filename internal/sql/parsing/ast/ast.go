@@ -22,12 +22,20 @@ type Expression interface {
 
 // SelectStatement node represents a SELECT statement.
 type SelectStatement struct {
-	Result  []ResultStatement
-	From    *FromStatement
-	Where   *WhereStatement
-	OrderBy *OrderByStatement
-	Limit   *LimitStatement
-	Offset  *OffsetStatement
+	Distinct *DistinctStatement
+	Result   []ResultStatement
+	From     *FromStatement
+	Where    *WhereStatement
+	GroupBy  *GroupByStatement
+	Having   *HavingStatement
+	OrderBy  *OrderByStatement
+	Limit    *LimitStatement
+	Offset   *OffsetStatement
+}
+
+// DistinctStatement node represents a DISTINCT or a DISTINCT ON statement, On is empty for the former.
+type DistinctStatement struct {
+	On []Expression
 }
 
 // ResultStatement node represents a returning expression in a SELECT statement.
@@ -36,9 +44,23 @@ type ResultStatement struct {
 	Expr  Expression
 }
 
-// FromStatement node represents a FROM statement.
+// FromStatement node represents a FROM statement: the table and the tables joined to it in the order they're written.
+// A derived table is a subquery in place of the table, it always has an alias.
 type FromStatement struct {
-	Table string
+	Table    string
+	Subquery *SelectStatement // nil unless the table is derived
+	Alias    string
+	Joins    []JoinStatement
+}
+
+// JoinStatement node represents a table joined to the tables before it. Type is one of token.Inner, token.Left,
+// token.Right, token.Full and token.Cross, a comma join is a cross join. A cross join has no condition.
+type JoinStatement struct {
+	Type     token.Type
+	Table    string
+	Subquery *SelectStatement // nil unless the table is derived
+	Alias    string
+	On       Expression
 }
 
 // WhereStatement node represents a WHERE statement.
@@ -46,8 +68,19 @@ type WhereStatement struct {
 	Expr Expression
 }
 
+// GroupByStatement node represents a GROUP BY statement.
+type GroupByStatement struct {
+	Exprs []Expression
+}
+
+// HavingStatement node represents a HAVING statement.
+type HavingStatement struct {
+	Expr Expression
+}
+
 // OrderByStatement node represents an ORDER BY statement.
 type OrderByStatement struct {
+	Table     string // qualifies the column, empty if it's not qualified
 	Column    string
 	Direction token.Type
 }
@@ -91,6 +124,7 @@ type DeleteStatement struct {
 // CreateDatabaseStatement node represents a CREATE DATABASE statement.
 type CreateDatabaseStatement struct {
 	Database string
+	Engine   string // storage engine given by USING, empty for the default one
 }
 
 // DropDatabaseStatement node represents a DROP DATABASE statement.
@@ -100,8 +134,10 @@ type DropDatabaseStatement struct {
 
 // CreateTableStatement node represents a CREATE TABLE statement.
 type CreateTableStatement struct {
-	Table   string
-	Columns []Column
+	Table      string
+	Columns    []Column
+	PrimaryKey []string // columns of the PRIMARY KEY table constraint
+	Engine     string   // storage engine given by USING, empty for the default one
 }
 
 // Column node represents a table column definition.
@@ -111,6 +147,7 @@ type Column struct {
 	Default    Expression
 	Nullable   bool
 	PrimaryKey bool
+	Identity   *SequenceOptions // options of GENERATED ALWAYS AS IDENTITY, nil for a regular column
 }
 
 // DropTableStatement node represents a DROP TABLE statement.
@@ -118,10 +155,66 @@ type DropTableStatement struct {
 	Table string
 }
 
+// CreateIndexStatement node represents a CREATE INDEX statement.
+type CreateIndexStatement struct {
+	Index   string
+	Table   string
+	Columns []string
+	Unique  bool
+}
+
+// DropIndexStatement node represents a DROP INDEX statement.
+type DropIndexStatement struct {
+	Index string
+}
+
+// CreateSequenceStatement node represents a CREATE SEQUENCE statement.
+type CreateSequenceStatement struct {
+	Sequence string
+	Options  SequenceOptions
+}
+
+// SequenceOptions node represents options of a sequence, nil options take the default values.
+type SequenceOptions struct {
+	Start     Expression
+	Increment Expression
+}
+
+// DropSequenceStatement node represents a DROP SEQUENCE statement.
+type DropSequenceStatement struct {
+	Sequence string
+}
+
+// AnalyzeStatement node represents an ANALYZE statement, an empty table stands for all tables of the database.
+type AnalyzeStatement struct {
+	Table string
+}
+
+// ExplainStatement node represents an EXPLAIN statement. Analyze runs the statement,
+// Format is either token.Text or token.JSON.
+type ExplainStatement struct {
+	Statement Statement
+	Analyze   bool
+	Format    token.Type
+}
+
+// BeginStatement node represents a BEGIN statement.
+type BeginStatement struct{}
+
+// CommitStatement node represents a COMMIT statement.
+type CommitStatement struct{}
+
+// RollbackStatement node represents a ROLLBACK statement.
+type RollbackStatement struct{}
+
 func (s *SelectStatement) statementNode()         {}
+func (s *DistinctStatement) statementNode()       {}
 func (s *ResultStatement) statementNode()         {}
 func (s *FromStatement) statementNode()           {}
+func (s *JoinStatement) statementNode()           {}
 func (s *WhereStatement) statementNode()          {}
+func (s *GroupByStatement) statementNode()        {}
+func (s *HavingStatement) statementNode()         {}
 func (s *OrderByStatement) statementNode()        {}
 func (s *LimitStatement) statementNode()          {}
 func (s *OffsetStatement) statementNode()         {}
@@ -133,10 +226,20 @@ func (s *CreateDatabaseStatement) statementNode() {}
 func (s *DropDatabaseStatement) statementNode()   {}
 func (s *CreateTableStatement) statementNode()    {}
 func (s *DropTableStatement) statementNode()      {}
+func (s *CreateIndexStatement) statementNode()    {}
+func (s *DropIndexStatement) statementNode()      {}
+func (s *CreateSequenceStatement) statementNode() {}
+func (s *DropSequenceStatement) statementNode()   {}
+func (s *AnalyzeStatement) statementNode()        {}
+func (s *ExplainStatement) statementNode()        {}
+func (s *BeginStatement) statementNode()          {}
+func (s *CommitStatement) statementNode()         {}
+func (s *RollbackStatement) statementNode()       {}
 
-// IdentExpr node represents an identifier.
+// IdentExpr node represents an identifier. The name of a column may be qualified by the name of its table.
 type IdentExpr struct {
-	Name string
+	Table string
+	Name  string
 }
 
 // BinaryExpr node represents a binary expression.
@@ -158,11 +261,40 @@ type ScalarExpr struct {
 	Literal string
 }
 
+// CallExpr node represents a function call.
+type CallExpr struct {
+	Name     string
+	Args     []Expression
+	Distinct bool // arguments of an aggregate function are preceded by DISTINCT
+}
+
 // AsteriskExpr node represents asterisk at `SELECT *` expression.
 type AsteriskExpr struct{}
 
+// SubqueryExpr node represents a scalar subquery: a SELECT statement in parentheses used as a value.
+type SubqueryExpr struct {
+	Select *SelectStatement
+}
+
+// ExistsExpr node represents an EXISTS or a NOT EXISTS test of a subquery.
+type ExistsExpr struct {
+	Select *SelectStatement
+	Not    bool
+}
+
+// InExpr node represents an IN or a NOT IN test of the value against the results of a subquery.
+type InExpr struct {
+	Left   Expression
+	Select *SelectStatement
+	Not    bool
+}
+
 func (e *IdentExpr) expressionNode()    {}
 func (e *BinaryExpr) expressionNode()   {}
 func (e *UnaryExpr) expressionNode()    {}
 func (e *ScalarExpr) expressionNode()   {}
+func (e *CallExpr) expressionNode()     {}
 func (e *AsteriskExpr) expressionNode() {}
+func (e *SubqueryExpr) expressionNode() {}
+func (e *ExistsExpr) expressionNode()   {}
+func (e *InExpr) expressionNode()       {}
@@ -282,6 +282,146 @@ func TestLexer_NextToken(t *testing.T) {
 			tokenType: token.Null,
 			literal:   token.Null.String(),
 		},
+		{
+			input:     "BEGIN",
+			tokenType: token.Begin,
+			literal:   token.Begin.String(),
+		},
+		{
+			input:     "COMMIT",
+			tokenType: token.Commit,
+			literal:   token.Commit.String(),
+		},
+		{
+			input:     "ROLLBACK",
+			tokenType: token.Rollback,
+			literal:   token.Rollback.String(),
+		},
+		{
+			input:     "USING",
+			tokenType: token.Using,
+			literal:   token.Using.String(),
+		},
+		{
+			input:     "SEQUENCE",
+			tokenType: token.Sequence,
+			literal:   token.Sequence.String(),
+		},
+		{
+			input:     "START",
+			tokenType: token.Start,
+			literal:   token.Start.String(),
+		},
+		{
+			input:     "WITH",
+			tokenType: token.With,
+			literal:   token.With.String(),
+		},
+		{
+			input:     "INCREMENT",
+			tokenType: token.Increment,
+			literal:   token.Increment.String(),
+		},
+		{
+			input:     "GENERATED",
+			tokenType: token.Generated,
+			literal:   token.Generated.String(),
+		},
+		{
+			input:     "ALWAYS",
+			tokenType: token.Always,
+			literal:   token.Always.String(),
+		},
+		{
+			input:     "IDENTITY",
+			tokenType: token.Identity,
+			literal:   token.Identity.String(),
+		},
+		{
+			input:     "ANALYZE",
+			tokenType: token.Analyze,
+			literal:   token.Analyze.String(),
+		},
+		{
+			input:     "EXPLAIN",
+			tokenType: token.Explain,
+			literal:   token.Explain.String(),
+		},
+		{
+			input:     "FORMAT",
+			tokenType: token.Format,
+			literal:   token.Format.String(),
+		},
+		{
+			input:     "JSON",
+			tokenType: token.JSON,
+			literal:   token.JSON.String(),
+		},
+		{
+			input:     ".",
+			tokenType: token.Period,
+			literal:   token.Period.String(),
+		},
+		{
+			input:     "JOIN",
+			tokenType: token.Join,
+			literal:   token.Join.String(),
+		},
+		{
+			input:     "INNER",
+			tokenType: token.Inner,
+			literal:   token.Inner.String(),
+		},
+		{
+			input:     "LEFT",
+			tokenType: token.Left,
+			literal:   token.Left.String(),
+		},
+		{
+			input:     "RIGHT",
+			tokenType: token.Right,
+			literal:   token.Right.String(),
+		},
+		{
+			input:     "FULL",
+			tokenType: token.Full,
+			literal:   token.Full.String(),
+		},
+		{
+			input:     "OUTER",
+			tokenType: token.Outer,
+			literal:   token.Outer.String(),
+		},
+		{
+			input:     "CROSS",
+			tokenType: token.Cross,
+			literal:   token.Cross.String(),
+		},
+		{
+			input:     "GROUP",
+			tokenType: token.Group,
+			literal:   token.Group.String(),
+		},
+		{
+			input:     "HAVING",
+			tokenType: token.Having,
+			literal:   token.Having.String(),
+		},
+		{
+			input:     "DISTINCT",
+			tokenType: token.Distinct,
+			literal:   token.Distinct.String(),
+		},
+		{
+			input:     "IN",
+			tokenType: token.In,
+			literal:   token.In.String(),
+		},
+		{
+			input:     "EXISTS",
+			tokenType: token.Exists,
+			literal:   token.Exists.String(),
+		},
 	}
 
 	for _, test := range tests {
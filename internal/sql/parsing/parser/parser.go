@@ -58,6 +58,21 @@ func (p *Parser) parseStatement() (ast.Statement, error) {
 		return p.parseCreateStatement()
 	case token.Drop:
 		return p.parseDropStatement()
+	case token.Analyze:
+		p.nextToken()
+
+		return p.parseAnalyzeStatement()
+	case token.Explain:
+		p.nextToken()
+
+		return p.parseExplainStatement()
+	// TCL
+	case token.Begin:
+		return &ast.BeginStatement{}, nil
+	case token.Commit:
+		return &ast.CommitStatement{}, nil
+	case token.Rollback:
+		return &ast.RollbackStatement{}, nil
 	case token.EOF:
 		return nil, nil
 	default:
@@ -77,6 +92,22 @@ func (p *Parser) parseCreateStatement() (ast.Statement, error) {
 		p.nextToken()
 
 		return p.parseCreateTableStatement()
+	case token.Index:
+		p.nextToken()
+
+		return p.parseCreateIndexStatement(false)
+	case token.Unique:
+		p.nextToken()
+
+		if err := p.expect(token.Index); err != nil {
+			return nil, err
+		}
+
+		return p.parseCreateIndexStatement(true)
+	case token.Sequence:
+		p.nextToken()
+
+		return p.parseCreateSequenceStatement()
 	default:
 		return nil, fmt.Errorf("unexpected keyword after CREATE statement %q", p.token.Type)
 	}
@@ -94,6 +125,14 @@ func (p *Parser) parseDropStatement() (ast.Statement, error) {
 		p.nextToken()
 
 		return p.parseDropTableStatement()
+	case token.Index:
+		p.nextToken()
+
+		return p.parseDropIndexStatement()
+	case token.Sequence:
+		p.nextToken()
+
+		return p.parseDropSequenceStatement()
 	default:
 		return nil, fmt.Errorf("unexpected keyword after DROP statement %q", p.token.Type)
 	}
@@ -102,6 +141,11 @@ func (p *Parser) parseDropStatement() (ast.Statement, error) {
 func (p *Parser) parseSelectStatement() (ast.Statement, error) {
 	p.nextToken()
 
+	distinct, err := p.parseDistinctStatement()
+	if err != nil {
+		return nil, err
+	}
+
 	result, err := p.parseResultStatement()
 	if err != nil {
 		return nil, err
@@ -117,6 +161,16 @@ func (p *Parser) parseSelectStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
+	group, err := p.parseGroupByStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	having, err := p.parseHavingStatement()
+	if err != nil {
+		return nil, err
+	}
+
 	order, err := p.parseOrderByStatement()
 	if err != nil {
 		return nil, err
@@ -133,12 +187,15 @@ func (p *Parser) parseSelectStatement() (ast.Statement, error) {
 	}
 
 	selectStmt := ast.SelectStatement{
-		Result:  result,
-		From:    from,
-		Where:   where,
-		OrderBy: order,
-		Limit:   limit,
-		Offset:  offset,
+		Distinct: distinct,
+		Result:   result,
+		From:     from,
+		Where:    where,
+		GroupBy:  group,
+		Having:   having,
+		OrderBy:  order,
+		Limit:    limit,
+		Offset:   offset,
 	}
 
 	return &selectStmt, nil
@@ -233,8 +290,14 @@ func (p *Parser) parseCreateDatabaseStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
+	engine, err := p.parseUsing()
+	if err != nil {
+		return nil, err
+	}
+
 	create := ast.CreateDatabaseStatement{
 		Database: database.Name,
+		Engine:   engine,
 	}
 
 	return &create, nil
@@ -246,24 +309,144 @@ func (p *Parser) parseCreateTableStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
-	columns, err := p.parseColumnsDefinition()
+	columns, primaryKey, err := p.parseColumnsDefinition()
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := p.parseUsing()
 	if err != nil {
 		return nil, err
 	}
 
 	create := ast.CreateTableStatement{
+		Table:      table.Name,
+		Columns:    columns,
+		PrimaryKey: primaryKey,
+		Engine:     engine,
+	}
+
+	return &create, nil
+}
+
+// parseUsing parses the optional USING clause that names the storage engine.
+func (p *Parser) parseUsing() (string, error) {
+	if p.token.Type != token.Using {
+		return "", nil
+	}
+
+	p.nextToken()
+
+	engine, err := p.parseIdent()
+	if err != nil {
+		return "", fmt.Errorf("parse storage engine: %w", err)
+	}
+
+	return engine.Name, nil
+}
+
+func (p *Parser) parseCreateIndexStatement(unique bool) (ast.Statement, error) {
+	index, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.expect(token.On); err != nil {
+		return nil, err
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := p.parseColumnsStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("no columns specified")
+	}
+
+	create := ast.CreateIndexStatement{
+		Index:   index.Name,
 		Table:   table.Name,
 		Columns: columns,
+		Unique:  unique,
 	}
 
 	return &create, nil
 }
 
-func (p *Parser) parseColumnsDefinition() ([]ast.Column, error) {
-	if err := p.expect(token.OpenParen); err != nil {
+func (p *Parser) parseCreateSequenceStatement() (ast.Statement, error) {
+	sequence, err := p.parseIdent()
+	if err != nil {
 		return nil, err
 	}
 
+	options, err := p.parseSequenceOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	create := ast.CreateSequenceStatement{
+		Sequence: sequence.Name,
+		Options:  options,
+	}
+
+	return &create, nil
+}
+
+// parseSequenceOptions parses the optional START [WITH] value and INCREMENT [BY] value options in any order.
+func (p *Parser) parseSequenceOptions() (ast.SequenceOptions, error) {
+	var options ast.SequenceOptions
+
+	for {
+		var (
+			option   *ast.Expression
+			optional token.Type
+		)
+
+		switch p.token.Type {
+		case token.Start:
+			option, optional = &options.Start, token.With
+		case token.Increment:
+			option, optional = &options.Increment, token.By
+		default:
+			return options, nil
+		}
+
+		if *option != nil {
+			return ast.SequenceOptions{}, fmt.Errorf("conflicting or redundant option %s", p.token.Type)
+		}
+
+		p.nextToken()
+
+		if p.token.Type == optional {
+			p.nextToken()
+		}
+
+		value, err := p.parseExpr(token.LowestPrecedence)
+		if err != nil {
+			return ast.SequenceOptions{}, err
+		}
+
+		p.nextToken()
+
+		*option = value
+	}
+}
+
+// parseColumnsDefinition parses definitions of columns and the optional PRIMARY KEY table constraint
+// that may appear among them.
+func (p *Parser) parseColumnsDefinition() ([]ast.Column, []string, error) {
+	if err := p.expect(token.OpenParen); err != nil {
+		return nil, nil, err
+	}
+
+	var primaryKey []string
+
 	columns := make([]ast.Column, 0)
 
 	for p.token.Type != token.EOF && p.token.Type != token.CloseParen {
@@ -271,18 +454,50 @@ func (p *Parser) parseColumnsDefinition() ([]ast.Column, error) {
 			p.nextToken()
 		}
 
+		if p.token.Type == token.Primary {
+			if primaryKey != nil {
+				return nil, nil, errors.New("multiple primary keys are not allowed")
+			}
+
+			var err error
+
+			if primaryKey, err = p.parsePrimaryKeyConstraint(); err != nil {
+				return nil, nil, err
+			}
+
+			continue
+		}
+
 		column, err := p.parseColumnDefinition()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		columns = append(columns, column)
 	}
 
 	if err := p.expect(token.CloseParen); err != nil {
+		return nil, nil, err
+	}
+
+	return columns, primaryKey, nil
+}
+
+// parsePrimaryKeyConstraint parses PRIMARY KEY (column [, ...]).
+func (p *Parser) parsePrimaryKeyConstraint() ([]string, error) {
+	if _, err := p.parseColumnPrimaryKey(); err != nil {
 		return nil, err
 	}
 
+	columns, err := p.parseColumnsStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("no primary key columns specified")
+	}
+
 	return columns, nil
 }
 
@@ -307,6 +522,11 @@ func (p *Parser) parseColumnDefinition() (ast.Column, error) {
 		return ast.Column{}, err
 	}
 
+	identity, err := p.parseColumnIdentity()
+	if err != nil {
+		return ast.Column{}, err
+	}
+
 	pk, err := p.parseColumnPrimaryKey()
 	if err != nil {
 		return ast.Column{}, err
@@ -318,6 +538,7 @@ func (p *Parser) parseColumnDefinition() (ast.Column, error) {
 		Default:    defaultExpr,
 		Nullable:   nullable,
 		PrimaryKey: pk,
+		Identity:   identity,
 	}
 
 	return column, nil
@@ -372,6 +593,38 @@ func (p *Parser) parseColumnDefault() (ast.Expression, error) {
 	return expr, nil
 }
 
+// parseColumnIdentity parses GENERATED ALWAYS AS IDENTITY [ ( sequence options ) ].
+func (p *Parser) parseColumnIdentity() (*ast.SequenceOptions, error) {
+	if p.token.Type != token.Generated {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	for _, expected := range []token.Type{token.Always, token.As, token.Identity} {
+		if err := p.expect(expected); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.token.Type != token.OpenParen {
+		return &ast.SequenceOptions{}, nil
+	}
+
+	p.nextToken()
+
+	options, err := p.parseSequenceOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.expect(token.CloseParen); err != nil {
+		return nil, err
+	}
+
+	return &options, nil
+}
+
 func (p *Parser) parseColumnPrimaryKey() (bool, error) {
 	if p.token.Type != token.Primary {
 		return false, nil
@@ -383,8 +636,6 @@ func (p *Parser) parseColumnPrimaryKey() (bool, error) {
 		return false, err
 	}
 
-	p.nextToken()
-
 	return true, nil
 }
 
@@ -414,10 +665,127 @@ func (p *Parser) parseDropTableStatement() (ast.Statement, error) {
 	return &drop, nil
 }
 
+func (p *Parser) parseDropIndexStatement() (ast.Statement, error) {
+	index, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	drop := ast.DropIndexStatement{
+		Index: index.Name,
+	}
+
+	return &drop, nil
+}
+
+func (p *Parser) parseDropSequenceStatement() (ast.Statement, error) {
+	sequence, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	drop := ast.DropSequenceStatement{
+		Sequence: sequence.Name,
+	}
+
+	return &drop, nil
+}
+
+func (p *Parser) parseAnalyzeStatement() (ast.Statement, error) {
+	var analyze ast.AnalyzeStatement
+
+	if p.token.Type == token.EOF || p.token.Type == token.Semicolon {
+		return &analyze, nil
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	analyze.Table = table.Name
+
+	return &analyze, nil
+}
+
+func (p *Parser) parseExplainStatement() (ast.Statement, error) {
+	explain := ast.ExplainStatement{Format: token.Text}
+
+	if p.token.Type == token.Analyze {
+		explain.Analyze = true
+		p.nextToken()
+	}
+
+	if p.token.Type == token.Format {
+		p.nextToken()
+
+		switch p.token.Type {
+		case token.Text, token.JSON:
+			explain.Format = p.token.Type
+			p.nextToken()
+		default:
+			return nil, fmt.Errorf("unexpected EXPLAIN format %q", p.token.Literal)
+		}
+	}
+
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	explain.Statement = stmt
+
+	return &explain, nil
+}
+
+func (p *Parser) parseDistinctStatement() (*ast.DistinctStatement, error) {
+	if p.token.Type != token.Distinct {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	var distinct ast.DistinctStatement
+
+	if p.token.Type != token.On {
+		return &distinct, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.OpenParen); err != nil {
+		return nil, err
+	}
+
+	for {
+		expr, err := p.parseExpr(token.LowestPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		distinct.On = append(distinct.On, expr)
+
+		p.nextToken()
+
+		if p.token.Type != token.Comma {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	if err := p.expect(token.CloseParen); err != nil {
+		return nil, err
+	}
+
+	return &distinct, nil
+}
+
 func (p *Parser) parseResultStatement() ([]ast.ResultStatement, error) {
 	var results []ast.ResultStatement
 
-	for p.token.Type != token.EOF && p.token.Type != token.From {
+	// The closing parenthesis ends the results of a subquery without the FROM clause.
+	for p.token.Type != token.EOF && p.token.Type != token.From && p.token.Type != token.CloseParen {
 		result, err := p.parseResult()
 		if err != nil {
 			return nil, err
@@ -457,12 +825,17 @@ func (p *Parser) parseResult() (ast.ResultStatement, error) {
 	p.nextToken()
 	p.nextToken()
 
-	alias, err := p.parseIdent()
-	if err != nil {
-		return ast.ResultStatement{}, err
+	// The alias is left as the current token like the last token of an expression, so the closing parenthesis
+	// of a subquery after it isn't skipped.
+	if p.token.Type != token.Ident {
+		return ast.ResultStatement{}, fmt.Errorf("unexpected token %q", p.token.Type)
 	}
 
-	result.Alias = alias.Name
+	result.Alias = p.token.Literal
+
+	if p.peekToken.Type == token.Comma {
+		p.nextToken()
+	}
 
 	return result, nil
 }
@@ -474,18 +847,153 @@ func (p *Parser) parseFromStatement() (*ast.FromStatement, error) {
 
 	p.nextToken()
 
-	table, err := p.parseIdent()
+	table, subquery, alias, err := p.parseTableName()
 	if err != nil {
 		return nil, err
 	}
 
 	from := ast.FromStatement{
-		Table: table.Name,
+		Table:    table,
+		Subquery: subquery,
+		Alias:    alias,
+	}
+
+	for {
+		join, ok, err := p.parseJoinStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		from.Joins = append(from.Joins, join)
 	}
 
 	return &from, nil
 }
 
+// parseTableName parses a table of the FROM clause, the subquery of a derived table is returned instead of its name:
+//
+//	name [ [ AS ] alias ] | ( select ) [ AS ] alias
+func (p *Parser) parseTableName() (string, *ast.SelectStatement, string, error) {
+	if p.token.Type == token.OpenParen {
+		return p.parseDerivedTable()
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	switch p.token.Type {
+	case token.As:
+		p.nextToken()
+	case token.Ident:
+	default:
+		return table.Name, nil, "", nil
+	}
+
+	alias, err := p.parseIdent()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return table.Name, nil, alias.Name, nil
+}
+
+// parseDerivedTable parses a subquery of the FROM clause, it must have an alias.
+func (p *Parser) parseDerivedTable() (string, *ast.SelectStatement, string, error) {
+	subquery, err := p.parseSubquery()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	p.nextToken()
+
+	if p.token.Type == token.As {
+		p.nextToken()
+	}
+
+	if p.token.Type != token.Ident {
+		return "", nil, "", errors.New("subquery in FROM must have an alias")
+	}
+
+	alias, err := p.parseIdent()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return "", subquery, alias.Name, nil
+}
+
+// parseJoinStatement parses a table joined to the tables before it, false is returned if there is none:
+//
+//	, table | CROSS JOIN table | [ INNER ] JOIN table ON condition
+//	{ LEFT | RIGHT | FULL } [ OUTER ] JOIN table ON condition
+func (p *Parser) parseJoinStatement() (ast.JoinStatement, bool, error) {
+	var join ast.JoinStatement
+
+	switch p.token.Type {
+	case token.Comma:
+		join.Type = token.Cross
+		p.nextToken()
+	case token.Cross:
+		join.Type = token.Cross
+		p.nextToken()
+
+		if err := p.expect(token.Join); err != nil {
+			return ast.JoinStatement{}, false, err
+		}
+	case token.Join:
+		join.Type = token.Inner
+		p.nextToken()
+	case token.Inner:
+		join.Type = token.Inner
+		p.nextToken()
+
+		if err := p.expect(token.Join); err != nil {
+			return ast.JoinStatement{}, false, err
+		}
+	case token.Left, token.Right, token.Full:
+		join.Type = p.token.Type
+		p.nextToken()
+
+		if p.token.Type == token.Outer {
+			p.nextToken()
+		}
+
+		if err := p.expect(token.Join); err != nil {
+			return ast.JoinStatement{}, false, err
+		}
+	default:
+		return ast.JoinStatement{}, false, nil
+	}
+
+	var err error
+
+	if join.Table, join.Subquery, join.Alias, err = p.parseTableName(); err != nil {
+		return ast.JoinStatement{}, false, err
+	}
+
+	if join.Type == token.Cross {
+		return join, true, nil
+	}
+
+	if err = p.expect(token.On); err != nil {
+		return ast.JoinStatement{}, false, err
+	}
+
+	if join.On, err = p.parseExpr(token.LowestPrecedence); err != nil {
+		return ast.JoinStatement{}, false, err
+	}
+
+	p.nextToken()
+
+	return join, true, nil
+}
+
 func (p *Parser) parseWhereStatement() (*ast.WhereStatement, error) {
 	if p.token.Type != token.Where {
 		return nil, nil
@@ -507,6 +1015,58 @@ func (p *Parser) parseWhereStatement() (*ast.WhereStatement, error) {
 	return &where, nil
 }
 
+func (p *Parser) parseGroupByStatement() (*ast.GroupByStatement, error) {
+	if p.token.Type != token.Group {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.By); err != nil {
+		return nil, err
+	}
+
+	var group ast.GroupByStatement
+
+	for {
+		expr, err := p.parseExpr(token.LowestPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		group.Exprs = append(group.Exprs, expr)
+
+		p.nextToken()
+
+		if p.token.Type != token.Comma {
+			return &group, nil
+		}
+
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseHavingStatement() (*ast.HavingStatement, error) {
+	if p.token.Type != token.Having {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	expr, err := p.parsePrimaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	having := ast.HavingStatement{
+		Expr: expr,
+	}
+
+	return &having, nil
+}
+
 func (p *Parser) parseOrderByStatement() (*ast.OrderByStatement, error) {
 	if p.token.Type != token.Order {
 		return nil, nil
@@ -523,6 +1083,18 @@ func (p *Parser) parseOrderByStatement() (*ast.OrderByStatement, error) {
 		return nil, err
 	}
 
+	var table string
+
+	if p.token.Type == token.Period {
+		p.nextToken()
+
+		table = column.Name
+
+		if column, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	}
+
 	var direction token.Type
 
 	switch p.token.Type {
@@ -534,6 +1106,7 @@ func (p *Parser) parseOrderByStatement() (*ast.OrderByStatement, error) {
 	}
 
 	order := ast.OrderByStatement{
+		Table:     table,
 		Column:    column.Name,
 		Direction: direction,
 	}
@@ -702,7 +1275,12 @@ func (p *Parser) parseExpr(precedence int) (ast.Expression, error) {
 	for p.peekToken.Type != token.Comma && precedence < p.peekToken.Type.Precedence() {
 		p.nextToken()
 
-		expr, err = p.parseBinaryExpr(expr)
+		if p.token.Type == token.In || p.token.Type == token.Not {
+			expr, err = p.parseInExpr(expr)
+		} else {
+			expr, err = p.parseBinaryExpr(expr)
+		}
+
 		if err != nil {
 			return nil, err
 		}
@@ -714,6 +1292,13 @@ func (p *Parser) parseExpr(precedence int) (ast.Expression, error) {
 func (p *Parser) parseOperand() (ast.Expression, error) {
 	switch p.token.Type {
 	case token.Ident:
+		switch p.peekToken.Type {
+		case token.OpenParen:
+			return p.parseCallExpr()
+		case token.Period:
+			return p.parseQualifiedIdent()
+		}
+
 		return &ast.IdentExpr{Name: p.token.Literal}, nil
 	case token.Mul:
 		return &ast.AsteriskExpr{}, nil
@@ -722,7 +1307,13 @@ func (p *Parser) parseOperand() (ast.Expression, error) {
 	case token.Add, token.Sub:
 		return p.parseUnaryExpr()
 	case token.OpenParen:
+		if p.peekToken.Type == token.Select {
+			return p.parseSubqueryExpr()
+		}
+
 		return p.parseGroupExpr()
+	case token.Exists, token.Not:
+		return p.parseExistsExpr()
 	default:
 		return nil, fmt.Errorf("unexpected operand %q", p.token.Type)
 	}
@@ -742,6 +1333,25 @@ func (p *Parser) parseIdent() (*ast.IdentExpr, error) {
 	return &ident, nil
 }
 
+// parseQualifiedIdent parses the name of a column qualified by the name of its table: table . column.
+func (p *Parser) parseQualifiedIdent() (ast.Expression, error) {
+	table := p.token.Literal
+
+	p.nextToken()
+	p.nextToken()
+
+	if p.token.Type != token.Ident {
+		return nil, fmt.Errorf("expected column name after %q but found %q", table+".", p.token.Type)
+	}
+
+	ident := ast.IdentExpr{
+		Table: table,
+		Name:  p.token.Literal,
+	}
+
+	return &ident, nil
+}
+
 func (p *Parser) parseScalar(expected token.Type) (ast.Expression, error) {
 	if p.token.Type != expected {
 		return nil, fmt.Errorf("unexpected scalar type %q", p.token.Type)
@@ -813,6 +1423,130 @@ func (p *Parser) parseGroupExpr() (ast.Expression, error) {
 	return expr, nil
 }
 
+// parseSubquery parses a SELECT statement in parentheses. The current token is the opening parenthesis,
+// the closing one is left as the current token.
+func (p *Parser) parseSubquery() (*ast.SelectStatement, error) {
+	if err := p.expect(token.OpenParen); err != nil {
+		return nil, err
+	}
+
+	if p.token.Type != token.Select {
+		return nil, fmt.Errorf("expected %q but found %q", token.Select, p.token.Type)
+	}
+
+	stmt, err := p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.Type != token.CloseParen {
+		return nil, fmt.Errorf("expected %q but found %q", token.CloseParen, p.token.Type)
+	}
+
+	return stmt.(*ast.SelectStatement), nil
+}
+
+// parseSubqueryExpr parses a scalar subquery: ( select ).
+func (p *Parser) parseSubqueryExpr() (ast.Expression, error) {
+	subquery, err := p.parseSubquery()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.SubqueryExpr{Select: subquery}, nil
+}
+
+// parseExistsExpr parses a test whether a subquery returns any rows: [ NOT ] EXISTS ( select ).
+func (p *Parser) parseExistsExpr() (ast.Expression, error) {
+	var exists ast.ExistsExpr
+
+	if p.token.Type == token.Not {
+		exists.Not = true
+		p.nextToken()
+	}
+
+	if err := p.expect(token.Exists); err != nil {
+		return nil, err
+	}
+
+	subquery, err := p.parseSubquery()
+	if err != nil {
+		return nil, err
+	}
+
+	exists.Select = subquery
+
+	return &exists, nil
+}
+
+// parseInExpr parses a test whether the value is among results of a subquery: value [ NOT ] IN ( select ).
+func (p *Parser) parseInExpr(left ast.Expression) (ast.Expression, error) {
+	in := ast.InExpr{
+		Left: left,
+	}
+
+	if p.token.Type == token.Not {
+		in.Not = true
+		p.nextToken()
+	}
+
+	if err := p.expect(token.In); err != nil {
+		return nil, err
+	}
+
+	subquery, err := p.parseSubquery()
+	if err != nil {
+		return nil, err
+	}
+
+	in.Select = subquery
+
+	return &in, nil
+}
+
+// parseCallExpr parses a function call: name ( [ [DISTINCT] argument [, ...] ] ).
+func (p *Parser) parseCallExpr() (ast.Expression, error) {
+	call := ast.CallExpr{
+		Name: p.token.Literal,
+	}
+
+	p.nextToken()
+
+	if p.peekToken.Type == token.Distinct {
+		p.nextToken()
+
+		call.Distinct = true
+	}
+
+	if p.peekToken.Type == token.CloseParen && !call.Distinct {
+		p.nextToken()
+
+		return &call, nil
+	}
+
+	for {
+		p.nextToken()
+
+		arg, err := p.parseExpr(token.LowestPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		call.Args = append(call.Args, arg)
+
+		p.nextToken()
+
+		switch p.token.Type {
+		case token.Comma:
+			continue
+		case token.CloseParen:
+			return &call, nil
+		default:
+			return nil, fmt.Errorf("expected %q but found %q", token.CloseParen, p.token.Type)
+		}
+	}
+}
+
 func (p *Parser) expect(tokenType token.Type) error {
 	defer p.nextToken()
 
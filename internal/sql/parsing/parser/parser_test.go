@@ -528,6 +528,365 @@ func TestParser_Select(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "SELECT currval(), setval('s', 10 + 1) AS value, nextval('s') * 2",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.CallExpr{
+							Name: "currval",
+						},
+					},
+					{
+						Alias: "value",
+						Expr: &ast.CallExpr{
+							Name: "setval",
+							Args: []ast.Expression{
+								&ast.ScalarExpr{
+									Type:    token.Text,
+									Literal: "s",
+								},
+								&ast.BinaryExpr{
+									Left: &ast.ScalarExpr{
+										Type:    token.Integer,
+										Literal: "10",
+									},
+									Operator: token.Add,
+									Right: &ast.ScalarExpr{
+										Type:    token.Integer,
+										Literal: "1",
+									},
+								},
+							},
+						},
+					},
+					{
+						Expr: &ast.BinaryExpr{
+							Left: &ast.CallExpr{
+								Name: "nextval",
+								Args: []ast.Expression{
+									&ast.ScalarExpr{
+										Type:    token.Text,
+										Literal: "s",
+									},
+								},
+							},
+							Operator: token.Mul,
+							Right: &ast.ScalarExpr{
+								Type:    token.Integer,
+								Literal: "2",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT f.id, name FROM flights AS f JOIN seats s ON f.aircraft = s.aircraft WHERE s.id > 1 ORDER BY f.id",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Table: "f", Name: "id"}},
+					{Expr: &ast.IdentExpr{Name: "name"}},
+				},
+				From: &ast.FromStatement{
+					Table: "flights",
+					Alias: "f",
+					Joins: []ast.JoinStatement{
+						{
+							Type:  token.Inner,
+							Table: "seats",
+							Alias: "s",
+							On: &ast.BinaryExpr{
+								Left:     &ast.IdentExpr{Table: "f", Name: "aircraft"},
+								Operator: token.Equal,
+								Right:    &ast.IdentExpr{Table: "s", Name: "aircraft"},
+							},
+						},
+					},
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Table: "s", Name: "id"},
+						Operator: token.GreaterThan,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "1"},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{
+					Table:     "f",
+					Column:    "id",
+					Direction: token.Asc,
+				},
+			},
+		},
+		{
+			input: "SELECT * FROM a, b CROSS JOIN c INNER JOIN d ON true LEFT OUTER JOIN e ON true RIGHT JOIN f ON true " +
+				"FULL JOIN g ON true",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.AsteriskExpr{}},
+				},
+				From: &ast.FromStatement{
+					Table: "a",
+					Joins: []ast.JoinStatement{
+						{Type: token.Cross, Table: "b"},
+						{Type: token.Cross, Table: "c"},
+						{Type: token.Inner, Table: "d", On: &ast.ScalarExpr{Type: token.Boolean, Literal: "true"}},
+						{Type: token.Left, Table: "e", On: &ast.ScalarExpr{Type: token.Boolean, Literal: "true"}},
+						{Type: token.Right, Table: "f", On: &ast.ScalarExpr{Type: token.Boolean, Literal: "true"}},
+						{Type: token.Full, Table: "g", On: &ast.ScalarExpr{Type: token.Boolean, Literal: "true"}},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT model, COUNT(*), SUM(DISTINCT range) AS total FROM aircrafts WHERE range > 0 " +
+				"GROUP BY model, range / 2 HAVING MAX(range) > 100 ORDER BY model",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "model"}},
+					{Expr: &ast.CallExpr{Name: "COUNT", Args: []ast.Expression{&ast.AsteriskExpr{}}}},
+					{
+						Expr: &ast.CallExpr{
+							Name:     "SUM",
+							Args:     []ast.Expression{&ast.IdentExpr{Name: "range"}},
+							Distinct: true,
+						},
+						Alias: "total",
+					},
+				},
+				From: &ast.FromStatement{
+					Table: "aircrafts",
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Name: "range"},
+						Operator: token.GreaterThan,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "0"},
+					},
+				},
+				GroupBy: &ast.GroupByStatement{
+					Exprs: []ast.Expression{
+						&ast.IdentExpr{Name: "model"},
+						&ast.BinaryExpr{
+							Left:     &ast.IdentExpr{Name: "range"},
+							Operator: token.Div,
+							Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "2"},
+						},
+					},
+				},
+				Having: &ast.HavingStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.CallExpr{Name: "MAX", Args: []ast.Expression{&ast.IdentExpr{Name: "range"}}},
+						Operator: token.GreaterThan,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "100"},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{
+					Column:    "model",
+					Direction: token.Asc,
+				},
+			},
+		},
+		{
+			input: "SELECT DISTINCT model, range FROM aircrafts",
+			stmt: &ast.SelectStatement{
+				Distinct: &ast.DistinctStatement{},
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "model"}},
+					{Expr: &ast.IdentExpr{Name: "range"}},
+				},
+				From: &ast.FromStatement{
+					Table: "aircrafts",
+				},
+			},
+		},
+		{
+			input: "SELECT DISTINCT ON (model, range / 1000) id, model FROM aircrafts ORDER BY model",
+			stmt: &ast.SelectStatement{
+				Distinct: &ast.DistinctStatement{
+					On: []ast.Expression{
+						&ast.IdentExpr{Name: "model"},
+						&ast.BinaryExpr{
+							Left:     &ast.IdentExpr{Name: "range"},
+							Operator: token.Div,
+							Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "1000"},
+						},
+					},
+				},
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+					{Expr: &ast.IdentExpr{Name: "model"}},
+				},
+				From: &ast.FromStatement{
+					Table: "aircrafts",
+				},
+				OrderBy: &ast.OrderByStatement{
+					Column:    "model",
+					Direction: token.Asc,
+				},
+			},
+		},
+		{
+			input: "SELECT id, (SELECT max(range) FROM aircrafts) AS longest FROM flights WHERE range > (SELECT 1000)",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+					{
+						Alias: "longest",
+						Expr: &ast.SubqueryExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{
+										Expr: &ast.CallExpr{
+											Name: "max",
+											Args: []ast.Expression{&ast.IdentExpr{Name: "range"}},
+										},
+									},
+								},
+								From: &ast.FromStatement{Table: "aircrafts"},
+							},
+						},
+					},
+				},
+				From: &ast.FromStatement{
+					Table: "flights",
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Name: "range"},
+						Operator: token.GreaterThan,
+						Right: &ast.SubqueryExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.ScalarExpr{Type: token.Integer, Literal: "1000"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM aircrafts a WHERE EXISTS (SELECT * FROM flights f WHERE f.aircraft = a.id) " +
+				"AND NOT EXISTS (SELECT 1 FROM repairs) ORDER BY id",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+				},
+				From: &ast.FromStatement{
+					Table: "aircrafts",
+					Alias: "a",
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left: &ast.ExistsExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.AsteriskExpr{}},
+								},
+								From: &ast.FromStatement{
+									Table: "flights",
+									Alias: "f",
+								},
+								Where: &ast.WhereStatement{
+									Expr: &ast.BinaryExpr{
+										Left:     &ast.IdentExpr{Table: "f", Name: "aircraft"},
+										Operator: token.Equal,
+										Right:    &ast.IdentExpr{Table: "a", Name: "id"},
+									},
+								},
+							},
+						},
+						Operator: token.And,
+						Right: &ast.ExistsExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.ScalarExpr{Type: token.Integer, Literal: "1"}},
+								},
+								From: &ast.FromStatement{Table: "repairs"},
+							},
+							Not: true,
+						},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{
+					Column:    "id",
+					Direction: token.Asc,
+				},
+			},
+		},
+		{
+			input: "SELECT id FROM aircrafts WHERE id + 1 IN (SELECT aircraft FROM flights) AND id NOT IN (SELECT 2)",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+				},
+				From: &ast.FromStatement{
+					Table: "aircrafts",
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left: &ast.InExpr{
+							Left: &ast.BinaryExpr{
+								Left:     &ast.IdentExpr{Name: "id"},
+								Operator: token.Add,
+								Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "1"},
+							},
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.IdentExpr{Name: "aircraft"}},
+								},
+								From: &ast.FromStatement{Table: "flights"},
+							},
+						},
+						Operator: token.And,
+						Right: &ast.InExpr{
+							Left: &ast.IdentExpr{Name: "id"},
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.ScalarExpr{Type: token.Integer, Literal: "2"}},
+								},
+							},
+							Not: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT t.model FROM (SELECT model FROM aircrafts LIMIT 5) AS t JOIN (SELECT 1 AS n) n ON t.model = n.n",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Table: "t", Name: "model"}},
+				},
+				From: &ast.FromStatement{
+					Subquery: &ast.SelectStatement{
+						Result: []ast.ResultStatement{
+							{Expr: &ast.IdentExpr{Name: "model"}},
+						},
+						From:  &ast.FromStatement{Table: "aircrafts"},
+						Limit: &ast.LimitStatement{Value: &ast.ScalarExpr{Type: token.Integer, Literal: "5"}},
+					},
+					Alias: "t",
+					Joins: []ast.JoinStatement{
+						{
+							Type: token.Inner,
+							Subquery: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Alias: "n", Expr: &ast.ScalarExpr{Type: token.Integer, Literal: "1"}},
+								},
+							},
+							Alias: "n",
+							On: &ast.BinaryExpr{
+								Left:     &ast.IdentExpr{Table: "t", Name: "model"},
+								Operator: token.Equal,
+								Right:    &ast.IdentExpr{Table: "n", Name: "n"},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -560,6 +919,36 @@ func TestParser_Select(t *testing.T) {
 			"SELECT id FROM customers OFFSET",
 			"SELECT id FROM customers OFFSET abc",
 			"SELECT (10-2",
+			"SELECT nextval(",
+			"SELECT nextval('s'",
+			"SELECT nextval('s',)",
+			"SELECT nextval('s' 'b')",
+			"SELECT a. FROM a",
+			"SELECT id FROM a JOIN",
+			"SELECT id FROM a JOIN b",
+			"SELECT id FROM a JOIN b ON",
+			"SELECT id FROM a LEFT b ON true",
+			"SELECT id FROM a CROSS b",
+			"SELECT id FROM a AS 9",
+			"SELECT id FROM a ORDER BY a.",
+			"SELECT id FROM a GROUP id",
+			"SELECT id FROM a GROUP BY",
+			"SELECT id FROM a GROUP BY id,",
+			"SELECT id FROM a GROUP BY id HAVING",
+			"SELECT count(DISTINCT) FROM a",
+			"SELECT DISTINCT",
+			"SELECT DISTINCT ON model FROM a",
+			"SELECT DISTINCT ON () id FROM a",
+			"SELECT DISTINCT ON (model id FROM a",
+			"SELECT DISTINCT ON (model,) id FROM a",
+			"SELECT (SELECT id FROM a",
+			"SELECT id FROM a WHERE EXISTS SELECT 1",
+			"SELECT id FROM a WHERE EXISTS (1)",
+			"SELECT id FROM a WHERE NOT id",
+			"SELECT id FROM a WHERE id IN (1, 2)",
+			"SELECT id FROM a WHERE id NOT (SELECT 1)",
+			"SELECT id FROM (SELECT id FROM a)",
+			"SELECT id FROM (SELECT id FROM a) AS",
 		}
 
 		for _, input := range inputs {
@@ -857,16 +1246,34 @@ func TestParser_Create(t *testing.T) {
 			assert.Equal(t, expected, stmts)
 		})
 
-		t.Run("wrong query", func(t *testing.T) {
+		t.Run("with storage engine", func(t *testing.T) {
 			t.Parallel()
 
-			input := "CREATE DATABASE ^"
+			input := "CREATE DATABASE events USING lsm"
+			expected := &ast.CreateDatabaseStatement{
+				Database: "events",
+				Engine:   "lsm",
+			}
+
 			p := parser.New(lexer.New(input))
 			stmts, err := p.Parse()
 
-			assert.Nil(t, stmts)
-			require.Error(t, err)
+			require.NoError(t, err)
+			assert.Equal(t, expected, stmts)
+		})
+
+		t.Run("wrong query", func(t *testing.T) {
+			t.Parallel()
+
+			for _, input := range []string{"CREATE DATABASE ^", "CREATE DATABASE events USING", "CREATE DATABASE events USING 1"} {
+				p := parser.New(lexer.New(input))
+				stmts, err := p.Parse()
+
+				assert.Nil(t, stmts)
+				require.Error(t, err)
+			}
 		})
+
 	})
 
 	t.Run("create table", func(t *testing.T) {
@@ -940,48 +1347,319 @@ func TestParser_Create(t *testing.T) {
 					},
 				},
 			},
-		}
-
-		for _, test := range tests {
-			t.Run(test.input, func(t *testing.T) {
-				t.Parallel()
-
-				p := parser.New(lexer.New(test.input))
-				stmts, err := p.Parse()
-
-				require.NoError(t, err)
-				assert.Equal(t, test.stmt, stmts)
-			})
-		}
-
-		t.Run("returns error", func(t *testing.T) {
-			t.Parallel()
-
-			inputs := []string{
-				"CREATE",
-				"CREATE TABLE",
-				"CREATE TABLE 666",
-				"CREATE TABLE customers id INTEGER",
-				"CREATE TABLE customers (99 INTEGER)",
-				"CREATE TABLE customers (id",
-				"CREATE TABLE customers (id INT)",
-				"CREATE TABLE customers (id INT)",
-				"CREATE TABLE customers (id INTEGER",
-				"CREATE TABLE customers (id INTEGER PRIMARY)",
-				"CREATE TABLE customers (id INTEGER PRIMARY NULL)",
-				"CREATE TABLE customers (id INTEGER DEFAULT &)",
-				"CREATE TABLE customers (id INTEGER NOT)",
-				"CREATE TABLE customers (id INTEGER DEFAULT NOT)",
-				"CREATE TABLE customers (id INTEGER DEFAULT NOT KEY)",
-			}
-
-			for _, input := range inputs {
-				t.Run(input, func(t *testing.T) {
-					t.Parallel()
-
-					p := parser.New(lexer.New(input))
-					stmts, err := p.Parse()
-
+			{
+				input: "CREATE TABLE events (id INTEGER PRIMARY KEY) USING lsm",
+				stmt: &ast.CreateTableStatement{
+					Table: "events",
+					Columns: []ast.Column{
+						{
+							Name:       "id",
+							Type:       token.Integer,
+							PrimaryKey: true,
+						},
+					},
+					Engine: "lsm",
+				},
+			},
+			{
+				input: "CREATE TABLE accounts (tenant_id INTEGER, code TEXT, name TEXT NULL, PRIMARY KEY (tenant_id, code))",
+				stmt: &ast.CreateTableStatement{
+					Table: "accounts",
+					Columns: []ast.Column{
+						{
+							Name: "tenant_id",
+							Type: token.Integer,
+						},
+						{
+							Name: "code",
+							Type: token.Text,
+						},
+						{
+							Name:     "name",
+							Type:     token.Text,
+							Nullable: true,
+						},
+					},
+					PrimaryKey: []string{"tenant_id", "code"},
+				},
+			},
+			{
+				input: "CREATE TABLE orders (id INTEGER PRIMARY KEY, number INTEGER GENERATED ALWAYS AS IDENTITY, " +
+					"code INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY (START WITH 100 INCREMENT BY -1))",
+				stmt: &ast.CreateTableStatement{
+					Table: "orders",
+					Columns: []ast.Column{
+						{
+							Name:       "id",
+							Type:       token.Integer,
+							PrimaryKey: true,
+						},
+						{
+							Name:     "number",
+							Type:     token.Integer,
+							Identity: &ast.SequenceOptions{},
+						},
+						{
+							Name: "code",
+							Type: token.Integer,
+							Identity: &ast.SequenceOptions{
+								Start: &ast.ScalarExpr{
+									Type:    token.Integer,
+									Literal: "100",
+								},
+								Increment: &ast.UnaryExpr{
+									Operator: token.Sub,
+									Right: &ast.ScalarExpr{
+										Type:    token.Integer,
+										Literal: "1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				input: "CREATE TABLE orders (id INTEGER PRIMARY KEY, number INTEGER DEFAULT nextval('orders_seq'))",
+				stmt: &ast.CreateTableStatement{
+					Table: "orders",
+					Columns: []ast.Column{
+						{
+							Name:       "id",
+							Type:       token.Integer,
+							PrimaryKey: true,
+						},
+						{
+							Name: "number",
+							Type: token.Integer,
+							Default: &ast.CallExpr{
+								Name: "nextval",
+								Args: []ast.Expression{
+									&ast.ScalarExpr{
+										Type:    token.Text,
+										Literal: "orders_seq",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.input, func(t *testing.T) {
+				t.Parallel()
+
+				p := parser.New(lexer.New(test.input))
+				stmts, err := p.Parse()
+
+				require.NoError(t, err)
+				assert.Equal(t, test.stmt, stmts)
+			})
+		}
+
+		t.Run("returns error", func(t *testing.T) {
+			t.Parallel()
+
+			inputs := []string{
+				"CREATE",
+				"CREATE TABLE",
+				"CREATE TABLE 666",
+				"CREATE TABLE customers id INTEGER",
+				"CREATE TABLE customers (99 INTEGER)",
+				"CREATE TABLE customers (id",
+				"CREATE TABLE customers (id INT)",
+				"CREATE TABLE customers (id INT)",
+				"CREATE TABLE customers (id INTEGER",
+				"CREATE TABLE customers (id INTEGER PRIMARY)",
+				"CREATE TABLE customers (id INTEGER PRIMARY NULL)",
+				"CREATE TABLE customers (id INTEGER DEFAULT &)",
+				"CREATE TABLE customers (id INTEGER NOT)",
+				"CREATE TABLE customers (id INTEGER DEFAULT NOT)",
+				"CREATE TABLE customers (id INTEGER DEFAULT NOT KEY)",
+				"CREATE TABLE customers (id INTEGER) USING",
+				"CREATE TABLE customers (id INTEGER) USING 'lsm'",
+				"CREATE TABLE customers (id INTEGER, PRIMARY KEY)",
+				"CREATE TABLE customers (id INTEGER, PRIMARY KEY ())",
+				"CREATE TABLE customers (id INTEGER, PRIMARY KEY (id), PRIMARY KEY (id))",
+				"CREATE TABLE customers (id INTEGER GENERATED)",
+				"CREATE TABLE customers (id INTEGER GENERATED ALWAYS IDENTITY)",
+				"CREATE TABLE customers (id INTEGER GENERATED ALWAYS AS IDENTITY (START 1)",
+				"CREATE TABLE customers (id INTEGER GENERATED ALWAYS AS IDENTITY (MINVALUE 1))",
+				"CREATE TABLE customers (id INTEGER DEFAULT nextval('s'",
+			}
+
+			for _, input := range inputs {
+				t.Run(input, func(t *testing.T) {
+					t.Parallel()
+
+					p := parser.New(lexer.New(input))
+					stmts, err := p.Parse()
+
+					require.Error(t, err)
+					assert.Nil(t, stmts)
+				})
+			}
+		})
+	})
+
+	t.Run("create index", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("correct query", func(t *testing.T) {
+			t.Parallel()
+
+			tests := []struct {
+				input string
+				stmt  ast.Statement
+			}{
+				{
+					input: "CREATE INDEX customers_name ON customers (name)",
+					stmt: &ast.CreateIndexStatement{
+						Index:   "customers_name",
+						Table:   "customers",
+						Columns: []string{"name"},
+					},
+				},
+				{
+					input: "CREATE UNIQUE INDEX customers_email ON customers (email, name)",
+					stmt: &ast.CreateIndexStatement{
+						Index:   "customers_email",
+						Table:   "customers",
+						Columns: []string{"email", "name"},
+						Unique:  true,
+					},
+				},
+			}
+
+			for _, test := range tests {
+				t.Run(test.input, func(t *testing.T) {
+					t.Parallel()
+
+					p := parser.New(lexer.New(test.input))
+					stmt, err := p.Parse()
+
+					require.NoError(t, err)
+					assert.Equal(t, test.stmt, stmt)
+				})
+			}
+		})
+
+		t.Run("wrong query", func(t *testing.T) {
+			t.Parallel()
+
+			inputs := []string{
+				"CREATE INDEX",
+				"CREATE UNIQUE",
+				"CREATE UNIQUE TABLE customers",
+				"CREATE INDEX 1 ON customers (name)",
+				"CREATE INDEX customers_name customers (name)",
+				"CREATE INDEX customers_name ON (name)",
+				"CREATE INDEX customers_name ON customers",
+				"CREATE INDEX customers_name ON customers ()",
+				"CREATE INDEX customers_name ON customers (name",
+			}
+
+			for _, input := range inputs {
+				t.Run(input, func(t *testing.T) {
+					t.Parallel()
+
+					p := parser.New(lexer.New(input))
+					stmts, err := p.Parse()
+
+					require.Error(t, err)
+					assert.Nil(t, stmts)
+				})
+			}
+		})
+	})
+
+	t.Run("create sequence", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("correct query", func(t *testing.T) {
+			t.Parallel()
+
+			tests := []struct {
+				input string
+				stmt  ast.Statement
+			}{
+				{
+					input: "CREATE SEQUENCE orders_seq",
+					stmt: &ast.CreateSequenceStatement{
+						Sequence: "orders_seq",
+					},
+				},
+				{
+					input: "CREATE SEQUENCE orders_seq START 100 INCREMENT 10",
+					stmt: &ast.CreateSequenceStatement{
+						Sequence: "orders_seq",
+						Options: ast.SequenceOptions{
+							Start: &ast.ScalarExpr{
+								Type:    token.Integer,
+								Literal: "100",
+							},
+							Increment: &ast.ScalarExpr{
+								Type:    token.Integer,
+								Literal: "10",
+							},
+						},
+					},
+				},
+				{
+					input: "CREATE SEQUENCE countdown INCREMENT BY -1 START WITH 10",
+					stmt: &ast.CreateSequenceStatement{
+						Sequence: "countdown",
+						Options: ast.SequenceOptions{
+							Start: &ast.ScalarExpr{
+								Type:    token.Integer,
+								Literal: "10",
+							},
+							Increment: &ast.UnaryExpr{
+								Operator: token.Sub,
+								Right: &ast.ScalarExpr{
+									Type:    token.Integer,
+									Literal: "1",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			for _, test := range tests {
+				t.Run(test.input, func(t *testing.T) {
+					t.Parallel()
+
+					p := parser.New(lexer.New(test.input))
+					stmt, err := p.Parse()
+
+					require.NoError(t, err)
+					assert.Equal(t, test.stmt, stmt)
+				})
+			}
+		})
+
+		t.Run("wrong query", func(t *testing.T) {
+			t.Parallel()
+
+			inputs := []string{
+				"CREATE SEQUENCE",
+				"CREATE SEQUENCE 1",
+				"CREATE SEQUENCE orders_seq START",
+				"CREATE SEQUENCE orders_seq START WITH",
+				"CREATE SEQUENCE orders_seq INCREMENT BY",
+				"CREATE SEQUENCE orders_seq START 1 START 2",
+			}
+
+			for _, input := range inputs {
+				t.Run(input, func(t *testing.T) {
+					t.Parallel()
+
+					p := parser.New(lexer.New(input))
+					stmts, err := p.Parse()
+
 					require.Error(t, err)
 					assert.Nil(t, stmts)
 				})
@@ -1064,6 +1742,66 @@ func TestParser_Drop(t *testing.T) {
 		})
 	})
 
+	t.Run("drop index", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("correct query", func(t *testing.T) {
+			t.Parallel()
+
+			input := "DROP INDEX customers_name"
+			expected := &ast.DropIndexStatement{
+				Index: "customers_name",
+			}
+
+			p := parser.New(lexer.New(input))
+			stmts, err := p.Parse()
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, stmts)
+		})
+
+		t.Run("wrong index name", func(t *testing.T) {
+			t.Parallel()
+
+			input := "DROP INDEX 1"
+			p := parser.New(lexer.New(input))
+			stmts, err := p.Parse()
+
+			require.Error(t, err)
+			assert.Nil(t, stmts)
+		})
+	})
+
+	t.Run("drop sequence", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("correct query", func(t *testing.T) {
+			t.Parallel()
+
+			input := "DROP SEQUENCE orders_seq"
+			expected := &ast.DropSequenceStatement{
+				Sequence: "orders_seq",
+			}
+
+			p := parser.New(lexer.New(input))
+			stmts, err := p.Parse()
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, stmts)
+		})
+
+		t.Run("wrong sequence name", func(t *testing.T) {
+			t.Parallel()
+
+			input := "DROP SEQUENCE 1"
+			p := parser.New(lexer.New(input))
+			stmts, err := p.Parse()
+
+			require.Error(t, err)
+			assert.Nil(t, stmts)
+		})
+	})
+
 	t.Run("drop unexpected", func(t *testing.T) {
 		t.Parallel()
 
@@ -1076,6 +1814,160 @@ func TestParser_Drop(t *testing.T) {
 	})
 }
 
+func TestParser_Analyze(t *testing.T) {
+	t.Parallel()
+
+	t.Run("correct query", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			input string
+			stmt  ast.Statement
+		}{
+			{
+				input: "ANALYZE",
+				stmt:  &ast.AnalyzeStatement{},
+			},
+			{
+				input: "analyze;",
+				stmt:  &ast.AnalyzeStatement{},
+			},
+			{
+				input: "ANALYZE users",
+				stmt:  &ast.AnalyzeStatement{Table: "users"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.input, func(t *testing.T) {
+				t.Parallel()
+
+				p := parser.New(lexer.New(test.input))
+				stmts, err := p.Parse()
+				require.NoError(t, err)
+				assert.Equal(t, test.stmt, stmts)
+			})
+		}
+	})
+
+	t.Run("wrong table name", func(t *testing.T) {
+		t.Parallel()
+
+		p := parser.New(lexer.New("ANALYZE 1"))
+		stmts, err := p.Parse()
+
+		require.Error(t, err)
+		assert.Nil(t, stmts)
+	})
+}
+
+func TestParser_Explain(t *testing.T) {
+	t.Parallel()
+
+	selectStmt := &ast.SelectStatement{
+		Result: []ast.ResultStatement{
+			{
+				Expr: &ast.IdentExpr{
+					Name: "id",
+				},
+			},
+		},
+		From: &ast.FromStatement{
+			Table: "users",
+		},
+	}
+
+	deleteStmt := &ast.DeleteStatement{
+		Table: "users",
+	}
+
+	tests := []struct {
+		input    string
+		expected *ast.ExplainStatement
+	}{
+		{
+			input:    "EXPLAIN SELECT id FROM users",
+			expected: &ast.ExplainStatement{Statement: selectStmt, Format: token.Text},
+		},
+		{
+			input:    "EXPLAIN ANALYZE SELECT id FROM users",
+			expected: &ast.ExplainStatement{Statement: selectStmt, Analyze: true, Format: token.Text},
+		},
+		{
+			input:    "explain format json SELECT id FROM users",
+			expected: &ast.ExplainStatement{Statement: selectStmt, Format: token.JSON},
+		},
+		{
+			input:    "EXPLAIN ANALYZE FORMAT TEXT DELETE FROM users",
+			expected: &ast.ExplainStatement{Statement: deleteStmt, Analyze: true, Format: token.Text},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, stmts)
+		})
+	}
+
+	t.Run("wrong statement", func(t *testing.T) {
+		t.Parallel()
+
+		p := parser.New(lexer.New("EXPLAIN SELECT FROM"))
+		stmts, err := p.Parse()
+
+		require.Error(t, err)
+		assert.Nil(t, stmts)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		p := parser.New(lexer.New("EXPLAIN FORMAT XML SELECT id FROM users"))
+		stmts, err := p.Parse()
+
+		require.Error(t, err)
+		assert.Nil(t, stmts)
+	})
+}
+
+func TestParser_Transaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "BEGIN",
+			stmt:  &ast.BeginStatement{},
+		},
+		{
+			input: "commit",
+			stmt:  &ast.CommitStatement{},
+		},
+		{
+			input: "ROLLBACK",
+			stmt:  &ast.RollbackStatement{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := parser.New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			require.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
 func TestParser_Parse(t *testing.T) {
 	t.Parallel()
 
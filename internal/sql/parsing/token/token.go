@@ -24,6 +24,7 @@ const (
 
 	// Special chars
 	Comma      // ,
+	Period     // .
 	Semicolon  // ;
 	OpenParen  // (
 	CloseParen // )
@@ -80,6 +81,36 @@ const (
 	Default
 	Primary
 	Key
+	Index
+	Unique
+	On
+	Begin
+	Commit
+	Rollback
+	Using
+	Sequence
+	Start
+	With
+	Increment
+	Generated
+	Always
+	Identity
+	Analyze
+	Explain
+	Format
+	JSON
+	Join
+	Inner
+	Left
+	Right
+	Full
+	Outer
+	Cross
+	Group
+	Having
+	Distinct
+	In
+	Exists
 )
 
 var tokens = [...]string{
@@ -88,6 +119,7 @@ var tokens = [...]string{
 	Ident:   "Ident",
 
 	Comma:      ",",
+	Period:     ".",
 	Semicolon:  ";",
 	OpenParen:  "(",
 	CloseParen: ")",
@@ -139,6 +171,37 @@ var tokens = [...]string{
 	Default:  "DEFAULT",
 	Primary:  "PRIMARY",
 	Key:      "KEY",
+	Index:    "INDEX",
+	Unique:   "UNIQUE",
+	On:       "ON",
+	Begin:    "BEGIN",
+	Commit:   "COMMIT",
+	Rollback: "ROLLBACK",
+	Using:    "USING",
+
+	Sequence:  "SEQUENCE",
+	Start:     "START",
+	With:      "WITH",
+	Increment: "INCREMENT",
+	Generated: "GENERATED",
+	Always:    "ALWAYS",
+	Identity:  "IDENTITY",
+	Analyze:   "ANALYZE",
+	Explain:   "EXPLAIN",
+	Format:    "FORMAT",
+	JSON:      "JSON",
+	Join:      "JOIN",
+	Inner:     "INNER",
+	Left:      "LEFT",
+	Right:     "RIGHT",
+	Full:      "FULL",
+	Outer:     "OUTER",
+	Cross:     "CROSS",
+	Group:     "GROUP",
+	Having:    "HAVING",
+	Distinct:  "DISTINCT",
+	In:        "IN",
+	Exists:    "EXISTS",
 }
 
 // Text returns the string corresponding to the token t.
@@ -192,6 +255,37 @@ func Lookup(ident string) Type {
 		"DEFAULT":  Default,
 		"PRIMARY":  Primary,
 		"KEY":      Key,
+		"INDEX":    Index,
+		"UNIQUE":   Unique,
+		"ON":       On,
+		"BEGIN":    Begin,
+		"COMMIT":   Commit,
+		"ROLLBACK": Rollback,
+		"USING":    Using,
+
+		"SEQUENCE":  Sequence,
+		"START":     Start,
+		"WITH":      With,
+		"INCREMENT": Increment,
+		"GENERATED": Generated,
+		"ALWAYS":    Always,
+		"IDENTITY":  Identity,
+		"ANALYZE":   Analyze,
+		"EXPLAIN":   Explain,
+		"FORMAT":    Format,
+		"JSON":      JSON,
+		"JOIN":      Join,
+		"INNER":     Inner,
+		"LEFT":      Left,
+		"RIGHT":     Right,
+		"FULL":      Full,
+		"OUTER":     Outer,
+		"CROSS":     Cross,
+		"GROUP":     Group,
+		"HAVING":    Having,
+		"DISTINCT":  Distinct,
+		"IN":        In,
+		"EXISTS":    Exists,
 	}
 
 	if t, ok := keywords[strings.ToUpper(ident)]; ok {
@@ -224,7 +318,8 @@ func (t Type) Precedence() int {
 		return 1
 	case And:
 		return 2
-	case Equal, NotEqual, LessThan, LessThanOrEqual, GreaterThan, GreaterThanOrEqual:
+	case Equal, NotEqual, LessThan, LessThanOrEqual, GreaterThan, GreaterThanOrEqual, In, Not:
+		// NOT is a binary operator only as a part of NOT IN.
 		return 3
 	case Add, Sub:
 		return 4
@@ -0,0 +1,83 @@
+package optimizer
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// SimplifyBooleans removes constant operands of AND and OR from conditions of filters: x AND true is x,
+// x AND false is false, x OR true is true and x OR false is x. A filter whose condition is always true
+// is removed, and one whose condition is always false is replaced with no rows, so its child isn't read.
+func SimplifyBooleans(node plan.Node) (plan.Node, bool) {
+	filter, ok := node.(*plan.Filter)
+	if !ok {
+		return node, false
+	}
+
+	cond, changed := simplify(filter.Cond())
+
+	if value, ok := boolean(cond); ok {
+		if value {
+			return child(filter), true
+		}
+
+		return plan.NewEmptyRows(filter.Columns()), true
+	}
+
+	if changed {
+		return plan.NewFilter(cond, child(filter)), true
+	}
+
+	return node, false
+}
+
+func simplify(node expr.Node) (expr.Node, bool) {
+	var simplified bool
+
+	node = expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+		binary, ok := expr.AsBinary(node)
+		if !ok || (binary.Operator != expr.And && binary.Operator != expr.Or) {
+			return nil, false
+		}
+
+		// AND is absorbed by false and OR by true, the other value is the identity of the operator.
+		absorbing := binary.Operator == expr.Or
+
+		for _, operands := range [][2]expr.Node{{binary.Left, binary.Right}, {binary.Right, binary.Left}} {
+			value, ok := boolean(operands[0])
+			if !ok {
+				continue
+			}
+
+			simplified = true
+
+			if value == absorbing {
+				return operands[0], true
+			}
+
+			return operands[1], true
+		}
+
+		return nil, false
+	})
+
+	return node, simplified
+}
+
+// boolean returns the value of the boolean literal, false if the node is not one.
+func boolean(node expr.Node) (bool, bool) {
+	value, ok := expr.IsLiteral(node)
+	if !ok {
+		return false, false
+	}
+
+	b, ok := value.(datatype.Boolean)
+	if !ok {
+		return false, false
+	}
+
+	raw, ok := b.Raw().(bool)
+
+	return raw, ok
+}
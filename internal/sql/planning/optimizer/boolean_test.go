@@ -0,0 +1,104 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestSimplifyBooleans(t *testing.T) {
+	t.Parallel()
+
+	adult := binary(expr.GreaterThanOrEqual, age, integer(t, 18))
+	bob := binary(expr.Equal, id, integer(t, 1))
+
+	tests := []struct {
+		name     string
+		cond     expr.Node
+		expected func(child plan.Node) plan.Node
+	}{
+		{
+			name: "x AND true is x",
+			cond: binary(expr.And, adult, boolean(t, true)),
+			expected: func(child plan.Node) plan.Node {
+				return plan.NewFilter(adult, child)
+			},
+		},
+		{
+			name: "true AND x is x",
+			cond: binary(expr.And, boolean(t, true), adult),
+			expected: func(child plan.Node) plan.Node {
+				return plan.NewFilter(adult, child)
+			},
+		},
+		{
+			name: "x OR false is x",
+			cond: binary(expr.Or, adult, boolean(t, false)),
+			expected: func(child plan.Node) plan.Node {
+				return plan.NewFilter(adult, child)
+			},
+		},
+		{
+			name: "nested operands are simplified",
+			cond: binary(expr.Or, binary(expr.And, adult, boolean(t, true)), binary(expr.And, boolean(t, false), bob)),
+			expected: func(child plan.Node) plan.Node {
+				return plan.NewFilter(adult, child)
+			},
+		},
+		{
+			name: "x OR true removes the filter",
+			cond: binary(expr.Or, adult, boolean(t, true)),
+			expected: func(child plan.Node) plan.Node {
+				return child
+			},
+		},
+		{
+			name: "true removes the filter",
+			cond: boolean(t, true),
+			expected: func(child plan.Node) plan.Node {
+				return child
+			},
+		},
+		{
+			name: "x AND false returns no rows",
+			cond: binary(expr.And, adult, binary(expr.Or, boolean(t, false), boolean(t, false))),
+			expected: func(plan.Node) plan.Node {
+				return plan.NewEmptyRows([]string{"id", "name", "age"})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			child := plan.NewMockNode(ctrl)
+			child.EXPECT().Columns().Return([]string{"id", "name", "age"}).AnyTimes()
+
+			simplified, ok := optimizer.SimplifyBooleans(plan.NewFilter(test.cond, child))
+			assert.True(t, ok)
+			assert.Equal(t, test.expected(child), simplified)
+		})
+	}
+
+	t.Run("keeps conditions without boolean constants", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewFilter(binary(expr.And, adult, bob), plan.NewMockNode(ctrl))
+
+		simplified, ok := optimizer.SimplifyBooleans(node)
+		assert.False(t, ok)
+		assert.Same(t, node, simplified)
+	})
+}
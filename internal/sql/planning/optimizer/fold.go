@@ -0,0 +1,63 @@
+package optimizer
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// FoldConstants evaluates parts of conditions of filters and joins and of projections that don't depend on rows,
+// so they are evaluated once instead of once per row: 2 * 3 becomes 6. Parts whose evaluation fails are kept,
+// so the error is reported when the plan is run.
+func FoldConstants(node plan.Node) (plan.Node, bool) {
+	switch n := node.(type) {
+	case *plan.Filter:
+		if cond, ok := fold(n.Cond()); ok {
+			return plan.NewFilter(cond, child(n)), true
+		}
+	case *plan.NestedLoopJoin:
+		if n.Cond() == nil {
+			break
+		}
+
+		if cond, ok := fold(n.Cond()); ok {
+			children := n.Children()
+			return plan.NewNestedLoopJoin(n.Type(), cond, children[0], children[1]), true
+		}
+	case *plan.Project:
+		projections, ok := rewriteProjections(n.Projections(), func(projection plan.Projection) (plan.Projection, bool) {
+			var folded bool
+
+			projection.Expr, folded = fold(projection.Expr)
+
+			return projection, folded
+		})
+		if ok {
+			return plan.NewProject(projections, child(n)), true
+		}
+	}
+
+	return node, false
+}
+
+// fold replaces constant parts of the expression with their values, false is returned if there are none.
+func fold(node expr.Node) (expr.Node, bool) {
+	var folded bool
+
+	node = expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+		if _, ok := expr.IsLiteral(node); ok || !expr.IsConstant(node) {
+			return nil, false
+		}
+
+		value, err := node.Eval(nil)
+		if err != nil {
+			return nil, false
+		}
+
+		constant, ok := expr.NewConstant(value)
+		folded = folded || ok
+
+		return constant, ok
+	})
+
+	return node, folded
+}
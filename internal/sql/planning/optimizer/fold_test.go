@@ -0,0 +1,137 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestFoldConstants(t *testing.T) {
+	t.Parallel()
+
+	t.Run("folds the condition of the filter", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		node := plan.NewFilter(binary(expr.GreaterThan, age, binary(expr.Mul, integer(t, 2), integer(t, 3))), leaf)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.True(t, ok)
+		assert.Equal(t, plan.NewFilter(binary(expr.GreaterThan, age, integer(t, 6)), leaf), folded)
+	})
+
+	t.Run("folds the whole condition", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		node := plan.NewFilter(binary(expr.Equal, integer(t, 1), integer(t, 1)), leaf)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.True(t, ok)
+		assert.Equal(t, plan.NewFilter(boolean(t, true), leaf), folded)
+	})
+
+	t.Run("folds the condition of the join", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		left, right := plan.NewMockNode(ctrl), plan.NewMockNode(ctrl)
+		node := plan.NewNestedLoopJoin(
+			plan.LeftJoin,
+			binary(expr.GreaterThan, age, binary(expr.Mul, integer(t, 2), integer(t, 3))),
+			left,
+			right,
+		)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.True(t, ok)
+		expected := plan.NewNestedLoopJoin(plan.LeftJoin, binary(expr.GreaterThan, age, integer(t, 6)), left, right)
+		assert.Equal(t, expected, folded)
+
+		_, ok = optimizer.FoldConstants(plan.NewNestedLoopJoin(plan.CrossJoin, nil, left, right))
+		assert.False(t, ok)
+	})
+
+	t.Run("folds projections and keeps their names", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		node := plan.NewProject([]plan.Projection{
+			{Expr: binary(expr.Add, integer(t, 2), integer(t, 3))},
+			{Alias: "total", Expr: binary(expr.Mul, id, binary(expr.Sub, integer(t, 4), integer(t, 1)))},
+			{Expr: name},
+		}, leaf)
+
+		expected := plan.NewProject([]plan.Projection{
+			{Alias: "(2 + 3)", Expr: integer(t, 5)},
+			{Alias: "total", Expr: binary(expr.Mul, id, integer(t, 3))},
+			{Expr: name},
+		}, leaf)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.True(t, ok)
+		assert.Equal(t, expected, folded)
+		assert.Equal(t, []string{"(2 + 3)", "total", "name"}, folded.Columns())
+	})
+
+	t.Run("keeps expressions that fail", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewFilter(
+			binary(expr.Equal, id, binary(expr.Div, integer(t, 1), integer(t, 0))),
+			plan.NewMockNode(ctrl),
+		)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.False(t, ok)
+		assert.Same(t, node, folded)
+	})
+
+	t.Run("keeps sequence functions", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewProject(
+			[]plan.Projection{{Expr: binary(expr.Add, &expr.Nextval{}, integer(t, 1))}},
+			plan.NewMockNode(ctrl),
+		)
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.False(t, ok)
+		assert.Same(t, node, folded)
+	})
+
+	t.Run("skips other nodes", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewLimit(1, plan.NewMockNode(ctrl))
+
+		folded, ok := optimizer.FoldConstants(node)
+		assert.False(t, ok)
+		assert.Same(t, node, folded)
+	})
+}
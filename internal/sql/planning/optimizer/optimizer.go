@@ -0,0 +1,99 @@
+// Package optimizer rewrites plans built from statements before access paths of tables are chosen.
+// Rewrites keep results of plans: they only make plans cheaper to run.
+package optimizer
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// Rule rewrites a node of the plan, false is returned if the rule doesn't apply to the node.
+type Rule func(node plan.Node) (plan.Node, bool)
+
+// Rules are applied by Optimize in this order.
+var Rules = []Rule{
+	FoldConstants,
+	SimplifyBooleans,
+	PushDownFilters,
+	PruneColumns,
+	TopN,
+}
+
+// maxPasses bounds the number of passes over the plan. A rewrite often lets another rule apply to the parent
+// of the node, so passes are repeated until the plan stops changing.
+const maxPasses = 16
+
+// Optimize applies the rules to every node of the plan, to children before their parents,
+// until none of the rules changes the plan.
+func Optimize(node plan.Node) plan.Node {
+	for range maxPasses {
+		var changed bool
+
+		if node, changed = apply(node, Rules); !changed {
+			break
+		}
+	}
+
+	return node
+}
+
+func apply(node plan.Node, rules []Rule) (plan.Node, bool) {
+	var changed bool
+
+	if parent, ok := node.(plan.Parent); ok {
+		children := parent.Children()
+		rewritten := make([]plan.Node, len(children))
+
+		for i, child := range children {
+			var ok bool
+
+			rewritten[i], ok = apply(child, rules)
+			changed = changed || ok
+		}
+
+		if changed {
+			node = parent.WithChildren(rewritten...)
+		}
+	}
+
+	for _, rule := range rules {
+		if replaced, ok := rule(node); ok {
+			node, changed = replaced, true
+		}
+	}
+
+	return node, changed
+}
+
+// child returns the only child of the node.
+func child(node plan.Parent) plan.Node {
+	return node.Children()[0]
+}
+
+// rewriteProjections rewrites expressions of the projections, false is returned if none of them changes.
+// Rewritten projections keep their names.
+func rewriteProjections(
+	projections []plan.Projection,
+	rewrite func(plan.Projection) (plan.Projection, bool),
+) ([]plan.Projection, bool) {
+	var rewritten []plan.Projection
+
+	for i, projection := range projections {
+		replaced, ok := rewrite(projection)
+		if !ok {
+			continue
+		}
+
+		if rewritten == nil {
+			rewritten = make([]plan.Projection, len(projections))
+			copy(rewritten, projections)
+		}
+
+		if replaced.Alias == "" {
+			replaced.Alias = projection.Expr.String()
+		}
+
+		rewritten[i] = replaced
+	}
+
+	return rewritten, rewritten != nil
+}
@@ -0,0 +1,114 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+var (
+	id   = expr.Column{Name: "id", Position: 0}
+	name = expr.Column{Name: "name", Position: 1}
+	age  = expr.Column{Name: "age", Position: 2}
+)
+
+func literal(t *testing.T, value sql.Value) expr.Node {
+	t.Helper()
+
+	node, ok := expr.NewConstant(value)
+	require.True(t, ok)
+
+	return node
+}
+
+func integer(t *testing.T, value int64) expr.Node {
+	t.Helper()
+
+	return literal(t, datatype.NewInteger(value))
+}
+
+func boolean(t *testing.T, value bool) expr.Node {
+	t.Helper()
+
+	return literal(t, datatype.NewBoolean(value))
+}
+
+func binary(operator expr.BinaryOp, left, right expr.Node) *expr.Binary {
+	return &expr.Binary{Operator: operator, Left: left, Right: right}
+}
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applies rules until the plan stops changing", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+
+		// SELECT name FROM users WHERE 1 = 1 AND age > 2 * 3 ORDER BY age LIMIT 2
+		cond := binary(
+			expr.And,
+			binary(expr.Equal, integer(t, 1), integer(t, 1)),
+			binary(expr.GreaterThan, age, binary(expr.Mul, integer(t, 2), integer(t, 3))),
+		)
+
+		node := plan.NewLimit(2, plan.NewProject(
+			[]plan.Projection{{Expr: name}},
+			plan.NewSort(2, plan.Ascending, plan.NewFilter(cond, leaf)),
+		))
+
+		expected := plan.NewProject(
+			[]plan.Projection{{Expr: name}},
+			plan.NewTopN(2, 2, plan.Ascending, plan.NewFilter(binary(expr.GreaterThan, age, integer(t, 6)), leaf)),
+		)
+
+		assert.Equal(t, expected, optimizer.Optimize(node))
+	})
+
+	t.Run("moves the filter below the projection and the sort", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		projections := []plan.Projection{{Alias: "years", Expr: age}, {Expr: id}}
+
+		node := plan.NewFilter(
+			binary(expr.GreaterThan, expr.Column{Name: "years", Position: 0}, integer(t, 18)),
+			plan.NewProject(projections, plan.NewSort(0, plan.Descending, leaf)),
+		)
+
+		expected := plan.NewProject(
+			projections,
+			plan.NewSort(0, plan.Descending, plan.NewFilter(binary(expr.GreaterThan, age, integer(t, 18)), leaf)),
+		)
+
+		assert.Equal(t, expected, optimizer.Optimize(node))
+	})
+
+	t.Run("keeps the plan that can't be improved", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewProject(
+			[]plan.Projection{{Expr: id}},
+			plan.NewFilter(binary(expr.Equal, name, literal(t, datatype.NewText("bob"))), plan.NewMockNode(ctrl)),
+		)
+
+		assert.Same(t, node, optimizer.Optimize(node))
+	})
+}
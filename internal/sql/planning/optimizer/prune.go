@@ -0,0 +1,153 @@
+package optimizer
+
+import (
+	"slices"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// PruneColumns drops columns nobody uses. A projection over a projection drops the columns of the inner one
+// that the outer one doesn't refer to, unless their expressions have side effects. A scan of a table that stores
+// columns separately (sql.ColumnScanner) under a projection reads only the columns used by the projection
+// and the nodes between them.
+func PruneColumns(node plan.Node) (plan.Node, bool) {
+	project, ok := node.(*plan.Project)
+	if !ok {
+		return node, false
+	}
+
+	if pruned, ok := pruneProjection(project); ok {
+		return pruned, true
+	}
+
+	return pruneScan(project)
+}
+
+// pruneProjection drops columns of the projection under the project that it doesn't use.
+func pruneProjection(project *plan.Project) (plan.Node, bool) {
+	inner, ok := child(project).(*plan.Project)
+	if !ok {
+		return project, false
+	}
+
+	used := make(map[uint8]bool)
+
+	for _, projection := range project.Projections() {
+		for _, position := range expr.Columns(projection.Expr) {
+			used[position] = true
+		}
+	}
+
+	kept := make([]plan.Projection, 0, len(inner.Projections()))
+	positions := make(map[uint8]uint8)
+
+	for i, projection := range inner.Projections() {
+		if used[uint8(i)] || expr.IsVolatile(projection.Expr) {
+			positions[uint8(i)] = uint8(len(kept))
+			kept = append(kept, projection)
+		}
+	}
+
+	if len(kept) == len(inner.Projections()) {
+		return project, false
+	}
+
+	projections, _ := rewriteProjections(project.Projections(), func(projection plan.Projection) (plan.Projection, bool) {
+		var moved bool
+
+		projection.Expr = expr.Transform(projection.Expr, func(node expr.Node) (expr.Node, bool) {
+			column, ok := node.(expr.Column)
+			if !ok || positions[column.Position] == column.Position {
+				return nil, false
+			}
+
+			moved = true
+			column.Position = positions[column.Position]
+
+			return column, true
+		})
+
+		return projection, moved
+	})
+
+	if projections == nil {
+		projections = project.Projections()
+	}
+
+	return plan.NewProject(projections, plan.NewProject(kept, child(inner))), true
+}
+
+// pruneScan makes the scan under the project read only the columns the project and the nodes between them use.
+func pruneScan(project *plan.Project) (plan.Node, bool) {
+	used := make(map[uint8]struct{})
+
+	for _, projection := range project.Projections() {
+		addColumns(used, projection.Expr)
+	}
+
+	var (
+		path []plan.Parent
+		node = child(project)
+	)
+
+	for {
+		switch n := node.(type) {
+		case *plan.Filter:
+			addColumns(used, n.Cond())
+		case *plan.Sort:
+			used[n.Column()] = struct{}{}
+		case *plan.TopN:
+			used[n.Column()] = struct{}{}
+		case *plan.Distinct:
+			for _, key := range n.On() {
+				addColumns(used, key)
+			}
+		case *plan.Limit, *plan.Offset:
+		case *plan.Scan:
+			scan, ok := columnScan(n, used)
+			if !ok {
+				return project, false
+			}
+
+			for i := len(path) - 1; i >= 0; i-- {
+				scan = path[i].WithChildren(scan)
+			}
+
+			return project.WithChildren(scan), true
+		default:
+			return project, false
+		}
+
+		parent, _ := node.(plan.Parent)
+		path = append(path, parent)
+		node = child(parent)
+	}
+}
+
+// columnScan returns the scan of the used columns of the table, false if the table doesn't store columns
+// separately, the scan already reads a part of columns or all columns are used.
+func columnScan(scan *plan.Scan, used map[uint8]struct{}) (plan.Node, bool) {
+	table := scan.Table()
+
+	if _, ok := table.(sql.ColumnScanner); !ok || scan.ColumnPositions() != nil || len(used) >= len(table.Scheme()) {
+		return scan, false
+	}
+
+	positions := make([]uint8, 0, len(used))
+
+	for position := range used {
+		positions = append(positions, position)
+	}
+
+	slices.Sort(positions)
+
+	return plan.NewColumnScan(table, positions), true
+}
+
+func addColumns(used map[uint8]struct{}, node expr.Node) {
+	for _, position := range expr.Columns(node) {
+		used[position] = struct{}{}
+	}
+}
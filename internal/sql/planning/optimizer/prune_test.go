@@ -0,0 +1,128 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/sqltest"
+)
+
+func TestPruneColumns(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+		"age":  sql.Column{Position: 2, Name: "age", DataType: sql.Integer},
+		"city": sql.Column{Position: 3, Name: "city", DataType: sql.Text},
+	}
+
+	t.Run("drops unused columns of the inner projection", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		inner := []plan.Projection{{Expr: id}, {Expr: name}, {Alias: "n", Expr: &expr.Nextval{}}, {Expr: age}}
+		outer := []plan.Projection{{Expr: expr.Column{Name: "age", Position: 3}}}
+
+		expected := plan.NewProject(
+			[]plan.Projection{{Alias: "age", Expr: expr.Column{Name: "age", Position: 1}}},
+			plan.NewProject([]plan.Projection{{Alias: "n", Expr: &expr.Nextval{}}, {Expr: age}}, leaf),
+		)
+
+		pruned, ok := optimizer.PruneColumns(plan.NewProject(outer, plan.NewProject(inner, leaf)))
+		assert.True(t, ok)
+		assert.Equal(t, expected, pruned)
+	})
+
+	t.Run("reads only used columns", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sqltest.NewColumnTable(ctrl)
+
+		table.MockTable.EXPECT().Scheme().Return(scheme).AnyTimes()
+
+		projections := []plan.Projection{{Expr: name}}
+		cond := binary(expr.GreaterThan, age, integer(t, 18))
+
+		node := plan.NewProject(projections, plan.NewSort(0, plan.Ascending, plan.NewFilter(cond, plan.NewScan(table))))
+		expected := plan.NewProject(
+			projections,
+			plan.NewSort(0, plan.Ascending, plan.NewFilter(cond, plan.NewColumnScan(table, []uint8{0, 1, 2}))),
+		)
+
+		pruned, ok := optimizer.PruneColumns(node)
+		assert.True(t, ok)
+		assert.Equal(t, expected, pruned)
+
+		// The scan already reads only the columns it needs.
+		pruned, ok = optimizer.PruneColumns(pruned)
+		assert.False(t, ok)
+		assert.Equal(t, expected, pruned)
+	})
+
+	t.Run("reads columns of distinct on", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sqltest.NewColumnTable(ctrl)
+
+		table.MockTable.EXPECT().Scheme().Return(scheme).AnyTimes()
+
+		projections := []plan.Projection{{Expr: name}}
+		on := []expr.Node{age}
+
+		node := plan.NewProject(projections, plan.NewDistinct(on, plan.NewScan(table)))
+		expected := plan.NewProject(projections, plan.NewDistinct(on, plan.NewColumnScan(table, []uint8{1, 2})))
+
+		pruned, ok := optimizer.PruneColumns(node)
+		assert.True(t, ok)
+		assert.Equal(t, expected, pruned)
+	})
+
+	t.Run("reads all columns if all are used", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sqltest.NewColumnTable(ctrl)
+
+		table.MockTable.EXPECT().Scheme().Return(scheme).AnyTimes()
+
+		node := plan.NewProject(
+			[]plan.Projection{{Expr: id}, {Expr: name}, {Expr: age}, {Expr: expr.Column{Name: "city", Position: 3}}},
+			plan.NewScan(table),
+		)
+
+		pruned, ok := optimizer.PruneColumns(node)
+		assert.False(t, ok)
+		assert.Same(t, node, pruned)
+	})
+
+	t.Run("reads all columns of a table that stores rows", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewProject([]plan.Projection{{Expr: id}}, plan.NewScan(sql.NewMockTable(ctrl)))
+
+		pruned, ok := optimizer.PruneColumns(node)
+		assert.False(t, ok)
+		assert.Same(t, node, pruned)
+	})
+}
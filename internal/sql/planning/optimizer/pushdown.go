@@ -0,0 +1,207 @@
+package optimizer
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// PushDownFilters moves filters below projections and sorts, so rows are dropped before they are projected
+// or sorted and the filter gets next to the scan whose access path it may narrow down. The condition of a filter
+// moved below a projection refers to the expressions of the projected columns. Conditions of adjacent filters
+// are combined into one. Conditions of a filter over a join and of the join itself that refer to a single child
+// are moved to the child, unless that changes which rows of an outer, a semi or an anti join are returned; the rest
+// of the filter over an inner join becomes a part of its condition.
+func PushDownFilters(node plan.Node) (plan.Node, bool) {
+	if join, ok := node.(*plan.NestedLoopJoin); ok {
+		return pushDownJoinCondition(join)
+	}
+
+	filter, ok := node.(*plan.Filter)
+	if !ok {
+		return node, false
+	}
+
+	switch c := child(filter).(type) {
+	case *plan.NestedLoopJoin:
+		return pushDownIntoJoin(filter, c)
+	case *plan.Sort:
+		return c.WithChildren(filter.WithChildren(child(c))), true
+	case *plan.Project:
+		cond, ok := substitute(filter.Cond(), c.Projections())
+		if !ok {
+			return node, false
+		}
+
+		return c.WithChildren(plan.NewFilter(cond, child(c))), true
+	case *plan.Filter:
+		cond := &expr.Binary{
+			Operator: expr.And,
+			Left:     c.Cond(),
+			Right:    filter.Cond(),
+		}
+
+		return plan.NewFilter(cond, child(c)), true
+	default:
+		return node, false
+	}
+}
+
+// substitute replaces columns of the projection in the condition with their expressions. False is returned
+// if the condition refers to a column whose expression has side effects: it must not be evaluated more often.
+func substitute(cond expr.Node, projections []plan.Projection) (expr.Node, bool) {
+	for _, position := range expr.Columns(cond) {
+		if int(position) >= len(projections) || expr.IsVolatile(projections[position].Expr) {
+			return nil, false
+		}
+	}
+
+	cond = expr.Transform(cond, func(node expr.Node) (expr.Node, bool) {
+		if column, ok := node.(expr.Column); ok {
+			return projections[column.Position].Expr, true
+		}
+
+		return nil, false
+	})
+
+	return cond, true
+}
+
+// side is the child of a join a condition refers to.
+type side uint8
+
+const (
+	bothSides side = iota
+	leftSide
+	rightSide
+)
+
+// sideOf returns the child of the join whose columns the condition refers to. A condition that refers
+// to no columns belongs to the left child.
+func sideOf(cond expr.Node, leftWidth int) side {
+	left, right := false, false
+
+	for _, position := range expr.Columns(cond) {
+		if int(position) < leftWidth {
+			left = true
+		} else {
+			right = true
+		}
+	}
+
+	switch {
+	case left && right:
+		return bothSides
+	case right:
+		return rightSide
+	default:
+		return leftSide
+	}
+}
+
+// pushDownIntoJoin moves conditions of the filter over the join to its children. A condition over rows of a child
+// whose unmatched rows the join returns stays in the filter, since it drops rows the join pads with NULL. The rest
+// of the filter over an inner or a cross join is combined with the condition of the join. A semi or an anti join
+// returns rows of the left child as they are, so the whole filter is moved to it.
+func pushDownIntoJoin(filter *plan.Filter, join *plan.NestedLoopJoin) (plan.Node, bool) {
+	var (
+		children  = join.Children()
+		leftWidth = len(children[0].Columns())
+		inner     = join.Type() == plan.InnerJoin || join.Type() == plan.CrossJoin
+		leftOnly  = join.Type() == plan.SemiJoin || join.Type() == plan.AntiJoin
+		left      []expr.Node
+		right     []expr.Node
+		joined    []expr.Node
+		kept      []expr.Node
+	)
+
+	for _, cond := range expr.Conjuncts(filter.Cond()) {
+		switch s := sideOf(cond, leftWidth); {
+		case expr.IsVolatile(cond):
+			kept = append(kept, cond)
+		case s == leftSide && (inner || leftOnly || join.Type() == plan.LeftJoin):
+			left = append(left, cond)
+		case s == rightSide && (inner || join.Type() == plan.RightJoin):
+			right = append(right, expr.ShiftColumns(cond, -leftWidth))
+		case inner:
+			joined = append(joined, cond)
+		default:
+			kept = append(kept, cond)
+		}
+	}
+
+	if len(kept) == len(expr.Conjuncts(filter.Cond())) {
+		return filter, false
+	}
+
+	joinType, cond := join.Type(), join.Cond()
+
+	if len(joined) > 0 {
+		if cond != nil {
+			joined = append([]expr.Node{cond}, joined...)
+		}
+
+		joinType, cond = plan.InnerJoin, expr.Conjunction(joined...)
+	}
+
+	pushed := plan.NewNestedLoopJoin(joinType, cond, withFilter(children[0], left), withFilter(children[1], right))
+
+	return withFilter(pushed, kept), true
+}
+
+// pushDownJoinCondition moves conditions of the join that refer to a single child to the child. Only conditions
+// over rows of a child whose unmatched rows the join doesn't return can be moved: rows that don't satisfy them
+// would match no row anyway. Unmatched rows of the right child are never returned by a semi or an anti join,
+// and neither are unmatched rows of the left child by a semi join.
+func pushDownJoinCondition(join *plan.NestedLoopJoin) (plan.Node, bool) {
+	if join.Cond() == nil {
+		return join, false
+	}
+
+	var (
+		children  = join.Children()
+		leftWidth = len(children[0].Columns())
+		left      []expr.Node
+		right     []expr.Node
+		kept      []expr.Node
+	)
+
+	for _, cond := range expr.Conjuncts(join.Cond()) {
+		switch s := sideOf(cond, leftWidth); {
+		case expr.IsVolatile(cond) || expr.IsConstant(cond):
+			kept = append(kept, cond)
+		case s == leftSide && (join.Type() == plan.InnerJoin || join.Type() == plan.RightJoin ||
+			join.Type() == plan.SemiJoin):
+			left = append(left, cond)
+		case s == rightSide && (join.Type() == plan.InnerJoin || join.Type() == plan.LeftJoin ||
+			join.Type() == plan.SemiJoin || join.Type() == plan.AntiJoin):
+			right = append(right, expr.ShiftColumns(cond, -leftWidth))
+		default:
+			kept = append(kept, cond)
+		}
+	}
+
+	if len(left) == 0 && len(right) == 0 {
+		return join, false
+	}
+
+	joinType := join.Type()
+
+	// An inner join whose conditions are all moved to children returns all pairs of their rows.
+	if len(kept) == 0 && joinType == plan.InnerJoin {
+		joinType = plan.CrossJoin
+	}
+
+	cond := expr.Conjunction(kept...)
+	node := plan.NewNestedLoopJoin(joinType, cond, withFilter(children[0], left), withFilter(children[1], right))
+
+	return node, true
+}
+
+// withFilter returns the node under the filter of the conditions, the node itself if there are none.
+func withFilter(node plan.Node, conds []expr.Node) plan.Node {
+	if len(conds) == 0 {
+		return node
+	}
+
+	return plan.NewFilter(expr.Conjunction(conds...), node)
+}
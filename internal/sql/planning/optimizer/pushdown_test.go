@@ -0,0 +1,224 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestPushDownFilters(t *testing.T) {
+	t.Parallel()
+
+	adult := binary(expr.GreaterThanOrEqual, age, integer(t, 18))
+
+	t.Run("below the sort", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		node := plan.NewFilter(adult, plan.NewSort(1, plan.Ascending, leaf))
+
+		pushed, ok := optimizer.PushDownFilters(node)
+		assert.True(t, ok)
+		assert.Equal(t, plan.NewSort(1, plan.Ascending, plan.NewFilter(adult, leaf)), pushed)
+	})
+
+	t.Run("below the projection", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		projections := []plan.Projection{
+			{Expr: id},
+			{Alias: "next_year", Expr: binary(expr.Add, age, integer(t, 1))},
+		}
+
+		// WHERE next_year > 18 AND id = 1 over SELECT id, age + 1 AS next_year
+		cond := binary(
+			expr.And,
+			binary(expr.GreaterThan, expr.Column{Name: "next_year", Position: 1}, integer(t, 18)),
+			binary(expr.Equal, expr.Column{Name: "id", Position: 0}, integer(t, 1)),
+		)
+
+		expected := plan.NewProject(projections, plan.NewFilter(
+			binary(
+				expr.And,
+				binary(expr.GreaterThan, binary(expr.Add, age, integer(t, 1)), integer(t, 18)),
+				binary(expr.Equal, id, integer(t, 1)),
+			),
+			leaf,
+		))
+
+		pushed, ok := optimizer.PushDownFilters(plan.NewFilter(cond, plan.NewProject(projections, leaf)))
+		assert.True(t, ok)
+		assert.Equal(t, expected, pushed)
+	})
+
+	t.Run("not below the projection with sequence functions", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		projections := []plan.Projection{{Alias: "n", Expr: &expr.Nextval{}}}
+		node := plan.NewFilter(
+			binary(expr.GreaterThan, expr.Column{Name: "n", Position: 0}, integer(t, 1)),
+			plan.NewProject(projections, plan.NewMockNode(ctrl)),
+		)
+
+		pushed, ok := optimizer.PushDownFilters(node)
+		assert.False(t, ok)
+		assert.Same(t, node, pushed)
+	})
+
+	t.Run("merges adjacent filters", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		bob := binary(expr.Equal, id, integer(t, 1))
+
+		pushed, ok := optimizer.PushDownFilters(plan.NewFilter(adult, plan.NewFilter(bob, leaf)))
+		assert.True(t, ok)
+		assert.Equal(t, plan.NewFilter(binary(expr.And, bob, adult), leaf), pushed)
+	})
+
+	t.Run("stops at other nodes", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewFilter(adult, plan.NewLimit(1, plan.NewMockNode(ctrl)))
+
+		pushed, ok := optimizer.PushDownFilters(node)
+		assert.False(t, ok)
+		assert.Same(t, node, pushed)
+	})
+	t.Run("into children of joins", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// users (id, name) joined with posts (user_id, title)
+		users := plan.NewMockNode(ctrl)
+		users.EXPECT().Columns().Return([]string{"id", "name"}).AnyTimes()
+
+		posts := plan.NewMockNode(ctrl)
+
+		var (
+			userID    = expr.Column{Name: "user_id", Position: 2}
+			title     = expr.Column{Name: "title", Position: 3}
+			isAnn     = binary(expr.Equal, expr.Column{Name: "name", Position: 1}, literal(t, datatype.NewText("ann")))
+			hasTitle  = binary(expr.Equal, title, literal(t, datatype.NewText("x")))
+			ownTitle  = binary(expr.Equal, expr.Column{Name: "title", Position: 1}, literal(t, datatype.NewText("x")))
+			usersPost = binary(expr.Equal, id, userID)
+		)
+
+		tests := []struct {
+			name     string
+			node     plan.Node
+			expected plan.Node
+		}{
+			{
+				name: "filter over the cross join",
+				node: plan.NewFilter(
+					binary(expr.And, binary(expr.And, isAnn, usersPost), hasTitle),
+					plan.NewNestedLoopJoin(plan.CrossJoin, nil, users, posts),
+				),
+				expected: plan.NewNestedLoopJoin(
+					plan.InnerJoin,
+					usersPost,
+					plan.NewFilter(isAnn, users),
+					plan.NewFilter(ownTitle, posts),
+				),
+			},
+			{
+				name: "filter over the left join",
+				node: plan.NewFilter(
+					binary(expr.And, isAnn, hasTitle),
+					plan.NewNestedLoopJoin(plan.LeftJoin, usersPost, users, posts),
+				),
+				expected: plan.NewFilter(
+					hasTitle,
+					plan.NewNestedLoopJoin(plan.LeftJoin, usersPost, plan.NewFilter(isAnn, users), posts),
+				),
+			},
+			{
+				name: "condition of the left join",
+				node: plan.NewNestedLoopJoin(plan.LeftJoin, binary(expr.And, usersPost, hasTitle), users, posts),
+				expected: plan.NewNestedLoopJoin(
+					plan.LeftJoin,
+					usersPost,
+					users,
+					plan.NewFilter(ownTitle, posts),
+				),
+			},
+			{
+				name:     "condition of the inner join",
+				node:     plan.NewNestedLoopJoin(plan.InnerJoin, isAnn, users, posts),
+				expected: plan.NewNestedLoopJoin(plan.CrossJoin, nil, plan.NewFilter(isAnn, users), posts),
+			},
+			{
+				name: "condition of the semi join",
+				node: plan.NewNestedLoopJoin(
+					plan.SemiJoin,
+					binary(expr.And, binary(expr.And, isAnn, usersPost), hasTitle),
+					users,
+					posts,
+				),
+				expected: plan.NewNestedLoopJoin(
+					plan.SemiJoin,
+					usersPost,
+					plan.NewFilter(isAnn, users),
+					plan.NewFilter(ownTitle, posts),
+				),
+			},
+			{
+				name:     "filter over the anti join",
+				node:     plan.NewFilter(isAnn, plan.NewNestedLoopJoin(plan.AntiJoin, usersPost, users, posts)),
+				expected: plan.NewNestedLoopJoin(plan.AntiJoin, usersPost, plan.NewFilter(isAnn, users), posts),
+			},
+		}
+
+		for _, test := range tests {
+			pushed, ok := optimizer.PushDownFilters(test.node)
+			assert.True(t, ok, test.name)
+			assert.Equal(t, test.expected, pushed, test.name)
+		}
+	})
+
+	t.Run("keeps conditions that decide unmatched rows", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		users := plan.NewMockNode(ctrl)
+		users.EXPECT().Columns().Return([]string{"id", "name"}).AnyTimes()
+
+		isAnn := binary(expr.Equal, expr.Column{Name: "name", Position: 1}, literal(t, datatype.NewText("ann")))
+		join := plan.NewNestedLoopJoin(plan.LeftJoin, isAnn, users, plan.NewMockNode(ctrl))
+		anti := plan.NewNestedLoopJoin(plan.AntiJoin, isAnn, users, plan.NewMockNode(ctrl))
+		hasTitle := binary(expr.Equal, expr.Column{Name: "title", Position: 3}, literal(t, datatype.NewText("x")))
+
+		for _, node := range []plan.Node{join, plan.NewFilter(hasTitle, join), anti} {
+			pushed, ok := optimizer.PushDownFilters(node)
+			assert.False(t, ok)
+			assert.Same(t, node, pushed)
+		}
+	})
+}
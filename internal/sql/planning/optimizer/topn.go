@@ -0,0 +1,48 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// TopN replaces a limit over a sort with a top-N node that keeps only the rows it returns in memory instead
+// of sorting all rows. Offsets and projections between the limit and the sort are kept above the top-N node,
+// which returns the rows they skip as well, so the limit itself isn't needed anymore.
+func TopN(node plan.Node) (plan.Node, bool) {
+	limit, ok := node.(*plan.Limit)
+	if !ok {
+		return node, false
+	}
+
+	var (
+		path []plan.Parent
+		n    = limit.Value
+	)
+
+	for current := child(limit); ; {
+		switch c := current.(type) {
+		case *plan.Offset:
+			if n > math.MaxInt64-c.Offset {
+				return node, false
+			}
+
+			n += c.Offset
+		case *plan.Project:
+		case *plan.Sort:
+			var top plan.Node = plan.NewTopN(n, c.Column(), c.Order(), child(c))
+
+			for i := len(path) - 1; i >= 0; i-- {
+				top = path[i].WithChildren(top)
+			}
+
+			return top, true
+		default:
+			return node, false
+		}
+
+		parent, _ := current.(plan.Parent)
+		path = append(path, parent)
+		current = child(parent)
+	}
+}
@@ -0,0 +1,72 @@
+package optimizer_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestTopN(t *testing.T) {
+	t.Parallel()
+
+	t.Run("limit over sort", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+
+		top, ok := optimizer.TopN(plan.NewLimit(3, plan.NewSort(2, plan.Descending, leaf)))
+		assert.True(t, ok)
+		assert.Equal(t, plan.NewTopN(3, 2, plan.Descending, leaf), top)
+	})
+
+	t.Run("offsets and projections are kept", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		leaf := plan.NewMockNode(ctrl)
+		projections := []plan.Projection{{Expr: name}}
+
+		node := plan.NewLimit(3, plan.NewOffset(2, plan.NewProject(projections, plan.NewSort(1, plan.Ascending, leaf))))
+		expected := plan.NewOffset(2, plan.NewProject(projections, plan.NewTopN(5, 1, plan.Ascending, leaf)))
+
+		top, ok := optimizer.TopN(node)
+		assert.True(t, ok)
+		assert.Equal(t, expected, top)
+	})
+
+	t.Run("limit without sort", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewLimit(3, plan.NewOffset(2, plan.NewMockNode(ctrl)))
+
+		top, ok := optimizer.TopN(node)
+		assert.False(t, ok)
+		assert.Same(t, node, top)
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewLimit(math.MaxInt64, plan.NewOffset(1, plan.NewSort(0, plan.Ascending, plan.NewMockNode(ctrl))))
+
+		top, ok := optimizer.TopN(node)
+		assert.False(t, ok)
+		assert.Same(t, node, top)
+	})
+}
@@ -0,0 +1,402 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/math"
+)
+
+// aggregation is what nodes that aggregate groups of rows have in common. Rows of the child whose values
+// of the group expressions are not distinct form a group, NULL values are the same. A row of a group is the values
+// of its group expressions followed by results of the aggregates. Without group expressions all rows of the child
+// form a single group that is returned even if there are no rows.
+type aggregation struct {
+	groups     []expr.Node
+	aggregates []*expr.Aggregate
+	child      Node
+}
+
+func (a *aggregation) Columns() []string {
+	columns := make([]string, 0, len(a.groups)+len(a.aggregates))
+
+	for _, group := range a.groups {
+		columns = append(columns, group.String())
+	}
+
+	for _, aggregate := range a.aggregates {
+		columns = append(columns, aggregate.String())
+	}
+
+	return columns
+}
+
+// Groups returns the expressions rows are grouped by.
+func (a *aggregation) Groups() []expr.Node {
+	return a.groups
+}
+
+// Aggregates returns the aggregate functions computed for every group.
+func (a *aggregation) Aggregates() []*expr.Aggregate {
+	return a.aggregates
+}
+
+func (a *aggregation) Children() []Node {
+	return []Node{a.child}
+}
+
+// Expressions returns the group expressions followed by the aggregates.
+func (a *aggregation) Expressions() []expr.Node {
+	exprs := make([]expr.Node, 0, len(a.groups)+len(a.aggregates))
+	exprs = append(exprs, a.groups...)
+
+	for _, aggregate := range a.aggregates {
+		exprs = append(exprs, aggregate)
+	}
+
+	return exprs
+}
+
+// split returns the group expressions and the aggregates among the expressions in the order of Expressions.
+func (a *aggregation) split(exprs []expr.Node) ([]expr.Node, []*expr.Aggregate) {
+	aggregates := make([]*expr.Aggregate, 0, len(a.aggregates))
+
+	for _, node := range exprs[len(a.groups):] {
+		aggregates = append(aggregates, node.(*expr.Aggregate))
+	}
+
+	return exprs[:len(a.groups)], aggregates
+}
+
+// explain returns the description of the aggregation done by the method.
+func (a *aggregation) explain(method string) (string, []Node) {
+	description := "Aggregate"
+
+	if len(a.groups) > 0 {
+		groups := make([]string, len(a.groups))
+
+		for i, group := range a.groups {
+			groups[i] = group.String()
+		}
+
+		description = method + " Aggregate by " + strings.Join(groups, ", ")
+	}
+
+	if len(a.aggregates) > 0 {
+		aggregates := make([]string, len(a.aggregates))
+
+		for i, aggregate := range a.aggregates {
+			aggregates[i] = aggregate.String()
+		}
+
+		description += ": " + strings.Join(aggregates, ", ")
+	}
+
+	return description, []Node{a.child}
+}
+
+// groupValues returns values of the group expressions for the row.
+func (a *aggregation) groupValues(row sql.Row) ([]sql.Value, error) {
+	values := make([]sql.Value, len(a.groups))
+
+	for i, group := range a.groups {
+		value, err := group.Eval(row)
+		if err != nil {
+			return nil, fmt.Errorf("eval group expression %s: %w", group, err)
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// newGroup returns the group of the values with no rows added yet.
+func (a *aggregation) newGroup(values []sql.Value) (*group, error) {
+	accumulators := make([]accumulator, len(a.aggregates))
+
+	for i, aggregate := range a.aggregates {
+		acc, err := newAccumulator(aggregate)
+		if err != nil {
+			return nil, err
+		}
+
+		accumulators[i] = acc
+	}
+
+	g := &group{
+		values:       values,
+		aggregates:   a.aggregates,
+		accumulators: accumulators,
+	}
+
+	return g, nil
+}
+
+// group is a group of rows: values of its group expressions and accumulators of its aggregates.
+type group struct {
+	values       []sql.Value
+	aggregates   []*expr.Aggregate
+	accumulators []accumulator
+}
+
+// add adds values of arguments of the aggregates for the row to the group.
+func (g *group) add(row sql.Row) error {
+	for i, aggregate := range g.aggregates {
+		// count(*) counts rows, any value other than NULL stands for one.
+		var value sql.Value = datatype.NewBoolean(true)
+
+		if aggregate.Arg != nil {
+			var err error
+
+			if value, err = aggregate.Arg.Eval(row); err != nil {
+				return fmt.Errorf("eval argument of %s: %w", aggregate, err)
+			}
+		}
+
+		if err := g.accumulators[i].add(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// row returns the row of the group.
+func (g *group) row() sql.Row {
+	row := make(sql.Row, 0, len(g.values)+len(g.accumulators))
+	row = append(row, g.values...)
+
+	for _, acc := range g.accumulators {
+		row = append(row, acc.result())
+	}
+
+	return row
+}
+
+// notDistinct reports whether values of the tuples are pairwise not distinct, NULL is the same as NULL.
+func notDistinct(a, b []sql.Value) bool {
+	for i := range a {
+		if !datatype.NotDistinct(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valueSet is a set of tuples of values, tuples whose values are not distinct are the same.
+type valueSet map[string][][]sql.Value
+
+// add adds the tuple to the set, false is returned if the set has it already.
+func (s valueSet) add(values []sql.Value) bool {
+	key := datatype.HashKey(values...)
+
+	for _, existing := range s[key] {
+		if notDistinct(existing, values) {
+			return false
+		}
+	}
+
+	s[key] = append(s[key], values)
+
+	return true
+}
+
+// accumulator computes the result of an aggregate function over values of its argument for rows of a group.
+// NULL values are skipped by all aggregate functions.
+type accumulator interface {
+	add(value sql.Value) error
+	result() sql.Value
+}
+
+func newAccumulator(aggregate *expr.Aggregate) (accumulator, error) {
+	var acc accumulator
+
+	switch aggregate.Func {
+	case expr.CountFunc:
+		acc = &countAccumulator{}
+	case expr.SumFunc:
+		acc = &sumAccumulator{name: aggregate.Func}
+	case expr.AvgFunc:
+		acc = &avgAccumulator{sum: sumAccumulator{name: aggregate.Func}}
+	case expr.MinFunc:
+		acc = &extremeAccumulator{keep: sql.Less}
+	case expr.MaxFunc:
+		acc = &extremeAccumulator{keep: sql.Greater}
+	default:
+		return nil, fmt.Errorf("unknown aggregate function %q", aggregate.Func)
+	}
+
+	if aggregate.Distinct {
+		acc = &distinctAccumulator{accumulator: acc, seen: make(valueSet)}
+	}
+
+	return acc, nil
+}
+
+// countAccumulator counts values, it's 0 if there are none.
+type countAccumulator struct {
+	count int64
+}
+
+func (a *countAccumulator) add(value sql.Value) error {
+	if value.DataType() != sql.Null {
+		a.count++
+	}
+
+	return nil
+}
+
+func (a *countAccumulator) result() sql.Value {
+	return datatype.NewInteger(a.count)
+}
+
+// sumAccumulator sums numbers, it's NULL if there are none. The sum of integers is an integer unless it overflows.
+type sumAccumulator struct {
+	name string
+	sum  sql.Value // nil if there are no values
+}
+
+func (a *sumAccumulator) add(value sql.Value) error {
+	switch value.DataType() {
+	case sql.Null:
+		return nil
+	case sql.Integer, sql.Float:
+	default:
+		return fmt.Errorf("%s of %s values is not supported", a.name, value.DataType())
+	}
+
+	if a.sum == nil {
+		a.sum = value
+		return nil
+	}
+
+	left, lok := a.sum.Raw().(int64)
+	right, rok := value.Raw().(int64)
+
+	if lok && rok {
+		sum := left + right
+
+		if (right > 0 && sum < left) || (right < 0 && sum > left) {
+			return fmt.Errorf("%s: integer out of range", a.name)
+		}
+
+		a.sum = datatype.NewInteger(sum)
+
+		return nil
+	}
+
+	sum, err := math.Add(a.sum, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.name, err)
+	}
+
+	a.sum = sum
+
+	return nil
+}
+
+func (a *sumAccumulator) result() sql.Value {
+	if a.sum == nil {
+		return datatype.NewNull()
+	}
+
+	return a.sum
+}
+
+// avgAccumulator computes the mean of numbers as a float, it's NULL if there are none.
+type avgAccumulator struct {
+	sum   sumAccumulator
+	count int64
+}
+
+func (a *avgAccumulator) add(value sql.Value) error {
+	if value.DataType() == sql.Null {
+		return nil
+	}
+
+	if err := a.sum.add(value); err != nil {
+		return err
+	}
+
+	a.count++
+
+	return nil
+}
+
+func (a *avgAccumulator) result() sql.Value {
+	if a.count == 0 {
+		return datatype.NewNull()
+	}
+
+	var sum float64
+
+	switch raw := a.sum.result().Raw().(type) {
+	case int64:
+		sum = float64(raw)
+	case float64:
+		sum = raw
+	}
+
+	return datatype.NewFloat(sum / float64(a.count))
+}
+
+// extremeAccumulator keeps the value that compares with the kept one as keep: the least or the greatest one.
+// It's NULL if there are no values.
+type extremeAccumulator struct {
+	keep  sql.CompareType
+	value sql.Value // nil if there are no values
+}
+
+func (a *extremeAccumulator) add(value sql.Value) error {
+	if value.DataType() == sql.Null {
+		return nil
+	}
+
+	if a.value == nil {
+		a.value = value
+		return nil
+	}
+
+	c, err := comparison.Compare(value, a.value)
+	if err != nil {
+		return err
+	}
+
+	if c == a.keep {
+		a.value = value
+	}
+
+	return nil
+}
+
+func (a *extremeAccumulator) result() sql.Value {
+	if a.value == nil {
+		return datatype.NewNull()
+	}
+
+	return a.value
+}
+
+// distinctAccumulator passes every distinct value to the accumulator once.
+type distinctAccumulator struct {
+	accumulator
+	seen valueSet
+}
+
+func (a *distinctAccumulator) add(value sql.Value) error {
+	if value.DataType() == sql.Null {
+		return nil
+	}
+
+	if !a.seen.add([]sql.Value{value}) {
+		return nil
+	}
+
+	return a.accumulator.add(value)
+}
@@ -0,0 +1,125 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// aircrafts is the child of aggregates, rows are in the order of the model, NULL goes first.
+func aircrafts() plan.Node {
+	return table([]string{"model", "range", "weight"},
+		sql.Row{datatype.NewNull(), datatype.NewInteger(100), datatype.NewFloat(1)},
+		sql.Row{datatype.NewText("airbus"), datatype.NewInteger(3000), datatype.NewFloat(2.5)},
+		sql.Row{datatype.NewText("airbus"), datatype.NewNull(), datatype.NewFloat(3.5)},
+		sql.Row{datatype.NewText("airbus"), datatype.NewInteger(3000), datatype.NewNull()},
+		sql.Row{datatype.NewText("boeing"), datatype.NewInteger(5000), datatype.NewFloat(4)},
+	)
+}
+
+var (
+	model  = expr.Column{Name: "model", Position: 0}
+	rng    = expr.Column{Name: "range", Position: 1}
+	weight = expr.Column{Name: "weight", Position: 2}
+)
+
+func aggregate(function string, arg expr.Node, distinct bool) *expr.Aggregate {
+	return &expr.Aggregate{Func: function, Arg: arg, Distinct: distinct}
+}
+
+// aggregates covers every aggregate function and how it treats NULL.
+func aggregates() []*expr.Aggregate {
+	return []*expr.Aggregate{
+		aggregate(expr.CountFunc, nil, false),
+		aggregate(expr.CountFunc, rng, false),
+		aggregate(expr.CountFunc, rng, true),
+		aggregate(expr.SumFunc, rng, false),
+		aggregate(expr.SumFunc, weight, false),
+		aggregate(expr.AvgFunc, rng, false),
+		aggregate(expr.MinFunc, weight, false),
+		aggregate(expr.MaxFunc, model, false),
+	}
+}
+
+// aggregatedRows returns rows of aircrafts grouped by the model with results of aggregates.
+func aggregatedRows() []sql.Row {
+	return []sql.Row{
+		{
+			datatype.NewNull(),
+			datatype.NewInteger(1),
+			datatype.NewInteger(1),
+			datatype.NewInteger(1),
+			datatype.NewInteger(100),
+			datatype.NewFloat(1),
+			datatype.NewFloat(100),
+			datatype.NewFloat(1),
+			datatype.NewNull(),
+		},
+		{
+			datatype.NewText("airbus"),
+			datatype.NewInteger(3),
+			datatype.NewInteger(2),
+			datatype.NewInteger(1),
+			datatype.NewInteger(6000),
+			datatype.NewFloat(6),
+			datatype.NewFloat(3000),
+			datatype.NewFloat(2.5),
+			datatype.NewText("airbus"),
+		},
+		{
+			datatype.NewText("boeing"),
+			datatype.NewInteger(1),
+			datatype.NewInteger(1),
+			datatype.NewInteger(1),
+			datatype.NewInteger(5000),
+			datatype.NewFloat(4),
+			datatype.NewFloat(5000),
+			datatype.NewFloat(4),
+			datatype.NewText("boeing"),
+		},
+	}
+}
+
+func TestAggregate_Columns(t *testing.T) {
+	t.Parallel()
+
+	node := plan.NewHashAggregate([]expr.Node{model}, aggregates()[:3], aircrafts())
+	assert.Equal(t, []string{"model", "count(*)", "count(range)", "count(DISTINCT range)"}, node.Columns())
+}
+
+func TestAggregate_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, node := range []plan.Node{
+		plan.NewHashAggregate(nil, []*expr.Aggregate{aggregate(expr.SumFunc, model, false)}, aircrafts()),
+		plan.NewSortAggregate(nil, []*expr.Aggregate{aggregate(expr.AvgFunc, model, false)}, aircrafts()),
+		plan.NewHashAggregate(nil, []*expr.Aggregate{aggregate("median", rng, false)}, aircrafts()),
+	} {
+		iter, err := node.RowIter()
+		if err == nil {
+			_, err = iter.Next()
+		}
+
+		require.Error(t, err)
+	}
+
+	t.Run("sum of integers that overflows", func(t *testing.T) {
+		t.Parallel()
+
+		child := table([]string{"n"},
+			sql.Row{datatype.NewInteger(1 << 62)},
+			sql.Row{datatype.NewInteger(1 << 62)},
+		)
+
+		sum := aggregate(expr.SumFunc, expr.Column{Name: "n", Position: 0}, false)
+
+		_, err := plan.NewHashAggregate(nil, []*expr.Aggregate{sum}, child).RowIter()
+		require.ErrorContains(t, err, "out of range")
+	})
+}
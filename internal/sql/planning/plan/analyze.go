@@ -0,0 +1,60 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=analyze.go -destination ./analyze_mock_test.go -package plan_test
+
+type StatisticsSetter interface {
+	SetStatistics(table string, statistics sql.Statistics) error
+}
+
+// Analyze reads all rows of tables and replaces their statistics.
+type Analyze struct {
+	setter StatisticsSetter
+	tables []sql.Table
+}
+
+func NewAnalyze(setter StatisticsSetter, tables []sql.Table) *Analyze {
+	return &Analyze{
+		setter: setter,
+		tables: tables,
+	}
+}
+
+func (a *Analyze) Columns() []string {
+	return nil
+}
+
+func (a *Analyze) RowIter() (sql.RowIter, error) {
+	for _, table := range a.tables {
+		if err := a.analyze(table); err != nil {
+			return nil, fmt.Errorf("analyze table %q: %w", table.Name(), err)
+		}
+	}
+
+	return sql.RowsIter(), nil
+}
+
+func (a *Analyze) analyze(table sql.Table) error {
+	iter, err := table.Scan()
+	if err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+
+	stats, err := statistics.Collect(table.Scheme(), iter)
+
+	if closeErr := iter.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close iterator: %w", closeErr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return a.setter.SetStatistics(table.Name(), stats)
+}
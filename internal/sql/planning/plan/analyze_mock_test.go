@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: analyze.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=analyze.go -destination ./analyze_mock_test.go -package plan_test
+//
+
+// Package plan_test is a generated GoMock package.
+package plan_test
+
+import (
+	reflect "reflect"
+
+	sql "github.com/i-sevostyanov/NanoDB/internal/sql"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStatisticsSetter is a mock of StatisticsSetter interface.
+type MockStatisticsSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatisticsSetterMockRecorder
+}
+
+// MockStatisticsSetterMockRecorder is the mock recorder for MockStatisticsSetter.
+type MockStatisticsSetterMockRecorder struct {
+	mock *MockStatisticsSetter
+}
+
+// NewMockStatisticsSetter creates a new mock instance.
+func NewMockStatisticsSetter(ctrl *gomock.Controller) *MockStatisticsSetter {
+	mock := &MockStatisticsSetter{ctrl: ctrl}
+	mock.recorder = &MockStatisticsSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatisticsSetter) EXPECT() *MockStatisticsSetterMockRecorder {
+	return m.recorder
+}
+
+// SetStatistics mocks base method.
+func (m *MockStatisticsSetter) SetStatistics(table string, statistics sql.Statistics) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatistics", table, statistics)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatistics indicates an expected call of SetStatistics.
+func (mr *MockStatisticsSetterMockRecorder) SetStatistics(table, statistics any) *MockStatisticsSetterSetStatisticsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatistics", reflect.TypeOf((*MockStatisticsSetter)(nil).SetStatistics), table, statistics)
+	return &MockStatisticsSetterSetStatisticsCall{Call: call}
+}
+
+// MockStatisticsSetterSetStatisticsCall wrap *gomock.Call
+type MockStatisticsSetterSetStatisticsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStatisticsSetterSetStatisticsCall) Return(arg0 error) *MockStatisticsSetterSetStatisticsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStatisticsSetterSetStatisticsCall) Do(f func(string, sql.Statistics) error) *MockStatisticsSetterSetStatisticsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStatisticsSetterSetStatisticsCall) DoAndReturn(f func(string, sql.Statistics) error) *MockStatisticsSetterSetStatisticsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
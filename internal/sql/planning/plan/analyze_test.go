@@ -0,0 +1,140 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestAnalyze_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	setter := NewMockStatisticsSetter(ctrl)
+	analyzePlan := plan.NewAnalyze(setter, nil)
+	assert.Nil(t, analyzePlan.Columns())
+}
+
+func TestAnalyze_RowIter(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+			Nullable: true,
+		},
+	}
+
+	t.Run("sets statistics of every table", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		users := sql.NewMockTable(ctrl)
+		users.EXPECT().Name().Return("users").AnyTimes()
+		users.EXPECT().Scheme().Return(scheme)
+		users.EXPECT().Scan().Return(sql.RowsIter(
+			sql.Row{datatype.NewInteger(1), datatype.NewText("bob")},
+			sql.Row{datatype.NewInteger(2), datatype.NewNull()},
+		), nil)
+
+		orders := sql.NewMockTable(ctrl)
+		orders.EXPECT().Name().Return("orders").AnyTimes()
+		orders.EXPECT().Scheme().Return(scheme)
+		orders.EXPECT().Scan().Return(sql.RowsIter(), nil)
+
+		setter := NewMockStatisticsSetter(ctrl)
+		setter.EXPECT().SetStatistics("users", sql.Statistics{
+			Rows: 2,
+			Columns: map[string]sql.ColumnStatistics{
+				"id": {
+					Distinct:  2,
+					Histogram: []sql.Value{datatype.NewInteger(1), datatype.NewInteger(2)},
+				},
+				"name": {
+					NullFraction: 0.5,
+					Distinct:     1,
+					Histogram:    []sql.Value{datatype.NewText("bob")},
+				},
+			},
+		}).Return(nil)
+		setter.EXPECT().SetStatistics("orders", sql.Statistics{
+			Columns: map[string]sql.ColumnStatistics{"id": {}, "name": {}},
+		}).Return(nil)
+
+		analyzePlan := plan.NewAnalyze(setter, []sql.Table{users, orders})
+		iter, err := analyzePlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("closes iterator on error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		rows := sql.NewMockRowIter(ctrl)
+		rows.EXPECT().Next().Return(nil, expectedErr)
+		rows.EXPECT().Close().Return(nil)
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Name().Return("users").AnyTimes()
+		table.EXPECT().Scheme().Return(scheme)
+		table.EXPECT().Scan().Return(rows, nil)
+
+		setter := NewMockStatisticsSetter(ctrl)
+		analyzePlan := plan.NewAnalyze(setter, []sql.Table{table})
+		iter, err := analyzePlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+
+	t.Run("returns error on set statistics", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Name().Return("users").AnyTimes()
+		table.EXPECT().Scheme().Return(scheme)
+		table.EXPECT().Scan().Return(sql.RowsIter(), nil)
+
+		setter := NewMockStatisticsSetter(ctrl)
+		setter.EXPECT().SetStatistics("users", gomock.Any()).Return(expectedErr)
+
+		analyzePlan := plan.NewAnalyze(setter, []sql.Table{table})
+		iter, err := analyzePlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
@@ -11,20 +11,21 @@ import (
 //go:generate go run go.uber.org/mock/mockgen -typed -source=delete.go -destination ./delete_mock_test.go -package plan_test
 
 type RowDeleter interface {
-	Delete(key int64) error
+	Delete(key sql.Key) error
 }
 
 type Delete struct {
-	deleter RowDeleter
-	child   Node
-	pkIndex uint8
+	deleter    RowDeleter
+	child      Node
+	primaryKey []uint8
 }
 
-func NewDelete(deleter RowDeleter, pkIndex uint8, child Node) *Delete {
+// NewDelete returns the node that deletes rows of the child, primaryKey is positions of the primary key columns.
+func NewDelete(deleter RowDeleter, primaryKey []uint8, child Node) *Delete {
 	return &Delete{
-		deleter: deleter,
-		child:   child,
-		pkIndex: pkIndex,
+		deleter:    deleter,
+		child:      child,
+		primaryKey: primaryKey,
 	}
 }
 
@@ -39,38 +40,68 @@ func (d *Delete) RowIter() (sql.RowIter, error) {
 	}
 
 	iter = &deleteIter{
-		iter:    iter,
-		deleter: d.deleter,
-		pkIndex: d.pkIndex,
+		iter:       iter,
+		deleter:    d.deleter,
+		primaryKey: d.primaryKey,
 	}
 
 	return iter, nil
 }
 
+func (d *Delete) Children() []Node {
+	return []Node{d.child}
+}
+
+func (d *Delete) WithChildren(children ...Node) Node {
+	return NewDelete(d.deleter, d.primaryKey, children[0])
+}
+
+func (d *Delete) Explain() (string, []Node) {
+	return "Delete" + target(d.deleter), []Node{d.child}
+}
+
 type deleteIter struct {
-	iter    sql.RowIter
-	deleter RowDeleter
-	pkIndex uint8
+	iter       sql.RowIter
+	deleter    RowDeleter
+	primaryKey []uint8
 }
 
+// Next deletes all rows of the child in one go. The keys are collected before the first row is deleted,
+// so the child doesn't read the table while it's changed. The statement runs in a transaction,
+// which discards the deleted rows if any deletion fails.
 func (i *deleteIter) Next() (sql.Row, error) {
+	keys, err := i.collect()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if err = i.deleter.Delete(key); err != nil {
+			return nil, fmt.Errorf("delete row: %w", err)
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (i *deleteIter) collect() ([]sql.Key, error) {
+	var keys []sql.Key
+
 	for {
 		row, err := i.iter.Next()
 		switch {
 		case errors.Is(err, io.EOF):
-			return nil, err
+			return keys, nil
 		case err != nil:
 			return nil, fmt.Errorf("get next row: %w", err)
 		}
 
-		key, ok := row[i.pkIndex].Raw().(int64)
-		if !ok {
-			return nil, fmt.Errorf("unsupported key type: %T", key)
+		key, err := sql.RowKey(row, i.primaryKey)
+		if err != nil {
+			return nil, fmt.Errorf("get row key: %w", err)
 		}
 
-		if err = i.deleter.Delete(key); err != nil {
-			return nil, fmt.Errorf("delete row: %w", err)
-		}
+		keys = append(keys, key)
 	}
 }
 
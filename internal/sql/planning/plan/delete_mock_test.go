@@ -12,6 +12,7 @@ package plan_test
 import (
 	reflect "reflect"
 
+	sql "github.com/i-sevostyanov/NanoDB/internal/sql"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -39,7 +40,7 @@ func (m *MockRowDeleter) EXPECT() *MockRowDeleterMockRecorder {
 }
 
 // Delete mocks base method.
-func (m *MockRowDeleter) Delete(key int64) error {
+func (m *MockRowDeleter) Delete(key sql.Key) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Delete", key)
 	ret0, _ := ret[0].(error)
@@ -65,13 +66,13 @@ func (c *MockRowDeleterDeleteCall) Return(arg0 error) *MockRowDeleterDeleteCall
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockRowDeleterDeleteCall) Do(f func(int64) error) *MockRowDeleterDeleteCall {
+func (c *MockRowDeleterDeleteCall) Do(f func(sql.Key) error) *MockRowDeleterDeleteCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockRowDeleterDeleteCall) DoAndReturn(f func(int64) error) *MockRowDeleterDeleteCall {
+func (c *MockRowDeleterDeleteCall) DoAndReturn(f func(sql.Key) error) *MockRowDeleterDeleteCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -23,7 +23,7 @@ func TestDelete_Columns(t *testing.T) {
 	child := plan.NewMockNode(ctrl)
 	deleter := NewMockRowDeleter(ctrl)
 
-	deletePlan := plan.NewDelete(deleter, uint8(0), child)
+	deletePlan := plan.NewDelete(deleter, []uint8{0}, child)
 	assert.Nil(t, deletePlan.Columns())
 }
 
@@ -36,7 +36,7 @@ func TestDelete_RowIter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		rows := []sql.Row{
 			{datatype.NewInteger(1), datatype.NewText("Max")},
 			{datatype.NewInteger(2), datatype.NewText("Vlad")},
@@ -51,19 +51,18 @@ func TestDelete_RowIter(t *testing.T) {
 			child.EXPECT().RowIter().Return(rowIter, nil),
 
 			rowIter.EXPECT().Next().Return(rows[0], nil),
-			deleter.EXPECT().Delete(int64(1)).Return(nil),
-
 			rowIter.EXPECT().Next().Return(rows[1], nil),
-			deleter.EXPECT().Delete(int64(2)).Return(nil),
-
 			rowIter.EXPECT().Next().Return(rows[2], nil),
-			deleter.EXPECT().Delete(int64(3)).Return(nil),
-
 			rowIter.EXPECT().Next().Return(nil, io.EOF),
+
+			deleter.EXPECT().Delete(sql.IntegerKey(1)).Return(nil),
+			deleter.EXPECT().Delete(sql.IntegerKey(2)).Return(nil),
+			deleter.EXPECT().Delete(sql.IntegerKey(3)).Return(nil),
+
 			rowIter.EXPECT().Close().Return(nil),
 		)
 
-		deletePlan := plan.NewDelete(deleter, pkIndex, child)
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
 		iter, err := deletePlan.RowIter()
 		require.NoError(t, err)
 
@@ -81,7 +80,7 @@ func TestDelete_RowIter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 
 		child := plan.NewMockNode(ctrl)
@@ -89,7 +88,7 @@ func TestDelete_RowIter(t *testing.T) {
 
 		child.EXPECT().RowIter().Return(nil, expectedErr)
 
-		deletePlan := plan.NewDelete(deleter, pkIndex, child)
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
 		iter, err := deletePlan.RowIter()
 		require.Error(t, err)
 		assert.Nil(t, iter)
@@ -101,7 +100,7 @@ func TestDelete_RowIter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 
 		child := plan.NewMockNode(ctrl)
@@ -114,7 +113,7 @@ func TestDelete_RowIter(t *testing.T) {
 			rowIter.EXPECT().Close().Return(nil),
 		)
 
-		deletePlan := plan.NewDelete(deleter, pkIndex, child)
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
 		iter, err := deletePlan.RowIter()
 		require.NoError(t, err)
 
@@ -132,7 +131,7 @@ func TestDelete_RowIter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 
 		row := sql.Row{
@@ -147,11 +146,12 @@ func TestDelete_RowIter(t *testing.T) {
 		gomock.InOrder(
 			child.EXPECT().RowIter().Return(rowIter, nil),
 			rowIter.EXPECT().Next().Return(row, nil),
-			deleter.EXPECT().Delete(int64(1)).Return(expectedErr),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+			deleter.EXPECT().Delete(sql.IntegerKey(1)).Return(expectedErr),
 			rowIter.EXPECT().Close().Return(nil),
 		)
 
-		deletePlan := plan.NewDelete(deleter, pkIndex, child)
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
 		iter, err := deletePlan.RowIter()
 		require.NoError(t, err)
 
@@ -163,15 +163,54 @@ func TestDelete_RowIter(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("stops on the first failed deletion", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		primaryKey := []uint8{0}
+		expectedErr := errors.New("something went wrong")
+		rows := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Max")},
+			{datatype.NewInteger(2), datatype.NewText("Vlad")},
+			{datatype.NewInteger(3), datatype.NewText("John")},
+		}
+
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+		deleter := NewMockRowDeleter(ctrl)
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			rowIter.EXPECT().Next().Return(rows[1], nil),
+			rowIter.EXPECT().Next().Return(rows[2], nil),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+
+			deleter.EXPECT().Delete(sql.IntegerKey(1)).Return(nil),
+			deleter.EXPECT().Delete(sql.IntegerKey(2)).Return(nil),
+			deleter.EXPECT().Delete(sql.IntegerKey(3)).Return(expectedErr),
+		)
+
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
+		iter, err := deletePlan.RowIter()
+		require.NoError(t, err)
+
+		r, err := iter.Next()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, r)
+	})
+
 	t.Run("returns error on unsupported key type", func(t *testing.T) {
 		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		row := sql.Row{
-			datatype.NewText("Max"),
+			datatype.NewNull(),
 		}
 
 		child := plan.NewMockNode(ctrl)
@@ -184,7 +223,7 @@ func TestDelete_RowIter(t *testing.T) {
 			rowIter.EXPECT().Close().Return(nil),
 		)
 
-		deletePlan := plan.NewDelete(deleter, pkIndex, child)
+		deletePlan := plan.NewDelete(deleter, primaryKey, child)
 		iter, err := deletePlan.RowIter()
 		require.NoError(t, err)
 
@@ -0,0 +1,131 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// Distinct returns the first row of the child of every set of rows whose keys are not distinct, NULL values are
+// the same. Keys are values of the expressions, the whole row if there are none. Keys of returned rows are kept
+// in a hash table, so rows are passed on as they are read.
+type Distinct struct {
+	on    []expr.Node
+	child Node
+}
+
+func NewDistinct(on []expr.Node, child Node) *Distinct {
+	return &Distinct{
+		on:    on,
+		child: child,
+	}
+}
+
+func (d *Distinct) Columns() []string {
+	return d.child.Columns()
+}
+
+func (d *Distinct) RowIter() (sql.RowIter, error) {
+	iter, err := d.child.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter: %w", err)
+	}
+
+	iter = &distinctIter{
+		on:   d.on,
+		iter: iter,
+		seen: make(valueSet),
+	}
+
+	return iter, nil
+}
+
+// On returns the expressions rows are told apart by, nil if they are told apart by whole rows.
+func (d *Distinct) On() []expr.Node {
+	return d.on
+}
+
+func (d *Distinct) Children() []Node {
+	return []Node{d.child}
+}
+
+func (d *Distinct) WithChildren(children ...Node) Node {
+	return NewDistinct(d.on, children[0])
+}
+
+func (d *Distinct) Expressions() []expr.Node {
+	return d.on
+}
+
+func (d *Distinct) WithExpressions(exprs ...expr.Node) Node {
+	return NewDistinct(exprs, d.child)
+}
+
+func (d *Distinct) Explain() (string, []Node) {
+	if len(d.on) == 0 {
+		return "Hash Distinct", []Node{d.child}
+	}
+
+	on := make([]string, len(d.on))
+
+	for i, node := range d.on {
+		on[i] = node.String()
+	}
+
+	return "Hash Distinct on " + strings.Join(on, ", "), []Node{d.child}
+}
+
+type distinctIter struct {
+	on   []expr.Node
+	iter sql.RowIter
+	seen valueSet
+}
+
+func (i *distinctIter) Next() (sql.Row, error) {
+	for {
+		row, err := i.iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil, err
+		case err != nil:
+			return nil, fmt.Errorf("get next row: %w", err)
+		}
+
+		key, err := i.key(row)
+		if err != nil {
+			return nil, err
+		}
+
+		if i.seen.add(key) {
+			return row, nil
+		}
+	}
+}
+
+// key returns values of the expressions for the row, the row itself if there are none.
+func (i *distinctIter) key(row sql.Row) ([]sql.Value, error) {
+	if len(i.on) == 0 {
+		return row, nil
+	}
+
+	values := make([]sql.Value, len(i.on))
+
+	for j, node := range i.on {
+		value, err := node.Eval(row)
+		if err != nil {
+			return nil, fmt.Errorf("eval distinct expression %s: %w", node, err)
+		}
+
+		values[j] = value
+	}
+
+	return values, nil
+}
+
+func (i *distinctIter) Close() error {
+	return i.iter.Close()
+}
@@ -0,0 +1,119 @@
+package plan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestDistinct_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drops repeated rows", func(t *testing.T) {
+		t.Parallel()
+
+		child := table([]string{"model", "range"},
+			sql.Row{datatype.NewText("airbus"), datatype.NewInteger(3000)},
+			sql.Row{datatype.NewNull(), datatype.NewNull()},
+			sql.Row{datatype.NewText("airbus"), datatype.NewFloat(3000)},
+			sql.Row{datatype.NewText("airbus"), datatype.NewInteger(5000)},
+			sql.Row{datatype.NewNull(), datatype.NewNull()},
+		)
+
+		expected := []sql.Row{
+			{datatype.NewText("airbus"), datatype.NewInteger(3000)},
+			{datatype.NewNull(), datatype.NewNull()},
+			{datatype.NewText("airbus"), datatype.NewInteger(5000)},
+		}
+
+		assert.Equal(t, expected, readRows(t, plan.NewDistinct(nil, child)))
+	})
+
+	t.Run("keeps the first row of every key", func(t *testing.T) {
+		t.Parallel()
+
+		expected := []sql.Row{
+			{datatype.NewNull(), datatype.NewInteger(100), datatype.NewFloat(1)},
+			{datatype.NewText("airbus"), datatype.NewInteger(3000), datatype.NewFloat(2.5)},
+			{datatype.NewText("boeing"), datatype.NewInteger(5000), datatype.NewFloat(4)},
+		}
+
+		assert.Equal(t, expected, readRows(t, plan.NewDistinct([]expr.Node{model}, aircrafts())))
+	})
+
+	t.Run("returns error on eval error", func(t *testing.T) {
+		t.Parallel()
+
+		iter, err := plan.NewDistinct([]expr.Node{aggregate(expr.CountFunc, nil, false)}, aircrafts()).RowIter()
+		require.NoError(t, err)
+
+		_, err = iter.Next()
+		require.Error(t, err)
+	})
+
+	t.Run("returns error on row iter error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		child.EXPECT().RowIter().Return(nil, io.ErrUnexpectedEOF)
+
+		iter, err := plan.NewDistinct(nil, child).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Nil(t, iter)
+	})
+
+	t.Run("returns error on next row error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		child.EXPECT().RowIter().Return(rowIter, nil)
+		rowIter.EXPECT().Next().Return(nil, io.ErrUnexpectedEOF)
+		rowIter.EXPECT().Close().Return(nil)
+
+		iter, err := plan.NewDistinct(nil, child).RowIter()
+		require.NoError(t, err)
+
+		_, err = iter.Next()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.NoError(t, iter.Close())
+	})
+}
+
+func TestDistinct_Explain(t *testing.T) {
+	t.Parallel()
+
+	child := aircrafts()
+
+	description, children := plan.NewDistinct(nil, child).Explain()
+	assert.Equal(t, "Hash Distinct", description)
+	assert.Equal(t, []plan.Node{child}, children)
+
+	description, _ = plan.NewDistinct([]expr.Node{model, rng}, child).Explain()
+	assert.Equal(t, "Hash Distinct on model, range", description)
+}
+
+func TestDistinct_WithChildren(t *testing.T) {
+	t.Parallel()
+
+	node := plan.NewDistinct([]expr.Node{model}, users())
+
+	assert.Equal(t, plan.NewDistinct([]expr.Node{model}, aircrafts()), node.WithChildren(aircrafts()))
+	assert.Equal(t, []string{"model", "range", "weight"}, node.WithChildren(aircrafts()).Columns())
+	assert.Equal(t, []expr.Node{model}, node.On())
+}
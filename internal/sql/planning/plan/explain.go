@@ -0,0 +1,449 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// Estimate is the number of rows a node is expected to produce and the cost of producing them.
+// Costs are abstract units, reading a row by a scan of the table costs 1. Startup is the part of the cost
+// spent before the first row is produced, so a parent that stops early pays only a share of the rest.
+type Estimate struct {
+	Rows    float64
+	Startup float64
+	Total   float64
+}
+
+// Actual is what a node did when EXPLAIN ANALYZE ran the plan. Times include the time spent by children
+// of the node and are summed over loops, the number of times the node was run.
+type Actual struct {
+	Rows    int64
+	Loops   int64
+	Startup time.Duration // until the first row is produced
+	Total   time.Duration // spent by the node in all
+}
+
+// ExplainFormat is the format EXPLAIN shows the plan in.
+type ExplainFormat uint8
+
+const (
+	// ExplainText shows a row per node, children are indented below their parent.
+	ExplainText ExplainFormat = iota
+	// ExplainJSON shows the plan as a JSON document, a row per line.
+	ExplainJSON
+)
+
+// Explainer is implemented by nodes shown by EXPLAIN.
+type Explainer interface {
+	// Explain returns the one-line description of the node and its child nodes.
+	Explain() (string, []Node)
+}
+
+// Evaluator is implemented by nodes that evaluate expressions, it lets EXPLAIN show plans of their subqueries.
+type Evaluator interface {
+	Node
+	// Expressions returns the expressions the node evaluates.
+	Expressions() []expr.Node
+	// WithExpressions returns a copy of the node that evaluates the expressions instead, in the order of Expressions.
+	WithExpressions(exprs ...expr.Node) Node
+}
+
+// Explain returns the plan of a statement instead of running it. Nodes that have estimates are followed by them.
+// If the plan is analyzed, it's run first and every node is followed by what it actually did.
+type Explain struct {
+	root      Node
+	estimates map[Node]Estimate
+	analyze   bool
+	format    ExplainFormat
+}
+
+func NewExplain(root Node, estimates map[Node]Estimate, analyze bool, format ExplainFormat) *Explain {
+	return &Explain{
+		root:      root,
+		estimates: estimates,
+		analyze:   analyze,
+		format:    format,
+	}
+}
+
+func (e *Explain) Columns() []string {
+	return []string{"QUERY PLAN"}
+}
+
+func (e *Explain) RowIter() (sql.RowIter, error) {
+	var (
+		root    = e.root
+		elapsed time.Duration
+	)
+
+	if e.analyze {
+		var err error
+
+		root = measure(root, &measuredPlans{
+			queries:    make(map[expr.Query]Node),
+			subqueries: make(map[expr.SubqueryNode]expr.Node),
+		})
+		if elapsed, err = run(root); err != nil {
+			return nil, fmt.Errorf("analyze: %w", err)
+		}
+	}
+
+	explained := e.explain(root, make(map[expr.Query]int))
+
+	var lines []string
+
+	switch e.format {
+	case ExplainText:
+		lines = explained.text(0, nil)
+
+		if e.analyze {
+			lines = append(lines, fmt.Sprintf("Execution time: %.3f ms", milliseconds(elapsed)))
+		}
+	case ExplainJSON:
+		document := explainDocument{Plan: explained}
+
+		if e.analyze {
+			executionTime := milliseconds(elapsed)
+			document.ExecutionTime = &executionTime
+		}
+
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal plan: %w", err)
+		}
+
+		lines = strings.Split(string(data), "\n")
+	default:
+		return nil, fmt.Errorf("unknown explain format: %v", e.format)
+	}
+
+	rows := make([]sql.Row, 0, len(lines))
+
+	for _, line := range lines {
+		rows = append(rows, sql.Row{datatype.NewText(line)})
+	}
+
+	return sql.RowsIter(rows...), nil
+}
+
+// explain describes the node and its children, plans of subqueries of the node follow them. Subplans are numbered
+// in the order they're found, a plan is described only where it's found first.
+func (e *Explain) explain(node Node, subplans map[expr.Query]int) explainedNode {
+	var (
+		explained explainedNode
+		actual    *Actual
+		planned   = node
+	)
+
+	// Estimates are recorded for the nodes of the plan, not for their measured copies.
+	if m, ok := node.(*measured); ok {
+		node, planned, actual = m.node, m.original, m.actual
+	}
+
+	var children []Node
+
+	explained.Type = strings.TrimPrefix(fmt.Sprintf("%T", node), "*plan.")
+	explained.Description = explained.Type
+
+	if explainer, ok := node.(Explainer); ok {
+		explained.Description, children = explainer.Explain()
+	}
+
+	if estimate, ok := e.estimates[planned]; ok {
+		explained.StartupCost = ptr(round(estimate.Startup, 2))
+		explained.TotalCost = ptr(round(estimate.Total, 2))
+		explained.PlanRows = ptr(math.Round(estimate.Rows))
+	}
+
+	if actual != nil {
+		explained.ActualLoops = &actual.Loops
+
+		if actual.Loops > 0 {
+			explained.ActualStartupTime = ptr(round(milliseconds(actual.Startup), 3))
+			explained.ActualTotalTime = ptr(round(milliseconds(actual.Total), 3))
+			explained.ActualRows = &actual.Rows
+		}
+	}
+
+	for _, child := range children {
+		explained.Plans = append(explained.Plans, e.explain(child, subplans))
+	}
+
+	for _, subquery := range subqueries(node) {
+		if _, ok := subplans[subquery.Query()]; ok {
+			continue
+		}
+
+		subplans[subquery.Query()] = len(subplans) + 1
+		explained.Plans = append(explained.Plans, e.explainSubplan(subquery, subplans))
+	}
+
+	return explained
+}
+
+// explainSubplan describes the plan of the subquery below the entry of the subplan. The plan of an uncorrelated
+// subquery is an init plan, it's read once. The plan of a correlated one is read again for every distinct set
+// of values of the columns of the outer query it refers to, so loops of its nodes count them.
+func (e *Explain) explainSubplan(subquery expr.SubqueryNode, subplans map[expr.Query]int) explainedNode {
+	kind := "SubPlan"
+
+	if len(subquery.Outer()) == 0 {
+		kind = "InitPlan"
+	}
+
+	return explainedNode{
+		Type:        kind,
+		Description: fmt.Sprintf("%s %d: %s", kind, subplans[subquery.Query()], subquery),
+		Plans:       []explainedNode{e.explain(subquery.Query(), subplans)},
+	}
+}
+
+// subqueries returns subquery expressions the node evaluates, nested ones are found in their plans.
+func subqueries(node Node) []expr.SubqueryNode {
+	evaluator, ok := node.(Evaluator)
+	if !ok {
+		return nil
+	}
+
+	var found []expr.SubqueryNode
+
+	for _, e := range evaluator.Expressions() {
+		expr.Inspect(e, func(n expr.Node) {
+			if subquery, ok := n.(expr.SubqueryNode); ok {
+				found = append(found, subquery)
+			}
+		})
+	}
+
+	return found
+}
+
+// explainDocument is the plan shown in the JSON format.
+type explainDocument struct {
+	Plan          explainedNode `json:"Plan"`
+	ExecutionTime *float64      `json:"Execution Time,omitempty"`
+}
+
+// explainedNode is a node of the plan shown by EXPLAIN. Costs are in abstract units, times are in milliseconds.
+type explainedNode struct {
+	Type              string          `json:"Node Type"`
+	Description       string          `json:"Description"`
+	StartupCost       *float64        `json:"Startup Cost,omitempty"`
+	TotalCost         *float64        `json:"Total Cost,omitempty"`
+	PlanRows          *float64        `json:"Plan Rows,omitempty"`
+	ActualStartupTime *float64        `json:"Actual Startup Time,omitempty"`
+	ActualTotalTime   *float64        `json:"Actual Total Time,omitempty"`
+	ActualRows        *int64          `json:"Actual Rows,omitempty"`
+	ActualLoops       *int64          `json:"Actual Loops,omitempty"`
+	Plans             []explainedNode `json:"Plans,omitempty"`
+}
+
+// text returns a line per node of the plan, children are indented below their parent.
+func (n explainedNode) text(depth int, lines []string) []string {
+	line := n.Description
+
+	if n.TotalCost != nil {
+		line += fmt.Sprintf("  (cost=%.2f..%.2f rows=%.0f)", *n.StartupCost, *n.TotalCost, *n.PlanRows)
+	}
+
+	switch {
+	case n.ActualRows != nil:
+		line += fmt.Sprintf(" (actual time=%.3f..%.3f rows=%d loops=%d)",
+			*n.ActualStartupTime, *n.ActualTotalTime, *n.ActualRows, *n.ActualLoops)
+	case n.ActualLoops != nil:
+		line += " (never executed)"
+	}
+
+	if depth > 0 {
+		line = strings.Repeat("   ", depth-1) + "-> " + line
+	}
+
+	lines = append(lines, line)
+
+	for _, child := range n.Plans {
+		lines = child.text(depth+1, lines)
+	}
+
+	return lines
+}
+
+// measuredPlans are measured copies of plans of subqueries and copies of subquery expressions that read them.
+// Copies of a subquery expression share the plan, and an expression shared by nodes reads it once for them all,
+// so they stay that way.
+type measuredPlans struct {
+	queries    map[expr.Query]Node
+	subqueries map[expr.SubqueryNode]expr.Node
+}
+
+// measure returns the copy of the plan whose nodes measure what they do, plans of subqueries of the nodes too.
+func measure(node Node, plans *measuredPlans) Node {
+	m := &measured{
+		original: node,
+		actual:   &Actual{},
+	}
+
+	if parent, ok := node.(Parent); ok {
+		children := parent.Children()
+		measuredChildren := make([]Node, len(children))
+
+		for i, child := range children {
+			measuredChildren[i] = measure(child, plans)
+		}
+
+		node = parent.WithChildren(measuredChildren...)
+	}
+
+	if evaluator, ok := node.(Evaluator); ok && len(subqueries(node)) > 0 {
+		exprs := evaluator.Expressions()
+		measuredExprs := make([]expr.Node, len(exprs))
+
+		for i, e := range exprs {
+			measuredExprs[i] = expr.Transform(e, func(n expr.Node) (expr.Node, bool) {
+				subquery, ok := n.(expr.SubqueryNode)
+				if !ok {
+					return nil, false
+				}
+
+				if measured, ok := plans.subqueries[subquery]; ok {
+					return measured, true
+				}
+
+				query, ok := plans.queries[subquery.Query()]
+				if !ok {
+					query = measure(subquery.Query(), plans)
+					plans.queries[subquery.Query()] = query
+				}
+
+				plans.subqueries[subquery] = subquery.WithQuery(query)
+
+				return plans.subqueries[subquery], true
+			})
+		}
+
+		node = evaluator.WithExpressions(measuredExprs...)
+	}
+
+	m.node = node
+
+	return m
+}
+
+// run reads all rows of the plan and returns the time it took.
+func run(node Node) (time.Duration, error) {
+	start := time.Now()
+
+	iter, err := node.RowIter()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		if _, err = iter.Next(); err != nil {
+			break
+		}
+	}
+
+	if !errors.Is(err, io.EOF) {
+		return 0, errors.Join(err, iter.Close())
+	}
+
+	if err = iter.Close(); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
+// measured is a node of the plan run by EXPLAIN ANALYZE, it counts rows and loops of the node and the time
+// spent in its iterator.
+type measured struct {
+	original Node
+	node     Node
+	actual   *Actual
+}
+
+func (m *measured) Columns() []string {
+	return m.node.Columns()
+}
+
+func (m *measured) RowIter() (sql.RowIter, error) {
+	start := time.Now()
+	iter, err := m.node.RowIter()
+	elapsed := time.Since(start)
+
+	m.actual.Loops++
+	m.actual.Total += elapsed
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &measuredIter{iter: iter, actual: m.actual, elapsed: elapsed}, nil
+}
+
+type measuredIter struct {
+	iter    sql.RowIter
+	actual  *Actual
+	elapsed time.Duration // spent by this loop so far
+	started bool          // the first row has been produced
+}
+
+func (i *measuredIter) Next() (sql.Row, error) {
+	start := time.Now()
+	row, err := i.iter.Next()
+	elapsed := time.Since(start)
+
+	i.elapsed += elapsed
+	i.actual.Total += elapsed
+
+	if !i.started {
+		i.started = true
+		i.actual.Startup += i.elapsed
+	}
+
+	if err == nil {
+		i.actual.Rows++
+	}
+
+	return row, err
+}
+
+func (i *measuredIter) Close() error {
+	start := time.Now()
+	err := i.iter.Close()
+	i.actual.Total += time.Since(start)
+
+	return err
+}
+
+// target returns the name of the table the node changes, empty if it has no name.
+func target(table any) string {
+	if named, ok := table.(interface{ Name() string }); ok {
+		return " on " + named.Name()
+	}
+
+	return ""
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func round(value float64, digits int) float64 {
+	scale := math.Pow10(digits)
+
+	return math.Round(value*scale) / scale
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,324 @@
+package plan_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestExplain_Columns(t *testing.T) {
+	t.Parallel()
+
+	explainPlan := plan.NewExplain(plan.NewRows(), nil, false, plan.ExplainText)
+	assert.Equal(t, []string{"QUERY PLAN"}, explainPlan.Columns())
+}
+
+func TestExplain_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shows nodes with estimates", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Name().Return("users")
+		table.EXPECT().Scheme().Return(sql.Scheme{
+			"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+			"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+		})
+
+		scan := plan.NewScan(table)
+		sort := plan.NewSort(1, plan.Descending, scan)
+		limit := plan.NewLimit(5, sort)
+
+		estimates := map[plan.Node]plan.Estimate{
+			scan:  {Rows: 100, Total: 100},
+			sort:  {Rows: 100, Startup: 106.66, Total: 106.66},
+			limit: {Rows: 5, Startup: 106.66, Total: 106.66},
+		}
+
+		explainPlan := plan.NewExplain(limit, estimates, false, plan.ExplainText)
+		expected := []string{
+			"Limit 5  (cost=106.66..106.66 rows=5)",
+			"-> Sort by name desc  (cost=106.66..106.66 rows=100)",
+			"   -> Scan on users  (cost=0.00..100.00 rows=100)",
+		}
+
+		assert.Equal(t, expected, explainLines(t, explainPlan))
+	})
+
+	t.Run("shows nodes without description by type", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		node := plan.NewMockNode(ctrl)
+		explainPlan := plan.NewExplain(plan.NewOffset(2, node), nil, false, plan.ExplainText)
+
+		assert.Equal(t, []string{"Offset 2", "-> MockNode"}, explainLines(t, explainPlan))
+	})
+}
+
+func TestExplain_JSON(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	table := sql.NewMockTable(ctrl)
+	table.EXPECT().Name().Return("users")
+
+	scan := plan.NewScan(table)
+	limit := plan.NewLimit(5, scan)
+
+	estimates := map[plan.Node]plan.Estimate{
+		scan:  {Rows: 100, Total: 100},
+		limit: {Rows: 5, Total: 5},
+	}
+
+	expected := `{
+  "Plan": {
+    "Node Type": "Limit",
+    "Description": "Limit 5",
+    "Startup Cost": 0,
+    "Total Cost": 5,
+    "Plan Rows": 5,
+    "Plans": [
+      {
+        "Node Type": "Scan",
+        "Description": "Scan on users",
+        "Startup Cost": 0,
+        "Total Cost": 100,
+        "Plan Rows": 100
+      }
+    ]
+  }
+}`
+
+	lines := explainLines(t, plan.NewExplain(limit, estimates, false, plan.ExplainJSON))
+	assert.JSONEq(t, expected, strings.Join(lines, "\n"))
+	assert.Equal(t, strings.Split(expected, "\n"), lines)
+}
+
+func TestExplain_Analyze(t *testing.T) {
+	t.Parallel()
+
+	rows := []sql.Row{
+		{datatype.NewInteger(1)},
+		{datatype.NewInteger(2)},
+		{datatype.NewInteger(3)},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+
+		child := plan.NewRows(rows...)
+		limit := plan.NewLimit(2, child)
+		estimates := map[plan.Node]plan.Estimate{
+			child: {Rows: 3},
+			limit: {Rows: 2},
+		}
+
+		lines := explainLines(t, plan.NewExplain(limit, estimates, true, plan.ExplainText))
+		require.Len(t, lines, 3)
+
+		actual := `\(actual time=\d+\.\d{3}\.\.\d+\.\d{3} rows=%d loops=1\)$`
+		assert.Regexp(t, `^Limit 2  \(cost=0\.00\.\.0\.00 rows=2\) `+fmt.Sprintf(actual, 2), lines[0])
+		assert.Regexp(t, `^-> Rows  \(cost=0\.00\.\.0\.00 rows=3\) `+fmt.Sprintf(actual, 2), lines[1])
+		assert.Regexp(t, `^Execution time: \d+\.\d{3} ms$`, lines[2])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var document struct {
+			Plan struct {
+				ActualRows  int64 `json:"Actual Rows"`
+				ActualLoops int64 `json:"Actual Loops"`
+				Plans       []struct {
+					ActualRows  int64 `json:"Actual Rows"`
+					ActualLoops int64 `json:"Actual Loops"`
+				} `json:"Plans"`
+			} `json:"Plan"`
+			ExecutionTime *float64 `json:"Execution Time"`
+		}
+
+		explainPlan := plan.NewExplain(plan.NewOffset(1, plan.NewRows(rows...)), nil, true, plan.ExplainJSON)
+		lines := explainLines(t, explainPlan)
+
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(lines, "\n")), &document))
+		assert.Equal(t, int64(2), document.Plan.ActualRows)
+		assert.Equal(t, int64(1), document.Plan.ActualLoops)
+		require.Len(t, document.Plan.Plans, 1)
+		assert.Equal(t, int64(3), document.Plan.Plans[0].ActualRows)
+		assert.Equal(t, int64(1), document.Plan.Plans[0].ActualLoops)
+		assert.NotNil(t, document.ExecutionTime)
+	})
+
+	t.Run("changes rows", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		deleter := NewMockRowDeleter(ctrl)
+		deleter.EXPECT().Delete(gomock.Any()).Return(nil).Times(3)
+
+		explainPlan := plan.NewExplain(plan.NewDelete(deleter, []uint8{0}, plan.NewRows(rows...)), nil, true, plan.ExplainText)
+		lines := explainLines(t, explainPlan)
+
+		require.Len(t, lines, 3)
+		assert.Regexp(t, `^Delete \(actual time=.* rows=0 loops=1\)$`, lines[0])
+		assert.Regexp(t, `^-> Rows \(actual time=.* rows=3 loops=1\)$`, lines[1])
+	})
+
+	t.Run("returns error of the plan", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(nil, io.ErrUnexpectedEOF),
+			rowIter.EXPECT().Close().Return(nil),
+		)
+
+		iter, err := plan.NewExplain(plan.NewLimit(1, child), nil, true, plan.ExplainText).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Nil(t, iter)
+	})
+}
+
+func TestExplain_Subplans(t *testing.T) {
+	t.Parallel()
+
+	// SELECT (SELECT 10 WHERE $1 ...) AS total, EXISTS (SELECT 1) AS found FROM rows WHERE EXISTS (SELECT 1)
+	subplans := func() (plan.Node, map[plan.Node]plan.Estimate) {
+		rows := plan.NewRows(
+			sql.Row{datatype.NewInteger(1)},
+			sql.Row{datatype.NewInteger(2)},
+			sql.Row{datatype.NewInteger(1)},
+		)
+		correlated := plan.NewRows(sql.Row{datatype.NewInteger(10)})
+		uncorrelated := plan.NewRows(sql.Row{datatype.NewInteger(1)}, sql.Row{datatype.NewInteger(1)})
+
+		outer := []expr.Node{expr.Column{Name: "id", Position: 0}}
+		total := expr.NewSubquery(correlated, expr.NewParams(nil), outer)
+		found := expr.NewExists(uncorrelated, expr.NewParams(nil), nil, false)
+
+		project := plan.NewProject([]plan.Projection{{Alias: "total", Expr: total}, {Alias: "found", Expr: found}}, rows)
+		filter := plan.NewFilter(found, project)
+
+		estimates := map[plan.Node]plan.Estimate{
+			rows:         {Rows: 3, Total: 3},
+			correlated:   {Rows: 1, Total: 1},
+			uncorrelated: {Rows: 2, Total: 2},
+			project:      {Rows: 3, Startup: 2, Total: 9},
+			filter:       {Rows: 3, Startup: 2, Total: 9.06},
+		}
+
+		return filter, estimates
+	}
+
+	t.Run("shows plans of subqueries below the node", func(t *testing.T) {
+		t.Parallel()
+
+		root, estimates := subplans()
+		expected := []string{
+			"Filter: EXISTS (subquery)  (cost=2.00..9.06 rows=3)",
+			"-> Project: total, found  (cost=2.00..9.00 rows=3)",
+			"   -> Rows  (cost=0.00..3.00 rows=3)",
+			"   -> SubPlan 1: (subquery)",
+			"      -> Rows  (cost=0.00..1.00 rows=1)",
+			"   -> InitPlan 2: EXISTS (subquery)",
+			"      -> Rows  (cost=0.00..2.00 rows=2)",
+		}
+
+		assert.Equal(t, expected, explainLines(t, plan.NewExplain(root, estimates, false, plan.ExplainText)))
+	})
+
+	t.Run("counts reads of plans of subqueries", func(t *testing.T) {
+		t.Parallel()
+
+		root, estimates := subplans()
+		lines := explainLines(t, plan.NewExplain(root, estimates, true, plan.ExplainText))
+		require.Len(t, lines, 8)
+
+		// The correlated subquery is read for every distinct value, the uncorrelated one once for both nodes.
+		actual := `\(actual time=\d+\.\d{3}\.\.\d+\.\d{3} rows=%d loops=%d\)$`
+		assert.Regexp(t, `^Filter: EXISTS \(subquery\)  \(cost=2\.00\.\.9\.06 rows=3\) `+fmt.Sprintf(actual, 3, 1), lines[0])
+		assert.Regexp(t, `^   -> Rows  \(cost=0\.00\.\.3\.00 rows=3\) `+fmt.Sprintf(actual, 3, 1), lines[2])
+		assert.Equal(t, "   -> SubPlan 1: (subquery)", lines[3])
+		assert.Regexp(t, `^      -> Rows  \(cost=0\.00\.\.1\.00 rows=1\) `+fmt.Sprintf(actual, 2, 2), lines[4])
+		assert.Equal(t, "   -> InitPlan 2: EXISTS (subquery)", lines[5])
+		assert.Regexp(t, `^      -> Rows  \(cost=0\.00\.\.2\.00 rows=2\) `+fmt.Sprintf(actual, 1, 1), lines[6])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		type node struct {
+			Type        string `json:"Node Type"`
+			Description string `json:"Description"`
+			Plans       []node `json:"Plans"`
+		}
+
+		var document struct {
+			Plan node `json:"Plan"`
+		}
+
+		root, estimates := subplans()
+		lines := explainLines(t, plan.NewExplain(root, estimates, false, plan.ExplainJSON))
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(lines, "\n")), &document))
+
+		project := document.Plan.Plans[0]
+		require.Len(t, project.Plans, 3)
+		assert.Equal(t, "SubPlan", project.Plans[1].Type)
+		assert.Equal(t, "SubPlan 1: (subquery)", project.Plans[1].Description)
+		assert.Equal(t, "InitPlan", project.Plans[2].Type)
+		require.Len(t, project.Plans[2].Plans, 1)
+		assert.Equal(t, "Rows", project.Plans[2].Plans[0].Type)
+	})
+}
+
+func explainLines(t *testing.T, explainPlan *plan.Explain) []string {
+	t.Helper()
+
+	iter, err := explainPlan.RowIter()
+	require.NoError(t, err)
+
+	var lines []string
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		lines = append(lines, row[0].Raw().(string))
+	}
+
+	require.NoError(t, iter.Close())
+
+	return lines
+}
@@ -39,6 +39,31 @@ func (f *Filter) RowIter() (sql.RowIter, error) {
 	return iter, nil
 }
 
+// Cond returns the condition rows must satisfy.
+func (f *Filter) Cond() expr.Node {
+	return f.cond
+}
+
+func (f *Filter) Children() []Node {
+	return []Node{f.child}
+}
+
+func (f *Filter) WithChildren(children ...Node) Node {
+	return NewFilter(f.cond, children[0])
+}
+
+func (f *Filter) Expressions() []expr.Node {
+	return []expr.Node{f.cond}
+}
+
+func (f *Filter) WithExpressions(exprs ...expr.Node) Node {
+	return NewFilter(exprs[0], f.child)
+}
+
+func (f *Filter) Explain() (string, []Node) {
+	return "Filter: " + f.cond.String(), []Node{f.child}
+}
+
 // filterIter is an iterator that filters another iterator and skips rows that don't match the given condition.
 type filterIter struct {
 	cond expr.Node
@@ -55,16 +80,11 @@ func (i *filterIter) Next() (sql.Row, error) {
 			return nil, fmt.Errorf("get next row: %w", err)
 		}
 
-		value, err := i.cond.Eval(row)
+		isTrue, err := holds(i.cond, row)
 		if err != nil {
 			return nil, err
 		}
 
-		isTrue, ok := value.Raw().(bool)
-		if !ok {
-			return nil, fmt.Errorf("argument must be type boolean, not type %T", isTrue)
-		}
-
 		if isTrue {
 			return row, nil
 		}
@@ -74,3 +94,25 @@ func (i *filterIter) Next() (sql.Row, error) {
 func (i *filterIter) Close() error {
 	return i.iter.Close()
 }
+
+// holds reports whether the condition is true for the row. NULL isn't true, so a row for which the condition
+// is unknown doesn't match.
+func holds(cond expr.Node, row sql.Row) (bool, error) {
+	value, err := cond.Eval(row)
+	if err != nil {
+		return false, err
+	}
+
+	if value.DataType() == sql.Null {
+		return false, nil
+	}
+
+	raw := value.Raw()
+
+	isTrue, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("argument must be type boolean, not type %T", raw)
+	}
+
+	return isTrue, nil
+}
@@ -53,6 +53,7 @@ func TestFilter_RowIter(t *testing.T) {
 
 		isTrue := datatype.NewBoolean(true)
 		isFalse := datatype.NewBoolean(false)
+		isNull := datatype.NewNull()
 
 		gomock.InOrder(
 			child.EXPECT().RowIter().Return(rowIter, nil),
@@ -61,7 +62,7 @@ func TestFilter_RowIter(t *testing.T) {
 			cond.EXPECT().Eval(rows[0]).Return(isTrue, nil),
 
 			rowIter.EXPECT().Next().Return(rows[1], nil),
-			cond.EXPECT().Eval(rows[1]).Return(isFalse, nil),
+			cond.EXPECT().Eval(rows[1]).Return(isNull, nil),
 
 			rowIter.EXPECT().Next().Return(rows[2], nil),
 			cond.EXPECT().Eval(rows[2]).Return(isFalse, nil),
@@ -193,6 +194,7 @@ func TestFilter_RowIter(t *testing.T) {
 			child.EXPECT().RowIter().Return(rowIter, nil),
 			rowIter.EXPECT().Next().Return(row, nil),
 			cond.EXPECT().Eval(row).Return(value, nil),
+			value.EXPECT().DataType().Return(sql.Integer),
 			value.EXPECT().Raw().Return(10),
 			rowIter.EXPECT().Close().Return(nil),
 		)
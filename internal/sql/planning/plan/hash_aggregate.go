@@ -0,0 +1,125 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// HashAggregate aggregates groups of rows of the child kept in a hash table by values of the group expressions.
+// All rows are read before the first group is returned, groups come in the order of their first rows.
+type HashAggregate struct {
+	aggregation
+}
+
+func NewHashAggregate(groups []expr.Node, aggregates []*expr.Aggregate, child Node) *HashAggregate {
+	return &HashAggregate{
+		aggregation: aggregation{
+			groups:     groups,
+			aggregates: aggregates,
+			child:      child,
+		},
+	}
+}
+
+func (h *HashAggregate) RowIter() (sql.RowIter, error) {
+	iter, err := h.child.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter: %w", err)
+	}
+
+	groups, err := h.aggregate(iter)
+	if err != nil {
+		return nil, errors.Join(err, iter.Close())
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, fmt.Errorf("close row iter: %w", err)
+	}
+
+	rows := make([]sql.Row, len(groups))
+
+	for i, g := range groups {
+		rows[i] = g.row()
+	}
+
+	return sql.RowsIter(rows...), nil
+}
+
+// aggregate adds all rows of the iterator to their groups and returns the groups.
+func (h *HashAggregate) aggregate(iter sql.RowIter) ([]*group, error) {
+	var (
+		groups []*group
+		table  = make(map[string][]*group)
+	)
+
+	if len(h.groups) == 0 {
+		g, err := h.newGroup(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+		table[datatype.HashKey()] = groups
+	}
+
+	for {
+		row, err := iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return groups, nil
+		case err != nil:
+			return nil, fmt.Errorf("get next row: %w", err)
+		}
+
+		values, err := h.groupValues(row)
+		if err != nil {
+			return nil, err
+		}
+
+		g := lookup(table, values)
+
+		if g == nil {
+			if g, err = h.newGroup(values); err != nil {
+				return nil, err
+			}
+
+			key := datatype.HashKey(values...)
+			table[key] = append(table[key], g)
+			groups = append(groups, g)
+		}
+
+		if err = g.add(row); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lookup returns the group of the values in the table, nil if there is none yet.
+func lookup(table map[string][]*group, values []sql.Value) *group {
+	for _, g := range table[datatype.HashKey(values...)] {
+		if notDistinct(g.values, values) {
+			return g
+		}
+	}
+
+	return nil
+}
+
+func (h *HashAggregate) WithChildren(children ...Node) Node {
+	return NewHashAggregate(h.groups, h.aggregates, children[0])
+}
+
+func (h *HashAggregate) WithExpressions(exprs ...expr.Node) Node {
+	groups, aggregates := h.split(exprs)
+
+	return NewHashAggregate(groups, aggregates, h.child)
+}
+
+func (h *HashAggregate) Explain() (string, []Node) {
+	return h.explain("Hash")
+}
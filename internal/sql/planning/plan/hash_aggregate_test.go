@@ -0,0 +1,131 @@
+package plan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestHashAggregate_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aggregates groups", func(t *testing.T) {
+		t.Parallel()
+
+		node := plan.NewHashAggregate([]expr.Node{model}, aggregates(), aircrafts())
+		assert.Equal(t, aggregatedRows(), readRows(t, node))
+	})
+
+	t.Run("groups of rows in any order", func(t *testing.T) {
+		t.Parallel()
+
+		child := table([]string{"n"},
+			sql.Row{datatype.NewInteger(2)},
+			sql.Row{datatype.NewInteger(1)},
+			sql.Row{datatype.NewInteger(2)},
+		)
+
+		node := plan.NewHashAggregate(
+			[]expr.Node{expr.Column{Name: "n", Position: 0}},
+			[]*expr.Aggregate{aggregate(expr.CountFunc, nil, false)},
+			child,
+		)
+
+		expected := []sql.Row{
+			{datatype.NewInteger(2), datatype.NewInteger(2)},
+			{datatype.NewInteger(1), datatype.NewInteger(1)},
+		}
+
+		assert.Equal(t, expected, readRows(t, node))
+	})
+
+	t.Run("single group of no rows", func(t *testing.T) {
+		t.Parallel()
+
+		node := plan.NewHashAggregate(nil, aggregates(), plan.NewEmptyRows([]string{"model", "range", "weight"}))
+
+		expected := []sql.Row{{
+			datatype.NewInteger(0),
+			datatype.NewInteger(0),
+			datatype.NewInteger(0),
+			datatype.NewNull(),
+			datatype.NewNull(),
+			datatype.NewNull(),
+			datatype.NewNull(),
+			datatype.NewNull(),
+		}}
+
+		assert.Equal(t, expected, readRows(t, node))
+	})
+
+	t.Run("no groups of no rows", func(t *testing.T) {
+		t.Parallel()
+
+		node := plan.NewHashAggregate([]expr.Node{model}, aggregates(), plan.NewEmptyRows([]string{"model"}))
+		assert.Empty(t, readRows(t, node))
+	})
+
+	t.Run("returns error on row iter error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		child.EXPECT().RowIter().Return(nil, io.ErrUnexpectedEOF)
+
+		iter, err := plan.NewHashAggregate(nil, aggregates(), child).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Nil(t, iter)
+	})
+
+	t.Run("returns error on next row error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		iter := sql.NewMockRowIter(ctrl)
+
+		child.EXPECT().RowIter().Return(iter, nil)
+		iter.EXPECT().Next().Return(nil, io.ErrUnexpectedEOF)
+		iter.EXPECT().Close().Return(nil)
+
+		_, err := plan.NewHashAggregate(nil, aggregates(), child).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
+func TestHashAggregate_Explain(t *testing.T) {
+	t.Parallel()
+
+	child := aircrafts()
+
+	description, children := plan.NewHashAggregate([]expr.Node{model, rng}, aggregates()[:2], child).Explain()
+	assert.Equal(t, "Hash Aggregate by model, range: count(*), count(range)", description)
+	assert.Equal(t, []plan.Node{child}, children)
+
+	description, _ = plan.NewHashAggregate(nil, aggregates()[2:4], child).Explain()
+	assert.Equal(t, "Aggregate: count(DISTINCT range), sum(range)", description)
+
+	description, _ = plan.NewHashAggregate([]expr.Node{model}, nil, child).Explain()
+	assert.Equal(t, "Hash Aggregate by model", description)
+}
+
+func TestHashAggregate_WithChildren(t *testing.T) {
+	t.Parallel()
+
+	node := plan.NewHashAggregate([]expr.Node{model}, aggregates(), users())
+	rebuilt := node.WithChildren(aircrafts())
+
+	assert.Equal(t, plan.NewHashAggregate([]expr.Node{model}, aggregates(), aircrafts()), rebuilt)
+}
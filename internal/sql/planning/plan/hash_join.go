@@ -0,0 +1,109 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// HashJoin joins rows whose keys are equal. Rows of the right child are read first and put in a hash table
+// by their keys, then every row of the left child is joined only with the rows of the table that have the same
+// keys, and the pair is returned if it satisfies the whole condition. A NULL key matches no row.
+type HashJoin struct {
+	join
+	leftKeys  []expr.Node // keys of rows of the left child
+	rightKeys []expr.Node // keys of rows of the right child, they refer to positions in those rows
+}
+
+func NewHashJoin(joinType JoinType, cond expr.Node, leftKeys, rightKeys []expr.Node, left, right Node) *HashJoin {
+	return &HashJoin{
+		join: join{
+			joinType: joinType,
+			cond:     cond,
+			left:     left,
+			right:    right,
+		},
+		leftKeys:  leftKeys,
+		rightKeys: rightKeys,
+	}
+}
+
+func (h *HashJoin) RowIter() (sql.RowIter, error) {
+	right, err := readAll(h.right)
+	if err != nil {
+		return nil, fmt.Errorf("read right rows: %w", err)
+	}
+
+	table := make(map[string][]int)
+
+	for position, row := range right {
+		key, ok, err := evalKey(h.rightKeys, row)
+		if err != nil {
+			return nil, fmt.Errorf("eval key of right row: %w", err)
+		}
+
+		if ok {
+			table[key] = append(table[key], position)
+		}
+	}
+
+	probe := func(row sql.Row) ([]int, error) {
+		key, ok, err := evalKey(h.leftKeys, row)
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		return table[key], nil
+	}
+
+	return newJoinIter(&h.join, right, probe)
+}
+
+// Keys returns keys of rows of the left and of the right child.
+func (h *HashJoin) Keys() ([]expr.Node, []expr.Node) {
+	return h.leftKeys, h.rightKeys
+}
+
+func (h *HashJoin) WithChildren(children ...Node) Node {
+	return NewHashJoin(h.joinType, h.cond, h.leftKeys, h.rightKeys, children[0], children[1])
+}
+
+// Expressions returns the condition of the join followed by keys of rows of the left and of the right child.
+func (h *HashJoin) Expressions() []expr.Node {
+	exprs := append(h.join.Expressions(), h.leftKeys...)
+
+	return append(exprs, h.rightKeys...)
+}
+
+func (h *HashJoin) WithExpressions(exprs ...expr.Node) Node {
+	keys := exprs[len(h.join.Expressions()):]
+	leftKeys, rightKeys := keys[:len(h.leftKeys)], keys[len(h.leftKeys):]
+
+	return NewHashJoin(h.joinType, h.condOf(exprs), leftKeys, rightKeys, h.left, h.right)
+}
+
+func (h *HashJoin) Explain() (string, []Node) {
+	return h.explain("Hash")
+}
+
+// evalKey returns the hash key of values of the expressions for the row, false if any of them is NULL.
+func evalKey(keys []expr.Node, row sql.Row) (string, bool, error) {
+	values := make([]sql.Value, 0, len(keys))
+
+	for _, key := range keys {
+		value, err := key.Eval(row)
+		if err != nil {
+			return "", false, err
+		}
+
+		if value.DataType() == sql.Null {
+			return "", false, nil
+		}
+
+		values = append(values, value)
+	}
+
+	return datatype.HashKey(values...), true, nil
+}
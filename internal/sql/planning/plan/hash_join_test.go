@@ -0,0 +1,111 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func usersPostsHashJoin(joinType plan.JoinType, cond expr.Node, left, right plan.Node) *plan.HashJoin {
+	leftKeys := []expr.Node{expr.Column{Name: "id", Position: 0}}
+	rightKeys := []expr.Node{expr.Column{Name: "user_id", Position: 0}}
+
+	return plan.NewHashJoin(joinType, cond, leftKeys, rightKeys, left, right)
+}
+
+func TestHashJoin_RowIter(t *testing.T) {
+	t.Parallel()
+
+	for _, joinType := range []plan.JoinType{plan.InnerJoin, plan.LeftJoin, plan.RightJoin, plan.FullJoin} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			join := usersPostsHashJoin(joinType, usersPosts, users(), posts())
+			assert.ElementsMatch(t, joinedRows(joinType), readRows(t, join))
+		})
+	}
+
+	for _, joinType := range []plan.JoinType{plan.SemiJoin, plan.AntiJoin} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			join := usersPostsHashJoin(joinType, usersPosts, users(), posts())
+			assert.Equal(t, matchedRows(joinType), readRows(t, join))
+		})
+	}
+
+	t.Run("inner join returns pairs in the order of left rows", func(t *testing.T) {
+		t.Parallel()
+
+		join := usersPostsHashJoin(plan.InnerJoin, usersPosts, users(), posts())
+		assert.Equal(t, joinedRows(plan.InnerJoin), readRows(t, join))
+	})
+
+	t.Run("integers match equal floats", func(t *testing.T) {
+		t.Parallel()
+
+		right := table([]string{"score", "title"},
+			sql.Row{datatype.NewFloat(2), datatype.NewText("p1")},
+			sql.Row{datatype.NewFloat(2.5), datatype.NewText("p2")},
+		)
+
+		join := usersPostsHashJoin(plan.InnerJoin, usersPosts, users(), right)
+		rows := readRows(t, join)
+
+		require.Len(t, rows, 2)
+		assert.Equal(t, sql.Row{
+			datatype.NewInteger(2),
+			datatype.NewText("bob"),
+			datatype.NewFloat(2),
+			datatype.NewText("p1"),
+		}, rows[0])
+	})
+
+	t.Run("checks the whole condition", func(t *testing.T) {
+		t.Parallel()
+
+		p2, ok := expr.NewConstant(datatype.NewText("p2"))
+		require.True(t, ok)
+
+		cond := &expr.Binary{
+			Operator: expr.And,
+			Left:     usersPosts,
+			Right: expr.Binary{
+				Operator: expr.Equal,
+				Left:     expr.Column{Name: "title", Position: 3},
+				Right:    p2,
+			},
+		}
+
+		join := usersPostsHashJoin(plan.InnerJoin, cond, users(), posts())
+		rows := readRows(t, join)
+
+		require.Len(t, rows, 2)
+		assert.Equal(t, joined(datatype.NewInteger(2), "bob", datatype.NewInteger(2), "p2"), rows[0])
+		assert.Equal(t, joined(datatype.NewInteger(2), "bea", datatype.NewInteger(2), "p2"), rows[1])
+	})
+}
+
+func TestHashJoin_Explain(t *testing.T) {
+	t.Parallel()
+
+	left, right := users(), posts()
+
+	description, children := usersPostsHashJoin(plan.InnerJoin, usersPosts, left, right).Explain()
+	assert.Equal(t, "Hash Join: (users.id = posts.user_id)", description)
+	assert.Equal(t, []plan.Node{left, right}, children)
+}
+
+func TestHashJoin_WithChildren(t *testing.T) {
+	t.Parallel()
+
+	join := usersPostsHashJoin(plan.RightJoin, usersPosts, users(), users())
+
+	assert.Equal(t, usersPostsHashJoin(plan.RightJoin, usersPosts, users(), posts()), join.WithChildren(users(), posts()))
+}
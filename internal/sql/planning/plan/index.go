@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=index.go -destination ./index_mock_test.go -package plan_test
+
+type IndexCreator interface {
+	CreateIndex(name string, columns []string, unique bool) (sql.Index, error)
+}
+
+type CreateIndex struct {
+	creator IndexCreator
+	name    string
+	columns []string
+	unique  bool
+}
+
+func NewCreateIndex(creator IndexCreator, name string, columns []string, unique bool) *CreateIndex {
+	return &CreateIndex{
+		creator: creator,
+		name:    name,
+		columns: columns,
+		unique:  unique,
+	}
+}
+
+func (i *CreateIndex) Columns() []string {
+	return nil
+}
+
+func (i *CreateIndex) RowIter() (sql.RowIter, error) {
+	if _, err := i.creator.CreateIndex(i.name, i.columns, i.unique); err != nil {
+		return nil, fmt.Errorf("create index: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
+
+type IndexDropper interface {
+	DropIndex(name string) error
+}
+
+type DropIndex struct {
+	dropper IndexDropper
+	name    string
+}
+
+func NewDropIndex(dropper IndexDropper, name string) *DropIndex {
+	return &DropIndex{
+		dropper: dropper,
+		name:    name,
+	}
+}
+
+func (i *DropIndex) Columns() []string {
+	return nil
+}
+
+func (i *DropIndex) RowIter() (sql.RowIter, error) {
+	if err := i.dropper.DropIndex(i.name); err != nil {
+		return nil, fmt.Errorf("drop index: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
+
+// IndexScan reads rows of the table that fall into the range of the index.
+type IndexScan struct {
+	table sql.Table
+	index sql.Index
+	rng   sql.IndexRange
+}
+
+func NewIndexScan(table sql.Table, index sql.Index, rng sql.IndexRange) *IndexScan {
+	return &IndexScan{
+		table: table,
+		index: index,
+		rng:   rng,
+	}
+}
+
+func (s *IndexScan) Columns() []string {
+	return NewScan(s.table).Columns()
+}
+
+func (s *IndexScan) RowIter() (sql.RowIter, error) {
+	return s.index.Scan(s.rng)
+}
+
+func (s *IndexScan) Explain() (string, []Node) {
+	return fmt.Sprintf("Index scan on %s using %s", s.table.Name(), s.index.Name()), nil
+}
@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: index.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=index.go -destination ./index_mock_test.go -package plan_test
+//
+
+// Package plan_test is a generated GoMock package.
+package plan_test
+
+import (
+	reflect "reflect"
+
+	sql "github.com/i-sevostyanov/NanoDB/internal/sql"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIndexCreator is a mock of IndexCreator interface.
+type MockIndexCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockIndexCreatorMockRecorder
+}
+
+// MockIndexCreatorMockRecorder is the mock recorder for MockIndexCreator.
+type MockIndexCreatorMockRecorder struct {
+	mock *MockIndexCreator
+}
+
+// NewMockIndexCreator creates a new mock instance.
+func NewMockIndexCreator(ctrl *gomock.Controller) *MockIndexCreator {
+	mock := &MockIndexCreator{ctrl: ctrl}
+	mock.recorder = &MockIndexCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIndexCreator) EXPECT() *MockIndexCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateIndex mocks base method.
+func (m *MockIndexCreator) CreateIndex(name string, columns []string, unique bool) (sql.Index, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIndex", name, columns, unique)
+	ret0, _ := ret[0].(sql.Index)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIndex indicates an expected call of CreateIndex.
+func (mr *MockIndexCreatorMockRecorder) CreateIndex(name, columns, unique any) *MockIndexCreatorCreateIndexCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndex", reflect.TypeOf((*MockIndexCreator)(nil).CreateIndex), name, columns, unique)
+	return &MockIndexCreatorCreateIndexCall{Call: call}
+}
+
+// MockIndexCreatorCreateIndexCall wrap *gomock.Call
+type MockIndexCreatorCreateIndexCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockIndexCreatorCreateIndexCall) Return(arg0 sql.Index, arg1 error) *MockIndexCreatorCreateIndexCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockIndexCreatorCreateIndexCall) Do(f func(string, []string, bool) (sql.Index, error)) *MockIndexCreatorCreateIndexCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockIndexCreatorCreateIndexCall) DoAndReturn(f func(string, []string, bool) (sql.Index, error)) *MockIndexCreatorCreateIndexCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockIndexDropper is a mock of IndexDropper interface.
+type MockIndexDropper struct {
+	ctrl     *gomock.Controller
+	recorder *MockIndexDropperMockRecorder
+}
+
+// MockIndexDropperMockRecorder is the mock recorder for MockIndexDropper.
+type MockIndexDropperMockRecorder struct {
+	mock *MockIndexDropper
+}
+
+// NewMockIndexDropper creates a new mock instance.
+func NewMockIndexDropper(ctrl *gomock.Controller) *MockIndexDropper {
+	mock := &MockIndexDropper{ctrl: ctrl}
+	mock.recorder = &MockIndexDropperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIndexDropper) EXPECT() *MockIndexDropperMockRecorder {
+	return m.recorder
+}
+
+// DropIndex mocks base method.
+func (m *MockIndexDropper) DropIndex(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropIndex", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropIndex indicates an expected call of DropIndex.
+func (mr *MockIndexDropperMockRecorder) DropIndex(name any) *MockIndexDropperDropIndexCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropIndex", reflect.TypeOf((*MockIndexDropper)(nil).DropIndex), name)
+	return &MockIndexDropperDropIndexCall{Call: call}
+}
+
+// MockIndexDropperDropIndexCall wrap *gomock.Call
+type MockIndexDropperDropIndexCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockIndexDropperDropIndexCall) Return(arg0 error) *MockIndexDropperDropIndexCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockIndexDropperDropIndexCall) Do(f func(string) error) *MockIndexDropperDropIndexCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockIndexDropperDropIndexCall) DoAndReturn(f func(string) error) *MockIndexDropperDropIndexCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
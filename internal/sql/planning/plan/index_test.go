@@ -0,0 +1,197 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestCreateIndex_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	creator := NewMockIndexCreator(ctrl)
+	createPlan := plan.NewCreateIndex(creator, "test", []string{"name"}, false)
+	assert.Nil(t, createPlan.Columns())
+}
+
+func TestCreateIndex_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		columns := []string{"name"}
+
+		creator := NewMockIndexCreator(ctrl)
+		creator.EXPECT().CreateIndex(name, columns, true).Return(nil, nil)
+
+		createPlan := plan.NewCreateIndex(creator, name, columns, true)
+		iter, err := createPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on create index", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		columns := []string{"name"}
+		expectedErr := errors.New("something went wrong")
+
+		creator := NewMockIndexCreator(ctrl)
+		creator.EXPECT().CreateIndex(name, columns, false).Return(nil, expectedErr)
+
+		createPlan := plan.NewCreateIndex(creator, name, columns, false)
+		iter, err := createPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestDropIndex_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dropper := NewMockIndexDropper(ctrl)
+	dropPlan := plan.NewDropIndex(dropper, "test")
+	assert.Nil(t, dropPlan.Columns())
+}
+
+func TestDropIndex_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		dropper := NewMockIndexDropper(ctrl)
+		dropper.EXPECT().DropIndex(name).Return(nil)
+
+		dropPlan := plan.NewDropIndex(dropper, name)
+		iter, err := dropPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on drop index", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		expectedErr := errors.New("something went wrong")
+
+		dropper := NewMockIndexDropper(ctrl)
+		dropper.EXPECT().DropIndex(name).Return(expectedErr)
+
+		dropPlan := plan.NewDropIndex(dropper, name)
+		iter, err := dropPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestIndexScan_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	table := sql.NewMockTable(ctrl)
+	table.EXPECT().Scheme().Return(scheme)
+
+	index := sql.NewMockIndex(ctrl)
+
+	scan := plan.NewIndexScan(table, index, sql.IndexRange{})
+	assert.Equal(t, []string{"id", "name"}, scan.Columns())
+}
+
+func TestIndexScan_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rng := sql.IndexRange{
+			Prefix: []sql.Value{datatype.NewText("bob")},
+		}
+
+		table := sql.NewMockTable(ctrl)
+		index := sql.NewMockIndex(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		index.EXPECT().Scan(rng).Return(rowIter, nil)
+
+		scan := plan.NewIndexScan(table, index, rng)
+		iter, err := scan.RowIter()
+		require.NoError(t, err)
+		assert.Equal(t, rowIter, iter)
+	})
+
+	t.Run("returns error on index scan", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		table := sql.NewMockTable(ctrl)
+		index := sql.NewMockIndex(ctrl)
+		index.EXPECT().Scan(sql.IndexRange{}).Return(nil, expectedErr)
+
+		scan := plan.NewIndexScan(table, index, sql.IndexRange{})
+		iter, err := scan.RowIter()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
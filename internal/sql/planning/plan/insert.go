@@ -2,27 +2,38 @@ package plan
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
 )
 
 //go:generate go run go.uber.org/mock/mockgen -typed -source=insert.go -destination ./insert_mock_test.go -package plan_test
 
 type TableInserter interface {
-	Insert(key int64, row sql.Row) error
+	Scheme() sql.Scheme
+	PrimaryKey() []sql.Column
+	Sequence() sql.Sequence
+	Insert(key sql.Key, row sql.Row) error
 }
 
+// Insert stores a single row. Values are evaluated and the key is taken from the sequence of the table
+// only when the node is executed, so a statement that fails before that doesn't consume sequence values.
 type Insert struct {
 	inserter TableInserter
-	key      int64
 	row      sql.Row
+	values   map[uint8]expr.Node
 }
 
-func NewInsert(inserter TableInserter, key int64, row sql.Row) *Insert {
+// NewInsert returns the node that inserts the row with default values of columns. Values are expressions of
+// columns set by the statement or filled from sequences, they're evaluated in the order of column positions.
+// A single integer primary key without a value is assigned from the sequence of the table.
+func NewInsert(inserter TableInserter, row sql.Row, values map[uint8]expr.Node) *Insert {
 	return &Insert{
 		inserter: inserter,
-		key:      key,
 		row:      row,
+		values:   values,
 	}
 }
 
@@ -31,9 +42,93 @@ func (i *Insert) Columns() []string {
 }
 
 func (i *Insert) RowIter() (sql.RowIter, error) {
-	if err := i.inserter.Insert(i.key, i.row); err != nil {
+	row, err := i.eval()
+	if err != nil {
+		return nil, err
+	}
+
+	primaryKey := i.inserter.PrimaryKey()
+	generated := len(primaryKey) == 1 && primaryKey[0].DataType == sql.Integer
+
+	if generated {
+		_, given := i.values[primaryKey[0].Position]
+		generated = !given
+	}
+
+	for j, column := range primaryKey {
+		if generated && j == 0 {
+			continue
+		}
+
+		if row[column.Position] == nil || row[column.Position].DataType() == sql.Null {
+			return nil, fmt.Errorf("primary key column %q should be set", column.Name)
+		}
+	}
+
+	var (
+		sequence sql.Sequence
+		value    int64
+	)
+
+	// The key is taken right before the row is stored and given back if storing fails.
+	if generated {
+		sequence = i.inserter.Sequence()
+		value = sequence.Next()
+		row[primaryKey[0].Position] = datatype.NewInteger(value)
+	}
+
+	key, err := sql.RowKey(row, sql.Positions(primaryKey))
+	if err == nil {
+		err = i.inserter.Insert(key, row)
+	}
+
+	if err != nil {
+		if sequence != nil {
+			sequence.Release(value)
+		}
+
 		return nil, fmt.Errorf("insert row: %w", err)
 	}
 
 	return sql.RowsIter(), nil
 }
+
+// eval returns the row with evaluated values.
+func (i *Insert) eval() (sql.Row, error) {
+	row := slices.Clone(i.row)
+	columns := make(map[uint8]sql.Column, len(row))
+
+	for _, column := range i.inserter.Scheme() {
+		columns[column.Position] = column
+	}
+
+	positions := make([]uint8, 0, len(i.values))
+
+	for position := range i.values {
+		positions = append(positions, position)
+	}
+
+	slices.Sort(positions)
+
+	for _, position := range positions {
+		value, err := i.values[position].Eval(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		column := columns[position]
+
+		if column.DataType != value.DataType() {
+			switch {
+			case value.DataType() != sql.Null:
+				return nil, fmt.Errorf("invalid value for column %q", column.Name)
+			case !column.Nullable:
+				return nil, fmt.Errorf("null value in column %q violates not-null constraint", column.Name)
+			}
+		}
+
+		row[position] = value
+	}
+
+	return row, nil
+}
@@ -40,7 +40,7 @@ func (m *MockTableInserter) EXPECT() *MockTableInserterMockRecorder {
 }
 
 // Insert mocks base method.
-func (m *MockTableInserter) Insert(key int64, row sql.Row) error {
+func (m *MockTableInserter) Insert(key sql.Key, row sql.Row) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Insert", key, row)
 	ret0, _ := ret[0].(error)
@@ -66,13 +66,127 @@ func (c *MockTableInserterInsertCall) Return(arg0 error) *MockTableInserterInser
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableInserterInsertCall) Do(f func(int64, sql.Row) error) *MockTableInserterInsertCall {
+func (c *MockTableInserterInsertCall) Do(f func(sql.Key, sql.Row) error) *MockTableInserterInsertCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableInserterInsertCall) DoAndReturn(f func(int64, sql.Row) error) *MockTableInserterInsertCall {
+func (c *MockTableInserterInsertCall) DoAndReturn(f func(sql.Key, sql.Row) error) *MockTableInserterInsertCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PrimaryKey mocks base method.
+func (m *MockTableInserter) PrimaryKey() []sql.Column {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryKey")
+	ret0, _ := ret[0].([]sql.Column)
+	return ret0
+}
+
+// PrimaryKey indicates an expected call of PrimaryKey.
+func (mr *MockTableInserterMockRecorder) PrimaryKey() *MockTableInserterPrimaryKeyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryKey", reflect.TypeOf((*MockTableInserter)(nil).PrimaryKey))
+	return &MockTableInserterPrimaryKeyCall{Call: call}
+}
+
+// MockTableInserterPrimaryKeyCall wrap *gomock.Call
+type MockTableInserterPrimaryKeyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableInserterPrimaryKeyCall) Return(arg0 []sql.Column) *MockTableInserterPrimaryKeyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableInserterPrimaryKeyCall) Do(f func() []sql.Column) *MockTableInserterPrimaryKeyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableInserterPrimaryKeyCall) DoAndReturn(f func() []sql.Column) *MockTableInserterPrimaryKeyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Scheme mocks base method.
+func (m *MockTableInserter) Scheme() sql.Scheme {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheme")
+	ret0, _ := ret[0].(sql.Scheme)
+	return ret0
+}
+
+// Scheme indicates an expected call of Scheme.
+func (mr *MockTableInserterMockRecorder) Scheme() *MockTableInserterSchemeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheme", reflect.TypeOf((*MockTableInserter)(nil).Scheme))
+	return &MockTableInserterSchemeCall{Call: call}
+}
+
+// MockTableInserterSchemeCall wrap *gomock.Call
+type MockTableInserterSchemeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableInserterSchemeCall) Return(arg0 sql.Scheme) *MockTableInserterSchemeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableInserterSchemeCall) Do(f func() sql.Scheme) *MockTableInserterSchemeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableInserterSchemeCall) DoAndReturn(f func() sql.Scheme) *MockTableInserterSchemeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Sequence mocks base method.
+func (m *MockTableInserter) Sequence() sql.Sequence {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sequence")
+	ret0, _ := ret[0].(sql.Sequence)
+	return ret0
+}
+
+// Sequence indicates an expected call of Sequence.
+func (mr *MockTableInserterMockRecorder) Sequence() *MockTableInserterSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sequence", reflect.TypeOf((*MockTableInserter)(nil).Sequence))
+	return &MockTableInserterSequenceCall{Call: call}
+}
+
+// MockTableInserterSequenceCall wrap *gomock.Call
+type MockTableInserterSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableInserterSequenceCall) Return(arg0 sql.Sequence) *MockTableInserterSequenceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableInserterSequenceCall) Do(f func() sql.Sequence) *MockTableInserterSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableInserterSequenceCall) DoAndReturn(f func() sql.Sequence) *MockTableInserterSequenceCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
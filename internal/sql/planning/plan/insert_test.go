@@ -11,6 +11,7 @@ import (
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
 )
 
@@ -20,36 +21,59 @@ func TestInsert_Columns(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	key := int64(1)
-	row := sql.Row{
-		datatype.NewInteger(key),
-		datatype.NewText("Max"),
-	}
-
 	inserter := NewMockTableInserter(ctrl)
-	insertPlan := plan.NewInsert(inserter, key, row)
+	insertPlan := plan.NewInsert(inserter, sql.Row{nil, nil}, nil)
 	assert.Nil(t, insertPlan.Columns())
 }
 
 func TestInsert_RowIter(t *testing.T) {
 	t.Parallel()
 
-	t.Run("no error", func(t *testing.T) {
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+			Nullable: true,
+		},
+	}
+
+	primaryKey := []sql.Column{scheme["id"]}
+
+	value := func(ctrl *gomock.Controller, v sql.Value) expr.Node {
+		node := expr.NewMockNode(ctrl)
+		node.EXPECT().Eval(nil).Return(v, nil).AnyTimes()
+
+		return node
+	}
+
+	t.Run("inserts row with given key", func(t *testing.T) {
 		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		key := int64(1)
 		row := sql.Row{
-			datatype.NewInteger(key),
+			datatype.NewInteger(10),
 			datatype.NewText("Max"),
 		}
 
 		inserter := NewMockTableInserter(ctrl)
-		inserter.EXPECT().Insert(key, row).Return(nil)
+		inserter.EXPECT().Scheme().Return(scheme)
+		inserter.EXPECT().PrimaryKey().Return(primaryKey)
+		inserter.EXPECT().Insert(sql.IntegerKey(10), row).Return(nil)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, map[uint8]expr.Node{
+			0: value(ctrl, datatype.NewInteger(10)),
+			1: value(ctrl, datatype.NewText("Max")),
+		})
 
-		insertPlan := plan.NewInsert(inserter, key, row)
 		iter, err := insertPlan.RowIter()
 		require.NoError(t, err)
 
@@ -58,24 +82,137 @@ func TestInsert_RowIter(t *testing.T) {
 		assert.Nil(t, row)
 	})
 
-	t.Run("returns error on insert row", func(t *testing.T) {
+	t.Run("takes key from sequence", func(t *testing.T) {
 		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		key := int64(1)
-		expectedErr := errors.New("something went wrong")
 		row := sql.Row{
-			datatype.NewInteger(key),
+			datatype.NewInteger(1),
 			datatype.NewText("Max"),
 		}
 
 		inserter := NewMockTableInserter(ctrl)
-		inserter.EXPECT().Insert(key, row).Return(expectedErr)
+		sequence := sql.NewMockSequence(ctrl)
+
+		inserter.EXPECT().Scheme().Return(scheme)
+		inserter.EXPECT().PrimaryKey().Return(primaryKey)
+		inserter.EXPECT().Sequence().Return(sequence)
+		sequence.EXPECT().Next().Return(int64(1))
+		inserter.EXPECT().Insert(sql.IntegerKey(1), row).Return(nil)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, map[uint8]expr.Node{
+			1: value(ctrl, datatype.NewText("Max")),
+		})
+
+		_, err := insertPlan.RowIter()
+		require.NoError(t, err)
+	})
+
+	t.Run("releases key if row is not stored", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		inserter := NewMockTableInserter(ctrl)
+		sequence := sql.NewMockSequence(ctrl)
+
+		inserter.EXPECT().Scheme().Return(scheme)
+		inserter.EXPECT().PrimaryKey().Return(primaryKey)
+		inserter.EXPECT().Sequence().Return(sequence)
+
+		gomock.InOrder(
+			sequence.EXPECT().Next().Return(int64(7)),
+			inserter.EXPECT().Insert(sql.IntegerKey(7), gomock.Any()).Return(expectedErr),
+			sequence.EXPECT().Release(int64(7)),
+		)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, nil)
+		iter, err := insertPlan.RowIter()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+
+	t.Run("doesn't take key if value is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		inserter := NewMockTableInserter(ctrl)
+		inserter.EXPECT().Scheme().Return(scheme)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, map[uint8]expr.Node{
+			1: value(ctrl, datatype.NewInteger(1)),
+		})
+
+		iter, err := insertPlan.RowIter()
+		require.Error(t, err)
+		assert.Nil(t, iter)
+	})
+
+	t.Run("returns error if value of not null column is null", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		inserter := NewMockTableInserter(ctrl)
+		inserter.EXPECT().Scheme().Return(scheme)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, map[uint8]expr.Node{
+			0: value(ctrl, datatype.NewNull()),
+		})
+
+		iter, err := insertPlan.RowIter()
+		require.Error(t, err)
+		assert.Nil(t, iter)
+	})
+
+	t.Run("returns error if primary key column is not set", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		composite := sql.Scheme{
+			"tenant": sql.Column{Position: 0, Name: "tenant", DataType: sql.Integer, PrimaryKey: true},
+			"code":   sql.Column{Position: 1, Name: "code", DataType: sql.Text, PrimaryKey: true},
+		}
+
+		inserter := NewMockTableInserter(ctrl)
+		inserter.EXPECT().Scheme().Return(composite)
+		inserter.EXPECT().PrimaryKey().Return([]sql.Column{composite["tenant"], composite["code"]})
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, nil}, map[uint8]expr.Node{
+			0: value(ctrl, datatype.NewInteger(1)),
+		})
+
+		iter, err := insertPlan.RowIter()
+		require.Error(t, err)
+		assert.Nil(t, iter)
+	})
+
+	t.Run("returns error on eval value", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		inserter := NewMockTableInserter(ctrl)
+		inserter.EXPECT().Scheme().Return(scheme)
 
-		createPlan := plan.NewInsert(inserter, key, row)
-		iter, err := createPlan.RowIter()
+		node := expr.NewMockNode(ctrl)
+		node.EXPECT().Eval(nil).Return(nil, expectedErr)
+
+		insertPlan := plan.NewInsert(inserter, sql.Row{nil, datatype.NewNull()}, map[uint8]expr.Node{1: node})
+		iter, err := insertPlan.RowIter()
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, iter)
 	})
@@ -0,0 +1,359 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// JoinType tells which rows a join returns besides pairs of rows that satisfy its condition.
+type JoinType uint8
+
+const (
+	// InnerJoin returns only pairs of rows that satisfy the condition.
+	InnerJoin JoinType = iota
+	// LeftJoin also returns rows of the left child that match no row, columns of the right child are NULL.
+	LeftJoin
+	// RightJoin also returns rows of the right child that match no row, columns of the left child are NULL.
+	RightJoin
+	// FullJoin also returns rows of both children that match no row.
+	FullJoin
+	// CrossJoin returns all pairs of rows, it has no condition.
+	CrossJoin
+	// SemiJoin returns rows of the left child that match any row, each of them once. Rows of the right child
+	// aren't returned, so its columns aren't columns of the join.
+	SemiJoin
+	// AntiJoin returns rows of the left child that match no row, columns of the right child aren't returned either.
+	AntiJoin
+)
+
+func (t JoinType) String() string {
+	switch t {
+	case InnerJoin:
+		return "Inner"
+	case LeftJoin:
+		return "Left"
+	case RightJoin:
+		return "Right"
+	case FullJoin:
+		return "Full"
+	case CrossJoin:
+		return "Cross"
+	case SemiJoin:
+		return "Semi"
+	case AntiJoin:
+		return "Anti"
+	default:
+		return fmt.Sprintf("JoinType(%d)", t)
+	}
+}
+
+// keepsLeft reports whether rows of the left child that match no row are returned.
+func (t JoinType) keepsLeft() bool {
+	return t == LeftJoin || t == FullJoin
+}
+
+// keepsRight reports whether rows of the right child that match no row are returned.
+func (t JoinType) keepsRight() bool {
+	return t == RightJoin || t == FullJoin
+}
+
+// leftOnly reports whether only rows of the left child are returned, a row is tested against the right rows.
+func (t JoinType) leftOnly() bool {
+	return t == SemiJoin || t == AntiJoin
+}
+
+// join is what nodes that join rows of two children have in common. A joined row is the row of the left child
+// followed by the row of the right one, so the condition refers to columns of the right child by their positions
+// in the joined row.
+type join struct {
+	joinType JoinType
+	cond     expr.Node // nil if every pair of rows matches
+	left     Node
+	right    Node
+}
+
+func (j *join) Columns() []string {
+	if j.joinType.leftOnly() {
+		return j.left.Columns()
+	}
+
+	left, right := j.left.Columns(), j.right.Columns()
+	columns := make([]string, 0, len(left)+len(right))
+
+	return append(append(columns, left...), right...)
+}
+
+// Type returns the type of the join.
+func (j *join) Type() JoinType {
+	return j.joinType
+}
+
+// Cond returns the condition pairs of rows must satisfy, nil if every pair does.
+func (j *join) Cond() expr.Node {
+	return j.cond
+}
+
+func (j *join) Children() []Node {
+	return []Node{j.left, j.right}
+}
+
+// Expressions returns the condition of the join, none if every pair of rows matches.
+func (j *join) Expressions() []expr.Node {
+	if j.cond == nil {
+		return nil
+	}
+
+	return []expr.Node{j.cond}
+}
+
+// condOf returns the condition among the expressions in the order of Expressions.
+func (j *join) condOf(exprs []expr.Node) expr.Node {
+	if j.cond == nil {
+		return nil
+	}
+
+	return exprs[0]
+}
+
+// explain returns the description of the join done by the method.
+func (j *join) explain(method string) (string, []Node) {
+	description := method + " Join"
+
+	if j.joinType != InnerJoin {
+		description = method + " " + j.joinType.String() + " Join"
+	}
+
+	if j.cond != nil {
+		description += ": " + j.cond.String()
+	}
+
+	return description, []Node{j.left, j.right}
+}
+
+// matches reports whether the joined row satisfies the condition.
+func (j *join) matches(row sql.Row) (bool, error) {
+	if j.cond == nil {
+		return true, nil
+	}
+
+	return holds(j.cond, row)
+}
+
+// readAll reads all rows of the node.
+func readAll(node Node) ([]sql.Row, error) {
+	iter, err := node.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter: %w", err)
+	}
+
+	var rows []sql.Row
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("get next row: %w", err), iter.Close())
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, fmt.Errorf("close row iter: %w", err)
+	}
+
+	return rows, nil
+}
+
+// joinRows returns the row of the left child followed by the row of the right one.
+func joinRows(left, right sql.Row) sql.Row {
+	row := make(sql.Row, 0, len(left)+len(right))
+
+	return append(append(row, left...), right...)
+}
+
+// nulls returns the row of NULL values that stands for the missing row of a child.
+func nulls(width int) sql.Row {
+	row := make(sql.Row, width)
+
+	for i := range row {
+		row[i] = datatype.NewNull()
+	}
+
+	return row
+}
+
+// NestedLoopJoin joins every row of the left child with every row of the right one and returns pairs that satisfy
+// the condition. Rows of the right child are read once and kept in memory.
+type NestedLoopJoin struct {
+	join
+}
+
+func NewNestedLoopJoin(joinType JoinType, cond expr.Node, left, right Node) *NestedLoopJoin {
+	return &NestedLoopJoin{
+		join: join{
+			joinType: joinType,
+			cond:     cond,
+			left:     left,
+			right:    right,
+		},
+	}
+}
+
+func (n *NestedLoopJoin) RowIter() (sql.RowIter, error) {
+	right, err := readAll(n.right)
+	if err != nil {
+		return nil, fmt.Errorf("read right rows: %w", err)
+	}
+
+	all := make([]int, len(right))
+
+	for i := range all {
+		all[i] = i
+	}
+
+	probe := func(sql.Row) ([]int, error) {
+		return all, nil
+	}
+
+	return newJoinIter(&n.join, right, probe)
+}
+
+func (n *NestedLoopJoin) WithChildren(children ...Node) Node {
+	return NewNestedLoopJoin(n.joinType, n.cond, children[0], children[1])
+}
+
+func (n *NestedLoopJoin) WithExpressions(exprs ...expr.Node) Node {
+	return NewNestedLoopJoin(n.joinType, n.condOf(exprs), n.left, n.right)
+}
+
+func (n *NestedLoopJoin) Explain() (string, []Node) {
+	return n.explain("Nested Loop")
+}
+
+// joinIter joins rows of the left child with rows of the right one kept in memory.
+type joinIter struct {
+	join  *join
+	left  sql.RowIter
+	right []sql.Row
+	// probe returns positions of the right rows that may match the left row
+	probe   func(row sql.Row) ([]int, error)
+	matched []bool // right rows that matched a row, nil if the join doesn't return unmatched right rows
+
+	leftWidth  int
+	rightWidth int
+
+	row        sql.Row // the current left row, nil if the next one has to be read
+	candidates []int   // positions of right rows not yet joined with the current left row
+	found      bool    // the current left row matched a row
+	done       bool    // all left rows are read
+	unmatched  int     // the next right row to check whether it matched, once all left rows are read
+}
+
+func newJoinIter(j *join, right []sql.Row, probe func(sql.Row) ([]int, error)) (*joinIter, error) {
+	left, err := j.left.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get left row iter: %w", err)
+	}
+
+	iter := &joinIter{
+		join:       j,
+		left:       left,
+		right:      right,
+		probe:      probe,
+		leftWidth:  len(j.left.Columns()),
+		rightWidth: len(j.right.Columns()),
+	}
+
+	if j.joinType.keepsRight() {
+		iter.matched = make([]bool, len(right))
+	}
+
+	return iter, nil
+}
+
+func (i *joinIter) Next() (sql.Row, error) {
+	for !i.done {
+		if i.row == nil {
+			row, err := i.left.Next()
+			switch {
+			case errors.Is(err, io.EOF):
+				i.done = true
+				continue
+			case err != nil:
+				return nil, fmt.Errorf("get next left row: %w", err)
+			}
+
+			if i.candidates, err = i.probe(row); err != nil {
+				return nil, err
+			}
+
+			i.row, i.found = row, false
+		}
+
+		for len(i.candidates) > 0 {
+			position := i.candidates[0]
+			i.candidates = i.candidates[1:]
+
+			joined := joinRows(i.row, i.right[position])
+
+			ok, err := i.join.matches(joined)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				continue
+			}
+
+			i.found = true
+
+			if i.matched != nil {
+				i.matched[position] = true
+			}
+
+			// The left row of a semi or an anti join is done with once it matches a row.
+			if i.join.joinType.leftOnly() {
+				i.candidates = nil
+				break
+			}
+
+			return joined, nil
+		}
+
+		row, found := i.row, i.found
+		i.row = nil
+
+		switch {
+		case found && i.join.joinType == SemiJoin:
+			return row, nil
+		case !found && i.join.joinType == AntiJoin:
+			return row, nil
+		case !found && i.join.joinType.keepsLeft():
+			return joinRows(row, nulls(i.rightWidth)), nil
+		}
+	}
+
+	for i.matched != nil && i.unmatched < len(i.right) {
+		position := i.unmatched
+		i.unmatched++
+
+		if !i.matched[position] {
+			return joinRows(nulls(i.leftWidth), i.right[position]), nil
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (i *joinIter) Close() error {
+	return i.left.Close()
+}
@@ -0,0 +1,260 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// users and posts are children of joins, both are in the ascending order of the key, NULL goes first.
+func users() plan.Node {
+	return table([]string{"id", "name"},
+		sql.Row{datatype.NewNull(), datatype.NewText("nil")},
+		sql.Row{datatype.NewInteger(1), datatype.NewText("ann")},
+		sql.Row{datatype.NewInteger(2), datatype.NewText("bob")},
+		sql.Row{datatype.NewInteger(2), datatype.NewText("bea")},
+		sql.Row{datatype.NewInteger(4), datatype.NewText("dan")},
+	)
+}
+
+func posts() plan.Node {
+	return table([]string{"user_id", "title"},
+		sql.Row{datatype.NewNull(), datatype.NewText("x")},
+		sql.Row{datatype.NewInteger(2), datatype.NewText("p1")},
+		sql.Row{datatype.NewInteger(2), datatype.NewText("p2")},
+		sql.Row{datatype.NewInteger(3), datatype.NewText("p3")},
+		sql.Row{datatype.NewInteger(4), datatype.NewText("p4")},
+	)
+}
+
+// table returns the node that produces the rows of the columns.
+func table(columns []string, rows ...sql.Row) plan.Node {
+	projections := make([]plan.Projection, len(columns))
+
+	for i, column := range columns {
+		projections[i] = plan.Projection{Expr: expr.Column{Name: column, Position: uint8(i)}}
+	}
+
+	return plan.NewProject(projections, plan.NewRows(rows...))
+}
+
+// usersPosts is the condition users.id = posts.user_id.
+var usersPosts = expr.Binary{
+	Operator: expr.Equal,
+	Left:     expr.Column{Table: "users", Name: "id", Position: 0},
+	Right:    expr.Column{Table: "posts", Name: "user_id", Position: 2},
+}
+
+func joined(id sql.Value, name string, userID sql.Value, title string) sql.Row {
+	text := func(value string) sql.Value {
+		if value == "" {
+			return datatype.NewNull()
+		}
+
+		return datatype.NewText(value)
+	}
+
+	return sql.Row{id, text(name), userID, text(title)}
+}
+
+// joinedRows returns rows of users joined with posts by the type of the join.
+func joinedRows(joinType plan.JoinType) []sql.Row {
+	var (
+		null = datatype.NewNull()
+		two  = datatype.NewInteger(2)
+		four = datatype.NewInteger(4)
+	)
+
+	rows := []sql.Row{
+		joined(two, "bob", two, "p1"),
+		joined(two, "bob", two, "p2"),
+		joined(two, "bea", two, "p1"),
+		joined(two, "bea", two, "p2"),
+		joined(four, "dan", four, "p4"),
+	}
+
+	if joinType == plan.LeftJoin || joinType == plan.FullJoin {
+		rows = append(rows,
+			joined(null, "nil", null, ""),
+			joined(datatype.NewInteger(1), "ann", null, ""),
+		)
+	}
+
+	if joinType == plan.RightJoin || joinType == plan.FullJoin {
+		rows = append(rows,
+			joined(null, "", null, "x"),
+			joined(null, "", datatype.NewInteger(3), "p3"),
+		)
+	}
+
+	return rows
+}
+
+// matchedRows returns rows of users a semi or anti join with posts returns: users that have posts or have none.
+func matchedRows(joinType plan.JoinType) []sql.Row {
+	user := func(id sql.Value, name string) sql.Row {
+		return sql.Row{id, datatype.NewText(name)}
+	}
+
+	if joinType == plan.AntiJoin {
+		return []sql.Row{
+			user(datatype.NewNull(), "nil"),
+			user(datatype.NewInteger(1), "ann"),
+		}
+	}
+
+	return []sql.Row{
+		user(datatype.NewInteger(2), "bob"),
+		user(datatype.NewInteger(2), "bea"),
+		user(datatype.NewInteger(4), "dan"),
+	}
+}
+
+func readRows(t *testing.T, node plan.Node) []sql.Row {
+	t.Helper()
+
+	iter, err := node.RowIter()
+	require.NoError(t, err)
+
+	var rows []sql.Row
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	return rows
+}
+
+func TestNestedLoopJoin_Columns(t *testing.T) {
+	t.Parallel()
+
+	join := plan.NewNestedLoopJoin(plan.InnerJoin, usersPosts, users(), posts())
+	assert.Equal(t, []string{"id", "name", "user_id", "title"}, join.Columns())
+
+	semi := plan.NewNestedLoopJoin(plan.SemiJoin, usersPosts, users(), posts())
+	assert.Equal(t, []string{"id", "name"}, semi.Columns())
+}
+
+func TestNestedLoopJoin_RowIter(t *testing.T) {
+	t.Parallel()
+
+	for _, joinType := range []plan.JoinType{plan.InnerJoin, plan.LeftJoin, plan.RightJoin, plan.FullJoin} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			join := plan.NewNestedLoopJoin(joinType, usersPosts, users(), posts())
+			assert.ElementsMatch(t, joinedRows(joinType), readRows(t, join))
+		})
+	}
+
+	for _, joinType := range []plan.JoinType{plan.SemiJoin, plan.AntiJoin} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			join := plan.NewNestedLoopJoin(joinType, usersPosts, users(), posts())
+			assert.Equal(t, matchedRows(joinType), readRows(t, join))
+		})
+	}
+
+	t.Run("inner join returns pairs in the order of left rows", func(t *testing.T) {
+		t.Parallel()
+
+		join := plan.NewNestedLoopJoin(plan.InnerJoin, usersPosts, users(), posts())
+		assert.Equal(t, joinedRows(plan.InnerJoin), readRows(t, join))
+	})
+
+	t.Run("cross join returns all pairs", func(t *testing.T) {
+		t.Parallel()
+
+		join := plan.NewNestedLoopJoin(plan.CrossJoin, nil, users(), posts())
+		rows := readRows(t, join)
+
+		require.Len(t, rows, 25)
+		assert.Equal(t, joined(datatype.NewNull(), "nil", datatype.NewNull(), "x"), rows[0])
+		assert.Equal(t, joined(datatype.NewInteger(4), "dan", datatype.NewInteger(4), "p4"), rows[24])
+	})
+
+	t.Run("right join of no left rows", func(t *testing.T) {
+		t.Parallel()
+
+		left := plan.NewEmptyRows([]string{"id", "name"})
+		join := plan.NewNestedLoopJoin(plan.RightJoin, usersPosts, left, posts())
+
+		rows := readRows(t, join)
+		require.Len(t, rows, 5)
+		assert.Equal(t, joined(datatype.NewNull(), "", datatype.NewInteger(3), "p3"), rows[3])
+	})
+
+	t.Run("returns error on right row iter error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		right := plan.NewMockNode(ctrl)
+		right.EXPECT().RowIter().Return(nil, io.ErrUnexpectedEOF)
+
+		iter, err := plan.NewNestedLoopJoin(plan.InnerJoin, usersPosts, users(), right).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Nil(t, iter)
+	})
+
+	t.Run("returns error on condition of other type", func(t *testing.T) {
+		t.Parallel()
+
+		cond := expr.Column{Name: "id", Position: 0}
+
+		iter, err := plan.NewNestedLoopJoin(plan.InnerJoin, cond, users(), posts()).RowIter()
+		require.NoError(t, err)
+
+		for {
+			if _, err = iter.Next(); err != nil {
+				break
+			}
+		}
+
+		require.Error(t, err)
+		require.NotErrorIs(t, err, io.EOF)
+		require.NoError(t, iter.Close())
+	})
+}
+
+func TestNestedLoopJoin_Explain(t *testing.T) {
+	t.Parallel()
+
+	left, right := users(), posts()
+
+	description, children := plan.NewNestedLoopJoin(plan.LeftJoin, usersPosts, left, right).Explain()
+	assert.Equal(t, "Nested Loop Left Join: (users.id = posts.user_id)", description)
+	assert.Equal(t, []plan.Node{left, right}, children)
+
+	description, _ = plan.NewNestedLoopJoin(plan.CrossJoin, nil, left, right).Explain()
+	assert.Equal(t, "Nested Loop Cross Join", description)
+}
+
+func TestNestedLoopJoin_WithChildren(t *testing.T) {
+	t.Parallel()
+
+	join := plan.NewNestedLoopJoin(plan.FullJoin, usersPosts, users(), users())
+	rebuilt := join.WithChildren(users(), posts())
+
+	assert.Equal(t, plan.NewNestedLoopJoin(plan.FullJoin, usersPosts, users(), posts()), rebuilt)
+}
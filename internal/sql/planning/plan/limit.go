@@ -37,14 +37,33 @@ func (l *Limit) RowIter() (sql.RowIter, error) {
 	return iter, nil
 }
 
+func (l *Limit) Children() []Node {
+	return []Node{l.child}
+}
+
+func (l *Limit) WithChildren(children ...Node) Node {
+	return NewLimit(l.Value, children[0])
+}
+
+func (l *Limit) Explain() (string, []Node) {
+	return fmt.Sprintf("Limit %d", l.Value), []Node{l.child}
+}
+
+// limitIter stops reading the child iterator once the limit is reached and closes it right away,
+// so a lazy scan under it doesn't read further.
 type limitIter struct {
-	limit int64
-	pos   int64
-	iter  sql.RowIter
+	limit  int64
+	pos    int64
+	iter   sql.RowIter
+	closed bool
 }
 
 func (i *limitIter) Next() (sql.Row, error) {
 	if i.pos >= i.limit {
+		if err := i.Close(); err != nil {
+			return nil, err
+		}
+
 		return nil, io.EOF
 	}
 
@@ -59,5 +78,11 @@ func (i *limitIter) Next() (sql.Row, error) {
 }
 
 func (i *limitIter) Close() error {
+	if i.closed {
+		return nil
+	}
+
+	i.closed = true
+
 	return i.iter.Close()
 }
@@ -42,6 +42,7 @@ func TestLimit_RowIter(t *testing.T) {
 		rowIter := sql.NewMockRowIter(ctrl)
 
 		child.EXPECT().RowIter().Return(rowIter, nil)
+		rowIter.EXPECT().Close().Return(nil)
 
 		limit := plan.NewLimit(-10, child)
 		iter, err := limit.RowIter()
@@ -63,6 +64,7 @@ func TestLimit_RowIter(t *testing.T) {
 		rowIter := sql.NewMockRowIter(ctrl)
 
 		child.EXPECT().RowIter().Return(rowIter, nil)
+		rowIter.EXPECT().Close().Return(nil)
 
 		limit := plan.NewLimit(0, child)
 		iter, err := limit.RowIter()
@@ -113,6 +115,11 @@ func TestLimit_RowIter(t *testing.T) {
 		assert.Equal(t, io.EOF, err)
 		assert.Nil(t, row)
 
+		// The child has been closed at the limit, it's neither read nor closed again.
+		row, err = iter.Next()
+		assert.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+
 		err = iter.Close()
 		require.NoError(t, err)
 	})
@@ -0,0 +1,66 @@
+package plan
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// Lookup reads the row with the primary key.
+type Lookup struct {
+	table sql.Table
+	key   sql.Key
+}
+
+func NewLookup(table sql.Table, key sql.Key) *Lookup {
+	return &Lookup{
+		table: table,
+		key:   key,
+	}
+}
+
+func (l *Lookup) Columns() []string {
+	return NewScan(l.table).Columns()
+}
+
+func (l *Lookup) RowIter() (sql.RowIter, error) {
+	row, ok, err := l.table.Get(l.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	return sql.RowsIter(row), nil
+}
+
+func (l *Lookup) Explain() (string, []Node) {
+	return "Lookup on " + l.table.Name(), nil
+}
+
+// RangeScan reads rows whose leading primary key column lies between the bounds, a nil bound is unbounded.
+type RangeScan struct {
+	table sql.Table
+	from  *sql.Bound
+	to    *sql.Bound
+}
+
+func NewRangeScan(table sql.Table, from, to *sql.Bound) *RangeScan {
+	return &RangeScan{
+		table: table,
+		from:  from,
+		to:    to,
+	}
+}
+
+func (s *RangeScan) Columns() []string {
+	return NewScan(s.table).Columns()
+}
+
+func (s *RangeScan) RowIter() (sql.RowIter, error) {
+	return s.table.ScanRange(s.from, s.to)
+}
+
+func (s *RangeScan) Explain() (string, []Node) {
+	return "Range scan on " + s.table.Name(), nil
+}
@@ -0,0 +1,140 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestLookup_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	table := sql.NewMockTable(ctrl)
+	table.EXPECT().Scheme().Return(scheme)
+
+	lookup := plan.NewLookup(table, sql.IntegerKey(1))
+	assert.Equal(t, []string{"id", "name"}, lookup.Columns())
+}
+
+func TestLookup_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("row found", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := sql.Row{datatype.NewInteger(1), datatype.NewText("Max")}
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Get(sql.IntegerKey(1)).Return(expected, true, nil)
+
+		iter, err := plan.NewLookup(table, sql.IntegerKey(1)).RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected, row)
+
+		row, err = iter.Next()
+		require.ErrorIs(t, err, io.EOF)
+		assert.Nil(t, row)
+	})
+
+	t.Run("row not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Get(sql.IntegerKey(1)).Return(nil, false, nil)
+
+		iter, err := plan.NewLookup(table, sql.IntegerKey(1)).RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.ErrorIs(t, err, io.EOF)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on get", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().Get(sql.IntegerKey(1)).Return(nil, false, expectedErr)
+
+		iter, err := plan.NewLookup(table, sql.IntegerKey(1)).RowIter()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestRangeScan_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		from := &sql.Bound{Value: datatype.NewInteger(1), Inclusive: true}
+		to := &sql.Bound{Value: datatype.NewInteger(10), Inclusive: false}
+
+		table := sql.NewMockTable(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+		table.EXPECT().ScanRange(from, to).Return(rowIter, nil)
+
+		iter, err := plan.NewRangeScan(table, from, to).RowIter()
+		require.NoError(t, err)
+		assert.Equal(t, rowIter, iter)
+	})
+
+	t.Run("returns error on scan range", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		table := sql.NewMockTable(ctrl)
+		table.EXPECT().ScanRange(nil, nil).Return(nil, expectedErr)
+
+		iter, err := plan.NewRangeScan(table, nil, nil).RowIter()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
@@ -0,0 +1,289 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+)
+
+// MergeJoin joins rows of children read in the ascending order of their key columns, keys of both children
+// are of the same type. Both children are read once side by side: only the right rows with the key of the current
+// left row are kept in memory, and the pair is returned if it satisfies the whole condition. A NULL key matches
+// no row.
+type MergeJoin struct {
+	join
+	leftKey  uint8 // the position of the key column in rows of the left child
+	rightKey uint8 // the position of the key column in rows of the right child
+}
+
+func NewMergeJoin(joinType JoinType, cond expr.Node, leftKey, rightKey uint8, left, right Node) *MergeJoin {
+	return &MergeJoin{
+		join: join{
+			joinType: joinType,
+			cond:     cond,
+			left:     left,
+			right:    right,
+		},
+		leftKey:  leftKey,
+		rightKey: rightKey,
+	}
+}
+
+func (m *MergeJoin) RowIter() (sql.RowIter, error) {
+	left, err := m.left.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get left row iter: %w", err)
+	}
+
+	right, err := m.right.RowIter()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("get right row iter: %w", err), left.Close())
+	}
+
+	iter := &mergeIter{
+		join:       m,
+		left:       left,
+		right:      right,
+		leftWidth:  len(m.left.Columns()),
+		rightWidth: len(m.right.Columns()),
+	}
+
+	return iter, nil
+}
+
+// Keys returns positions of the key columns in rows of the left and of the right child.
+func (m *MergeJoin) Keys() (uint8, uint8) {
+	return m.leftKey, m.rightKey
+}
+
+func (m *MergeJoin) WithChildren(children ...Node) Node {
+	return NewMergeJoin(m.joinType, m.cond, m.leftKey, m.rightKey, children[0], children[1])
+}
+
+func (m *MergeJoin) WithExpressions(exprs ...expr.Node) Node {
+	return NewMergeJoin(m.joinType, m.condOf(exprs), m.leftKey, m.rightKey, m.left, m.right)
+}
+
+func (m *MergeJoin) Explain() (string, []Node) {
+	return m.explain("Merge")
+}
+
+// mergeIter joins rows of the left child with the run of right rows that have the same key.
+type mergeIter struct {
+	join  *MergeJoin
+	left  sql.RowIter
+	right sql.RowIter
+
+	leftWidth  int
+	rightWidth int
+
+	run     []sql.Row // right rows with the same key, nil if there is no run
+	runKey  sql.Value
+	matched []bool // rows of the run that matched a row
+
+	next      sql.Row   // the right row read after the run, nil if it has to be read
+	rightDone bool      // all right rows are read
+	leftDone  bool      // all left rows are read
+	queue     []sql.Row // joined rows to return
+}
+
+func (i *mergeIter) Next() (sql.Row, error) {
+	for len(i.queue) == 0 {
+		if i.leftDone {
+			// Right rows left after all left rows match nothing.
+			if !i.join.joinType.keepsRight() {
+				return nil, io.EOF
+			}
+
+			loaded, err := i.nextRun()
+			if err != nil {
+				return nil, err
+			}
+
+			if !loaded && len(i.queue) == 0 {
+				return nil, io.EOF
+			}
+
+			continue
+		}
+
+		row, err := i.left.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			i.leftDone = true
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("get next left row: %w", err)
+		}
+
+		if err = i.joinRow(row); err != nil {
+			return nil, err
+		}
+	}
+
+	row := i.queue[0]
+	i.queue = i.queue[1:]
+
+	return row, nil
+}
+
+// joinRow queues pairs of the left row with the rows of the run that have its key.
+func (i *mergeIter) joinRow(row sql.Row) error {
+	key := row[i.join.leftKey]
+	found := false
+
+	if key.DataType() != sql.Null {
+		c, err := i.seek(key)
+		if err != nil {
+			return err
+		}
+
+		for position := 0; c == sql.Equal && position < len(i.run); position++ {
+			joined := joinRows(row, i.run[position])
+
+			ok, err := i.join.matches(joined)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				found = true
+				i.matched[position] = true
+				i.queue = append(i.queue, joined)
+			}
+		}
+	}
+
+	if !found && i.join.joinType.keepsLeft() {
+		i.queue = append(i.queue, joinRows(row, nulls(i.rightWidth)))
+	}
+
+	return nil
+}
+
+// seek skips runs of keys less than the key and compares the key with the key of the run it stops at.
+// Greater is returned if there are no more runs.
+func (i *mergeIter) seek(key sql.Value) (sql.CompareType, error) {
+	for {
+		if i.run != nil {
+			c, err := comparison.Compare(key, i.runKey)
+			if err != nil {
+				return c, err
+			}
+
+			if c != sql.Greater {
+				return c, nil
+			}
+		}
+
+		loaded, err := i.nextRun()
+		if err != nil {
+			return sql.Equal, err
+		}
+
+		if !loaded {
+			return sql.Greater, nil
+		}
+	}
+}
+
+// nextRun replaces the run with the next right rows that have the same key, false is returned if there are
+// no more right rows. Unmatched rows of the replaced run and right rows with a NULL key are queued if the join
+// returns them.
+func (i *mergeIter) nextRun() (bool, error) {
+	i.flushRun()
+
+	for {
+		row, err := i.nextRight()
+		if err != nil {
+			return false, err
+		}
+
+		if row == nil {
+			break
+		}
+
+		key := row[i.join.rightKey]
+
+		if key.DataType() == sql.Null {
+			i.queueUnmatched(row)
+			continue
+		}
+
+		if i.run == nil {
+			i.run, i.runKey = []sql.Row{row}, key
+			continue
+		}
+
+		c, err := comparison.Compare(key, i.runKey)
+		if err != nil {
+			return false, err
+		}
+
+		if c != sql.Equal {
+			i.next = row
+			break
+		}
+
+		i.run = append(i.run, row)
+	}
+
+	if i.run == nil {
+		return false, nil
+	}
+
+	i.matched = make([]bool, len(i.run))
+
+	return true, nil
+}
+
+// nextRight returns the next right row, nil if there are no more rows.
+func (i *mergeIter) nextRight() (sql.Row, error) {
+	if i.next != nil {
+		row := i.next
+		i.next = nil
+
+		return row, nil
+	}
+
+	if i.rightDone {
+		return nil, nil
+	}
+
+	row, err := i.right.Next()
+	switch {
+	case errors.Is(err, io.EOF):
+		i.rightDone = true
+
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("get next right row: %w", err)
+	}
+
+	return row, nil
+}
+
+// flushRun drops the run, its rows that matched no row are queued if the join returns them.
+func (i *mergeIter) flushRun() {
+	for position, row := range i.run {
+		if !i.matched[position] {
+			i.queueUnmatched(row)
+		}
+	}
+
+	i.run, i.runKey, i.matched = nil, nil, nil
+}
+
+func (i *mergeIter) queueUnmatched(row sql.Row) {
+	if i.join.joinType.keepsRight() {
+		i.queue = append(i.queue, joinRows(nulls(i.leftWidth), row))
+	}
+}
+
+func (i *mergeIter) Close() error {
+	return errors.Join(i.left.Close(), i.right.Close())
+}
@@ -0,0 +1,95 @@
+package plan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestMergeJoin_RowIter(t *testing.T) {
+	t.Parallel()
+
+	for _, joinType := range []plan.JoinType{plan.InnerJoin, plan.LeftJoin, plan.RightJoin, plan.FullJoin} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			t.Parallel()
+
+			join := plan.NewMergeJoin(joinType, usersPosts, 0, 0, users(), posts())
+			assert.ElementsMatch(t, joinedRows(joinType), readRows(t, join))
+		})
+	}
+
+	t.Run("inner join returns pairs in the order of keys", func(t *testing.T) {
+		t.Parallel()
+
+		join := plan.NewMergeJoin(plan.InnerJoin, usersPosts, 0, 0, users(), posts())
+		assert.Equal(t, joinedRows(plan.InnerJoin), readRows(t, join))
+	})
+
+	t.Run("right rows after the last left row", func(t *testing.T) {
+		t.Parallel()
+
+		left := table([]string{"id", "name"}, sql.Row{datatype.NewInteger(1), datatype.NewText("ann")})
+		join := plan.NewMergeJoin(plan.FullJoin, usersPosts, 0, 0, left, posts())
+
+		rows := readRows(t, join)
+		require.Len(t, rows, 6)
+		assert.Equal(t, joined(datatype.NewNull(), "", datatype.NewInteger(4), "p4"), rows[5])
+	})
+
+	t.Run("returns error on keys of other types", func(t *testing.T) {
+		t.Parallel()
+
+		right := table([]string{"user_id", "title"}, sql.Row{datatype.NewText("1"), datatype.NewText("p1")})
+
+		iter, err := plan.NewMergeJoin(plan.InnerJoin, usersPosts, 0, 0, users(), right).RowIter()
+		require.NoError(t, err)
+
+		for {
+			if _, err = iter.Next(); err != nil {
+				break
+			}
+		}
+
+		require.Error(t, err)
+		require.NotErrorIs(t, err, io.EOF)
+		require.NoError(t, iter.Close())
+	})
+
+	t.Run("closes left rows on right row iter error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		left := plan.NewMockNode(ctrl)
+		right := plan.NewMockNode(ctrl)
+		leftIter := sql.NewMockRowIter(ctrl)
+
+		gomock.InOrder(
+			left.EXPECT().RowIter().Return(leftIter, nil),
+			right.EXPECT().RowIter().Return(nil, io.ErrUnexpectedEOF),
+			leftIter.EXPECT().Close().Return(nil),
+		)
+
+		iter, err := plan.NewMergeJoin(plan.InnerJoin, usersPosts, 0, 0, left, right).RowIter()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.Nil(t, iter)
+	})
+}
+
+func TestMergeJoin_Explain(t *testing.T) {
+	t.Parallel()
+
+	left, right := users(), posts()
+
+	description, children := plan.NewMergeJoin(plan.FullJoin, usersPosts, 0, 0, left, right).Explain()
+	assert.Equal(t, "Merge Full Join: (users.id = posts.user_id)", description)
+	assert.Equal(t, []plan.Node{left, right}, children)
+}
@@ -40,6 +40,18 @@ func (o *Offset) RowIter() (sql.RowIter, error) {
 	return iter, nil
 }
 
+func (o *Offset) Children() []Node {
+	return []Node{o.child}
+}
+
+func (o *Offset) WithChildren(children ...Node) Node {
+	return NewOffset(o.Offset, children[0])
+}
+
+func (o *Offset) Explain() (string, []Node) {
+	return fmt.Sprintf("Offset %d", o.Offset), []Node{o.child}
+}
+
 type offsetIter struct {
 	skip int64
 	iter sql.RowIter
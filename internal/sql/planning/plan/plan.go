@@ -10,3 +10,12 @@ type Node interface {
 	Columns() []string
 	RowIter() (sql.RowIter, error)
 }
+
+// Parent is implemented by nodes that read rows of other nodes, it lets the optimizer rewrite the plan.
+type Parent interface {
+	Node
+	// Children returns the nodes whose rows are read.
+	Children() []Node
+	// WithChildren returns a copy of the node that reads rows of the children instead, one per child.
+	WithChildren(children ...Node) Node
+}
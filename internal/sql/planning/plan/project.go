@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
@@ -48,18 +49,51 @@ func (p *Project) RowIter() (sql.RowIter, error) {
 		return nil, fmt.Errorf("get row iter: %w", err)
 	}
 
-	projections := make([]expr.Node, 0, len(p.projections))
+	iter = &projectIter{
+		projections: p.Expressions(),
+		iter:        iter,
+	}
+
+	return iter, nil
+}
+
+// Projections returns expressions of the columns of the node.
+func (p *Project) Projections() []Projection {
+	return p.projections
+}
+
+func (p *Project) Children() []Node {
+	return []Node{p.child}
+}
+
+func (p *Project) WithChildren(children ...Node) Node {
+	return NewProject(p.projections, children[0])
+}
+
+// Expressions returns expressions of the columns in their order.
+func (p *Project) Expressions() []expr.Node {
+	exprs := make([]expr.Node, 0, len(p.projections))
 
 	for i := range p.projections {
-		projections = append(projections, p.projections[i].Expr)
+		exprs = append(exprs, p.projections[i].Expr)
 	}
 
-	iter = &projectIter{
-		projections: projections,
-		iter:        iter,
+	return exprs
+}
+
+// WithExpressions returns a copy of the node whose columns have the same names and the expressions instead.
+func (p *Project) WithExpressions(exprs ...expr.Node) Node {
+	projections := make([]Projection, 0, len(p.projections))
+
+	for i := range p.projections {
+		projections = append(projections, Projection{Alias: p.projections[i].Alias, Expr: exprs[i]})
 	}
 
-	return iter, nil
+	return NewProject(projections, p.child)
+}
+
+func (p *Project) Explain() (string, []Node) {
+	return "Project: " + strings.Join(p.Columns(), ", "), []Node{p.child}
 }
 
 type projectIter struct {
@@ -5,19 +5,37 @@ import (
 )
 
 type Rows struct {
-	rows []sql.Row
+	columns []string
+	rows    []sql.Row
 }
 
-func NewRows(row ...sql.Row) Rows {
-	return Rows{
+func NewRows(row ...sql.Row) *Rows {
+	return &Rows{
 		rows: row,
 	}
 }
 
-func (e Rows) Columns() []string {
-	return nil
+// NewEmptyRows returns no rows of the columns. It replaces a node known to produce no rows, so parents that
+// rely on the number of columns of their children, like joins, still get it.
+func NewEmptyRows(columns []string) *Rows {
+	return &Rows{
+		columns: columns,
+	}
+}
+
+func (e *Rows) Columns() []string {
+	return e.columns
 }
 
-func (e Rows) RowIter() (sql.RowIter, error) {
+func (e *Rows) RowIter() (sql.RowIter, error) {
 	return sql.RowsIter(e.rows...), nil
 }
+
+// Len returns the number of rows.
+func (e *Rows) Len() int {
+	return len(e.rows)
+}
+
+func (e *Rows) Explain() (string, []Node) {
+	return "Rows", nil
+}
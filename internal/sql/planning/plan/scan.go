@@ -4,8 +4,11 @@ import (
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 )
 
+// Scan reads all rows of the table. A scan of a part of columns reads only them from tables
+// that store columns separately (sql.ColumnScanner), other values of rows may be NULL.
 type Scan struct {
-	table sql.Table
+	table   sql.Table
+	columns []uint8
 }
 
 func NewScan(table sql.Table) *Scan {
@@ -14,6 +17,14 @@ func NewScan(table sql.Table) *Scan {
 	}
 }
 
+// NewColumnScan returns a scan of the table that needs only the columns at the positions.
+func NewColumnScan(table sql.Table, columns []uint8) *Scan {
+	return &Scan{
+		table:   table,
+		columns: columns,
+	}
+}
+
 func (s *Scan) Columns() []string {
 	scheme := s.table.Scheme()
 	columns := make([]string, len(scheme))
@@ -27,5 +38,23 @@ func (s *Scan) Columns() []string {
 }
 
 func (s *Scan) RowIter() (sql.RowIter, error) {
+	if scanner, ok := s.table.(sql.ColumnScanner); ok && s.columns != nil {
+		return scanner.ScanColumns(s.columns)
+	}
+
 	return s.table.Scan()
 }
+
+// Table returns the table that is read.
+func (s *Scan) Table() sql.Table {
+	return s.table
+}
+
+// ColumnPositions returns positions of the columns the scan reads, nil if it reads all columns.
+func (s *Scan) ColumnPositions() []uint8 {
+	return s.columns
+}
+
+func (s *Scan) Explain() (string, []Node) {
+	return "Scan on " + s.table.Name(), nil
+}
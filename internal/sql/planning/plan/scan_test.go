@@ -10,6 +10,7 @@ import (
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/sqltest"
 )
 
 func TestScan_Columns(t *testing.T) {
@@ -89,3 +90,39 @@ func TestScan_RowIter(t *testing.T) {
 		require.Nil(t, iter)
 	})
 }
+
+func TestColumnScan_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads the columns", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sqltest.NewColumnTable(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		table.MockColumnScanner.EXPECT().ScanColumns([]uint8{1}).Return(rowIter, nil)
+
+		iter, err := plan.NewColumnScan(table, []uint8{1}).RowIter()
+		require.NoError(t, err)
+		require.Equal(t, rowIter, iter)
+	})
+
+	t.Run("reads whole rows of other tables", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		table := sql.NewMockTable(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		table.EXPECT().Scan().Return(rowIter, nil)
+
+		iter, err := plan.NewColumnScan(table, []uint8{1}).RowIter()
+		require.NoError(t, err)
+		require.Equal(t, rowIter, iter)
+	})
+}
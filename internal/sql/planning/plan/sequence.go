@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=sequence.go -destination ./sequence_mock_test.go -package plan_test
+
+type SequenceCreator interface {
+	CreateSequence(name string, options sql.SequenceOptions) (sql.NamedSequence, error)
+}
+
+type CreateSequence struct {
+	creator SequenceCreator
+	name    string
+	options sql.SequenceOptions
+}
+
+func NewCreateSequence(creator SequenceCreator, name string, options sql.SequenceOptions) *CreateSequence {
+	return &CreateSequence{
+		creator: creator,
+		name:    name,
+		options: options,
+	}
+}
+
+func (s *CreateSequence) Columns() []string {
+	return nil
+}
+
+func (s *CreateSequence) RowIter() (sql.RowIter, error) {
+	if _, err := s.creator.CreateSequence(s.name, s.options); err != nil {
+		return nil, fmt.Errorf("create sequence: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
+
+type SequenceDropper interface {
+	DropSequence(name string) error
+}
+
+type DropSequence struct {
+	dropper SequenceDropper
+	name    string
+}
+
+func NewDropSequence(dropper SequenceDropper, name string) *DropSequence {
+	return &DropSequence{
+		dropper: dropper,
+		name:    name,
+	}
+}
+
+func (s *DropSequence) Columns() []string {
+	return nil
+}
+
+func (s *DropSequence) RowIter() (sql.RowIter, error) {
+	if err := s.dropper.DropSequence(s.name); err != nil {
+		return nil, fmt.Errorf("drop sequence: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
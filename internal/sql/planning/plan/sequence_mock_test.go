@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sequence.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=sequence.go -destination ./sequence_mock_test.go -package plan_test
+//
+
+// Package plan_test is a generated GoMock package.
+package plan_test
+
+import (
+	reflect "reflect"
+
+	sql "github.com/i-sevostyanov/NanoDB/internal/sql"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSequenceCreator is a mock of SequenceCreator interface.
+type MockSequenceCreator struct {
+	ctrl     *gomock.Controller
+	recorder *MockSequenceCreatorMockRecorder
+}
+
+// MockSequenceCreatorMockRecorder is the mock recorder for MockSequenceCreator.
+type MockSequenceCreatorMockRecorder struct {
+	mock *MockSequenceCreator
+}
+
+// NewMockSequenceCreator creates a new mock instance.
+func NewMockSequenceCreator(ctrl *gomock.Controller) *MockSequenceCreator {
+	mock := &MockSequenceCreator{ctrl: ctrl}
+	mock.recorder = &MockSequenceCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSequenceCreator) EXPECT() *MockSequenceCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateSequence mocks base method.
+func (m *MockSequenceCreator) CreateSequence(name string, options sql.SequenceOptions) (sql.NamedSequence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSequence", name, options)
+	ret0, _ := ret[0].(sql.NamedSequence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSequence indicates an expected call of CreateSequence.
+func (mr *MockSequenceCreatorMockRecorder) CreateSequence(name, options any) *MockSequenceCreatorCreateSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSequence", reflect.TypeOf((*MockSequenceCreator)(nil).CreateSequence), name, options)
+	return &MockSequenceCreatorCreateSequenceCall{Call: call}
+}
+
+// MockSequenceCreatorCreateSequenceCall wrap *gomock.Call
+type MockSequenceCreatorCreateSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSequenceCreatorCreateSequenceCall) Return(arg0 sql.NamedSequence, arg1 error) *MockSequenceCreatorCreateSequenceCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSequenceCreatorCreateSequenceCall) Do(f func(string, sql.SequenceOptions) (sql.NamedSequence, error)) *MockSequenceCreatorCreateSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSequenceCreatorCreateSequenceCall) DoAndReturn(f func(string, sql.SequenceOptions) (sql.NamedSequence, error)) *MockSequenceCreatorCreateSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockSequenceDropper is a mock of SequenceDropper interface.
+type MockSequenceDropper struct {
+	ctrl     *gomock.Controller
+	recorder *MockSequenceDropperMockRecorder
+}
+
+// MockSequenceDropperMockRecorder is the mock recorder for MockSequenceDropper.
+type MockSequenceDropperMockRecorder struct {
+	mock *MockSequenceDropper
+}
+
+// NewMockSequenceDropper creates a new mock instance.
+func NewMockSequenceDropper(ctrl *gomock.Controller) *MockSequenceDropper {
+	mock := &MockSequenceDropper{ctrl: ctrl}
+	mock.recorder = &MockSequenceDropperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSequenceDropper) EXPECT() *MockSequenceDropperMockRecorder {
+	return m.recorder
+}
+
+// DropSequence mocks base method.
+func (m *MockSequenceDropper) DropSequence(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropSequence", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropSequence indicates an expected call of DropSequence.
+func (mr *MockSequenceDropperMockRecorder) DropSequence(name any) *MockSequenceDropperDropSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropSequence", reflect.TypeOf((*MockSequenceDropper)(nil).DropSequence), name)
+	return &MockSequenceDropperDropSequenceCall{Call: call}
+}
+
+// MockSequenceDropperDropSequenceCall wrap *gomock.Call
+type MockSequenceDropperDropSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSequenceDropperDropSequenceCall) Return(arg0 error) *MockSequenceDropperDropSequenceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSequenceDropperDropSequenceCall) Do(f func(string) error) *MockSequenceDropperDropSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSequenceDropperDropSequenceCall) DoAndReturn(f func(string) error) *MockSequenceDropperDropSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
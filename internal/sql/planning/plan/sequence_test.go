@@ -0,0 +1,123 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestCreateSequence_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	creator := NewMockSequenceCreator(ctrl)
+	createPlan := plan.NewCreateSequence(creator, "test", sql.SequenceOptions{Start: 1, Increment: 1})
+	assert.Nil(t, createPlan.Columns())
+}
+
+func TestCreateSequence_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		options := sql.SequenceOptions{Start: 100, Increment: 10}
+
+		creator := NewMockSequenceCreator(ctrl)
+		creator.EXPECT().CreateSequence(name, options).Return(nil, nil)
+
+		createPlan := plan.NewCreateSequence(creator, name, options)
+		iter, err := createPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on create sequence", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		options := sql.SequenceOptions{Start: 100, Increment: 10}
+		expectedErr := errors.New("something went wrong")
+
+		creator := NewMockSequenceCreator(ctrl)
+		creator.EXPECT().CreateSequence(name, options).Return(nil, expectedErr)
+
+		createPlan := plan.NewCreateSequence(creator, name, options)
+		iter, err := createPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestDropSequence_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dropper := NewMockSequenceDropper(ctrl)
+	dropPlan := plan.NewDropSequence(dropper, "test")
+	assert.Nil(t, dropPlan.Columns())
+}
+
+func TestDropSequence_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		dropper := NewMockSequenceDropper(ctrl)
+		dropper.EXPECT().DropSequence(name).Return(nil)
+
+		dropPlan := plan.NewDropSequence(dropper, name)
+		iter, err := dropPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on drop sequence", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		name := "test"
+		expectedErr := errors.New("something went wrong")
+
+		dropper := NewMockSequenceDropper(ctrl)
+		dropper.EXPECT().DropSequence(name).Return(expectedErr)
+
+		dropPlan := plan.NewDropSequence(dropper, name)
+		iter, err := dropPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
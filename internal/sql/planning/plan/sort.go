@@ -51,6 +51,40 @@ func (s *Sort) RowIter() (sql.RowIter, error) {
 	return iter, nil
 }
 
+// Column returns the position of the column rows are sorted by.
+func (s *Sort) Column() uint8 {
+	return s.columnPos
+}
+
+// Order returns the order rows are sorted in.
+func (s *Sort) Order() Order {
+	return s.order
+}
+
+func (s *Sort) Children() []Node {
+	return []Node{s.child}
+}
+
+func (s *Sort) WithChildren(children ...Node) Node {
+	return NewSort(s.columnPos, s.order, children[0])
+}
+
+func (s *Sort) Explain() (string, []Node) {
+	var column string
+
+	if columns := s.child.Columns(); int(s.columnPos) < len(columns) {
+		column = columns[s.columnPos]
+	}
+
+	order := "asc"
+
+	if s.order == Descending {
+		order = "desc"
+	}
+
+	return fmt.Sprintf("Sort by %s %s", column, order), []Node{s.child}
+}
+
 type sortIter struct {
 	columnPos uint8
 	order     Order
@@ -0,0 +1,125 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+)
+
+// SortAggregate aggregates groups of rows of the child read in the order of the group expressions, so rows
+// of a group come one after another. Only the current group is kept in memory and it's returned as soon as a row
+// of the next group is read.
+type SortAggregate struct {
+	aggregation
+}
+
+func NewSortAggregate(groups []expr.Node, aggregates []*expr.Aggregate, child Node) *SortAggregate {
+	return &SortAggregate{
+		aggregation: aggregation{
+			groups:     groups,
+			aggregates: aggregates,
+			child:      child,
+		},
+	}
+}
+
+func (s *SortAggregate) RowIter() (sql.RowIter, error) {
+	iter, err := s.child.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter: %w", err)
+	}
+
+	return &sortAggregateIter{aggregate: s, iter: iter}, nil
+}
+
+func (s *SortAggregate) WithChildren(children ...Node) Node {
+	return NewSortAggregate(s.groups, s.aggregates, children[0])
+}
+
+func (s *SortAggregate) WithExpressions(exprs ...expr.Node) Node {
+	groups, aggregates := s.split(exprs)
+
+	return NewSortAggregate(groups, aggregates, s.child)
+}
+
+func (s *SortAggregate) Explain() (string, []Node) {
+	return s.explain("Sort")
+}
+
+type sortAggregateIter struct {
+	aggregate *SortAggregate
+	iter      sql.RowIter
+	group     *group // the current group, nil if no row is read yet
+	started   bool   // a row was read or the single group was returned
+	done      bool   // all rows are read
+}
+
+func (i *sortAggregateIter) Next() (sql.Row, error) {
+	for !i.done {
+		row, err := i.iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			i.done = true
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("get next row: %w", err)
+		}
+
+		i.started = true
+
+		values, err := i.aggregate.groupValues(row)
+		if err != nil {
+			return nil, err
+		}
+
+		var finished *group
+
+		if i.group != nil {
+			if !notDistinct(i.group.values, values) {
+				finished, i.group = i.group, nil
+			}
+		}
+
+		if i.group == nil {
+			if i.group, err = i.aggregate.newGroup(values); err != nil {
+				return nil, err
+			}
+		}
+
+		if err = i.group.add(row); err != nil {
+			return nil, err
+		}
+
+		if finished != nil {
+			return finished.row(), nil
+		}
+	}
+
+	// Without group expressions the single group is returned even if there are no rows.
+	if !i.started && len(i.aggregate.groups) == 0 {
+		i.started = true
+
+		g, err := i.aggregate.newGroup(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return g.row(), nil
+	}
+
+	if i.group == nil {
+		return nil, io.EOF
+	}
+
+	last := i.group
+	i.group = nil
+
+	return last.row(), nil
+}
+
+func (i *sortAggregateIter) Close() error {
+	return i.iter.Close()
+}
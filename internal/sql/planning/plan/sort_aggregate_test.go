@@ -0,0 +1,85 @@
+package plan_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestSortAggregate_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aggregates groups of sorted rows", func(t *testing.T) {
+		t.Parallel()
+
+		node := plan.NewSortAggregate([]expr.Node{model}, aggregates(), aircrafts())
+		assert.Equal(t, aggregatedRows(), readRows(t, node))
+	})
+
+	t.Run("single group", func(t *testing.T) {
+		t.Parallel()
+
+		count := []*expr.Aggregate{aggregate(expr.CountFunc, nil, false)}
+
+		rows := readRows(t, plan.NewSortAggregate(nil, count, aircrafts()))
+		assert.Equal(t, []sql.Row{{datatype.NewInteger(5)}}, rows)
+
+		rows = readRows(t, plan.NewSortAggregate(nil, count, plan.NewEmptyRows([]string{"model"})))
+		assert.Equal(t, []sql.Row{{datatype.NewInteger(0)}}, rows)
+	})
+
+	t.Run("no groups of no rows", func(t *testing.T) {
+		t.Parallel()
+
+		node := plan.NewSortAggregate([]expr.Node{model}, aggregates(), plan.NewEmptyRows([]string{"model"}))
+		assert.Empty(t, readRows(t, node))
+	})
+
+	t.Run("returns error on next row error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		iter := sql.NewMockRowIter(ctrl)
+
+		child.EXPECT().RowIter().Return(iter, nil)
+		iter.EXPECT().Next().Return(nil, io.ErrUnexpectedEOF)
+		iter.EXPECT().Close().Return(nil)
+
+		rowIter, err := plan.NewSortAggregate([]expr.Node{model}, aggregates(), child).RowIter()
+		require.NoError(t, err)
+
+		_, err = rowIter.Next()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.NoError(t, rowIter.Close())
+	})
+}
+
+func TestSortAggregate_Explain(t *testing.T) {
+	t.Parallel()
+
+	child := aircrafts()
+
+	description, children := plan.NewSortAggregate([]expr.Node{model}, aggregates()[5:], child).Explain()
+	assert.Equal(t, "Sort Aggregate by model: avg(range), min(weight), max(model)", description)
+	assert.Equal(t, []plan.Node{child}, children)
+}
+
+func TestSortAggregate_WithChildren(t *testing.T) {
+	t.Parallel()
+
+	node := plan.NewSortAggregate([]expr.Node{model}, aggregates(), users())
+	rebuilt := node.WithChildren(aircrafts())
+
+	assert.Equal(t, plan.NewSortAggregate([]expr.Node{model}, aggregates(), aircrafts()), rebuilt)
+}
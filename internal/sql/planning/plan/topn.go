@@ -0,0 +1,196 @@
+package plan
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+)
+
+// TopN returns the first N rows of the child in the order of the column, the same rows as a Limit over a Sort.
+// It keeps only N rows in memory instead of sorting all of them.
+type TopN struct {
+	n         int64
+	columnPos uint8
+	order     Order
+	child     Node
+}
+
+func NewTopN(n int64, columnPos uint8, order Order, child Node) *TopN {
+	return &TopN{
+		n:         n,
+		columnPos: columnPos,
+		order:     order,
+		child:     child,
+	}
+}
+
+func (t *TopN) Columns() []string {
+	return t.child.Columns()
+}
+
+func (t *TopN) RowIter() (sql.RowIter, error) {
+	if t.order != Ascending && t.order != Descending {
+		return nil, fmt.Errorf("unknown sort order: %v", t.order)
+	}
+
+	iter, err := t.child.RowIter()
+	if err != nil {
+		return nil, fmt.Errorf("get row iter: %w", err)
+	}
+
+	iter = &topNIter{
+		n:         t.n,
+		columnPos: t.columnPos,
+		order:     t.order,
+		iter:      iter,
+		index:     -1,
+	}
+
+	return iter, nil
+}
+
+// N returns the number of rows the node returns at most.
+func (t *TopN) N() int64 {
+	return t.n
+}
+
+// Column returns the position of the column rows are sorted by.
+func (t *TopN) Column() uint8 {
+	return t.columnPos
+}
+
+// Order returns the order rows are sorted in.
+func (t *TopN) Order() Order {
+	return t.order
+}
+
+func (t *TopN) Children() []Node {
+	return []Node{t.child}
+}
+
+func (t *TopN) WithChildren(children ...Node) Node {
+	return NewTopN(t.n, t.columnPos, t.order, children[0])
+}
+
+func (t *TopN) Explain() (string, []Node) {
+	var column string
+
+	if columns := t.child.Columns(); int(t.columnPos) < len(columns) {
+		column = columns[t.columnPos]
+	}
+
+	order := "asc"
+
+	if t.order == Descending {
+		order = "desc"
+	}
+
+	return fmt.Sprintf("Top %d by %s %s", t.n, column, order), []Node{t.child}
+}
+
+type topNIter struct {
+	n         int64
+	columnPos uint8
+	order     Order
+	rows      []sql.Row
+	iter      sql.RowIter
+	index     int
+}
+
+func (i *topNIter) Next() (sql.Row, error) {
+	if i.index == -1 {
+		if err := i.selectRows(); err != nil {
+			return nil, err
+		}
+	}
+
+	if i.index >= len(i.rows)-1 {
+		return nil, io.EOF
+	}
+
+	i.index++
+
+	return i.rows[i.index], nil
+}
+
+func (i *topNIter) Close() error {
+	i.rows = nil
+
+	return i.iter.Close()
+}
+
+// selectRows reads all rows of the child and keeps the first N of them in a heap whose root is the last row
+// kept, so a row that goes before it replaces it.
+func (i *topNIter) selectRows() error {
+	rows := &rowHeap{
+		columnPos: i.columnPos,
+		order:     i.order,
+	}
+
+	for {
+		row, err := i.iter.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			sort.Sort(sort.Reverse(rows))
+			i.rows = rows.rows
+
+			return nil
+		case err != nil:
+			return fmt.Errorf("get next row: %w", err)
+		}
+
+		switch {
+		case int64(rows.Len()) < i.n:
+			heap.Push(rows, row)
+		case i.n > 0 && rows.before(row, rows.rows[0]):
+			rows.rows[0] = row
+			heap.Fix(rows, 0)
+		}
+	}
+}
+
+// rowHeap is a heap of rows whose root is the row that goes last in the order of the column.
+type rowHeap struct {
+	columnPos uint8
+	order     Order
+	rows      []sql.Row
+}
+
+// before reports whether the row a goes before the row b in the order of the column.
+func (h *rowHeap) before(a, b sql.Row) bool {
+	c, _ := comparison.Compare(a[h.columnPos], b[h.columnPos])
+
+	if h.order == Descending {
+		return c == sql.Greater
+	}
+
+	return c == sql.Less
+}
+
+func (h *rowHeap) Len() int {
+	return len(h.rows)
+}
+
+func (h *rowHeap) Less(x, y int) bool {
+	return h.before(h.rows[y], h.rows[x])
+}
+
+func (h *rowHeap) Swap(x, y int) {
+	h.rows[x], h.rows[y] = h.rows[y], h.rows[x]
+}
+
+func (h *rowHeap) Push(row any) {
+	h.rows = append(h.rows, row.(sql.Row))
+}
+
+func (h *rowHeap) Pop() any {
+	row := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+
+	return row
+}
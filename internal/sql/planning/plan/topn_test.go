@@ -0,0 +1,157 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestTopN_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	columns := []string{"id", "name"}
+
+	child := plan.NewMockNode(ctrl)
+	child.EXPECT().Columns().Return(columns)
+
+	top := plan.NewTopN(3, 1, plan.Descending, child)
+	assert.Equal(t, columns, top.Columns())
+}
+
+func TestTopN_RowIter(t *testing.T) {
+	t.Parallel()
+
+	rows := []sql.Row{
+		{datatype.NewInteger(4), datatype.NewText("d")},
+		{datatype.NewInteger(1), datatype.NewText("a")},
+		{datatype.NewInteger(5), datatype.NewText("e")},
+		{datatype.NewInteger(3), datatype.NewText("c")},
+		{datatype.NewInteger(2), datatype.NewText("b")},
+	}
+
+	tests := []struct {
+		name     string
+		n        int64
+		order    plan.Order
+		expected []sql.Row
+	}{
+		{
+			name:     "ascending",
+			n:        2,
+			order:    plan.Ascending,
+			expected: []sql.Row{rows[1], rows[4]},
+		},
+		{
+			name:     "descending",
+			n:        3,
+			order:    plan.Descending,
+			expected: []sql.Row{rows[2], rows[0], rows[3]},
+		},
+		{
+			name:     "more than rows",
+			n:        10,
+			order:    plan.Ascending,
+			expected: []sql.Row{rows[1], rows[4], rows[3], rows[0], rows[2]},
+		},
+		{
+			name:     "max int",
+			n:        math.MaxInt64,
+			order:    plan.Descending,
+			expected: []sql.Row{rows[2], rows[0], rows[3], rows[4], rows[1]},
+		},
+		{
+			name:     "zero",
+			n:        0,
+			order:    plan.Ascending,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			top := plan.NewTopN(test.n, 0, test.order, plan.NewRows(rows...))
+
+			iter, err := top.RowIter()
+			require.NoError(t, err)
+
+			var actual []sql.Row
+
+			for {
+				row, err := iter.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				actual = append(actual, row)
+			}
+
+			require.NoError(t, iter.Close())
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	t.Run("returns error on unknown order", func(t *testing.T) {
+		t.Parallel()
+
+		top := plan.NewTopN(1, 0, plan.Order(100), plan.NewRows(rows...))
+
+		iter, err := top.RowIter()
+		require.Error(t, err)
+		require.Nil(t, iter)
+	})
+
+	t.Run("returns error on child row iter error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		child.EXPECT().RowIter().Return(nil, io.ErrUnexpectedEOF)
+
+		iter, err := plan.NewTopN(1, 0, plan.Ascending, child).RowIter()
+		require.Error(t, err)
+		require.Nil(t, iter)
+	})
+
+	t.Run("returns error on next row error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			rowIter.EXPECT().Next().Return(nil, io.ErrUnexpectedEOF),
+			rowIter.EXPECT().Close().Return(nil),
+		)
+
+		iter, err := plan.NewTopN(1, 0, plan.Ascending, child).RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Error(t, err)
+		require.Nil(t, row)
+		require.NoError(t, iter.Close())
+	})
+}
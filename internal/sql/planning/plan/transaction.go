@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=transaction.go -destination ./transaction_mock_test.go -package plan_test
+
+type TxBeginner interface {
+	Begin() error
+}
+
+type Begin struct {
+	beginner TxBeginner
+}
+
+func NewBegin(beginner TxBeginner) *Begin {
+	return &Begin{
+		beginner: beginner,
+	}
+}
+
+func (b *Begin) Columns() []string {
+	return nil
+}
+
+func (b *Begin) RowIter() (sql.RowIter, error) {
+	if err := b.beginner.Begin(); err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
+
+type TxCommitter interface {
+	Commit() error
+}
+
+type Commit struct {
+	committer TxCommitter
+}
+
+func NewCommit(committer TxCommitter) *Commit {
+	return &Commit{
+		committer: committer,
+	}
+}
+
+func (c *Commit) Columns() []string {
+	return nil
+}
+
+func (c *Commit) RowIter() (sql.RowIter, error) {
+	if err := c.committer.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
+
+type TxRollbacker interface {
+	Rollback() error
+}
+
+type Rollback struct {
+	rollbacker TxRollbacker
+}
+
+func NewRollback(rollbacker TxRollbacker) *Rollback {
+	return &Rollback{
+		rollbacker: rollbacker,
+	}
+}
+
+func (r *Rollback) Columns() []string {
+	return nil
+}
+
+func (r *Rollback) RowIter() (sql.RowIter, error) {
+	if err := r.rollbacker.Rollback(); err != nil {
+		return nil, fmt.Errorf("rollback transaction: %w", err)
+	}
+
+	return sql.RowsIter(), nil
+}
@@ -0,0 +1,199 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: transaction.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=transaction.go -destination ./transaction_mock_test.go -package plan_test
+//
+
+// Package plan_test is a generated GoMock package.
+package plan_test
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTxBeginner is a mock of TxBeginner interface.
+type MockTxBeginner struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxBeginnerMockRecorder
+}
+
+// MockTxBeginnerMockRecorder is the mock recorder for MockTxBeginner.
+type MockTxBeginnerMockRecorder struct {
+	mock *MockTxBeginner
+}
+
+// NewMockTxBeginner creates a new mock instance.
+func NewMockTxBeginner(ctrl *gomock.Controller) *MockTxBeginner {
+	mock := &MockTxBeginner{ctrl: ctrl}
+	mock.recorder = &MockTxBeginnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxBeginner) EXPECT() *MockTxBeginnerMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockTxBeginner) Begin() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockTxBeginnerMockRecorder) Begin() *MockTxBeginnerBeginCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockTxBeginner)(nil).Begin))
+	return &MockTxBeginnerBeginCall{Call: call}
+}
+
+// MockTxBeginnerBeginCall wrap *gomock.Call
+type MockTxBeginnerBeginCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTxBeginnerBeginCall) Return(arg0 error) *MockTxBeginnerBeginCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTxBeginnerBeginCall) Do(f func() error) *MockTxBeginnerBeginCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTxBeginnerBeginCall) DoAndReturn(f func() error) *MockTxBeginnerBeginCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockTxCommitter is a mock of TxCommitter interface.
+type MockTxCommitter struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxCommitterMockRecorder
+}
+
+// MockTxCommitterMockRecorder is the mock recorder for MockTxCommitter.
+type MockTxCommitterMockRecorder struct {
+	mock *MockTxCommitter
+}
+
+// NewMockTxCommitter creates a new mock instance.
+func NewMockTxCommitter(ctrl *gomock.Controller) *MockTxCommitter {
+	mock := &MockTxCommitter{ctrl: ctrl}
+	mock.recorder = &MockTxCommitterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxCommitter) EXPECT() *MockTxCommitterMockRecorder {
+	return m.recorder
+}
+
+// Commit mocks base method.
+func (m *MockTxCommitter) Commit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockTxCommitterMockRecorder) Commit() *MockTxCommitterCommitCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTxCommitter)(nil).Commit))
+	return &MockTxCommitterCommitCall{Call: call}
+}
+
+// MockTxCommitterCommitCall wrap *gomock.Call
+type MockTxCommitterCommitCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTxCommitterCommitCall) Return(arg0 error) *MockTxCommitterCommitCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTxCommitterCommitCall) Do(f func() error) *MockTxCommitterCommitCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTxCommitterCommitCall) DoAndReturn(f func() error) *MockTxCommitterCommitCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockTxRollbacker is a mock of TxRollbacker interface.
+type MockTxRollbacker struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxRollbackerMockRecorder
+}
+
+// MockTxRollbackerMockRecorder is the mock recorder for MockTxRollbacker.
+type MockTxRollbackerMockRecorder struct {
+	mock *MockTxRollbacker
+}
+
+// NewMockTxRollbacker creates a new mock instance.
+func NewMockTxRollbacker(ctrl *gomock.Controller) *MockTxRollbacker {
+	mock := &MockTxRollbacker{ctrl: ctrl}
+	mock.recorder = &MockTxRollbackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxRollbacker) EXPECT() *MockTxRollbackerMockRecorder {
+	return m.recorder
+}
+
+// Rollback mocks base method.
+func (m *MockTxRollbacker) Rollback() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockTxRollbackerMockRecorder) Rollback() *MockTxRollbackerRollbackCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTxRollbacker)(nil).Rollback))
+	return &MockTxRollbackerRollbackCall{Call: call}
+}
+
+// MockTxRollbackerRollbackCall wrap *gomock.Call
+type MockTxRollbackerRollbackCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTxRollbackerRollbackCall) Return(arg0 error) *MockTxRollbackerRollbackCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTxRollbackerRollbackCall) Do(f func() error) *MockTxRollbackerRollbackCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTxRollbackerRollbackCall) DoAndReturn(f func() error) *MockTxRollbackerRollbackCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
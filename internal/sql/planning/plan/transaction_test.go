@@ -0,0 +1,166 @@
+package plan_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+func TestBegin_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	beginner := NewMockTxBeginner(ctrl)
+	beginPlan := plan.NewBegin(beginner)
+	assert.Nil(t, beginPlan.Columns())
+}
+
+func TestBegin_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		beginner := NewMockTxBeginner(ctrl)
+		beginner.EXPECT().Begin().Return(nil)
+
+		beginPlan := plan.NewBegin(beginner)
+		iter, err := beginPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on begin", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		beginner := NewMockTxBeginner(ctrl)
+		beginner.EXPECT().Begin().Return(expectedErr)
+
+		beginPlan := plan.NewBegin(beginner)
+		iter, err := beginPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestCommit_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	committer := NewMockTxCommitter(ctrl)
+	commitPlan := plan.NewCommit(committer)
+	assert.Nil(t, commitPlan.Columns())
+}
+
+func TestCommit_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		committer := NewMockTxCommitter(ctrl)
+		committer.EXPECT().Commit().Return(nil)
+
+		commitPlan := plan.NewCommit(committer)
+		iter, err := commitPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on commit", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		committer := NewMockTxCommitter(ctrl)
+		committer.EXPECT().Commit().Return(expectedErr)
+
+		commitPlan := plan.NewCommit(committer)
+		iter, err := commitPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestRollback_Columns(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rollbacker := NewMockTxRollbacker(ctrl)
+	rollbackPlan := plan.NewRollback(rollbacker)
+	assert.Nil(t, rollbackPlan.Columns())
+}
+
+func TestRollback_RowIter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rollbacker := NewMockTxRollbacker(ctrl)
+		rollbacker.EXPECT().Rollback().Return(nil)
+
+		rollbackPlan := plan.NewRollback(rollbacker)
+		iter, err := rollbackPlan.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.Equal(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error on rollback", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		rollbacker := NewMockTxRollbacker(ctrl)
+		rollbacker.EXPECT().Rollback().Return(expectedErr)
+
+		rollbackPlan := plan.NewRollback(rollbacker)
+		iter, err := rollbackPlan.RowIter()
+
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, iter)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
@@ -11,23 +12,29 @@ import (
 
 //go:generate go run go.uber.org/mock/mockgen -typed -source=update.go -destination ./update_mock_test.go -package plan_test
 
+// RowUpdater changes rows of a table. A row whose primary key is changed is moved: it's deleted and inserted
+// under the new key.
 type RowUpdater interface {
-	Update(key int64, row sql.Row) error
+	Get(key sql.Key) (sql.Row, bool, error)
+	Insert(key sql.Key, row sql.Row) error
+	Update(key sql.Key, row sql.Row) error
+	Delete(key sql.Key) error
 }
 
 type Update struct {
-	updater RowUpdater
-	child   Node
-	pkIndex uint8
-	columns map[uint8]expr.Node
+	updater    RowUpdater
+	child      Node
+	primaryKey []uint8
+	columns    map[uint8]expr.Node
 }
 
-func NewUpdate(updater RowUpdater, pkIndex uint8, columns map[uint8]expr.Node, child Node) *Update {
+// NewUpdate returns the node that updates rows of the child, primaryKey is positions of the primary key columns.
+func NewUpdate(updater RowUpdater, primaryKey []uint8, columns map[uint8]expr.Node, child Node) *Update {
 	return &Update{
-		updater: updater,
-		child:   child,
-		pkIndex: pkIndex,
-		columns: columns,
+		updater:    updater,
+		child:      child,
+		primaryKey: primaryKey,
+		columns:    columns,
 	}
 }
 
@@ -42,28 +49,166 @@ func (u *Update) RowIter() (sql.RowIter, error) {
 	}
 
 	iter = &updateIter{
-		iter:    iter,
-		updater: u.updater,
-		pkIndex: u.pkIndex,
-		columns: u.columns,
+		iter:       iter,
+		updater:    u.updater,
+		primaryKey: u.primaryKey,
+		columns:    u.columns,
+		moves:      slices.ContainsFunc(u.primaryKey, func(position uint8) bool { return u.columns[position] != nil }),
 	}
 
 	return iter, err
 }
 
+func (u *Update) Children() []Node {
+	return []Node{u.child}
+}
+
+func (u *Update) WithChildren(children ...Node) Node {
+	return NewUpdate(u.updater, u.primaryKey, u.columns, children[0])
+}
+
+// Expressions returns the new values of the columns in the order of the columns.
+func (u *Update) Expressions() []expr.Node {
+	exprs := make([]expr.Node, 0, len(u.columns))
+
+	for _, position := range u.positions() {
+		exprs = append(exprs, u.columns[position])
+	}
+
+	return exprs
+}
+
+func (u *Update) WithExpressions(exprs ...expr.Node) Node {
+	columns := make(map[uint8]expr.Node, len(u.columns))
+
+	for i, position := range u.positions() {
+		columns[position] = exprs[i]
+	}
+
+	return NewUpdate(u.updater, u.primaryKey, columns, u.child)
+}
+
+// positions returns positions of the changed columns in ascending order.
+func (u *Update) positions() []uint8 {
+	positions := make([]uint8, 0, len(u.columns))
+
+	for position := range u.columns {
+		positions = append(positions, position)
+	}
+
+	slices.Sort(positions)
+
+	return positions
+}
+
+func (u *Update) Explain() (string, []Node) {
+	return "Update" + target(u.updater), []Node{u.child}
+}
+
 type updateIter struct {
-	iter    sql.RowIter
-	updater RowUpdater
-	pkIndex uint8
-	columns map[uint8]expr.Node
+	iter       sql.RowIter
+	updater    RowUpdater
+	primaryKey []uint8
+	columns    map[uint8]expr.Node
+	// moves reports whether columns of the primary key are set, so rows may get new keys.
+	moves bool
+}
+
+// rowUpdate is a row changed by the statement: the key, the key after the change and the row after the change.
+type rowUpdate struct {
+	key     sql.Key
+	newKey  sql.Key
+	updated sql.Row
+}
+
+func (r rowUpdate) moved() bool {
+	return r.newKey != r.key
 }
 
+// Next updates all rows of the child in one go. All new rows are evaluated before the first change is applied,
+// so the child doesn't read rows changed by the statement. Moved rows are deleted before any row is inserted,
+// so a row may take the old key of another one, as in SET id = id + 1. The statement runs in a transaction,
+// which discards the applied changes if any change fails.
 func (u *updateIter) Next() (sql.Row, error) {
+	updates, err := u.collect()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.checkKeys(updates); err != nil {
+		return nil, err
+	}
+
+	for _, update := range updates {
+		if update.moved() {
+			if err = u.updater.Delete(update.key); err != nil {
+				return nil, fmt.Errorf("delete row: %w", err)
+			}
+		}
+	}
+
+	for _, update := range updates {
+		if update.moved() {
+			err = u.updater.Insert(update.newKey, update.updated)
+		} else {
+			err = u.updater.Update(update.key, update.updated)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("update row: %w", err)
+		}
+	}
+
+	return nil, io.EOF
+}
+
+// checkKeys returns an error if a new key is given to several rows or taken by a row that keeps it.
+func (u *updateIter) checkKeys(updates []rowUpdate) error {
+	if !u.moves {
+		return nil
+	}
+
+	keys := make(map[sql.Key]bool, len(updates))
+	vacated := make(map[sql.Key]bool)
+
+	for _, update := range updates {
+		if keys[update.newKey] {
+			return fmt.Errorf("duplicate primary key: %s", update.newKey)
+		}
+
+		keys[update.newKey] = true
+
+		if update.moved() {
+			vacated[update.key] = true
+		}
+	}
+
+	for _, update := range updates {
+		if !update.moved() || vacated[update.newKey] {
+			continue
+		}
+
+		_, ok, err := u.updater.Get(update.newKey)
+		if err != nil {
+			return fmt.Errorf("get row: %w", err)
+		}
+
+		if ok {
+			return fmt.Errorf("duplicate primary key: %s", update.newKey)
+		}
+	}
+
+	return nil
+}
+
+func (u *updateIter) collect() ([]rowUpdate, error) {
+	var updates []rowUpdate
+
 	for {
 		row, err := u.iter.Next()
 		switch {
 		case errors.Is(err, io.EOF):
-			return nil, err
+			return updates, nil
 		case err != nil:
 			return nil, fmt.Errorf("get next row: %w", err)
 		}
@@ -77,14 +222,24 @@ func (u *updateIter) Next() (sql.Row, error) {
 			}
 		}
 
-		key, ok := row[u.pkIndex].Raw().(int64)
-		if !ok {
-			return nil, fmt.Errorf("unsupported key type: %T", key)
+		key, err := sql.RowKey(row, u.primaryKey)
+		if err != nil {
+			return nil, fmt.Errorf("get row key: %w", err)
 		}
 
-		if err = u.updater.Update(key, updatedRow); err != nil {
-			return nil, fmt.Errorf("update row: %w", err)
+		newKey := key
+
+		if u.moves {
+			if newKey, err = sql.RowKey(updatedRow, u.primaryKey); err != nil {
+				return nil, fmt.Errorf("get new row key: %w", err)
+			}
 		}
+
+		updates = append(updates, rowUpdate{
+			key:     key,
+			newKey:  newKey,
+			updated: updatedRow,
+		})
 	}
 }
 
@@ -39,8 +39,124 @@ func (m *MockRowUpdater) EXPECT() *MockRowUpdaterMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockRowUpdater) Delete(key sql.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRowUpdaterMockRecorder) Delete(key any) *MockRowUpdaterDeleteCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRowUpdater)(nil).Delete), key)
+	return &MockRowUpdaterDeleteCall{Call: call}
+}
+
+// MockRowUpdaterDeleteCall wrap *gomock.Call
+type MockRowUpdaterDeleteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockRowUpdaterDeleteCall) Return(arg0 error) *MockRowUpdaterDeleteCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockRowUpdaterDeleteCall) Do(f func(sql.Key) error) *MockRowUpdaterDeleteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockRowUpdaterDeleteCall) DoAndReturn(f func(sql.Key) error) *MockRowUpdaterDeleteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Get mocks base method.
+func (m *MockRowUpdater) Get(key sql.Key) (sql.Row, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].(sql.Row)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRowUpdaterMockRecorder) Get(key any) *MockRowUpdaterGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRowUpdater)(nil).Get), key)
+	return &MockRowUpdaterGetCall{Call: call}
+}
+
+// MockRowUpdaterGetCall wrap *gomock.Call
+type MockRowUpdaterGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockRowUpdaterGetCall) Return(arg0 sql.Row, arg1 bool, arg2 error) *MockRowUpdaterGetCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockRowUpdaterGetCall) Do(f func(sql.Key) (sql.Row, bool, error)) *MockRowUpdaterGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockRowUpdaterGetCall) DoAndReturn(f func(sql.Key) (sql.Row, bool, error)) *MockRowUpdaterGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Insert mocks base method.
+func (m *MockRowUpdater) Insert(key sql.Key, row sql.Row) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", key, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockRowUpdaterMockRecorder) Insert(key, row any) *MockRowUpdaterInsertCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockRowUpdater)(nil).Insert), key, row)
+	return &MockRowUpdaterInsertCall{Call: call}
+}
+
+// MockRowUpdaterInsertCall wrap *gomock.Call
+type MockRowUpdaterInsertCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockRowUpdaterInsertCall) Return(arg0 error) *MockRowUpdaterInsertCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockRowUpdaterInsertCall) Do(f func(sql.Key, sql.Row) error) *MockRowUpdaterInsertCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockRowUpdaterInsertCall) DoAndReturn(f func(sql.Key, sql.Row) error) *MockRowUpdaterInsertCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Update mocks base method.
-func (m *MockRowUpdater) Update(key int64, row sql.Row) error {
+func (m *MockRowUpdater) Update(key sql.Key, row sql.Row) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", key, row)
 	ret0, _ := ret[0].(error)
@@ -66,13 +182,13 @@ func (c *MockRowUpdaterUpdateCall) Return(arg0 error) *MockRowUpdaterUpdateCall
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockRowUpdaterUpdateCall) Do(f func(int64, sql.Row) error) *MockRowUpdaterUpdateCall {
+func (c *MockRowUpdaterUpdateCall) Do(f func(sql.Key, sql.Row) error) *MockRowUpdaterUpdateCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockRowUpdaterUpdateCall) DoAndReturn(f func(int64, sql.Row) error) *MockRowUpdaterUpdateCall {
+func (c *MockRowUpdaterUpdateCall) DoAndReturn(f func(sql.Key, sql.Row) error) *MockRowUpdaterUpdateCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -27,7 +27,7 @@ func TestUpdate_Columns(t *testing.T) {
 	child := plan.NewMockNode(ctrl)
 	child.EXPECT().Columns().Return(columns)
 
-	update := plan.NewUpdate(updater, 1, nil, child)
+	update := plan.NewUpdate(updater, []uint8{1}, nil, child)
 	assert.Equal(t, columns, update.Columns())
 }
 
@@ -40,7 +40,7 @@ func TestUpdate_RowIter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 
 		nameExpr := expr.NewMockNode(ctrl)
 		salaryExpr := expr.NewMockNode(ctrl)
@@ -76,24 +76,24 @@ func TestUpdate_RowIter(t *testing.T) {
 		nameExpr.EXPECT().Eval(rows[0]).Return(updated[0][1], nil)
 		salaryExpr.EXPECT().Eval(rows[0]).Return(updated[0][2], nil)
 		firstID.EXPECT().Raw().Return(int64(1))
-		updater.EXPECT().Update(int64(1), updated[0]).Return(nil)
+		updater.EXPECT().Update(sql.IntegerKey(1), updated[0]).Return(nil)
 
 		rowIter.EXPECT().Next().Return(rows[1], nil)
 		nameExpr.EXPECT().Eval(rows[1]).Return(updated[1][1], nil)
 		salaryExpr.EXPECT().Eval(rows[1]).Return(updated[1][2], nil)
 		secondID.EXPECT().Raw().Return(int64(2))
-		updater.EXPECT().Update(int64(2), updated[1]).Return(nil)
+		updater.EXPECT().Update(sql.IntegerKey(2), updated[1]).Return(nil)
 
 		rowIter.EXPECT().Next().Return(rows[2], nil)
 		nameExpr.EXPECT().Eval(rows[2]).Return(updated[2][1], nil)
 		salaryExpr.EXPECT().Eval(rows[2]).Return(updated[2][2], nil)
 		thirdID.EXPECT().Raw().Return(int64(3))
-		updater.EXPECT().Update(int64(3), updated[2]).Return(nil)
+		updater.EXPECT().Update(sql.IntegerKey(3), updated[2]).Return(nil)
 
 		rowIter.EXPECT().Next().Return(nil, io.EOF)
 		rowIter.EXPECT().Close().Return(nil)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.NoError(t, err)
 
@@ -108,7 +108,7 @@ func TestUpdate_RowIter(t *testing.T) {
 	t.Run("returns error on RowIter call", func(t *testing.T) {
 		t.Parallel()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 		columns := map[uint8]expr.Node{}
 
@@ -120,7 +120,7 @@ func TestUpdate_RowIter(t *testing.T) {
 
 		child.EXPECT().RowIter().Return(nil, expectedErr)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, iter)
@@ -129,7 +129,7 @@ func TestUpdate_RowIter(t *testing.T) {
 	t.Run("returns error on Next call", func(t *testing.T) {
 		t.Parallel()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 		columns := map[uint8]expr.Node{}
 
@@ -143,7 +143,7 @@ func TestUpdate_RowIter(t *testing.T) {
 		child.EXPECT().RowIter().Return(rowIter, nil)
 		rowIter.EXPECT().Next().Return(nil, expectedErr)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.NoError(t, err)
 		require.NotNil(t, iter)
@@ -156,7 +156,7 @@ func TestUpdate_RowIter(t *testing.T) {
 	t.Run("returns error on eval call", func(t *testing.T) {
 		t.Parallel()
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		expectedErr := errors.New("something went wrong")
 		row := sql.Row{
 			datatype.NewInteger(1),
@@ -180,7 +180,7 @@ func TestUpdate_RowIter(t *testing.T) {
 		rowIter.EXPECT().Next().Return(row, nil)
 		nameExpr.EXPECT().Eval(row).Return(nil, expectedErr)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.NoError(t, err)
 		require.NotNil(t, iter)
@@ -202,7 +202,7 @@ func TestUpdate_RowIter(t *testing.T) {
 		child := plan.NewMockNode(ctrl)
 		rowIter := sql.NewMockRowIter(ctrl)
 
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		columns := map[uint8]expr.Node{
 			1: nameExpr,
 		}
@@ -216,10 +216,11 @@ func TestUpdate_RowIter(t *testing.T) {
 			child.EXPECT().RowIter().Return(rowIter, nil),
 			rowIter.EXPECT().Next().Return(row, nil),
 			nameExpr.EXPECT().Eval(row).Return(nil, nil),
-			id.EXPECT().Raw().Return("xyz"),
+			id.EXPECT().Raw().Return(nil),
+			id.EXPECT().DataType().Return(sql.Null),
 		)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.NoError(t, err)
 		require.NotNil(t, iter)
@@ -243,7 +244,7 @@ func TestUpdate_RowIter(t *testing.T) {
 
 		expectedErr := errors.New("something went wrong")
 		key := int64(1)
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 		columns := map[uint8]expr.Node{
 			1: nameExpr,
 		}
@@ -263,10 +264,11 @@ func TestUpdate_RowIter(t *testing.T) {
 			rowIter.EXPECT().Next().Return(row, nil),
 			nameExpr.EXPECT().Eval(row).Return(updated[1], nil),
 			id.EXPECT().Raw().Return(key),
-			updater.EXPECT().Update(key, updated).Return(expectedErr),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+			updater.EXPECT().Update(sql.IntegerKey(key), updated).Return(expectedErr),
 		)
 
-		update := plan.NewUpdate(updater, pkIndex, columns, child)
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
 		iter, err := update.RowIter()
 		require.NoError(t, err)
 		require.NotNil(t, iter)
@@ -275,4 +277,226 @@ func TestUpdate_RowIter(t *testing.T) {
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, row)
 	})
+
+	t.Run("does not change rows if eval fails", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nameExpr := expr.NewMockNode(ctrl)
+		updater := NewMockRowUpdater(ctrl)
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		expectedErr := errors.New("division by zero")
+		primaryKey := []uint8{0}
+		columns := map[uint8]expr.Node{
+			1: nameExpr,
+		}
+
+		rows := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Max")},
+			{datatype.NewInteger(2), datatype.NewText("Jane")},
+		}
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			nameExpr.EXPECT().Eval(rows[0]).Return(datatype.NewText("John"), nil),
+			rowIter.EXPECT().Next().Return(rows[1], nil),
+			nameExpr.EXPECT().Eval(rows[1]).Return(nil, expectedErr),
+		)
+
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
+		iter, err := update.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, row)
+	})
+
+	t.Run("stops on the first failed update", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nameExpr := expr.NewMockNode(ctrl)
+		updater := NewMockRowUpdater(ctrl)
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		expectedErr := errors.New("something went wrong")
+		primaryKey := []uint8{0}
+		columns := map[uint8]expr.Node{
+			1: nameExpr,
+		}
+
+		rows := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Max")},
+			{datatype.NewInteger(2), datatype.NewText("Jane")},
+			{datatype.NewInteger(3), datatype.NewText("John")},
+		}
+
+		updated := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Bob")},
+			{datatype.NewInteger(2), datatype.NewText("Bob")},
+			{datatype.NewInteger(3), datatype.NewText("Bob")},
+		}
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			nameExpr.EXPECT().Eval(rows[0]).Return(updated[0][1], nil),
+			rowIter.EXPECT().Next().Return(rows[1], nil),
+			nameExpr.EXPECT().Eval(rows[1]).Return(updated[1][1], nil),
+			rowIter.EXPECT().Next().Return(rows[2], nil),
+			nameExpr.EXPECT().Eval(rows[2]).Return(updated[2][1], nil),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+
+			updater.EXPECT().Update(sql.IntegerKey(1), updated[0]).Return(nil),
+			updater.EXPECT().Update(sql.IntegerKey(2), updated[1]).Return(nil),
+			updater.EXPECT().Update(sql.IntegerKey(3), updated[2]).Return(expectedErr),
+		)
+
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
+		iter, err := update.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, row)
+	})
+
+	t.Run("moves rows with a new primary key", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		idExpr := expr.NewMockNode(ctrl)
+		updater := NewMockRowUpdater(ctrl)
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		primaryKey := []uint8{0}
+		columns := map[uint8]expr.Node{
+			0: idExpr,
+		}
+
+		rows := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Max")},
+			{datatype.NewInteger(2), datatype.NewText("Jane")},
+			{datatype.NewInteger(5), datatype.NewText("John")},
+		}
+
+		updated := []sql.Row{
+			{datatype.NewInteger(2), datatype.NewText("Max")},
+			{datatype.NewInteger(3), datatype.NewText("Jane")},
+			{datatype.NewInteger(5), datatype.NewText("John")},
+		}
+
+		// The second key is given up by the second row, so only the third one is checked.
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			idExpr.EXPECT().Eval(rows[0]).Return(updated[0][0], nil),
+			rowIter.EXPECT().Next().Return(rows[1], nil),
+			idExpr.EXPECT().Eval(rows[1]).Return(updated[1][0], nil),
+			rowIter.EXPECT().Next().Return(rows[2], nil),
+			idExpr.EXPECT().Eval(rows[2]).Return(updated[2][0], nil),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+
+			updater.EXPECT().Get(sql.IntegerKey(3)).Return(nil, false, nil),
+			updater.EXPECT().Delete(sql.IntegerKey(1)).Return(nil),
+			updater.EXPECT().Delete(sql.IntegerKey(2)).Return(nil),
+			updater.EXPECT().Insert(sql.IntegerKey(2), updated[0]).Return(nil),
+			updater.EXPECT().Insert(sql.IntegerKey(3), updated[1]).Return(nil),
+			updater.EXPECT().Update(sql.IntegerKey(5), updated[2]).Return(nil),
+		)
+
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
+		iter, err := update.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.ErrorIs(t, err, io.EOF)
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error if new key is taken", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		idExpr := expr.NewMockNode(ctrl)
+		updater := NewMockRowUpdater(ctrl)
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		primaryKey := []uint8{0}
+		columns := map[uint8]expr.Node{
+			0: idExpr,
+		}
+
+		row := sql.Row{datatype.NewInteger(1), datatype.NewText("Max")}
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(row, nil),
+			idExpr.EXPECT().Eval(row).Return(datatype.NewInteger(2), nil),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+			updater.EXPECT().Get(sql.IntegerKey(2)).Return(sql.Row{datatype.NewInteger(2)}, true, nil),
+		)
+
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
+		iter, err := update.RowIter()
+		require.NoError(t, err)
+
+		row, err = iter.Next()
+		require.ErrorContains(t, err, "duplicate primary key: 2")
+		assert.Nil(t, row)
+	})
+
+	t.Run("returns error if new key is given to several rows", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		idExpr := expr.NewMockNode(ctrl)
+		updater := NewMockRowUpdater(ctrl)
+		child := plan.NewMockNode(ctrl)
+		rowIter := sql.NewMockRowIter(ctrl)
+
+		primaryKey := []uint8{0}
+		columns := map[uint8]expr.Node{
+			0: idExpr,
+		}
+
+		rows := []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("Max")},
+			{datatype.NewInteger(2), datatype.NewText("Jane")},
+		}
+
+		gomock.InOrder(
+			child.EXPECT().RowIter().Return(rowIter, nil),
+			rowIter.EXPECT().Next().Return(rows[0], nil),
+			idExpr.EXPECT().Eval(rows[0]).Return(datatype.NewInteger(7), nil),
+			rowIter.EXPECT().Next().Return(rows[1], nil),
+			idExpr.EXPECT().Eval(rows[1]).Return(datatype.NewInteger(7), nil),
+			rowIter.EXPECT().Next().Return(nil, io.EOF),
+		)
+
+		update := plan.NewUpdate(updater, primaryKey, columns, child)
+		iter, err := update.RowIter()
+		require.NoError(t, err)
+
+		row, err := iter.Next()
+		require.ErrorContains(t, err, "duplicate primary key: 7")
+		assert.Nil(t, row)
+	})
 }
@@ -0,0 +1,397 @@
+package planner
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// bounds are restrictions of a column found in the WHERE clause.
+type bounds struct {
+	equal sql.Value
+	from  *sql.Bound
+	to    *sql.Bound
+}
+
+// access is a way to read rows of a table that may satisfy the condition of a statement.
+type access struct {
+	node     plan.Node
+	estimate plan.Estimate
+	ordered  bool // rows are read in the ascending order of the column rows are sorted by
+	index    sql.Index
+	rng      sql.IndexRange
+}
+
+// rowsQuery is what a statement needs from rows of a table.
+type rowsQuery struct {
+	cond   expr.Node // the condition rows must satisfy, nil if any row does
+	sorted bool      // rows are sorted
+	order  string    // the column rows are sorted by in the ascending order, empty if they are sorted otherwise
+	limit  float64   // the number of rows the statement reads at most, 0 is unlimited
+}
+
+// planAccess chooses the cheapest way to read rows of the table that may satisfy the query. The lookup by
+// the primary key is taken whenever all its columns are compared for equality. Otherwise, candidates are the scan
+// of the whole table, the range scan of the leading primary key column and scans of indexes with bounds on their
+// leading columns. Rows of a range scan and of an index scan come in the order of the column, so the sort may be
+// skipped: with a LIMIT it's often cheaper than reading and sorting all rows.
+func (p *Planner) planAccess(database string, table sql.Table, query rowsQuery, scan plan.Node) access {
+	if query.cond == nil && query.order == "" && p.estimates == nil {
+		return access{node: scan}
+	}
+
+	scheme := table.Scheme()
+	columns := make(map[string]*bounds)
+
+	if query.cond != nil {
+		for _, conjunct := range expr.Conjuncts(query.cond) {
+			collectBound(scheme, conjunct, columns)
+		}
+	}
+
+	// Nothing restricts or orders the rows, so only the whole table can be read.
+	if len(columns) == 0 && query.order == "" && p.estimates == nil {
+		return access{node: scan}
+	}
+
+	// A lookup reads at most a single row, no other way is cheaper.
+	primaryKey := table.PrimaryKey()
+
+	if key, ok := lookupKey(primaryKey, columns); ok {
+		lookup := access{node: plan.NewLookup(table, key), ordered: true}
+
+		if p.estimates != nil {
+			lookup.estimate = lookupEstimate(p.estimator(database, table).Rows())
+			p.record(lookup.node, lookup.estimate)
+		}
+
+		return lookup
+	}
+
+	candidates := accessCandidates(table, primaryKey, columns, query.order, scan)
+
+	// Statistics are needed only to compare candidates or to explain the plan.
+	if len(candidates) == 1 && p.estimates == nil {
+		return candidates[0]
+	}
+
+	estimator := p.estimator(database, table)
+	tableRows := estimator.Rows()
+	filtered := tableRows
+
+	if query.cond != nil {
+		filtered = estimator.Cardinality(selectivity(estimator, scheme, query.cond))
+	}
+
+	var (
+		best     access
+		bestCost float64
+	)
+
+	for i, candidate := range candidates {
+		rows := tableRows
+
+		switch candidate.node.(type) {
+		case *plan.RangeScan:
+			rows = estimator.Cardinality(boundsSelectivity(estimator, leadBounds(primaryKey, columns)))
+			candidate.estimate = rangeScanEstimate(tableRows, rows)
+		case *plan.IndexScan:
+			rows = estimator.Cardinality(boundsSelectivity(estimator, indexBounds(candidate, columns)))
+
+			// A unique index has a single row of values of all its columns.
+			if candidate.index.Unique() && len(candidate.rng.Prefix) == len(candidate.index.Columns()) {
+				rows = min(rows, 1)
+			}
+
+			candidate.estimate = indexScanEstimate(tableRows, rows)
+		default:
+			candidate.estimate = scanEstimate(tableRows)
+		}
+
+		cost := candidateCost(candidate, query, min(filtered, candidate.estimate.Rows))
+
+		if i == 0 || cost < bestCost {
+			best, bestCost = candidate, cost
+		}
+	}
+
+	p.record(best.node, best.estimate)
+
+	return best
+}
+
+// candidateCost returns the cost of reading rows that satisfy the query in the requested order.
+func candidateCost(candidate access, query rowsQuery, rows float64) float64 {
+	estimate := candidate.estimate
+
+	if query.cond != nil {
+		estimate = filterEstimate(estimate, rows)
+	}
+
+	switch {
+	case candidate.ordered || !query.sorted:
+		if query.limit > 0 {
+			estimate = limitEstimate(estimate, query.limit)
+		}
+	case query.limit > 0:
+		estimate = topNEstimate(estimate, query.limit)
+	default:
+		estimate = sortEstimate(estimate)
+	}
+
+	return estimate.Total
+}
+
+// accessCandidates returns ways to read rows of the table restricted by the bounds, the scan of the whole table
+// goes first. The order is the column rows are sorted by in the ascending order, empty if any.
+func accessCandidates(
+	table sql.Table,
+	primaryKey []sql.Column,
+	columns map[string]*bounds,
+	order string,
+	scan plan.Node,
+) []access {
+	candidates := []access{{node: scan}}
+
+	if len(primaryKey) > 0 {
+		lead := primaryKey[0].Name
+		ordered := order == lead
+
+		switch b := columns[lead]; {
+		case b != nil && b.equal != nil:
+			bound := &sql.Bound{Value: b.equal, Inclusive: true}
+			candidates = append(candidates, access{node: plan.NewRangeScan(table, bound, bound), ordered: ordered})
+		case b != nil:
+			candidates = append(candidates, access{node: plan.NewRangeScan(table, b.from, b.to), ordered: ordered})
+		case ordered:
+			candidates = append(candidates, access{node: plan.NewRangeScan(table, nil, nil), ordered: true})
+		}
+	}
+
+	indexes := table.Indexes()
+	slices.SortFunc(indexes, func(a, b sql.Index) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	for _, index := range indexes {
+		rng, matched := indexRange(index.Columns(), columns)
+		next := len(rng.Prefix)
+		ordered := order != "" && next < len(index.Columns()) && index.Columns()[next] == order
+
+		if matched > 0 || ordered {
+			candidates = append(candidates, access{
+				node:    plan.NewIndexScan(table, index, rng),
+				ordered: ordered,
+				index:   index,
+				rng:     rng,
+			})
+		}
+	}
+
+	return candidates
+}
+
+// leadBounds returns bounds of the leading primary key column.
+func leadBounds(primaryKey []sql.Column, columns map[string]*bounds) map[string]*bounds {
+	lead := primaryKey[0].Name
+
+	if b, ok := columns[lead]; ok {
+		return map[string]*bounds{lead: b}
+	}
+
+	return nil
+}
+
+// indexBounds returns bounds of the columns the range of the index scan restricts.
+func indexBounds(scan access, columns map[string]*bounds) map[string]*bounds {
+	restricted := make(map[string]*bounds)
+	indexColumns := scan.index.Columns()
+	rng := scan.rng
+
+	for i := range rng.Prefix {
+		restricted[indexColumns[i]] = &bounds{equal: rng.Prefix[i]}
+	}
+
+	if rng.From != nil || rng.To != nil {
+		restricted[indexColumns[len(rng.Prefix)]] = columns[indexColumns[len(rng.Prefix)]]
+	}
+
+	return restricted
+}
+
+// lookupKey returns the primary key if all its columns are compared for equality.
+func lookupKey(primaryKey []sql.Column, columns map[string]*bounds) (sql.Key, bool) {
+	values := make([]sql.Value, 0, len(primaryKey))
+
+	for _, column := range primaryKey {
+		b, ok := columns[column.Name]
+		if !ok || b.equal == nil {
+			return "", false
+		}
+
+		values = append(values, b.equal)
+	}
+
+	key, err := sql.NewKey(values...)
+	if err != nil || len(values) == 0 {
+		return "", false
+	}
+
+	return key, true
+}
+
+// indexRange returns the range of entries of the index with the columns restricted by the bounds and how well
+// the bounds match: equality on a prefix of columns, optionally followed by a range on the next column.
+// Zero is returned if there are no bounds on the leading column.
+func indexRange(indexColumns []string, columns map[string]*bounds) (sql.IndexRange, int) {
+	var rng sql.IndexRange
+
+	for _, name := range indexColumns {
+		b, ok := columns[name]
+		if !ok {
+			break
+		}
+
+		if b.equal != nil {
+			rng.Prefix = append(rng.Prefix, b.equal)
+
+			continue
+		}
+
+		rng.From, rng.To = b.from, b.to
+
+		break
+	}
+
+	score := 2 * len(rng.Prefix)
+
+	if rng.From != nil || rng.To != nil {
+		score++
+	}
+
+	return rng, score
+}
+
+// collectBound records the comparison of a column with a constant in the bounds of the column,
+// false is returned if the condition is not such a comparison.
+func collectBound(scheme sql.Scheme, cond expr.Node, columns map[string]*bounds) bool {
+	binary, ok := expr.AsBinary(cond)
+	if !ok {
+		return false
+	}
+
+	operator := binary.Operator
+	ref, isColumn := binary.Left.(expr.Column)
+	other := binary.Right
+
+	if !isColumn {
+		if ref, isColumn = binary.Right.(expr.Column); !isColumn {
+			return false
+		}
+
+		other = binary.Left
+		operator = mirror(operator)
+	}
+
+	column, ok := scheme[ref.Name]
+	if !ok || column.Position != ref.Position {
+		return false
+	}
+
+	switch operator {
+	case expr.Equal, expr.GreaterThan, expr.GreaterThanOrEqual, expr.LessThan, expr.LessThanOrEqual:
+	default:
+		return false
+	}
+
+	value, ok := constant(other, column.DataType)
+	if !ok {
+		return false
+	}
+
+	b, ok := columns[column.Name]
+	if !ok {
+		b = &bounds{}
+		columns[column.Name] = b
+	}
+
+	switch operator {
+	case expr.Equal:
+		if b.equal == nil {
+			b.equal = value
+		}
+	case expr.GreaterThan, expr.GreaterThanOrEqual:
+		bound := &sql.Bound{Value: value, Inclusive: operator == expr.GreaterThanOrEqual}
+
+		if b.from == nil || tighter(bound, b.from, sql.Greater) {
+			b.from = bound
+		}
+	default:
+		bound := &sql.Bound{Value: value, Inclusive: operator == expr.LessThanOrEqual}
+
+		if b.to == nil || tighter(bound, b.to, sql.Less) {
+			b.to = bound
+		}
+	}
+
+	return true
+}
+
+// mirror returns the operator that gives the same result when operands are swapped.
+func mirror(operator expr.BinaryOp) expr.BinaryOp {
+	switch operator {
+	case expr.LessThan:
+		return expr.GreaterThan
+	case expr.LessThanOrEqual:
+		return expr.GreaterThanOrEqual
+	case expr.GreaterThan:
+		return expr.LessThan
+	case expr.GreaterThanOrEqual:
+		return expr.LessThanOrEqual
+	default:
+		return operator
+	}
+}
+
+// tighter reports whether the bound restricts the range more than the current one,
+// direction is the side the tighter bound lies on.
+func tighter(bound, current *sql.Bound, direction sql.CompareType) bool {
+	c, err := comparison.Compare(bound.Value, current.Value)
+	if err != nil {
+		return false
+	}
+
+	if c == sql.Equal {
+		return !bound.Inclusive
+	}
+
+	return c == direction
+}
+
+// constant evaluates the expression that doesn't depend on rows and converts it to the column type.
+func constant(node expr.Node, dataType sql.DataType) (sql.Value, bool) {
+	if !expr.IsConstant(node) {
+		return nil, false
+	}
+
+	value, err := node.Eval(nil)
+	if err != nil {
+		return nil, false
+	}
+
+	switch {
+	case value.DataType() == dataType:
+		return value, true
+	case value.DataType() == sql.Integer && dataType == sql.Float:
+		raw, _ := value.Raw().(int64)
+
+		return datatype.NewFloat(float64(raw)), true
+	default:
+		return nil, false
+	}
+}
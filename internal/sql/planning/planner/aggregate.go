@@ -0,0 +1,255 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// grouping is how a grouped statement computes its results. Rows are aggregated into rows of groups: values
+// of the group expressions followed by results of the aggregates. Expressions of the results, HAVING, DISTINCT ON
+// and ORDER BY are rewritten to refer to columns of those rows.
+type grouping struct {
+	groups     []expr.Node
+	aggregates []*expr.Aggregate
+	having     expr.Node // nil if there is no HAVING clause, it refers to rows of tables
+}
+
+// planGrouping returns the grouping of the statement, nil if it has neither GROUP BY nor HAVING and neither its results
+// nor DISTINCT ON call aggregate functions.
+func (p *Planner) planGrouping(database string, scope *expr.Scope, stmt *ast.SelectStatement) (*grouping, error) {
+	var (
+		g       grouping
+		grouped = stmt.GroupBy != nil || stmt.Having != nil
+	)
+
+	for _, result := range stmt.Result {
+		if _, ok := result.Expr.(*ast.AsteriskExpr); ok {
+			continue
+		}
+
+		node, err := expr.NewWithAggregates(result.Expr, scope, p.sequences(database))
+		if err != nil {
+			return nil, err
+		}
+
+		grouped = g.addAggregates(node) || grouped
+	}
+
+	if stmt.Distinct != nil {
+		for _, e := range stmt.Distinct.On {
+			node, err := expr.NewWithAggregates(e, scope, p.sequences(database))
+			if err != nil {
+				return nil, fmt.Errorf("distinct on: %w", err)
+			}
+
+			grouped = g.addAggregates(node) || grouped
+		}
+	}
+
+	if !grouped {
+		return nil, nil
+	}
+
+	if stmt.GroupBy != nil {
+		for _, e := range stmt.GroupBy.Exprs {
+			node, err := expr.NewInScope(e, scope, p.sequences(database))
+			if err != nil {
+				return nil, fmt.Errorf("group by: %w", err)
+			}
+
+			g.groups = append(g.groups, node)
+		}
+	}
+
+	if stmt.Having != nil {
+		node, err := expr.NewWithAggregates(stmt.Having.Expr, scope, p.sequences(database))
+		if err != nil {
+			return nil, fmt.Errorf("having: %w", err)
+		}
+
+		g.having = node
+		g.addAggregates(node)
+	}
+
+	if len(g.groups)+len(g.aggregates) > math.MaxUint8+1 {
+		return nil, fmt.Errorf("too many group expressions and aggregates: %d", len(g.groups)+len(g.aggregates))
+	}
+
+	return &g, nil
+}
+
+// addAggregates adds aggregates the expression calls, the same aggregate is computed once. It reports whether
+// the expression calls any.
+func (g *grouping) addAggregates(node expr.Node) bool {
+	found := false
+
+	expr.Inspect(node, func(node expr.Node) {
+		aggregate, ok := node.(*expr.Aggregate)
+		if !ok {
+			return
+		}
+
+		found = true
+
+		for _, a := range g.aggregates {
+			if exprKey(a) == exprKey(aggregate) {
+				return
+			}
+		}
+
+		g.aggregates = append(g.aggregates, aggregate)
+	})
+
+	return found
+}
+
+// plan returns the aggregate of rows of the child and the filter of HAVING over it.
+func (g *grouping) plan(child plan.Node) (plan.Node, error) {
+	node := plan.Node(plan.NewHashAggregate(g.groups, g.aggregates, child))
+
+	if g.having == nil {
+		return node, nil
+	}
+
+	cond, err := g.rewrite(g.having)
+	if err != nil {
+		return nil, fmt.Errorf("having: %w", err)
+	}
+
+	return plan.NewFilter(cond, node), nil
+}
+
+// rewrite returns the expression over rows of groups. Group expressions and aggregates become columns of those
+// rows, an error is returned if the expression refers to any other column of a table.
+func (g *grouping) rewrite(node expr.Node) (expr.Node, error) {
+	var err error
+
+	rewritten := expr.Replace(node, func(node expr.Node) (expr.Node, bool) {
+		key := exprKey(node)
+
+		for i, group := range g.groups {
+			if exprKey(group) != key {
+				continue
+			}
+
+			column := expr.Column{Name: node.String(), Position: uint8(i)}
+
+			if c, ok := node.(expr.Column); ok {
+				column.Table, column.Name = c.Table, c.Name
+			}
+
+			return column, true
+		}
+
+		switch n := node.(type) {
+		case *expr.Aggregate:
+			for i, aggregate := range g.aggregates {
+				if exprKey(aggregate) == key {
+					return expr.Column{Name: n.String(), Position: uint8(len(g.groups) + i)}, true
+				}
+			}
+		case expr.Column:
+			if err == nil {
+				err = fmt.Errorf("column %q must appear in the GROUP BY clause or be used in an aggregate function", n)
+			}
+
+			return n, true
+		}
+
+		return nil, false
+	})
+
+	return rewritten, err
+}
+
+// exprKey returns the key of the expression that is the same for expressions computing the same value: columns
+// are told apart by their positions, however they are qualified. Subqueries are all written the same,
+// so they are told apart by their plans.
+func exprKey(node expr.Node) string {
+	return expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+		switch n := node.(type) {
+		case expr.Column:
+			return expr.Column{Name: "$" + strconv.Itoa(int(n.Position)), Position: n.Position}, true
+		case interface{ Query() expr.Query }:
+			return expr.Column{Name: fmt.Sprintf("$%p", n.Query())}, true
+		}
+
+		return nil, false
+	}).String()
+}
+
+// planAggregate aggregates rows of the table under the aggregate one group after another if it has a single group
+// column and reading rows in the order of that column is cheaper than sorting them. Otherwise, groups are kept
+// in a hash table.
+func (p *Planner) planAggregate(database string, aggregate *plan.HashAggregate) plan.Node {
+	if len(aggregate.Groups()) == 1 {
+		if sorted, ok := p.planSortAggregate(database, aggregate); ok {
+			p.recordAggregateEstimate(database, aggregate, sorted)
+
+			return sorted
+		}
+	}
+
+	node := aggregate.WithChildren(p.planPhysical(database, aggregate.Children()[0], 0))
+	p.recordAggregateEstimate(database, aggregate, node)
+
+	return node
+}
+
+// planSortAggregate aggregates rows of the table read in the order of the group column, false is returned
+// if the group isn't a column of a table the child reads directly or its rows can't be read in that order.
+func (p *Planner) planSortAggregate(database string, aggregate *plan.HashAggregate) (plan.Node, bool) {
+	column, ok := aggregate.Groups()[0].(expr.Column)
+	if !ok {
+		return nil, false
+	}
+
+	scan, cond, ok := scanOf(aggregate.Children()[0])
+	if !ok {
+		return nil, false
+	}
+
+	rows := p.planRows(database, scan, rowsQuery{cond: cond, sorted: true, order: column.Name})
+	if !rows.ordered {
+		return nil, false
+	}
+
+	return plan.NewSortAggregate(aggregate.Groups(), aggregate.Aggregates(), rows.node), true
+}
+
+// recordAggregateEstimate records the estimate of the physical aggregate of the logical one by the estimate
+// of its child. Values of group expressions are assumed to be independent, there's a single group without them.
+func (p *Planner) recordAggregateEstimate(database string, aggregate *plan.HashAggregate, node plan.Node) {
+	parent, ok := node.(plan.Parent)
+	if !ok {
+		return
+	}
+
+	child, ok := p.estimate(parent.Children()[0])
+	if !ok {
+		return
+	}
+
+	groups := 1.0
+
+	if len(aggregate.Groups()) > 0 {
+		for _, group := range aggregate.Groups() {
+			groups *= max(p.keyDistinct(database, aggregate.Children()[0], group, child.Rows), 1)
+		}
+
+		groups = min(groups, child.Rows)
+	}
+
+	exprs := len(aggregate.Groups()) + len(aggregate.Aggregates())
+
+	if _, ok := node.(*plan.SortAggregate); ok {
+		p.record(node, sortAggregateEstimate(child, groups, exprs))
+	} else {
+		p.record(node, hashAggregateEstimate(child, groups, exprs))
+	}
+}
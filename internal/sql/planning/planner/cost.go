@@ -0,0 +1,352 @@
+package planner
+
+import (
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+// Costs of operations in abstract units. They are the same for all storage engines:
+// reading rows one by one in the key order is cheaper than reading them by keys found elsewhere.
+const (
+	scanCost    = 1.0  // reading the next row of a scan
+	fetchCost   = 4.0  // reading a row by its primary key
+	entryCost   = 0.5  // reading the next entry of an index
+	compareCost = 0.01 // comparing two values by a search in a tree or a sort
+	evalCost    = 0.02 // evaluating an expression for a row
+	hashCost    = 0.04 // putting a row in a hash table
+	writeCost   = 2.0  // changing a row of the table
+)
+
+// estimator returns the estimator of rows of the table by its statistics.
+// Defaults are used if the table has never been analyzed or statistics can't be read.
+func (p *Planner) estimator(database string, table sql.Table) statistics.Estimator {
+	db, err := p.catalog.GetDatabase(database)
+	if err != nil {
+		return statistics.NewEstimator(sql.Statistics{}, false)
+	}
+
+	return statistics.NewEstimator(db.Statistics(table.Name()))
+}
+
+// record keeps the estimate of the node to be shown by EXPLAIN, the cost of subqueries of the node is added to it.
+func (p *Planner) record(node plan.Node, estimate plan.Estimate) {
+	if p.estimates != nil {
+		p.estimates[node] = p.subqueryEstimate(node, estimate)
+	}
+}
+
+// subqueryEstimate adds the cost of reading plans of subqueries of expressions the node evaluates to its estimate.
+// An uncorrelated subquery is read once before the first row is produced. A correlated one is read once for every
+// distinct set of values of the columns of the outer query it refers to among the rows the node reads: the number
+// of them isn't known, it's assumed to be the number of distinct values of a column.
+func (p *Planner) subqueryEstimate(node plan.Node, estimate plan.Estimate) plan.Estimate {
+	evaluator, ok := node.(plan.Evaluator)
+	if !ok {
+		return estimate
+	}
+
+	var rows float64
+
+	if parent, ok := node.(plan.Parent); ok {
+		for _, child := range parent.Children() {
+			if child, ok := p.estimate(child); ok {
+				rows += child.Rows
+			}
+		}
+	}
+
+	seen := make(map[expr.Query]bool)
+
+	for _, node := range evaluator.Expressions() {
+		expr.Inspect(node, func(node expr.Node) {
+			subquery, ok := node.(expr.SubqueryNode)
+			if !ok || seen[subquery.Query()] {
+				return
+			}
+
+			seen[subquery.Query()] = true
+
+			query, ok := p.estimate(subquery.Query())
+			if !ok {
+				return
+			}
+
+			loops := 1.0
+
+			if len(subquery.Outer()) > 0 {
+				loops = max(min(rows, statistics.DefaultDistinct), 1)
+			}
+
+			estimate.Startup += query.Total
+			estimate.Total += query.Total * loops
+		})
+	}
+
+	return estimate
+}
+
+// estimate returns the recorded estimate of the node, false if estimates aren't recorded.
+func (p *Planner) estimate(node plan.Node) (plan.Estimate, bool) {
+	estimate, ok := p.estimates[node]
+
+	return estimate, ok
+}
+
+// seekCost returns the cost of finding the first row of a range among the rows.
+func seekCost(rows float64) float64 {
+	return math.Log2(rows+1) * compareCost
+}
+
+func scanEstimate(rows float64) plan.Estimate {
+	return plan.Estimate{
+		Rows:  rows,
+		Total: rows * scanCost,
+	}
+}
+
+func lookupEstimate(rows float64) plan.Estimate {
+	cost := seekCost(rows) + fetchCost
+
+	return plan.Estimate{
+		Rows:    min(rows, 1),
+		Startup: cost,
+		Total:   cost,
+	}
+}
+
+func rangeScanEstimate(tableRows, rows float64) plan.Estimate {
+	startup := seekCost(tableRows)
+
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: startup,
+		Total:   startup + rows*scanCost,
+	}
+}
+
+func indexScanEstimate(tableRows, rows float64) plan.Estimate {
+	startup := seekCost(tableRows)
+
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: startup,
+		Total:   startup + rows*(entryCost+fetchCost),
+	}
+}
+
+// filterEstimate returns the estimate of the filter of the child that passes the rows.
+func filterEstimate(child plan.Estimate, rows float64) plan.Estimate {
+	return plan.Estimate{
+		Rows:    min(rows, child.Rows),
+		Startup: child.Startup,
+		Total:   child.Total + child.Rows*evalCost,
+	}
+}
+
+// sortEstimate returns the estimate of the sort of the child: all rows are read and sorted before the first one
+// is produced.
+func sortEstimate(child plan.Estimate) plan.Estimate {
+	cost := child.Total + child.Rows*math.Log2(child.Rows+1)*compareCost
+
+	return plan.Estimate{
+		Rows:    child.Rows,
+		Startup: cost,
+		Total:   cost,
+	}
+}
+
+// topNEstimate returns the estimate of the first rows of the child in the order of a column: all rows are read
+// before the first one is produced, but each of them is compared only with the rows kept.
+func topNEstimate(child plan.Estimate, rows float64) plan.Estimate {
+	cost := child.Total + child.Rows*math.Log2(rows+1)*compareCost
+
+	return plan.Estimate{
+		Rows:    min(rows, child.Rows),
+		Startup: cost,
+		Total:   cost,
+	}
+}
+
+func projectEstimate(child plan.Estimate, projections int) plan.Estimate {
+	return plan.Estimate{
+		Rows:    child.Rows,
+		Startup: child.Startup,
+		Total:   child.Total + child.Rows*float64(projections)*evalCost,
+	}
+}
+
+// limitEstimate returns the estimate of the child that stops after the rows, it pays only a share of the cost.
+func limitEstimate(child plan.Estimate, rows float64) plan.Estimate {
+	if rows >= child.Rows {
+		return child
+	}
+
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: child.Startup,
+		Total:   child.Startup + (child.Total-child.Startup)*rows/child.Rows,
+	}
+}
+
+// offsetEstimate returns the estimate of the child whose first rows are skipped before the first row is produced.
+func offsetEstimate(child plan.Estimate, rows float64) plan.Estimate {
+	skipped := min(rows, child.Rows)
+	estimate := child
+
+	estimate.Rows = child.Rows - skipped
+
+	if child.Rows > 0 {
+		estimate.Startup += (child.Total - child.Startup) * skipped / child.Rows
+	}
+
+	return estimate
+}
+
+// nestedLoopEstimate returns the estimate of the join that checks every pair of rows of the children.
+// Right rows are read before the first row is produced.
+func nestedLoopEstimate(left, right plan.Estimate, rows float64) plan.Estimate {
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: right.Total + left.Startup,
+		Total:   right.Total + left.Total + left.Rows*right.Rows*evalCost,
+	}
+}
+
+// hashJoinEstimate returns the estimate of the join that puts right rows in a hash table by their keys before
+// the first row is produced, then checks every left row only with the right rows of its keys. Building the table
+// costs more than probing it, so the smaller child is better put in it.
+func hashJoinEstimate(left, right plan.Estimate, rows float64) plan.Estimate {
+	startup := right.Total + right.Rows*(evalCost+hashCost) + left.Startup
+
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: startup,
+		Total:   startup + left.Total - left.Startup + left.Rows*evalCost + rows*evalCost,
+	}
+}
+
+// mergeJoinEstimate returns the estimate of the join that reads rows of both children side by side
+// in the order of their keys.
+func mergeJoinEstimate(left, right plan.Estimate, rows float64) plan.Estimate {
+	return plan.Estimate{
+		Rows:    rows,
+		Startup: left.Startup + right.Startup,
+		Total:   left.Total + right.Total + (left.Rows+right.Rows)*compareCost + rows*evalCost,
+	}
+}
+
+// hashAggregateEstimate returns the estimate of the aggregate that puts all rows of the child in groups
+// of a hash table before the first group is produced.
+func hashAggregateEstimate(child plan.Estimate, groups float64, exprs int) plan.Estimate {
+	cost := child.Total + child.Rows*float64(exprs)*evalCost
+
+	return plan.Estimate{
+		Rows:    groups,
+		Startup: cost,
+		Total:   cost,
+	}
+}
+
+// sortAggregateEstimate returns the estimate of the aggregate of rows of the child read in the order of the group
+// expression: a group is produced as soon as the next one starts.
+func sortAggregateEstimate(child plan.Estimate, groups float64, exprs int) plan.Estimate {
+	return plan.Estimate{
+		Rows:    groups,
+		Startup: child.Startup,
+		Total:   child.Total + child.Rows*(float64(exprs)*evalCost+compareCost),
+	}
+}
+
+// distinctEstimate returns the estimate of the dedupe of rows of the child by the keys. The number of distinct keys
+// isn't known, it's assumed to be the number of distinct values of a column.
+func distinctEstimate(child plan.Estimate, keys int) plan.Estimate {
+	return plan.Estimate{
+		Rows:    min(child.Rows, statistics.DefaultDistinct),
+		Startup: child.Startup,
+		Total:   child.Total + child.Rows*float64(keys)*evalCost,
+	}
+}
+
+// modifyEstimate returns the estimate of the change of all rows of the child. The change is applied after
+// all rows are read and produces no rows.
+func modifyEstimate(child plan.Estimate) plan.Estimate {
+	cost := child.Total + child.Rows*writeCost
+
+	return plan.Estimate{
+		Startup: cost,
+		Total:   cost,
+	}
+}
+
+// selectivity returns the estimated fraction of rows of the table that satisfy the condition.
+// Comparisons of a column with the same constant are combined into a single range, conditions are assumed
+// to be independent of each other.
+func selectivity(estimator statistics.Estimator, scheme sql.Scheme, cond expr.Node) float64 {
+	columns := make(map[string]*bounds)
+	fraction := 1.0
+
+	for _, conjunct := range expr.Conjuncts(cond) {
+		if !collectBound(scheme, conjunct, columns) {
+			fraction *= conditionSelectivity(estimator, scheme, conjunct)
+		}
+	}
+
+	return fraction * boundsSelectivity(estimator, columns)
+}
+
+// boundsSelectivity returns the estimated fraction of rows that satisfy all bounds.
+func boundsSelectivity(estimator statistics.Estimator, columns map[string]*bounds) float64 {
+	fraction := 1.0
+
+	for column, b := range columns {
+		if b.equal != nil {
+			fraction *= estimator.Equal(column, b.equal)
+		} else {
+			fraction *= estimator.Range(column, b.from, b.to)
+		}
+	}
+
+	return fraction
+}
+
+// conditionSelectivity returns the estimated fraction of rows that satisfy the condition other than a conjunction.
+func conditionSelectivity(estimator statistics.Estimator, scheme sql.Scheme, cond expr.Node) float64 {
+	if binary, ok := expr.AsBinary(cond); ok {
+		switch binary.Operator {
+		case expr.Or:
+			left := selectivity(estimator, scheme, binary.Left)
+			right := selectivity(estimator, scheme, binary.Right)
+
+			return left + right - left*right
+		case expr.NotEqual:
+			equal := &expr.Binary{Operator: expr.Equal, Left: binary.Left, Right: binary.Right}
+
+			return max(1-selectivity(estimator, scheme, equal)-nullFraction(estimator, binary), 0)
+		}
+	}
+
+	if value, ok := constant(cond, sql.Boolean); ok {
+		if value.Raw() == true {
+			return 1
+		}
+
+		return 0
+	}
+
+	return statistics.DefaultRangeSelectivity
+}
+
+// nullFraction returns the fraction of NULL values of the column the comparison refers to.
+func nullFraction(estimator statistics.Estimator, cond expr.Binary) float64 {
+	for _, operand := range []expr.Node{cond.Left, cond.Right} {
+		if column, ok := operand.(expr.Column); ok {
+			return estimator.Null(column.Name)
+		}
+	}
+
+	return 0
+}
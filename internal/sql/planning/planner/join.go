@@ -0,0 +1,367 @@
+package planner
+
+import (
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+// planJoin chooses the cheapest way to join rows of the children by estimates of their costs. Rows may be merged
+// if the condition compares columns of both tables for equality and rows of both tables can be read in the order
+// of those columns; they may be joined by a hash table if the condition compares any expressions over rows of each
+// child for equality; every pair of rows may be checked in any case. Semi and anti joins aren't merged. Children of
+// an inner join are also tried the other way round, e.g. to put the smaller one in the hash table, the columns
+// of the joined rows are put back in the order of the query then.
+func (p *Planner) planJoin(database string, join *plan.NestedLoopJoin) plan.Node {
+	// Costs are estimated to choose the join even if the plan isn't explained.
+	if p.estimates == nil {
+		estimator := &Planner{
+			catalog:   p.catalog,
+			estimates: make(map[plan.Node]plan.Estimate),
+		}
+
+		return estimator.planJoin(database, join)
+	}
+
+	children := join.Children()
+	leftKeys, rightKeys := joinKeys(join.Cond(), len(children[0].Columns()))
+	leftOnly := join.Type() == plan.SemiJoin || join.Type() == plan.AntiJoin
+
+	var candidates []plan.Node
+
+	if len(leftKeys) > 0 && !leftOnly {
+		if merge, ok := p.planMergeJoin(database, join, leftKeys[0], rightKeys[0]); ok {
+			p.recordJoinEstimate(database, join, merge, leftKeys, rightKeys)
+			candidates = append(candidates, merge)
+		}
+	}
+
+	left := p.planPhysical(database, children[0], 0)
+	right := p.planPhysical(database, children[1], 0)
+
+	candidates = append(candidates, p.planJoinOf(database, join, left, right)...)
+
+	if join.Type() == plan.InnerJoin && (join.Cond() == nil || !expr.IsVolatile(join.Cond())) {
+		leftWidth, rightWidth := len(left.Columns()), len(right.Columns())
+		swapped := plan.NewNestedLoopJoin(
+			plan.InnerJoin,
+			swapColumns(join.Cond(), leftWidth, rightWidth),
+			children[1],
+			children[0],
+		)
+
+		for _, node := range p.planJoinOf(database, swapped, right, left) {
+			candidates = append(candidates, p.restoreColumns(node, leftWidth, rightWidth))
+		}
+	}
+
+	return p.cheapest(candidates)
+}
+
+// planJoinOf returns the ways to join the physical children of the join without reading them in any order:
+// by a hash table if the condition compares expressions over rows of each child for equality and by checking
+// every pair of rows.
+func (p *Planner) planJoinOf(database string, join *plan.NestedLoopJoin, left, right plan.Node) []plan.Node {
+	leftKeys, rightKeys := joinKeys(join.Cond(), len(left.Columns()))
+	nodes := make([]plan.Node, 0, 2)
+
+	if len(leftKeys) > 0 {
+		hash := plan.NewHashJoin(join.Type(), join.Cond(), leftKeys, rightKeys, left, right)
+		p.recordJoinEstimate(database, join, hash, leftKeys, rightKeys)
+		nodes = append(nodes, hash)
+	}
+
+	nested := join.WithChildren(left, right)
+	p.recordJoinEstimate(database, join, nested, leftKeys, rightKeys)
+
+	return append(nodes, nested)
+}
+
+// restoreColumns puts columns of rows of the join of the children swapped back in the order of the query:
+// the leftWidth columns of the left child of the query first, then the rightWidth columns of the right one.
+func (p *Planner) restoreColumns(join plan.Node, leftWidth, rightWidth int) plan.Node {
+	columns := join.Columns()
+	projections := make([]plan.Projection, 0, leftWidth+rightWidth)
+
+	for i := range leftWidth + rightWidth {
+		position := i + rightWidth
+		if i >= leftWidth {
+			position = i - leftWidth
+		}
+
+		projections = append(projections, plan.Projection{
+			Alias: columns[position],
+			Expr:  expr.Column{Name: columns[position], Position: uint8(position)},
+		})
+	}
+
+	project := plan.NewProject(projections, join)
+
+	// Columns are only moved, nothing is evaluated.
+	if estimate, ok := p.estimate(join); ok {
+		p.record(project, estimate)
+	}
+
+	return project
+}
+
+// cheapest returns the node with the lowest estimated total cost, the first one of equally cheap nodes.
+func (p *Planner) cheapest(nodes []plan.Node) plan.Node {
+	best := nodes[0]
+	bestEstimate, ok := p.estimate(best)
+
+	for _, node := range nodes[1:] {
+		estimate, found := p.estimate(node)
+		if found && (!ok || estimate.Total < bestEstimate.Total) {
+			best, bestEstimate, ok = node, estimate, true
+		}
+	}
+
+	return best
+}
+
+// swapColumns returns the condition over rows of the join of two children whose columns refer to the rows
+// of the join of the same children in the other order.
+func swapColumns(cond expr.Node, leftWidth, rightWidth int) expr.Node {
+	if cond == nil {
+		return nil
+	}
+
+	return expr.Transform(cond, func(node expr.Node) (expr.Node, bool) {
+		column, ok := node.(expr.Column)
+		if !ok {
+			return nil, false
+		}
+
+		if int(column.Position) < leftWidth {
+			column.Position += uint8(rightWidth)
+		} else {
+			column.Position -= uint8(leftWidth)
+		}
+
+		return column, true
+	})
+}
+
+// planMergeJoin merges rows of the tables of the children by the key columns, false is returned if the keys
+// aren't columns of the same type or rows of either table can't be read in their order.
+func (p *Planner) planMergeJoin(
+	database string,
+	join *plan.NestedLoopJoin,
+	leftKey, rightKey expr.Node,
+) (plan.Node, bool) {
+	children := join.Children()
+
+	leftColumn, ok := leftKey.(expr.Column)
+	if !ok {
+		return nil, false
+	}
+
+	rightColumn, ok := rightKey.(expr.Column)
+	if !ok {
+		return nil, false
+	}
+
+	leftScan, leftCond, ok := scanOf(children[0])
+	if !ok {
+		return nil, false
+	}
+
+	rightScan, rightCond, ok := scanOf(children[1])
+	if !ok {
+		return nil, false
+	}
+
+	leftType, ok := leftScan.Table().Scheme()[leftColumn.Name]
+	if !ok {
+		return nil, false
+	}
+
+	rightType, ok := rightScan.Table().Scheme()[rightColumn.Name]
+	if !ok || leftType.DataType != rightType.DataType {
+		return nil, false
+	}
+
+	left := p.planRows(database, leftScan, rowsQuery{cond: leftCond, sorted: true, order: leftColumn.Name})
+	if !left.ordered {
+		return nil, false
+	}
+
+	right := p.planRows(database, rightScan, rowsQuery{cond: rightCond, sorted: true, order: rightColumn.Name})
+	if !right.ordered {
+		return nil, false
+	}
+
+	merge := plan.NewMergeJoin(
+		join.Type(),
+		join.Cond(),
+		leftColumn.Position,
+		rightColumn.Position,
+		left.node,
+		right.node,
+	)
+
+	return merge, true
+}
+
+// scanOf returns the scan of the node and the condition of the filter over it, false if the node doesn't read
+// rows of a table directly.
+func scanOf(node plan.Node) (*plan.Scan, expr.Node, bool) {
+	var cond expr.Node
+
+	if filter, ok := node.(*plan.Filter); ok {
+		cond, node = filter.Cond(), filter.Children()[0]
+	}
+
+	scan, ok := node.(*plan.Scan)
+
+	return scan, cond, ok
+}
+
+// joinKeys returns pairs of expressions the condition of the join compares for equality, one refers only to columns
+// of the left child and the other only to columns of the right one. Keys of the right child refer to positions
+// in its rows.
+func joinKeys(cond expr.Node, leftWidth int) ([]expr.Node, []expr.Node) {
+	var leftKeys, rightKeys []expr.Node
+
+	if cond == nil {
+		return nil, nil
+	}
+
+	for _, conjunct := range expr.Conjuncts(cond) {
+		binary, ok := expr.AsBinary(conjunct)
+		if !ok || binary.Operator != expr.Equal || expr.IsVolatile(conjunct) {
+			continue
+		}
+
+		left, right := binary.Left, binary.Right
+
+		if isLeftKey(right, leftWidth) && isRightKey(left, leftWidth) {
+			left, right = right, left
+		}
+
+		if isLeftKey(left, leftWidth) && isRightKey(right, leftWidth) {
+			leftKeys = append(leftKeys, left)
+			rightKeys = append(rightKeys, expr.ShiftColumns(right, -leftWidth))
+		}
+	}
+
+	return leftKeys, rightKeys
+}
+
+// isLeftKey reports whether the expression refers to columns of the left child only.
+func isLeftKey(node expr.Node, leftWidth int) bool {
+	columns := expr.Columns(node)
+
+	for _, position := range columns {
+		if int(position) >= leftWidth {
+			return false
+		}
+	}
+
+	return len(columns) > 0
+}
+
+// isRightKey reports whether the expression refers to columns of the right child only.
+func isRightKey(node expr.Node, leftWidth int) bool {
+	columns := expr.Columns(node)
+
+	for _, position := range columns {
+		if int(position) < leftWidth {
+			return false
+		}
+	}
+
+	return len(columns) > 0
+}
+
+// recordJoinEstimate records the estimate of the physical join of the logical one by estimates of its children.
+// Keys are assumed to have the values of the key with more distinct values, other conditions are assumed
+// to be independent of them.
+func (p *Planner) recordJoinEstimate(
+	database string,
+	join *plan.NestedLoopJoin,
+	node plan.Node,
+	leftKeys, rightKeys []expr.Node,
+) {
+	parent, ok := node.(plan.Parent)
+	if !ok {
+		return
+	}
+
+	left, ok := p.estimate(parent.Children()[0])
+	if !ok {
+		return
+	}
+
+	right, ok := p.estimate(parent.Children()[1])
+	if !ok {
+		return
+	}
+
+	children := join.Children()
+	rows := left.Rows * right.Rows
+
+	for i := range leftKeys {
+		distinct := max(
+			p.keyDistinct(database, children[0], leftKeys[i], left.Rows),
+			p.keyDistinct(database, children[1], rightKeys[i], right.Rows),
+			1,
+		)
+
+		rows /= distinct
+	}
+
+	if join.Cond() != nil {
+		others := len(expr.Conjuncts(join.Cond())) - len(leftKeys)
+		rows *= math.Pow(statistics.DefaultRangeSelectivity, float64(others))
+	}
+
+	rows = outerJoinRows(join.Type(), rows, left.Rows, right.Rows)
+
+	switch node.(type) {
+	case *plan.MergeJoin:
+		p.record(node, mergeJoinEstimate(left, right, rows))
+	case *plan.HashJoin:
+		p.record(node, hashJoinEstimate(left, right, rows))
+	default:
+		p.record(node, nestedLoopEstimate(left, right, rows))
+	}
+}
+
+// keyDistinct returns the estimated number of distinct values of the key over the rows of the child. Statistics
+// are known only for a column of a table the child reads directly, any other key is assumed to be unique.
+func (p *Planner) keyDistinct(database string, child plan.Node, key expr.Node, rows float64) float64 {
+	column, ok := key.(expr.Column)
+	if !ok {
+		return rows
+	}
+
+	scan, _, ok := scanOf(child)
+	if !ok {
+		return rows
+	}
+
+	return min(p.estimator(database, scan.Table()).Distinct(column.Name), rows)
+}
+
+// outerJoinRows returns the number of rows of the join of the type, rows of a child whose unmatched rows
+// are returned are all there. A semi join returns left rows that have matches, an anti join the rest of them.
+func outerJoinRows(joinType plan.JoinType, rows, left, right float64) float64 {
+	switch joinType {
+	case plan.SemiJoin:
+		return min(rows, left)
+	case plan.AntiJoin:
+		return max(left-rows, min(left, 1))
+	case plan.LeftJoin:
+		return max(rows, left)
+	case plan.RightJoin:
+		return max(rows, right)
+	case plan.FullJoin:
+		return max(rows, left, right)
+	default:
+		return rows
+	}
+}
@@ -0,0 +1,195 @@
+package planner
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+// planPhysical replaces scans of the optimized plan with the cheapest ways to read rows of their tables,
+// chooses the ways to join them and drops sorts of rows that are read in the order already. Limit is the number
+// of rows the parent of the node reads at most, 0 if it reads all of them: the fewer rows are read, the cheaper
+// ordered access is. Estimates of nodes are recorded for EXPLAIN bottom-up.
+func (p *Planner) planPhysical(database string, node plan.Node, limit float64) plan.Node {
+	switch n := node.(type) {
+	case *plan.Scan:
+		return p.planRows(database, n, rowsQuery{limit: limit}).node
+	case *plan.Filter:
+		if scan, ok := n.Children()[0].(*plan.Scan); ok {
+			return p.planRows(database, scan, rowsQuery{cond: n.Cond(), limit: limit}).node
+		}
+	case *plan.Sort:
+		if sorted, ok := p.planSorted(database, n, n.Column(), n.Order(), limit); ok {
+			return sorted
+		}
+	case *plan.TopN:
+		if sorted, ok := p.planSorted(database, n, n.Column(), n.Order(), float64(n.N())); ok {
+			return sorted
+		}
+	case *plan.NestedLoopJoin:
+		return p.planJoin(database, n)
+	case *plan.HashAggregate:
+		return p.planAggregate(database, n)
+	case *plan.Rows:
+		p.record(n, plan.Estimate{Rows: float64(n.Len())})
+
+		return n
+	}
+
+	parent, ok := node.(plan.Parent)
+	if !ok {
+		return node
+	}
+
+	// Only nodes that pass rows through one by one let their child stop early.
+	var childLimit float64
+
+	switch n := node.(type) {
+	case *plan.Limit:
+		childLimit = float64(n.Value)
+	case *plan.Offset:
+		if limit > 0 {
+			childLimit = limit + float64(n.Offset)
+		}
+	case *plan.Project:
+		childLimit = limit
+	}
+
+	children := parent.Children()
+	physical := make([]plan.Node, len(children))
+
+	for i, child := range children {
+		physical[i] = p.planPhysical(database, child, childLimit)
+	}
+
+	node = parent.WithChildren(physical...)
+	p.recordEstimate(database, node)
+
+	return node
+}
+
+// planSorted reads rows of the table under the sort node in the order of the column if that's cheaper than
+// sorting them, false is returned if the node doesn't sort rows of a table or the rows have to be sorted.
+func (p *Planner) planSorted(
+	database string,
+	node plan.Parent,
+	column uint8,
+	order plan.Order,
+	limit float64,
+) (plan.Node, bool) {
+	query := rowsQuery{sorted: true, limit: limit}
+	child := node.Children()[0]
+
+	if filter, ok := child.(*plan.Filter); ok {
+		query.cond = filter.Cond()
+		child = filter.Children()[0]
+	}
+
+	scan, ok := child.(*plan.Scan)
+	if !ok {
+		return nil, false
+	}
+
+	// Rows can be read only in the ascending order of a column.
+	if columns := scan.Columns(); order == plan.Ascending && int(column) < len(columns) {
+		query.order = columns[column]
+	}
+
+	rows := p.planRows(database, scan, query)
+
+	// Rows read in the order need no sort, the first of them are the top ones.
+	if rows.ordered {
+		top, ok := node.(*plan.TopN)
+		if !ok {
+			return rows.node, true
+		}
+
+		limit := plan.NewLimit(top.N(), rows.node)
+		p.recordEstimate(database, limit)
+
+		return limit, true
+	}
+
+	sorted := node.WithChildren(rows.node)
+	p.recordEstimate(database, sorted)
+
+	return sorted, true
+}
+
+// planRows returns the cheapest way to read rows of the table that satisfy the query, it tells whether they are
+// read in the order of the query. The access path narrows down the rows to read, the filter still checks
+// the whole condition.
+func (p *Planner) planRows(database string, scan *plan.Scan, query rowsQuery) access {
+	table := scan.Table()
+	rows := p.planAccess(database, table, query, scan)
+
+	if query.cond == nil {
+		return rows
+	}
+
+	filter := plan.NewFilter(query.cond, rows.node)
+
+	if estimate, ok := p.estimate(rows.node); ok {
+		estimator := p.estimator(database, table)
+		cardinality := estimator.Cardinality(selectivity(estimator, table.Scheme(), query.cond))
+		p.record(filter, filterEstimate(estimate, cardinality))
+	}
+
+	rows.node = filter
+
+	return rows
+}
+
+// recordEstimate records the estimate of the node by the estimate of its child.
+func (p *Planner) recordEstimate(database string, node plan.Node) {
+	parent, ok := node.(plan.Parent)
+	if !ok || len(parent.Children()) != 1 {
+		return
+	}
+
+	child, ok := p.estimate(parent.Children()[0])
+	if !ok {
+		return
+	}
+
+	var estimate plan.Estimate
+
+	switch n := node.(type) {
+	case *plan.Filter:
+		estimate = filterEstimate(child, conditionRows(n.Cond(), child.Rows))
+	case *plan.Sort:
+		estimate = sortEstimate(child)
+	case *plan.TopN:
+		estimate = topNEstimate(child, float64(n.N()))
+	case *plan.Project:
+		estimate = projectEstimate(child, len(n.Projections()))
+	case *plan.Distinct:
+		keys := len(n.On())
+		if keys == 0 {
+			keys = len(n.Columns())
+		}
+
+		estimate = distinctEstimate(child, keys)
+	case *plan.Offset:
+		estimate = offsetEstimate(child, float64(n.Offset))
+	case *plan.Limit:
+		estimate = limitEstimate(child, float64(n.Value))
+	case *plan.Update, *plan.Delete:
+		estimate = modifyEstimate(child)
+	default:
+		estimate = child
+	}
+
+	p.record(node, estimate)
+}
+
+// conditionRows returns the estimated number of the rows that satisfy the condition of a filter that doesn't read
+// a table directly, so there are no statistics of its columns.
+func conditionRows(cond expr.Node, rows float64) float64 {
+	if value, ok := constant(cond, sql.Boolean); ok && value.Raw() == true {
+		return rows
+	}
+
+	return rows * statistics.DefaultRangeSelectivity
+}
@@ -3,17 +3,23 @@ package planner
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/optimizer"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
 )
 
 type Planner struct {
 	catalog sql.Catalog
+	// estimates of nodes recorded for EXPLAIN, nil if the plan is executed
+	estimates map[plan.Node]plan.Estimate
 }
 
 func New(catalog sql.Catalog) *Planner {
@@ -22,7 +28,25 @@ func New(catalog sql.Catalog) *Planner {
 	}
 }
 
-func (p *Planner) Plan(database string, node ast.Node) (plan.Node, error) {
+// Plan builds the plan of the statement. Statements run inside the open transaction of the session, if any.
+func (p *Planner) Plan(session *transaction.Session, database string, node ast.Node) (plan.Node, error) {
+	switch node.(type) {
+	case *ast.BeginStatement:
+		return plan.NewBegin(session), nil
+	case *ast.CommitStatement:
+		return plan.NewCommit(session), nil
+	case *ast.RollbackStatement:
+		return plan.NewRollback(session), nil
+	}
+
+	if tx := session.Tx(); tx != nil {
+		return New(tx).plan(database, node)
+	}
+
+	return p.plan(database, node)
+}
+
+func (p *Planner) plan(database string, node ast.Node) (plan.Node, error) {
 	switch stmt := node.(type) {
 	// DDL
 	case *ast.CreateDatabaseStatement:
@@ -33,15 +57,28 @@ func (p *Planner) Plan(database string, node ast.Node) (plan.Node, error) {
 		return p.planCreateTable(database, stmt)
 	case *ast.DropTableStatement:
 		return p.planDropTable(database, stmt)
+	case *ast.CreateIndexStatement:
+		return p.planCreateIndex(database, stmt)
+	case *ast.DropIndexStatement:
+		return p.planDropIndex(database, stmt)
+	case *ast.CreateSequenceStatement:
+		return p.planCreateSequence(database, stmt)
+	case *ast.DropSequenceStatement:
+		return p.planDropSequence(database, stmt)
 	// DML
 	case *ast.SelectStatement:
-		return p.planSelect(database, stmt)
+		return p.planSelect(database, stmt, nil)
 	case *ast.InsertStatement:
 		return p.planInsert(database, stmt)
 	case *ast.UpdateStatement:
 		return p.planUpdate(database, stmt)
 	case *ast.DeleteStatement:
 		return p.planDelete(database, stmt)
+	// Statistics
+	case *ast.AnalyzeStatement:
+		return p.planAnalyze(database, stmt)
+	case *ast.ExplainStatement:
+		return p.planExplain(database, stmt)
 	case nil:
 		return plan.NewRows(), nil
 	default:
@@ -49,29 +86,73 @@ func (p *Planner) Plan(database string, node ast.Node) (plan.Node, error) {
 	}
 }
 
-func (p *Planner) planSelect(database string, stmt *ast.SelectStatement) (plan.Node, error) {
+// planSelect builds the plan of the statement as it's written, rewrites it by the rules of the optimizer
+// and then chooses access paths of the tables it reads and ways to join them. The statement is a subquery
+// if there are parameters, the columns of the outer query it refers to become them.
+func (p *Planner) planSelect(database string, stmt *ast.SelectStatement, params *expr.Params) (plan.Node, error) {
+	node, err := p.planQuery(database, stmt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.planPhysical(database, optimizer.Optimize(node), 0), nil
+}
+
+// planQuery builds the plan of the statement as it's written.
+func (p *Planner) planQuery(database string, stmt *ast.SelectStatement, params *expr.Params) (plan.Node, error) {
 	var (
-		table sql.Table
+		scope *expr.Scope
 		node  plan.Node
+		where *ast.WhereStatement
 		err   error
 	)
 
-	if table, node, err = p.planScan(database, stmt.From); err != nil {
-		return nil, fmt.Errorf("plan scan: %w", err)
+	if scope, node, err = p.planFrom(database, stmt.From, params); err != nil {
+		return nil, fmt.Errorf("plan from: %w", err)
 	}
 
-	if node, err = p.planFilter(table, stmt.Where, node); err != nil {
+	if where, node, err = p.planSemiJoins(database, scope, stmt.Where, node); err != nil {
+		return nil, fmt.Errorf("plan subqueries: %w", err)
+	}
+
+	if node, err = p.planFilter(database, scope, where, node); err != nil {
 		return nil, fmt.Errorf("plan filter: %w", err)
 	}
 
-	if node, err = p.planSort(table, stmt.OrderBy, node); err != nil {
+	// Expressions of every statement are resolved to find aggregates, so errors in them are returned as they are:
+	// the statement may not be grouped at all.
+	g, err := p.planGrouping(database, scope, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if g != nil {
+		if node, err = g.plan(node); err != nil {
+			return nil, fmt.Errorf("plan grouping: %w", err)
+		}
+	}
+
+	if node, err = p.planSort(scope, g, stmt.OrderBy, node); err != nil {
 		return nil, fmt.Errorf("plan sort: %w", err)
 	}
 
-	if node, err = p.planProject(table, stmt.Result, node); err != nil {
+	if node, err = p.planDistinctOn(database, scope, g, stmt.Distinct, stmt.OrderBy, node); err != nil {
+		return nil, fmt.Errorf("plan distinct: %w", err)
+	}
+
+	if err = checkDistinctOrder(scope, stmt); err != nil {
+		return nil, fmt.Errorf("plan distinct: %w", err)
+	}
+
+	if node, err = p.planProject(database, scope, g, stmt.Result, node); err != nil {
 		return nil, fmt.Errorf("plan project: %w", err)
 	}
 
+	// Plain DISTINCT tells rows apart by all their columns.
+	if stmt.Distinct != nil && len(stmt.Distinct.On) == 0 {
+		node = plan.NewDistinct(nil, node)
+	}
+
 	if node, err = p.planOffset(stmt.Offset, node); err != nil {
 		return nil, fmt.Errorf("plan offset: %w", err)
 	}
@@ -83,17 +164,114 @@ func (p *Planner) planSelect(database string, stmt *ast.SelectStatement) (plan.N
 	return node, nil
 }
 
-func (p *Planner) planScan(database string, stmt *ast.FromStatement) (sql.Table, plan.Node, error) {
+// planFrom builds the plan that reads rows of the tables of the FROM clause and the scope of their columns.
+// Tables are joined in the order they're written, a table joined to the tables before it is the right child
+// of the join, planJoin swaps children of an inner join if that's cheaper. A statement without the FROM clause
+// reads a single empty row, it has no scope unless it's a subquery: then its names are columns of the outer query.
+func (p *Planner) planFrom(
+	database string,
+	stmt *ast.FromStatement,
+	params *expr.Params,
+) (*expr.Scope, plan.Node, error) {
 	if stmt == nil {
+		if params != nil {
+			return expr.NewScope("", nil).Correlated(params), plan.NewRows(sql.Row{}), nil
+		}
+
 		return nil, plan.NewRows(sql.Row{}), nil
 	}
 
-	table, err := p.getTable(database, stmt.Table)
+	scope, node, err := p.planFromTable(database, stmt.Table, stmt.Subquery, stmt.Alias, params)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return table, plan.NewScan(table), nil
+	if params != nil {
+		scope = scope.Correlated(params)
+	}
+
+	for _, join := range stmt.Joins {
+		tableScope, table, err := p.planFromTable(database, join.Table, join.Subquery, join.Alias, params)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if scope, err = scope.Join(tableScope); err != nil {
+			return nil, nil, err
+		}
+
+		var cond expr.Node
+
+		if join.On != nil {
+			if cond, err = expr.NewInScope(join.On, scope, nil); err != nil {
+				return nil, nil, fmt.Errorf("join condition: %w", err)
+			}
+		}
+
+		joinType, err := planJoinType(join.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node = plan.NewNestedLoopJoin(joinType, cond, node, table)
+	}
+
+	return scope, node, nil
+}
+
+// planFromTable returns the plan that reads rows of the table of the FROM clause and the scope of its columns,
+// the table is derived if there is the subquery.
+func (p *Planner) planFromTable(
+	database, name string,
+	subquery *ast.SelectStatement,
+	alias string,
+	params *expr.Params,
+) (*expr.Scope, plan.Node, error) {
+	if subquery != nil {
+		return p.planDerivedTable(database, subquery, alias, params)
+	}
+
+	return p.planTable(database, name, alias)
+}
+
+// planTable returns the scan of the table and the scope of its columns, qualified by the alias if there is one.
+func (p *Planner) planTable(database, name, alias string) (*expr.Scope, plan.Node, error) {
+	table, err := p.getTable(database, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if alias == "" {
+		alias = name
+	}
+
+	return scopeOf(alias, table), plan.NewScan(table), nil
+}
+
+// scopeOf returns the scope of columns of the table named by the name, nil if there is no table.
+func scopeOf(name string, table sql.Table) *expr.Scope {
+	if table == nil {
+		return nil
+	}
+
+	return expr.NewScope(name, table.Scheme())
+}
+
+func planJoinType(joinType token.Type) (plan.JoinType, error) {
+	switch joinType {
+	case token.Inner:
+		return plan.InnerJoin, nil
+	case token.Left:
+		return plan.LeftJoin, nil
+	case token.Right:
+		return plan.RightJoin, nil
+	case token.Full:
+		return plan.FullJoin, nil
+	case token.Cross:
+		return plan.CrossJoin, nil
+	default:
+		return 0, fmt.Errorf("unexpected join type: %s", joinType)
+	}
 }
 
 func (p *Planner) planInsert(database string, stmt *ast.InsertStatement) (plan.Node, error) {
@@ -106,57 +284,56 @@ func (p *Planner) planInsert(database string, stmt *ast.InsertStatement) (plan.N
 		return nil, errors.New("number of expressions should be equal to the number of columns")
 	}
 
-	var key int64
-
 	scheme := table.Scheme()
+	sequences := p.sequences(database)
 	row := make(sql.Row, len(scheme))
+	values := make(map[uint8]expr.Node, len(stmt.Columns))
 
+	// Columns without a default are NULL unless they're given.
 	for i := range scheme {
-		value := scheme[i].Default
-
-		if scheme[i].PrimaryKey {
-			key = table.Sequence().Next()
-			value = datatype.NewInteger(key)
+		if row[scheme[i].Position] = scheme[i].Default; row[scheme[i].Position] == nil {
+			row[scheme[i].Position] = datatype.NewNull()
 		}
-
-		row[scheme[i].Position] = value
 	}
 
+	// Values are evaluated by the plan node, so nothing is taken from sequences if the statement is invalid.
 	for idx, columnName := range stmt.Columns {
 		column, ok := scheme[columnName]
 		if !ok {
 			return nil, fmt.Errorf("column %q not found", columnName)
 		}
 
-		valueExpr, exprErr := expr.New(stmt.Values[idx], scheme)
-		if exprErr != nil {
-			return nil, exprErr
+		if column.Hidden {
+			return nil, fmt.Errorf("column %q can't be set", columnName)
 		}
 
-		value, evalErr := valueExpr.Eval(nil)
-		if evalErr != nil {
-			return nil, evalErr
+		if column.Identity {
+			return nil, fmt.Errorf("column %q is an identity column and can't be set", columnName)
 		}
 
-		if column.DataType != value.DataType() {
-			switch {
-			case value.DataType() != sql.Null:
-				return nil, fmt.Errorf("invalid value for column %q", column.Name)
-			case value.DataType() == sql.Null && !column.Nullable:
-				return nil, fmt.Errorf("null value in column %q violates not-null constraint", column.Name)
-			}
+		value, err := expr.NewWithSequences(stmt.Values[idx], scheme, sequences)
+		if err != nil {
+			return nil, err
 		}
 
-		if column.PrimaryKey {
-			if key, ok = value.Raw().(int64); !ok {
-				return nil, fmt.Errorf("unsupported primary key type %T", value.Raw())
-			}
+		values[column.Position] = value
+	}
+
+	// Columns that aren't given take the next value of their sequence.
+	for name := range scheme {
+		if _, ok := values[scheme[name].Position]; ok || scheme[name].Sequence == "" {
+			continue
 		}
 
-		row[column.Position] = value
+		sequence, err := sequences.GetSequence(scheme[name].Sequence)
+		if err != nil {
+			return nil, fmt.Errorf("sequence of column %q: %w", name, err)
+		}
+
+		values[scheme[name].Position] = &expr.Nextval{Sequence: sequence}
 	}
 
-	return plan.NewInsert(table, key, row), nil
+	return plan.NewInsert(table, row, values), nil
 }
 
 func (p *Planner) planUpdate(database string, stmt *ast.UpdateStatement) (plan.Node, error) {
@@ -171,19 +348,35 @@ func (p *Planner) planUpdate(database string, stmt *ast.UpdateStatement) (plan.N
 		return nil, err
 	}
 
-	if node, err = p.planFilter(table, stmt.Where, plan.NewScan(table)); err != nil {
+	if node, err = p.planTableFilter(database, stmt.Table, table, stmt.Where); err != nil {
 		return nil, fmt.Errorf("plan filter: %w", err)
 	}
 
-	if columns, err = p.planUpdateColumns(table.Scheme(), stmt.Set); err != nil {
+	node = p.planPhysical(database, optimizer.Optimize(node), 0)
+
+	if columns, err = p.planUpdateColumns(database, stmt.Table, table, stmt.Set); err != nil {
 		return nil, fmt.Errorf("plan columns for update: %w", err)
 	}
 
-	return plan.NewUpdate(table, table.PrimaryKey().Position, columns, node), nil
+	update := plan.NewUpdate(table, sql.Positions(table.PrimaryKey()), columns, node)
+	p.recordEstimate(database, update)
+
+	return update, nil
 }
 
-func (p *Planner) planUpdateColumns(scheme sql.Scheme, stmts []ast.SetStatement) (map[uint8]expr.Node, error) {
-	columns := make(map[uint8]expr.Node, len(stmts))
+// planUpdateColumns returns new values of columns by their positions. Values are expressions over the row
+// of the table, they may refer to its columns qualified by the name of the table.
+func (p *Planner) planUpdateColumns(
+	database string,
+	name string,
+	table sql.Table,
+	stmts []ast.SetStatement,
+) (map[uint8]expr.Node, error) {
+	var (
+		scheme  = table.Scheme()
+		scope   = expr.NewScope(name, scheme)
+		columns = make(map[uint8]expr.Node, len(stmts))
+	)
 
 	for i := range stmts {
 		column, ok := scheme[stmts[i].Column]
@@ -191,7 +384,15 @@ func (p *Planner) planUpdateColumns(scheme sql.Scheme, stmts []ast.SetStatement)
 			return nil, fmt.Errorf("column %q not found", stmts[i].Column)
 		}
 
-		value, err := expr.New(stmts[i].Value, scheme)
+		if column.Hidden {
+			return nil, fmt.Errorf("column %q can't be set", stmts[i].Column)
+		}
+
+		if column.Identity {
+			return nil, fmt.Errorf("column %q is an identity column and can't be set", stmts[i].Column)
+		}
+
+		value, err := expr.NewInScope(stmts[i].Value, scope, p.sequences(database))
 		if err != nil {
 			return nil, fmt.Errorf("create expr from value: %w", err)
 		}
@@ -213,11 +414,76 @@ func (p *Planner) planDelete(database string, stmt *ast.DeleteStatement) (plan.N
 		return nil, err
 	}
 
-	if node, err = p.planFilter(table, stmt.Where, plan.NewScan(table)); err != nil {
+	if node, err = p.planTableFilter(database, stmt.Table, table, stmt.Where); err != nil {
 		return nil, fmt.Errorf("plan filter: %w", err)
 	}
 
-	return plan.NewDelete(table, table.PrimaryKey().Position, node), nil
+	node = p.planPhysical(database, optimizer.Optimize(node), 0)
+
+	deletion := plan.NewDelete(table, sql.Positions(table.PrimaryKey()), node)
+	p.recordEstimate(database, deletion)
+
+	return deletion, nil
+}
+
+// planExplain builds the plan of the statement with estimates of its nodes and returns the node that shows it.
+func (p *Planner) planExplain(database string, stmt *ast.ExplainStatement) (plan.Node, error) {
+	switch stmt.Statement.(type) {
+	case *ast.SelectStatement, *ast.UpdateStatement, *ast.DeleteStatement:
+	default:
+		return nil, fmt.Errorf("EXPLAIN of %T is not supported", stmt.Statement)
+	}
+
+	var format plan.ExplainFormat
+
+	switch stmt.Format {
+	case token.Text:
+		format = plan.ExplainText
+	case token.JSON:
+		format = plan.ExplainJSON
+	default:
+		return nil, fmt.Errorf("unexpected EXPLAIN format: %s", stmt.Format)
+	}
+
+	explainer := &Planner{
+		catalog:   p.catalog,
+		estimates: make(map[plan.Node]plan.Estimate),
+	}
+
+	node, err := explainer.plan(database, stmt.Statement)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewExplain(node, explainer.estimates, stmt.Analyze, format), nil
+}
+
+// planAnalyze builds the plan that collects statistics of the table, or of all tables of the database.
+func (p *Planner) planAnalyze(database string, stmt *ast.AnalyzeStatement) (plan.Node, error) {
+	if database == "" {
+		return nil, errors.New("database not specified")
+	}
+
+	db, err := p.catalog.GetDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("get database: %w", err)
+	}
+
+	if stmt.Table != "" {
+		table, err := db.GetTable(stmt.Table)
+		if err != nil {
+			return nil, fmt.Errorf("get table %q: %w", stmt.Table, err)
+		}
+
+		return plan.NewAnalyze(db, []sql.Table{table}), nil
+	}
+
+	tables := db.ListTables()
+	slices.SortFunc(tables, func(a, b sql.Table) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return plan.NewAnalyze(db, tables), nil
 }
 
 func (p *Planner) planCreateDatabase(stmt *ast.CreateDatabaseStatement) (plan.Node, error) {
@@ -225,7 +491,16 @@ func (p *Planner) planCreateDatabase(stmt *ast.CreateDatabaseStatement) (plan.No
 		return nil, fmt.Errorf("database %q already exist", stmt.Database)
 	}
 
-	return plan.NewCreateDatabase(p.catalog, stmt.Database), nil
+	if stmt.Engine == "" {
+		return plan.NewCreateDatabase(p.catalog, stmt.Database), nil
+	}
+
+	catalog, ok := p.catalog.(sql.EngineCatalog)
+	if !ok {
+		return nil, fmt.Errorf("storage engine %q is not supported", stmt.Engine)
+	}
+
+	return plan.NewCreateDatabase(databaseEngine{catalog: catalog, engine: stmt.Engine}, stmt.Database), nil
 }
 
 func (p *Planner) planDropDatabase(stmt *ast.DropDatabaseStatement) (plan.Node, error) {
@@ -246,20 +521,77 @@ func (p *Planner) planCreateTable(database string, stmt *ast.CreateTableStatemen
 		return nil, fmt.Errorf("table %q already exist", stmt.Table)
 	}
 
-	scheme, err := p.planTableScheme(stmt.Columns)
+	scheme, err := p.planTableScheme(db, stmt.Table, stmt.Columns, stmt.PrimaryKey)
 	if err != nil {
 		return nil, fmt.Errorf("plan table scheme: %w", err)
 	}
 
-	return plan.NewCreateTable(db, stmt.Table, scheme), nil
+	identities, err := p.planIdentitySequences(db, stmt.Table, stmt.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("plan identity columns: %w", err)
+	}
+
+	var creator plan.TableCreator = db
+
+	if stmt.Engine != "" {
+		engineDB, ok := db.(sql.EngineDatabase)
+		if !ok {
+			return nil, fmt.Errorf("storage engine %q is not supported", stmt.Engine)
+		}
+
+		creator = tableEngine{database: engineDB, engine: stmt.Engine}
+	}
+
+	if len(identities) > 0 {
+		creator = identityTable{creator: creator, database: db, sequences: identities}
+	}
+
+	return plan.NewCreateTable(creator, stmt.Table, scheme), nil
 }
 
-func (p *Planner) planTableScheme(columns []ast.Column) (sql.Scheme, error) {
+// planIdentitySequences plans sequences of identity columns, they are named after the table and the column.
+func (p *Planner) planIdentitySequences(
+	db sql.Database,
+	table string,
+	columns []ast.Column,
+) ([]identitySequence, error) {
+	var sequences []identitySequence
+
+	for i := range columns {
+		if columns[i].Identity == nil {
+			continue
+		}
+
+		options, err := p.planSequenceOptions(*columns[i].Identity)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", columns[i].Name, err)
+		}
+
+		name := identitySequenceName(table, columns[i].Name)
+
+		if _, err = db.GetSequence(name); err == nil {
+			return nil, fmt.Errorf("sequence %q already exist", name)
+		}
+
+		sequences = append(sequences, identitySequence{name: name, options: options})
+	}
+
+	return sequences, nil
+}
+
+// planTableScheme plans columns of a table, the primary key is either a column constraint
+// or a table constraint that lists its columns.
+func (p *Planner) planTableScheme(
+	db sql.Database,
+	table string,
+	columns []ast.Column,
+	primaryKey []string,
+) (sql.Scheme, error) {
 	primaryKeys := 0
 	scheme := make(sql.Scheme, len(columns))
 
 	for i := range columns {
-		column, err := p.planSchemeColumn(uint8(i), columns[i])
+		column, err := p.planSchemeColumn(db, table, uint8(i), columns[i])
 		if err != nil {
 			return nil, err
 		}
@@ -271,21 +603,67 @@ func (p *Planner) planTableScheme(columns []ast.Column) (sql.Scheme, error) {
 		scheme[column.Name] = column
 	}
 
-	if primaryKeys == 0 {
-		return nil, errors.New("primary key is required")
+	if primaryKeys > 1 || (primaryKeys == 1 && len(primaryKey) > 0) {
+		return nil, errors.New("multiple primary keys are not allowed")
 	}
 
-	if primaryKeys > 1 {
-		return nil, errors.New("multiple primary keys are not allowed")
+	for i, name := range primaryKey {
+		column, ok := scheme[name]
+		if !ok {
+			return nil, fmt.Errorf("primary key column %q not found", name)
+		}
+
+		if column.PrimaryKey {
+			return nil, fmt.Errorf("primary key column %q is listed more than once", name)
+		}
+
+		column.PrimaryKey = true
+		column.KeyPosition = uint8(i)
+		scheme[name] = column
+		primaryKeys++
+	}
+
+	for name := range scheme {
+		if scheme[name].PrimaryKey && scheme[name].Nullable {
+			return nil, fmt.Errorf("primary key column %q can't be nullable", name)
+		}
+
+		// The primary key of a table is generated by the sequence of the table.
+		if scheme[name].PrimaryKey && scheme[name].Identity {
+			return nil, fmt.Errorf("primary key column %q can't be an identity column", name)
+		}
+	}
+
+	// Rows of a table without a primary key are identified by a hidden row id.
+	if primaryKeys == 0 {
+		if _, ok := scheme[sql.RowID]; ok {
+			return nil, fmt.Errorf("column name %q is reserved for tables without a primary key", sql.RowID)
+		}
+
+		scheme[sql.RowID] = sql.Column{
+			Position:   uint8(len(columns)),
+			Name:       sql.RowID,
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+			Hidden:     true,
+		}
 	}
 
 	return scheme, nil
 }
 
-func (p *Planner) planSchemeColumn(position uint8, column ast.Column) (sql.Column, error) {
+func (p *Planner) planSchemeColumn(
+	db sql.Database,
+	table string,
+	position uint8,
+	column ast.Column,
+) (sql.Column, error) {
 	var (
 		dataType sql.DataType
 		value    sql.Value
+		sequence string
 	)
 
 	switch column.Type {
@@ -301,7 +679,31 @@ func (p *Planner) planSchemeColumn(position uint8, column ast.Column) (sql.Colum
 		return sql.Column{}, fmt.Errorf("unexpected column type: %q", column.Type)
 	}
 
-	if column.Default != nil {
+	switch {
+	case column.Identity != nil:
+		if err := checkIdentityColumn(column, dataType); err != nil {
+			return sql.Column{}, err
+		}
+
+		sequence = identitySequenceName(table, column.Name)
+	case isCall(column.Default):
+		// DEFAULT nextval('sequence') is the only function call allowed, it's called on every insert.
+		defaultExpr, err := expr.NewWithSequences(column.Default, nil, db)
+		if err != nil {
+			return sql.Column{}, fmt.Errorf("create default expr: %w", err)
+		}
+
+		nextval, ok := defaultExpr.(*expr.Nextval)
+		if !ok {
+			return sql.Column{}, fmt.Errorf("default of column %q can only call %s", column.Name, expr.NextvalFunc)
+		}
+
+		if dataType != sql.Integer {
+			return sql.Column{}, fmt.Errorf("invalid default value for column %q", column.Name)
+		}
+
+		sequence = nextval.Sequence.Name()
+	case column.Default != nil:
 		defaultExpr, err := expr.New(column.Default, nil)
 		if err != nil {
 			return sql.Column{}, fmt.Errorf("create default expr: %w", err)
@@ -329,76 +731,264 @@ func (p *Planner) planSchemeColumn(position uint8, column ast.Column) (sql.Colum
 		PrimaryKey: column.PrimaryKey,
 		Nullable:   column.Nullable,
 		Default:    value,
+		Sequence:   sequence,
+		Identity:   column.Identity != nil,
 	}, nil
 }
 
+func checkIdentityColumn(column ast.Column, dataType sql.DataType) error {
+	switch {
+	case dataType != sql.Integer:
+		return fmt.Errorf("identity column %q must be of type %s", column.Name, sql.Integer)
+	case column.Nullable:
+		return fmt.Errorf("identity column %q can't be nullable", column.Name)
+	case column.Default != nil:
+		return fmt.Errorf("both default and identity specified for column %q", column.Name)
+	default:
+		return nil
+	}
+}
+
+func isCall(node ast.Expression) bool {
+	_, ok := node.(*ast.CallExpr)
+
+	return ok
+}
+
+// identitySequenceName returns the name of the sequence of the identity column.
+func identitySequenceName(table, column string) string {
+	return fmt.Sprintf("%s_%s_seq", table, column)
+}
+
+func (p *Planner) planSequenceOptions(stmt ast.SequenceOptions) (sql.SequenceOptions, error) {
+	var start, increment *int64
+
+	for _, option := range []struct {
+		name  string
+		node  ast.Expression
+		value **int64
+	}{
+		{name: "START", node: stmt.Start, value: &start},
+		{name: "INCREMENT", node: stmt.Increment, value: &increment},
+	} {
+		if option.node == nil {
+			continue
+		}
+
+		optionExpr, err := expr.New(option.node, nil)
+		if err != nil {
+			return sql.SequenceOptions{}, fmt.Errorf("create %s expr: %w", option.name, err)
+		}
+
+		value, err := optionExpr.Eval(nil)
+		if err != nil {
+			return sql.SequenceOptions{}, fmt.Errorf("eval %s expr: %w", option.name, err)
+		}
+
+		n, ok := value.Raw().(int64)
+		if !ok {
+			return sql.SequenceOptions{}, fmt.Errorf("%s expr must be integer type", option.name)
+		}
+
+		*option.value = &n
+	}
+
+	options := sql.DefaultSequenceOptions(start, increment)
+	if err := options.Validate(); err != nil {
+		return sql.SequenceOptions{}, err
+	}
+
+	return options, nil
+}
+
+func (p *Planner) planCreateSequence(database string, stmt *ast.CreateSequenceStatement) (plan.Node, error) {
+	db, err := p.catalog.GetDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("get database: %w", err)
+	}
+
+	if _, err = db.GetSequence(stmt.Sequence); err == nil {
+		return nil, fmt.Errorf("sequence %q already exist", stmt.Sequence)
+	}
+
+	options, err := p.planSequenceOptions(stmt.Options)
+	if err != nil {
+		return nil, fmt.Errorf("plan sequence options: %w", err)
+	}
+
+	return plan.NewCreateSequence(db, stmt.Sequence, options), nil
+}
+
+func (p *Planner) planDropSequence(database string, stmt *ast.DropSequenceStatement) (plan.Node, error) {
+	db, err := p.catalog.GetDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("get database: %w", err)
+	}
+
+	if _, err = db.GetSequence(stmt.Sequence); err != nil {
+		return nil, fmt.Errorf("get sequence %q: %w", stmt.Sequence, err)
+	}
+
+	for _, table := range db.ListTables() {
+		for _, column := range table.Scheme() {
+			if column.Sequence == stmt.Sequence {
+				return nil, fmt.Errorf("sequence %q is used by column %q of table %q", stmt.Sequence, column.Name, table.Name())
+			}
+		}
+	}
+
+	return plan.NewDropSequence(db, stmt.Sequence), nil
+}
+
 func (p *Planner) planDropTable(database string, stmt *ast.DropTableStatement) (plan.Node, error) {
 	db, err := p.catalog.GetDatabase(database)
 	if err != nil {
 		return nil, fmt.Errorf("get database: %w", err)
 	}
 
-	if _, err = db.GetTable(stmt.Table); err != nil {
+	table, err := db.GetTable(stmt.Table)
+	if err != nil {
 		return nil, fmt.Errorf("get table %q: %w", stmt.Table, err)
 	}
 
+	// Sequences of identity columns belong to the table, so they are dropped with it.
+	var identities []string
+
+	for _, column := range table.Scheme() {
+		if column.Identity {
+			identities = append(identities, column.Sequence)
+		}
+	}
+
+	if len(identities) > 0 {
+		return plan.NewDropTable(identityDropper{database: db, sequences: identities}, stmt.Table), nil
+	}
+
 	return plan.NewDropTable(db, stmt.Table), nil
 }
 
-func (p *Planner) planProject(table sql.Table, stmt []ast.ResultStatement, child plan.Node) (plan.Node, error) {
-	var (
-		scheme      sql.Scheme
-		projections []plan.Projection
-		err         error
-	)
+func (p *Planner) planCreateIndex(database string, stmt *ast.CreateIndexStatement) (plan.Node, error) {
+	table, err := p.getTable(database, stmt.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.findIndex(database, stmt.Index); err == nil {
+		return nil, fmt.Errorf("index %q already exist", stmt.Index)
+	}
 
+	scheme := table.Scheme()
+
+	for _, column := range stmt.Columns {
+		if _, ok := scheme[column]; !ok {
+			return nil, fmt.Errorf("column %q not found", column)
+		}
+	}
+
+	return plan.NewCreateIndex(table, stmt.Index, stmt.Columns, stmt.Unique), nil
+}
+
+func (p *Planner) planDropIndex(database string, stmt *ast.DropIndexStatement) (plan.Node, error) {
+	table, err := p.findIndex(database, stmt.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewDropIndex(table, stmt.Index), nil
+}
+
+// findIndex returns the table that owns the index, index names are unique within a database.
+func (p *Planner) findIndex(database, name string) (sql.Table, error) {
+	if database == "" {
+		return nil, errors.New("database not specified")
+	}
+
+	db, err := p.catalog.GetDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("get database: %w", err)
+	}
+
+	for _, table := range db.ListTables() {
+		for _, index := range table.Indexes() {
+			if index.Name() == name {
+				return table, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("index %q not found", name)
+}
+
+// planProject builds the projection of the results. Results of a grouped statement are computed from rows of groups.
+func (p *Planner) planProject(
+	database string,
+	scope *expr.Scope,
+	g *grouping,
+	stmt []ast.ResultStatement,
+	child plan.Node,
+) (plan.Node, error) {
 	if len(stmt) == 0 {
 		return nil, errors.New("projections list should be not empty")
 	}
 
-	if table != nil {
-		scheme = table.Scheme()
+	projections, err := p.planProjections(database, scope, stmt)
+	if err != nil {
+		return nil, err
 	}
 
-	if projections, err = p.planProjections(scheme, stmt); err != nil {
-		return nil, err
+	if g != nil {
+		for i := range projections {
+			if projections[i].Expr, err = g.rewrite(projections[i].Expr); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return plan.NewProject(projections, child), nil
 }
 
-func (p *Planner) planProjections(scheme sql.Scheme, stmt []ast.ResultStatement) ([]plan.Projection, error) {
+func (p *Planner) planProjections(
+	database string,
+	scope *expr.Scope,
+	stmt []ast.ResultStatement,
+) ([]plan.Projection, error) {
 	projections := make([]plan.Projection, 0, len(stmt))
 
 	for i := range stmt {
-		switch stmt[i].Expr.(type) {
+		switch result := stmt[i].Expr.(type) {
 		case *ast.AsteriskExpr:
-			if scheme == nil {
+			if scope == nil {
 				return nil, errors.New("table not specified")
 			}
 
-			columns := make([]string, len(scheme))
-
-			for name := range scheme {
-				columns[scheme[name].Position] = name
-			}
+			for _, column := range scope.Columns() {
+				if column.Hidden {
+					continue
+				}
 
-			for position := range columns {
 				projections = append(projections, plan.Projection{
 					Expr: expr.Column{
-						Name:     columns[position],
-						Position: uint8(position),
+						Name:     column.Name,
+						Position: column.Position,
 					},
 				})
 			}
 		default:
-			node, err := expr.New(stmt[i].Expr, scheme)
+			// Results call aggregate functions only if the statement is grouped.
+			node, err := expr.NewWithAggregates(result, scope, p.sequences(database))
 			if err != nil {
 				return nil, err
 			}
 
+			alias := stmt[i].Alias
+
+			// A qualified column is named by the column alone.
+			if ident, ok := result.(*ast.IdentExpr); ok && alias == "" && ident.Table != "" {
+				alias = ident.Name
+			}
+
 			projections = append(projections, plan.Projection{
-				Alias: stmt[i].Alias,
+				Alias: alias,
 				Expr:  node,
 			})
 		}
@@ -407,16 +997,21 @@ func (p *Planner) planProjections(scheme sql.Scheme, stmt []ast.ResultStatement)
 	return projections, nil
 }
 
-func (p *Planner) planFilter(table sql.Table, stmt *ast.WhereStatement, child plan.Node) (plan.Node, error) {
+func (p *Planner) planFilter(
+	database string,
+	scope *expr.Scope,
+	stmt *ast.WhereStatement,
+	child plan.Node,
+) (plan.Node, error) {
 	if stmt == nil {
 		return child, nil
 	}
 
-	if table == nil {
+	if scope == nil {
 		return nil, errors.New("table not specified")
 	}
 
-	cond, err := expr.New(stmt.Expr, table.Scheme())
+	cond, err := expr.NewInScope(stmt.Expr, scope, p.sequences(database))
 	if err != nil {
 		return nil, err
 	}
@@ -424,18 +1019,33 @@ func (p *Planner) planFilter(table sql.Table, stmt *ast.WhereStatement, child pl
 	return plan.NewFilter(cond, child), nil
 }
 
-func (p *Planner) planSort(table sql.Table, stmt *ast.OrderByStatement, child plan.Node) (plan.Node, error) {
+// planTableFilter builds the filter of rows of the single table a statement modifies.
+func (p *Planner) planTableFilter(
+	database, name string,
+	table sql.Table,
+	stmt *ast.WhereStatement,
+) (plan.Node, error) {
 	if stmt == nil {
-		return child, nil
+		return plan.NewScan(table), nil
 	}
 
-	if table == nil {
-		return nil, errors.New("table not specified")
+	return p.planFilter(database, scopeOf(name, table), stmt, plan.NewScan(table))
+}
+
+// planSort builds the sort by the column, a grouped statement is sorted by the group expression of the column.
+func (p *Planner) planSort(
+	scope *expr.Scope,
+	g *grouping,
+	stmt *ast.OrderByStatement,
+	child plan.Node,
+) (plan.Node, error) {
+	if stmt == nil {
+		return child, nil
 	}
 
-	column, ok := table.Scheme()[stmt.Column]
-	if !ok {
-		return nil, fmt.Errorf("column %s not exists", stmt.Column)
+	position, err := sortPosition(scope, g, stmt)
+	if err != nil {
+		return nil, err
 	}
 
 	var order plan.Order
@@ -449,7 +1059,113 @@ func (p *Planner) planSort(table sql.Table, stmt *ast.OrderByStatement, child pl
 		return nil, fmt.Errorf("unexpected sort order: %s", stmt.Direction)
 	}
 
-	return plan.NewSort(column.Position, order, child), nil
+	return plan.NewSort(position, order, child), nil
+}
+
+// sortPosition returns the position of the column of ORDER BY in rows of the statement, or in rows of groups
+// if the statement is grouped.
+func sortPosition(scope *expr.Scope, g *grouping, stmt *ast.OrderByStatement) (uint8, error) {
+	if scope == nil {
+		return 0, errors.New("table not specified")
+	}
+
+	column, err := scope.Column(stmt.Table, stmt.Column)
+	if err != nil {
+		return 0, err
+	}
+
+	if g == nil {
+		return column.Position, nil
+	}
+
+	node, err := g.rewrite(expr.Column{Table: stmt.Table, Name: column.Name, Position: column.Position})
+	if err != nil {
+		return 0, err
+	}
+
+	return node.(expr.Column).Position, nil
+}
+
+// planDistinctOn builds the dedupe of rows by the expressions of DISTINCT ON, the first row of each set of rows
+// in the order of ORDER BY is kept. It's built under the projection, so the expressions may refer to any column.
+// ORDER BY has to sort by one of the expressions, as in PostgreSQL.
+func (p *Planner) planDistinctOn(
+	database string,
+	scope *expr.Scope,
+	g *grouping,
+	stmt *ast.DistinctStatement,
+	orderBy *ast.OrderByStatement,
+	child plan.Node,
+) (plan.Node, error) {
+	if stmt == nil || len(stmt.On) == 0 {
+		return child, nil
+	}
+
+	on := make([]expr.Node, 0, len(stmt.On))
+
+	for _, e := range stmt.On {
+		var (
+			node expr.Node
+			err  error
+		)
+
+		// Expressions of a grouped statement refer to rows of groups.
+		if g == nil {
+			node, err = expr.NewInScope(e, scope, p.sequences(database))
+		} else if node, err = expr.NewWithAggregates(e, scope, p.sequences(database)); err == nil {
+			node, err = g.rewrite(node)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		on = append(on, node)
+	}
+
+	if orderBy != nil {
+		position, err := sortPosition(scope, g, orderBy)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := func(node expr.Node) bool {
+			column, ok := node.(expr.Column)
+			return ok && column.Position == position
+		}
+
+		if !slices.ContainsFunc(on, matches) {
+			return nil, errors.New("SELECT DISTINCT ON expressions must match initial ORDER BY expressions")
+		}
+	}
+
+	return plan.NewDistinct(on, child), nil
+}
+
+// checkDistinctOrder checks that the column of ORDER BY of the statement with plain DISTINCT is selected:
+// rows that differ only in it become one row, so there would be no value to sort them by.
+func checkDistinctOrder(scope *expr.Scope, stmt *ast.SelectStatement) error {
+	if stmt.Distinct == nil || len(stmt.Distinct.On) > 0 || stmt.OrderBy == nil || scope == nil {
+		return nil
+	}
+
+	column, err := scope.Column(stmt.OrderBy.Table, stmt.OrderBy.Column)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range stmt.Result {
+		switch e := result.Expr.(type) {
+		case *ast.AsteriskExpr:
+			return nil
+		case *ast.IdentExpr:
+			if selected, err := scope.Column(e.Table, e.Name); err == nil && selected.Position == column.Position {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("for SELECT DISTINCT, ORDER BY expressions must appear in select list")
 }
 
 func (p *Planner) planOffset(stmt *ast.OffsetStatement, child plan.Node) (plan.Node, error) {
@@ -527,3 +1243,118 @@ func (p *Planner) getTable(databaseName, tableName string) (sql.Table, error) {
 
 	return table, nil
 }
+
+// databaseEngine creates databases with the storage engine chosen by USING.
+type databaseEngine struct {
+	catalog sql.EngineCatalog
+	engine  string
+}
+
+func (c databaseEngine) CreateDatabase(name string) (sql.Database, error) {
+	return c.catalog.CreateDatabaseUsing(name, c.engine)
+}
+
+// tableEngine creates tables with the storage engine chosen by USING.
+type tableEngine struct {
+	database sql.EngineDatabase
+	engine   string
+}
+
+func (d tableEngine) CreateTable(name string, scheme sql.Scheme) (sql.Table, error) {
+	return d.database.CreateTableUsing(name, scheme, d.engine)
+}
+
+// sequences returns the source of sequences of the database for sequence functions in expressions,
+// it plans subqueries of expressions too.
+func (p *Planner) sequences(database string) expr.Sequences {
+	return databaseSequences{planner: p, catalog: p.catalog, database: database}
+}
+
+// databaseSequences looks up the database only when an expression calls a sequence function.
+type databaseSequences struct {
+	planner  *Planner
+	catalog  sql.Catalog
+	database string
+}
+
+func (s databaseSequences) PlanSubquery(stmt *ast.SelectStatement, params *expr.Params) (expr.Query, error) {
+	return s.planner.planSelect(s.database, stmt, params)
+}
+
+func (s databaseSequences) GetSequence(name string) (sql.NamedSequence, error) {
+	if s.database == "" {
+		return nil, errors.New("database not specified")
+	}
+
+	db, err := s.catalog.GetDatabase(s.database)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.GetSequence(name)
+}
+
+type identitySequence struct {
+	name    string
+	options sql.SequenceOptions
+}
+
+// identityTable creates sequences of identity columns before the table, so a table never lacks them.
+type identityTable struct {
+	creator   plan.TableCreator
+	database  sql.Database
+	sequences []identitySequence
+}
+
+func (t identityTable) CreateTable(name string, scheme sql.Scheme) (sql.Table, error) {
+	created := make([]string, 0, len(t.sequences))
+
+	for _, sequence := range t.sequences {
+		if _, err := t.database.CreateSequence(sequence.name, sequence.options); err != nil {
+			return nil, errors.Join(err, t.drop(created))
+		}
+
+		created = append(created, sequence.name)
+	}
+
+	table, err := t.creator.CreateTable(name, scheme)
+	if err != nil {
+		return nil, errors.Join(err, t.drop(created))
+	}
+
+	return table, nil
+}
+
+func (t identityTable) drop(sequences []string) error {
+	var errs []error
+
+	for _, sequence := range sequences {
+		if err := t.database.DropSequence(sequence); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// identityDropper drops sequences of identity columns after the table.
+type identityDropper struct {
+	database  sql.Database
+	sequences []string
+}
+
+func (d identityDropper) DropTable(name string) error {
+	if err := d.database.DropTable(name); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, sequence := range d.sequences {
+		if err := d.database.DropSequence(sequence); err != nil {
+			errs = append(errs, fmt.Errorf("drop sequence %q: %w", sequence, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,6 +2,7 @@ package planner_test
 
 import (
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,8 @@ import (
 	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
 	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/planner"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/sqltest"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
 )
 
 func TestPlanner_CreateDatabase(t *testing.T) {
@@ -37,7 +40,7 @@ func TestPlanner_CreateDatabase(t *testing.T) {
 			Database: database,
 		}
 
-		planNode, err := planner.New(catalog).Plan("", stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -59,10 +62,73 @@ func TestPlanner_CreateDatabase(t *testing.T) {
 			Database: databaseName,
 		}
 
-		planNode, err := planner.New(catalog).Plan("", stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
+
+	t.Run("uses storage engine", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		database := "events"
+		errDBNotExist := errors.New("database not exist")
+
+		catalog := engineCatalog{
+			MockCatalog:       sql.NewMockCatalog(ctrl),
+			MockEngineCatalog: sql.NewMockEngineCatalog(ctrl),
+		}
+
+		catalog.MockCatalog.EXPECT().GetDatabase(database).Return(nil, errDBNotExist)
+		catalog.MockEngineCatalog.EXPECT().CreateDatabaseUsing(database, "lsm").Return(sql.NewMockDatabase(ctrl), nil)
+
+		stmt := &ast.CreateDatabaseStatement{
+			Database: database,
+			Engine:   "lsm",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
+		require.NoError(t, err)
+
+		_, err = planNode.RowIter()
+		require.NoError(t, err)
+	})
+
+	t.Run("returns error if storage engines are not supported", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		database := "events"
+		errDBNotExist := errors.New("database not exist")
+
+		catalog := sql.NewMockCatalog(ctrl)
+		catalog.EXPECT().GetDatabase(database).Return(nil, errDBNotExist)
+
+		stmt := &ast.CreateDatabaseStatement{
+			Database: database,
+			Engine:   "lsm",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+// engineCatalog is a catalog that offers several storage engines.
+type engineCatalog struct {
+	*sql.MockCatalog
+	*sql.MockEngineCatalog
+}
+
+// engineDatabase is a database that offers several storage engines.
+type engineDatabase struct {
+	*sql.MockDatabase
+	*sql.MockEngineDatabase
 }
 
 func TestPlanner_DropDatabase(t *testing.T) {
@@ -84,7 +150,7 @@ func TestPlanner_DropDatabase(t *testing.T) {
 			Database: database,
 		}
 
-		planNode, err := planner.New(catalog).Plan("", stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -105,7 +171,7 @@ func TestPlanner_DropDatabase(t *testing.T) {
 			Database: database,
 		}
 
-		planNode, err := planner.New(catalog).Plan("", stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -114,6 +180,87 @@ func TestPlanner_DropDatabase(t *testing.T) {
 func TestPlanner_CreateTable(t *testing.T) {
 	t.Parallel()
 
+	t.Run("uses storage engine", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "events"
+		databaseName := "playground"
+		errTableNotExist := errors.New("table not exist")
+
+		stmt := &ast.CreateTableStatement{
+			Table: tableName,
+			Columns: []ast.Column{
+				{
+					Name:       "id",
+					Type:       token.Integer,
+					PrimaryKey: true,
+				},
+			},
+			Engine: "lsm",
+		}
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := engineDatabase{
+			MockDatabase:       sql.NewMockDatabase(ctrl),
+			MockEngineDatabase: sql.NewMockEngineDatabase(ctrl),
+		}
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.MockDatabase.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
+		database.MockEngineDatabase.EXPECT().CreateTableUsing(tableName, scheme, "lsm").Return(sql.NewMockTable(ctrl), nil)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+
+		_, err = planNode.RowIter()
+		require.NoError(t, err)
+	})
+
+	t.Run("returns error if storage engines are not supported", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "events"
+		databaseName := "playground"
+		errTableNotExist := errors.New("table not exist")
+
+		stmt := &ast.CreateTableStatement{
+			Table: tableName,
+			Columns: []ast.Column{
+				{
+					Name:       "id",
+					Type:       token.Integer,
+					PrimaryKey: true,
+				},
+			},
+			Engine: "lsm",
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
 	t.Run("no error", func(t *testing.T) {
 		t.Parallel()
 
@@ -207,7 +354,7 @@ func TestPlanner_CreateTable(t *testing.T) {
 
 		expected := plan.NewCreateTable(database, tableName, scheme)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -272,7 +419,7 @@ func TestPlanner_CreateTable(t *testing.T) {
 
 		expected := plan.NewCreateTable(database, tableName, scheme)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -313,7 +460,7 @@ func TestPlanner_CreateTable(t *testing.T) {
 		catalog := sql.NewMockCatalog(ctrl)
 		catalog.EXPECT().GetDatabase(databaseName).Return(nil, expectedErr)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -356,18 +503,18 @@ func TestPlanner_CreateTable(t *testing.T) {
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(nil, nil)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
 
-	t.Run("return error if no primary key specified", func(t *testing.T) {
+	t.Run("adds hidden row id if no primary key specified", func(t *testing.T) {
 		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		tableName := "users"
+		tableName := "logs"
 		databaseName := "playground"
 		errTableNotExist := errors.New("table not exist")
 
@@ -375,32 +522,93 @@ func TestPlanner_CreateTable(t *testing.T) {
 			Table: tableName,
 			Columns: []ast.Column{
 				{
-					Name:       "id",
-					Type:       token.Integer,
+					Name:       "level",
+					Type:       token.Text,
 					Default:    nil,
 					Nullable:   false,
 					PrimaryKey: false,
 				},
 				{
-					Name: "name",
-					Type: token.Text,
-					Default: &ast.ScalarExpr{
-						Type:    token.Null,
-						Literal: "null",
-					},
+					Name:       "message",
+					Type:       token.Text,
+					Default:    nil,
 					Nullable:   true,
 					PrimaryKey: false,
 				},
 			},
 		}
 
+		scheme := sql.Scheme{
+			"level": {
+				Position:   0,
+				Name:       "level",
+				DataType:   sql.Text,
+				PrimaryKey: false,
+				Nullable:   false,
+				Default:    nil,
+			},
+			"message": {
+				Position:   1,
+				Name:       "message",
+				DataType:   sql.Text,
+				PrimaryKey: false,
+				Nullable:   true,
+				Default:    nil,
+			},
+			sql.RowID: {
+				Position:   2,
+				Name:       sql.RowID,
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+				Nullable:   false,
+				Default:    nil,
+				Hidden:     true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
+
+		expected := plan.NewCreateTable(database, tableName, scheme)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("return error if column of table without primary key is named as row id", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "logs"
+		databaseName := "playground"
+		errTableNotExist := errors.New("table not exist")
+
+		stmt := &ast.CreateTableStatement{
+			Table: tableName,
+			Columns: []ast.Column{
+				{
+					Name:       sql.RowID,
+					Type:       token.Integer,
+					Default:    nil,
+					Nullable:   false,
+					PrimaryKey: false,
+				},
+			},
+		}
+
 		catalog := sql.NewMockCatalog(ctrl)
 		database := sql.NewMockDatabase(ctrl)
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
@@ -444,11 +652,155 @@ func TestPlanner_CreateTable(t *testing.T) {
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
 
+	t.Run("composite primary key", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "accounts"
+		databaseName := "playground"
+		errTableNotExist := errors.New("table not exist")
+
+		stmt := &ast.CreateTableStatement{
+			Table: tableName,
+			Columns: []ast.Column{
+				{
+					Name:     "id",
+					Type:     token.Integer,
+					Nullable: false,
+				},
+				{
+					Name:     "tenant_id",
+					Type:     token.Text,
+					Nullable: false,
+				},
+				{
+					Name:     "name",
+					Type:     token.Text,
+					Nullable: true,
+				},
+			},
+			PrimaryKey: []string{"tenant_id", "id"},
+		}
+
+		scheme := sql.Scheme{
+			"id": {
+				Position:    0,
+				Name:        "id",
+				DataType:    sql.Integer,
+				PrimaryKey:  true,
+				Nullable:    false,
+				Default:     nil,
+				KeyPosition: 1,
+			},
+			"tenant_id": {
+				Position:    1,
+				Name:        "tenant_id",
+				DataType:    sql.Text,
+				PrimaryKey:  true,
+				Nullable:    false,
+				Default:     nil,
+				KeyPosition: 0,
+			},
+			"name": {
+				Position:   2,
+				Name:       "name",
+				DataType:   sql.Text,
+				PrimaryKey: false,
+				Nullable:   true,
+				Default:    nil,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
+
+		expected := plan.NewCreateTable(database, tableName, scheme)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if primary key constraint is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name       string
+			columns    []ast.Column
+			primaryKey []string
+		}{
+			{
+				name: "column is not found",
+				columns: []ast.Column{
+					{Name: "id", Type: token.Integer},
+				},
+				primaryKey: []string{"id", "tenant_id"},
+			},
+			{
+				name: "column is listed twice",
+				columns: []ast.Column{
+					{Name: "id", Type: token.Integer},
+				},
+				primaryKey: []string{"id", "id"},
+			},
+			{
+				name: "column is nullable",
+				columns: []ast.Column{
+					{Name: "id", Type: token.Integer},
+					{Name: "code", Type: token.Text, Nullable: true},
+				},
+				primaryKey: []string{"id", "code"},
+			},
+			{
+				name: "column primary key is specified as well",
+				columns: []ast.Column{
+					{Name: "id", Type: token.Integer, PrimaryKey: true},
+					{Name: "code", Type: token.Text},
+				},
+				primaryKey: []string{"code"},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				tableName := "accounts"
+				databaseName := "playground"
+				errTableNotExist := errors.New("table not exist")
+
+				stmt := &ast.CreateTableStatement{
+					Table:      tableName,
+					Columns:    test.columns,
+					PrimaryKey: test.primaryKey,
+				}
+
+				catalog := sql.NewMockCatalog(ctrl)
+				database := sql.NewMockDatabase(ctrl)
+
+				catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+				database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
+
+				planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+				require.Error(t, err)
+				assert.Nil(t, planNode)
+			})
+		}
+	})
+
 	t.Run("returns error if column default value have different type", func(t *testing.T) {
 		t.Parallel()
 
@@ -488,7 +840,7 @@ func TestPlanner_CreateTable(t *testing.T) {
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
@@ -532,7 +884,7 @@ func TestPlanner_CreateTable(t *testing.T) {
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(nil, errTableNotExist)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
@@ -552,38 +904,79 @@ func TestPlanner_DropTable(t *testing.T) {
 
 		catalog := sql.NewMockCatalog(ctrl)
 		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
-		database.EXPECT().GetTable(tableName).Return(nil, nil)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Scheme().Return(sql.Scheme{
+			"id": {Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		})
 
 		expected := plan.NewDropTable(database, tableName)
 		stmt := &ast.DropTableStatement{
 			Table: tableName,
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
 
-	t.Run("returns error if can't get database", func(t *testing.T) {
+	t.Run("drops sequences of identity columns", func(t *testing.T) {
 		t.Parallel()
 
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		tableName := "users"
+		tableName := "orders"
 		databaseName := "playground"
-		expectedErr := errors.New("something went wrong")
 
 		catalog := sql.NewMockCatalog(ctrl)
-		catalog.EXPECT().GetDatabase(databaseName).Return(nil, expectedErr)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Scheme().Return(sql.Scheme{
+			"id":     {Name: "id", DataType: sql.Integer, PrimaryKey: true},
+			"number": {Position: 1, Name: "number", DataType: sql.Integer, Sequence: "orders_number_seq", Identity: true},
+			"ticket": {Position: 2, Name: "ticket", DataType: sql.Integer, Sequence: "tickets_seq"},
+		})
 
 		stmt := &ast.DropTableStatement{
 			Table: tableName,
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+
+		gomock.InOrder(
+			database.EXPECT().DropTable(tableName).Return(nil),
+			database.EXPECT().DropSequence("orders_number_seq").Return(nil),
+		)
+
+		_, err = planNode.RowIter()
+		require.NoError(t, err)
+	})
+
+	t.Run("returns error if can't get database", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "users"
+		databaseName := "playground"
+		expectedErr := errors.New("something went wrong")
+
+		catalog := sql.NewMockCatalog(ctrl)
+		catalog.EXPECT().GetDatabase(databaseName).Return(nil, expectedErr)
+
+		stmt := &ast.DropTableStatement{
+			Table: tableName,
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -608,7 +1001,7 @@ func TestPlanner_DropTable(t *testing.T) {
 			Table: tableName,
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -657,9 +1050,13 @@ func TestPlanner_Select(t *testing.T) {
 		database := sql.NewMockDatabase(ctrl)
 		table := sql.NewMockTable(ctrl)
 
-		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(2)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().Statistics(tableName).Return(sql.Statistics{}, false)
+		table.EXPECT().Name().Return(tableName)
 		table.EXPECT().Scheme().Return(scheme).Times(3)
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]})
+		table.EXPECT().Indexes().Return(nil)
 
 		stmt := &ast.SelectStatement{
 			Result: []ast.ResultStatement{
@@ -730,27 +1127,27 @@ func TestPlanner_Select(t *testing.T) {
 		cond, err := expr.New(stmt.Where.Expr, scheme)
 		require.NoError(t, err)
 
-		expected := plan.NewLimit(
-			10,
-			plan.NewOffset(
-				2,
-				plan.NewProject(
-					projections,
-					plan.NewSort(
-						2,
-						plan.Descending,
-						plan.NewFilter(
-							cond,
-							plan.NewScan(
-								table,
-							),
+		expected := plan.NewOffset(
+			2,
+			plan.NewProject(
+				projections,
+				plan.NewTopN(
+					12,
+					2,
+					plan.Descending,
+					plan.NewFilter(
+						cond,
+						plan.NewRangeScan(
+							table,
+							&sql.Bound{Value: datatype.NewInteger(10), Inclusive: false},
+							nil,
 						),
 					),
 				),
 			),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -832,7 +1229,69 @@ func TestPlanner_Select(t *testing.T) {
 			),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("select * skips hidden columns", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "logs"
+		databaseName := "playground"
+
+		scheme := sql.Scheme{
+			"message": sql.Column{
+				Position:   0,
+				Name:       "message",
+				DataType:   sql.Text,
+				PrimaryKey: false,
+				Nullable:   false,
+				Default:    nil,
+			},
+			sql.RowID: sql.Column{
+				Position:   1,
+				Name:       sql.RowID,
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+				Nullable:   false,
+				Default:    nil,
+				Hidden:     true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.SelectStatement{
+			Result: []ast.ResultStatement{
+				{
+					Expr: &ast.AsteriskExpr{},
+				},
+			},
+			From: &ast.FromStatement{
+				Table: tableName,
+			},
+		}
+
+		projections := []plan.Projection{
+			{
+				Alias: "",
+				Expr:  expr.Column{Name: "message", Position: 0},
+			},
+		}
+
+		expected := plan.NewProject(projections, plan.NewScan(table))
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -923,7 +1382,7 @@ func TestPlanner_Select(t *testing.T) {
 			),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -963,7 +1422,7 @@ func TestPlanner_Select(t *testing.T) {
 			plan.NewRows(sql.Row{}),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -986,7 +1445,7 @@ func TestPlanner_Select(t *testing.T) {
 				},
 			}
 
-			planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 			require.Error(t, err)
 			assert.Nil(t, planNode)
 		})
@@ -1006,7 +1465,7 @@ func TestPlanner_Select(t *testing.T) {
 				},
 			}
 
-			planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 			require.Error(t, err)
 			assert.Nil(t, planNode)
 		})
@@ -1070,7 +1529,7 @@ func TestPlanner_Select(t *testing.T) {
 				},
 			}
 
-			planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 			require.Error(t, err)
 			assert.Nil(t, planNode)
 		})
@@ -1119,13 +1578,10 @@ func TestPlanner_Insert(t *testing.T) {
 		catalog := sql.NewMockCatalog(ctrl)
 		database := sql.NewMockDatabase(ctrl)
 		table := sql.NewMockTable(ctrl)
-		seq := sql.NewMockSequence(ctrl)
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
 		table.EXPECT().Scheme().Return(scheme)
-		table.EXPECT().Sequence().Return(seq)
-		seq.EXPECT().Next().Return(int64(1))
 
 		stmt := &ast.InsertStatement{
 			Table: tableName,
@@ -1145,19 +1601,236 @@ func TestPlanner_Insert(t *testing.T) {
 			},
 		}
 
-		key := int64(1)
-		row := sql.Row{
-			datatype.NewInteger(key),
-			datatype.NewText("Mad Max"),
-			datatype.NewFloat(200.2),
+		name, err := expr.NewString("Mad Max")
+		require.NoError(t, err)
+
+		salary, err := expr.NewFloat("200.2")
+		require.NoError(t, err)
+
+		expected := plan.NewInsert(table, sql.Row{datatype.NewNull(), datatype.NewNull(), datatype.NewNull()}, map[uint8]expr.Node{
+			1: name,
+			2: salary,
+		})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("composite primary key", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "accounts"
+		databaseName := "playground"
+
+		scheme := sql.Scheme{
+			"tenant_id": sql.Column{
+				Position:    0,
+				Name:        "tenant_id",
+				DataType:    sql.Text,
+				PrimaryKey:  true,
+				KeyPosition: 0,
+			},
+			"id": sql.Column{
+				Position:    1,
+				Name:        "id",
+				DataType:    sql.Integer,
+				PrimaryKey:  true,
+				KeyPosition: 1,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.InsertStatement{
+			Table:   tableName,
+			Columns: []string{"id", "tenant_id"},
+			Values: []ast.Expression{
+				&ast.ScalarExpr{Type: token.Integer, Literal: "7"},
+				&ast.ScalarExpr{Type: token.Text, Literal: "acme"},
+			},
+		}
+
+		id, err := expr.NewInteger("7")
+		require.NoError(t, err)
+
+		tenant, err := expr.NewString("acme")
+		require.NoError(t, err)
+
+		expected := plan.NewInsert(table, sql.Row{datatype.NewNull(), datatype.NewNull()}, map[uint8]expr.Node{
+			0: tenant,
+			1: id,
+		})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("fills columns from sequences", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "orders"
+		databaseName := "playground"
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+			"number": sql.Column{
+				Position: 1,
+				Name:     "number",
+				DataType: sql.Integer,
+				Sequence: "orders_number_seq",
+				Identity: true,
+			},
+			"code": sql.Column{
+				Position: 2,
+				Name:     "code",
+				DataType: sql.Integer,
+				Sequence: "codes",
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+		number := sql.NewMockNamedSequence(ctrl)
+		codes := sql.NewMockNamedSequence(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(3)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().GetSequence("orders_number_seq").Return(number, nil)
+		database.EXPECT().GetSequence("codes").Return(codes, nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.InsertStatement{
+			Table: tableName,
 		}
 
-		expected := plan.NewInsert(table, key, row)
+		expected := plan.NewInsert(table, sql.Row{datatype.NewNull(), datatype.NewNull(), datatype.NewNull()}, map[uint8]expr.Node{
+			1: &expr.Nextval{Sequence: number},
+			2: &expr.Nextval{Sequence: codes},
+		})
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
+
+	t.Run("doesn't take values from sequences if statement is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "orders"
+		databaseName := "playground"
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+		sequence := sql.NewMockNamedSequence(ctrl)
+
+		// Neither the table sequence nor the named one expect Next.
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(2)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().GetSequence("codes").Return(sequence, nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.InsertStatement{
+			Table:   tableName,
+			Columns: []string{"id", "code"},
+			Values: []ast.Expression{
+				&ast.CallExpr{
+					Name: expr.NextvalFunc,
+					Args: []ast.Expression{&ast.ScalarExpr{Type: token.Text, Literal: "codes"}},
+				},
+				&ast.ScalarExpr{Type: token.Integer, Literal: "1"},
+			},
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err, "column code not found")
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error if hidden column is set", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "logs"
+		databaseName := "playground"
+
+		scheme := sql.Scheme{
+			"message": sql.Column{
+				Position:   0,
+				Name:       "message",
+				DataType:   sql.Text,
+				PrimaryKey: false,
+				Nullable:   false,
+				Default:    nil,
+			},
+			sql.RowID: sql.Column{
+				Position:   1,
+				Name:       sql.RowID,
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+				Nullable:   false,
+				Default:    nil,
+				Hidden:     true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.InsertStatement{
+			Table:   tableName,
+			Columns: []string{sql.RowID},
+			Values: []ast.Expression{
+				&ast.ScalarExpr{
+					Type:    token.Integer,
+					Literal: "10",
+				},
+			},
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
 }
 
 func TestPlanner_Update(t *testing.T) {
@@ -1171,7 +1844,7 @@ func TestPlanner_Update(t *testing.T) {
 
 		tableName := "users"
 		databaseName := "playground"
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 
 		scheme := sql.Scheme{
 			"id": sql.Column{
@@ -1233,23 +1906,23 @@ func TestPlanner_Update(t *testing.T) {
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
-		table.EXPECT().Scheme().Return(scheme).Times(2)
-		table.EXPECT().PrimaryKey().Return(scheme["id"])
+		table.EXPECT().Scheme().Return(scheme).Times(3)
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).Times(2)
 
 		cond, err := expr.New(stmt.Where.Expr, scheme)
 		require.NoError(t, err)
 
 		expected := plan.NewUpdate(
 			table,
-			pkIndex,
+			primaryKey,
 			columns,
 			plan.NewFilter(
 				cond,
-				plan.NewScan(table),
+				plan.NewLookup(table, sql.IntegerKey(10)),
 			),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -1262,7 +1935,7 @@ func TestPlanner_Update(t *testing.T) {
 
 		tableName := "users"
 		databaseName := "playground"
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 
 		scheme := sql.Scheme{
 			"id": sql.Column{
@@ -1315,19 +1988,54 @@ func TestPlanner_Update(t *testing.T) {
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
 		table.EXPECT().Scheme().Return(scheme)
-		table.EXPECT().PrimaryKey().Return(scheme["id"])
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]})
 
 		expected := plan.NewUpdate(
 			table,
-			pkIndex,
+			primaryKey,
 			columns,
 			plan.NewScan(table),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
+
+	t.Run("primary key may be set", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		scheme := sql.Scheme{
+			"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+			"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+		}
+
+		stmt := &ast.UpdateStatement{
+			Table: "users",
+			Set: []ast.SetStatement{
+				{Column: "id", Value: &ast.ScalarExpr{Type: token.Integer, Literal: "7"}},
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable("users").Return(table, nil)
+		database.EXPECT().Statistics(gomock.Any()).Return(sql.Statistics{}, false).AnyTimes()
+		table.EXPECT().Name().Return("users").AnyTimes()
+		table.EXPECT().Scheme().Return(scheme).AnyTimes()
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+		table.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.NoError(t, err)
+		assert.IsType(t, &plan.Update{}, planNode)
+	})
 }
 
 func TestPlanner_Delete(t *testing.T) {
@@ -1341,7 +2049,7 @@ func TestPlanner_Delete(t *testing.T) {
 
 		databaseName := "playground"
 		tableName := "users"
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 
 		scheme := sql.Scheme{
 			"id": sql.Column{
@@ -1368,8 +2076,8 @@ func TestPlanner_Delete(t *testing.T) {
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
-		table.EXPECT().PrimaryKey().Return(scheme["id"])
-		table.EXPECT().Scheme().Return(scheme)
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).Times(2)
+		table.EXPECT().Scheme().Return(scheme).Times(2)
 
 		stmt := &ast.DeleteStatement{
 			Table: tableName,
@@ -1387,14 +2095,14 @@ func TestPlanner_Delete(t *testing.T) {
 
 		expected := plan.NewDelete(
 			table,
-			pkIndex,
+			primaryKey,
 			plan.NewFilter(
 				cond,
-				plan.NewScan(table),
+				plan.NewLookup(table, sql.IntegerKey(10)),
 			),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -1407,7 +2115,7 @@ func TestPlanner_Delete(t *testing.T) {
 
 		databaseName := "playground"
 		tableName := "users"
-		pkIndex := uint8(0)
+		primaryKey := []uint8{0}
 
 		scheme := sql.Scheme{
 			"id": sql.Column{
@@ -1434,7 +2142,7 @@ func TestPlanner_Delete(t *testing.T) {
 
 		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
 		database.EXPECT().GetTable(tableName).Return(table, nil)
-		table.EXPECT().PrimaryKey().Return(scheme["id"])
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]})
 
 		stmt := &ast.DeleteStatement{
 			Table: tableName,
@@ -1442,11 +2150,11 @@ func TestPlanner_Delete(t *testing.T) {
 
 		expected := plan.NewDelete(
 			table,
-			pkIndex,
+			primaryKey,
 			plan.NewScan(table),
 		)
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.NoError(t, err)
 		assert.Equal(t, expected, planNode)
 	})
@@ -1468,7 +2176,7 @@ func TestPlanner_Delete(t *testing.T) {
 			Table: tableName,
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -1493,7 +2201,7 @@ func TestPlanner_Delete(t *testing.T) {
 			Table: tableName,
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.ErrorIs(t, err, expectedErr)
 		assert.Nil(t, planNode)
 	})
@@ -1518,7 +2226,7 @@ func TestPlanner_Delete(t *testing.T) {
 			Where: &ast.WhereStatement{Expr: nil},
 		}
 
-		planNode, err := planner.New(catalog).Plan(databaseName, stmt)
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
 		require.Error(t, err)
 		assert.Nil(t, planNode)
 	})
@@ -1528,7 +2236,7 @@ func TestPlanner_Empty(t *testing.T) {
 	t.Parallel()
 
 	databaseName := "playground"
-	planNode, err := planner.New(nil).Plan(databaseName, nil)
+	planNode, err := planner.New(nil).Plan(transaction.NewSession(transaction.NewManager(nil)), databaseName, nil)
 	require.NoError(t, err)
 	assert.Equal(t, plan.NewRows(), planNode)
 }
@@ -1537,7 +2245,2349 @@ func TestPlanner_UnexpectedStatement(t *testing.T) {
 	t.Parallel()
 
 	databaseName := "playground"
-	planNode, err := planner.New(nil).Plan(databaseName, 1)
+	planNode, err := planner.New(nil).Plan(transaction.NewSession(transaction.NewManager(nil)), databaseName, 1)
 	require.Error(t, err)
 	assert.Nil(t, planNode)
 }
+
+func TestPlanner_CreateIndex(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(2)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Indexes().Return(nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.CreateIndexStatement{
+			Index:   "users_name",
+			Table:   tableName,
+			Columns: []string{"name"},
+			Unique:  true,
+		}
+
+		expected := plan.NewCreateIndex(table, "users_name", []string{"name"}, true)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if index already exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+		index := sql.NewMockIndex(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(2)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Indexes().Return([]sql.Index{index})
+		index.EXPECT().Name().Return("users_name")
+
+		stmt := &ast.CreateIndexStatement{
+			Index:   "users_name",
+			Table:   tableName,
+			Columns: []string{"name"},
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error if column not exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).Times(2)
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Indexes().Return(nil)
+		table.EXPECT().Scheme().Return(scheme)
+
+		stmt := &ast.CreateIndexStatement{
+			Index:   "users_email",
+			Table:   tableName,
+			Columns: []string{"email"},
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+func TestPlanner_DropIndex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+		index := sql.NewMockIndex(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Indexes().Return([]sql.Index{index})
+		index.EXPECT().Name().Return("users_name")
+
+		stmt := &ast.DropIndexStatement{
+			Index: "users_name",
+		}
+
+		expected := plan.NewDropIndex(table, "users_name")
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if index not exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Indexes().Return(nil)
+
+		stmt := &ast.DropIndexStatement{
+			Index: "users_name",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+func TestPlanner_CreateSequence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(nil, errors.New("sequence not found"))
+
+		stmt := &ast.CreateSequenceStatement{
+			Sequence: "orders_seq",
+			Options: ast.SequenceOptions{
+				Start: &ast.ScalarExpr{Type: token.Integer, Literal: "100"},
+				Increment: &ast.UnaryExpr{
+					Operator: token.Sub,
+					Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "10"},
+				},
+			},
+		}
+
+		expected := plan.NewCreateSequence(database, "orders_seq", sql.SequenceOptions{Start: 100, Increment: -10})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("descending sequence starts from -1", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("countdown").Return(nil, errors.New("sequence not found"))
+
+		stmt := &ast.CreateSequenceStatement{
+			Sequence: "countdown",
+			Options: ast.SequenceOptions{
+				Increment: &ast.UnaryExpr{
+					Operator: token.Sub,
+					Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "1"},
+				},
+			},
+		}
+
+		expected := plan.NewCreateSequence(database, "countdown", sql.SequenceOptions{Start: -1, Increment: -1})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if sequence already exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		sequence := sql.NewMockNamedSequence(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(sequence, nil)
+
+		stmt := &ast.CreateSequenceStatement{
+			Sequence: "orders_seq",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error if increment is zero", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(nil, errors.New("sequence not found"))
+
+		stmt := &ast.CreateSequenceStatement{
+			Sequence: "orders_seq",
+			Options: ast.SequenceOptions{
+				Increment: &ast.ScalarExpr{Type: token.Integer, Literal: "0"},
+			},
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+func TestPlanner_DropSequence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(sequence, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Scheme().Return(sql.Scheme{
+			"id": sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		})
+
+		stmt := &ast.DropSequenceStatement{
+			Sequence: "orders_seq",
+		}
+
+		expected := plan.NewDropSequence(database, "orders_seq")
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if sequence not exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(nil, errors.New("sequence not found"))
+
+		stmt := &ast.DropSequenceStatement{
+			Sequence: "orders_seq",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error if sequence is used by a column", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		sequence := sql.NewMockNamedSequence(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetSequence("orders_seq").Return(sequence, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{table})
+		table.EXPECT().Name().Return("orders")
+		table.EXPECT().Scheme().Return(sql.Scheme{
+			"number": sql.Column{Position: 1, Name: "number", DataType: sql.Integer, Sequence: "orders_seq"},
+		})
+
+		stmt := &ast.DropSequenceStatement{
+			Sequence: "orders_seq",
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+func TestPlanner_AccessPath(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+		"salary": sql.Column{
+			Position: 2,
+			Name:     "salary",
+			DataType: sql.Float,
+		},
+	}
+
+	compare := func(column string, operator token.Type, value ast.Expression) ast.Expression {
+		return &ast.BinaryExpr{
+			Left:     &ast.IdentExpr{Name: column},
+			Operator: operator,
+			Right:    value,
+		}
+	}
+
+	and := func(left, right ast.Expression) ast.Expression {
+		return &ast.BinaryExpr{
+			Left:     left,
+			Operator: token.And,
+			Right:    right,
+		}
+	}
+
+	integer := func(literal string) ast.Expression {
+		return &ast.ScalarExpr{Type: token.Integer, Literal: literal}
+	}
+
+	text := func(literal string) ast.Expression {
+		return &ast.ScalarExpr{Type: token.Text, Literal: literal}
+	}
+
+	tests := []struct {
+		name     string
+		where    ast.Expression
+		expected func(table sql.Table, index sql.Index) plan.Node
+	}{
+		{
+			name:  "primary key equality",
+			where: and(compare("id", token.Equal, integer("42")), compare("name", token.Equal, text("bob"))),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewLookup(table, sql.IntegerKey(42))
+			},
+		},
+		{
+			name: "primary key range",
+			where: and(
+				compare("id", token.GreaterThanOrEqual, integer("10")),
+				&ast.BinaryExpr{Left: integer("20"), Operator: token.GreaterThan, Right: &ast.IdentExpr{Name: "id"}},
+			),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewRangeScan(
+					table,
+					&sql.Bound{Value: datatype.NewInteger(10), Inclusive: true},
+					&sql.Bound{Value: datatype.NewInteger(20), Inclusive: false},
+				)
+			},
+		},
+		{
+			name: "tightest primary key bounds",
+			where: and(
+				and(compare("id", token.GreaterThan, integer("10")), compare("id", token.GreaterThanOrEqual, integer("10"))),
+				and(compare("id", token.LessThanOrEqual, integer("30")), compare("id", token.LessThan, integer("20"))),
+			),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewRangeScan(
+					table,
+					&sql.Bound{Value: datatype.NewInteger(10), Inclusive: false},
+					&sql.Bound{Value: datatype.NewInteger(20), Inclusive: false},
+				)
+			},
+		},
+		{
+			name:  "index equality is preferred over primary key range",
+			where: and(compare("id", token.GreaterThan, integer("10")), compare("name", token.Equal, text("bob"))),
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				return plan.NewIndexScan(table, index, sql.IndexRange{
+					Prefix: []sql.Value{datatype.NewText("bob")},
+				})
+			},
+		},
+		{
+			name:  "primary key range is preferred over index range",
+			where: and(compare("id", token.GreaterThan, integer("10")), compare("name", token.GreaterThan, text("a"))),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewRangeScan(table, &sql.Bound{Value: datatype.NewInteger(10), Inclusive: false}, nil)
+			},
+		},
+		{
+			name:  "equality on the leading index column",
+			where: compare("name", token.Equal, text("bob")),
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				return plan.NewIndexScan(table, index, sql.IndexRange{
+					Prefix: []sql.Value{datatype.NewText("bob")},
+				})
+			},
+		},
+		{
+			name: "index equality and range",
+			where: and(
+				&ast.BinaryExpr{Left: integer("100"), Operator: token.LessThan, Right: &ast.IdentExpr{Name: "salary"}},
+				compare("name", token.Equal, text("bob")),
+			),
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				return plan.NewIndexScan(table, index, sql.IndexRange{
+					Prefix: []sql.Value{datatype.NewText("bob")},
+					From:   &sql.Bound{Value: datatype.NewFloat(100), Inclusive: false},
+				})
+			},
+		},
+		{
+			name:  "range on the leading index column",
+			where: and(compare("name", token.GreaterThanOrEqual, text("a")), compare("name", token.LessThan, text("c"))),
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				return plan.NewIndexScan(table, index, sql.IndexRange{
+					From: &sql.Bound{Value: datatype.NewText("a"), Inclusive: true},
+					To:   &sql.Bound{Value: datatype.NewText("c"), Inclusive: false},
+				})
+			},
+		},
+		{
+			name:  "condition on a column that is not leading",
+			where: compare("salary", token.Equal, &ast.ScalarExpr{Type: token.Float, Literal: "10.5"}),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewScan(table)
+			},
+		},
+		{
+			name: "disjunction",
+			where: &ast.BinaryExpr{
+				Left:     compare("name", token.Equal, text("bob")),
+				Operator: token.Or,
+				Right:    compare("id", token.Equal, integer("1")),
+			},
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewScan(table)
+			},
+		},
+		{
+			name:  "comparison of columns",
+			where: compare("id", token.Equal, &ast.IdentExpr{Name: "salary"}),
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return plan.NewScan(table)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			databaseName := "playground"
+			tableName := "users"
+
+			catalog := sql.NewMockCatalog(ctrl)
+			database := sql.NewMockDatabase(ctrl)
+			table := sql.NewMockTable(ctrl)
+			index := sql.NewMockIndex(ctrl)
+
+			catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+			database.EXPECT().GetTable(tableName).Return(table, nil)
+			database.EXPECT().Statistics(tableName).Return(sql.Statistics{}, false).AnyTimes()
+			table.EXPECT().Name().Return(tableName).AnyTimes()
+			table.EXPECT().Scheme().Return(scheme).AnyTimes()
+			table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+			table.EXPECT().Indexes().Return([]sql.Index{index}).AnyTimes()
+			index.EXPECT().Name().Return("users_name_salary").AnyTimes()
+			index.EXPECT().Columns().Return([]string{"name", "salary"}).AnyTimes()
+			index.EXPECT().Unique().Return(false).AnyTimes()
+
+			stmt := &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+				},
+				From: &ast.FromStatement{
+					Table: tableName,
+				},
+				Where: &ast.WhereStatement{
+					Expr: test.where,
+				},
+			}
+
+			cond, err := expr.New(test.where, scheme)
+			require.NoError(t, err)
+
+			expected := plan.NewProject(
+				[]plan.Projection{
+					{Expr: expr.Column{Name: "id", Position: 0}},
+				},
+				plan.NewFilter(cond, test.expected(table, index)),
+			)
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+			require.NoError(t, err)
+			assert.Equal(t, expected, planNode)
+		})
+	}
+}
+
+func TestPlanner_CompositeKeyAccessPath(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"tenant_id": sql.Column{
+			Position:    0,
+			Name:        "tenant_id",
+			DataType:    sql.Text,
+			PrimaryKey:  true,
+			KeyPosition: 0,
+		},
+		"id": sql.Column{
+			Position:    1,
+			Name:        "id",
+			DataType:    sql.Integer,
+			PrimaryKey:  true,
+			KeyPosition: 1,
+		},
+	}
+
+	primaryKey := scheme.PrimaryKey()
+
+	compare := func(column string, operator token.Type, value ast.Expression) ast.Expression {
+		return &ast.BinaryExpr{
+			Left:     &ast.IdentExpr{Name: column},
+			Operator: operator,
+			Right:    value,
+		}
+	}
+
+	and := func(left, right ast.Expression) ast.Expression {
+		return &ast.BinaryExpr{
+			Left:     left,
+			Operator: token.And,
+			Right:    right,
+		}
+	}
+
+	integer := &ast.ScalarExpr{Type: token.Integer, Literal: "7"}
+	text := &ast.ScalarExpr{Type: token.Text, Literal: "acme"}
+
+	tests := []struct {
+		name     string
+		where    ast.Expression
+		expected func(table sql.Table) plan.Node
+	}{
+		{
+			name:  "equality on all key columns",
+			where: and(compare("id", token.Equal, integer), compare("tenant_id", token.Equal, text)),
+			expected: func(table sql.Table) plan.Node {
+				key, err := sql.NewKey(datatype.NewText("acme"), datatype.NewInteger(7))
+				require.NoError(t, err)
+
+				return plan.NewLookup(table, key)
+			},
+		},
+		{
+			name:  "equality on the leading key column",
+			where: compare("tenant_id", token.Equal, text),
+			expected: func(table sql.Table) plan.Node {
+				bound := &sql.Bound{Value: datatype.NewText("acme"), Inclusive: true}
+
+				return plan.NewRangeScan(table, bound, bound)
+			},
+		},
+		{
+			name:  "range on the leading key column",
+			where: compare("tenant_id", token.GreaterThan, text),
+			expected: func(table sql.Table) plan.Node {
+				return plan.NewRangeScan(table, &sql.Bound{Value: datatype.NewText("acme"), Inclusive: false}, nil)
+			},
+		},
+		{
+			name:  "equality on the second key column",
+			where: compare("id", token.Equal, integer),
+			expected: func(table sql.Table) plan.Node {
+				return plan.NewScan(table)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			databaseName := "playground"
+			tableName := "accounts"
+
+			catalog := sql.NewMockCatalog(ctrl)
+			database := sql.NewMockDatabase(ctrl)
+			table := sql.NewMockTable(ctrl)
+
+			catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+			database.EXPECT().GetTable(tableName).Return(table, nil)
+			database.EXPECT().Statistics(tableName).Return(sql.Statistics{}, false).AnyTimes()
+			table.EXPECT().Name().Return(tableName).AnyTimes()
+			table.EXPECT().Scheme().Return(scheme).AnyTimes()
+			table.EXPECT().PrimaryKey().Return(primaryKey).AnyTimes()
+			table.EXPECT().Indexes().Return(nil).AnyTimes()
+
+			stmt := &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
+				},
+				From: &ast.FromStatement{
+					Table: tableName,
+				},
+				Where: &ast.WhereStatement{
+					Expr: test.where,
+				},
+			}
+
+			cond, err := expr.New(test.where, scheme)
+			require.NoError(t, err)
+
+			expected := plan.NewProject(
+				[]plan.Projection{
+					{Expr: expr.Column{Name: "id", Position: 1}},
+				},
+				plan.NewFilter(cond, test.expected(table)),
+			)
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+			require.NoError(t, err)
+			assert.Equal(t, expected, planNode)
+		})
+	}
+}
+
+func TestPlanner_CostBasedAccess(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	project := func(child plan.Node) plan.Node {
+		return plan.NewProject([]plan.Projection{{Expr: expr.Column{Name: "id", Position: 0}}}, child)
+	}
+
+	nameIsBob := &ast.BinaryExpr{
+		Left:     &ast.IdentExpr{Name: "name"},
+		Operator: token.Equal,
+		Right:    &ast.ScalarExpr{Type: token.Text, Literal: "bob"},
+	}
+
+	cond, err := expr.New(nameIsBob, scheme)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		stmt     *ast.SelectStatement
+		stats    sql.Statistics
+		analyzed bool
+		expected func(table sql.Table, index sql.Index) plan.Node
+	}{
+		{
+			name: "selective equality uses the index",
+			stmt: &ast.SelectStatement{
+				Where: &ast.WhereStatement{Expr: nameIsBob},
+			},
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				rng := sql.IndexRange{Prefix: []sql.Value{datatype.NewText("bob")}}
+
+				return project(plan.NewFilter(cond, plan.NewIndexScan(table, index, rng)))
+			},
+		},
+		{
+			name: "equality matching half of the table reads the whole table",
+			stmt: &ast.SelectStatement{
+				Where: &ast.WhereStatement{Expr: nameIsBob},
+			},
+			stats: sql.Statistics{
+				Rows: 10000,
+				Columns: map[string]sql.ColumnStatistics{
+					"name": {Distinct: 2},
+				},
+			},
+			analyzed: true,
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return project(plan.NewFilter(cond, plan.NewScan(table)))
+			},
+		},
+		{
+			name: "order by the primary key reads rows in the key order",
+			stmt: &ast.SelectStatement{
+				OrderBy: &ast.OrderByStatement{Column: "id", Direction: token.Asc},
+			},
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return project(plan.NewRangeScan(table, nil, nil))
+			},
+		},
+		{
+			name: "descending order is sorted",
+			stmt: &ast.SelectStatement{
+				OrderBy: &ast.OrderByStatement{Column: "id", Direction: token.Desc},
+			},
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return project(plan.NewSort(0, plan.Descending, plan.NewScan(table)))
+			},
+		},
+		{
+			name: "order by an indexed column with a small limit reads the index",
+			stmt: &ast.SelectStatement{
+				OrderBy: &ast.OrderByStatement{Column: "name", Direction: token.Asc},
+				Limit:   &ast.LimitStatement{Value: &ast.ScalarExpr{Type: token.Integer, Literal: "5"}},
+			},
+			expected: func(table sql.Table, index sql.Index) plan.Node {
+				return project(plan.NewLimit(5, plan.NewIndexScan(table, index, sql.IndexRange{})))
+			},
+		},
+		{
+			name: "order by an indexed column without a limit is sorted",
+			stmt: &ast.SelectStatement{
+				OrderBy: &ast.OrderByStatement{Column: "name", Direction: token.Asc},
+			},
+			expected: func(table sql.Table, _ sql.Index) plan.Node {
+				return project(plan.NewSort(1, plan.Ascending, plan.NewScan(table)))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			databaseName := "playground"
+			tableName := "users"
+
+			catalog := sql.NewMockCatalog(ctrl)
+			database := sql.NewMockDatabase(ctrl)
+			table := sql.NewMockTable(ctrl)
+			index := sql.NewMockIndex(ctrl)
+
+			catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+			database.EXPECT().GetTable(tableName).Return(table, nil)
+			database.EXPECT().Statistics(tableName).Return(test.stats, test.analyzed).AnyTimes()
+			table.EXPECT().Name().Return(tableName).AnyTimes()
+			table.EXPECT().Scheme().Return(scheme).AnyTimes()
+			table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+			table.EXPECT().Indexes().Return([]sql.Index{index}).AnyTimes()
+			index.EXPECT().Name().Return("users_name").AnyTimes()
+			index.EXPECT().Columns().Return([]string{"name"}).AnyTimes()
+			index.EXPECT().Unique().Return(false).AnyTimes()
+
+			stmt := *test.stmt
+			stmt.Result = []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "id"}}}
+			stmt.From = &ast.FromStatement{Table: tableName}
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, &stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected(table, index), planNode)
+		})
+	}
+}
+
+func TestPlanner_Transaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("transaction control statements", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		catalog := sql.NewMockCatalog(ctrl)
+		session := transaction.NewSession(transaction.NewManager(catalog))
+		p := planner.New(catalog)
+
+		planNode, err := p.Plan(session, "", &ast.BeginStatement{})
+		require.NoError(t, err)
+		assert.Equal(t, plan.NewBegin(session), planNode)
+
+		planNode, err = p.Plan(session, "", &ast.CommitStatement{})
+		require.NoError(t, err)
+		assert.Equal(t, plan.NewCommit(session), planNode)
+
+		planNode, err = p.Plan(session, "", &ast.RollbackStatement{})
+		require.NoError(t, err)
+		assert.Equal(t, plan.NewRollback(session), planNode)
+	})
+
+	t.Run("statements run inside open transaction", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		tableName := "users"
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().Name().Return(databaseName).AnyTimes()
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		table.EXPECT().Name().Return(tableName).AnyTimes()
+		table.EXPECT().PrimaryKey().Return(nil).AnyTimes()
+		table.EXPECT().Scheme().Return(sql.Scheme{}).AnyTimes()
+
+		session := transaction.NewSession(transaction.NewManager(catalog))
+		require.NoError(t, session.Begin())
+
+		stmt := &ast.DropTableStatement{
+			Table: tableName,
+		}
+
+		planNode, err := planner.New(catalog).Plan(session, databaseName, stmt)
+		require.NoError(t, err)
+
+		iter, err := planNode.RowIter()
+		require.ErrorIs(t, err, transaction.ErrSchemaChange)
+		assert.Nil(t, iter)
+	})
+}
+
+func TestPlanner_ColumnScan(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+		"salary": sql.Column{
+			Position: 2,
+			Name:     "salary",
+			DataType: sql.Float,
+		},
+	}
+
+	where := &ast.BinaryExpr{
+		Left:     &ast.IdentExpr{Name: "salary"},
+		Operator: token.GreaterThan,
+		Right:    &ast.ScalarExpr{Type: token.Float, Literal: "1.5"},
+	}
+
+	tests := []struct {
+		name        string
+		result      []ast.ResultStatement
+		projections []plan.Projection
+		expected    func(table sql.Table) plan.Node
+	}{
+		{
+			name:   "reads referenced columns",
+			result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "name"}}},
+			projections: []plan.Projection{
+				{Expr: expr.Column{Name: "name", Position: 1}},
+			},
+			expected: func(table sql.Table) plan.Node {
+				return plan.NewColumnScan(table, []uint8{1, 2})
+			},
+		},
+		{
+			name:   "reads all columns",
+			result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+			projections: []plan.Projection{
+				{Expr: expr.Column{Name: "id", Position: 0}},
+				{Expr: expr.Column{Name: "name", Position: 1}},
+				{Expr: expr.Column{Name: "salary", Position: 2}},
+			},
+			expected: func(table sql.Table) plan.Node {
+				return plan.NewScan(table)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			catalog := sql.NewMockCatalog(ctrl)
+			database := sql.NewMockDatabase(ctrl)
+			table := sqltest.NewColumnTable(ctrl)
+
+			catalog.EXPECT().GetDatabase("playground").Return(database, nil)
+			database.EXPECT().GetTable("users").Return(table, nil)
+			table.MockTable.EXPECT().Scheme().Return(scheme).AnyTimes()
+			table.MockTable.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+			table.MockTable.EXPECT().Indexes().Return(nil).AnyTimes()
+
+			stmt := &ast.SelectStatement{
+				Result: test.result,
+				From:   &ast.FromStatement{Table: "users"},
+				Where:  &ast.WhereStatement{Expr: where},
+			}
+
+			cond, err := expr.New(where, scheme)
+			require.NoError(t, err)
+
+			expected := plan.NewProject(test.projections, plan.NewFilter(cond, test.expected(table)))
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+			require.NoError(t, err)
+			assert.Equal(t, expected, planNode)
+		})
+	}
+}
+
+func TestPlanner_Join(t *testing.T) {
+	t.Parallel()
+
+	usersScheme := sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+	}
+
+	postsScheme := sql.Scheme{
+		"id":      sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"user_id": sql.Column{Position: 1, Name: "user_id", DataType: sql.Integer},
+		"title":   sql.Column{Position: 2, Name: "title", DataType: sql.Text},
+	}
+
+	ident := func(table, name string) ast.Expression {
+		return &ast.IdentExpr{Table: table, Name: name}
+	}
+
+	compare := func(left ast.Expression, operator token.Type, right ast.Expression) ast.Expression {
+		return &ast.BinaryExpr{Left: left, Operator: operator, Right: right}
+	}
+
+	column := func(table, name string, position uint8) expr.Column {
+		return expr.Column{Table: table, Name: name, Position: position}
+	}
+
+	equal := func(left, right expr.Node) expr.Node {
+		return &expr.Binary{Operator: expr.Equal, Left: left, Right: right}
+	}
+
+	tests := []struct {
+		name     string
+		join     ast.JoinStatement
+		expected func(users, posts sql.Table) plan.Node
+	}{
+		{
+			name: "hash join on equal columns",
+			join: ast.JoinStatement{
+				Type:  token.Inner,
+				Table: "posts",
+				On:    compare(ident("users", "id"), token.Equal, ident("posts", "user_id")),
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				return plan.NewHashJoin(
+					plan.InnerJoin,
+					equal(column("users", "id", 0), column("posts", "user_id", 3)),
+					[]expr.Node{column("users", "id", 0)},
+					[]expr.Node{column("posts", "user_id", 1)},
+					plan.NewScan(users),
+					plan.NewScan(posts),
+				)
+			},
+		},
+		{
+			name: "merge join on primary keys",
+			join: ast.JoinStatement{
+				Type:  token.Left,
+				Table: "posts",
+				Alias: "p",
+				On:    compare(ident("p", "id"), token.Equal, ident("users", "id")),
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				return plan.NewMergeJoin(
+					plan.LeftJoin,
+					equal(column("p", "id", 2), column("users", "id", 0)),
+					0,
+					0,
+					plan.NewRangeScan(users, nil, nil),
+					plan.NewRangeScan(posts, nil, nil),
+				)
+			},
+		},
+		{
+			name: "nested loop join on other conditions",
+			join: ast.JoinStatement{
+				Type:  token.Full,
+				Table: "posts",
+				On:    compare(ident("users", "name"), token.LessThan, ident("posts", "title")),
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				cond := &expr.Binary{
+					Operator: expr.LessThan,
+					Left:     column("users", "name", 1),
+					Right:    column("posts", "title", 4),
+				}
+
+				return plan.NewNestedLoopJoin(plan.FullJoin, cond, plan.NewScan(users), plan.NewScan(posts))
+			},
+		},
+		{
+			name: "cross join",
+			join: ast.JoinStatement{
+				Type:  token.Cross,
+				Table: "posts",
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				return plan.NewNestedLoopJoin(plan.CrossJoin, nil, plan.NewScan(users), plan.NewScan(posts))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			catalog := sql.NewMockCatalog(ctrl)
+			database := sql.NewMockDatabase(ctrl)
+			users := sql.NewMockTable(ctrl)
+			posts := sql.NewMockTable(ctrl)
+
+			catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+			database.EXPECT().GetTable("users").Return(users, nil)
+			database.EXPECT().GetTable("posts").Return(posts, nil)
+			database.EXPECT().Statistics(gomock.Any()).Return(sql.Statistics{}, false).AnyTimes()
+			users.EXPECT().Name().Return("users").AnyTimes()
+			users.EXPECT().Scheme().Return(usersScheme).AnyTimes()
+			users.EXPECT().PrimaryKey().Return([]sql.Column{usersScheme["id"]}).AnyTimes()
+			users.EXPECT().Indexes().Return(nil).AnyTimes()
+			posts.EXPECT().Name().Return("posts").AnyTimes()
+			posts.EXPECT().Scheme().Return(postsScheme).AnyTimes()
+			posts.EXPECT().PrimaryKey().Return([]sql.Column{postsScheme["id"]}).AnyTimes()
+			posts.EXPECT().Indexes().Return(nil).AnyTimes()
+
+			stmt := &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: ident("users", "name")},
+				},
+				From: &ast.FromStatement{
+					Table: "users",
+					Joins: []ast.JoinStatement{test.join},
+				},
+			}
+
+			expected := plan.NewProject(
+				[]plan.Projection{
+					{Alias: "name", Expr: column("users", "name", 1)},
+				},
+				test.expected(users, posts),
+			)
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+			require.NoError(t, err)
+			assert.Equal(t, expected, planNode)
+		})
+	}
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			result ast.Expression
+			join   ast.JoinStatement
+		}{
+			{
+				name:   "on ambiguous column",
+				result: ident("", "id"),
+				join:   ast.JoinStatement{Type: token.Cross, Table: "posts"},
+			},
+			{
+				name:   "on column of unknown table",
+				result: ident("comments", "id"),
+				join:   ast.JoinStatement{Type: token.Cross, Table: "posts"},
+			},
+			{
+				name:   "on table specified twice",
+				result: ident("users", "name"),
+				join:   ast.JoinStatement{Type: token.Cross, Table: "posts", Alias: "users"},
+			},
+			{
+				name:   "on unknown column in join condition",
+				result: ident("users", "name"),
+				join: ast.JoinStatement{
+					Type:  token.Inner,
+					Table: "posts",
+					On:    compare(ident("users", "id"), token.Equal, ident("posts", "missing")),
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				catalog := sql.NewMockCatalog(ctrl)
+				database := sql.NewMockDatabase(ctrl)
+				users := sql.NewMockTable(ctrl)
+				posts := sql.NewMockTable(ctrl)
+
+				catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+				database.EXPECT().GetTable("users").Return(users, nil)
+				database.EXPECT().GetTable("posts").Return(posts, nil)
+				users.EXPECT().Scheme().Return(usersScheme).AnyTimes()
+				posts.EXPECT().Scheme().Return(postsScheme).AnyTimes()
+
+				stmt := &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: test.result}},
+					From: &ast.FromStatement{
+						Table: "users",
+						Joins: []ast.JoinStatement{test.join},
+					},
+				}
+
+				session := transaction.NewSession(transaction.NewManager(catalog))
+				planNode, err := planner.New(catalog).Plan(session, "playground", stmt)
+				require.Error(t, err)
+				assert.Nil(t, planNode)
+			})
+		}
+	})
+}
+
+func TestPlanner_JoinOrder(t *testing.T) {
+	t.Parallel()
+
+	usersScheme := sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+	}
+
+	postsScheme := sql.Scheme{
+		"id":      sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"user_id": sql.Column{Position: 1, Name: "user_id", DataType: sql.Integer},
+		"title":   sql.Column{Position: 2, Name: "title", DataType: sql.Text},
+	}
+
+	usersStats := sql.Statistics{
+		Rows:    10,
+		Columns: map[string]sql.ColumnStatistics{"id": {Distinct: 10}},
+	}
+
+	postsStats := sql.Statistics{
+		Rows:    10000,
+		Columns: map[string]sql.ColumnStatistics{"user_id": {Distinct: 10}},
+	}
+
+	ident := func(table, name string) ast.Expression {
+		return &ast.IdentExpr{Table: table, Name: name}
+	}
+
+	column := func(table, name string, position uint8) expr.Column {
+		return expr.Column{Table: table, Name: name, Position: position}
+	}
+
+	equal := func(left, right expr.Node) expr.Node {
+		return &expr.Binary{Operator: expr.Equal, Left: left, Right: right}
+	}
+
+	setup := func(t *testing.T, from, join string) (sql.Catalog, *ast.SelectStatement, sql.Table, sql.Table) {
+		ctrl := gomock.NewController(t)
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		users := sql.NewMockTable(ctrl)
+		posts := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable("users").Return(users, nil)
+		database.EXPECT().GetTable("posts").Return(posts, nil)
+		database.EXPECT().Statistics("users").Return(usersStats, true).AnyTimes()
+		database.EXPECT().Statistics("posts").Return(postsStats, true).AnyTimes()
+		users.EXPECT().Name().Return("users").AnyTimes()
+		users.EXPECT().Scheme().Return(usersScheme).AnyTimes()
+		users.EXPECT().PrimaryKey().Return([]sql.Column{usersScheme["id"]}).AnyTimes()
+		users.EXPECT().Indexes().Return(nil).AnyTimes()
+		posts.EXPECT().Name().Return("posts").AnyTimes()
+		posts.EXPECT().Scheme().Return(postsScheme).AnyTimes()
+		posts.EXPECT().PrimaryKey().Return([]sql.Column{postsScheme["id"]}).AnyTimes()
+		posts.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		stmt := &ast.SelectStatement{
+			Result: []ast.ResultStatement{{Expr: ident("users", "name")}},
+			From: &ast.FromStatement{
+				Table: from,
+				Joins: []ast.JoinStatement{{
+					Type:  token.Inner,
+					Table: join,
+					On: &ast.BinaryExpr{
+						Left:     ident("users", "id"),
+						Operator: token.Equal,
+						Right:    ident("posts", "user_id"),
+					},
+				}},
+			},
+		}
+
+		return catalog, stmt, users, posts
+	}
+
+	t.Run("smaller table is put in the hash table", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, stmt, users, posts := setup(t, "users", "posts")
+
+		// Posts are read first, so columns are put back in the order of the query.
+		expected := plan.NewProject(
+			[]plan.Projection{{Alias: "name", Expr: column("users", "name", 1)}},
+			plan.NewProject(
+				[]plan.Projection{
+					{Alias: "id", Expr: expr.Column{Name: "id", Position: 3}},
+					{Alias: "name", Expr: expr.Column{Name: "name", Position: 4}},
+					{Alias: "id", Expr: expr.Column{Name: "id", Position: 0}},
+					{Alias: "user_id", Expr: expr.Column{Name: "user_id", Position: 1}},
+					{Alias: "title", Expr: expr.Column{Name: "title", Position: 2}},
+				},
+				plan.NewHashJoin(
+					plan.InnerJoin,
+					equal(column("users", "id", 3), column("posts", "user_id", 1)),
+					[]expr.Node{column("posts", "user_id", 1)},
+					[]expr.Node{column("users", "id", 0)},
+					plan.NewScan(posts),
+					plan.NewScan(users),
+				),
+			),
+		)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("written order is kept if it's cheaper", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, stmt, users, posts := setup(t, "posts", "users")
+
+		expected := plan.NewProject(
+			[]plan.Projection{{Alias: "name", Expr: column("users", "name", 4)}},
+			plan.NewHashJoin(
+				plan.InnerJoin,
+				equal(column("users", "id", 3), column("posts", "user_id", 1)),
+				[]expr.Node{column("posts", "user_id", 1)},
+				[]expr.Node{column("users", "id", 0)},
+				plan.NewScan(posts),
+				plan.NewScan(users),
+			),
+		)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("single row is checked with every row", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, stmt, users, posts := setup(t, "posts", "users")
+		stmt.Where = &ast.WhereStatement{
+			Expr: &ast.BinaryExpr{
+				Left:     ident("users", "id"),
+				Operator: token.Equal,
+				Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "5"},
+			},
+		}
+
+		five, err := expr.NewInteger("5")
+		require.NoError(t, err)
+
+		// The hash table of a single row isn't worth building.
+		expected := plan.NewProject(
+			[]plan.Projection{{Alias: "name", Expr: column("users", "name", 4)}},
+			plan.NewNestedLoopJoin(
+				plan.InnerJoin,
+				equal(column("users", "id", 3), column("posts", "user_id", 1)),
+				plan.NewScan(posts),
+				plan.NewFilter(
+					equal(column("users", "id", 0), five),
+					plan.NewLookup(users, sql.IntegerKey(5)),
+				),
+			),
+		)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+}
+
+func TestPlanner_Subquery(t *testing.T) {
+	t.Parallel()
+
+	usersScheme := sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text},
+	}
+
+	postsScheme := sql.Scheme{
+		"id":      sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"user_id": sql.Column{Position: 1, Name: "user_id", DataType: sql.Integer},
+		"title":   sql.Column{Position: 2, Name: "title", DataType: sql.Text},
+	}
+
+	ident := func(table, name string) ast.Expression {
+		return &ast.IdentExpr{Table: table, Name: name}
+	}
+
+	column := func(table, name string, position uint8) expr.Column {
+		return expr.Column{Table: table, Name: name, Position: position}
+	}
+
+	// SELECT <result> FROM posts WHERE posts.user_id = users.id
+	posts := func(result ast.Expression) *ast.SelectStatement {
+		return &ast.SelectStatement{
+			Result: []ast.ResultStatement{{Expr: result}},
+			From:   &ast.FromStatement{Table: "posts"},
+			Where: &ast.WhereStatement{
+				Expr: &ast.BinaryExpr{Left: ident("posts", "user_id"), Operator: token.Equal, Right: ident("users", "id")},
+			},
+		}
+	}
+
+	mockTables := func(ctrl *gomock.Controller) (*sql.MockCatalog, *sql.MockTable, *sql.MockTable) {
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		users := sql.NewMockTable(ctrl)
+		posts := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable("users").Return(users, nil).AnyTimes()
+		database.EXPECT().GetTable("posts").Return(posts, nil).AnyTimes()
+		database.EXPECT().Statistics(gomock.Any()).Return(sql.Statistics{}, false).AnyTimes()
+		users.EXPECT().Name().Return("users").AnyTimes()
+		users.EXPECT().Scheme().Return(usersScheme).AnyTimes()
+		users.EXPECT().PrimaryKey().Return([]sql.Column{usersScheme["id"]}).AnyTimes()
+		users.EXPECT().Indexes().Return(nil).AnyTimes()
+		posts.EXPECT().Name().Return("posts").AnyTimes()
+		posts.EXPECT().Scheme().Return(postsScheme).AnyTimes()
+		posts.EXPECT().PrimaryKey().Return([]sql.Column{postsScheme["id"]}).AnyTimes()
+		posts.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		return catalog, users, posts
+	}
+
+	tests := []struct {
+		name     string
+		stmt     *ast.SelectStatement
+		expected func(users, posts sql.Table) plan.Node
+	}{
+		{
+			name: "exists is a semi join",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: ident("users", "name")}},
+				From:   &ast.FromStatement{Table: "users"},
+				Where:  &ast.WhereStatement{Expr: &ast.ExistsExpr{Select: posts(ident("", "title"))}},
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				return plan.NewProject(
+					[]plan.Projection{{Alias: "name", Expr: column("users", "name", 1)}},
+					plan.NewHashJoin(
+						plan.SemiJoin,
+						&expr.Binary{
+							Operator: expr.Equal,
+							Left:     column("posts", "user_id", 3),
+							Right:    column("users", "id", 0),
+						},
+						[]expr.Node{column("users", "id", 0)},
+						[]expr.Node{column("posts", "user_id", 1)},
+						plan.NewScan(users),
+						plan.NewScan(posts),
+					),
+				)
+			},
+		},
+		{
+			name: "not exists is an anti join",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: ident("users", "name")}},
+				From:   &ast.FromStatement{Table: "users"},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ExistsExpr{Select: posts(ident("", "title")), Not: true},
+				},
+			},
+			expected: func(users, posts sql.Table) plan.Node {
+				return plan.NewProject(
+					[]plan.Projection{{Alias: "name", Expr: column("users", "name", 1)}},
+					plan.NewHashJoin(
+						plan.AntiJoin,
+						&expr.Binary{
+							Operator: expr.Equal,
+							Left:     column("posts", "user_id", 3),
+							Right:    column("users", "id", 0),
+						},
+						[]expr.Node{column("users", "id", 0)},
+						[]expr.Node{column("posts", "user_id", 1)},
+						plan.NewScan(users),
+						plan.NewScan(posts),
+					),
+				)
+			},
+		},
+		{
+			name: "derived table",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: ident("u", "name")}},
+				From: &ast.FromStatement{
+					Alias: "u",
+					Subquery: &ast.SelectStatement{
+						Result: []ast.ResultStatement{{Expr: ident("", "name")}},
+						From:   &ast.FromStatement{Table: "users"},
+					},
+				},
+			},
+			expected: func(users, _ sql.Table) plan.Node {
+				return plan.NewProject(
+					[]plan.Projection{{Alias: "name", Expr: column("u", "name", 0)}},
+					plan.NewProject(
+						[]plan.Projection{{Expr: column("", "name", 1)}},
+						plan.NewScan(users),
+					),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			catalog, users, posts := mockTables(ctrl)
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", test.stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected(users, posts), planNode)
+		})
+	}
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		twoColumns := posts(ident("", "id"))
+		twoColumns.Result = append(twoColumns.Result, ast.ResultStatement{Expr: ident("", "title")})
+
+		tests := []struct {
+			name string
+			stmt *ast.SelectStatement
+		}{
+			{
+				name: "on subquery of more than one column",
+				stmt: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.SubqueryExpr{Select: twoColumns}}},
+					From:   &ast.FromStatement{Table: "users"},
+				},
+			},
+			{
+				name: "on column of derived table specified twice",
+				stmt: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+					From: &ast.FromStatement{
+						Alias: "u",
+						Subquery: &ast.SelectStatement{
+							Result: []ast.ResultStatement{{Expr: ident("", "id")}, {Expr: ident("", "id")}},
+							From:   &ast.FromStatement{Table: "users"},
+						},
+					},
+				},
+			},
+			{
+				name: "on column of outer query in derived table",
+				stmt: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+					From: &ast.FromStatement{
+						Table: "users",
+						Joins: []ast.JoinStatement{{
+							Type:     token.Cross,
+							Alias:    "p",
+							Subquery: posts(ident("", "title")),
+						}},
+					},
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				catalog, _, _ := mockTables(ctrl)
+
+				session := transaction.NewSession(transaction.NewManager(catalog))
+				planNode, err := planner.New(catalog).Plan(session, "playground", test.stmt)
+				require.Error(t, err)
+				assert.Nil(t, planNode)
+			})
+		}
+	})
+}
+
+func TestPlanner_Aggregate(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id":      sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"user_id": sql.Column{Position: 1, Name: "user_id", DataType: sql.Integer},
+		"title":   sql.Column{Position: 2, Name: "title", DataType: sql.Text},
+	}
+
+	ident := func(name string) ast.Expression {
+		return &ast.IdentExpr{Name: name}
+	}
+
+	call := func(name string, arg ast.Expression) ast.Expression {
+		return &ast.CallExpr{Name: name, Args: []ast.Expression{arg}}
+	}
+
+	countAll := call("COUNT", &ast.AsteriskExpr{})
+	one := &ast.ScalarExpr{Type: token.Integer, Literal: "1"}
+
+	newPosts := func(ctrl *gomock.Controller) (sql.Catalog, sql.Table) {
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		posts := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase("playground").Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable("posts").Return(posts, nil)
+		database.EXPECT().Statistics(gomock.Any()).Return(sql.Statistics{}, false).AnyTimes()
+		posts.EXPECT().Name().Return("posts").AnyTimes()
+		posts.EXPECT().Scheme().Return(scheme).AnyTimes()
+		posts.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+		posts.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		return catalog, posts
+	}
+
+	tests := []struct {
+		name     string
+		stmt     *ast.SelectStatement
+		expected func(posts sql.Table) plan.Node
+	}{
+		{
+			name: "hash aggregate by column",
+			stmt: &ast.SelectStatement{
+				Result:  []ast.ResultStatement{{Expr: ident("user_id")}, {Expr: countAll}},
+				From:    &ast.FromStatement{Table: "posts"},
+				GroupBy: &ast.GroupByStatement{Exprs: []ast.Expression{ident("user_id")}},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				count := &expr.Aggregate{Func: expr.CountFunc}
+
+				return plan.NewProject(
+					[]plan.Projection{
+						{Expr: expr.Column{Name: "user_id", Position: 0}},
+						{Expr: expr.Column{Name: "count(*)", Position: 1}},
+					},
+					plan.NewHashAggregate(
+						[]expr.Node{expr.Column{Name: "user_id", Position: 1}},
+						[]*expr.Aggregate{count},
+						plan.NewScan(posts),
+					),
+				)
+			},
+		},
+		{
+			name: "sort aggregate by primary key",
+			stmt: &ast.SelectStatement{
+				Result:  []ast.ResultStatement{{Expr: ident("id")}, {Expr: call("max", ident("title"))}},
+				From:    &ast.FromStatement{Table: "posts"},
+				GroupBy: &ast.GroupByStatement{Exprs: []ast.Expression{ident("id")}},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				maxTitle := &expr.Aggregate{Func: expr.MaxFunc, Arg: expr.Column{Name: "title", Position: 2}}
+
+				return plan.NewProject(
+					[]plan.Projection{
+						{Expr: expr.Column{Name: "id", Position: 0}},
+						{Expr: expr.Column{Name: "max(title)", Position: 1}},
+					},
+					plan.NewSortAggregate(
+						[]expr.Node{expr.Column{Name: "id", Position: 0}},
+						[]*expr.Aggregate{maxTitle},
+						plan.NewRangeScan(posts, nil, nil),
+					),
+				)
+			},
+		},
+		{
+			name: "distinct rows",
+			stmt: &ast.SelectStatement{
+				Distinct: &ast.DistinctStatement{},
+				Result:   []ast.ResultStatement{{Expr: ident("user_id")}},
+				From:     &ast.FromStatement{Table: "posts"},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				return plan.NewDistinct(nil, plan.NewProject(
+					[]plan.Projection{{Expr: expr.Column{Name: "user_id", Position: 1}}},
+					plan.NewScan(posts),
+				))
+			},
+		},
+		{
+			name: "distinct on expression under projection",
+			stmt: &ast.SelectStatement{
+				Distinct: &ast.DistinctStatement{On: []ast.Expression{ident("user_id")}},
+				Result:   []ast.ResultStatement{{Expr: ident("title")}},
+				From:     &ast.FromStatement{Table: "posts"},
+				OrderBy:  &ast.OrderByStatement{Column: "user_id", Direction: token.Desc},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				return plan.NewProject(
+					[]plan.Projection{{Expr: expr.Column{Name: "title", Position: 2}}},
+					plan.NewDistinct(
+						[]expr.Node{expr.Column{Name: "user_id", Position: 1}},
+						plan.NewSort(1, plan.Descending, plan.NewScan(posts)),
+					),
+				)
+			},
+		},
+		{
+			name: "distinct on group expression",
+			stmt: &ast.SelectStatement{
+				Distinct: &ast.DistinctStatement{On: []ast.Expression{call("max", ident("title"))}},
+				Result:   []ast.ResultStatement{{Expr: ident("user_id")}},
+				From:     &ast.FromStatement{Table: "posts"},
+				GroupBy:  &ast.GroupByStatement{Exprs: []ast.Expression{ident("user_id")}},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				maxTitle := &expr.Aggregate{Func: expr.MaxFunc, Arg: expr.Column{Name: "title", Position: 2}}
+
+				return plan.NewProject(
+					[]plan.Projection{{Expr: expr.Column{Name: "user_id", Position: 0}}},
+					plan.NewDistinct(
+						[]expr.Node{expr.Column{Name: "max(title)", Position: 1}},
+						plan.NewHashAggregate(
+							[]expr.Node{expr.Column{Name: "user_id", Position: 1}},
+							[]*expr.Aggregate{maxTitle},
+							plan.NewScan(posts),
+						),
+					),
+				)
+			},
+		},
+		{
+			name: "aggregate of all rows with having",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: countAll, Alias: "total"}},
+				From:   &ast.FromStatement{Table: "posts"},
+				Having: &ast.HavingStatement{
+					Expr: &ast.BinaryExpr{Left: countAll, Operator: token.GreaterThan, Right: one},
+				},
+			},
+			expected: func(posts sql.Table) plan.Node {
+				count := &expr.Aggregate{Func: expr.CountFunc}
+				column := expr.Column{Name: "count(*)", Position: 0}
+				value, err := expr.NewInteger("1")
+				require.NoError(t, err)
+
+				return plan.NewProject(
+					[]plan.Projection{{Alias: "total", Expr: column}},
+					plan.NewFilter(
+						&expr.Binary{Operator: expr.GreaterThan, Left: column, Right: value},
+						plan.NewHashAggregate(nil, []*expr.Aggregate{count}, plan.NewScan(posts)),
+					),
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			catalog, posts := newPosts(ctrl)
+
+			planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", test.stmt)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected(posts), planNode)
+		})
+	}
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name string
+			stmt *ast.SelectStatement
+		}{
+			{
+				name: "on column not in group by",
+				stmt: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: ident("title")}, {Expr: countAll}},
+					From:   &ast.FromStatement{Table: "posts"},
+				},
+			},
+			{
+				name: "on sort by column not in group by",
+				stmt: &ast.SelectStatement{
+					Result:  []ast.ResultStatement{{Expr: countAll}},
+					From:    &ast.FromStatement{Table: "posts"},
+					GroupBy: &ast.GroupByStatement{Exprs: []ast.Expression{ident("user_id")}},
+					OrderBy: &ast.OrderByStatement{Column: "title", Direction: token.Asc},
+				},
+			},
+			{
+				name: "on aggregate in where",
+				stmt: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: ident("id")}},
+					From:   &ast.FromStatement{Table: "posts"},
+					Where: &ast.WhereStatement{
+						Expr: &ast.BinaryExpr{Left: countAll, Operator: token.GreaterThan, Right: one},
+					},
+				},
+			},
+			{
+				name: "on aggregate in group by",
+				stmt: &ast.SelectStatement{
+					Result:  []ast.ResultStatement{{Expr: countAll}},
+					From:    &ast.FromStatement{Table: "posts"},
+					GroupBy: &ast.GroupByStatement{Exprs: []ast.Expression{countAll}},
+				},
+			},
+			{
+				name: "on aggregate in distinct on of ungrouped column",
+				stmt: &ast.SelectStatement{
+					Distinct: &ast.DistinctStatement{On: []ast.Expression{countAll}},
+					Result:   []ast.ResultStatement{{Expr: ident("id")}},
+					From:     &ast.FromStatement{Table: "posts"},
+				},
+			},
+			{
+				name: "on distinct on column not in group by",
+				stmt: &ast.SelectStatement{
+					Distinct: &ast.DistinctStatement{On: []ast.Expression{ident("title")}},
+					Result:   []ast.ResultStatement{{Expr: countAll}},
+					From:     &ast.FromStatement{Table: "posts"},
+					GroupBy:  &ast.GroupByStatement{Exprs: []ast.Expression{ident("user_id")}},
+				},
+			},
+			{
+				name: "on asterisk with group by",
+				stmt: &ast.SelectStatement{
+					Result:  []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+					From:    &ast.FromStatement{Table: "posts"},
+					GroupBy: &ast.GroupByStatement{Exprs: []ast.Expression{ident("id")}},
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				catalog, _ := newPosts(ctrl)
+
+				session := transaction.NewSession(transaction.NewManager(catalog))
+				planNode, err := planner.New(catalog).Plan(session, "playground", test.stmt)
+				require.Error(t, err)
+				assert.Nil(t, planNode)
+			})
+		}
+	})
+
+	t.Run("returns error if distinct rows are sorted by other expressions", func(t *testing.T) {
+		t.Parallel()
+
+		byTitle := &ast.OrderByStatement{Column: "title", Direction: token.Asc}
+
+		tests := []struct {
+			name string
+			stmt *ast.SelectStatement
+			err  string
+		}{
+			{
+				name: "distinct sorted by column not selected",
+				stmt: &ast.SelectStatement{
+					Distinct: &ast.DistinctStatement{},
+					Result:   []ast.ResultStatement{{Expr: ident("user_id")}},
+					From:     &ast.FromStatement{Table: "posts"},
+					OrderBy:  byTitle,
+				},
+				err: "for SELECT DISTINCT, ORDER BY expressions must appear in select list",
+			},
+			{
+				name: "distinct on sorted by other column",
+				stmt: &ast.SelectStatement{
+					Distinct: &ast.DistinctStatement{On: []ast.Expression{ident("user_id")}},
+					Result:   []ast.ResultStatement{{Expr: ident("user_id")}, {Expr: ident("title")}},
+					From:     &ast.FromStatement{Table: "posts"},
+					OrderBy:  byTitle,
+				},
+				err: "SELECT DISTINCT ON expressions must match initial ORDER BY expressions",
+			},
+			{
+				name: "distinct on expression sorted by its column",
+				stmt: &ast.SelectStatement{
+					Distinct: &ast.DistinctStatement{On: []ast.Expression{
+						&ast.BinaryExpr{Left: ident("user_id"), Operator: token.Add, Right: one},
+					}},
+					Result:  []ast.ResultStatement{{Expr: ident("user_id")}},
+					From:    &ast.FromStatement{Table: "posts"},
+					OrderBy: &ast.OrderByStatement{Column: "user_id", Direction: token.Asc},
+				},
+				err: "SELECT DISTINCT ON expressions must match initial ORDER BY expressions",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				catalog, _ := newPosts(ctrl)
+
+				session := transaction.NewSession(transaction.NewManager(catalog))
+				planNode, err := planner.New(catalog).Plan(session, "playground", test.stmt)
+				require.ErrorContains(t, err, test.err)
+				assert.Nil(t, planNode)
+			})
+		}
+	})
+}
+
+func TestPlanner_Analyze(t *testing.T) {
+	t.Parallel()
+
+	t.Run("analyzes the table", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable("users").Return(table, nil)
+
+		stmt := &ast.AnalyzeStatement{Table: "users"}
+		expected := plan.NewAnalyze(database, []sql.Table{table})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("analyzes all tables in the order of names", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		users := sql.NewMockTable(ctrl)
+		orders := sql.NewMockTable(ctrl)
+
+		users.EXPECT().Name().Return("users").AnyTimes()
+		orders.EXPECT().Name().Return("orders").AnyTimes()
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().ListTables().Return([]sql.Table{users, orders})
+
+		stmt := &ast.AnalyzeStatement{}
+		expected := plan.NewAnalyze(database, []sql.Table{orders, users})
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, expected, planNode)
+	})
+
+	t.Run("returns error if table not exist", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil)
+		database.EXPECT().GetTable("users").Return(nil, errors.New("table not found"))
+
+		stmt := &ast.AnalyzeStatement{Table: "users"}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error if database not specified", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		catalog := sql.NewMockCatalog(ctrl)
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "", &ast.AnalyzeStatement{})
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
+
+func TestPlanner_Explain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("explains the select", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+			"name": sql.Column{
+				Position: 1,
+				Name:     "name",
+				DataType: sql.Text,
+			},
+		}
+
+		stats := sql.Statistics{
+			Rows: 100,
+			Columns: map[string]sql.ColumnStatistics{
+				"id": {
+					Distinct:  100,
+					Histogram: []sql.Value{datatype.NewInteger(1), datatype.NewInteger(101)},
+				},
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().Statistics(tableName).Return(stats, true).AnyTimes()
+		table.EXPECT().Name().Return(tableName).AnyTimes()
+		table.EXPECT().Scheme().Return(scheme).AnyTimes()
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+		table.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		stmt := &ast.ExplainStatement{
+			Statement: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "name"}}},
+				From:   &ast.FromStatement{Table: tableName},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Name: "id"},
+						Operator: token.GreaterThan,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "90"},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{Column: "name", Direction: token.Asc},
+			},
+			Format: token.Text,
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"QUERY PLAN"}, planNode.Columns())
+
+		iter, err := planNode.RowIter()
+		require.NoError(t, err)
+
+		var lines []string
+
+		for {
+			row, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+			lines = append(lines, row[0].Raw().(string))
+		}
+
+		expected := []string{
+			"Project: name  (cost=10.61..10.81 rows=10)",
+			"-> Sort by name asc  (cost=10.61..10.61 rows=10)",
+			"   -> Filter: (id > 90)  (cost=0.07..10.27 rows=10)",
+			"      -> Range scan on users  (cost=0.07..10.07 rows=10)",
+		}
+
+		assert.Equal(t, expected, lines)
+	})
+
+	t.Run("explains the delete", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable(tableName).Return(table, nil)
+		database.EXPECT().Statistics(tableName).Return(sql.Statistics{Rows: 100}, true).AnyTimes()
+		table.EXPECT().Name().Return(tableName).AnyTimes()
+		table.EXPECT().Scheme().Return(scheme).AnyTimes()
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+		table.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		stmt := &ast.ExplainStatement{
+			Statement: &ast.DeleteStatement{
+				Table: tableName,
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Name: "id"},
+						Operator: token.Equal,
+						Right:    &ast.ScalarExpr{Type: token.Integer, Literal: "7"},
+					},
+				},
+			},
+			Format: token.Text,
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+
+		iter, err := planNode.RowIter()
+		require.NoError(t, err)
+
+		var lines []string
+
+		for {
+			row, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+			lines = append(lines, row[0].Raw().(string))
+		}
+
+		expected := []string{
+			"Delete on users  (cost=5.09..5.09 rows=0)",
+			"-> Filter: (id = 7)  (cost=4.07..4.09 rows=1)",
+			"   -> Lookup on users  (cost=4.07..4.07 rows=1)",
+		}
+
+		assert.Equal(t, expected, lines)
+	})
+
+	t.Run("explains subqueries", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		databaseName := "playground"
+		tableName := "users"
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+			"name": sql.Column{
+				Position: 1,
+				Name:     "name",
+				DataType: sql.Text,
+			},
+		}
+
+		catalog := sql.NewMockCatalog(ctrl)
+		database := sql.NewMockDatabase(ctrl)
+		table := sql.NewMockTable(ctrl)
+
+		catalog.EXPECT().GetDatabase(databaseName).Return(database, nil).AnyTimes()
+		database.EXPECT().GetTable(tableName).Return(table, nil).AnyTimes()
+		database.EXPECT().Statistics(tableName).Return(sql.Statistics{Rows: 100}, true).AnyTimes()
+		table.EXPECT().Name().Return(tableName).AnyTimes()
+		table.EXPECT().Scheme().Return(scheme).AnyTimes()
+		table.EXPECT().PrimaryKey().Return([]sql.Column{scheme["id"]}).AnyTimes()
+		table.EXPECT().Indexes().Return(nil).AnyTimes()
+
+		// SELECT (SELECT count(*) FROM users u WHERE u.name = users.name) FROM users
+		// WHERE id > (SELECT min(id) FROM users u)
+		subquery := func(result ast.Expression, where ast.Expression) *ast.SelectStatement {
+			stmt := &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: result}},
+				From:   &ast.FromStatement{Table: tableName, Alias: "u"},
+			}
+
+			if where != nil {
+				stmt.Where = &ast.WhereStatement{Expr: where}
+			}
+
+			return stmt
+		}
+
+		stmt := &ast.ExplainStatement{
+			Statement: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{
+					Expr: &ast.SubqueryExpr{Select: subquery(
+						&ast.CallExpr{Name: "count", Args: []ast.Expression{&ast.AsteriskExpr{}}},
+						&ast.BinaryExpr{
+							Left:     &ast.IdentExpr{Table: "u", Name: "name"},
+							Operator: token.Equal,
+							Right:    &ast.IdentExpr{Table: tableName, Name: "name"},
+						},
+					)},
+				}},
+				From: &ast.FromStatement{Table: tableName},
+				Where: &ast.WhereStatement{
+					Expr: &ast.BinaryExpr{
+						Left:     &ast.IdentExpr{Name: "id"},
+						Operator: token.GreaterThan,
+						Right: &ast.SubqueryExpr{Select: subquery(
+							&ast.CallExpr{Name: "min", Args: []ast.Expression{&ast.IdentExpr{Name: "id"}}},
+							nil,
+						)},
+					},
+				},
+			},
+			Format: token.Text,
+		}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), databaseName, stmt)
+		require.NoError(t, err)
+
+		iter, err := planNode.RowIter()
+		require.NoError(t, err)
+
+		var lines []string
+
+		for {
+			row, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+			lines = append(lines, row[0].Raw().(string))
+		}
+
+		// The uncorrelated subquery is read once before the first row, the correlated one once for every row.
+		expected := []string{
+			"Project: (subquery)  (cost=204.71..3627.58 rows=33)",
+			"-> Filter: (id > (subquery))  (cost=102.02..204.02 rows=33)",
+			"   -> Scan on users  (cost=0.00..100.00 rows=100)",
+			"   -> InitPlan 1: (subquery)",
+			"      -> Project: min(id)  (cost=102.00..102.02 rows=1)",
+			"         -> Aggregate: min(id)  (cost=102.00..102.00 rows=1)",
+			"            -> Scan on users  (cost=0.00..100.00 rows=100)",
+			"-> SubPlan 2: (subquery)",
+			"   -> Project: count(*)  (cost=102.67..102.69 rows=1)",
+			"      -> Aggregate: count(*)  (cost=102.67..102.67 rows=1)",
+			"         -> Filter: (u.name = users.name)  (cost=0.00..102.00 rows=33)",
+			"            -> Scan on users  (cost=0.00..100.00 rows=100)",
+		}
+
+		assert.Equal(t, expected, lines)
+	})
+
+	t.Run("returns error on unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		catalog := sql.NewMockCatalog(ctrl)
+		stmt := &ast.ExplainStatement{Statement: &ast.SelectStatement{}, Format: token.Integer}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+
+	t.Run("returns error on statement other than select, update and delete", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		catalog := sql.NewMockCatalog(ctrl)
+		stmt := &ast.ExplainStatement{Statement: &ast.AnalyzeStatement{}, Format: token.Text}
+
+		planNode, err := planner.New(catalog).Plan(transaction.NewSession(transaction.NewManager(catalog)), "playground", stmt)
+		require.Error(t, err)
+		assert.Nil(t, planNode)
+	})
+}
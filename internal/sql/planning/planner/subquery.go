@@ -0,0 +1,219 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/ast"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/parsing/token"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/planning/plan"
+)
+
+// planDerivedTable builds the plan of the subquery of the FROM clause and the scope of its results, they're
+// qualified by the alias. The plan isn't optimized on its own, so filters of the outer query may be moved into it.
+func (p *Planner) planDerivedTable(
+	database string,
+	stmt *ast.SelectStatement,
+	alias string,
+	params *expr.Params,
+) (*expr.Scope, plan.Node, error) {
+	node, err := p.planQuery(database, stmt, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("subquery %q: %w", alias, err)
+	}
+
+	columns := node.Columns()
+	scheme := make(sql.Scheme, len(columns))
+
+	for i, name := range columns {
+		if _, ok := scheme[name]; ok {
+			return nil, nil, fmt.Errorf("column %q specified more than once in subquery %q", name, alias)
+		}
+
+		scheme[name] = sql.Column{Name: name, Position: uint8(i), Nullable: true}
+	}
+
+	return expr.NewScope(alias, scheme), node, nil
+}
+
+// planSemiJoins turns conditions of WHERE that test a subquery by EXISTS, NOT EXISTS or IN into semi and anti joins
+// of rows of the FROM clause with rows of the subquery, so a correlated subquery isn't read for every row.
+// The rest of WHERE is returned.
+func (p *Planner) planSemiJoins(
+	database string,
+	scope *expr.Scope,
+	stmt *ast.WhereStatement,
+	node plan.Node,
+) (*ast.WhereStatement, plan.Node, error) {
+	if stmt == nil || scope == nil {
+		return stmt, node, nil
+	}
+
+	var kept []ast.Expression
+
+	for _, cond := range conjuncts(stmt.Expr) {
+		joined, ok, err := p.planSemiJoin(database, scope, cond, node)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok {
+			node = joined
+		} else {
+			kept = append(kept, cond)
+		}
+	}
+
+	switch len(kept) {
+	case 0:
+		return nil, node, nil
+	case len(conjuncts(stmt.Expr)):
+		return stmt, node, nil
+	}
+
+	where := &ast.WhereStatement{Expr: kept[0]}
+
+	for _, cond := range kept[1:] {
+		where.Expr = &ast.BinaryExpr{Left: where.Expr, Operator: token.And, Right: cond}
+	}
+
+	return where, node, nil
+}
+
+// planSemiJoin joins rows of the node with rows of the FROM clause of the subquery the condition tests, false
+// is returned if the condition isn't such a test or the subquery can't be flattened into a join. The condition
+// of the join is WHERE of the subquery and the equality of the value with the result for IN. NOT IN isn't
+// a join: it's NULL rather than false if the subquery returns NULL.
+func (p *Planner) planSemiJoin(
+	database string,
+	scope *expr.Scope,
+	cond ast.Expression,
+	node plan.Node,
+) (plan.Node, bool, error) {
+	var (
+		stmt     *ast.SelectStatement
+		value    ast.Expression
+		joinType = plan.SemiJoin
+	)
+
+	switch e := cond.(type) {
+	case *ast.ExistsExpr:
+		stmt = e.Select
+
+		if e.Not {
+			joinType = plan.AntiJoin
+		}
+	case *ast.InExpr:
+		if e.Not {
+			return nil, false, nil
+		}
+
+		stmt, value = e.Select, e.Left
+	default:
+		return nil, false, nil
+	}
+
+	if !isFlat(stmt) {
+		return nil, false, nil
+	}
+
+	params := expr.NewParams(scope)
+
+	innerScope, inner, err := p.planFrom(database, stmt.From, params)
+	if err != nil {
+		return nil, false, fmt.Errorf("plan from: %w", err)
+	}
+
+	// Rows of the subquery must not depend on the outer row, only its conditions may.
+	if len(params.Refs()) > 0 || scope.Width()+innerScope.Width() > math.MaxUint8+1 {
+		return nil, false, nil
+	}
+
+	if g, err := p.planGrouping(database, innerScope, stmt); err != nil || g != nil {
+		return nil, false, err
+	}
+
+	var conds []expr.Node
+
+	if stmt.Where != nil {
+		where, err := expr.NewInScope(stmt.Where.Expr, innerScope, p.sequences(database))
+		if err != nil {
+			return nil, false, err
+		}
+
+		conds = append(conds, decorrelate(where, params, scope.Width()))
+	}
+
+	if value != nil {
+		if len(stmt.Result) != 1 {
+			return nil, false, nil
+		}
+
+		if _, ok := stmt.Result[0].Expr.(*ast.AsteriskExpr); ok {
+			return nil, false, nil
+		}
+
+		result, err := expr.NewInScope(stmt.Result[0].Expr, innerScope, p.sequences(database))
+		if err != nil {
+			return nil, false, err
+		}
+
+		left, err := expr.NewInScope(value, scope, p.sequences(database))
+		if err != nil {
+			return nil, false, err
+		}
+
+		conds = append(conds, &expr.Binary{
+			Operator: expr.Equal,
+			Left:     left,
+			Right:    decorrelate(result, params, scope.Width()),
+		})
+	} else if len(params.Refs()) == 0 {
+		// An uncorrelated EXISTS is read once, there's nothing to join.
+		return nil, false, nil
+	}
+
+	return plan.NewNestedLoopJoin(joinType, expr.Conjunction(conds...), node, inner), true, nil
+}
+
+// isFlat reports whether rows of the statement are rows of its FROM clause that satisfy WHERE, so EXISTS or IN
+// of it can be a join with them. A plain DISTINCT and ORDER BY don't change whether a value is among results.
+func isFlat(stmt *ast.SelectStatement) bool {
+	return stmt.From != nil &&
+		(stmt.Distinct == nil || len(stmt.Distinct.On) == 0) &&
+		stmt.GroupBy == nil &&
+		stmt.Having == nil &&
+		stmt.Limit == nil &&
+		stmt.Offset == nil
+}
+
+// decorrelate returns the expression of a subquery over joined rows: the outer row followed by the row
+// of the subquery. Columns of the subquery are moved by the width of the outer row, its parameters become
+// the columns of the outer row they refer to.
+func decorrelate(node expr.Node, params *expr.Params, width int) expr.Node {
+	return expr.Transform(node, func(node expr.Node) (expr.Node, bool) {
+		switch e := node.(type) {
+		case expr.Column:
+			e.Position = uint8(int(e.Position) + width)
+
+			return e, true
+		case expr.Param:
+			if e.Of(params) {
+				return params.Refs()[e.Index], true
+			}
+		}
+
+		return nil, false
+	})
+}
+
+// conjuncts splits the condition into the conditions combined by AND.
+func conjuncts(cond ast.Expression) []ast.Expression {
+	if binary, ok := cond.(*ast.BinaryExpr); ok && binary.Operator == token.And {
+		return append(conjuncts(binary.Left), conjuncts(binary.Right)...)
+	}
+
+	return []ast.Expression{cond}
+}
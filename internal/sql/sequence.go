@@ -0,0 +1,58 @@
+package sql
+
+import (
+	"errors"
+	"math"
+)
+
+// SequenceOptions are the start value and the increment of a named sequence.
+type SequenceOptions struct {
+	Start     int64
+	Increment int64
+}
+
+// DefaultSequenceOptions returns options of a sequence whose options are not given: the increment is 1,
+// a sequence starts at 1 if it's ascending and at -1 if it's descending.
+func DefaultSequenceOptions(start, increment *int64) SequenceOptions {
+	options := SequenceOptions{
+		Start:     1,
+		Increment: 1,
+	}
+
+	if increment != nil {
+		options.Increment = *increment
+	}
+
+	switch {
+	case start != nil:
+		options.Start = *start
+	case options.Increment < 0:
+		options.Start = -1
+	}
+
+	return options
+}
+
+// Validate checks the options before a sequence is created.
+func (o SequenceOptions) Validate() error {
+	if o.Increment == 0 {
+		return errors.New("sequence increment must not be zero")
+	}
+
+	return nil
+}
+
+// NextValue returns the value that follows the current one, called tells whether the sequence
+// has the current value at all, otherwise the start value is returned.
+func (o SequenceOptions) NextValue(current int64, called bool) (int64, error) {
+	if !called {
+		return o.Start, nil
+	}
+
+	if (o.Increment > 0 && current > math.MaxInt64-o.Increment) ||
+		(o.Increment < 0 && current < math.MinInt64-o.Increment) {
+		return 0, errors.New("sequence reached its limit")
+	}
+
+	return current + o.Increment, nil
+}
@@ -0,0 +1,93 @@
+package sql_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+func TestDefaultSequenceOptions(t *testing.T) {
+	t.Parallel()
+
+	ten, minusTwo := int64(10), int64(-2)
+
+	assert.Equal(t, sql.SequenceOptions{Start: 1, Increment: 1}, sql.DefaultSequenceOptions(nil, nil))
+	assert.Equal(t, sql.SequenceOptions{Start: 10, Increment: 1}, sql.DefaultSequenceOptions(&ten, nil))
+	assert.Equal(t, sql.SequenceOptions{Start: -1, Increment: -2}, sql.DefaultSequenceOptions(nil, &minusTwo))
+	assert.Equal(t, sql.SequenceOptions{Start: 10, Increment: -2}, sql.DefaultSequenceOptions(&ten, &minusTwo))
+}
+
+func TestSequenceOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, sql.SequenceOptions{Start: 1, Increment: -1}.Validate())
+	require.Error(t, sql.SequenceOptions{Start: 1, Increment: 0}.Validate())
+}
+
+func TestSequenceOptions_NextValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  sql.SequenceOptions
+		current  int64
+		called   bool
+		expected int64
+		fails    bool
+	}{
+		{
+			name:     "not called",
+			options:  sql.SequenceOptions{Start: 100, Increment: 10},
+			current:  0,
+			expected: 100,
+		},
+		{
+			name:     "ascending",
+			options:  sql.SequenceOptions{Start: 100, Increment: 10},
+			current:  110,
+			called:   true,
+			expected: 120,
+		},
+		{
+			name:     "descending",
+			options:  sql.SequenceOptions{Start: -1, Increment: -5},
+			current:  -1,
+			called:   true,
+			expected: -6,
+		},
+		{
+			name:    "maximum",
+			options: sql.SequenceOptions{Start: 1, Increment: 2},
+			current: math.MaxInt64 - 1,
+			called:  true,
+			fails:   true,
+		},
+		{
+			name:    "minimum",
+			options: sql.SequenceOptions{Start: -1, Increment: -1},
+			current: math.MinInt64,
+			called:  true,
+			fails:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := test.options.NextValue(test.current, test.called)
+			if test.fails {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
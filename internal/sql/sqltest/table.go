@@ -0,0 +1,22 @@
+// Package sqltest provides test doubles of the sql package shared by tests of several packages.
+package sqltest
+
+import (
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// ColumnTable is a mock of a table that stores columns separately: it's a table and a column scanner.
+type ColumnTable struct {
+	*sql.MockTable
+	*sql.MockColumnScanner
+}
+
+// NewColumnTable returns the mock of a table that stores columns separately.
+func NewColumnTable(ctrl *gomock.Controller) ColumnTable {
+	return ColumnTable{
+		MockTable:         sql.NewMockTable(ctrl),
+		MockColumnScanner: sql.NewMockColumnScanner(ctrl),
+	}
+}
@@ -0,0 +1,22 @@
+package sql
+
+// Statistics describes data of a table. It's collected by ANALYZE and used by the planner
+// to estimate the number of rows a query reads.
+type Statistics struct {
+	// Rows is the number of rows in the table.
+	Rows int64
+	// Columns are statistics of columns by their names.
+	Columns map[string]ColumnStatistics
+}
+
+// ColumnStatistics describes values of a column.
+type ColumnStatistics struct {
+	// NullFraction is the fraction of rows with NULL in the column.
+	NullFraction float64
+	// Distinct is the estimated number of distinct values other than NULL.
+	Distinct int64
+	// Histogram is the bounds of an equi-depth histogram of values other than NULL in ascending order:
+	// every pair of neighbouring bounds limits a bucket holding about the same number of rows.
+	// It's empty if all values are NULL.
+	Histogram []Value
+}
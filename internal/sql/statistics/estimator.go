@@ -0,0 +1,220 @@
+package statistics
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+)
+
+// Defaults used when a table has never been analyzed or a value can't be compared with the statistics.
+const (
+	// DefaultRows is the assumed number of rows of a table.
+	DefaultRows = 1000
+	// DefaultEqualSelectivity is the assumed fraction of rows equal to a value.
+	DefaultEqualSelectivity = 0.005
+	// DefaultRangeSelectivity is the assumed fraction of rows on one side of a value.
+	DefaultRangeSelectivity = 1.0 / 3
+	// DefaultNullSelectivity is the assumed fraction of rows with NULL.
+	DefaultNullSelectivity = 0.005
+	// DefaultDistinct is the assumed number of distinct values of a column.
+	DefaultDistinct = 200
+)
+
+// Estimator estimates the number of rows of a table and the fraction of them matching predicates.
+type Estimator struct {
+	statistics sql.Statistics
+	analyzed   bool
+}
+
+// NewEstimator returns the estimator by statistics of a table, analyzed is false if the table has no statistics.
+func NewEstimator(statistics sql.Statistics, analyzed bool) Estimator {
+	return Estimator{
+		statistics: statistics,
+		analyzed:   analyzed,
+	}
+}
+
+// Analyzed reports whether estimates are based on statistics rather than defaults.
+func (e Estimator) Analyzed() bool {
+	return e.analyzed
+}
+
+// Rows returns the number of rows of the table.
+func (e Estimator) Rows() float64 {
+	if !e.analyzed {
+		return DefaultRows
+	}
+
+	return float64(e.statistics.Rows)
+}
+
+// Distinct returns the number of distinct values of the column other than NULL.
+func (e Estimator) Distinct(column string) float64 {
+	stats, ok := e.column(column)
+	if !ok {
+		return min(DefaultDistinct, e.Rows())
+	}
+
+	return float64(stats.Distinct)
+}
+
+// Equal returns the fraction of rows whose column is equal to the value.
+func (e Estimator) Equal(column string, value sql.Value) float64 {
+	if value.DataType() == sql.Null {
+		return 0
+	}
+
+	stats, ok := e.column(column)
+	if !ok {
+		return DefaultEqualSelectivity
+	}
+
+	if _, ok = e.less(stats, value); !ok {
+		return DefaultEqualSelectivity
+	}
+
+	return (1 - stats.NullFraction) * equalFraction(stats)
+}
+
+// Range returns the fraction of rows whose column falls into the range, a nil bound is unbounded.
+func (e Estimator) Range(column string, from, to *sql.Bound) float64 {
+	if (from != nil && from.Value.DataType() == sql.Null) || (to != nil && to.Value.DataType() == sql.Null) {
+		return 0
+	}
+
+	stats, ok := e.column(column)
+	if !ok {
+		if from != nil && to != nil {
+			return DefaultRangeSelectivity * DefaultRangeSelectivity
+		}
+
+		return DefaultRangeSelectivity
+	}
+
+	// Fractions of non-null values below the lower and the upper bound.
+	low, high := 0.0, 1.0
+
+	if from != nil {
+		less, ok := e.less(stats, from.Value)
+		if !ok {
+			return DefaultRangeSelectivity
+		}
+
+		low = less
+
+		if !from.Inclusive {
+			low += equalFraction(stats)
+		}
+	}
+
+	if to != nil {
+		less, ok := e.less(stats, to.Value)
+		if !ok {
+			return DefaultRangeSelectivity
+		}
+
+		high = less
+
+		if to.Inclusive {
+			high += equalFraction(stats)
+		}
+	}
+
+	return (1 - stats.NullFraction) * min(max(high-low, 0), 1)
+}
+
+// Null returns the fraction of rows with NULL in the column.
+func (e Estimator) Null(column string) float64 {
+	stats, ok := e.column(column)
+	if !ok {
+		return DefaultNullSelectivity
+	}
+
+	return stats.NullFraction
+}
+
+// Cardinality returns the number of rows of the table matching a predicate with the selectivity.
+func (e Estimator) Cardinality(selectivity float64) float64 {
+	return e.Rows() * min(max(selectivity, 0), 1)
+}
+
+func (e Estimator) column(name string) (sql.ColumnStatistics, bool) {
+	if !e.analyzed {
+		return sql.ColumnStatistics{}, false
+	}
+
+	stats, ok := e.statistics.Columns[name]
+
+	return stats, ok
+}
+
+// less returns the fraction of non-null values of the column that are less than the value.
+// False is returned if the value can't be compared with values of the column.
+func (e Estimator) less(stats sql.ColumnStatistics, value sql.Value) (float64, bool) {
+	bounds := stats.Histogram
+	if len(bounds) == 0 {
+		return 0, true
+	}
+
+	// The first bound that isn't less than the value closes the bucket with the value.
+	i := 0
+
+	for ; i < len(bounds); i++ {
+		c, err := comparison.Compare(bounds[i], value)
+		if err != nil {
+			return 0, false
+		}
+
+		if c != sql.Less {
+			break
+		}
+	}
+
+	switch {
+	case i == 0:
+		return 0, true
+	case i == len(bounds):
+		return 1, true
+	}
+
+	buckets := float64(len(bounds) - 1)
+
+	return (float64(i-1) + interpolate(bounds[i-1], bounds[i], value)) / buckets, true
+}
+
+// equalFraction returns the fraction of non-null values equal to a single value, assuming they are spread evenly.
+func equalFraction(stats sql.ColumnStatistics) float64 {
+	if stats.Distinct == 0 {
+		return 0
+	}
+
+	return 1 / float64(stats.Distinct)
+}
+
+// interpolate returns the position of the value between the bounds of a bucket from 0 to 1.
+// Values other than numbers are assumed to be in the middle of the bucket.
+func interpolate(low, high, value sql.Value) float64 {
+	l, lok := number(low)
+	h, hok := number(high)
+	v, vok := number(value)
+
+	if !lok || !hok || !vok {
+		return 0.5
+	}
+
+	if h <= l {
+		return 0
+	}
+
+	return min(max((v-l)/(h-l), 0), 1)
+}
+
+func number(value sql.Value) (float64, bool) {
+	switch v := value.Raw().(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,85 @@
+package statistics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+func TestEstimator(t *testing.T) {
+	t.Parallel()
+
+	// Ids from 0 to 1000 spread evenly, ten percent of ages are NULL.
+	stats := sql.Statistics{
+		Rows: 1000,
+		Columns: map[string]sql.ColumnStatistics{
+			"id": {
+				Distinct: 1000,
+				Histogram: []sql.Value{
+					datatype.NewInteger(0),
+					datatype.NewInteger(250),
+					datatype.NewInteger(500),
+					datatype.NewInteger(750),
+					datatype.NewInteger(1000),
+				},
+			},
+			"age": {
+				NullFraction: 0.1,
+				Distinct:     50,
+				Histogram:    []sql.Value{datatype.NewInteger(20), datatype.NewInteger(70)},
+			},
+		},
+	}
+
+	bound := func(v int64, inclusive bool) *sql.Bound {
+		return &sql.Bound{Value: datatype.NewInteger(v), Inclusive: inclusive}
+	}
+
+	t.Run("analyzed table", func(t *testing.T) {
+		t.Parallel()
+
+		estimator := statistics.NewEstimator(stats, true)
+
+		assert.True(t, estimator.Analyzed())
+		assert.InDelta(t, 1000, estimator.Rows(), 0)
+		assert.InDelta(t, 50, estimator.Distinct("age"), 0)
+		assert.InDelta(t, 0.001, estimator.Equal("id", datatype.NewInteger(10)), 1e-9)
+		assert.InDelta(t, 0.9/50, estimator.Equal("age", datatype.NewInteger(30)), 1e-9)
+		assert.Zero(t, estimator.Equal("age", datatype.NewNull()))
+		assert.InDelta(t, 0.1, estimator.Null("age"), 0)
+		assert.InDelta(t, 0.1, estimator.Range("id", bound(100, true), bound(200, false)), 1e-9)
+		assert.InDelta(t, 0.6, estimator.Range("id", bound(400, true), nil), 1e-9)
+		assert.InDelta(t, 0.25, estimator.Range("id", nil, bound(250, false)), 1e-9)
+		assert.InDelta(t, 0.9*0.2, estimator.Range("age", nil, bound(30, false)), 1e-9)
+		assert.Zero(t, estimator.Range("id", bound(2000, true), nil))
+		assert.InDelta(t, 1, estimator.Range("id", bound(-10, true), nil), 1e-9)
+		assert.InDelta(t, 100, estimator.Cardinality(0.1), 1e-9)
+	})
+
+	t.Run("never analyzed table", func(t *testing.T) {
+		t.Parallel()
+
+		estimator := statistics.NewEstimator(sql.Statistics{}, false)
+
+		assert.False(t, estimator.Analyzed())
+		assert.InDelta(t, statistics.DefaultRows, estimator.Rows(), 0)
+		assert.InDelta(t, statistics.DefaultDistinct, estimator.Distinct("id"), 0)
+		assert.InDelta(t, statistics.DefaultEqualSelectivity, estimator.Equal("id", datatype.NewInteger(1)), 0)
+		assert.InDelta(t, statistics.DefaultRangeSelectivity, estimator.Range("id", bound(1, true), nil), 0)
+		assert.InDelta(t, statistics.DefaultNullSelectivity, estimator.Null("id"), 0)
+	})
+
+	t.Run("value of another type", func(t *testing.T) {
+		t.Parallel()
+
+		estimator := statistics.NewEstimator(stats, true)
+		text := datatype.NewText("abc")
+
+		assert.InDelta(t, statistics.DefaultEqualSelectivity, estimator.Equal("id", text), 0)
+		assert.InDelta(t, statistics.DefaultRangeSelectivity, estimator.Range("id", &sql.Bound{Value: text}, nil), 0)
+	})
+}
@@ -0,0 +1,166 @@
+// Package statistics collects statistics of tables for ANALYZE and estimates selectivity of predicates by them.
+package statistics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+)
+
+// SampleSize is the number of rows a table is sampled by: every row is counted,
+// but distinct values and histograms are built from a random sample of rows.
+const SampleSize = 30000
+
+// HistogramBuckets is the maximum number of buckets of a histogram.
+const HistogramBuckets = 100
+
+// Collect reads all rows of the iterator and returns statistics of the table with the scheme.
+// The sample is chosen by a generator with a fixed seed, so the same rows always give the same statistics.
+func Collect(scheme sql.Scheme, iter sql.RowIter) (sql.Statistics, error) {
+	random := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // the sample doesn't need a secure generator
+	sample := make([]sql.Row, 0)
+	rows := int64(0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return sql.Statistics{}, fmt.Errorf("get next row: %w", err)
+		}
+
+		rows++
+
+		// Reservoir sampling: every row read so far gets into the sample with the same probability.
+		if len(sample) < SampleSize {
+			sample = append(sample, row)
+		} else if i := random.Int64N(rows); i < SampleSize {
+			sample[i] = row
+		}
+	}
+
+	statistics := sql.Statistics{
+		Rows:    rows,
+		Columns: make(map[string]sql.ColumnStatistics, len(scheme)),
+	}
+
+	for name, column := range scheme {
+		values := make([]sql.Value, 0, len(sample))
+
+		for _, row := range sample {
+			if value := row[column.Position]; value != nil && value.DataType() != sql.Null {
+				values = append(values, value)
+			}
+		}
+
+		columnStatistics, err := collectColumn(values, len(sample), rows)
+		if err != nil {
+			return sql.Statistics{}, fmt.Errorf("column %q: %w", name, err)
+		}
+
+		statistics.Columns[name] = columnStatistics
+	}
+
+	return statistics, nil
+}
+
+// collectColumn returns statistics of the column by its non-null values of the sample of the table.
+func collectColumn(values []sql.Value, sampled int, rows int64) (sql.ColumnStatistics, error) {
+	var column sql.ColumnStatistics
+
+	if sampled == 0 {
+		return column, nil
+	}
+
+	column.NullFraction = float64(sampled-len(values)) / float64(sampled)
+
+	if len(values) == 0 {
+		return column, nil
+	}
+
+	var compareErr error
+
+	slices.SortFunc(values, func(a, b sql.Value) int {
+		c, err := comparison.Compare(a, b)
+		if err != nil {
+			compareErr = err
+		}
+
+		return int(c)
+	})
+
+	if compareErr != nil {
+		return sql.ColumnStatistics{}, compareErr
+	}
+
+	column.Distinct = estimateDistinct(values, sampled, rows)
+	column.Histogram = histogram(values)
+
+	return column, nil
+}
+
+// estimateDistinct estimates the number of distinct values in the table by the sorted sample values,
+// using the estimator of Haas and Stokes: values that occur in the sample once tell how many values
+// weren't sampled at all.
+func estimateDistinct(values []sql.Value, sampled int, rows int64) int64 {
+	distinct, once := 0, 0
+
+	for i := 0; i < len(values); {
+		j := i + 1
+
+		for j < len(values) && equal(values[i], values[j]) {
+			j++
+		}
+
+		distinct++
+
+		if j-i == 1 {
+			once++
+		}
+
+		i = j
+	}
+
+	switch {
+	case int64(sampled) == rows:
+		// The sample is the whole table.
+		return int64(distinct)
+	case once == len(values):
+		// All sampled values are unique, the column is likely to be unique as well.
+		return int64(float64(rows) * float64(len(values)) / float64(sampled))
+	}
+
+	n, d, f1, total := float64(len(values)), float64(distinct), float64(once), float64(rows)
+	estimate := n * d / (n - f1 + f1*n/total)
+
+	return int64(min(max(estimate, d), total))
+}
+
+// histogram returns bounds of the equi-depth histogram of the sorted values.
+func histogram(values []sql.Value) []sql.Value {
+	buckets := min(HistogramBuckets, len(values)-1)
+	if buckets == 0 {
+		return []sql.Value{values[0]}
+	}
+
+	bounds := make([]sql.Value, 0, buckets+1)
+
+	for i := 0; i <= buckets; i++ {
+		bounds = append(bounds, values[i*(len(values)-1)/buckets])
+	}
+
+	return bounds
+}
+
+func equal(a, b sql.Value) bool {
+	c, err := comparison.Compare(a, b)
+
+	return err == nil && c == sql.Equal
+}
@@ -0,0 +1,118 @@
+package statistics_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/statistics"
+)
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"city": sql.Column{
+			Position: 1,
+			Name:     "city",
+			DataType: sql.Text,
+			Nullable: true,
+		},
+	}
+
+	t.Run("whole table", func(t *testing.T) {
+		t.Parallel()
+
+		rows := []sql.Row{
+			{datatype.NewInteger(3), datatype.NewText("Paris")},
+			{datatype.NewInteger(1), datatype.NewText("Berlin")},
+			{datatype.NewInteger(4), datatype.NewNull()},
+			{datatype.NewInteger(2), datatype.NewText("Paris")},
+		}
+
+		stats, err := statistics.Collect(scheme, sql.RowsIter(rows...))
+		require.NoError(t, err)
+
+		expected := sql.Statistics{
+			Rows: 4,
+			Columns: map[string]sql.ColumnStatistics{
+				"id": {
+					Distinct: 4,
+					Histogram: []sql.Value{
+						datatype.NewInteger(1),
+						datatype.NewInteger(2),
+						datatype.NewInteger(3),
+						datatype.NewInteger(4),
+					},
+				},
+				"city": {
+					NullFraction: 0.25,
+					Distinct:     2,
+					Histogram: []sql.Value{
+						datatype.NewText("Berlin"),
+						datatype.NewText("Paris"),
+						datatype.NewText("Paris"),
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, expected, stats)
+	})
+
+	t.Run("sample of a large table", func(t *testing.T) {
+		t.Parallel()
+
+		const total = 3 * statistics.SampleSize
+
+		rows := make([]sql.Row, 0, total)
+
+		for i := range total {
+			rows = append(rows, sql.Row{datatype.NewInteger(int64(i)), datatype.NewText(string(rune('a' + i%10)))})
+		}
+
+		stats, err := statistics.Collect(scheme, sql.RowsIter(rows...))
+		require.NoError(t, err)
+		assert.Equal(t, int64(total), stats.Rows)
+
+		id := stats.Columns["id"]
+		assert.Equal(t, int64(total), id.Distinct, "sampled unique values are scaled to the table")
+		assert.Len(t, id.Histogram, statistics.HistogramBuckets+1)
+		assert.Zero(t, id.NullFraction)
+
+		city := stats.Columns["city"]
+		assert.Equal(t, int64(10), city.Distinct)
+		assert.Equal(t, datatype.NewText("a"), city.Histogram[0])
+		assert.Equal(t, datatype.NewText("j"), city.Histogram[len(city.Histogram)-1])
+
+		again, err := statistics.Collect(scheme, sql.RowsIter(rows...))
+		require.NoError(t, err)
+		assert.Equal(t, stats, again, "the same rows must give the same statistics")
+	})
+
+	t.Run("returns error on next row", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expectedErr := errors.New("something went wrong")
+
+		iter := sql.NewMockRowIter(ctrl)
+		iter.EXPECT().Next().Return(nil, expectedErr)
+
+		_, err := statistics.Collect(scheme, iter)
+		require.ErrorIs(t, err, expectedErr)
+	})
+}
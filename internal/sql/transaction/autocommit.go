@@ -0,0 +1,239 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// autoDatabase is the view of a database outside a transaction.
+type autoDatabase struct {
+	manager *Manager
+	base    sql.Database
+}
+
+func (d *autoDatabase) Name() string {
+	return d.base.Name()
+}
+
+func (d *autoDatabase) GetTable(name string) (sql.Table, error) {
+	d.manager.mu.RLock()
+	defer d.manager.mu.RUnlock()
+
+	t, err := d.base.GetTable(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.table(t), nil
+}
+
+func (d *autoDatabase) ListTables() []sql.Table {
+	d.manager.mu.RLock()
+	defer d.manager.mu.RUnlock()
+
+	list := d.base.ListTables()
+	tables := make([]sql.Table, 0, len(list))
+
+	for _, t := range list {
+		tables = append(tables, d.table(t))
+	}
+
+	return tables
+}
+
+func (d *autoDatabase) CreateTable(name string, scheme sql.Scheme) (sql.Table, error) {
+	d.manager.mu.Lock()
+	defer d.manager.mu.Unlock()
+
+	t, err := d.base.CreateTable(name, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.table(t), nil
+}
+
+func (d *autoDatabase) CreateTableUsing(name string, scheme sql.Scheme, engine string) (sql.Table, error) {
+	creator, ok := d.base.(sql.EngineDatabase)
+	if !ok {
+		return nil, fmt.Errorf("storage engine %q is not supported", engine)
+	}
+
+	d.manager.mu.Lock()
+	defer d.manager.mu.Unlock()
+
+	t, err := creator.CreateTableUsing(name, scheme, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.table(t), nil
+}
+
+func (d *autoDatabase) DropTable(name string) error {
+	d.manager.mu.Lock()
+	defer d.manager.mu.Unlock()
+
+	if err := d.base.DropTable(name); err != nil {
+		return err
+	}
+
+	d.manager.forget(tableName{database: d.base.Name(), table: name})
+
+	return nil
+}
+
+func (d *autoDatabase) GetSequence(name string) (sql.NamedSequence, error) {
+	return d.base.GetSequence(name)
+}
+
+func (d *autoDatabase) ListSequences() []sql.NamedSequence {
+	return d.base.ListSequences()
+}
+
+func (d *autoDatabase) CreateSequence(name string, options sql.SequenceOptions) (sql.NamedSequence, error) {
+	d.manager.mu.Lock()
+	defer d.manager.mu.Unlock()
+
+	return d.base.CreateSequence(name, options)
+}
+
+func (d *autoDatabase) DropSequence(name string) error {
+	d.manager.mu.Lock()
+	defer d.manager.mu.Unlock()
+
+	return d.base.DropSequence(name)
+}
+
+func (d *autoDatabase) Statistics(table string) (sql.Statistics, bool) {
+	return d.base.Statistics(table)
+}
+
+func (d *autoDatabase) SetStatistics(table string, statistics sql.Statistics) error {
+	return d.base.SetStatistics(table, statistics)
+}
+
+func (d *autoDatabase) table(t sql.Table) *autoTable {
+	return &autoTable{
+		manager: d.manager,
+		name:    tableName{database: d.base.Name(), table: t.Name()},
+		base:    t,
+	}
+}
+
+// autoTable is the view of a table outside a transaction: reads see the latest committed rows,
+// every write is committed on its own.
+type autoTable struct {
+	manager *Manager
+	name    tableName
+	base    sql.Table
+}
+
+func (t *autoTable) Name() string {
+	return t.base.Name()
+}
+
+func (t *autoTable) Scheme() sql.Scheme {
+	return t.base.Scheme()
+}
+
+func (t *autoTable) PrimaryKey() []sql.Column {
+	return t.base.PrimaryKey()
+}
+
+func (t *autoTable) Sequence() sql.Sequence {
+	return t.base.Sequence()
+}
+
+func (t *autoTable) Scan() (sql.RowIter, error) {
+	t.manager.mu.RLock()
+	defer t.manager.mu.RUnlock()
+
+	return t.base.Scan()
+}
+
+func (t *autoTable) ScanColumns(positions []uint8) (sql.RowIter, error) {
+	t.manager.mu.RLock()
+	defer t.manager.mu.RUnlock()
+
+	return scanColumns(t.base, positions)
+}
+
+func (t *autoTable) Get(key sql.Key) (sql.Row, bool, error) {
+	t.manager.mu.RLock()
+	defer t.manager.mu.RUnlock()
+
+	return t.base.Get(key)
+}
+
+func (t *autoTable) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	t.manager.mu.RLock()
+	defer t.manager.mu.RUnlock()
+
+	return t.base.ScanRange(from, to)
+}
+
+func (t *autoTable) Insert(key sql.Key, row sql.Row) error {
+	return t.manager.write(t.name, t.base, key, func() error {
+		return t.base.Insert(key, row)
+	})
+}
+
+func (t *autoTable) Delete(key sql.Key) error {
+	return t.manager.write(t.name, t.base, key, func() error {
+		return t.base.Delete(key)
+	})
+}
+
+func (t *autoTable) Update(key sql.Key, row sql.Row) error {
+	return t.manager.write(t.name, t.base, key, func() error {
+		return t.base.Update(key, row)
+	})
+}
+
+func (t *autoTable) Indexes() []sql.Index {
+	t.manager.mu.RLock()
+	defer t.manager.mu.RUnlock()
+
+	list := t.base.Indexes()
+	indexes := make([]sql.Index, 0, len(list))
+
+	for _, idx := range list {
+		indexes = append(indexes, &autoIndex{Index: idx, manager: t.manager})
+	}
+
+	return indexes
+}
+
+func (t *autoTable) CreateIndex(name string, columns []string, unique bool) (sql.Index, error) {
+	t.manager.mu.Lock()
+	defer t.manager.mu.Unlock()
+
+	idx, err := t.base.CreateIndex(name, columns, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoIndex{Index: idx, manager: t.manager}, nil
+}
+
+func (t *autoTable) DropIndex(name string) error {
+	t.manager.mu.Lock()
+	defer t.manager.mu.Unlock()
+
+	return t.base.DropIndex(name)
+}
+
+// autoIndex is the view of an index outside a transaction.
+type autoIndex struct {
+	sql.Index
+	manager *Manager
+}
+
+func (i *autoIndex) Scan(r sql.IndexRange) (sql.RowIter, error) {
+	i.manager.mu.RLock()
+	defer i.manager.mu.RUnlock()
+
+	return i.Index.Scan(r)
+}
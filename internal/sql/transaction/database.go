@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// database is the view of a database inside a transaction.
+type database struct {
+	tx   *Tx
+	base sql.Database
+}
+
+func (d *database) Name() string {
+	return d.base.Name()
+}
+
+func (d *database) GetTable(name string) (sql.Table, error) {
+	d.tx.manager.mu.RLock()
+	defer d.tx.manager.mu.RUnlock()
+
+	t, err := d.base.GetTable(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.tx.table(d.base.Name(), t), nil
+}
+
+func (d *database) ListTables() []sql.Table {
+	d.tx.manager.mu.RLock()
+	defer d.tx.manager.mu.RUnlock()
+
+	list := d.base.ListTables()
+	tables := make([]sql.Table, 0, len(list))
+
+	for _, t := range list {
+		tables = append(tables, d.tx.table(d.base.Name(), t))
+	}
+
+	return tables
+}
+
+func (d *database) CreateTable(string, sql.Scheme) (sql.Table, error) {
+	return nil, ErrSchemaChange
+}
+
+func (d *database) CreateTableUsing(string, sql.Scheme, string) (sql.Table, error) {
+	return nil, ErrSchemaChange
+}
+
+func (d *database) DropTable(string) error {
+	return ErrSchemaChange
+}
+
+// GetSequence returns the sequence itself: sequences are not transactional.
+func (d *database) GetSequence(name string) (sql.NamedSequence, error) {
+	return d.base.GetSequence(name)
+}
+
+func (d *database) ListSequences() []sql.NamedSequence {
+	return d.base.ListSequences()
+}
+
+func (d *database) CreateSequence(string, sql.SequenceOptions) (sql.NamedSequence, error) {
+	return nil, ErrSchemaChange
+}
+
+func (d *database) DropSequence(string) error {
+	return ErrSchemaChange
+}
+
+func (d *database) Statistics(table string) (sql.Statistics, bool) {
+	return d.base.Statistics(table)
+}
+
+// SetStatistics replaces statistics right away: they describe the table only approximately,
+// so statistics collected from the snapshot of the transaction are good enough.
+func (d *database) SetStatistics(table string, statistics sql.Statistics) error {
+	return d.base.SetStatistics(table, statistics)
+}
@@ -0,0 +1,312 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// ErrConflict is returned on commit if a row written by the transaction was changed by another transaction
+// after the snapshot of the transaction was taken.
+var ErrConflict = errors.New("could not serialize access due to concurrent update")
+
+// version is a committed row replaced by a later change. The row was current for snapshots taken before end,
+// a nil row means there was no row with the key.
+type version struct {
+	row sql.Row
+	end uint64
+}
+
+// Manager serializes access to a catalog and keeps the history of rows needed by open transactions.
+//
+// Every change gets the next transaction ID when it's committed: a single write outside a transaction or all writes
+// of a transaction at once. A transaction reads the snapshot of the last ID committed before it began: the latest
+// rows of tables are replaced with the versions from the history that were current at the snapshot. Versions are
+// dropped as soon as no open transaction can see them.
+//
+// Manager implements sql.Catalog, statements run through it outside a transaction change tables directly.
+// Manager is safe for concurrent use as long as the catalog is accessed only through it.
+type Manager struct {
+	mu      sync.RWMutex
+	catalog sql.Catalog
+	lastID  uint64
+	active  map[uint64]int
+	history map[tableName]map[sql.Key][]version
+}
+
+func NewManager(catalog sql.Catalog) *Manager {
+	return &Manager{
+		catalog: catalog,
+		active:  make(map[uint64]int),
+		history: make(map[tableName]map[sql.Key][]version),
+	}
+}
+
+// Begin starts a transaction that reads the snapshot of the last commit.
+func (m *Manager) Begin() *Tx {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.active[m.lastID]++
+
+	return newTx(m, m.lastID)
+}
+
+func (m *Manager) GetDatabase(name string) (sql.Database, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	db, err := m.catalog.GetDatabase(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoDatabase{manager: m, base: db}, nil
+}
+
+func (m *Manager) ListDatabases() ([]sql.Database, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list, err := m.catalog.ListDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make([]sql.Database, 0, len(list))
+
+	for _, db := range list {
+		databases = append(databases, &autoDatabase{manager: m, base: db})
+	}
+
+	return databases, nil
+}
+
+func (m *Manager) CreateDatabase(name string) (sql.Database, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	db, err := m.catalog.CreateDatabase(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoDatabase{manager: m, base: db}, nil
+}
+
+func (m *Manager) CreateDatabaseUsing(name, engine string) (sql.Database, error) {
+	creator, ok := m.catalog.(sql.EngineCatalog)
+	if !ok {
+		return nil, fmt.Errorf("storage engine %q is not supported", engine)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	db, err := creator.CreateDatabaseUsing(name, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoDatabase{manager: m, base: db}, nil
+}
+
+func (m *Manager) DropDatabase(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.catalog.DropDatabase(name); err != nil {
+		return err
+	}
+
+	for table := range m.history {
+		if table.database == name {
+			delete(m.history, table)
+		}
+	}
+
+	return nil
+}
+
+// write applies a single change outside a transaction.
+func (m *Manager) write(name tableName, table sql.Table, key sql.Key, apply func() error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok, err := table.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		before = nil
+	}
+
+	if err = apply(); err != nil {
+		return err
+	}
+
+	m.lastID++
+	m.record(name, key, before, m.lastID)
+
+	return nil
+}
+
+// commit applies changes of the transaction atomically: either all of them become visible or none.
+func (m *Manager) commit(snapshot uint64, changes []change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The snapshot is released after the check, because it keeps versions the check relies on.
+	err := m.checkConflicts(snapshot, changes)
+	m.release(snapshot)
+
+	if err != nil {
+		return err
+	}
+
+	type applied struct {
+		change change
+		before sql.Row
+		undo   func() error
+	}
+
+	done := make([]applied, 0, len(changes))
+
+	for _, c := range changes {
+		before, undo, err := c.apply()
+		if err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				if undoErr := done[i].undo(); undoErr != nil {
+					err = errors.Join(err, fmt.Errorf("revert change: %w", undoErr))
+				}
+			}
+
+			return fmt.Errorf("commit changes of table %q: %w", c.name.table, err)
+		}
+
+		if undo != nil {
+			done = append(done, applied{change: c, before: before, undo: undo})
+		}
+	}
+
+	m.lastID++
+
+	for _, a := range done {
+		m.record(a.change.name, a.change.key, a.before, m.lastID)
+	}
+
+	return nil
+}
+
+// checkConflicts returns an error if any of the rows was changed by another commit after the snapshot.
+func (m *Manager) checkConflicts(snapshot uint64, changes []change) error {
+	for _, c := range changes {
+		if m.changedAfter(c.name, c.key, snapshot) {
+			return fmt.Errorf("%w: row with key %s of table %q", ErrConflict, c.key, c.name.table)
+		}
+	}
+
+	return nil
+}
+
+// rollback closes the snapshot of a transaction.
+func (m *Manager) rollback(snapshot uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.release(snapshot)
+}
+
+// release closes the snapshot and drops versions that are no longer visible to any open transaction.
+func (m *Manager) release(snapshot uint64) {
+	if m.active[snapshot]--; m.active[snapshot] == 0 {
+		delete(m.active, snapshot)
+	}
+
+	if len(m.active) == 0 {
+		clear(m.history)
+
+		return
+	}
+
+	oldest := m.lastID
+
+	for id := range m.active {
+		oldest = min(oldest, id)
+	}
+
+	for table, keys := range m.history {
+		for key, versions := range keys {
+			n := 0
+
+			for n < len(versions) && versions[n].end <= oldest {
+				n++
+			}
+
+			if n == len(versions) {
+				delete(keys, key)
+			} else {
+				keys[key] = versions[n:]
+			}
+		}
+
+		if len(keys) == 0 {
+			delete(m.history, table)
+		}
+	}
+}
+
+// record keeps the replaced row while there are open transactions that may read it.
+func (m *Manager) record(name tableName, key sql.Key, row sql.Row, id uint64) {
+	if len(m.active) == 0 {
+		return
+	}
+
+	keys, ok := m.history[name]
+	if !ok {
+		keys = make(map[sql.Key][]version)
+		m.history[name] = keys
+	}
+
+	keys[key] = append(keys[key], version{row: row, end: id})
+}
+
+// changedAfter reports whether the row was changed by a commit after the snapshot.
+func (m *Manager) changedAfter(name tableName, key sql.Key, snapshot uint64) bool {
+	versions := m.history[name][key]
+
+	return len(versions) > 0 && versions[len(versions)-1].end > snapshot
+}
+
+// asOf returns the row as it was at the snapshot if it has been changed since then, a nil row means
+// there was no row with the key. False is returned if the row wasn't changed, so the latest row is current.
+func (m *Manager) asOf(name tableName, key sql.Key, snapshot uint64) (sql.Row, bool) {
+	for _, v := range m.history[name][key] {
+		if v.end > snapshot {
+			return v.row, true
+		}
+	}
+
+	return nil, false
+}
+
+// changedSince returns rows of the table as they were at the snapshot for all keys changed since then.
+func (m *Manager) changedSince(name tableName, snapshot uint64) map[sql.Key]sql.Row {
+	rows := make(map[sql.Key]sql.Row)
+
+	for key := range m.history[name] {
+		if row, ok := m.asOf(name, key, snapshot); ok {
+			rows[key] = row
+		}
+	}
+
+	return rows
+}
+
+// forget drops the history of the table, it's called when the table is dropped.
+func (m *Manager) forget(name tableName) {
+	delete(m.history, name)
+}
@@ -0,0 +1,190 @@
+package transaction_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
+)
+
+func TestManager_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		accounts  = 10
+		balance   = 100
+		writers   = 8
+		readers   = 4
+		transfers = 50
+	)
+
+	manager, base := newCatalog(t)
+
+	for id := int64(1); id <= accounts; id++ {
+		require.NoError(t, base.Insert(sql.IntegerKey(id), userRow(id, fmt.Sprint(balance))))
+	}
+
+	// Each transfer moves one unit between two accounts; the total stays the same in every snapshot.
+	transfer := func(session *transaction.Session, from, to int64) error {
+		if err := session.Begin(); err != nil {
+			return err
+		}
+
+		table := txTable(t, session.Tx())
+
+		for _, move := range []struct {
+			id    int64
+			delta int
+		}{{from, -1}, {to, 1}} {
+			row, ok, err := table.Get(sql.IntegerKey(move.id))
+			if err != nil || !ok {
+				_ = session.Rollback()
+				return fmt.Errorf("get account %d: %v", move.id, err)
+			}
+
+			var value int
+			if _, err = fmt.Sscan(row[1].Raw().(string), &value); err != nil {
+				_ = session.Rollback()
+				return err
+			}
+
+			if err = table.Update(sql.IntegerKey(move.id), userRow(move.id, fmt.Sprint(value+move.delta))); err != nil {
+				_ = session.Rollback()
+				return err
+			}
+		}
+
+		return session.Commit()
+	}
+
+	total := func(session *transaction.Session) (int, error) {
+		if err := session.Begin(); err != nil {
+			return 0, err
+		}
+
+		defer func() { _ = session.Rollback() }()
+
+		sum := 0
+
+		for _, row := range scan(t, txTable(t, session.Tx()).Scan) {
+			var value int
+			if _, err := fmt.Sscan(row[1].Raw().(string), &value); err != nil {
+				return 0, err
+			}
+
+			sum += value
+		}
+
+		return sum, nil
+	}
+
+	var (
+		wg        sync.WaitGroup
+		committed sync.Map
+	)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			session := transaction.NewSession(manager)
+
+			for i := 0; i < transfers; i++ {
+				from := int64((w+i)%accounts + 1)
+				to := int64((w+2*i+1)%accounts + 1)
+
+				if from == to {
+					continue
+				}
+
+				err := transfer(session, from, to)
+				if errors.Is(err, transaction.ErrConflict) {
+					continue
+				}
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				committed.Store(fmt.Sprint(w, i), true)
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			session := transaction.NewSession(manager)
+
+			for i := 0; i < transfers; i++ {
+				sum, err := total(session)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, accounts*balance, sum)
+			}
+		}()
+	}
+
+	// Autocommit writes to other keys run alongside the transactions.
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		table := txTable(t, manager)
+
+		for id := int64(accounts + 1); id <= accounts+transfers; id++ {
+			assert.NoError(t, table.Insert(sql.IntegerKey(id), sql.Row{datatype.NewInteger(id), datatype.NewText("0")}))
+			assert.NoError(t, table.Delete(sql.IntegerKey(id)))
+		}
+	}()
+
+	wg.Wait()
+
+	count := 0
+
+	committed.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+
+	assert.Positive(t, count)
+
+	sum, err := total(transaction.NewSession(manager))
+	require.NoError(t, err)
+	assert.Equal(t, accounts*balance, sum)
+	assert.Len(t, scan(t, base.Scan), accounts)
+}
+
+func TestManager_Engines(t *testing.T) {
+	t.Parallel()
+
+	// The memory catalog has a single storage engine.
+	catalog, _ := newCatalog(t)
+
+	_, err := catalog.CreateDatabaseUsing("test", "lsm")
+	require.Error(t, err)
+
+	db, err := catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	_, err = db.(sql.EngineDatabase).CreateTableUsing("test", usersScheme(), "lsm")
+	require.Error(t, err)
+
+	_, err = db.GetTable("test")
+	require.Error(t, err)
+}
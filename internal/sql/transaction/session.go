@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Session holds the state of a client connection between statements: the open transaction, if any.
+// Session is not safe for concurrent use.
+type Session struct {
+	manager *Manager
+	tx      *Tx
+}
+
+func NewSession(manager *Manager) *Session {
+	return &Session{
+		manager: manager,
+	}
+}
+
+// Tx returns the open transaction or nil if statements run in autocommit mode.
+func (s *Session) Tx() *Tx {
+	return s.tx
+}
+
+// Begin opens a transaction.
+func (s *Session) Begin() error {
+	if s.tx != nil {
+		return errors.New("there is already a transaction in progress")
+	}
+
+	s.tx = s.manager.Begin()
+
+	return nil
+}
+
+// Commit commits the open transaction. The transaction is closed even if commit fails.
+func (s *Session) Commit() error {
+	if s.tx == nil {
+		return errors.New("there is no transaction in progress")
+	}
+
+	tx := s.tx
+	s.tx = nil
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction is rolled back: %w", err)
+	}
+
+	return nil
+}
+
+// Rollback discards the open transaction.
+func (s *Session) Rollback() error {
+	if s.tx == nil {
+		return errors.New("there is no transaction in progress")
+	}
+
+	s.tx.Rollback()
+	s.tx = nil
+
+	return nil
+}
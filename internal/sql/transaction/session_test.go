@@ -0,0 +1,70 @@
+package transaction_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	t.Run("begin and commit", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		session := transaction.NewSession(catalog)
+		assert.Nil(t, session.Tx())
+
+		require.NoError(t, session.Begin())
+		require.Error(t, session.Begin(), "transaction is already in progress")
+		require.NotNil(t, session.Tx())
+
+		require.NoError(t, txTable(t, session.Tx()).Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, session.Commit())
+
+		assert.Nil(t, session.Tx())
+		assert.Equal(t, []sql.Row{userRow(1, "bob")}, scan(t, base.Scan))
+	})
+
+	t.Run("begin and rollback", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		session := transaction.NewSession(catalog)
+
+		require.NoError(t, session.Begin())
+		require.NoError(t, txTable(t, session.Tx()).Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, session.Rollback())
+
+		assert.Nil(t, session.Tx())
+		assert.Empty(t, scan(t, base.Scan))
+	})
+
+	t.Run("failed commit closes transaction", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		session := transaction.NewSession(catalog)
+
+		require.NoError(t, session.Begin())
+		require.NoError(t, txTable(t, session.Tx()).Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "alice")))
+
+		require.Error(t, session.Commit())
+		assert.Nil(t, session.Tx())
+	})
+
+	t.Run("returns error without transaction", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, _ := newCatalog(t)
+		session := transaction.NewSession(catalog)
+		require.Error(t, session.Commit())
+		require.Error(t, session.Rollback())
+	})
+}
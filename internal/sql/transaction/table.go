@@ -0,0 +1,612 @@
+package transaction
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/index"
+)
+
+// write is the latest version of a row written by the transaction.
+type write struct {
+	// row is nil if the row is deleted.
+	row sql.Row
+	// existed reports whether the row existed in the snapshot when the transaction changed it for the first time.
+	existed bool
+	// seq orders writes of the transaction.
+	seq uint64
+}
+
+func (w write) deleted() bool {
+	return w.row == nil
+}
+
+// table is the view of a table inside a transaction: rows written by the transaction hide rows of the snapshot.
+// Written rows are also kept in transaction-local indexes, so index scans and uniqueness checks see them.
+type table struct {
+	tx      *Tx
+	name    tableName
+	base    sql.Table
+	writes  *btree.Tree[sql.Key, write]
+	indexes map[string]*index.Index
+	// primaryKey is positions of the primary key columns.
+	primaryKey []uint8
+}
+
+func newTable(tx *Tx, name tableName, base sql.Table) *table {
+	return &table{
+		tx:      tx,
+		name:    name,
+		base:    base,
+		writes:  btree.New[sql.Key, write](cmp.Compare[sql.Key]),
+		indexes: make(map[string]*index.Index),
+
+		primaryKey: sql.Positions(base.PrimaryKey()),
+	}
+}
+
+func (t *table) Name() string {
+	return t.base.Name()
+}
+
+func (t *table) Scheme() sql.Scheme {
+	return t.base.Scheme()
+}
+
+func (t *table) PrimaryKey() []sql.Column {
+	return t.base.PrimaryKey()
+}
+
+func (t *table) Sequence() sql.Sequence {
+	return tableSequence{table: t}
+}
+
+func (t *table) Scan() (sql.RowIter, error) {
+	return t.read("", "", t.base.Scan)
+}
+
+// ScanColumns is like Scan, but rows of the snapshot may have NULL in columns other than the requested ones.
+func (t *table) ScanColumns(positions []uint8) (sql.RowIter, error) {
+	return t.read("", "", func() (sql.RowIter, error) {
+		return scanColumns(t.base, positions)
+	})
+}
+
+func (t *table) Get(key sql.Key) (sql.Row, bool, error) {
+	if w, ok := t.writes.Get(key); ok {
+		return w.row, !w.deleted(), nil
+	}
+
+	m := t.tx.manager
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if row, ok := m.asOf(t.name, key, t.tx.snapshot); ok {
+		return row, row != nil, nil
+	}
+
+	return t.base.Get(key)
+}
+
+func (t *table) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	low, high, ok, err := sql.KeyRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	return t.read(low, high, func() (sql.RowIter, error) {
+		return t.base.ScanRange(from, to)
+	})
+}
+
+func (t *table) Insert(key sql.Key, row sql.Row) error {
+	_, ok, err := t.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return fmt.Errorf("duplicate primary key: %s", key)
+	}
+
+	if err = t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	t.put(key, row, false)
+
+	return nil
+}
+
+func (t *table) Delete(key sql.Key) error {
+	_, ok, err := t.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	t.put(key, nil, true)
+
+	return nil
+}
+
+func (t *table) Update(key sql.Key, row sql.Row) error {
+	_, ok, err := t.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err = t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	t.put(key, row, true)
+
+	return nil
+}
+
+func (t *table) Indexes() []sql.Index {
+	list := t.baseIndexes()
+	indexes := make([]sql.Index, 0, len(list))
+
+	for _, idx := range list {
+		indexes = append(indexes, &tableIndex{Index: idx, table: t})
+	}
+
+	return indexes
+}
+
+func (t *table) baseIndexes() []sql.Index {
+	t.tx.manager.mu.RLock()
+	defer t.tx.manager.mu.RUnlock()
+
+	return t.base.Indexes()
+}
+
+func (t *table) CreateIndex(string, []string, bool) (sql.Index, error) {
+	return nil, ErrSchemaChange
+}
+
+func (t *table) DropIndex(string) error {
+	return ErrSchemaChange
+}
+
+// read returns rows of the snapshot with keys in [low, high) together with rows written by the transaction,
+// ordered by the primary key. The empty high key is unbounded. The scan function returns the latest rows of the range,
+// they are read lazily, so reading a part of the range doesn't read the rest.
+func (t *table) read(low, high sql.Key, scan func() (sql.RowIter, error)) (sql.RowIter, error) {
+	iter, err := t.scan(scan)
+	if err != nil {
+		return nil, err
+	}
+
+	written := make(map[sql.Key]sql.Row)
+	writes := make([]sql.Key, 0)
+
+	t.writes.AscendGreaterOrEqual(low, func(key sql.Key, w write) bool {
+		if !sql.InRange(key, low, high) {
+			return false
+		}
+
+		written[key] = w.row
+
+		if !w.deleted() {
+			writes = append(writes, key)
+		}
+
+		return true
+	})
+
+	return &snapshotIter{
+		table:   t,
+		base:    iter,
+		low:     low,
+		high:    high,
+		written: written,
+		writes:  writes,
+	}, nil
+}
+
+// snapshot reads the latest rows with the scan function and returns the ones that are current in the snapshot
+// and not changed by the transaction. Rows of the snapshot that have been changed by later commits
+// are returned separately by their keys.
+func (t *table) snapshot(scan func() (sql.RowIter, error)) ([]sql.Row, map[sql.Key]sql.Row, error) {
+	iter, err := t.scan(scan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := t.collect(iter, func(sql.Key) bool { return true })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rows changed while they were read are in the history by now, so they are replaced by their versions.
+	m := t.tx.manager
+
+	m.mu.RLock()
+	changed := m.changedSince(t.name, t.tx.snapshot)
+	m.mu.RUnlock()
+
+	rows = slices.DeleteFunc(rows, func(row sql.Row) bool {
+		_, ok := changed[t.key(row)]
+
+		return ok
+	})
+
+	maps.DeleteFunc(changed, func(key sql.Key, row sql.Row) bool {
+		_, ok := t.writes.Get(key)
+
+		return ok || row == nil
+	})
+
+	return rows, changed, nil
+}
+
+// scan starts reading the latest rows with the scan function. Rows are read without holding the lock
+// of the manager, so writers aren't blocked by readers.
+func (t *table) scan(scan func() (sql.RowIter, error)) (sql.RowIter, error) {
+	t.tx.manager.mu.RLock()
+	defer t.tx.manager.mu.RUnlock()
+
+	return scan()
+}
+
+// put stores the row written by the transaction, a nil row marks the key as deleted.
+// existed tells whether the snapshot has the row, it's used only for the first write of the key.
+func (t *table) put(key sql.Key, row sql.Row, existed bool) {
+	if prev, ok := t.writes.Get(key); ok {
+		existed = prev.existed
+
+		if !prev.deleted() {
+			for _, idx := range t.indexes {
+				idx.Delete(key, prev.row)
+			}
+		}
+	}
+
+	if row != nil {
+		for _, idx := range t.indexes {
+			idx.Insert(key, row)
+		}
+	}
+
+	t.writes.Put(key, write{
+		row:     row,
+		existed: existed,
+		seq:     t.tx.nextSeq(),
+	})
+}
+
+// checkIndexes returns an error if the row violates a unique index of the table,
+// taking into account both the latest committed rows and rows written by the transaction.
+func (t *table) checkIndexes(key sql.Key, row sql.Row) error {
+	for _, base := range t.baseIndexes() {
+		if !base.Unique() {
+			continue
+		}
+
+		local, err := t.index(base)
+		if err != nil {
+			return err
+		}
+
+		if err = local.Check(key, row); err != nil {
+			return err
+		}
+
+		scheme := t.base.Scheme()
+		prefix := make([]sql.Value, 0, len(base.Columns()))
+
+		for _, column := range base.Columns() {
+			prefix = append(prefix, row[scheme[column].Position])
+		}
+
+		if slices.ContainsFunc(prefix, func(v sql.Value) bool { return v.DataType() == sql.Null }) {
+			continue
+		}
+
+		rows, err := t.committed(base, sql.IndexRange{Prefix: prefix})
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			if t.key(r) != key {
+				return fmt.Errorf("duplicate key value violates unique index %q", base.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+// index returns the transaction-local counterpart of the base index with all rows written so far.
+func (t *table) index(base sql.Index) (*index.Index, error) {
+	if idx, ok := t.indexes[base.Name()]; ok {
+		return idx, nil
+	}
+
+	idx, err := index.New(base.Name(), t.base.Scheme(), base.Columns(), base.Unique())
+	if err != nil {
+		return nil, fmt.Errorf("build index %q: %w", base.Name(), err)
+	}
+
+	t.writes.Ascend(func(key sql.Key, w write) bool {
+		if !w.deleted() {
+			idx.Insert(key, w.row)
+		}
+
+		return true
+	})
+
+	t.indexes[base.Name()] = idx
+
+	return idx, nil
+}
+
+// committed returns the latest rows of the base index in the range that the transaction didn't change.
+func (t *table) committed(base sql.Index, r sql.IndexRange) ([]sql.Row, error) {
+	iter, err := t.scan(func() (sql.RowIter, error) {
+		return base.Scan(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.collect(iter, func(sql.Key) bool { return true })
+}
+
+// collect reads and closes the iterator, keeping rows the transaction didn't change that match the filter.
+func (t *table) collect(iter sql.RowIter, filter func(key sql.Key) bool) ([]sql.Row, error) {
+	rows := make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Join(err, iter.Close())
+		}
+
+		key := t.key(row)
+
+		if _, ok := t.writes.Get(key); !ok && filter(key) {
+			rows = append(rows, row)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (t *table) key(row sql.Row) sql.Key {
+	key, _ := sql.RowKey(row, t.primaryKey)
+
+	return key
+}
+
+// snapshotIter merges the latest rows of a range with versions of rows changed since the snapshot and rows written
+// by the transaction, in the order of keys. The latest rows are read while other sessions change the table: a row
+// changed since the snapshot is in the history of the manager by the time it's read, so it's replaced by its version,
+// and a row deleted before the scan has reached it is taken from the history as well.
+type snapshotIter struct {
+	table     *table
+	base      sql.RowIter
+	low, high sql.Key
+	// written is rows of the range written by the transaction before the scan, nil rows are deleted.
+	written map[sql.Key]sql.Row
+	// writes is keys of written rows that aren't deleted, in order.
+	writes []sql.Key
+	// changed is rows of the snapshot changed by commits up to lastID, nil rows didn't exist.
+	changed map[sql.Key]sql.Row
+	lastID  uint64
+	// old is keys of changed rows of the range after the last returned one that existed in the snapshot, in order.
+	old []sql.Key
+	// next is the next latest row that is current in the snapshot, nil if it's not read yet.
+	next     sql.Row
+	nextKey  sql.Key
+	baseDone bool
+	last     sql.Key
+}
+
+func (i *snapshotIter) Next() (sql.Row, error) {
+	if err := i.fill(); err != nil {
+		return nil, err
+	}
+
+	key, row := i.nextKey, i.next
+
+	// A row read before it was changed is both the next row and an old one, the rows are the same then.
+	if len(i.old) > 0 && (row == nil || i.old[0] <= key) {
+		key, row = i.old[0], i.changed[i.old[0]]
+	}
+
+	if len(i.writes) > 0 && (row == nil || i.writes[0] < key) {
+		key, row = i.writes[0], i.written[i.writes[0]]
+	}
+
+	if row == nil {
+		return nil, io.EOF
+	}
+
+	if i.next != nil && i.nextKey == key {
+		i.next = nil
+	}
+
+	if len(i.old) > 0 && i.old[0] == key {
+		i.old = i.old[1:]
+	}
+
+	if len(i.writes) > 0 && i.writes[0] == key {
+		i.writes = i.writes[1:]
+	}
+
+	i.last = key
+
+	return row, nil
+}
+
+// fill reads the next latest row that is current in the snapshot and not written by the transaction.
+// The history is checked after the row is read, so it has all rows changed before that.
+func (i *snapshotIter) fill() error {
+	for i.next == nil && !i.baseDone {
+		row, err := i.base.Next()
+		if errors.Is(err, io.EOF) {
+			i.baseDone = true
+
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		key := i.table.key(row)
+
+		if _, ok := i.written[key]; ok {
+			continue
+		}
+
+		i.refresh()
+
+		if _, ok := i.changed[key]; !ok {
+			i.next, i.nextKey = row, key
+		}
+	}
+
+	i.refresh()
+
+	return nil
+}
+
+// refresh reads rows changed since the snapshot again if there have been commits since the last read.
+func (i *snapshotIter) refresh() {
+	m := i.table.tx.manager
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if i.changed != nil && i.lastID == m.lastID {
+		return
+	}
+
+	i.changed = m.changedSince(i.table.name, i.table.tx.snapshot)
+	i.lastID = m.lastID
+	i.old = i.old[:0]
+
+	for key, row := range i.changed {
+		if _, ok := i.written[key]; !ok && row != nil && key > i.last && sql.InRange(key, i.low, i.high) {
+			i.old = append(i.old, key)
+		}
+	}
+
+	slices.Sort(i.old)
+}
+
+func (i *snapshotIter) Close() error {
+	return i.base.Close()
+}
+
+// scanColumns reads the columns of the table if it supports reading a part of columns, otherwise it reads whole rows.
+func scanColumns(table sql.Table, positions []uint8) (sql.RowIter, error) {
+	if scanner, ok := table.(sql.ColumnScanner); ok {
+		return scanner.ScanColumns(positions)
+	}
+
+	return table.Scan()
+}
+
+// tableSequence is the sequence of the table inside a transaction. Keys inserted by the transaction reach the table
+// only on commit, so the sequence skips them by itself. Keys are taken from the table and are never given back
+// by a rollback: a rolled back insert always leaves a gap, whatever other sessions do in the meantime.
+type tableSequence struct {
+	table *table
+}
+
+func (s tableSequence) Next() int64 {
+	base := s.table.base.Sequence()
+
+	for {
+		value := base.Next()
+
+		if _, ok := s.table.writes.Get(sql.IntegerKey(value)); !ok {
+			return value
+		}
+	}
+}
+
+func (s tableSequence) Release(value int64) {
+	s.table.base.Sequence().Release(value)
+}
+
+// tableIndex is the view of an index inside a transaction.
+type tableIndex struct {
+	sql.Index
+	table *table
+}
+
+// Scan returns rows of the snapshot in the range that the transaction didn't change together with rows
+// of the range written by the transaction, in the index order.
+func (i *tableIndex) Scan(r sql.IndexRange) (sql.RowIter, error) {
+	local, err := i.table.index(i.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, old, err := i.table.snapshot(func() (sql.RowIter, error) {
+		return i.Index.Scan(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(rows)
+
+	for _, row := range old {
+		if local.Match(r, row) {
+			rows = append(rows, row)
+		}
+	}
+
+	keys, err := local.Keys(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		w, _ := i.table.writes.Get(key)
+		rows = append(rows, w.row)
+	}
+
+	if len(rows) > n {
+		slices.SortFunc(rows, func(a, b sql.Row) int {
+			return local.Compare(a, i.table.key(a), b, i.table.key(b))
+		})
+	}
+
+	return sql.RowsIter(rows...), nil
+}
@@ -0,0 +1,202 @@
+// Package transaction implements multi-statement transactions on top of any sql.Catalog.
+//
+// A transaction reads a consistent snapshot of the catalog and buffers its own changes of rows in memory,
+// so changes are visible only inside the transaction until it's committed. Commit applies the buffered
+// changes to the tables of the underlying catalog at once, rollback drops them. See Manager for details.
+package transaction
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// ErrSchemaChange is returned on an attempt to create or drop a database, a table or an index inside a transaction.
+var ErrSchemaChange = errors.New("schema can't be changed inside a transaction")
+
+// tableName identifies a table of the catalog.
+type tableName struct {
+	database string
+	table    string
+}
+
+// Tx is a transaction. It implements sql.Catalog, all reads done through it see the snapshot
+// the transaction has started with and changes of the transaction. Tx is not safe for concurrent use.
+type Tx struct {
+	manager  *Manager
+	snapshot uint64
+	tables   map[tableName]*table
+	seq      uint64
+	closed   bool
+}
+
+func newTx(manager *Manager, snapshot uint64) *Tx {
+	return &Tx{
+		manager:  manager,
+		snapshot: snapshot,
+		tables:   make(map[tableName]*table),
+	}
+}
+
+func (tx *Tx) GetDatabase(name string) (sql.Database, error) {
+	tx.manager.mu.RLock()
+	defer tx.manager.mu.RUnlock()
+
+	db, err := tx.manager.catalog.GetDatabase(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{tx: tx, base: db}, nil
+}
+
+func (tx *Tx) ListDatabases() ([]sql.Database, error) {
+	tx.manager.mu.RLock()
+	defer tx.manager.mu.RUnlock()
+
+	list, err := tx.manager.catalog.ListDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make([]sql.Database, 0, len(list))
+
+	for _, db := range list {
+		databases = append(databases, &database{tx: tx, base: db})
+	}
+
+	return databases, nil
+}
+
+func (tx *Tx) CreateDatabase(string) (sql.Database, error) {
+	return nil, ErrSchemaChange
+}
+
+func (tx *Tx) CreateDatabaseUsing(string, string) (sql.Database, error) {
+	return nil, ErrSchemaChange
+}
+
+func (tx *Tx) DropDatabase(string) error {
+	return ErrSchemaChange
+}
+
+// Commit applies changes of the transaction to the catalog. Deletes go first, then inserts and updates
+// in the order they were made. ErrConflict is returned if another transaction has changed any of the rows
+// since the snapshot was taken. If any change fails, the applied ones are reverted and the error is returned.
+// The transaction is closed in any case.
+func (tx *Tx) Commit() error {
+	if tx.closed {
+		return errors.New("transaction is closed")
+	}
+
+	changes := make([]change, 0)
+
+	for name, t := range tx.tables {
+		t.writes.Ascend(func(key sql.Key, w write) bool {
+			changes = append(changes, change{name: name, table: t.base, key: key, write: w})
+
+			return true
+		})
+	}
+
+	slices.SortFunc(changes, func(a, b change) int {
+		if a.write.deleted() != b.write.deleted() {
+			if a.write.deleted() {
+				return -1
+			}
+
+			return 1
+		}
+
+		return cmp.Compare(a.write.seq, b.write.seq)
+	})
+
+	tx.close()
+
+	return tx.manager.commit(tx.snapshot, changes)
+}
+
+// Rollback discards changes of the transaction and closes it.
+func (tx *Tx) Rollback() {
+	if tx.closed {
+		return
+	}
+
+	tx.close()
+	tx.manager.rollback(tx.snapshot)
+}
+
+func (tx *Tx) close() {
+	tx.closed = true
+	tx.tables = make(map[tableName]*table)
+}
+
+// table returns the view of the table inside the transaction.
+func (tx *Tx) table(database string, base sql.Table) *table {
+	name := tableName{database: database, table: base.Name()}
+
+	if t, ok := tx.tables[name]; ok && t.base == base {
+		return t
+	}
+
+	t := newTable(tx, name, base)
+	tx.tables[name] = t
+
+	return t
+}
+
+// nextSeq returns the sequence number of the next write of the transaction.
+func (tx *Tx) nextSeq() uint64 {
+	tx.seq++
+
+	return tx.seq
+}
+
+// change is a pending write of a row.
+type change struct {
+	name  tableName
+	table sql.Table
+	key   sql.Key
+	write write
+}
+
+// apply writes the change to the table. It returns the replaced row and the function that reverts the change,
+// the function is nil if the change is a no-op.
+func (c change) apply() (sql.Row, func() error, error) {
+	switch {
+	case c.write.deleted() && !c.write.existed:
+		return nil, nil, nil
+	case !c.write.existed:
+		if err := c.table.Insert(c.key, c.write.row); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, func() error { return c.table.Delete(c.key) }, nil
+	}
+
+	before, ok, err := c.table.Get(c.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !ok {
+		return nil, nil, fmt.Errorf("row with key %s not found", c.key)
+	}
+
+	if c.write.deleted() {
+		if err = c.table.Delete(c.key); err != nil {
+			return nil, nil, err
+		}
+
+		return before, func() error { return c.table.Insert(c.key, before) }, nil
+	}
+
+	if err = c.table.Update(c.key, c.write.row); err != nil {
+		return nil, nil, err
+	}
+
+	return before, func() error { return c.table.Update(c.key, before) }, nil
+}
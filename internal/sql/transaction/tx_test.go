@@ -0,0 +1,589 @@
+package transaction_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/transaction"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/memory"
+)
+
+func TestTx_Isolation(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+	require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+
+	require.NoError(t, table.Insert(sql.IntegerKey(3), userRow(3, "tom")))
+	require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "robert")))
+	require.NoError(t, table.Delete(sql.IntegerKey(2)))
+
+	assert.Equal(t, []sql.Row{userRow(1, "robert"), userRow(3, "tom")}, scan(t, table.Scan))
+	assert.Equal(t, []sql.Row{userRow(1, "bob"), userRow(2, "alice")}, scan(t, base.Scan))
+
+	row, ok, err := table.Get(sql.IntegerKey(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+	assert.Nil(t, row)
+
+	row, ok, err = table.Get(sql.IntegerKey(3))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, userRow(3, "tom"), row)
+
+	rows := scan(t, func() (sql.RowIter, error) {
+		return table.ScanRange(&sql.Bound{Value: datatype.NewInteger(2), Inclusive: true}, nil)
+	})
+	assert.Equal(t, []sql.Row{userRow(3, "tom")}, rows)
+
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []sql.Row{userRow(1, "robert"), userRow(3, "tom")}, scan(t, base.Scan))
+}
+
+func TestTx_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+	require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+	_, err := base.CreateIndex("users_name", []string{"name"}, false)
+	require.NoError(t, err)
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+	initial := []sql.Row{userRow(1, "bob"), userRow(2, "alice")}
+
+	assert.Equal(t, initial, scan(t, table.Scan))
+
+	autocommit := txTable(t, catalog)
+	require.NoError(t, autocommit.Update(sql.IntegerKey(1), userRow(1, "robert")))
+	require.NoError(t, autocommit.Delete(sql.IntegerKey(2)))
+	require.NoError(t, autocommit.Insert(sql.IntegerKey(3), userRow(3, "tom")))
+	require.NoError(t, autocommit.Update(sql.IntegerKey(3), userRow(3, "bob")))
+
+	latest := []sql.Row{userRow(1, "robert"), userRow(3, "bob")}
+	assert.Equal(t, latest, scan(t, autocommit.Scan))
+
+	assert.Equal(t, initial, scan(t, table.Scan))
+
+	rows := scan(t, func() (sql.RowIter, error) {
+		return table.ScanRange(&sql.Bound{Value: datatype.NewInteger(2), Inclusive: true}, nil)
+	})
+	assert.Equal(t, []sql.Row{userRow(2, "alice")}, rows)
+
+	row, ok, err := table.Get(sql.IntegerKey(1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, userRow(1, "bob"), row)
+
+	_, ok, err = table.Get(sql.IntegerKey(3))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	indexes := table.Indexes()
+	require.Len(t, indexes, 1)
+
+	rows = scan(t, func() (sql.RowIter, error) {
+		return indexes[0].Scan(sql.IndexRange{Prefix: []sql.Value{datatype.NewText("bob")}})
+	})
+	assert.Equal(t, []sql.Row{userRow(1, "bob")}, rows)
+
+	next := catalog.Begin()
+	assert.Equal(t, latest, scan(t, txTable(t, next).Scan))
+
+	tx.Rollback()
+	next.Rollback()
+}
+
+func TestTx_ConcurrentScan(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+
+	initial := make([]sql.Row, 0, 300)
+
+	for id := int64(1); id <= 300; id++ {
+		require.NoError(t, base.Insert(sql.IntegerKey(id), userRow(id, "bob")))
+		initial = append(initial, userRow(id, "bob"))
+	}
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+	require.NoError(t, table.Update(sql.IntegerKey(150), userRow(150, "tom")))
+
+	iter, err := table.Scan()
+	require.NoError(t, err)
+
+	row, err := iter.Next()
+	require.NoError(t, err)
+	require.Equal(t, userRow(1, "bob"), row)
+
+	// Rows are changed by other sessions and by the transaction itself while the scan is running.
+	autocommit := txTable(t, catalog)
+	require.NoError(t, autocommit.Delete(sql.IntegerKey(1)))
+	require.NoError(t, autocommit.Update(sql.IntegerKey(2), userRow(2, "alice")))
+	require.NoError(t, autocommit.Delete(sql.IntegerKey(200)))
+	require.NoError(t, autocommit.Update(sql.IntegerKey(250), userRow(250, "alice")))
+	require.NoError(t, autocommit.Insert(sql.IntegerKey(350), userRow(350, "alice")))
+	require.NoError(t, table.Insert(sql.IntegerKey(400), userRow(400, "tom")))
+
+	rows := []sql.Row{row}
+
+	for {
+		row, err = iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	initial[149] = userRow(150, "tom")
+	assert.Equal(t, initial, rows)
+
+	tx.Rollback()
+}
+
+func TestTx_LazyScan(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+
+	for id := int64(1); id <= 300; id++ {
+		require.NoError(t, base.Insert(sql.IntegerKey(id), userRow(id, "bob")))
+	}
+
+	tx := catalog.Begin()
+	defer tx.Rollback()
+
+	iter, err := txTable(t, tx).Scan()
+	require.NoError(t, err)
+
+	_, err = iter.Next()
+	require.NoError(t, err)
+
+	// The table is changed behind the manager: the scan shows the change, because it hasn't read the rows yet.
+	require.NoError(t, base.Delete(sql.IntegerKey(300)))
+
+	rows := 1
+
+	for {
+		_, err = iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows++
+	}
+
+	require.NoError(t, iter.Close())
+	assert.Equal(t, 299, rows)
+}
+
+func TestTx_Conflict(t *testing.T) {
+	t.Parallel()
+
+	t.Run("first committer wins", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+
+		first := catalog.Begin()
+		second := catalog.Begin()
+
+		require.NoError(t, txTable(t, first).Update(sql.IntegerKey(1), userRow(1, "robert")))
+		require.NoError(t, txTable(t, second).Delete(sql.IntegerKey(1)))
+
+		require.NoError(t, first.Commit())
+		require.ErrorIs(t, second.Commit(), transaction.ErrConflict)
+
+		assert.Equal(t, []sql.Row{userRow(1, "robert")}, scan(t, base.Scan))
+	})
+
+	t.Run("write outside transaction", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+		tx := catalog.Begin()
+		require.NoError(t, txTable(t, tx).Update(sql.IntegerKey(2), userRow(2, "carol")))
+		require.NoError(t, txTable(t, tx).Update(sql.IntegerKey(1), userRow(1, "robert")))
+
+		require.NoError(t, txTable(t, catalog).Update(sql.IntegerKey(1), userRow(1, "tom")))
+
+		require.ErrorIs(t, tx.Commit(), transaction.ErrConflict)
+		assert.Equal(t, []sql.Row{userRow(1, "tom"), userRow(2, "alice")}, scan(t, base.Scan))
+	})
+
+	t.Run("disjoint rows", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+		first := catalog.Begin()
+		second := catalog.Begin()
+
+		require.NoError(t, txTable(t, first).Update(sql.IntegerKey(1), userRow(1, "robert")))
+		require.NoError(t, txTable(t, second).Update(sql.IntegerKey(2), userRow(2, "carol")))
+
+		require.NoError(t, first.Commit())
+		require.NoError(t, second.Commit())
+
+		assert.Equal(t, []sql.Row{userRow(1, "robert"), userRow(2, "carol")}, scan(t, base.Scan))
+	})
+}
+
+func TestTx_Rollback(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+
+	require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+	require.NoError(t, table.Delete(sql.IntegerKey(1)))
+
+	tx.Rollback()
+
+	assert.Equal(t, []sql.Row{userRow(1, "bob")}, scan(t, base.Scan))
+	require.Error(t, tx.Commit(), "transaction is closed")
+}
+
+func TestTx_KeySequence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("skips keys inserted by transaction", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+
+		tx := catalog.Begin()
+		table := txTable(t, tx)
+
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+		assert.Equal(t, int64(3), table.Sequence().Next())
+
+		require.NoError(t, tx.Commit())
+		assert.Equal(t, int64(4), base.Sequence().Next())
+	})
+
+	t.Run("rolled back keys are not reused", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+
+		tx := catalog.Begin()
+		table := txTable(t, tx)
+
+		key := table.Sequence().Next()
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, "alice")))
+		require.NoError(t, table.Insert(sql.IntegerKey(5), userRow(5, "tom")))
+
+		tx.Rollback()
+
+		assert.Equal(t, int64(2), base.Sequence().Next(), "explicit keys of rolled back transaction are forgotten")
+	})
+}
+
+func TestTx_Writes(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+
+	require.Error(t, table.Insert(sql.IntegerKey(1), userRow(1, "alice")), "duplicate primary key")
+	require.Error(t, table.Update(sql.IntegerKey(2), userRow(2, "alice")), "row not found")
+
+	require.NoError(t, table.Delete(sql.IntegerKey(1)))
+	require.Error(t, table.Delete(sql.IntegerKey(1)), "row is already deleted")
+	require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "alice")), "key of deleted row is free")
+
+	require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "tom")))
+	require.NoError(t, table.Delete(sql.IntegerKey(2)))
+
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []sql.Row{userRow(1, "alice")}, scan(t, base.Scan))
+}
+
+func TestTx_Indexes(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+	require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+	_, err := base.CreateIndex("users_name", []string{"name"}, true)
+	require.NoError(t, err)
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+
+	require.Error(t, table.Insert(sql.IntegerKey(3), userRow(3, "bob")), "committed row has the same name")
+	require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "robert")))
+	require.NoError(t, table.Insert(sql.IntegerKey(3), userRow(3, "bob")), "name is freed by the transaction")
+	require.Error(t, table.Insert(sql.IntegerKey(4), userRow(4, "bob")), "row of the transaction has the same name")
+
+	indexes := table.Indexes()
+	require.Len(t, indexes, 1)
+
+	rows := scan(t, func() (sql.RowIter, error) {
+		return indexes[0].Scan(sql.IndexRange{Prefix: []sql.Value{datatype.NewText("bob")}})
+	})
+	assert.Equal(t, []sql.Row{userRow(3, "bob")}, rows)
+
+	rows = scan(t, func() (sql.RowIter, error) {
+		return indexes[0].Scan(sql.IndexRange{})
+	})
+	assert.Equal(t, []sql.Row{userRow(2, "alice"), userRow(3, "bob"), userRow(1, "robert")}, rows, "rows come in the index order")
+
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []sql.Row{userRow(1, "robert"), userRow(2, "alice"), userRow(3, "bob")}, scan(t, base.Scan))
+}
+
+func TestTx_Commit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applies deletes before inserts", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+
+		_, err := base.CreateIndex("users_name", []string{"name"}, true)
+		require.NoError(t, err)
+
+		tx := catalog.Begin()
+		table := txTable(t, tx)
+
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "tom")))
+		require.NoError(t, table.Delete(sql.IntegerKey(1)))
+		require.NoError(t, table.Update(sql.IntegerKey(2), userRow(2, "bob")))
+
+		require.NoError(t, tx.Commit())
+		assert.Equal(t, []sql.Row{userRow(2, "bob")}, scan(t, base.Scan))
+	})
+
+	t.Run("reverts applied changes on error", func(t *testing.T) {
+		t.Parallel()
+
+		catalog, base := newCatalog(t)
+		require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+		require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+		tx := catalog.Begin()
+		table := txTable(t, tx)
+
+		require.NoError(t, table.Delete(sql.IntegerKey(2)))
+		require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "robert")))
+		require.NoError(t, table.Insert(sql.IntegerKey(3), userRow(3, "tom")))
+
+		require.NoError(t, base.Insert(sql.IntegerKey(3), userRow(3, "carol")), "concurrent insert of the same key")
+
+		require.Error(t, tx.Commit())
+		assert.Equal(t, []sql.Row{userRow(1, "bob"), userRow(2, "alice"), userRow(3, "carol")}, scan(t, base.Scan))
+	})
+}
+
+func TestTx_SchemaChange(t *testing.T) {
+	t.Parallel()
+
+	catalog, _ := newCatalog(t)
+	tx := catalog.Begin()
+
+	_, err := tx.CreateDatabase("test")
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+	require.ErrorIs(t, tx.DropDatabase("playground"), transaction.ErrSchemaChange)
+
+	_, err = tx.CreateDatabaseUsing("test", "lsm")
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+
+	db, err := tx.GetDatabase("playground")
+	require.NoError(t, err)
+
+	_, err = db.CreateTable("test", usersScheme())
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+
+	_, err = db.(sql.EngineDatabase).CreateTableUsing("test", usersScheme(), "lsm")
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+	require.ErrorIs(t, db.DropTable("users"), transaction.ErrSchemaChange)
+
+	table := txTable(t, tx)
+
+	_, err = table.CreateIndex("users_name", []string{"name"}, false)
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+	require.ErrorIs(t, table.DropIndex("users_name"), transaction.ErrSchemaChange)
+
+	_, err = db.CreateSequence("users_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+	require.ErrorIs(t, err, transaction.ErrSchemaChange)
+	require.ErrorIs(t, db.DropSequence("users_seq"), transaction.ErrSchemaChange)
+}
+
+func TestTx_Sequences(t *testing.T) {
+	t.Parallel()
+
+	catalog, _ := newCatalog(t)
+
+	db, err := catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	_, err = db.CreateSequence("users_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+	require.NoError(t, err)
+
+	tx := catalog.Begin()
+
+	txDB, err := tx.GetDatabase("playground")
+	require.NoError(t, err)
+
+	sequence, err := txDB.GetSequence("users_seq")
+	require.NoError(t, err)
+	require.Len(t, txDB.ListSequences(), 1)
+
+	value, err := sequence.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+	tx.Rollback()
+
+	// Values taken by a transaction are not given back by the rollback.
+	sequence, err = db.GetSequence("users_seq")
+	require.NoError(t, err)
+
+	value, err = sequence.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+
+	require.NoError(t, db.DropSequence("users_seq"))
+	assert.Empty(t, db.ListSequences())
+}
+
+// newCatalog returns the manager of a catalog with the users table and the table itself.
+func newCatalog(t *testing.T) (*transaction.Manager, sql.Table) {
+	t.Helper()
+
+	catalog := memory.NewCatalog()
+
+	db, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := db.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	return transaction.NewManager(catalog), table
+}
+
+func txTable(t *testing.T, catalog sql.Catalog) sql.Table {
+	t.Helper()
+
+	db, err := catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := db.GetTable("users")
+	require.NoError(t, err)
+
+	return table
+}
+
+func scan(t *testing.T, scanFn func() (sql.RowIter, error)) []sql.Row {
+	t.Helper()
+
+	iter, err := scanFn()
+	require.NoError(t, err)
+
+	rows := make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	return rows
+}
+
+func usersScheme() sql.Scheme {
+	return sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+}
+
+func userRow(id int64, name string) sql.Row {
+	return sql.Row{
+		datatype.NewInteger(id),
+		datatype.NewText(name),
+	}
+}
+
+func TestTx_ScanColumns(t *testing.T) {
+	t.Parallel()
+
+	catalog, base := newCatalog(t)
+	require.NoError(t, base.Insert(sql.IntegerKey(1), userRow(1, "bob")))
+	require.NoError(t, base.Insert(sql.IntegerKey(2), userRow(2, "alice")))
+
+	tx := catalog.Begin()
+	table := txTable(t, tx)
+	require.NoError(t, table.Update(sql.IntegerKey(2), userRow(2, "tom")))
+
+	// The memory table doesn't store columns separately, so whole rows are read.
+	scanner, ok := table.(sql.ColumnScanner)
+	require.True(t, ok)
+
+	rows := scan(t, func() (sql.RowIter, error) {
+		return scanner.ScanColumns([]uint8{1})
+	})
+	assert.Equal(t, []sql.Row{userRow(1, "bob"), userRow(2, "tom")}, rows)
+
+	scanner, ok = txTable(t, catalog).(sql.ColumnScanner)
+	require.True(t, ok)
+
+	rows = scan(t, func() (sql.RowIter, error) {
+		return scanner.ScanColumns([]uint8{1})
+	})
+	assert.Equal(t, []sql.Row{userRow(1, "bob"), userRow(2, "alice")}, rows)
+
+	tx.Rollback()
+}
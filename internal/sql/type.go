@@ -1,8 +1,21 @@
 package sql
 
+import (
+	"cmp"
+	"slices"
+)
+
 //go:generate go run go.uber.org/mock/mockgen -typed -source=type.go -destination ./type_mock.go -package sql
 
 // Catalog holds meta-information about databases.
+//
+// Concurrency contract: Catalog, Database, Table and Index implementations of a storage engine are safe for
+// concurrent use by multiple goroutines, including DDL running alongside DML. Every method call is atomic on its
+// own; consistency across several calls is provided by transactions, not by the storage. A Database or Table
+// obtained before it was dropped may still be used but is no longer reachable from the catalog. Iterators are
+// owned by the caller and are not safe for concurrent use; whether an open iterator observes later changes is up to
+// the engine. Rows returned by a table must not be modified, and rows passed to Insert and Update must not be
+// modified afterwards.
 type Catalog interface {
 	GetDatabase(name string) (Database, error)
 	ListDatabases() ([]Database, error)
@@ -17,28 +30,138 @@ type Database interface {
 	ListTables() []Table
 	CreateTable(name string, scheme Scheme) (Table, error)
 	DropTable(name string) error
+	GetSequence(name string) (NamedSequence, error)
+	ListSequences() []NamedSequence
+	CreateSequence(name string, options SequenceOptions) (NamedSequence, error)
+	DropSequence(name string) error
+	// Statistics returns statistics of the table collected by ANALYZE, false if the table has never been analyzed.
+	Statistics(table string) (Statistics, bool)
+	// SetStatistics replaces statistics of the table, they are dropped together with the table.
+	SetStatistics(table string, statistics Statistics) error
+}
+
+// EngineCatalog is implemented by catalogs that offer several storage engines.
+// The engine of a database is the default one for its tables, an empty name selects the default of the catalog.
+type EngineCatalog interface {
+	CreateDatabaseUsing(name, engine string) (Database, error)
+}
+
+// EngineDatabase is implemented by databases that offer several storage engines.
+// An empty name selects the engine of the database.
+type EngineDatabase interface {
+	CreateTableUsing(name string, scheme Scheme, engine string) (Table, error)
 }
 
 // Table represents the backend of an SQL table.
 type Table interface {
 	Name() string
 	Scheme() Scheme
-	PrimaryKey() Column
+	// PrimaryKey returns columns of the primary key in the key order.
+	PrimaryKey() []Column
 	Sequence() Sequence
 	Scan() (RowIter, error)
-	Insert(key int64, row Row) error
-	Delete(key int64) error
-	Update(key int64, row Row) error
+	// Get returns the row with the primary key, false is returned if there is no such row.
+	Get(key Key) (Row, bool, error)
+	// ScanRange returns rows in the key order whose leading primary key column lies between the bounds,
+	// a nil bound is unbounded.
+	ScanRange(from, to *Bound) (RowIter, error)
+	Insert(key Key, row Row) error
+	Delete(key Key) error
+	Update(key Key, row Row) error
+	Indexes() []Index
+	CreateIndex(name string, columns []string, unique bool) (Index, error)
+	DropIndex(name string) error
+}
+
+// ColumnScanner is implemented by tables that store columns separately, so reading a part of columns
+// is cheaper than reading whole rows.
+type ColumnScanner interface {
+	// ScanColumns returns rows with values of the columns at the positions and of the primary key,
+	// other values of rows are NULL.
+	ScanColumns(positions []uint8) (RowIter, error)
+}
+
+// Index is a secondary index over one or more columns of a table.
+type Index interface {
+	Name() string
+	Columns() []string
+	Unique() bool
+	// Scan returns rows of the range in the index order: by values of the columns with NULL first,
+	// then by the primary key.
+	Scan(r IndexRange) (RowIter, error)
+}
+
+// IndexRange selects rows of an index: the leading columns are equal to Prefix,
+// the next column lies between From and To. A nil bound means the range is unbounded on that side.
+type IndexRange struct {
+	Prefix []Value
+	From   *Bound
+	To     *Bound
+}
+
+// Bound is an end of a range.
+type Bound struct {
+	Value     Value
+	Inclusive bool
 }
 
-// Sequence returns a sequentially increasing value every time you call Next.
+// Sequence assigns the single integer primary key of rows inserted without one. A key is taken right before
+// the row is stored and given back with Release if storing fails, so only stored rows consume keys.
 type Sequence interface {
+	// Next advances the sequence and returns the new value.
 	Next() int64
+	// Release gives the value back if it's the last one returned by Next and no row has been stored since then,
+	// otherwise the value is skipped.
+	Release(value int64)
+}
+
+// NamedSequence is a sequence created by CREATE SEQUENCE. Sequences are not transactional:
+// a value handed out by Next is never given back, even if the transaction that took it rolls back.
+type NamedSequence interface {
+	Name() string
+	Options() SequenceOptions
+	// Next advances the sequence and returns the new value.
+	Next() (int64, error)
+	// Current returns the value last returned by Next or set by SetValue, false if there is no such value yet.
+	Current() (int64, bool)
+	// SetValue makes the value the current one, so Next returns the value plus the increment.
+	SetValue(value int64) error
 }
 
 // Scheme is the definition of a table (column-name => definition).
 type Scheme map[string]Column
 
+// PrimaryKey returns columns of the primary key in the key order.
+func (s Scheme) PrimaryKey() []Column {
+	columns := make([]Column, 0, 1)
+
+	for name := range s {
+		if s[name].PrimaryKey {
+			columns = append(columns, s[name])
+		}
+	}
+
+	slices.SortFunc(columns, func(a, b Column) int {
+		return cmp.Compare(a.KeyPosition, b.KeyPosition)
+	})
+
+	return columns
+}
+
+// Positions returns positions of the columns in a row.
+func Positions(columns []Column) []uint8 {
+	positions := make([]uint8, len(columns))
+
+	for i := range columns {
+		positions[i] = columns[i].Position
+	}
+
+	return positions
+}
+
+// RowID is the name of the hidden primary key of tables defined without a primary key.
+const RowID = "rowid"
+
 // Column is the definition of a table column.
 type Column struct {
 	Position   uint8
@@ -47,6 +170,14 @@ type Column struct {
 	PrimaryKey bool
 	Nullable   bool
 	Default    Value
+	// KeyPosition is the position of the column within the primary key, it matters only for primary key columns.
+	KeyPosition uint8
+	// Hidden columns are left out of SELECT * and table descriptions, values of them are assigned by the database.
+	Hidden bool
+	// Sequence is the named sequence whose next value is the default of the column, as in DEFAULT nextval('name').
+	Sequence string
+	// Identity columns take values only from their sequence, as GENERATED ALWAYS AS IDENTITY columns do.
+	Identity bool
 }
 
 type CompareType int
@@ -216,6 +216,45 @@ func (m *MockDatabase) EXPECT() *MockDatabaseMockRecorder {
 	return m.recorder
 }
 
+// CreateSequence mocks base method.
+func (m *MockDatabase) CreateSequence(name string, options SequenceOptions) (NamedSequence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSequence", name, options)
+	ret0, _ := ret[0].(NamedSequence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSequence indicates an expected call of CreateSequence.
+func (mr *MockDatabaseMockRecorder) CreateSequence(name, options any) *MockDatabaseCreateSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSequence", reflect.TypeOf((*MockDatabase)(nil).CreateSequence), name, options)
+	return &MockDatabaseCreateSequenceCall{Call: call}
+}
+
+// MockDatabaseCreateSequenceCall wrap *gomock.Call
+type MockDatabaseCreateSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDatabaseCreateSequenceCall) Return(arg0 NamedSequence, arg1 error) *MockDatabaseCreateSequenceCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDatabaseCreateSequenceCall) Do(f func(string, SequenceOptions) (NamedSequence, error)) *MockDatabaseCreateSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDatabaseCreateSequenceCall) DoAndReturn(f func(string, SequenceOptions) (NamedSequence, error)) *MockDatabaseCreateSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CreateTable mocks base method.
 func (m *MockDatabase) CreateTable(name string, scheme Scheme) (Table, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +294,44 @@ func (c *MockDatabaseCreateTableCall) DoAndReturn(f func(string, Scheme) (Table,
 	return c
 }
 
+// DropSequence mocks base method.
+func (m *MockDatabase) DropSequence(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropSequence", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropSequence indicates an expected call of DropSequence.
+func (mr *MockDatabaseMockRecorder) DropSequence(name any) *MockDatabaseDropSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropSequence", reflect.TypeOf((*MockDatabase)(nil).DropSequence), name)
+	return &MockDatabaseDropSequenceCall{Call: call}
+}
+
+// MockDatabaseDropSequenceCall wrap *gomock.Call
+type MockDatabaseDropSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDatabaseDropSequenceCall) Return(arg0 error) *MockDatabaseDropSequenceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDatabaseDropSequenceCall) Do(f func(string) error) *MockDatabaseDropSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDatabaseDropSequenceCall) DoAndReturn(f func(string) error) *MockDatabaseDropSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // DropTable mocks base method.
 func (m *MockDatabase) DropTable(name string) error {
 	m.ctrl.T.Helper()
@@ -293,6 +370,45 @@ func (c *MockDatabaseDropTableCall) DoAndReturn(f func(string) error) *MockDatab
 	return c
 }
 
+// GetSequence mocks base method.
+func (m *MockDatabase) GetSequence(name string) (NamedSequence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSequence", name)
+	ret0, _ := ret[0].(NamedSequence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSequence indicates an expected call of GetSequence.
+func (mr *MockDatabaseMockRecorder) GetSequence(name any) *MockDatabaseGetSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSequence", reflect.TypeOf((*MockDatabase)(nil).GetSequence), name)
+	return &MockDatabaseGetSequenceCall{Call: call}
+}
+
+// MockDatabaseGetSequenceCall wrap *gomock.Call
+type MockDatabaseGetSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDatabaseGetSequenceCall) Return(arg0 NamedSequence, arg1 error) *MockDatabaseGetSequenceCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDatabaseGetSequenceCall) Do(f func(string) (NamedSequence, error)) *MockDatabaseGetSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDatabaseGetSequenceCall) DoAndReturn(f func(string) (NamedSequence, error)) *MockDatabaseGetSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetTable mocks base method.
 func (m *MockDatabase) GetTable(name string) (Table, error) {
 	m.ctrl.T.Helper()
@@ -332,6 +448,44 @@ func (c *MockDatabaseGetTableCall) DoAndReturn(f func(string) (Table, error)) *M
 	return c
 }
 
+// ListSequences mocks base method.
+func (m *MockDatabase) ListSequences() []NamedSequence {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSequences")
+	ret0, _ := ret[0].([]NamedSequence)
+	return ret0
+}
+
+// ListSequences indicates an expected call of ListSequences.
+func (mr *MockDatabaseMockRecorder) ListSequences() *MockDatabaseListSequencesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSequences", reflect.TypeOf((*MockDatabase)(nil).ListSequences))
+	return &MockDatabaseListSequencesCall{Call: call}
+}
+
+// MockDatabaseListSequencesCall wrap *gomock.Call
+type MockDatabaseListSequencesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDatabaseListSequencesCall) Return(arg0 []NamedSequence) *MockDatabaseListSequencesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDatabaseListSequencesCall) Do(f func() []NamedSequence) *MockDatabaseListSequencesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDatabaseListSequencesCall) DoAndReturn(f func() []NamedSequence) *MockDatabaseListSequencesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ListTables mocks base method.
 func (m *MockDatabase) ListTables() []Table {
 	m.ctrl.T.Helper()
@@ -408,330 +562,963 @@ func (c *MockDatabaseNameCall) DoAndReturn(f func() string) *MockDatabaseNameCal
 	return c
 }
 
-// MockTable is a mock of Table interface.
-type MockTable struct {
-	ctrl     *gomock.Controller
-	recorder *MockTableMockRecorder
-}
-
-// MockTableMockRecorder is the mock recorder for MockTable.
-type MockTableMockRecorder struct {
-	mock *MockTable
-}
-
-// NewMockTable creates a new mock instance.
-func NewMockTable(ctrl *gomock.Controller) *MockTable {
-	mock := &MockTable{ctrl: ctrl}
-	mock.recorder = &MockTableMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockTable) EXPECT() *MockTableMockRecorder {
-	return m.recorder
-}
-
-// Delete mocks base method.
-func (m *MockTable) Delete(key int64) error {
+// SetStatistics mocks base method.
+func (m *MockDatabase) SetStatistics(table string, statistics Statistics) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", key)
+	ret := m.ctrl.Call(m, "SetStatistics", table, statistics)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockTableMockRecorder) Delete(key any) *MockTableDeleteCall {
+// SetStatistics indicates an expected call of SetStatistics.
+func (mr *MockDatabaseMockRecorder) SetStatistics(table, statistics any) *MockDatabaseSetStatisticsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTable)(nil).Delete), key)
-	return &MockTableDeleteCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatistics", reflect.TypeOf((*MockDatabase)(nil).SetStatistics), table, statistics)
+	return &MockDatabaseSetStatisticsCall{Call: call}
 }
 
-// MockTableDeleteCall wrap *gomock.Call
-type MockTableDeleteCall struct {
+// MockDatabaseSetStatisticsCall wrap *gomock.Call
+type MockDatabaseSetStatisticsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableDeleteCall) Return(arg0 error) *MockTableDeleteCall {
+func (c *MockDatabaseSetStatisticsCall) Return(arg0 error) *MockDatabaseSetStatisticsCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableDeleteCall) Do(f func(int64) error) *MockTableDeleteCall {
+func (c *MockDatabaseSetStatisticsCall) Do(f func(string, Statistics) error) *MockDatabaseSetStatisticsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableDeleteCall) DoAndReturn(f func(int64) error) *MockTableDeleteCall {
+func (c *MockDatabaseSetStatisticsCall) DoAndReturn(f func(string, Statistics) error) *MockDatabaseSetStatisticsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Insert mocks base method.
-func (m *MockTable) Insert(key int64, row Row) error {
+// Statistics mocks base method.
+func (m *MockDatabase) Statistics(table string) (Statistics, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Insert", key, row)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Statistics", table)
+	ret0, _ := ret[0].(Statistics)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
 }
 
-// Insert indicates an expected call of Insert.
-func (mr *MockTableMockRecorder) Insert(key, row any) *MockTableInsertCall {
+// Statistics indicates an expected call of Statistics.
+func (mr *MockDatabaseMockRecorder) Statistics(table any) *MockDatabaseStatisticsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockTable)(nil).Insert), key, row)
-	return &MockTableInsertCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Statistics", reflect.TypeOf((*MockDatabase)(nil).Statistics), table)
+	return &MockDatabaseStatisticsCall{Call: call}
 }
 
-// MockTableInsertCall wrap *gomock.Call
-type MockTableInsertCall struct {
+// MockDatabaseStatisticsCall wrap *gomock.Call
+type MockDatabaseStatisticsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableInsertCall) Return(arg0 error) *MockTableInsertCall {
-	c.Call = c.Call.Return(arg0)
+func (c *MockDatabaseStatisticsCall) Return(arg0 Statistics, arg1 bool) *MockDatabaseStatisticsCall {
+	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableInsertCall) Do(f func(int64, Row) error) *MockTableInsertCall {
+func (c *MockDatabaseStatisticsCall) Do(f func(string) (Statistics, bool)) *MockDatabaseStatisticsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableInsertCall) DoAndReturn(f func(int64, Row) error) *MockTableInsertCall {
+func (c *MockDatabaseStatisticsCall) DoAndReturn(f func(string) (Statistics, bool)) *MockDatabaseStatisticsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Name mocks base method.
-func (m *MockTable) Name() string {
+// MockEngineCatalog is a mock of EngineCatalog interface.
+type MockEngineCatalog struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineCatalogMockRecorder
+}
+
+// MockEngineCatalogMockRecorder is the mock recorder for MockEngineCatalog.
+type MockEngineCatalogMockRecorder struct {
+	mock *MockEngineCatalog
+}
+
+// NewMockEngineCatalog creates a new mock instance.
+func NewMockEngineCatalog(ctrl *gomock.Controller) *MockEngineCatalog {
+	mock := &MockEngineCatalog{ctrl: ctrl}
+	mock.recorder = &MockEngineCatalogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngineCatalog) EXPECT() *MockEngineCatalogMockRecorder {
+	return m.recorder
+}
+
+// CreateDatabaseUsing mocks base method.
+func (m *MockEngineCatalog) CreateDatabaseUsing(name, engine string) (Database, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Name")
-	ret0, _ := ret[0].(string)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateDatabaseUsing", name, engine)
+	ret0, _ := ret[0].(Database)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Name indicates an expected call of Name.
-func (mr *MockTableMockRecorder) Name() *MockTableNameCall {
+// CreateDatabaseUsing indicates an expected call of CreateDatabaseUsing.
+func (mr *MockEngineCatalogMockRecorder) CreateDatabaseUsing(name, engine any) *MockEngineCatalogCreateDatabaseUsingCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockTable)(nil).Name))
-	return &MockTableNameCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDatabaseUsing", reflect.TypeOf((*MockEngineCatalog)(nil).CreateDatabaseUsing), name, engine)
+	return &MockEngineCatalogCreateDatabaseUsingCall{Call: call}
 }
 
-// MockTableNameCall wrap *gomock.Call
-type MockTableNameCall struct {
+// MockEngineCatalogCreateDatabaseUsingCall wrap *gomock.Call
+type MockEngineCatalogCreateDatabaseUsingCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableNameCall) Return(arg0 string) *MockTableNameCall {
-	c.Call = c.Call.Return(arg0)
+func (c *MockEngineCatalogCreateDatabaseUsingCall) Return(arg0 Database, arg1 error) *MockEngineCatalogCreateDatabaseUsingCall {
+	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableNameCall) Do(f func() string) *MockTableNameCall {
+func (c *MockEngineCatalogCreateDatabaseUsingCall) Do(f func(string, string) (Database, error)) *MockEngineCatalogCreateDatabaseUsingCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableNameCall) DoAndReturn(f func() string) *MockTableNameCall {
+func (c *MockEngineCatalogCreateDatabaseUsingCall) DoAndReturn(f func(string, string) (Database, error)) *MockEngineCatalogCreateDatabaseUsingCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// PrimaryKey mocks base method.
-func (m *MockTable) PrimaryKey() Column {
+// MockEngineDatabase is a mock of EngineDatabase interface.
+type MockEngineDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineDatabaseMockRecorder
+}
+
+// MockEngineDatabaseMockRecorder is the mock recorder for MockEngineDatabase.
+type MockEngineDatabaseMockRecorder struct {
+	mock *MockEngineDatabase
+}
+
+// NewMockEngineDatabase creates a new mock instance.
+func NewMockEngineDatabase(ctrl *gomock.Controller) *MockEngineDatabase {
+	mock := &MockEngineDatabase{ctrl: ctrl}
+	mock.recorder = &MockEngineDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngineDatabase) EXPECT() *MockEngineDatabaseMockRecorder {
+	return m.recorder
+}
+
+// CreateTableUsing mocks base method.
+func (m *MockEngineDatabase) CreateTableUsing(name string, scheme Scheme, engine string) (Table, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PrimaryKey")
-	ret0, _ := ret[0].(Column)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateTableUsing", name, scheme, engine)
+	ret0, _ := ret[0].(Table)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// PrimaryKey indicates an expected call of PrimaryKey.
-func (mr *MockTableMockRecorder) PrimaryKey() *MockTablePrimaryKeyCall {
+// CreateTableUsing indicates an expected call of CreateTableUsing.
+func (mr *MockEngineDatabaseMockRecorder) CreateTableUsing(name, scheme, engine any) *MockEngineDatabaseCreateTableUsingCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryKey", reflect.TypeOf((*MockTable)(nil).PrimaryKey))
-	return &MockTablePrimaryKeyCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTableUsing", reflect.TypeOf((*MockEngineDatabase)(nil).CreateTableUsing), name, scheme, engine)
+	return &MockEngineDatabaseCreateTableUsingCall{Call: call}
 }
 
-// MockTablePrimaryKeyCall wrap *gomock.Call
-type MockTablePrimaryKeyCall struct {
+// MockEngineDatabaseCreateTableUsingCall wrap *gomock.Call
+type MockEngineDatabaseCreateTableUsingCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTablePrimaryKeyCall) Return(arg0 Column) *MockTablePrimaryKeyCall {
-	c.Call = c.Call.Return(arg0)
+func (c *MockEngineDatabaseCreateTableUsingCall) Return(arg0 Table, arg1 error) *MockEngineDatabaseCreateTableUsingCall {
+	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTablePrimaryKeyCall) Do(f func() Column) *MockTablePrimaryKeyCall {
+func (c *MockEngineDatabaseCreateTableUsingCall) Do(f func(string, Scheme, string) (Table, error)) *MockEngineDatabaseCreateTableUsingCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTablePrimaryKeyCall) DoAndReturn(f func() Column) *MockTablePrimaryKeyCall {
+func (c *MockEngineDatabaseCreateTableUsingCall) DoAndReturn(f func(string, Scheme, string) (Table, error)) *MockEngineDatabaseCreateTableUsingCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Scan mocks base method.
-func (m *MockTable) Scan() (RowIter, error) {
+// MockTable is a mock of Table interface.
+type MockTable struct {
+	ctrl     *gomock.Controller
+	recorder *MockTableMockRecorder
+}
+
+// MockTableMockRecorder is the mock recorder for MockTable.
+type MockTableMockRecorder struct {
+	mock *MockTable
+}
+
+// NewMockTable creates a new mock instance.
+func NewMockTable(ctrl *gomock.Controller) *MockTable {
+	mock := &MockTable{ctrl: ctrl}
+	mock.recorder = &MockTableMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTable) EXPECT() *MockTableMockRecorder {
+	return m.recorder
+}
+
+// CreateIndex mocks base method.
+func (m *MockTable) CreateIndex(name string, columns []string, unique bool) (Index, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Scan")
-	ret0, _ := ret[0].(RowIter)
+	ret := m.ctrl.Call(m, "CreateIndex", name, columns, unique)
+	ret0, _ := ret[0].(Index)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Scan indicates an expected call of Scan.
-func (mr *MockTableMockRecorder) Scan() *MockTableScanCall {
+// CreateIndex indicates an expected call of CreateIndex.
+func (mr *MockTableMockRecorder) CreateIndex(name, columns, unique any) *MockTableCreateIndexCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockTable)(nil).Scan))
-	return &MockTableScanCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndex", reflect.TypeOf((*MockTable)(nil).CreateIndex), name, columns, unique)
+	return &MockTableCreateIndexCall{Call: call}
 }
 
-// MockTableScanCall wrap *gomock.Call
-type MockTableScanCall struct {
+// MockTableCreateIndexCall wrap *gomock.Call
+type MockTableCreateIndexCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableScanCall) Return(arg0 RowIter, arg1 error) *MockTableScanCall {
+func (c *MockTableCreateIndexCall) Return(arg0 Index, arg1 error) *MockTableCreateIndexCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableScanCall) Do(f func() (RowIter, error)) *MockTableScanCall {
+func (c *MockTableCreateIndexCall) Do(f func(string, []string, bool) (Index, error)) *MockTableCreateIndexCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableScanCall) DoAndReturn(f func() (RowIter, error)) *MockTableScanCall {
+func (c *MockTableCreateIndexCall) DoAndReturn(f func(string, []string, bool) (Index, error)) *MockTableCreateIndexCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Scheme mocks base method.
-func (m *MockTable) Scheme() Scheme {
+// Delete mocks base method.
+func (m *MockTable) Delete(key Key) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Scheme")
-	ret0, _ := ret[0].(Scheme)
+	ret := m.ctrl.Call(m, "Delete", key)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Scheme indicates an expected call of Scheme.
-func (mr *MockTableMockRecorder) Scheme() *MockTableSchemeCall {
+// Delete indicates an expected call of Delete.
+func (mr *MockTableMockRecorder) Delete(key any) *MockTableDeleteCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheme", reflect.TypeOf((*MockTable)(nil).Scheme))
-	return &MockTableSchemeCall{Call: call}
-}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTable)(nil).Delete), key)
+	return &MockTableDeleteCall{Call: call}
+}
+
+// MockTableDeleteCall wrap *gomock.Call
+type MockTableDeleteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableDeleteCall) Return(arg0 error) *MockTableDeleteCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableDeleteCall) Do(f func(Key) error) *MockTableDeleteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableDeleteCall) DoAndReturn(f func(Key) error) *MockTableDeleteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DropIndex mocks base method.
+func (m *MockTable) DropIndex(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropIndex", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropIndex indicates an expected call of DropIndex.
+func (mr *MockTableMockRecorder) DropIndex(name any) *MockTableDropIndexCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropIndex", reflect.TypeOf((*MockTable)(nil).DropIndex), name)
+	return &MockTableDropIndexCall{Call: call}
+}
+
+// MockTableDropIndexCall wrap *gomock.Call
+type MockTableDropIndexCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableDropIndexCall) Return(arg0 error) *MockTableDropIndexCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableDropIndexCall) Do(f func(string) error) *MockTableDropIndexCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableDropIndexCall) DoAndReturn(f func(string) error) *MockTableDropIndexCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Get mocks base method.
+func (m *MockTable) Get(key Key) (Row, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", key)
+	ret0, _ := ret[0].(Row)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTableMockRecorder) Get(key any) *MockTableGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTable)(nil).Get), key)
+	return &MockTableGetCall{Call: call}
+}
+
+// MockTableGetCall wrap *gomock.Call
+type MockTableGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableGetCall) Return(arg0 Row, arg1 bool, arg2 error) *MockTableGetCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableGetCall) Do(f func(Key) (Row, bool, error)) *MockTableGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableGetCall) DoAndReturn(f func(Key) (Row, bool, error)) *MockTableGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Indexes mocks base method.
+func (m *MockTable) Indexes() []Index {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Indexes")
+	ret0, _ := ret[0].([]Index)
+	return ret0
+}
+
+// Indexes indicates an expected call of Indexes.
+func (mr *MockTableMockRecorder) Indexes() *MockTableIndexesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Indexes", reflect.TypeOf((*MockTable)(nil).Indexes))
+	return &MockTableIndexesCall{Call: call}
+}
+
+// MockTableIndexesCall wrap *gomock.Call
+type MockTableIndexesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableIndexesCall) Return(arg0 []Index) *MockTableIndexesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableIndexesCall) Do(f func() []Index) *MockTableIndexesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableIndexesCall) DoAndReturn(f func() []Index) *MockTableIndexesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Insert mocks base method.
+func (m *MockTable) Insert(key Key, row Row) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", key, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockTableMockRecorder) Insert(key, row any) *MockTableInsertCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockTable)(nil).Insert), key, row)
+	return &MockTableInsertCall{Call: call}
+}
+
+// MockTableInsertCall wrap *gomock.Call
+type MockTableInsertCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableInsertCall) Return(arg0 error) *MockTableInsertCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableInsertCall) Do(f func(Key, Row) error) *MockTableInsertCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableInsertCall) DoAndReturn(f func(Key, Row) error) *MockTableInsertCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Name mocks base method.
+func (m *MockTable) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockTableMockRecorder) Name() *MockTableNameCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockTable)(nil).Name))
+	return &MockTableNameCall{Call: call}
+}
+
+// MockTableNameCall wrap *gomock.Call
+type MockTableNameCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableNameCall) Return(arg0 string) *MockTableNameCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableNameCall) Do(f func() string) *MockTableNameCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableNameCall) DoAndReturn(f func() string) *MockTableNameCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PrimaryKey mocks base method.
+func (m *MockTable) PrimaryKey() []Column {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryKey")
+	ret0, _ := ret[0].([]Column)
+	return ret0
+}
+
+// PrimaryKey indicates an expected call of PrimaryKey.
+func (mr *MockTableMockRecorder) PrimaryKey() *MockTablePrimaryKeyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryKey", reflect.TypeOf((*MockTable)(nil).PrimaryKey))
+	return &MockTablePrimaryKeyCall{Call: call}
+}
+
+// MockTablePrimaryKeyCall wrap *gomock.Call
+type MockTablePrimaryKeyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTablePrimaryKeyCall) Return(arg0 []Column) *MockTablePrimaryKeyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTablePrimaryKeyCall) Do(f func() []Column) *MockTablePrimaryKeyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTablePrimaryKeyCall) DoAndReturn(f func() []Column) *MockTablePrimaryKeyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Scan mocks base method.
+func (m *MockTable) Scan() (RowIter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan")
+	ret0, _ := ret[0].(RowIter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockTableMockRecorder) Scan() *MockTableScanCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockTable)(nil).Scan))
+	return &MockTableScanCall{Call: call}
+}
+
+// MockTableScanCall wrap *gomock.Call
+type MockTableScanCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableScanCall) Return(arg0 RowIter, arg1 error) *MockTableScanCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableScanCall) Do(f func() (RowIter, error)) *MockTableScanCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableScanCall) DoAndReturn(f func() (RowIter, error)) *MockTableScanCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ScanRange mocks base method.
+func (m *MockTable) ScanRange(from, to *Bound) (RowIter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanRange", from, to)
+	ret0, _ := ret[0].(RowIter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanRange indicates an expected call of ScanRange.
+func (mr *MockTableMockRecorder) ScanRange(from, to any) *MockTableScanRangeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanRange", reflect.TypeOf((*MockTable)(nil).ScanRange), from, to)
+	return &MockTableScanRangeCall{Call: call}
+}
+
+// MockTableScanRangeCall wrap *gomock.Call
+type MockTableScanRangeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableScanRangeCall) Return(arg0 RowIter, arg1 error) *MockTableScanRangeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableScanRangeCall) Do(f func(*Bound, *Bound) (RowIter, error)) *MockTableScanRangeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableScanRangeCall) DoAndReturn(f func(*Bound, *Bound) (RowIter, error)) *MockTableScanRangeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Scheme mocks base method.
+func (m *MockTable) Scheme() Scheme {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheme")
+	ret0, _ := ret[0].(Scheme)
+	return ret0
+}
+
+// Scheme indicates an expected call of Scheme.
+func (mr *MockTableMockRecorder) Scheme() *MockTableSchemeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheme", reflect.TypeOf((*MockTable)(nil).Scheme))
+	return &MockTableSchemeCall{Call: call}
+}
+
+// MockTableSchemeCall wrap *gomock.Call
+type MockTableSchemeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableSchemeCall) Return(arg0 Scheme) *MockTableSchemeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableSchemeCall) Do(f func() Scheme) *MockTableSchemeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableSchemeCall) DoAndReturn(f func() Scheme) *MockTableSchemeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Sequence mocks base method.
+func (m *MockTable) Sequence() Sequence {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sequence")
+	ret0, _ := ret[0].(Sequence)
+	return ret0
+}
+
+// Sequence indicates an expected call of Sequence.
+func (mr *MockTableMockRecorder) Sequence() *MockTableSequenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sequence", reflect.TypeOf((*MockTable)(nil).Sequence))
+	return &MockTableSequenceCall{Call: call}
+}
+
+// MockTableSequenceCall wrap *gomock.Call
+type MockTableSequenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableSequenceCall) Return(arg0 Sequence) *MockTableSequenceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableSequenceCall) Do(f func() Sequence) *MockTableSequenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableSequenceCall) DoAndReturn(f func() Sequence) *MockTableSequenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Update mocks base method.
+func (m *MockTable) Update(key Key, row Row) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", key, row)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTableMockRecorder) Update(key, row any) *MockTableUpdateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTable)(nil).Update), key, row)
+	return &MockTableUpdateCall{Call: call}
+}
+
+// MockTableUpdateCall wrap *gomock.Call
+type MockTableUpdateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTableUpdateCall) Return(arg0 error) *MockTableUpdateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTableUpdateCall) Do(f func(Key, Row) error) *MockTableUpdateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTableUpdateCall) DoAndReturn(f func(Key, Row) error) *MockTableUpdateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockColumnScanner is a mock of ColumnScanner interface.
+type MockColumnScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockColumnScannerMockRecorder
+}
+
+// MockColumnScannerMockRecorder is the mock recorder for MockColumnScanner.
+type MockColumnScannerMockRecorder struct {
+	mock *MockColumnScanner
+}
+
+// NewMockColumnScanner creates a new mock instance.
+func NewMockColumnScanner(ctrl *gomock.Controller) *MockColumnScanner {
+	mock := &MockColumnScanner{ctrl: ctrl}
+	mock.recorder = &MockColumnScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockColumnScanner) EXPECT() *MockColumnScannerMockRecorder {
+	return m.recorder
+}
+
+// ScanColumns mocks base method.
+func (m *MockColumnScanner) ScanColumns(positions []uint8) (RowIter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanColumns", positions)
+	ret0, _ := ret[0].(RowIter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanColumns indicates an expected call of ScanColumns.
+func (mr *MockColumnScannerMockRecorder) ScanColumns(positions any) *MockColumnScannerScanColumnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanColumns", reflect.TypeOf((*MockColumnScanner)(nil).ScanColumns), positions)
+	return &MockColumnScannerScanColumnsCall{Call: call}
+}
+
+// MockColumnScannerScanColumnsCall wrap *gomock.Call
+type MockColumnScannerScanColumnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockColumnScannerScanColumnsCall) Return(arg0 RowIter, arg1 error) *MockColumnScannerScanColumnsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockColumnScannerScanColumnsCall) Do(f func([]uint8) (RowIter, error)) *MockColumnScannerScanColumnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
 
-// MockTableSchemeCall wrap *gomock.Call
-type MockTableSchemeCall struct {
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockColumnScannerScanColumnsCall) DoAndReturn(f func([]uint8) (RowIter, error)) *MockColumnScannerScanColumnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockIndex is a mock of Index interface.
+type MockIndex struct {
+	ctrl     *gomock.Controller
+	recorder *MockIndexMockRecorder
+}
+
+// MockIndexMockRecorder is the mock recorder for MockIndex.
+type MockIndexMockRecorder struct {
+	mock *MockIndex
+}
+
+// NewMockIndex creates a new mock instance.
+func NewMockIndex(ctrl *gomock.Controller) *MockIndex {
+	mock := &MockIndex{ctrl: ctrl}
+	mock.recorder = &MockIndexMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIndex) EXPECT() *MockIndexMockRecorder {
+	return m.recorder
+}
+
+// Columns mocks base method.
+func (m *MockIndex) Columns() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Columns")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Columns indicates an expected call of Columns.
+func (mr *MockIndexMockRecorder) Columns() *MockIndexColumnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Columns", reflect.TypeOf((*MockIndex)(nil).Columns))
+	return &MockIndexColumnsCall{Call: call}
+}
+
+// MockIndexColumnsCall wrap *gomock.Call
+type MockIndexColumnsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableSchemeCall) Return(arg0 Scheme) *MockTableSchemeCall {
+func (c *MockIndexColumnsCall) Return(arg0 []string) *MockIndexColumnsCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableSchemeCall) Do(f func() Scheme) *MockTableSchemeCall {
+func (c *MockIndexColumnsCall) Do(f func() []string) *MockIndexColumnsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableSchemeCall) DoAndReturn(f func() Scheme) *MockTableSchemeCall {
+func (c *MockIndexColumnsCall) DoAndReturn(f func() []string) *MockIndexColumnsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Sequence mocks base method.
-func (m *MockTable) Sequence() Sequence {
+// Name mocks base method.
+func (m *MockIndex) Name() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Sequence")
-	ret0, _ := ret[0].(Sequence)
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// Sequence indicates an expected call of Sequence.
-func (mr *MockTableMockRecorder) Sequence() *MockTableSequenceCall {
+// Name indicates an expected call of Name.
+func (mr *MockIndexMockRecorder) Name() *MockIndexNameCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sequence", reflect.TypeOf((*MockTable)(nil).Sequence))
-	return &MockTableSequenceCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockIndex)(nil).Name))
+	return &MockIndexNameCall{Call: call}
 }
 
-// MockTableSequenceCall wrap *gomock.Call
-type MockTableSequenceCall struct {
+// MockIndexNameCall wrap *gomock.Call
+type MockIndexNameCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableSequenceCall) Return(arg0 Sequence) *MockTableSequenceCall {
+func (c *MockIndexNameCall) Return(arg0 string) *MockIndexNameCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableSequenceCall) Do(f func() Sequence) *MockTableSequenceCall {
+func (c *MockIndexNameCall) Do(f func() string) *MockIndexNameCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableSequenceCall) DoAndReturn(f func() Sequence) *MockTableSequenceCall {
+func (c *MockIndexNameCall) DoAndReturn(f func() string) *MockIndexNameCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Update mocks base method.
-func (m *MockTable) Update(key int64, row Row) error {
+// Scan mocks base method.
+func (m *MockIndex) Scan(r IndexRange) (RowIter, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", key, row)
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "Scan", r)
+	ret0, _ := ret[0].(RowIter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockIndexMockRecorder) Scan(r any) *MockIndexScanCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockIndex)(nil).Scan), r)
+	return &MockIndexScanCall{Call: call}
+}
+
+// MockIndexScanCall wrap *gomock.Call
+type MockIndexScanCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockIndexScanCall) Return(arg0 RowIter, arg1 error) *MockIndexScanCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockIndexScanCall) Do(f func(IndexRange) (RowIter, error)) *MockIndexScanCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockIndexScanCall) DoAndReturn(f func(IndexRange) (RowIter, error)) *MockIndexScanCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Unique mocks base method.
+func (m *MockIndex) Unique() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unique")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockTableMockRecorder) Update(key, row any) *MockTableUpdateCall {
+// Unique indicates an expected call of Unique.
+func (mr *MockIndexMockRecorder) Unique() *MockIndexUniqueCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTable)(nil).Update), key, row)
-	return &MockTableUpdateCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unique", reflect.TypeOf((*MockIndex)(nil).Unique))
+	return &MockIndexUniqueCall{Call: call}
 }
 
-// MockTableUpdateCall wrap *gomock.Call
-type MockTableUpdateCall struct {
+// MockIndexUniqueCall wrap *gomock.Call
+type MockIndexUniqueCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockTableUpdateCall) Return(arg0 error) *MockTableUpdateCall {
+func (c *MockIndexUniqueCall) Return(arg0 bool) *MockIndexUniqueCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockTableUpdateCall) Do(f func(int64, Row) error) *MockTableUpdateCall {
+func (c *MockIndexUniqueCall) Do(f func() bool) *MockIndexUniqueCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockTableUpdateCall) DoAndReturn(f func(int64, Row) error) *MockTableUpdateCall {
+func (c *MockIndexUniqueCall) DoAndReturn(f func() bool) *MockIndexUniqueCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -796,3 +1583,254 @@ func (c *MockSequenceNextCall) DoAndReturn(f func() int64) *MockSequenceNextCall
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// Release mocks base method.
+func (m *MockSequence) Release(value int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release", value)
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockSequenceMockRecorder) Release(value any) *MockSequenceReleaseCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockSequence)(nil).Release), value)
+	return &MockSequenceReleaseCall{Call: call}
+}
+
+// MockSequenceReleaseCall wrap *gomock.Call
+type MockSequenceReleaseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSequenceReleaseCall) Return() *MockSequenceReleaseCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSequenceReleaseCall) Do(f func(int64)) *MockSequenceReleaseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSequenceReleaseCall) DoAndReturn(f func(int64)) *MockSequenceReleaseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockNamedSequence is a mock of NamedSequence interface.
+type MockNamedSequence struct {
+	ctrl     *gomock.Controller
+	recorder *MockNamedSequenceMockRecorder
+}
+
+// MockNamedSequenceMockRecorder is the mock recorder for MockNamedSequence.
+type MockNamedSequenceMockRecorder struct {
+	mock *MockNamedSequence
+}
+
+// NewMockNamedSequence creates a new mock instance.
+func NewMockNamedSequence(ctrl *gomock.Controller) *MockNamedSequence {
+	mock := &MockNamedSequence{ctrl: ctrl}
+	mock.recorder = &MockNamedSequenceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNamedSequence) EXPECT() *MockNamedSequenceMockRecorder {
+	return m.recorder
+}
+
+// Current mocks base method.
+func (m *MockNamedSequence) Current() (int64, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Current")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Current indicates an expected call of Current.
+func (mr *MockNamedSequenceMockRecorder) Current() *MockNamedSequenceCurrentCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Current", reflect.TypeOf((*MockNamedSequence)(nil).Current))
+	return &MockNamedSequenceCurrentCall{Call: call}
+}
+
+// MockNamedSequenceCurrentCall wrap *gomock.Call
+type MockNamedSequenceCurrentCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNamedSequenceCurrentCall) Return(arg0 int64, arg1 bool) *MockNamedSequenceCurrentCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNamedSequenceCurrentCall) Do(f func() (int64, bool)) *MockNamedSequenceCurrentCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNamedSequenceCurrentCall) DoAndReturn(f func() (int64, bool)) *MockNamedSequenceCurrentCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Name mocks base method.
+func (m *MockNamedSequence) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockNamedSequenceMockRecorder) Name() *MockNamedSequenceNameCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockNamedSequence)(nil).Name))
+	return &MockNamedSequenceNameCall{Call: call}
+}
+
+// MockNamedSequenceNameCall wrap *gomock.Call
+type MockNamedSequenceNameCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNamedSequenceNameCall) Return(arg0 string) *MockNamedSequenceNameCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNamedSequenceNameCall) Do(f func() string) *MockNamedSequenceNameCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNamedSequenceNameCall) DoAndReturn(f func() string) *MockNamedSequenceNameCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Next mocks base method.
+func (m *MockNamedSequence) Next() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockNamedSequenceMockRecorder) Next() *MockNamedSequenceNextCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockNamedSequence)(nil).Next))
+	return &MockNamedSequenceNextCall{Call: call}
+}
+
+// MockNamedSequenceNextCall wrap *gomock.Call
+type MockNamedSequenceNextCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNamedSequenceNextCall) Return(arg0 int64, arg1 error) *MockNamedSequenceNextCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNamedSequenceNextCall) Do(f func() (int64, error)) *MockNamedSequenceNextCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNamedSequenceNextCall) DoAndReturn(f func() (int64, error)) *MockNamedSequenceNextCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Options mocks base method.
+func (m *MockNamedSequence) Options() SequenceOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Options")
+	ret0, _ := ret[0].(SequenceOptions)
+	return ret0
+}
+
+// Options indicates an expected call of Options.
+func (mr *MockNamedSequenceMockRecorder) Options() *MockNamedSequenceOptionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Options", reflect.TypeOf((*MockNamedSequence)(nil).Options))
+	return &MockNamedSequenceOptionsCall{Call: call}
+}
+
+// MockNamedSequenceOptionsCall wrap *gomock.Call
+type MockNamedSequenceOptionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNamedSequenceOptionsCall) Return(arg0 SequenceOptions) *MockNamedSequenceOptionsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNamedSequenceOptionsCall) Do(f func() SequenceOptions) *MockNamedSequenceOptionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNamedSequenceOptionsCall) DoAndReturn(f func() SequenceOptions) *MockNamedSequenceOptionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetValue mocks base method.
+func (m *MockNamedSequence) SetValue(value int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetValue", value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetValue indicates an expected call of SetValue.
+func (mr *MockNamedSequenceMockRecorder) SetValue(value any) *MockNamedSequenceSetValueCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetValue", reflect.TypeOf((*MockNamedSequence)(nil).SetValue), value)
+	return &MockNamedSequenceSetValueCall{Call: call}
+}
+
+// MockNamedSequenceSetValueCall wrap *gomock.Call
+type MockNamedSequenceSetValueCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNamedSequenceSetValueCall) Return(arg0 error) *MockNamedSequenceSetValueCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNamedSequenceSetValueCall) Do(f func(int64) error) *MockNamedSequenceSetValueCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNamedSequenceSetValueCall) DoAndReturn(f func(int64) error) *MockNamedSequenceSetValueCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,380 @@
+// Package btree implements an in-memory B+tree: an ordered map with logarithmic insertion, deletion and lookup.
+package btree
+
+// maxKeys is the maximum number of keys in a node, a node with more keys is split into two.
+const maxKeys = 64
+
+// minKeys is the minimum number of keys in a non-root node, a node with fewer keys borrows from or merges with
+// a sibling.
+const minKeys = maxKeys / 2
+
+// CompareFn returns a negative number if a < b, zero if a == b and a positive number if a > b.
+type CompareFn[K any] func(a, b K) int
+
+// node is either a leaf or an internal node.
+//
+// Leaves keep keys with their values and are linked into a list in the key order.
+// Internal nodes keep separators: all keys of children[i] are less than keys[i],
+// all keys of children[i+1] are greater than or equal to keys[i].
+type node[K, V any] struct {
+	leaf     bool
+	keys     []K
+	values   []V
+	children []*node[K, V]
+	next     *node[K, V]
+}
+
+// Tree is a B+tree, it's not safe for concurrent use.
+type Tree[K, V any] struct {
+	root    *node[K, V]
+	compare CompareFn[K]
+	length  int
+}
+
+// New returns an empty tree ordered by the compare function.
+func New[K, V any](compare CompareFn[K]) *Tree[K, V] {
+	return &Tree[K, V]{
+		root:    &node[K, V]{leaf: true},
+		compare: compare,
+	}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.length
+}
+
+// Get returns the value stored by the key.
+func (t *Tree[K, V]) Get(key K) (V, bool) {
+	leaf := t.findLeaf(key)
+
+	if i, found := t.search(leaf.keys, key); found {
+		return leaf.values[i], true
+	}
+
+	var zero V
+
+	return zero, false
+}
+
+// Put stores the value by the key and returns true if the key already existed (the value is replaced).
+func (t *Tree[K, V]) Put(key K, value V) bool {
+	separator, right, replaced := t.insert(t.root, key, value)
+
+	if right != nil {
+		t.root = &node[K, V]{
+			keys:     []K{separator},
+			children: []*node[K, V]{t.root, right},
+		}
+	}
+
+	if !replaced {
+		t.length++
+	}
+
+	return replaced
+}
+
+// Delete removes the key and returns its value.
+func (t *Tree[K, V]) Delete(key K) (V, bool) {
+	value, deleted := t.delete(t.root, key)
+	if !deleted {
+		return value, false
+	}
+
+	t.length--
+
+	if !t.root.leaf && len(t.root.keys) == 0 {
+		t.root = t.root.children[0]
+	}
+
+	return value, true
+}
+
+// Ascend calls fn for every key in ascending order until fn returns false.
+func (t *Tree[K, V]) Ascend(fn func(key K, value V) bool) {
+	leaf := t.root
+
+	for !leaf.leaf {
+		leaf = leaf.children[0]
+	}
+
+	t.ascend(leaf, 0, nil, fn)
+}
+
+// AscendGreaterOrEqual calls fn for every key >= from in ascending order until fn returns false.
+func (t *Tree[K, V]) AscendGreaterOrEqual(from K, fn func(key K, value V) bool) {
+	leaf := t.findLeaf(from)
+	i, _ := t.search(leaf.keys, from)
+
+	t.ascend(leaf, i, nil, fn)
+}
+
+// AscendRange calls fn for every key in the range [from, to) in ascending order until fn returns false.
+func (t *Tree[K, V]) AscendRange(from, to K, fn func(key K, value V) bool) {
+	leaf := t.findLeaf(from)
+	i, _ := t.search(leaf.keys, from)
+
+	t.ascend(leaf, i, &to, fn)
+}
+
+func (t *Tree[K, V]) ascend(leaf *node[K, V], i int, to *K, fn func(key K, value V) bool) {
+	for ; leaf != nil; leaf, i = leaf.next, 0 {
+		for ; i < len(leaf.keys); i++ {
+			if to != nil && t.compare(leaf.keys[i], *to) >= 0 {
+				return
+			}
+
+			if !fn(leaf.keys[i], leaf.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// findLeaf returns the leaf that contains the key or would contain it.
+func (t *Tree[K, V]) findLeaf(key K) *node[K, V] {
+	n := t.root
+
+	for !n.leaf {
+		n = n.children[t.childIndex(n, key)]
+	}
+
+	return n
+}
+
+// search returns the position of the first key >= the given one and whether it's equal.
+func (t *Tree[K, V]) search(keys []K, key K) (int, bool) {
+	low, high := 0, len(keys)
+
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+
+		if t.compare(keys[mid], key) < 0 {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+
+	return low, low < len(keys) && t.compare(keys[low], key) == 0
+}
+
+// childIndex returns the position of the child of the internal node that covers the key.
+func (t *Tree[K, V]) childIndex(n *node[K, V], key K) int {
+	i, found := t.search(n.keys, key)
+	if found {
+		i++
+	}
+
+	return i
+}
+
+// insert puts the key into the subtree. If the node overflows, it's split,
+// and the new right node is returned with the separator for the parent.
+func (t *Tree[K, V]) insert(n *node[K, V], key K, value V) (K, *node[K, V], bool) {
+	var zero K
+
+	if n.leaf {
+		i, found := t.search(n.keys, key)
+		if found {
+			n.values[i] = value
+
+			return zero, nil, true
+		}
+
+		n.keys = insertAt(n.keys, i, key)
+		n.values = insertAt(n.values, i, value)
+
+		if len(n.keys) <= maxKeys {
+			return zero, nil, false
+		}
+
+		right := t.splitLeaf(n)
+
+		return right.keys[0], right, false
+	}
+
+	i := t.childIndex(n, key)
+
+	separator, right, replaced := t.insert(n.children[i], key, value)
+	if right == nil {
+		return zero, nil, replaced
+	}
+
+	n.keys = insertAt(n.keys, i, separator)
+	n.children = insertAt(n.children, i+1, right)
+
+	if len(n.keys) <= maxKeys {
+		return zero, nil, replaced
+	}
+
+	separator, right = t.splitInternal(n)
+
+	return separator, right, replaced
+}
+
+func (t *Tree[K, V]) splitLeaf(n *node[K, V]) *node[K, V] {
+	mid := len(n.keys) / 2
+
+	right := &node[K, V]{
+		leaf:   true,
+		keys:   append([]K(nil), n.keys[mid:]...),
+		values: append([]V(nil), n.values[mid:]...),
+		next:   n.next,
+	}
+
+	n.keys = clearTail(n.keys, mid)
+	n.values = clearTail(n.values, mid)
+	n.next = right
+
+	return right
+}
+
+func (t *Tree[K, V]) splitInternal(n *node[K, V]) (K, *node[K, V]) {
+	mid := len(n.keys) / 2
+	separator := n.keys[mid]
+
+	right := &node[K, V]{
+		keys:     append([]K(nil), n.keys[mid+1:]...),
+		children: append([]*node[K, V](nil), n.children[mid+1:]...),
+	}
+
+	n.keys = clearTail(n.keys, mid)
+	n.children = clearTail(n.children, mid+1)
+
+	return separator, right
+}
+
+// delete removes the key from the subtree, rebalancing children that become too small.
+func (t *Tree[K, V]) delete(n *node[K, V], key K) (V, bool) {
+	if n.leaf {
+		i, found := t.search(n.keys, key)
+		if !found {
+			var zero V
+
+			return zero, false
+		}
+
+		value := n.values[i]
+		n.keys = removeAt(n.keys, i)
+		n.values = removeAt(n.values, i)
+
+		return value, true
+	}
+
+	i := t.childIndex(n, key)
+
+	value, deleted := t.delete(n.children[i], key)
+	if deleted && len(n.children[i].keys) < minKeys {
+		t.rebalance(n, i)
+	}
+
+	return value, deleted
+}
+
+// rebalance restores the minimum size of the i-th child by borrowing a key from a sibling or merging with it.
+func (t *Tree[K, V]) rebalance(parent *node[K, V], i int) {
+	if i > 0 && len(parent.children[i-1].keys) > minKeys {
+		t.borrowFromLeft(parent, i)
+
+		return
+	}
+
+	if i < len(parent.children)-1 && len(parent.children[i+1].keys) > minKeys {
+		t.borrowFromRight(parent, i)
+
+		return
+	}
+
+	if i > 0 {
+		t.merge(parent, i-1)
+	} else if len(parent.children) > 1 {
+		t.merge(parent, i)
+	}
+}
+
+func (t *Tree[K, V]) borrowFromLeft(parent *node[K, V], i int) {
+	child, left := parent.children[i], parent.children[i-1]
+	last := len(left.keys) - 1
+
+	if child.leaf {
+		child.keys = insertAt(child.keys, 0, left.keys[last])
+		child.values = insertAt(child.values, 0, left.values[last])
+		left.keys = clearTail(left.keys, last)
+		left.values = clearTail(left.values, last)
+		parent.keys[i-1] = child.keys[0]
+
+		return
+	}
+
+	child.keys = insertAt(child.keys, 0, parent.keys[i-1])
+	child.children = insertAt(child.children, 0, left.children[last+1])
+	parent.keys[i-1] = left.keys[last]
+	left.keys = clearTail(left.keys, last)
+	left.children = clearTail(left.children, last+1)
+}
+
+func (t *Tree[K, V]) borrowFromRight(parent *node[K, V], i int) {
+	child, right := parent.children[i], parent.children[i+1]
+
+	if child.leaf {
+		child.keys = append(child.keys, right.keys[0])
+		child.values = append(child.values, right.values[0])
+		right.keys = removeAt(right.keys, 0)
+		right.values = removeAt(right.values, 0)
+		parent.keys[i] = right.keys[0]
+
+		return
+	}
+
+	child.keys = append(child.keys, parent.keys[i])
+	child.children = append(child.children, right.children[0])
+	parent.keys[i] = right.keys[0]
+	right.keys = removeAt(right.keys, 0)
+	right.children = removeAt(right.children, 0)
+}
+
+// merge moves all keys of the (i+1)-th child into the i-th one and removes the separator between them.
+func (t *Tree[K, V]) merge(parent *node[K, V], i int) {
+	left, right := parent.children[i], parent.children[i+1]
+
+	if left.leaf {
+		left.keys = append(left.keys, right.keys...)
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+	} else {
+		left.keys = append(left.keys, parent.keys[i])
+		left.keys = append(left.keys, right.keys...)
+		left.children = append(left.children, right.children...)
+	}
+
+	parent.keys = removeAt(parent.keys, i)
+	parent.children = removeAt(parent.children, i+1)
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+
+	return s
+}
+
+func removeAt[T any](s []T, i int) []T {
+	var zero T
+
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = zero
+
+	return s[:len(s)-1]
+}
+
+// clearTail truncates the slice to n elements, zeroing the rest so they can be garbage collected.
+func clearTail[T any](s []T, n int) []T {
+	clear(s[n:])
+
+	return s[:n]
+}
@@ -0,0 +1,181 @@
+package btree_test
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+)
+
+func TestTree_Put(t *testing.T) {
+	t.Parallel()
+
+	tree := btree.New[int64, string](cmp.Compare[int64])
+	assert.Zero(t, tree.Len())
+
+	assert.False(t, tree.Put(2, "two"))
+	assert.False(t, tree.Put(1, "one"))
+	assert.True(t, tree.Put(2, "TWO"))
+	assert.Equal(t, 2, tree.Len())
+
+	value, ok := tree.Get(2)
+	require.True(t, ok)
+	assert.Equal(t, "TWO", value)
+
+	_, ok = tree.Get(3)
+	require.False(t, ok)
+}
+
+func TestTree_Delete(t *testing.T) {
+	t.Parallel()
+
+	tree := btree.New[int64, string](cmp.Compare[int64])
+	tree.Put(1, "one")
+
+	value, ok := tree.Delete(1)
+	require.True(t, ok)
+	assert.Equal(t, "one", value)
+	assert.Zero(t, tree.Len())
+
+	_, ok = tree.Delete(1)
+	require.False(t, ok)
+}
+
+func TestTree_Ascend(t *testing.T) {
+	t.Parallel()
+
+	tree := btree.New[int64, int64](cmp.Compare[int64])
+
+	for _, key := range rand.Perm(1000) {
+		tree.Put(int64(key), int64(key)*10)
+	}
+
+	t.Run("all keys", func(t *testing.T) {
+		t.Parallel()
+
+		keys := collect(func(fn func(int64, int64) bool) {
+			tree.Ascend(fn)
+		})
+
+		require.Len(t, keys, 1000)
+		assert.True(t, slices.IsSorted(keys))
+	})
+
+	t.Run("stops when callback returns false", func(t *testing.T) {
+		t.Parallel()
+
+		var keys []int64
+
+		tree.Ascend(func(key, _ int64) bool {
+			keys = append(keys, key)
+
+			return len(keys) < 3
+		})
+
+		assert.Equal(t, []int64{0, 1, 2}, keys)
+	})
+
+	t.Run("greater or equal", func(t *testing.T) {
+		t.Parallel()
+
+		keys := collect(func(fn func(int64, int64) bool) {
+			tree.AscendGreaterOrEqual(995, fn)
+		})
+
+		assert.Equal(t, []int64{995, 996, 997, 998, 999}, keys)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		t.Parallel()
+
+		keys := collect(func(fn func(int64, int64) bool) {
+			tree.AscendRange(100, 105, fn)
+		})
+
+		assert.Equal(t, []int64{100, 101, 102, 103, 104}, keys)
+
+		keys = collect(func(fn func(int64, int64) bool) {
+			tree.AscendRange(-10, 2, fn)
+		})
+
+		assert.Equal(t, []int64{0, 1}, keys)
+
+		keys = collect(func(fn func(int64, int64) bool) {
+			tree.AscendRange(2000, 3000, fn)
+		})
+
+		assert.Empty(t, keys)
+	})
+}
+
+func TestTree_Random(t *testing.T) {
+	t.Parallel()
+
+	tree := btree.New[int64, int64](cmp.Compare[int64])
+	expected := make(map[int64]int64)
+
+	for i := 0; i < 50_000; i++ {
+		key := rand.Int64N(5000)
+
+		if rand.IntN(3) == 0 {
+			_, existed := expected[key]
+			_, deleted := tree.Delete(key)
+			require.Equal(t, existed, deleted)
+			delete(expected, key)
+
+			continue
+		}
+
+		_, existed := expected[key]
+		require.Equal(t, existed, tree.Put(key, int64(i)))
+		expected[key] = int64(i)
+	}
+
+	require.Equal(t, len(expected), tree.Len())
+
+	keys := make([]int64, 0, len(expected))
+
+	for key := range expected {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	actual := make([]int64, 0, tree.Len())
+
+	tree.Ascend(func(key, value int64) bool {
+		actual = append(actual, key)
+		assert.Equal(t, expected[key], value)
+
+		return true
+	})
+
+	require.Equal(t, keys, actual)
+
+	for _, key := range keys {
+		_, ok := tree.Delete(key)
+		require.True(t, ok)
+	}
+
+	assert.Zero(t, tree.Len())
+	assert.Empty(t, collect(func(fn func(int64, int64) bool) {
+		tree.Ascend(fn)
+	}))
+}
+
+func collect(ascend func(fn func(int64, int64) bool)) []int64 {
+	keys := make([]int64, 0)
+
+	ascend(func(key, _ int64) bool {
+		keys = append(keys, key)
+
+		return true
+	})
+
+	return keys
+}
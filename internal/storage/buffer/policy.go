@@ -0,0 +1,106 @@
+package buffer
+
+import (
+	"container/list"
+)
+
+// Policy chooses the frame to evict when the pool is full. Policies are used under the lock of the pool.
+type Policy interface {
+	// Access records a use of the frame.
+	Access(frame int)
+	// Remove forgets the frame, it holds no page anymore.
+	Remove(frame int)
+	// Victim returns a frame to evict among the ones evictable returns true for.
+	Victim(evictable func(frame int) bool) (int, bool)
+}
+
+// LRU evicts the least recently used frame.
+type LRU struct {
+	order    *list.List // the most recently used frame is at the front
+	elements map[int]*list.Element
+}
+
+func NewLRU() *LRU {
+	return &LRU{
+		order:    list.New(),
+		elements: make(map[int]*list.Element),
+	}
+}
+
+func (l *LRU) Access(frame int) {
+	if e, ok := l.elements[frame]; ok {
+		l.order.MoveToFront(e)
+
+		return
+	}
+
+	l.elements[frame] = l.order.PushFront(frame)
+}
+
+func (l *LRU) Remove(frame int) {
+	if e, ok := l.elements[frame]; ok {
+		l.order.Remove(e)
+		delete(l.elements, frame)
+	}
+}
+
+func (l *LRU) Victim(evictable func(frame int) bool) (int, bool) {
+	for e := l.order.Back(); e != nil; e = e.Prev() {
+		if frame, _ := e.Value.(int); evictable(frame) {
+			return frame, true
+		}
+	}
+
+	return 0, false
+}
+
+// Clock approximates LRU with a reference bit per frame: the hand sweeps over frames,
+// clearing set bits, and evicts the first evictable frame whose bit is already clear.
+type Clock struct {
+	used []bool // the frame holds a page
+	ref  []bool
+	hand int
+}
+
+func NewClock() *Clock {
+	return &Clock{}
+}
+
+func (c *Clock) Access(frame int) {
+	for len(c.used) <= frame {
+		c.used = append(c.used, false)
+		c.ref = append(c.ref, false)
+	}
+
+	c.used[frame] = true
+	c.ref[frame] = true
+}
+
+func (c *Clock) Remove(frame int) {
+	if frame < len(c.used) {
+		c.used[frame] = false
+		c.ref[frame] = false
+	}
+}
+
+func (c *Clock) Victim(evictable func(frame int) bool) (int, bool) {
+	// Two turns are enough: the first one clears reference bits, the second one finds a frame with a clear bit.
+	for i := 0; i < 2*len(c.used); i++ {
+		frame := c.hand
+		c.hand = (c.hand + 1) % len(c.used)
+
+		if !c.used[frame] || !evictable(frame) {
+			continue
+		}
+
+		if c.ref[frame] {
+			c.ref[frame] = false
+
+			continue
+		}
+
+		return frame, true
+	}
+
+	return 0, false
+}
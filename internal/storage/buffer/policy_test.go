@@ -0,0 +1,71 @@
+package buffer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+)
+
+func all(int) bool {
+	return true
+}
+
+func TestLRU_Victim(t *testing.T) {
+	t.Parallel()
+
+	lru := buffer.NewLRU()
+
+	_, ok := lru.Victim(all)
+	assert.False(t, ok)
+
+	lru.Access(0)
+	lru.Access(1)
+	lru.Access(2)
+	lru.Access(0)
+
+	victim, ok := lru.Victim(all)
+	assert.True(t, ok)
+	assert.Equal(t, 1, victim)
+
+	victim, _ = lru.Victim(func(frame int) bool { return frame != 1 })
+	assert.Equal(t, 2, victim, "pinned frame is skipped")
+
+	lru.Remove(1)
+
+	victim, _ = lru.Victim(all)
+	assert.Equal(t, 2, victim)
+}
+
+func TestClock_Victim(t *testing.T) {
+	t.Parallel()
+
+	clock := buffer.NewClock()
+
+	_, ok := clock.Victim(all)
+	assert.False(t, ok)
+
+	clock.Access(0)
+	clock.Access(1)
+	clock.Access(2)
+
+	// All frames are referenced, the hand clears the bits and comes back to the first frame.
+	victim, ok := clock.Victim(all)
+	assert.True(t, ok)
+	assert.Equal(t, 0, victim)
+
+	// The second frame gets a second chance.
+	clock.Access(1)
+
+	victim, _ = clock.Victim(all)
+	assert.Equal(t, 2, victim)
+
+	clock.Remove(0)
+
+	victim, _ = clock.Victim(func(frame int) bool { return frame != 2 })
+	assert.Equal(t, 1, victim)
+
+	_, ok = clock.Victim(func(int) bool { return false })
+	assert.False(t, ok)
+}
@@ -0,0 +1,221 @@
+// Package buffer implements a buffer pool: a fixed number of page frames shared by page-structured files.
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoFreeFrames is returned when every frame of the pool is pinned and no page can be evicted.
+var ErrNoFreeFrames = errors.New("buffer pool: all frames are pinned")
+
+// File is a page-structured file, the pool reads pages from it and writes dirty pages back.
+type File interface {
+	ReadPage(n int, buf []byte) error
+	WritePage(n int, buf []byte) error
+}
+
+// Stats are counters of the pool.
+type Stats struct {
+	Hits      uint64 // Fetch calls served from a frame
+	Misses    uint64 // Fetch calls that read the page from the file
+	Evictions uint64 // pages dropped from frames to make room for other pages
+	Writes    uint64 // dirty pages written back to files
+}
+
+// pageID identifies a page of a file.
+type pageID struct {
+	file File
+	page int
+}
+
+// Frame holds a page of a file. The page stays in the frame as long as the frame is pinned.
+type Frame struct {
+	index int
+	id    pageID
+	data  []byte
+	pins  int
+	dirty bool
+}
+
+// Data returns the content of the page, it's valid only while the frame is pinned.
+func (f *Frame) Data() []byte {
+	return f.data
+}
+
+// Pool caches pages of files in a fixed number of frames. When all frames are taken, the policy chooses
+// an unpinned frame to evict, a dirty page is written back to its file before the frame is reused.
+// Pool is safe for concurrent use, the content of a frame is guarded by the owner of the file.
+type Pool struct {
+	mu       sync.Mutex
+	pageSize int
+	frames   []*Frame
+	free     []int
+	pages    map[pageID]*Frame
+	policy   Policy
+	stats    Stats
+}
+
+// New creates a pool of frames, each holds a page of pageSize bytes.
+func New(frames, pageSize int, policy Policy) *Pool {
+	p := &Pool{
+		pageSize: pageSize,
+		frames:   make([]*Frame, frames),
+		free:     make([]int, 0, frames),
+		pages:    make(map[pageID]*Frame, frames),
+		policy:   policy,
+	}
+
+	for i := frames - 1; i >= 0; i-- {
+		p.frames[i] = &Frame{
+			index: i,
+			data:  make([]byte, pageSize),
+		}
+
+		p.free = append(p.free, i)
+	}
+
+	return p
+}
+
+// Fetch pins the page of the file in a frame, reading it from the file if it isn't cached.
+// Every Fetch must be followed by Unpin.
+func (p *Pool) Fetch(file File, n int) (*Frame, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := pageID{file: file, page: n}
+
+	if frame, ok := p.pages[id]; ok {
+		frame.pins++
+		p.stats.Hits++
+		p.policy.Access(frame.index)
+
+		return frame, nil
+	}
+
+	p.stats.Misses++
+
+	frame, err := p.frame()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = file.ReadPage(n, frame.data); err != nil {
+		p.free = append(p.free, frame.index)
+
+		return nil, err
+	}
+
+	frame.id = id
+	frame.pins = 1
+	frame.dirty = false
+	p.pages[id] = frame
+	p.policy.Access(frame.index)
+
+	return frame, nil
+}
+
+// Unpin releases the frame taken by Fetch, dirty marks the page as changed.
+func (p *Pool) Unpin(frame *Frame, dirty bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if frame.pins == 0 {
+		panic(fmt.Sprintf("buffer pool: unpin of unpinned page %d", frame.id.page))
+	}
+
+	frame.pins--
+	frame.dirty = frame.dirty || dirty
+}
+
+// Flush writes dirty pages of the file back to it. Pages stay cached.
+func (p *Pool) Flush(file File) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, frame := range p.frames {
+		if frame.id.file != file || !frame.dirty {
+			continue
+		}
+
+		if err := p.write(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Discard drops pages of the file without writing them back, e.g. when the file is removed or closed.
+func (p *Pool) Discard(file File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, frame := range p.pages {
+		if id.file != file {
+			continue
+		}
+
+		delete(p.pages, id)
+		p.policy.Remove(frame.index)
+
+		frame.id = pageID{}
+		frame.pins = 0
+		frame.dirty = false
+		p.free = append(p.free, frame.index)
+	}
+}
+
+// Stats returns counters of the pool.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stats
+}
+
+// frame returns a frame to read a page into: a free one or an evicted one.
+func (p *Pool) frame() (*Frame, error) {
+	if n := len(p.free); n > 0 {
+		index := p.free[n-1]
+		p.free = p.free[:n-1]
+
+		return p.frames[index], nil
+	}
+
+	index, ok := p.policy.Victim(func(index int) bool {
+		return p.frames[index].pins == 0
+	})
+	if !ok {
+		return nil, ErrNoFreeFrames
+	}
+
+	frame := p.frames[index]
+
+	if frame.dirty {
+		if err := p.write(frame); err != nil {
+			return nil, err
+		}
+	}
+
+	delete(p.pages, frame.id)
+	p.policy.Remove(index)
+	p.stats.Evictions++
+
+	frame.id = pageID{}
+
+	return frame, nil
+}
+
+func (p *Pool) write(frame *Frame) error {
+	if err := frame.id.file.WritePage(frame.id.page, frame.data); err != nil {
+		return fmt.Errorf("write back page %d: %w", frame.id.page, err)
+	}
+
+	frame.dirty = false
+	p.stats.Writes++
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package buffer_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+)
+
+const pageSize = 16
+
+// memFile is a page-structured file kept in memory, it counts reads and writes.
+type memFile struct {
+	mu     sync.Mutex
+	pages  map[int][]byte
+	reads  int
+	writes int
+	err    error
+}
+
+func newMemFile() *memFile {
+	return &memFile{
+		pages: make(map[int][]byte),
+	}
+}
+
+func (f *memFile) ReadPage(n int, buf []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+
+	f.reads++
+	clear(buf)
+	copy(buf, f.pages[n])
+
+	return nil
+}
+
+func (f *memFile) WritePage(n int, buf []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+
+	f.writes++
+	f.pages[n] = append([]byte(nil), buf...)
+
+	return nil
+}
+
+func fetch(t *testing.T, pool *buffer.Pool, file buffer.File, n int) *buffer.Frame {
+	t.Helper()
+
+	frame, err := pool.Fetch(file, n)
+	require.NoError(t, err)
+
+	return frame
+}
+
+func TestPool_Fetch(t *testing.T) {
+	t.Parallel()
+
+	file := newMemFile()
+	file.pages[1] = []byte("first")
+
+	pool := buffer.New(2, pageSize, buffer.NewLRU())
+
+	frame := fetch(t, pool, file, 1)
+	assert.Equal(t, []byte("first"), frame.Data()[:5])
+	assert.Len(t, frame.Data(), pageSize)
+	pool.Unpin(frame, false)
+
+	frame = fetch(t, pool, file, 1)
+	pool.Unpin(frame, false)
+
+	assert.Equal(t, 1, file.reads)
+	assert.Equal(t, buffer.Stats{Hits: 1, Misses: 1}, pool.Stats())
+}
+
+func TestPool_Evict(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes dirty page back", func(t *testing.T) {
+		t.Parallel()
+
+		file := newMemFile()
+		pool := buffer.New(1, pageSize, buffer.NewLRU())
+
+		frame := fetch(t, pool, file, 1)
+		copy(frame.Data(), "changed")
+		pool.Unpin(frame, true)
+
+		frame = fetch(t, pool, file, 2)
+		pool.Unpin(frame, false)
+
+		assert.Equal(t, []byte("changed"), file.pages[1][:7])
+		assert.Equal(t, buffer.Stats{Misses: 2, Evictions: 1, Writes: 1}, pool.Stats())
+
+		frame = fetch(t, pool, file, 1)
+		assert.Equal(t, []byte("changed"), frame.Data()[:7])
+		pool.Unpin(frame, false)
+	})
+
+	t.Run("keeps pinned pages", func(t *testing.T) {
+		t.Parallel()
+
+		file := newMemFile()
+		pool := buffer.New(2, pageSize, buffer.NewClock())
+
+		first := fetch(t, pool, file, 1)
+		second := fetch(t, pool, file, 2)
+
+		_, err := pool.Fetch(file, 3)
+		require.ErrorIs(t, err, buffer.ErrNoFreeFrames)
+
+		pool.Unpin(second, false)
+
+		third := fetch(t, pool, file, 3)
+		pool.Unpin(third, false)
+
+		// The first page is still cached, the second one is evicted.
+		pool.Unpin(fetch(t, pool, file, 1), false)
+		pool.Unpin(first, false)
+		assert.Equal(t, 3, file.reads)
+	})
+
+	t.Run("returns write error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("disk is full")
+		file := newMemFile()
+		pool := buffer.New(1, pageSize, buffer.NewLRU())
+
+		pool.Unpin(fetch(t, pool, file, 1), true)
+		file.err = expectedErr
+
+		_, err := pool.Fetch(newMemFile(), 2)
+		require.ErrorIs(t, err, expectedErr)
+
+		// The dirty page stays in the pool.
+		file.err = nil
+		require.NoError(t, pool.Flush(file))
+		assert.Equal(t, 1, file.writes)
+	})
+}
+
+func TestPool_Flush(t *testing.T) {
+	t.Parallel()
+
+	first := newMemFile()
+	second := newMemFile()
+	pool := buffer.New(4, pageSize, buffer.NewLRU())
+
+	pool.Unpin(fetch(t, pool, first, 1), true)
+	pool.Unpin(fetch(t, pool, first, 2), false)
+	pool.Unpin(fetch(t, pool, second, 1), true)
+
+	require.NoError(t, pool.Flush(first))
+	assert.Equal(t, 1, first.writes)
+	assert.Equal(t, 0, second.writes)
+
+	require.NoError(t, pool.Flush(first))
+	assert.Equal(t, 1, first.writes, "flushed page is clean")
+}
+
+func TestPool_Discard(t *testing.T) {
+	t.Parallel()
+
+	file := newMemFile()
+	pool := buffer.New(2, pageSize, buffer.NewClock())
+
+	pool.Unpin(fetch(t, pool, file, 1), true)
+	pool.Unpin(fetch(t, pool, file, 2), true)
+
+	pool.Discard(file)
+
+	pool.Unpin(fetch(t, pool, file, 1), false)
+	assert.Equal(t, 0, file.writes)
+	assert.Equal(t, 3, file.reads)
+	assert.Equal(t, uint64(0), pool.Stats().Evictions)
+}
+
+func TestPool_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 8
+		pages   = 16
+		rounds  = 200
+	)
+
+	files := make([]*memFile, workers)
+	pool := buffer.New(pages/2, pageSize, buffer.NewLRU())
+
+	var wg sync.WaitGroup
+
+	for w := range files {
+		files[w] = newMemFile()
+
+		wg.Add(1)
+
+		go func(file *memFile) {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				frame, err := pool.Fetch(file, i%pages)
+				if errors.Is(err, buffer.ErrNoFreeFrames) {
+					continue
+				}
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				copy(frame.Data(), fmt.Sprint(i%pages))
+				pool.Unpin(frame, true)
+			}
+		}(files[w])
+	}
+
+	wg.Wait()
+
+	for _, file := range files {
+		require.NoError(t, pool.Flush(file))
+
+		for n, data := range file.pages {
+			assert.Equal(t, fmt.Sprint(n), string(data[:len(fmt.Sprint(n))]))
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package codec implements the binary encoding of values, rows and table schemes used by on-disk storage.
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+var errShortBuffer = errors.New("unexpected end of buffer")
+
+// AppendValue appends the binary form of the value to the buf and returns the extended buffer.
+func AppendValue(buf []byte, value sql.Value) ([]byte, error) {
+	if value == nil {
+		return append(buf, byte(sql.Null)), nil
+	}
+
+	buf = append(buf, byte(value.DataType()))
+
+	switch value.DataType() {
+	case sql.Null:
+		return buf, nil
+	case sql.Integer:
+		v, ok := value.Raw().(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected integer value %T", value.Raw())
+		}
+
+		return binary.BigEndian.AppendUint64(buf, uint64(v)), nil
+	case sql.Float:
+		v, ok := value.Raw().(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected float value %T", value.Raw())
+		}
+
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v)), nil
+	case sql.Text:
+		v, ok := value.Raw().(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected text value %T", value.Raw())
+		}
+
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+
+		return append(buf, v...), nil
+	case sql.Boolean:
+		v, ok := value.Raw().(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected boolean value %T", value.Raw())
+		}
+
+		if v {
+			return append(buf, 1), nil
+		}
+
+		return append(buf, 0), nil
+	default:
+		return nil, fmt.Errorf("unsupported data type %s", value.DataType())
+	}
+}
+
+// DecodeValue decodes a value from the beginning of the buf and returns it with the number of bytes read.
+func DecodeValue(buf []byte) (sql.Value, int, error) {
+	if len(buf) == 0 {
+		return nil, 0, errShortBuffer
+	}
+
+	dataType := sql.DataType(buf[0])
+	payload := buf[1:]
+
+	switch dataType {
+	case sql.Null:
+		return datatype.NewNull(), 1, nil
+	case sql.Integer:
+		if len(payload) < 8 {
+			return nil, 0, errShortBuffer
+		}
+
+		return datatype.NewInteger(int64(binary.BigEndian.Uint64(payload))), 9, nil
+	case sql.Float:
+		if len(payload) < 8 {
+			return nil, 0, errShortBuffer
+		}
+
+		return datatype.NewFloat(math.Float64frombits(binary.BigEndian.Uint64(payload))), 9, nil
+	case sql.Text:
+		length, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < length {
+			return nil, 0, errShortBuffer
+		}
+
+		text := string(payload[n : n+int(length)])
+
+		return datatype.NewText(text), 1 + n + int(length), nil
+	case sql.Boolean:
+		if len(payload) < 1 {
+			return nil, 0, errShortBuffer
+		}
+
+		return datatype.NewBoolean(payload[0] == 1), 2, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %s", dataType)
+	}
+}
+
+// AppendRow appends the binary form of the row to the buf and returns the extended buffer.
+func AppendRow(buf []byte, row sql.Row) ([]byte, error) {
+	var err error
+
+	buf = binary.AppendUvarint(buf, uint64(len(row)))
+
+	for i := range row {
+		if buf, err = AppendValue(buf, row[i]); err != nil {
+			return nil, fmt.Errorf("encode value at %d: %w", i, err)
+		}
+	}
+
+	return buf, nil
+}
+
+// DecodeRow decodes a row from the beginning of the buf and returns it with the number of bytes read.
+func DecodeRow(buf []byte) (sql.Row, int, error) {
+	count, offset := binary.Uvarint(buf)
+	if offset <= 0 {
+		return nil, 0, errShortBuffer
+	}
+
+	row := make(sql.Row, 0, count)
+
+	for i := uint64(0); i < count; i++ {
+		value, n, err := DecodeValue(buf[offset:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode value at %d: %w", i, err)
+		}
+
+		row = append(row, value)
+		offset += n
+	}
+
+	return row, offset, nil
+}
+
+// AppendScheme appends the binary form of the scheme to the buf and returns the extended buffer.
+// Columns are written in the order of their positions.
+func AppendScheme(buf []byte, scheme sql.Scheme) ([]byte, error) {
+	var err error
+
+	columns := make([]sql.Column, 0, len(scheme))
+
+	for name := range scheme {
+		columns = append(columns, scheme[name])
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].Position < columns[j].Position
+	})
+
+	buf = binary.AppendUvarint(buf, uint64(len(columns)))
+
+	for i := range columns {
+		var flags byte
+
+		if columns[i].PrimaryKey {
+			flags |= flagPrimaryKey
+		}
+
+		if columns[i].Nullable {
+			flags |= flagNullable
+		}
+
+		if columns[i].Default != nil {
+			flags |= flagDefault
+		}
+
+		if columns[i].Hidden {
+			flags |= flagHidden
+		}
+
+		if columns[i].Sequence != "" {
+			flags |= flagSequence
+		}
+
+		if columns[i].Identity {
+			flags |= flagIdentity
+		}
+
+		buf = append(buf, columns[i].Position, byte(columns[i].DataType), flags)
+
+		if columns[i].PrimaryKey {
+			buf = append(buf, columns[i].KeyPosition)
+		}
+
+		buf = binary.AppendUvarint(buf, uint64(len(columns[i].Name)))
+		buf = append(buf, columns[i].Name...)
+
+		if columns[i].Default != nil {
+			if buf, err = AppendValue(buf, columns[i].Default); err != nil {
+				return nil, fmt.Errorf("encode default of column %q: %w", columns[i].Name, err)
+			}
+		}
+
+		if columns[i].Sequence != "" {
+			buf = binary.AppendUvarint(buf, uint64(len(columns[i].Sequence)))
+			buf = append(buf, columns[i].Sequence...)
+		}
+	}
+
+	return buf, nil
+}
+
+// DecodeScheme decodes a scheme from the beginning of the buf and returns it with the number of bytes read.
+func DecodeScheme(buf []byte) (sql.Scheme, int, error) {
+	count, offset := binary.Uvarint(buf)
+	if offset <= 0 {
+		return nil, 0, errShortBuffer
+	}
+
+	scheme := make(sql.Scheme, count)
+
+	for i := uint64(0); i < count; i++ {
+		if len(buf[offset:]) < 3 {
+			return nil, 0, errShortBuffer
+		}
+
+		column := sql.Column{
+			Position:   buf[offset],
+			DataType:   sql.DataType(buf[offset+1]),
+			PrimaryKey: buf[offset+2]&flagPrimaryKey != 0,
+			Nullable:   buf[offset+2]&flagNullable != 0,
+			Hidden:     buf[offset+2]&flagHidden != 0,
+			Identity:   buf[offset+2]&flagIdentity != 0,
+		}
+
+		hasDefault := buf[offset+2]&flagDefault != 0
+		hasSequence := buf[offset+2]&flagSequence != 0
+		offset += 3
+
+		if column.PrimaryKey {
+			if len(buf[offset:]) < 1 {
+				return nil, 0, errShortBuffer
+			}
+
+			column.KeyPosition = buf[offset]
+			offset++
+		}
+
+		length, n := binary.Uvarint(buf[offset:])
+		if n <= 0 || uint64(len(buf[offset+n:])) < length {
+			return nil, 0, errShortBuffer
+		}
+
+		offset += n
+		column.Name = string(buf[offset : offset+int(length)])
+		offset += int(length)
+
+		if hasDefault {
+			value, m, err := DecodeValue(buf[offset:])
+			if err != nil {
+				return nil, 0, fmt.Errorf("decode default of column %q: %w", column.Name, err)
+			}
+
+			column.Default = value
+			offset += m
+		}
+
+		if hasSequence {
+			length, n = binary.Uvarint(buf[offset:])
+			if n <= 0 || uint64(len(buf[offset+n:])) < length {
+				return nil, 0, errShortBuffer
+			}
+
+			offset += n
+			column.Sequence = string(buf[offset : offset+int(length)])
+			offset += int(length)
+		}
+
+		scheme[column.Name] = column
+	}
+
+	return scheme, offset, nil
+}
+
+const (
+	flagPrimaryKey byte = 1 << iota
+	flagNullable
+	flagDefault
+	flagHidden
+	flagSequence
+	flagIdentity
+)
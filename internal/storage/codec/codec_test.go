@@ -0,0 +1,151 @@
+package codec_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/codec"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	values := []sql.Value{
+		datatype.NewNull(),
+		datatype.NewInteger(-42),
+		datatype.NewFloat(3.14),
+		datatype.NewText("Hello, World!"),
+		datatype.NewText(""),
+		datatype.NewBoolean(true),
+		datatype.NewBoolean(false),
+	}
+
+	for _, value := range values {
+		buf, err := codec.AppendValue(nil, value)
+		require.NoError(t, err)
+
+		decoded, n, err := codec.DecodeValue(buf)
+		require.NoError(t, err)
+		assert.Equal(t, len(buf), n)
+		assert.Equal(t, value, decoded)
+	}
+}
+
+func TestValue_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unsupported data type", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := codec.DecodeValue([]byte{255})
+		require.Error(t, err)
+	})
+
+	t.Run("short buffer", func(t *testing.T) {
+		t.Parallel()
+
+		buf, err := codec.AppendValue(nil, datatype.NewText("Max"))
+		require.NoError(t, err)
+
+		_, _, err = codec.DecodeValue(buf[:len(buf)-1])
+		require.Error(t, err)
+
+		_, _, err = codec.DecodeValue(nil)
+		require.Error(t, err)
+	})
+}
+
+func TestRow(t *testing.T) {
+	t.Parallel()
+
+	row := sql.Row{
+		datatype.NewInteger(1),
+		datatype.NewText("Max"),
+		datatype.NewFloat(99.5),
+		datatype.NewBoolean(true),
+		datatype.NewNull(),
+	}
+
+	buf, err := codec.AppendRow(nil, row)
+	require.NoError(t, err)
+
+	decoded, n, err := codec.DecodeRow(buf)
+	require.NoError(t, err)
+	assert.Equal(t, len(buf), n)
+	assert.Equal(t, row, decoded)
+}
+
+func TestScheme(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": {
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+		},
+		"name": {
+			Position:   1,
+			Name:       "name",
+			DataType:   sql.Text,
+			PrimaryKey: false,
+			Nullable:   true,
+			Default:    datatype.NewText("<unknown>"),
+		},
+		"is_active": {
+			Position:   2,
+			Name:       "is_active",
+			DataType:   sql.Boolean,
+			PrimaryKey: false,
+			Nullable:   false,
+			Default:    datatype.NewBoolean(false),
+		},
+		sql.RowID: {
+			Position:   3,
+			Name:       sql.RowID,
+			DataType:   sql.Integer,
+			PrimaryKey: false,
+			Nullable:   false,
+			Default:    nil,
+			Hidden:     true,
+		},
+		"code": {
+			Position:    4,
+			Name:        "code",
+			DataType:    sql.Text,
+			PrimaryKey:  true,
+			Nullable:    false,
+			Default:     nil,
+			KeyPosition: 1,
+		},
+		"number": {
+			Position: 5,
+			Name:     "number",
+			DataType: sql.Integer,
+			Sequence: "orders_number_seq",
+			Identity: true,
+		},
+		"ticket": {
+			Position: 6,
+			Name:     "ticket",
+			DataType: sql.Integer,
+			Nullable: true,
+			Sequence: "tickets_seq",
+		},
+	}
+
+	buf, err := codec.AppendScheme(nil, scheme)
+	require.NoError(t, err)
+
+	decoded, n, err := codec.DecodeScheme(buf)
+	require.NoError(t, err)
+	assert.Equal(t, len(buf), n)
+	assert.Equal(t, scheme, decoded)
+}
@@ -0,0 +1,137 @@
+package columnar
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"slices"
+)
+
+// bitmap is a set of row numbers.
+type bitmap []uint64
+
+func newBitmap(n int) bitmap {
+	return make(bitmap, (n+63)/64)
+}
+
+func (b bitmap) get(i int) bool {
+	return i/64 < len(b) && b[i/64]&(1<<(i%64)) != 0
+}
+
+func (b bitmap) set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b bitmap) count() int {
+	n := 0
+
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+
+	return n
+}
+
+// with returns a copy of the bitmap that also contains i, the bitmap itself isn't changed.
+func (b bitmap) with(i int) bitmap {
+	c := slices.Clone(b)
+
+	for len(c) <= i/64 {
+		c = append(c, 0)
+	}
+
+	c.set(i)
+
+	return c
+}
+
+func appendWords(buf []byte, words []uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(words)))
+
+	for _, word := range words {
+		buf = binary.LittleEndian.AppendUint64(buf, word)
+	}
+
+	return buf
+}
+
+func decodeWords(buf []byte) ([]uint64, int, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf[n:]))/8 < length {
+		return nil, 0, errUnexpectedEnd
+	}
+
+	words := make([]uint64, length)
+
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(buf[n+i*8:])
+	}
+
+	return words, n + int(length)*8, nil
+}
+
+// packed is an array of unsigned integers of the same bit width laid out back to back,
+// e.g. booleans take one bit and dictionary codes take as many bits as the largest code needs.
+type packed struct {
+	width int
+	words []uint64
+}
+
+func newPacked(width, n int) packed {
+	return packed{
+		width: width,
+		words: make([]uint64, (width*n+63)/64),
+	}
+}
+
+// widthOf returns the number of bits needed to store values up to max.
+func widthOf(maxValue uint64) int {
+	return max(bits.Len64(maxValue), 1)
+}
+
+func (p packed) get(i int) uint64 {
+	offset := i * p.width
+	word, shift := offset/64, offset%64
+	value := p.words[word] >> shift
+
+	// The value continues in the next word.
+	if shift+p.width > 64 {
+		value |= p.words[word+1] << (64 - shift)
+	}
+
+	return value & (1<<p.width - 1)
+}
+
+func (p packed) set(i int, value uint64) {
+	offset := i * p.width
+	word, shift := offset/64, offset%64
+	p.words[word] |= value << shift
+
+	if shift+p.width > 64 {
+		p.words[word+1] |= value >> (64 - shift)
+	}
+}
+
+func (p packed) append(buf []byte) []byte {
+	buf = append(buf, byte(p.width))
+
+	return appendWords(buf, p.words)
+}
+
+func decodePacked(buf []byte) (packed, int, error) {
+	if len(buf) == 0 {
+		return packed{}, 0, errUnexpectedEnd
+	}
+
+	width := int(buf[0])
+	if width < 1 || width > 64 {
+		return packed{}, 0, errors.New("invalid bit width")
+	}
+
+	words, n, err := decodeWords(buf[1:])
+	if err != nil {
+		return packed{}, 0, err
+	}
+
+	return packed{width: width, words: words}, n + 1, nil
+}
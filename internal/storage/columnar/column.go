@@ -0,0 +1,544 @@
+package columnar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+// blockSize is the number of integers delta-encoded together: reading a single value decodes at most one block.
+const blockSize = 128
+
+var errUnexpectedEnd = errors.New("unexpected end of data")
+
+// column is the immutable encoded content of a column in a segment.
+// Rows with NULL keep a placeholder in the encoded values, so the row number is the index of every value.
+type column interface {
+	// value returns the value of the row.
+	value(i int) sql.Value
+	// values appends values of the first n rows.
+	values(dst []sql.Value, n int) []sql.Value
+	// append appends the encoded column to the buffer.
+	append(buf []byte) []byte
+	// size returns the number of bytes taken by encoded values.
+	size() int
+}
+
+// encodeColumn encodes values of the data type with the encoding of the type:
+// integers are delta-encoded, text is dictionary-encoded, booleans are bit-packed, floats are stored as they are.
+func encodeColumn(dataType sql.DataType, values []sql.Value) column {
+	nulls := newBitmap(len(values))
+	hasNulls := false
+
+	for i, value := range values {
+		if value.DataType() == sql.Null {
+			nulls.set(i)
+			hasNulls = true
+		}
+	}
+
+	if !hasNulls {
+		nulls = nil
+	}
+
+	switch dataType {
+	case sql.Integer:
+		return encodeIntegers(values, nulls)
+	case sql.Float:
+		return encodeFloats(values, nulls)
+	case sql.Text:
+		return encodeTexts(values, nulls)
+	default:
+		return encodeBooleans(values, nulls)
+	}
+}
+
+func decodeColumn(dataType sql.DataType, buf []byte, rows int) (column, int, error) {
+	words, n, err := decodeWords(buf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("decode nulls: %w", err)
+	}
+
+	nulls := bitmap(words)
+	if len(nulls) == 0 {
+		nulls = nil
+	}
+
+	var (
+		c column
+		m int
+	)
+
+	switch dataType {
+	case sql.Integer:
+		c, m, err = decodeIntegers(buf[n:], nulls, rows)
+	case sql.Float:
+		c, m, err = decodeFloats(buf[n:], nulls, rows)
+	case sql.Text:
+		c, m, err = decodeTexts(buf[n:], nulls, rows)
+	case sql.Boolean:
+		c, m, err = decodeBooleans(buf[n:], nulls, rows)
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %s", dataType)
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return c, n + m, nil
+}
+
+// intColumn stores every integer as the zigzag varint of the difference from the previous one,
+// so sequences and slowly changing values take a byte or two. The first integer of a block is stored as is.
+type intColumn struct {
+	nulls  bitmap
+	blocks []int // offset of every block in data
+	data   []byte
+}
+
+func encodeIntegers(values []sql.Value, nulls bitmap) *intColumn {
+	c := &intColumn{
+		nulls:  nulls,
+		blocks: make([]int, 0, (len(values)+blockSize-1)/blockSize),
+		data:   make([]byte, 0, len(values)),
+	}
+
+	var previous int64
+
+	for i, value := range values {
+		if i%blockSize == 0 {
+			c.blocks = append(c.blocks, len(c.data))
+			previous = 0
+		}
+
+		// NULL repeats the previous value, so it takes a byte.
+		v, ok := value.Raw().(int64)
+		if !ok {
+			v = previous
+		}
+
+		c.data = binary.AppendVarint(c.data, v-previous)
+		previous = v
+	}
+
+	return c
+}
+
+func decodeIntegers(buf []byte, nulls bitmap, rows int) (*intColumn, int, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf[n:])) < length {
+		return nil, 0, errUnexpectedEnd
+	}
+
+	c := &intColumn{
+		nulls:  nulls,
+		blocks: make([]int, 0, (rows+blockSize-1)/blockSize),
+		data:   buf[n : n+int(length)],
+	}
+
+	// Offsets of blocks aren't stored, they are found by skipping values.
+	for i, offset := 0, 0; i < rows; i++ {
+		if i%blockSize == 0 {
+			c.blocks = append(c.blocks, offset)
+		}
+
+		_, m := binary.Varint(c.data[offset:])
+		if m <= 0 {
+			return nil, 0, errUnexpectedEnd
+		}
+
+		offset += m
+	}
+
+	return c, n + int(length), nil
+}
+
+func (c *intColumn) value(i int) sql.Value {
+	if c.nulls.get(i) {
+		return datatype.NewNull()
+	}
+
+	var (
+		v      int64
+		offset = c.blocks[i/blockSize]
+	)
+
+	for j := 0; j <= i%blockSize; j++ {
+		delta, n := binary.Varint(c.data[offset:])
+		v += delta
+		offset += n
+	}
+
+	return datatype.NewInteger(v)
+}
+
+func (c *intColumn) values(dst []sql.Value, n int) []sql.Value {
+	var (
+		v      int64
+		offset int
+	)
+
+	for i := 0; i < n; i++ {
+		if i%blockSize == 0 {
+			v = 0
+		}
+
+		delta, m := binary.Varint(c.data[offset:])
+		v += delta
+		offset += m
+
+		if c.nulls.get(i) {
+			dst = append(dst, datatype.NewNull())
+		} else {
+			dst = append(dst, datatype.NewInteger(v))
+		}
+	}
+
+	return dst
+}
+
+func (c *intColumn) append(buf []byte) []byte {
+	buf = appendWords(buf, c.nulls)
+	buf = binary.AppendUvarint(buf, uint64(len(c.data)))
+
+	return append(buf, c.data...)
+}
+
+func (c *intColumn) size() int {
+	return len(c.data)
+}
+
+// floatColumn stores floats as they are.
+type floatColumn struct {
+	nulls bitmap
+	data  []uint64
+}
+
+func encodeFloats(values []sql.Value, nulls bitmap) *floatColumn {
+	c := &floatColumn{
+		nulls: nulls,
+		data:  make([]uint64, len(values)),
+	}
+
+	for i, value := range values {
+		v, _ := value.Raw().(float64)
+		c.data[i] = math.Float64bits(v)
+	}
+
+	return c
+}
+
+func decodeFloats(buf []byte, nulls bitmap, rows int) (*floatColumn, int, error) {
+	data, n, err := decodeWords(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(data) != rows {
+		return nil, 0, errors.New("number of floats doesn't match the number of rows")
+	}
+
+	return &floatColumn{nulls: nulls, data: data}, n, nil
+}
+
+func (c *floatColumn) value(i int) sql.Value {
+	if c.nulls.get(i) {
+		return datatype.NewNull()
+	}
+
+	return datatype.NewFloat(math.Float64frombits(c.data[i]))
+}
+
+func (c *floatColumn) values(dst []sql.Value, n int) []sql.Value {
+	for i := 0; i < n; i++ {
+		dst = append(dst, c.value(i))
+	}
+
+	return dst
+}
+
+func (c *floatColumn) append(buf []byte) []byte {
+	buf = appendWords(buf, c.nulls)
+
+	return appendWords(buf, c.data)
+}
+
+func (c *floatColumn) size() int {
+	return len(c.data) * 8
+}
+
+// textColumn stores every distinct string once in the dictionary, rows keep bit-packed codes of their strings.
+type textColumn struct {
+	nulls bitmap
+	dict  []string
+	codes packed
+}
+
+func encodeTexts(values []sql.Value, nulls bitmap) *textColumn {
+	var (
+		dict  = make([]string, 0)
+		codes = make(map[string]uint64)
+		refs  = make([]uint64, len(values))
+	)
+
+	for i, value := range values {
+		if nulls.get(i) {
+			continue
+		}
+
+		s, _ := value.Raw().(string)
+
+		code, ok := codes[s]
+		if !ok {
+			code = uint64(len(dict))
+			codes[s] = code
+			dict = append(dict, s)
+		}
+
+		refs[i] = code
+	}
+
+	c := &textColumn{
+		nulls: nulls,
+		dict:  dict,
+		codes: newPacked(widthOf(uint64(max(len(dict)-1, 0))), len(values)),
+	}
+
+	for i, code := range refs {
+		c.codes.set(i, code)
+	}
+
+	return c
+}
+
+func decodeTexts(buf []byte, nulls bitmap, rows int) (*textColumn, int, error) {
+	length, offset := binary.Uvarint(buf)
+	if offset <= 0 || length > uint64(len(buf)) {
+		return nil, 0, errUnexpectedEnd
+	}
+
+	dict := make([]string, length)
+
+	for i := range dict {
+		size, n := binary.Uvarint(buf[offset:])
+		if n <= 0 || uint64(len(buf[offset+n:])) < size {
+			return nil, 0, errUnexpectedEnd
+		}
+
+		dict[i] = string(buf[offset+n : offset+n+int(size)])
+		offset += n + int(size)
+	}
+
+	codes, n, err := decodePacked(buf[offset:])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(codes.words)*64 < codes.width*rows {
+		return nil, 0, errUnexpectedEnd
+	}
+
+	c := &textColumn{nulls: nulls, dict: dict, codes: codes}
+
+	for i := 0; i < rows; i++ {
+		if !nulls.get(i) && codes.get(i) >= uint64(len(dict)) {
+			return nil, 0, errors.New("dictionary code out of range")
+		}
+	}
+
+	return c, offset + n, nil
+}
+
+func (c *textColumn) value(i int) sql.Value {
+	if c.nulls.get(i) {
+		return datatype.NewNull()
+	}
+
+	return datatype.NewText(c.dict[c.codes.get(i)])
+}
+
+func (c *textColumn) values(dst []sql.Value, n int) []sql.Value {
+	for i := 0; i < n; i++ {
+		dst = append(dst, c.value(i))
+	}
+
+	return dst
+}
+
+func (c *textColumn) append(buf []byte) []byte {
+	buf = appendWords(buf, c.nulls)
+	buf = binary.AppendUvarint(buf, uint64(len(c.dict)))
+
+	for _, s := range c.dict {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+
+	return c.codes.append(buf)
+}
+
+func (c *textColumn) size() int {
+	n := len(c.codes.words) * 8
+
+	for _, s := range c.dict {
+		n += len(s)
+	}
+
+	return n
+}
+
+// boolColumn stores a bit per row.
+type boolColumn struct {
+	nulls bitmap
+	data  packed
+}
+
+func encodeBooleans(values []sql.Value, nulls bitmap) *boolColumn {
+	c := &boolColumn{
+		nulls: nulls,
+		data:  newPacked(1, len(values)),
+	}
+
+	for i, value := range values {
+		if v, _ := value.Raw().(bool); v {
+			c.data.set(i, 1)
+		}
+	}
+
+	return c
+}
+
+func decodeBooleans(buf []byte, nulls bitmap, rows int) (*boolColumn, int, error) {
+	data, n, err := decodePacked(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if data.width != 1 || len(data.words)*64 < rows {
+		return nil, 0, errors.New("invalid packed booleans")
+	}
+
+	return &boolColumn{nulls: nulls, data: data}, n, nil
+}
+
+func (c *boolColumn) value(i int) sql.Value {
+	if c.nulls.get(i) {
+		return datatype.NewNull()
+	}
+
+	return datatype.NewBoolean(c.data.get(i) == 1)
+}
+
+func (c *boolColumn) values(dst []sql.Value, n int) []sql.Value {
+	for i := 0; i < n; i++ {
+		dst = append(dst, c.value(i))
+	}
+
+	return dst
+}
+
+func (c *boolColumn) append(buf []byte) []byte {
+	buf = appendWords(buf, c.nulls)
+
+	return c.data.append(buf)
+}
+
+func (c *boolColumn) size() int {
+	return len(c.data.words) * 8
+}
+
+// keyColumn stores primary keys front-coded: every key is stored as the length of the prefix it shares
+// with the previous key followed by the rest of it, so adjacent keys of a segment take a few bytes.
+type keyColumn struct {
+	data []byte
+}
+
+func encodeKeys(keys []sql.Key) *keyColumn {
+	var (
+		c        = &keyColumn{data: make([]byte, 0, 3*len(keys))}
+		previous sql.Key
+	)
+
+	for _, key := range keys {
+		shared := 0
+
+		for shared < len(key) && shared < len(previous) && key[shared] == previous[shared] {
+			shared++
+		}
+
+		c.data = binary.AppendUvarint(c.data, uint64(shared))
+		c.data = binary.AppendUvarint(c.data, uint64(len(key)-shared))
+		c.data = append(c.data, key[shared:]...)
+		previous = key
+	}
+
+	return c
+}
+
+func decodeKeys(buf []byte, rows int) (*keyColumn, int, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf[n:])) < length {
+		return nil, 0, errUnexpectedEnd
+	}
+
+	c := &keyColumn{data: buf[n : n+int(length)]}
+
+	previous := 0
+
+	for i, offset := 0, 0; i < rows; i++ {
+		shared, m := binary.Uvarint(c.data[offset:])
+		if m <= 0 || shared > uint64(previous) {
+			return nil, 0, errUnexpectedEnd
+		}
+
+		offset += m
+
+		size, m := binary.Uvarint(c.data[offset:])
+		if m <= 0 || uint64(len(c.data[offset+m:])) < size {
+			return nil, 0, errUnexpectedEnd
+		}
+
+		offset += m + int(size)
+		previous = int(shared) + int(size)
+	}
+
+	return c, n + int(length), nil
+}
+
+// keys appends the first n keys. Keys are checked when they are decoded, so reading them never fails.
+func (c *keyColumn) keys(dst []sql.Key, n int) []sql.Key {
+	var (
+		key    []byte
+		offset int
+	)
+
+	for i := 0; i < n; i++ {
+		shared, m := binary.Uvarint(c.data[offset:])
+		offset += m
+
+		size, m := binary.Uvarint(c.data[offset:])
+		offset += m
+
+		key = append(key[:shared], c.data[offset:offset+int(size)]...)
+		offset += int(size)
+
+		dst = append(dst, sql.Key(key))
+	}
+
+	return dst
+}
+
+func (c *keyColumn) append(buf []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(c.data)))
+
+	return append(buf, c.data...)
+}
+
+func (c *keyColumn) size() int {
+	return len(c.data)
+}
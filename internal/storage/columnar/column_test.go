@@ -0,0 +1,139 @@
+package columnar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+)
+
+func TestColumn(t *testing.T) {
+	t.Parallel()
+
+	const rows = 300
+
+	tests := []struct {
+		name     string
+		dataType sql.DataType
+		value    func(i int) sql.Value
+		maxSize  int
+	}{
+		{
+			name:     "integers are delta-encoded",
+			dataType: sql.Integer,
+			value: func(i int) sql.Value {
+				return datatype.NewInteger(int64(1_000_000_000 + i*3))
+			},
+			// A byte per delta plus the first value of every block.
+			maxSize: rows + 3*5,
+		},
+		{
+			name:     "text is dictionary-encoded",
+			dataType: sql.Text,
+			value: func(i int) sql.Value {
+				return datatype.NewText([]string{"pending", "shipped", "delivered"}[i%3])
+			},
+			// Two bits per code plus the dictionary.
+			maxSize: rows*2/8 + 8 + 23,
+		},
+		{
+			name:     "booleans are bit-packed",
+			dataType: sql.Boolean,
+			value: func(i int) sql.Value {
+				return datatype.NewBoolean(i%3 == 0)
+			},
+			maxSize: (rows + 63) / 64 * 8,
+		},
+		{
+			name:     "floats",
+			dataType: sql.Float,
+			value: func(i int) sql.Value {
+				return datatype.NewFloat(float64(i) / 7)
+			},
+			maxSize: rows * 8,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			values := make([]sql.Value, rows)
+
+			for i := range values {
+				values[i] = test.value(i)
+
+				if i%50 == 7 {
+					values[i] = datatype.NewNull()
+				}
+			}
+
+			c := encodeColumn(test.dataType, values)
+			assert.LessOrEqual(t, c.size(), test.maxSize)
+			assert.Equal(t, values, c.values(nil, rows))
+
+			for i, value := range values {
+				assert.Equal(t, value, c.value(i))
+			}
+
+			buf := c.append(nil)
+
+			decoded, n, err := decodeColumn(test.dataType, buf, rows)
+			require.NoError(t, err)
+			assert.Equal(t, len(buf), n)
+			assert.Equal(t, values, decoded.values(nil, rows))
+
+			_, _, err = decodeColumn(test.dataType, buf[:len(buf)-1], rows)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestPacked(t *testing.T) {
+	t.Parallel()
+
+	// Values of 7 bits cross word boundaries.
+	p := newPacked(widthOf(100), 100)
+
+	for i := 0; i < 100; i++ {
+		p.set(i, uint64(i))
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, uint64(i), p.get(i))
+	}
+
+	full := newPacked(64, 2)
+	full.set(1, 1<<63+5)
+	assert.Equal(t, uint64(1<<63+5), full.get(1))
+}
+
+func TestKeyColumn(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]sql.Key, 0, 100)
+
+	for i := 0; i < 100; i++ {
+		key, err := sql.NewKey(datatype.NewText("tenant"), datatype.NewInteger(int64(i)))
+		require.NoError(t, err)
+
+		keys = append(keys, key)
+	}
+
+	encoded := encodeKeys(keys)
+
+	// Keys share the tenant and the high bytes of the integer, so a key takes a few bytes.
+	assert.Less(t, encoded.size(), 4*len(keys))
+
+	decoded, n, err := decodeKeys(encoded.append(nil), len(keys))
+	require.NoError(t, err)
+	assert.Equal(t, len(encoded.append(nil)), n)
+	assert.Equal(t, keys, decoded.keys(nil, len(keys)))
+	assert.Equal(t, keys[:10], decoded.keys(nil, 10))
+
+	_, _, err = decodeKeys(encoded.append(nil), len(keys)+1)
+	require.Error(t, err)
+}
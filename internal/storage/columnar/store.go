@@ -0,0 +1,477 @@
+// Package columnar implements a column-oriented store of rows with primary keys.
+//
+// Rows are appended to the tail, which keeps them column by column as they are. Once the tail holds SegmentSize rows,
+// it's sealed into an immutable segment where every column is stored contiguously with an encoding of its type:
+// delta for integers, a dictionary for text, bit-packing for booleans, front coding for keys.
+// Reading a part of columns decodes only them.
+//
+// A replaced or removed row is only marked as deleted, Compact rewrites live rows without the deleted ones.
+// The store isn't safe for concurrent use, but a Reader doesn't depend on it after creation: it reads rows
+// as of the moment it was created.
+package columnar
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+)
+
+// SegmentSize is the number of rows in a sealed segment.
+const SegmentSize = 1024
+
+// location is the position of a row: the index of the segment, len(segments) stands for the tail.
+type location struct {
+	segment int
+	row     int
+}
+
+// segment is a group of rows encoded column by column. Removing a row replaces the segment
+// with a copy that shares the columns, so readers keep the segments they started with intact.
+type segment struct {
+	rows    int
+	keys    *keyColumn
+	columns []column
+	deleted bitmap
+}
+
+// tail holds rows that don't fill a segment yet. Like segments, it's never changed where a reader may see it:
+// rows are appended past the part visible to readers, removed rows are marked in a copy of the bitmap.
+type tail struct {
+	keys    []sql.Key
+	columns [][]sql.Value
+	deleted bitmap
+}
+
+func newTail(width int) tail {
+	return tail{
+		keys:    make([]sql.Key, 0),
+		columns: make([][]sql.Value, width),
+		deleted: nil,
+	}
+}
+
+// Stats are counters of the store.
+type Stats struct {
+	Rows     int // live rows
+	Deleted  int // replaced and removed rows that take space until compaction
+	Segments int // sealed segments
+	Bytes    int // size of encoded values of sealed segments
+}
+
+// Store keeps rows column by column.
+type Store struct {
+	types     []sql.DataType
+	segments  []*segment
+	tail      tail
+	locations *btree.Tree[sql.Key, location]
+	deleted   int
+}
+
+// New returns an empty store of rows with columns of the types, types are ordered by the column position.
+func New(types []sql.DataType) *Store {
+	return &Store{
+		types:     types,
+		segments:  make([]*segment, 0),
+		tail:      newTail(len(types)),
+		locations: btree.New[sql.Key, location](cmp.Compare[sql.Key]),
+	}
+}
+
+// Len returns the number of rows.
+func (s *Store) Len() int {
+	return s.locations.Len()
+}
+
+// Stats returns counters of the store.
+func (s *Store) Stats() Stats {
+	st := Stats{
+		Rows:     s.locations.Len(),
+		Deleted:  s.deleted,
+		Segments: len(s.segments),
+	}
+
+	for _, seg := range s.segments {
+		st.Bytes += seg.keys.size()
+
+		for _, c := range seg.columns {
+			st.Bytes += c.size()
+		}
+	}
+
+	return st
+}
+
+// Get returns the row with the key.
+func (s *Store) Get(key sql.Key) (sql.Row, bool) {
+	loc, ok := s.locations.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	row := make(sql.Row, len(s.types))
+
+	if loc.segment == len(s.segments) {
+		for position := range row {
+			row[position] = s.tail.columns[position][loc.row]
+		}
+
+		return row, true
+	}
+
+	seg := s.segments[loc.segment]
+
+	for position := range row {
+		row[position] = seg.columns[position].value(loc.row)
+	}
+
+	return row, true
+}
+
+// Keys calls fn for every key in [low, high) in ascending order until fn returns false,
+// the empty high key is unbounded.
+func (s *Store) Keys(low, high sql.Key, fn func(key sql.Key) bool) {
+	s.locations.AscendGreaterOrEqual(low, func(key sql.Key, _ location) bool {
+		return sql.InRange(key, low, high) && fn(key)
+	})
+}
+
+// Check checks that values of the row are of the types of their columns or NULL.
+func (s *Store) Check(row sql.Row) error {
+	if len(row) != len(s.types) {
+		return fmt.Errorf("row has %d values, expected %d", len(row), len(s.types))
+	}
+
+	for position, value := range row {
+		if dataType := value.DataType(); dataType != s.types[position] && dataType != sql.Null {
+			return fmt.Errorf("value of column %d has type %s, expected %s", position, dataType, s.types[position])
+		}
+	}
+
+	return nil
+}
+
+// Put inserts the row or replaces the existing one with the same key, the row must pass Check.
+func (s *Store) Put(key sql.Key, row sql.Row) error {
+	if err := s.Check(row); err != nil {
+		return err
+	}
+
+	if loc, ok := s.locations.Get(key); ok {
+		s.markDeleted(loc)
+	}
+
+	s.tail.keys = append(s.tail.keys, key)
+
+	for position, value := range row {
+		s.tail.columns[position] = append(s.tail.columns[position], value)
+	}
+
+	s.locations.Put(key, location{segment: len(s.segments), row: len(s.tail.keys) - 1})
+
+	if len(s.tail.keys) == SegmentSize {
+		s.seal()
+	}
+
+	return nil
+}
+
+// Delete removes the row with the key and reports whether it existed.
+func (s *Store) Delete(key sql.Key) bool {
+	loc, ok := s.locations.Delete(key)
+	if ok {
+		s.markDeleted(loc)
+	}
+
+	return ok
+}
+
+func (s *Store) markDeleted(loc location) {
+	s.deleted++
+
+	if loc.segment == len(s.segments) {
+		s.tail.deleted = s.tail.deleted.with(loc.row)
+
+		return
+	}
+
+	seg := *s.segments[loc.segment]
+	seg.deleted = seg.deleted.with(loc.row)
+	s.segments[loc.segment] = &seg
+}
+
+// seal encodes the tail into a new segment.
+func (s *Store) seal() {
+	s.segments = append(s.segments, s.tail.encode(s.types))
+	s.tail = newTail(len(s.types))
+}
+
+func (t tail) encode(types []sql.DataType) *segment {
+	seg := &segment{
+		rows:    len(t.keys),
+		keys:    encodeKeys(t.keys),
+		columns: make([]column, len(types)),
+		deleted: t.deleted,
+	}
+
+	for position, dataType := range types {
+		seg.columns[position] = encodeColumn(dataType, t.columns[position])
+	}
+
+	return seg
+}
+
+// Compact rewrites live rows into new segments, so deleted rows don't take space anymore.
+func (s *Store) Compact() {
+	r := s.Reader(nil)
+	c := New(s.types)
+
+	for key, row, ok := r.Next(); ok; key, row, ok = r.Next() {
+		// Rows of the store are valid, so Put doesn't fail.
+		_ = c.Put(key, row)
+	}
+
+	*s = *c
+}
+
+// Reader returns a reader of values of the columns at the positions, other values of rows are NULL.
+// Nil positions read all columns.
+func (s *Store) Reader(positions []uint8) *Reader {
+	if positions == nil {
+		positions = make([]uint8, len(s.types))
+
+		for i := range positions {
+			positions[i] = uint8(i)
+		}
+	}
+
+	// Appending rows changes slice headers of tail columns, so the reader keeps its own ones.
+	tail := s.tail
+	tail.columns = slices.Clone(tail.columns)
+
+	return &Reader{
+		width:     len(s.types),
+		positions: positions,
+		segments:  slices.Clone(s.segments),
+		tail:      tail,
+		keys:      nil,
+		rows:      nil,
+	}
+}
+
+// Reader reads rows of the store in the storage order segment by segment.
+type Reader struct {
+	width     int
+	positions []uint8
+	segments  []*segment
+	tail      tail
+	next      int // index of the next segment to read, len(segments) stands for the tail
+	keys      []sql.Key
+	rows      []sql.Row
+}
+
+// Next returns the next row and its key, false is returned when there are no more rows.
+func (r *Reader) Next() (sql.Key, sql.Row, bool) {
+	for len(r.rows) == 0 {
+		if r.next > len(r.segments) {
+			return "", nil, false
+		}
+
+		if r.next == len(r.segments) {
+			r.readTail()
+		} else {
+			r.readSegment(r.segments[r.next])
+		}
+
+		r.next++
+	}
+
+	key, row := r.keys[0], r.rows[0]
+	r.keys, r.rows = r.keys[1:], r.rows[1:]
+
+	return key, row, true
+}
+
+func (r *Reader) readSegment(seg *segment) {
+	keys := seg.keys.keys(make([]sql.Key, 0, seg.rows), seg.rows)
+	columns := make([][]sql.Value, r.width)
+
+	for _, position := range r.positions {
+		columns[position] = seg.columns[position].values(make([]sql.Value, 0, seg.rows), seg.rows)
+	}
+
+	r.fill(seg.rows, seg.deleted, keys, columns)
+}
+
+func (r *Reader) readTail() {
+	r.fill(len(r.tail.keys), r.tail.deleted, r.tail.keys, r.tail.columns)
+}
+
+// fill makes rows of the batch, skipping deleted ones.
+func (r *Reader) fill(n int, deleted bitmap, keys []sql.Key, columns [][]sql.Value) {
+	r.keys = make([]sql.Key, 0, n)
+	r.rows = make([]sql.Row, 0, n)
+	null := datatype.NewNull()
+
+	for i := 0; i < n; i++ {
+		if deleted.get(i) {
+			continue
+		}
+
+		row := make(sql.Row, r.width)
+
+		for position := range row {
+			row[position] = null
+		}
+
+		for _, position := range r.positions {
+			row[position] = columns[position][i]
+		}
+
+		r.keys = append(r.keys, keys[i])
+		r.rows = append(r.rows, row)
+	}
+}
+
+// AppendTo appends the encoded store to the buffer, the tail is encoded as the last segment.
+func (s *Store) AppendTo(buf []byte) []byte {
+	segments := s.segments
+
+	if len(s.tail.keys) > 0 {
+		segments = append(slices.Clip(segments), s.tail.encode(s.types))
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(s.types)))
+	buf = binary.AppendUvarint(buf, uint64(len(segments)))
+
+	for _, seg := range segments {
+		buf = binary.AppendUvarint(buf, uint64(seg.rows))
+		buf = appendWords(buf, seg.deleted)
+		buf = seg.keys.append(buf)
+
+		for _, c := range seg.columns {
+			buf = c.append(buf)
+		}
+	}
+
+	return buf
+}
+
+// Decode decodes the store encoded by AppendTo, the types must be the ones the store was created with.
+func Decode(types []sql.DataType, buf []byte) (*Store, error) {
+	s := New(types)
+
+	width, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return nil, errUnexpectedEnd
+	}
+
+	if width != uint64(len(types)) {
+		return nil, fmt.Errorf("store has %d columns, expected %d", width, len(types))
+	}
+
+	offset := n
+
+	count, n := binary.Uvarint(buf[offset:])
+	if n <= 0 {
+		return nil, errUnexpectedEnd
+	}
+
+	offset += n
+
+	for i := uint64(0); i < count; i++ {
+		seg, n, err := decodeSegment(types, buf[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("decode segment %d: %w", i, err)
+		}
+
+		offset += n
+
+		if err = s.add(seg, i == count-1); err != nil {
+			return nil, fmt.Errorf("decode segment %d: %w", i, err)
+		}
+	}
+
+	if offset != len(buf) {
+		return nil, errors.New("unexpected data after the last segment")
+	}
+
+	return s, nil
+}
+
+func decodeSegment(types []sql.DataType, buf []byte) (*segment, int, error) {
+	rows, offset := binary.Uvarint(buf)
+	if offset <= 0 || rows == 0 || rows > SegmentSize {
+		return nil, 0, errors.New("invalid number of rows")
+	}
+
+	seg := &segment{
+		rows:    int(rows),
+		columns: make([]column, len(types)),
+	}
+
+	words, n, err := decodeWords(buf[offset:])
+	if err != nil {
+		return nil, 0, fmt.Errorf("decode deleted rows: %w", err)
+	}
+
+	seg.deleted = words
+	offset += n
+
+	if seg.keys, n, err = decodeKeys(buf[offset:], seg.rows); err != nil {
+		return nil, 0, fmt.Errorf("decode keys: %w", err)
+	}
+
+	offset += n
+
+	for position, dataType := range types {
+		if seg.columns[position], n, err = decodeColumn(dataType, buf[offset:], seg.rows); err != nil {
+			return nil, 0, fmt.Errorf("decode column %d: %w", position, err)
+		}
+
+		offset += n
+	}
+
+	return seg, offset, nil
+}
+
+// add adds the decoded segment to the store, the last segment that isn't full becomes the tail.
+func (s *Store) add(seg *segment, last bool) error {
+	keys := seg.keys.keys(make([]sql.Key, 0, seg.rows), seg.rows)
+	current := location{segment: len(s.segments), row: 0}
+
+	if last && seg.rows < SegmentSize {
+		s.tail.deleted = seg.deleted
+
+		for position := range s.types {
+			s.tail.columns[position] = seg.columns[position].values(make([]sql.Value, 0, seg.rows), seg.rows)
+		}
+	} else {
+		s.segments = append(s.segments, seg)
+	}
+
+	for i, key := range keys {
+		if current.segment == len(s.segments) {
+			s.tail.keys = append(s.tail.keys, key)
+		}
+
+		if seg.deleted.get(i) {
+			s.deleted++
+
+			continue
+		}
+
+		current.row = i
+
+		if s.locations.Put(key, current) {
+			return fmt.Errorf("duplicate key %s", key)
+		}
+	}
+
+	return nil
+}
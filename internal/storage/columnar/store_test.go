@@ -0,0 +1,208 @@
+package columnar_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/columnar"
+)
+
+var types = []sql.DataType{sql.Integer, sql.Text, sql.Float, sql.Boolean}
+
+func row(key int64, status string) sql.Row {
+	return sql.Row{
+		datatype.NewInteger(key),
+		datatype.NewText(status),
+		datatype.NewFloat(float64(key) / 2),
+		datatype.NewBoolean(key%2 == 0),
+	}
+}
+
+// readAll returns rows of the reader by their keys.
+func readAll(t *testing.T, r *columnar.Reader) map[sql.Key]sql.Row {
+	t.Helper()
+
+	rows := make(map[sql.Key]sql.Row)
+
+	for key, row, ok := r.Next(); ok; key, row, ok = r.Next() {
+		require.NotContains(t, rows, key)
+
+		rows[key] = row
+	}
+
+	return rows
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	const n = 3*columnar.SegmentSize + 100
+
+	store := columnar.New(types)
+
+	for key := int64(1); key <= n; key++ {
+		require.NoError(t, store.Put(sql.IntegerKey(key), row(key, "new")))
+	}
+
+	for key := int64(1); key <= n; key += 3 {
+		require.True(t, store.Delete(sql.IntegerKey(key)))
+	}
+
+	assert.False(t, store.Delete(sql.IntegerKey(1)))
+
+	for key := int64(2); key <= n; key += 3 {
+		require.NoError(t, store.Put(sql.IntegerKey(key), row(key, "shipped")))
+	}
+
+	require.Error(t, store.Put(sql.IntegerKey(n+1), sql.Row{datatype.NewInteger(1)}))
+	require.Error(t, store.Put(sql.IntegerKey(n+1), sql.Row{
+		datatype.NewText("1"),
+		datatype.NewText("new"),
+		datatype.NewFloat(1),
+		datatype.NewBoolean(true),
+	}))
+	require.NoError(t, store.Put(sql.IntegerKey(n+1), sql.Row{
+		datatype.NewInteger(n + 1),
+		datatype.NewNull(),
+		datatype.NewNull(),
+		datatype.NewNull(),
+	}))
+
+	expected := make(map[sql.Key]sql.Row)
+
+	for key := int64(1); key <= n; key++ {
+		switch key % 3 {
+		case 2:
+			expected[sql.IntegerKey(key)] = row(key, "shipped")
+		case 0:
+			expected[sql.IntegerKey(key)] = row(key, "new")
+		}
+	}
+
+	expected[sql.IntegerKey(n+1)] = sql.Row{datatype.NewInteger(n + 1), datatype.NewNull(), datatype.NewNull(), datatype.NewNull()}
+
+	check := func(t *testing.T, store *columnar.Store) {
+		t.Helper()
+
+		assert.Equal(t, len(expected), store.Len())
+		assert.Equal(t, expected, readAll(t, store.Reader(nil)))
+
+		for key, expectedRow := range expected {
+			actual, ok := store.Get(key)
+			require.True(t, ok)
+			require.Equal(t, expectedRow, actual)
+		}
+
+		_, ok := store.Get(sql.IntegerKey(1))
+		assert.False(t, ok)
+
+		keys := make([]sql.Key, 0)
+
+		store.Keys(sql.IntegerKey(1), sql.IntegerKey(10), func(key sql.Key) bool {
+			keys = append(keys, key)
+
+			return true
+		})
+
+		expectedKeys := []sql.Key{
+			sql.IntegerKey(2), sql.IntegerKey(3), sql.IntegerKey(5), sql.IntegerKey(6), sql.IntegerKey(8), sql.IntegerKey(9),
+		}
+		assert.Equal(t, expectedKeys, keys)
+	}
+
+	t.Run("reads rows", func(t *testing.T) {
+		t.Parallel()
+
+		check(t, store)
+	})
+
+	t.Run("decodes encoded rows", func(t *testing.T) {
+		t.Parallel()
+
+		buf := store.AppendTo(nil)
+
+		decoded, err := columnar.Decode(types, buf)
+		require.NoError(t, err)
+		check(t, decoded)
+		assert.Equal(t, store.Stats(), decoded.Stats())
+
+		_, err = columnar.Decode(types[:2], buf)
+		require.Error(t, err)
+
+		_, err = columnar.Decode(types, buf[:len(buf)-1])
+		require.Error(t, err)
+	})
+
+	t.Run("compacts rows", func(t *testing.T) {
+		t.Parallel()
+
+		decoded, err := columnar.Decode(types, store.AppendTo(nil))
+		require.NoError(t, err)
+
+		before := decoded.Stats()
+		assert.Equal(t, n-n/3, before.Deleted)
+
+		decoded.Compact()
+
+		after := decoded.Stats()
+		assert.Zero(t, after.Deleted)
+		assert.Less(t, after.Bytes, before.Bytes)
+		check(t, decoded)
+	})
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads only the columns", func(t *testing.T) {
+		t.Parallel()
+
+		store := columnar.New(types)
+
+		for key := int64(1); key <= columnar.SegmentSize+10; key++ {
+			require.NoError(t, store.Put(sql.IntegerKey(key), row(key, "new")))
+		}
+
+		rows := readAll(t, store.Reader([]uint8{1, 3}))
+		require.Len(t, rows, columnar.SegmentSize+10)
+
+		for key, actual := range rows {
+			value, _ := key.Integer()
+			expected := row(value, "new")
+			expected[0] = datatype.NewNull()
+			expected[2] = datatype.NewNull()
+			require.Equal(t, expected, actual)
+		}
+	})
+
+	t.Run("reads rows as of its creation", func(t *testing.T) {
+		t.Parallel()
+
+		store := columnar.New(types)
+
+		for key := int64(1); key <= columnar.SegmentSize+10; key++ {
+			require.NoError(t, store.Put(sql.IntegerKey(key), row(key, "new")))
+		}
+
+		r := store.Reader(nil)
+
+		for key := int64(1); key <= columnar.SegmentSize+10; key++ {
+			require.NoError(t, store.Put(sql.IntegerKey(key), row(key, "shipped")))
+		}
+
+		require.NoError(t, store.Put(sql.IntegerKey(0), row(0, "new")))
+		store.Compact()
+
+		rows := readAll(t, r)
+		require.Len(t, rows, columnar.SegmentSize+10)
+
+		for key, actual := range rows {
+			value, _ := key.Integer()
+			require.Equal(t, row(value, "new"), actual)
+		}
+	})
+}
@@ -0,0 +1,465 @@
+// Package disk implements persistent storage: every database is a directory, every table is a page-structured file
+// (EngineHeap), a directory of an LSM tree (EngineLSM) or a directory of columns (EngineColumnar).
+//
+// All changes are written to the write-ahead log before they are applied to data files. When the catalog is opened,
+// the log is replayed on top of data files, so every acknowledged change survives a crash of the process.
+// Periodically, data files are flushed to the disk and the log is truncated (a checkpoint).
+package disk
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/wal"
+)
+
+// DefaultCheckpointSize is the size of the write-ahead log that triggers a checkpoint.
+const DefaultCheckpointSize = 16 << 20
+
+// DefaultBufferPoolSize is the number of pages cached by the buffer pool unless another pool is given.
+const DefaultBufferPoolSize = 4096
+
+// Storage engines of tables.
+const (
+	// EngineHeap stores a table in a page-structured data file read through the buffer pool.
+	EngineHeap = "heap"
+	// EngineLSM stores a table in a log-structured merge tree, it suits write-heavy tables.
+	EngineLSM = "lsm"
+	// EngineColumnar stores a table column by column, it suits analytic tables whose queries read a few columns.
+	EngineColumnar = "columnar"
+)
+
+type options struct {
+	groupCommit    time.Duration
+	checkpointSize int64
+	pool           *buffer.Pool
+	engine         string
+	lsm            lsm.Options
+}
+
+// Option configures the catalog.
+type Option func(*options)
+
+// WithGroupCommit makes writers wait up to the window before flushing the log,
+// so concurrent changes are flushed together by a single fsync.
+func WithGroupCommit(window time.Duration) Option {
+	return func(o *options) {
+		o.groupCommit = window
+	}
+}
+
+// WithCheckpointSize sets the size of the write-ahead log that triggers a checkpoint.
+func WithCheckpointSize(size int64) Option {
+	return func(o *options) {
+		o.checkpointSize = size
+	}
+}
+
+// WithBufferPool makes tables read pages through the pool instead of the default one.
+// The pool must have frames of PageSize bytes, it may be shared with other catalogs.
+func WithBufferPool(pool *buffer.Pool) Option {
+	return func(o *options) {
+		o.pool = pool
+	}
+}
+
+// WithEngine sets the storage engine of databases created without one, EngineHeap is the default.
+func WithEngine(engine string) Option {
+	return func(o *options) {
+		o.engine = engine
+	}
+}
+
+// WithLSMOptions configures LSM trees of tables stored by EngineLSM.
+func WithLSMOptions(opts lsm.Options) Option {
+	return func(o *options) {
+		o.lsm = opts
+	}
+}
+
+// Catalog keeps databases in the data directory. Every database has a storage engine
+// that is the default one for its tables, a table may have an engine of its own.
+type Catalog struct {
+	mu        sync.RWMutex
+	dir       string
+	engine    string
+	journal   *journal
+	pool      *buffer.Pool
+	lsm       lsm.Options
+	databases map[string]*Database
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Open opens the catalog stored in the data directory, the directory is created if it doesn't exist.
+func Open(dir string, opts ...Option) (*Catalog, error) {
+	o := options{
+		groupCommit:    0,
+		checkpointSize: DefaultCheckpointSize,
+		engine:         EngineHeap,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := checkEngine(o.engine); err != nil {
+		return nil, err
+	}
+
+	if o.pool == nil {
+		o.pool = buffer.New(DefaultBufferPoolSize, PageSize, buffer.NewClock())
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	log, err := wal.Open(filepath.Join(dir, walFileName), o.groupCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		dir:       dir,
+		engine:    o.engine,
+		journal:   newJournal(log, o.checkpointSize),
+		pool:      o.pool,
+		lsm:       o.lsm,
+		databases: make(map[string]*Database),
+		done:      make(chan struct{}),
+	}
+
+	if err = catalog.recover(); err != nil {
+		_ = catalog.closeDatabases()
+		_ = log.Close()
+
+		return nil, err
+	}
+
+	catalog.wg.Add(1)
+
+	go catalog.checkpointer()
+
+	return catalog, nil
+}
+
+// recover opens data files, replays the write-ahead log on top of them and makes a checkpoint.
+func (c *Catalog) recover() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("read data directory: %w", err)
+	}
+
+	for _, dirEntry := range entries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+
+		database, err := openDatabase(filepath.Join(c.dir, dirEntry.Name()), dirEntry.Name(), c.journal, c.pool, c.lsm)
+		if err != nil {
+			return fmt.Errorf("open database %q: %w", dirEntry.Name(), err)
+		}
+
+		c.databases[dirEntry.Name()] = database
+	}
+
+	err = c.journal.log.Replay(func(record []byte) error {
+		e, err := decodeEntry(record)
+		if err != nil {
+			return fmt.Errorf("decode log entry: %w", err)
+		}
+
+		return c.apply(e)
+	})
+	if err != nil {
+		return fmt.Errorf("replay log: %w", err)
+	}
+
+	return c.checkpoint()
+}
+
+// apply applies the replayed entry. Data files may already contain the change, so every operation is idempotent.
+func (c *Catalog) apply(e entry) error {
+	switch e.op {
+	case opCreateDatabase:
+		engine := cmp.Or(e.engine, EngineHeap)
+
+		// The engine is stored after the directory is created, so the creation may have stopped in between.
+		if database, ok := c.databases[e.database]; ok {
+			return database.setEngine(engine)
+		}
+
+		_, err := c.createDatabase(e.database, engine)
+
+		return err
+	case opDropDatabase:
+		return c.dropDatabase(e.database)
+	}
+
+	database, ok := c.databases[e.database]
+	if !ok {
+		return nil
+	}
+
+	switch e.op {
+	case opCreateTable:
+		if _, ok = database.tables[e.table]; ok {
+			return nil
+		}
+
+		_, err := database.createTable(e.table, e.scheme, cmp.Or(e.engine, EngineHeap))
+
+		return err
+	case opDropTable:
+		return database.dropTable(e.table)
+	case opCreateSequence:
+		if _, ok = database.sequences[e.sequence.name]; !ok {
+			database.createSequence(e.sequence)
+		}
+
+		return nil
+	case opDropSequence:
+		database.dropSequence(e.sequence.name)
+
+		return nil
+	case opSetSequence:
+		if sequence, ok := database.sequences[e.sequence.name]; ok {
+			sequence.set(e.sequence.value, e.sequence.called)
+		}
+
+		return nil
+	case opSetStatistics:
+		if _, ok = database.tables[e.table]; ok {
+			database.statistics[e.table] = e.statistics
+		}
+
+		return nil
+	}
+
+	table, ok := database.tables[e.table]
+	if !ok {
+		return nil
+	}
+
+	switch e.op {
+	case opInsert, opUpdate:
+		record, err := encodeRecord(e.key, e.row)
+		if err != nil {
+			return err
+		}
+
+		return table.put(e.key, e.row, record)
+	case opDelete:
+		return table.remove(e.key)
+	case opCreateIndex:
+		return table.createIndex(e.index)
+	case opDropIndex:
+		return table.dropIndex(e.index.name)
+	default:
+		return fmt.Errorf("unknown operation %d", e.op)
+	}
+}
+
+func (c *Catalog) GetDatabase(name string) (sql.Database, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if database, ok := c.databases[name]; ok {
+		return database, nil
+	}
+
+	return nil, fmt.Errorf("database %q not found", name)
+}
+
+func (c *Catalog) ListDatabases() ([]sql.Database, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	databases := make([]sql.Database, 0, len(c.databases))
+
+	for name := range c.databases {
+		databases = append(databases, c.databases[name])
+	}
+
+	return databases, nil
+}
+
+func (c *Catalog) CreateDatabase(name string) (sql.Database, error) {
+	return c.CreateDatabaseUsing(name, "")
+}
+
+// CreateDatabaseUsing creates the database whose tables are stored by the engine unless a table has
+// an engine of its own. An empty name selects the engine of the catalog.
+func (c *Catalog) CreateDatabaseUsing(name, engine string) (sql.Database, error) {
+	if engine == "" {
+		engine = c.engine
+	}
+
+	if err := checkEngine(engine); err != nil {
+		return nil, err
+	}
+
+	c.journal.mu.RLock()
+	defer c.journal.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.databases[name]; ok {
+		return nil, fmt.Errorf("database %q already exist", name)
+	}
+
+	if err := c.journal.write(entry{op: opCreateDatabase, database: name, engine: engine}); err != nil {
+		return nil, err
+	}
+
+	return c.createDatabase(name, engine)
+}
+
+func (c *Catalog) DropDatabase(name string) error {
+	c.journal.mu.RLock()
+	defer c.journal.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.databases[name]; !ok {
+		return fmt.Errorf("database %q not found", name)
+	}
+
+	if err := c.journal.write(entry{op: opDropDatabase, database: name}); err != nil {
+		return err
+	}
+
+	return c.dropDatabase(name)
+}
+
+func (c *Catalog) createDatabase(name, engine string) (*Database, error) {
+	database, err := createDatabase(filepath.Join(c.dir, name), name, engine, c.journal, c.pool, c.lsm)
+	if err != nil {
+		return nil, fmt.Errorf("create database %q: %w", name, err)
+	}
+
+	c.databases[name] = database
+
+	return database, nil
+}
+
+func (c *Catalog) dropDatabase(name string) error {
+	database, ok := c.databases[name]
+	if !ok {
+		return nil
+	}
+
+	if err := database.Close(); err != nil {
+		return fmt.Errorf("close database %q: %w", name, err)
+	}
+
+	if err := os.RemoveAll(database.dir); err != nil {
+		return fmt.Errorf("remove database %q: %w", name, err)
+	}
+
+	delete(c.databases, name)
+
+	return nil
+}
+
+// BufferStats returns counters of the buffer pool, e.g. to size it by the hit ratio.
+func (c *Catalog) BufferStats() buffer.Stats {
+	return c.pool.Stats()
+}
+
+// Checkpoint flushes all data files to the disk and truncates the write-ahead log.
+func (c *Catalog) Checkpoint() error {
+	c.journal.mu.Lock()
+	defer c.journal.mu.Unlock()
+
+	return c.checkpoint()
+}
+
+func (c *Catalog) checkpoint() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, database := range c.databases {
+		if err := database.sync(); err != nil {
+			return fmt.Errorf("sync database %q: %w", name, err)
+		}
+	}
+
+	if err := syncDir(c.dir); err != nil {
+		return err
+	}
+
+	return c.journal.log.Reset()
+}
+
+// checkpointer makes a checkpoint every time the log grows over the limit.
+func (c *Catalog) checkpointer() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.journal.checkpointCh:
+			// If the checkpoint fails, the log keeps growing and the next write triggers another attempt.
+			_ = c.Checkpoint()
+		}
+	}
+}
+
+// Close makes a checkpoint and closes all databases, the catalog must not be used after that.
+func (c *Catalog) Close() error {
+	close(c.done)
+	c.wg.Wait()
+
+	var errs []error
+
+	if err := c.Checkpoint(); err != nil {
+		errs = append(errs, fmt.Errorf("checkpoint: %w", err))
+	}
+
+	if err := c.closeDatabases(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.journal.log.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close log: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Catalog) closeDatabases() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+
+	for name, database := range c.databases {
+		if err := database.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close database %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkEngine(engine string) error {
+	switch engine {
+	case EngineHeap, EngineLSM, EngineColumnar:
+		return nil
+	default:
+		return fmt.Errorf("unknown storage engine %q", engine)
+	}
+}
@@ -0,0 +1,156 @@
+package disk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+)
+
+func TestCatalog_GetDatabase(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		expected, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		database, err := catalog.GetDatabase("playground")
+		require.NoError(t, err)
+		require.Equal(t, expected, database)
+	})
+
+	t.Run("returns error if database not exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.GetDatabase("not-exist")
+		require.Error(t, err)
+		require.Nil(t, database)
+	})
+}
+
+func TestCatalog_ListDatabases(t *testing.T) {
+	t.Parallel()
+
+	catalog := openCatalog(t, t.TempDir())
+
+	databases, err := catalog.ListDatabases()
+	require.NoError(t, err)
+	require.Empty(t, databases)
+
+	first, err := catalog.CreateDatabase("first")
+	require.NoError(t, err)
+
+	second, err := catalog.CreateDatabase("second")
+	require.NoError(t, err)
+
+	databases, err = catalog.ListDatabases()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []sql.Database{first, second}, databases)
+}
+
+func TestCatalog_CreateDatabase(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create database successfully", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+
+		db, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		require.DirExists(t, dir+"/playground")
+	})
+
+	t.Run("returns error if database already exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		db, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+		require.NotNil(t, db)
+
+		db, err = catalog.CreateDatabase("playground")
+		require.Error(t, err)
+		require.Nil(t, db)
+	})
+}
+
+func TestCatalog_DropDatabase(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drop database without errors", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+
+		db, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		_, err = db.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+
+		err = catalog.DropDatabase("playground")
+		require.NoError(t, err)
+		require.NoDirExists(t, dir+"/playground")
+
+		_, err = catalog.GetDatabase("playground")
+		require.Error(t, err)
+	})
+
+	t.Run("returns error if database not exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		err := catalog.DropDatabase("playground")
+		require.Error(t, err)
+	})
+}
+
+func TestCatalog_Reopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	catalog, err := disk.Open(dir)
+	require.NoError(t, err)
+
+	_, err = catalog.CreateDatabase("first")
+	require.NoError(t, err)
+
+	_, err = catalog.CreateDatabase("second")
+	require.NoError(t, err)
+
+	err = catalog.DropDatabase("first")
+	require.NoError(t, err)
+
+	require.NoError(t, catalog.Close())
+
+	catalog = openCatalog(t, dir)
+
+	databases, err := catalog.ListDatabases()
+	require.NoError(t, err)
+	require.Len(t, databases, 1)
+	require.Equal(t, "second", databases[0].Name())
+}
+
+func openCatalog(t *testing.T, dir string, opts ...disk.Option) *disk.Catalog {
+	t.Helper()
+
+	catalog, err := disk.Open(dir, opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, catalog.Close())
+	})
+
+	return catalog
+}
@@ -0,0 +1,499 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/columnar"
+)
+
+const (
+	// columnarTableExt is the extension of directories of tables stored column by column.
+	columnarTableExt = ".col"
+	// columnarDataName is the file in the table directory that holds the encoded columns.
+	columnarDataName = "data"
+)
+
+// ColumnarTable stores its rows column by column, every column is stored contiguously with an encoding of its type,
+// which suits analytic tables: a scan of a few columns decodes only them (see ScanColumns).
+//
+// Columns are kept in memory and written to the data file of the table directory as a whole when the table
+// is synced. The scheme, the sequence value and index definitions are stored in the header file,
+// the content of secondary indexes is rebuilt when the table is opened.
+//
+// Every change is written to the journal before it's applied to the columns.
+type ColumnarTable struct {
+	mu         sync.RWMutex
+	database   string
+	name       string
+	dir        string
+	scheme     sql.Scheme
+	primaryKey []sql.Column
+	header     *dataFile
+	journal    *journal
+	store      *columnar.Store
+	dirty      bool // columns have changed since they were written
+	seq        *Sequence
+	tableIndexes
+}
+
+func newColumnarTable(
+	dir, database, name string,
+	scheme sql.Scheme,
+	header *dataFile,
+	journal *journal,
+	seq int64,
+) *ColumnarTable {
+	t := &ColumnarTable{
+		database:   database,
+		name:       name,
+		dir:        dir,
+		scheme:     scheme,
+		primaryKey: scheme.PrimaryKey(),
+		header:     header,
+		journal:    journal,
+		store:      columnar.New(columnTypes(scheme)),
+		seq: &Sequence{
+			mu:    sync.RWMutex{},
+			value: seq,
+		},
+	}
+
+	t.tableIndexes = newTableIndexes(t, &t.mu, journal, header)
+
+	return t
+}
+
+func createColumnarTable(dir, database, name string, scheme sql.Scheme, journal *journal) (*ColumnarTable, error) {
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	header, err := createDataFile(filepath.Join(dir, headerFileName), scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return newColumnarTable(dir, database, name, scheme, header, journal, 0), nil
+}
+
+func openColumnarTable(dir, database, name string, journal *journal) (*ColumnarTable, error) {
+	header, h, err := openDataFile(filepath.Join(dir, headerFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	table := newColumnarTable(dir, database, name, h.scheme, header, journal, h.sequence)
+
+	if err = table.loadIndexes(h.indexes); err != nil {
+		_ = header.close()
+
+		return nil, err
+	}
+
+	if err = table.load(); err != nil {
+		_ = header.close()
+
+		return nil, fmt.Errorf("load table %q: %w", name, err)
+	}
+
+	return table, nil
+}
+
+// load reads the data file and restores the sequence value and the content of indexes.
+// A table that has never been synced has no data file.
+func (t *ColumnarTable) load() error {
+	buf, err := os.ReadFile(filepath.Join(t.dir, columnarDataName))
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case len(buf) < 4 || crc32.ChecksumIEEE(buf[4:]) != binary.BigEndian.Uint32(buf):
+		return errors.New("data file is corrupted")
+	}
+
+	if t.store, err = columnar.Decode(columnTypes(t.scheme), buf[4:]); err != nil {
+		return fmt.Errorf("decode data file: %w", err)
+	}
+
+	r := t.store.Reader(nil)
+
+	for key, row, ok := r.Next(); ok; key, row, ok = r.Next() {
+		t.seq.advance(key)
+
+		for _, idx := range t.indexes {
+			idx.Insert(key, row)
+		}
+	}
+
+	return nil
+}
+
+func (t *ColumnarTable) Name() string {
+	return t.name
+}
+
+func (t *ColumnarTable) Scheme() sql.Scheme {
+	return t.scheme
+}
+
+func (t *ColumnarTable) PrimaryKey() []sql.Column {
+	return t.primaryKey
+}
+
+func (t *ColumnarTable) Sequence() sql.Sequence {
+	return t.seq
+}
+
+// Scan returns all rows in the storage order, the iterator doesn't observe changes made after it's created.
+func (t *ColumnarTable) Scan() (sql.RowIter, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return &columnarIter{reader: t.store.Reader(nil)}, nil
+}
+
+// ScanColumns is like Scan, but it decodes only the columns at the positions and the primary key,
+// other values of rows are NULL.
+func (t *ColumnarTable) ScanColumns(positions []uint8) (sql.RowIter, error) {
+	for _, position := range positions {
+		if int(position) >= len(t.scheme) {
+			return nil, fmt.Errorf("column position %d is out of range", position)
+		}
+	}
+
+	positions = slices.Clip(positions)
+
+	for _, column := range t.primaryKey {
+		if !slices.Contains(positions, column.Position) {
+			positions = append(positions, column.Position)
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return &columnarIter{reader: t.store.Reader(positions)}, nil
+}
+
+func (t *ColumnarTable) Get(key sql.Key) (sql.Row, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	row, ok := t.store.Get(key)
+
+	return row, ok, nil
+}
+
+func (t *ColumnarTable) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	low, high, ok, err := sql.KeyRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	i := &rangeIter{
+		read: t.readRange,
+		from: low,
+		to:   high,
+		rows: nil,
+	}
+
+	return i, nil
+}
+
+func (t *ColumnarTable) Insert(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.store.Get(key); exists {
+		return fmt.Errorf("duplicate primary key: %s", key)
+	}
+
+	if err := t.store.Check(row); err != nil {
+		return err
+	}
+
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	if err := t.journal.write(t.entry(opInsert, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, nil)
+}
+
+func (t *ColumnarTable) Delete(key sql.Key) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.store.Get(key); !exists {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err := t.journal.write(t.entry(opDelete, key, nil)); err != nil {
+		return err
+	}
+
+	return t.remove(key)
+}
+
+func (t *ColumnarTable) Update(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.store.Get(key); !exists {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err := t.store.Check(row); err != nil {
+		return err
+	}
+
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	if err := t.journal.write(t.entry(opUpdate, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, nil)
+}
+
+func (t *ColumnarTable) entry(op operation, key sql.Key, row sql.Row) entry {
+	return entry{
+		op:       op,
+		database: t.database,
+		table:    t.name,
+		key:      key,
+		row:      row,
+	}
+}
+
+// put inserts the row or replaces the existing one with the same key, the encoded record isn't used.
+// Uniqueness of indexes isn't checked, see Table.put.
+func (t *ColumnarTable) put(key sql.Key, row sql.Row, _ []byte) error {
+	previous, _ := t.store.Get(key)
+
+	if err := t.store.Put(key, row); err != nil {
+		return err
+	}
+
+	t.dirty = true
+	t.seq.advance(key)
+
+	for _, idx := range t.indexes {
+		if previous != nil {
+			idx.Delete(key, previous)
+		}
+
+		idx.Insert(key, row)
+	}
+
+	return nil
+}
+
+// remove deletes the row with the key if it exists.
+func (t *ColumnarTable) remove(key sql.Key) error {
+	previous, ok := t.store.Get(key)
+	if !ok {
+		return nil
+	}
+
+	t.store.Delete(key)
+	t.dirty = true
+
+	for _, idx := range t.indexes {
+		idx.Delete(key, previous)
+	}
+
+	return nil
+}
+
+// readRange returns up to limit rows with keys in the range [low, high) in the key order
+// and the key of the last returned row.
+func (t *ColumnarTable) readRange(low, high sql.Key, limit int) ([]sql.Row, sql.Key, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	keys := make([]sql.Key, 0, limit)
+
+	t.store.Keys(low, high, func(key sql.Key) bool {
+		keys = append(keys, key)
+
+		return len(keys) < limit
+	})
+
+	if len(keys) == 0 {
+		return nil, "", nil
+	}
+
+	rows, err := t.readRows(keys)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rows, keys[len(keys)-1], nil
+}
+
+// readRows returns rows with the keys, keys of removed rows are skipped. The caller must hold the lock.
+func (t *ColumnarTable) readRows(keys []sql.Key) ([]sql.Row, error) {
+	rows := make([]sql.Row, 0, len(keys))
+
+	for _, key := range keys {
+		if row, ok := t.store.Get(key); ok {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// scanAll calls fn for every row of the table. The caller must hold the lock.
+func (t *ColumnarTable) scanAll(fn func(key sql.Key, row sql.Row) error) error {
+	r := t.store.Reader(nil)
+
+	for key, row, ok := r.Next(); ok; key, row, ok = r.Next() {
+		if err := fn(key, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeData replaces the data file with the current content of columns. The new file is written next to
+// the old one and renamed over it, so a crash leaves one of them intact. The caller must hold the lock.
+func (t *ColumnarTable) writeData() error {
+	if !t.dirty {
+		return nil
+	}
+
+	buf := make([]byte, 4, 4+t.store.Len()*8)
+	buf = t.store.AppendTo(buf)
+	binary.BigEndian.PutUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+
+	path := filepath.Join(t.dir, columnarDataName)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+
+	if err = errors.Join(err, f.Close()); err != nil {
+		return fmt.Errorf("write data file: %w", err)
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write data file: %w", err)
+	}
+
+	if err = syncDir(t.dir); err != nil {
+		return err
+	}
+
+	t.dirty = false
+
+	return nil
+}
+
+// sync compacts columns if most of stored rows are deleted, writes the data file
+// and writes the sequence value to the header file.
+func (t *ColumnarTable) sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stats := t.store.Stats(); stats.Deleted > stats.Rows {
+		t.store.Compact()
+	}
+
+	if err := t.writeData(); err != nil {
+		return err
+	}
+
+	if err := t.header.writeSequence(t.seq.Value()); err != nil {
+		return err
+	}
+
+	return t.header.sync()
+}
+
+// Close writes the data file and closes the header file.
+func (t *ColumnarTable) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.writeData()
+	if err == nil {
+		err = t.header.writeSequence(t.seq.Value())
+	}
+
+	return errors.Join(err, t.header.close())
+}
+
+// columnTypes returns data types of columns ordered by position.
+func columnTypes(scheme sql.Scheme) []sql.DataType {
+	types := make([]sql.DataType, len(scheme))
+
+	for _, column := range scheme {
+		types[column.Position] = column.DataType
+	}
+
+	return types
+}
+
+// columnarIter reads rows of the table as of the moment the iterator was created.
+type columnarIter struct {
+	reader *columnar.Reader
+}
+
+func (i *columnarIter) Next() (sql.Row, error) {
+	if i.reader == nil {
+		return nil, io.EOF
+	}
+
+	_, row, ok := i.reader.Next()
+	if !ok {
+		i.reader = nil
+
+		return nil, io.EOF
+	}
+
+	return row, nil
+}
+
+func (i *columnarIter) Close() error {
+	i.reader = nil
+
+	return nil
+}
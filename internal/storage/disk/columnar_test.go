@@ -0,0 +1,175 @@
+package disk_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/columnar"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+)
+
+func TestColumnarTable(t *testing.T) {
+	t.Parallel()
+
+	table := createColumnarTable(t, openCatalog(t, t.TempDir()))
+	require.IsType(t, &disk.ColumnarTable{}, table)
+
+	// More rows than a sealed segment holds.
+	for key := int64(1); key <= columnar.SegmentSize+100; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, "Max")))
+	}
+
+	require.Error(t, table.Insert(sql.IntegerKey(1), userRow(1, "Vlad")))
+	require.Error(t, table.Insert(sql.IntegerKey(0), sql.Row{datatype.NewText("0"), datatype.NewText("Max")}))
+
+	for key := int64(2); key <= columnar.SegmentSize+100; key += 2 {
+		require.NoError(t, table.Delete(sql.IntegerKey(key)))
+	}
+
+	require.Error(t, table.Delete(sql.IntegerKey(2)))
+	require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "Vlad")))
+	require.Error(t, table.Update(sql.IntegerKey(2), userRow(2, "Vlad")))
+
+	rows := scanRows(t, table)
+	require.Len(t, rows, columnar.SegmentSize/2+50)
+	assert.Contains(t, rows, userRow(1, "Vlad"))
+	assert.NotContains(t, rows, userRow(1, "Max"))
+
+	row, ok, err := table.Get(sql.IntegerKey(3))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, userRow(3, "Max"), row)
+
+	_, ok, err = table.Get(sql.IntegerKey(4))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	iter, err := table.ScanRange(
+		&sql.Bound{Value: datatype.NewInteger(2), Inclusive: true},
+		&sql.Bound{Value: datatype.NewInteger(7), Inclusive: false},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{userRow(3, "Max"), userRow(5, "Max")}, readIter(t, iter))
+}
+
+func TestColumnarTable_ScanColumns(t *testing.T) {
+	t.Parallel()
+
+	table := createColumnarTable(t, openCatalog(t, t.TempDir()))
+	require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+	require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Vlad")))
+
+	scanner, ok := table.(sql.ColumnScanner)
+	require.True(t, ok)
+
+	// The primary key is read even if it's not requested.
+	iter, err := scanner.ScanColumns(nil)
+	require.NoError(t, err)
+
+	expected := []sql.Row{
+		{datatype.NewInteger(1), datatype.NewNull()},
+		{datatype.NewInteger(2), datatype.NewNull()},
+	}
+	assert.Equal(t, expected, readIter(t, iter))
+
+	iter, err = scanner.ScanColumns([]uint8{1})
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{userRow(1, "Max"), userRow(2, "Vlad")}, readIter(t, iter))
+
+	_, err = scanner.ScanColumns([]uint8{2})
+	require.Error(t, err)
+}
+
+func TestColumnarTable_Persistence(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	catalog, err := disk.Open(dir)
+	require.NoError(t, err)
+
+	table := createColumnarTable(t, catalog)
+
+	_, err = table.CreateIndex("users_name", []string{"name"}, false)
+	require.NoError(t, err)
+
+	for key := int64(1); key <= 3*columnar.SegmentSize; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, "Max")))
+	}
+
+	require.NoError(t, catalog.Checkpoint())
+
+	// Most rows are deleted, so the next checkpoint compacts columns.
+	for key := int64(1); key < 3*columnar.SegmentSize; key++ {
+		require.NoError(t, table.Delete(sql.IntegerKey(key)))
+	}
+
+	require.NoError(t, table.Update(sql.IntegerKey(3*columnar.SegmentSize), userRow(3*columnar.SegmentSize, "Vlad")))
+	require.NoError(t, catalog.Checkpoint())
+	require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+
+	table.Sequence().Next()
+
+	expected := scanRows(t, table)
+	require.Len(t, expected, 2)
+	require.NoError(t, catalog.Close())
+
+	catalog = openCatalog(t, dir)
+
+	database, err := catalog.GetDatabase("events")
+	require.NoError(t, err)
+
+	table, err = database.GetTable("users")
+	require.NoError(t, err)
+	require.IsType(t, &disk.ColumnarTable{}, table)
+	require.ElementsMatch(t, expected, scanRows(t, table))
+	require.Equal(t, int64(3*columnar.SegmentSize+2), table.Sequence().Next())
+
+	indexes := table.Indexes()
+	require.Len(t, indexes, 1)
+	assert.Equal(t, []sql.Row{userRow(3*columnar.SegmentSize, "Vlad")}, scanIndex(t, indexes[0], "Vlad"))
+
+	require.NoError(t, database.DropTable("users"))
+	require.NoDirExists(t, filepath.Join(dir, "events", "users.col"))
+}
+
+// createColumnarTable creates the users table in the events database stored by the columnar engine.
+func createColumnarTable(t *testing.T, catalog *disk.Catalog) sql.Table {
+	t.Helper()
+
+	database, err := catalog.CreateDatabaseUsing("events", disk.EngineColumnar)
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	return table
+}
+
+func readIter(t *testing.T, iter sql.RowIter) []sql.Row {
+	t.Helper()
+
+	rows := make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	return rows
+}
@@ -0,0 +1,236 @@
+package disk_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+)
+
+const (
+	crashDirEnv    = "NANODB_CRASH_DIR"
+	crashEngineEnv = "NANODB_CRASH_ENGINE"
+)
+
+// TestCrashRecovery runs the workload in a child process, kills it at a random point and checks that
+// the recovered table contains every acknowledged change and no partially applied ones.
+func TestCrashRecovery(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping crash test in short mode")
+	}
+
+	for round := 0; round < 12; round++ {
+		engine := []string{disk.EngineHeap, disk.EngineLSM, disk.EngineColumnar}[round%3]
+
+		t.Run(fmt.Sprintf("round %d %s", round, engine), func(t *testing.T) {
+			dir := t.TempDir()
+			acked := runAndKill(t, dir, engine, 1+rand.IntN(400))
+
+			catalog := openCatalog(t, dir)
+
+			database, err := catalog.GetDatabase("crash")
+			require.NoError(t, err)
+
+			table, err := database.GetTable("events")
+			require.NoError(t, err)
+
+			actual := make(map[int64]sql.Row)
+
+			for _, row := range scanRows(t, table) {
+				key, ok := row[0].Raw().(int64)
+				require.True(t, ok)
+
+				_, duplicate := actual[key]
+				require.False(t, duplicate, "duplicate key %d", key)
+
+				actual[key] = row
+			}
+
+			// The operation after the last acknowledged one might be in progress when the process was killed.
+			withoutInFlight := applyWorkload(acked + 1)
+			withInFlight := applyWorkload(acked + 2)
+
+			if !rowsEqual(withoutInFlight, actual) && !rowsEqual(withInFlight, actual) {
+				t.Fatalf("recovered state doesn't match acknowledged operations (last acknowledged %d)", acked)
+			}
+
+			// The index is rebuilt from recovered rows, so it contains every row exactly once.
+			indexes := table.Indexes()
+			require.Len(t, indexes, 1)
+
+			iter, err := indexes[0].Scan(sql.IndexRange{})
+			require.NoError(t, err)
+
+			indexed := 0
+
+			for _, err = iter.Next(); err == nil; _, err = iter.Next() {
+				indexed++
+			}
+
+			require.ErrorIs(t, err, io.EOF)
+			require.Equal(t, len(actual), indexed)
+		})
+	}
+}
+
+// TestCrashWorker is the workload executed in the child process by TestCrashRecovery.
+func TestCrashWorker(t *testing.T) {
+	dir := os.Getenv(crashDirEnv)
+	if dir == "" {
+		t.Skip("executed only as a child process of the crash test")
+	}
+
+	// Small memtables make the LSM engine flush and compact SSTables while the workload runs.
+	catalog, err := disk.Open(
+		dir,
+		disk.WithGroupCommit(time.Microsecond),
+		disk.WithCheckpointSize(32<<10),
+		disk.WithEngine(os.Getenv(crashEngineEnv)),
+		disk.WithLSMOptions(lsm.Options{MemtableSize: 8 << 10, CompactionThreshold: 3}),
+	)
+	require.NoError(t, err)
+
+	database, err := catalog.CreateDatabase("crash")
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("events", usersScheme())
+	require.NoError(t, err)
+
+	_, err = table.CreateIndex("events_name", []string{"name"}, false)
+	require.NoError(t, err)
+
+	for i := 0; ; i++ {
+		op := workload(i)
+
+		switch op.kind {
+		case "insert":
+			err = table.Insert(sql.IntegerKey(op.key), op.row)
+		case "update":
+			err = table.Update(sql.IntegerKey(op.key), op.row)
+		case "delete":
+			err = table.Delete(sql.IntegerKey(op.key))
+		}
+
+		require.NoError(t, err)
+
+		_, err = fmt.Fprintf(os.Stdout, "ack %d\n", i)
+		require.NoError(t, err)
+	}
+}
+
+func runAndKill(t *testing.T, dir, engine string, killAfter int) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCrashWorker$")
+	cmd.Env = append(os.Environ(), crashDirEnv+"="+dir, crashEngineEnv+"="+engine)
+
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	acked := -1
+	killed := false
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "ack ")
+		if !ok {
+			continue
+		}
+
+		acked, err = strconv.Atoi(line)
+		require.NoError(t, err)
+
+		if !killed && acked+1 >= killAfter {
+			time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+			require.NoError(t, cmd.Process.Kill())
+
+			killed = true
+		}
+	}
+
+	_ = cmd.Wait()
+
+	require.True(t, killed, "worker exited before it was killed")
+
+	return acked
+}
+
+type operation struct {
+	kind string
+	key  int64
+	row  sql.Row
+}
+
+// workload returns the i-th operation: three inserts followed by either an update or a delete of a previous row.
+// Updated rows grow, so some of them move to another page.
+func workload(i int) operation {
+	switch {
+	case i%8 == 3:
+		return operation{kind: "update", key: int64(i - 1), row: eventRow(int64(i-1), 1500)}
+	case i%8 == 7:
+		return operation{kind: "delete", key: int64(i - 2)}
+	default:
+		return operation{kind: "insert", key: int64(i), row: eventRow(int64(i), i*37%600)}
+	}
+}
+
+func applyWorkload(n int) map[int64]sql.Row {
+	rows := make(map[int64]sql.Row)
+
+	for i := 0; i < n; i++ {
+		op := workload(i)
+
+		switch op.kind {
+		case "insert", "update":
+			rows[op.key] = op.row
+		case "delete":
+			delete(rows, op.key)
+		}
+	}
+
+	return rows
+}
+
+func eventRow(key int64, size int) sql.Row {
+	return sql.Row{
+		datatype.NewInteger(key),
+		datatype.NewText(strings.Repeat(strconv.FormatInt(key%10, 10), size)),
+	}
+}
+
+func rowsEqual(expected, actual map[int64]sql.Row) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for key, row := range expected {
+		other, ok := actual[key]
+		if !ok || len(other) != len(row) {
+			return false
+		}
+
+		for i := range row {
+			if row[i] != other[i] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,654 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+)
+
+// engineFileName is the file in the database directory that holds the default storage engine of its tables.
+const engineFileName = "ENGINE"
+
+// headerFileName is the file in the directory of an LSM or columnar table that holds the header page of the table,
+// it has the layout of the header page of a data file.
+const headerFileName = "header"
+
+// storedTable is a table of any storage engine.
+type storedTable interface {
+	sql.Table
+	// put inserts the row or replaces the existing one with the same key, the record is the encoded row.
+	put(key sql.Key, row sql.Row, record []byte) error
+	// remove deletes the row with the key if it exists.
+	remove(key sql.Key) error
+	// createIndex builds the index if it doesn't exist.
+	createIndex(def indexDef) error
+	// dropIndex removes the index if it exists.
+	dropIndex(name string) error
+	// sync makes all applied changes durable.
+	sync() error
+	Close() error
+}
+
+// Database is a directory that holds a data file, an LSM tree directory or a directory of columns per table,
+// and files of named sequences and statistics of tables.
+type Database struct {
+	mu         sync.RWMutex
+	name       string
+	dir        string
+	engine     string
+	journal    *journal
+	pool       *buffer.Pool
+	lsm        lsm.Options
+	tables     map[string]storedTable
+	sequences  map[string]*NamedSequence
+	statistics map[string]sql.Statistics
+}
+
+func createDatabase(
+	dir, name, engine string,
+	journal *journal,
+	pool *buffer.Pool,
+	lsmOpts lsm.Options,
+) (*Database, error) {
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	database := &Database{
+		name:       name,
+		dir:        dir,
+		journal:    journal,
+		pool:       pool,
+		lsm:        lsmOpts,
+		tables:     make(map[string]storedTable),
+		sequences:  make(map[string]*NamedSequence),
+		statistics: make(map[string]sql.Statistics),
+	}
+
+	if err := database.setEngine(engine); err != nil {
+		return nil, err
+	}
+
+	return database, nil
+}
+
+func openDatabase(dir, name string, journal *journal, pool *buffer.Pool, lsmOpts lsm.Options) (*Database, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := os.ReadFile(filepath.Join(dir, engineFileName))
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Databases created before storage engines were introduced keep tables in data files.
+		engine = []byte(EngineHeap)
+	case err != nil:
+		return nil, fmt.Errorf("read storage engine: %w", err)
+	}
+
+	database := &Database{
+		name:      name,
+		dir:       dir,
+		engine:    string(engine),
+		journal:   journal,
+		pool:      pool,
+		lsm:       lsmOpts,
+		tables:    make(map[string]storedTable),
+		sequences: make(map[string]*NamedSequence),
+	}
+
+	sequences, err := readSequences(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if database.statistics, err = readStatistics(dir); err != nil {
+		return nil, err
+	}
+
+	for _, state := range sequences {
+		database.createSequence(state)
+	}
+
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); entry.IsDir() && (ext == lsmTableExt || ext == columnarTableExt) {
+			if err = database.openTableDir(entry.Name()); err != nil {
+				_ = database.Close()
+
+				return nil, err
+			}
+
+			continue
+		}
+
+		if entry.IsDir() || filepath.Ext(entry.Name()) != tableFileExt {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		tableName := strings.TrimSuffix(entry.Name(), tableFileExt)
+
+		info, err := entry.Info()
+		if err != nil {
+			_ = database.Close()
+
+			return nil, err
+		}
+
+		// The table creation was interrupted before the header was written,
+		// the write-ahead log creates the table again if the creation was acknowledged.
+		if info.Size() < PageSize {
+			if err = os.Remove(path); err != nil {
+				_ = database.Close()
+
+				return nil, err
+			}
+
+			continue
+		}
+
+		table, err := openTable(path, name, tableName, journal, pool)
+		if err != nil {
+			_ = database.Close()
+
+			return nil, err
+		}
+
+		database.tables[tableName] = table
+	}
+
+	// Files of tables dropped since the last checkpoint are already removed.
+	for table := range database.statistics {
+		if _, ok := database.tables[table]; !ok {
+			delete(database.statistics, table)
+		}
+	}
+
+	return database, nil
+}
+
+// openTableDir opens the table stored in the directory of an LSM tree or of columns.
+func (d *Database) openTableDir(dirName string) error {
+	dir := filepath.Join(d.dir, dirName)
+	ext := filepath.Ext(dirName)
+	tableName := strings.TrimSuffix(dirName, ext)
+
+	// The table creation was interrupted before the header was written, or the removal of the table
+	// was interrupted. The write-ahead log creates the table again if the creation was acknowledged.
+	if info, err := os.Stat(filepath.Join(dir, headerFileName)); err != nil || info.Size() < PageSize {
+		if err = os.RemoveAll(dir); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	var (
+		table storedTable
+		err   error
+	)
+
+	switch ext {
+	case lsmTableExt:
+		table, err = openLSMTable(dir, d.name, tableName, d.journal, d.lsm)
+	default:
+		table, err = openColumnarTable(dir, d.name, tableName, d.journal)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	d.tables[tableName] = table
+
+	return nil
+}
+
+// setEngine changes the default storage engine of tables created in the database.
+func (d *Database) setEngine(engine string) error {
+	if d.engine == engine {
+		return nil
+	}
+
+	if err := os.WriteFile(filepath.Join(d.dir, engineFileName), []byte(engine), 0o644); err != nil {
+		return fmt.Errorf("write storage engine: %w", err)
+	}
+
+	d.engine = engine
+
+	return nil
+}
+
+// Engine returns the default storage engine of tables created in the database.
+func (d *Database) Engine() string {
+	return d.engine
+}
+
+func (d *Database) Name() string {
+	return d.name
+}
+
+func (d *Database) ListTables() []sql.Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make([]sql.Table, 0, len(d.tables))
+
+	for _, t := range d.tables {
+		tables = append(tables, t)
+	}
+
+	return tables
+}
+
+func (d *Database) GetTable(name string) (sql.Table, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if table, ok := d.tables[name]; ok {
+		return table, nil
+	}
+
+	return nil, fmt.Errorf("table %q not found", name)
+}
+
+func (d *Database) CreateTable(name string, scheme sql.Scheme) (sql.Table, error) {
+	return d.CreateTableUsing(name, scheme, "")
+}
+
+// CreateTableUsing creates the table stored by the engine, an empty name selects the engine of the database.
+func (d *Database) CreateTableUsing(name string, scheme sql.Scheme, engine string) (sql.Table, error) {
+	if engine == "" {
+		engine = d.engine
+	}
+
+	if err := checkEngine(engine); err != nil {
+		return nil, err
+	}
+
+	d.journal.mu.RLock()
+	defer d.journal.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; ok {
+		return nil, errors.New("table already exist")
+	}
+
+	if len(scheme) == 0 {
+		return nil, errors.New("scheme should not be empty")
+	}
+
+	e := entry{
+		op:       opCreateTable,
+		database: d.name,
+		table:    name,
+		scheme:   scheme,
+		engine:   engine,
+	}
+
+	if err := d.journal.write(e); err != nil {
+		return nil, err
+	}
+
+	return d.createTable(name, scheme, engine)
+}
+
+func (d *Database) DropTable(name string) error {
+	d.journal.mu.RLock()
+	defer d.journal.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; !ok {
+		return fmt.Errorf("table %s not found", name)
+	}
+
+	e := entry{
+		op:       opDropTable,
+		database: d.name,
+		table:    name,
+	}
+
+	if err := d.journal.write(e); err != nil {
+		return err
+	}
+
+	return d.dropTable(name)
+}
+
+func (d *Database) Statistics(table string) (sql.Statistics, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	statistics, ok := d.statistics[table]
+
+	return statistics, ok
+}
+
+func (d *Database) SetStatistics(table string, statistics sql.Statistics) error {
+	d.journal.mu.RLock()
+	defer d.journal.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[table]; !ok {
+		return fmt.Errorf("table %q not found", table)
+	}
+
+	e := entry{
+		op:         opSetStatistics,
+		database:   d.name,
+		table:      table,
+		statistics: statistics,
+	}
+
+	if err := d.journal.write(e); err != nil {
+		return err
+	}
+
+	d.statistics[table] = statistics
+
+	return nil
+}
+
+func (d *Database) ListSequences() []sql.NamedSequence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	sequences := make([]sql.NamedSequence, 0, len(d.sequences))
+
+	for _, s := range d.sequences {
+		sequences = append(sequences, s)
+	}
+
+	return sequences
+}
+
+func (d *Database) GetSequence(name string) (sql.NamedSequence, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if sequence, ok := d.sequences[name]; ok {
+		return sequence, nil
+	}
+
+	return nil, fmt.Errorf("sequence %q not found", name)
+}
+
+func (d *Database) CreateSequence(name string, options sql.SequenceOptions) (sql.NamedSequence, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.journal.mu.RLock()
+	defer d.journal.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sequences[name]; ok {
+		return nil, fmt.Errorf("sequence %q already exist", name)
+	}
+
+	state := sequenceState{
+		name:    name,
+		options: options,
+	}
+
+	if err := d.journal.write(entry{op: opCreateSequence, database: d.name, sequence: state}); err != nil {
+		return nil, err
+	}
+
+	return d.createSequence(state), nil
+}
+
+func (d *Database) DropSequence(name string) error {
+	d.journal.mu.RLock()
+	defer d.journal.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sequences[name]; !ok {
+		return fmt.Errorf("sequence %q not found", name)
+	}
+
+	e := entry{
+		op:       opDropSequence,
+		database: d.name,
+		sequence: sequenceState{name: name},
+	}
+
+	if err := d.journal.write(e); err != nil {
+		return err
+	}
+
+	d.dropSequence(name)
+
+	return nil
+}
+
+func (d *Database) createSequence(state sequenceState) *NamedSequence {
+	sequence := newNamedSequence(d.name, state, d.journal)
+	d.sequences[state.name] = sequence
+
+	return sequence
+}
+
+func (d *Database) dropSequence(name string) {
+	sequence, ok := d.sequences[name]
+	if !ok {
+		return
+	}
+
+	sequence.mu.Lock()
+	sequence.dropped = true
+	sequence.mu.Unlock()
+
+	delete(d.sequences, name)
+}
+
+func (d *Database) createTable(name string, scheme sql.Scheme, engine string) (storedTable, error) {
+	var (
+		table storedTable
+		err   error
+	)
+
+	switch engine {
+	case EngineLSM:
+		table, err = createLSMTable(d.lsmTablePath(name), d.name, name, scheme, d.journal, d.lsm)
+	case EngineColumnar:
+		table, err = createColumnarTable(d.columnarTablePath(name), d.name, name, scheme, d.journal)
+	default:
+		table, err = createTable(d.tablePath(name), d.name, name, scheme, d.journal, d.pool)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("create table %q: %w", name, err)
+	}
+
+	d.tables[name] = table
+
+	return table, nil
+}
+
+func (d *Database) dropTable(name string) error {
+	table, ok := d.tables[name]
+	if !ok {
+		return nil
+	}
+
+	if err := table.Close(); err != nil {
+		return fmt.Errorf("close table %q: %w", name, err)
+	}
+
+	if err := d.removeTable(name, table); err != nil {
+		return fmt.Errorf("remove table %q: %w", name, err)
+	}
+
+	delete(d.tables, name)
+	delete(d.statistics, name)
+
+	return nil
+}
+
+// sync flushes data files of all tables, writes files of sequences and statistics and flushes the directory itself.
+func (d *Database) sync() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for name, table := range d.tables {
+		if err := table.sync(); err != nil {
+			return fmt.Errorf("sync table %q: %w", name, err)
+		}
+	}
+
+	states := make([]sequenceState, 0, len(d.sequences))
+
+	for _, sequence := range d.sequences {
+		states = append(states, sequence.snapshot())
+	}
+
+	if err := writeSequences(d.dir, states); err != nil {
+		return err
+	}
+
+	if err := writeStatistics(d.dir, d.statistics); err != nil {
+		return err
+	}
+
+	return syncDir(d.dir)
+}
+
+// Close closes data files of all tables.
+func (d *Database) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+
+	for name, table := range d.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close table %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeTable removes files of the closed table.
+func (d *Database) removeTable(name string, table storedTable) error {
+	var dir string
+
+	switch table.(type) {
+	case *LSMTable:
+		dir = d.lsmTablePath(name)
+	case *ColumnarTable:
+		dir = d.columnarTablePath(name)
+	default:
+		return os.Remove(d.tablePath(name))
+	}
+
+	// The header goes first: a directory without it is removed when the database is opened,
+	// so an interrupted removal never leaves a table with a part of its files.
+
+	if err := os.Remove(filepath.Join(dir, headerFileName)); err != nil {
+		return err
+	}
+
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (d *Database) tablePath(name string) string {
+	return filepath.Join(d.dir, name+tableFileExt)
+}
+
+func (d *Database) lsmTablePath(name string) string {
+	return filepath.Join(d.dir, name+lsmTableExt)
+}
+
+func (d *Database) columnarTablePath(name string) string {
+	return filepath.Join(d.dir, name+columnarTableExt)
+}
+
+// readMetaFile returns the content of a file written by writeMetaFile, nil if the file doesn't exist.
+func readMetaFile(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case len(buf) < 4 || binary.BigEndian.Uint32(buf) != crc32.ChecksumIEEE(buf[4:]):
+		return nil, errors.New("checksum mismatch")
+	}
+
+	return buf[4:], nil
+}
+
+// writeMetaFile replaces the file with the content prefixed by its checksum. The new file is written next to the old
+// one and renamed over it, so a crash leaves one of them intact. The file is removed if the content is empty.
+func writeMetaFile(path string, content []byte) error {
+	if len(content) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(content)), crc32.ChecksumIEEE(content))
+	buf = append(buf, content...)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+
+	if err = errors.Join(err, f.Close()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// syncDir flushes the directory entries, so created and removed files survive a crash.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("sync directory %s: %w", dir, err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,389 @@
+package disk_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+)
+
+func TestDatabase_ListTables(t *testing.T) {
+	t.Parallel()
+
+	catalog := openCatalog(t, t.TempDir())
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+	require.Empty(t, database.ListTables())
+
+	users, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	tickets, err := database.CreateTable("tickets", usersScheme())
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []sql.Table{users, tickets}, database.ListTables())
+}
+
+func TestDatabase_GetTable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		expected, err := database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+
+		table, err := database.GetTable("users")
+		require.NoError(t, err)
+		require.Equal(t, expected, table)
+	})
+
+	t.Run("returns error if table not exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		table, err := database.GetTable("xxx")
+		require.Error(t, err)
+		require.Nil(t, table)
+	})
+}
+
+func TestDatabase_CreateTable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create table without errors", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		table, err := database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+		assert.Equal(t, usersScheme(), table.Scheme())
+		assert.FileExists(t, dir+"/playground/users.tbl")
+	})
+
+	t.Run("returns error if table already exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		_, err = database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+
+		_, err = database.CreateTable("users", usersScheme())
+		require.Error(t, err)
+	})
+
+	t.Run("return error if scheme is empty", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		_, err = database.CreateTable("users", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestDatabase_DropTable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drop table without errors", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		table, err := database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+
+		err = database.DropTable(table.Name())
+		require.NoError(t, err)
+		assert.NoFileExists(t, dir+"/playground/users.tbl")
+
+		deleted, err := database.GetTable(table.Name())
+		require.Error(t, err)
+		require.Nil(t, deleted)
+	})
+
+	t.Run("returns error if table not exist", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		err = database.DropTable("xxx")
+		require.Error(t, err)
+	})
+}
+
+func TestDatabase_Name(t *testing.T) {
+	t.Parallel()
+
+	catalog := openCatalog(t, t.TempDir())
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+	assert.Equal(t, "playground", database.Name())
+}
+
+func usersScheme() sql.Scheme {
+	return sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+		},
+		"name": sql.Column{
+			Position:   1,
+			Name:       "name",
+			DataType:   sql.Text,
+			PrimaryKey: false,
+			Nullable:   true,
+			Default:    datatype.NewText("<unknown>"),
+		},
+	}
+}
+
+func TestDatabase_Sequences(t *testing.T) {
+	t.Parallel()
+
+	nextValues := func(t *testing.T, sequence sql.NamedSequence, n int) []int64 {
+		t.Helper()
+
+		values := make([]int64, 0, n)
+
+		for range n {
+			value, err := sequence.Next()
+			require.NoError(t, err)
+
+			values = append(values, value)
+		}
+
+		return values
+	}
+
+	t.Run("create, get and drop", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		sequence, err := database.CreateSequence("orders_seq", sql.SequenceOptions{Start: 100, Increment: 10})
+		require.NoError(t, err)
+		assert.Equal(t, sql.SequenceOptions{Start: 100, Increment: 10}, sequence.Options())
+
+		_, err = database.CreateSequence("orders_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+		require.Error(t, err)
+
+		_, err = database.CreateSequence("broken_seq", sql.SequenceOptions{Start: 1})
+		require.Error(t, err)
+
+		found, err := database.GetSequence("orders_seq")
+		require.NoError(t, err)
+		assert.Equal(t, sequence, found)
+		assert.Equal(t, []sql.NamedSequence{sequence}, database.ListSequences())
+		assert.Equal(t, []int64{100, 110, 120}, nextValues(t, sequence, 3))
+
+		require.NoError(t, database.DropSequence("orders_seq"))
+		require.Error(t, database.DropSequence("orders_seq"))
+
+		_, err = database.GetSequence("orders_seq")
+		require.Error(t, err)
+
+		_, err = sequence.Next()
+		require.Error(t, err, "dropped sequence must not hand out values")
+	})
+
+	t.Run("survives reopen", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		catalog, err := disk.Open(dir)
+		require.NoError(t, err)
+
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		sequence, err := database.CreateSequence("orders_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, nextValues(t, sequence, 3))
+
+		dropped, err := database.CreateSequence("dropped_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+		require.NoError(t, err)
+		require.NoError(t, catalog.Checkpoint())
+		require.NoError(t, database.DropSequence(dropped.Name()))
+		require.NoError(t, catalog.Close())
+
+		catalog = openCatalog(t, dir)
+		database, err = catalog.GetDatabase("playground")
+		require.NoError(t, err)
+		require.Len(t, database.ListSequences(), 1)
+
+		sequence, err = database.GetSequence("orders_seq")
+		require.NoError(t, err)
+
+		current, ok := sequence.Current()
+		require.True(t, ok)
+		assert.Equal(t, int64(3), current)
+		assert.Equal(t, []int64{4, 5}, nextValues(t, sequence, 2))
+
+		require.NoError(t, sequence.SetValue(1000))
+		assert.Equal(t, []int64{1001}, nextValues(t, sequence, 1))
+	})
+
+	t.Run("never hands out a value twice after a crash", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		sequence, err := database.CreateSequence("countdown", sql.SequenceOptions{Start: -1, Increment: -1})
+		require.NoError(t, err)
+
+		values := nextValues(t, sequence, 40)
+		require.NoError(t, catalog.Checkpoint())
+
+		values = append(values, nextValues(t, sequence, 5)...)
+
+		// The copy of the data directory is what a crash leaves behind: the log isn't checkpointed.
+		crashed := filepath.Join(t.TempDir(), "crashed")
+		require.NoError(t, os.CopyFS(crashed, os.DirFS(dir)))
+
+		recovered, err := openCatalog(t, crashed).GetDatabase("playground")
+		require.NoError(t, err)
+
+		sequence, err = recovered.GetSequence("countdown")
+		require.NoError(t, err)
+
+		next, err := sequence.Next()
+		require.NoError(t, err)
+		assert.Less(t, next, values[len(values)-1])
+	})
+}
+
+func TestDatabase_Statistics(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id":   sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text, Nullable: true},
+	}
+
+	statistics := sql.Statistics{
+		Rows: 3,
+		Columns: map[string]sql.ColumnStatistics{
+			"id": {
+				Distinct:  3,
+				Histogram: []sql.Value{datatype.NewInteger(1), datatype.NewInteger(2), datatype.NewInteger(3)},
+			},
+			"name": {
+				NullFraction: 1.0 / 3,
+				Distinct:     2,
+				Histogram:    []sql.Value{datatype.NewText("alice"), datatype.NewText("bob")},
+			},
+		},
+	}
+
+	t.Run("set, get and drop with table", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := openCatalog(t, t.TempDir())
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		_, err = database.CreateTable("users", scheme)
+		require.NoError(t, err)
+
+		_, ok := database.Statistics("users")
+		require.False(t, ok)
+		require.Error(t, database.SetStatistics("unknown", statistics))
+		require.NoError(t, database.SetStatistics("users", statistics))
+
+		found, ok := database.Statistics("users")
+		require.True(t, ok)
+		assert.Equal(t, statistics, found)
+
+		require.NoError(t, database.DropTable("users"))
+
+		_, err = database.CreateTable("users", scheme)
+		require.NoError(t, err)
+
+		_, ok = database.Statistics("users")
+		require.False(t, ok, "statistics must be dropped together with the table")
+	})
+
+	t.Run("survives reopen and crash", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		catalog, err := disk.Open(dir)
+		require.NoError(t, err)
+
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		for _, name := range []string{"users", "orders", "dropped"} {
+			_, err = database.CreateTable(name, scheme)
+			require.NoError(t, err)
+			require.NoError(t, database.SetStatistics(name, statistics))
+		}
+
+		require.NoError(t, catalog.Checkpoint())
+		require.NoError(t, database.DropTable("dropped"))
+
+		// The copy of the data directory is what a crash leaves behind: the log isn't checkpointed.
+		updated := sql.Statistics{Rows: 10, Columns: map[string]sql.ColumnStatistics{}}
+		require.NoError(t, database.SetStatistics("orders", updated))
+
+		crashed := filepath.Join(t.TempDir(), "crashed")
+		require.NoError(t, os.CopyFS(crashed, os.DirFS(dir)))
+		require.NoError(t, catalog.Close())
+
+		for _, path := range []string{dir, crashed} {
+			database, err = openCatalog(t, path).GetDatabase("playground")
+			require.NoError(t, err)
+
+			found, ok := database.Statistics("users")
+			require.True(t, ok)
+			assert.Equal(t, statistics, found)
+
+			found, ok = database.Statistics("orders")
+			require.True(t, ok)
+			assert.Equal(t, updated, found)
+
+			_, ok = database.Statistics("dropped")
+			require.False(t, ok)
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/codec"
+)
+
+// tableFileExt is the extension of table data files.
+const tableFileExt = ".tbl"
+
+var magic = []byte("NANODB02")
+
+// The first page of a data file is the header page:
+//
+//	+-------+----------+---------------+----------------+---------------------+
+//	| magic | sequence | scheme length | encoded scheme | index definitions   |
+//	+-------+----------+---------------+----------------+---------------------+
+//
+// All following pages are slotted data pages.
+const (
+	headerSequenceOffset     = 8
+	headerSchemeLengthOffset = 16
+	headerSchemeOffset       = 20
+)
+
+// indexDef is the definition of a secondary index, only definitions are stored, the content of indexes
+// is rebuilt from rows when the table is opened.
+type indexDef struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// header is the content of the header page.
+type header struct {
+	sequence int64
+	scheme   sql.Scheme
+	indexes  []indexDef
+}
+
+// dataFile is a page-structured file that stores one table.
+type dataFile struct {
+	file  *os.File
+	pages int
+}
+
+func createDataFile(path string, scheme sql.Scheme) (*dataFile, error) {
+	meta, err := encodeMeta(scheme, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	header := newPage()
+	copy(header, magic)
+	copy(header[headerSchemeLengthOffset:], meta)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = file.WriteAt(header, 0); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	return &dataFile{file: file, pages: 1}, nil
+}
+
+func openDataFile(path string) (*dataFile, header, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, header{}, err
+	}
+
+	df, h, err := readDataFile(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, header{}, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	return df, h, nil
+}
+
+func readDataFile(file *os.File) (*dataFile, header, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, header{}, err
+	}
+
+	if info.Size() < PageSize || info.Size()%PageSize != 0 {
+		return nil, header{}, fmt.Errorf("unexpected file size %d", info.Size())
+	}
+
+	buf := newPage()
+
+	if _, err = file.ReadAt(buf, 0); err != nil {
+		return nil, header{}, fmt.Errorf("read header: %w", err)
+	}
+
+	if !bytes.Equal(buf[:len(magic)], magic) {
+		return nil, header{}, errors.New("not a data file")
+	}
+
+	length := int(binary.BigEndian.Uint32(buf[headerSchemeLengthOffset:headerSchemeOffset]))
+
+	if length > PageSize-headerSchemeOffset {
+		return nil, header{}, errors.New("corrupted header")
+	}
+
+	scheme, _, err := codec.DecodeScheme(buf[headerSchemeOffset : headerSchemeOffset+length])
+	if err != nil {
+		return nil, header{}, fmt.Errorf("decode scheme: %w", err)
+	}
+
+	indexes, err := decodeIndexDefs(buf[headerSchemeOffset+length:])
+	if err != nil {
+		return nil, header{}, fmt.Errorf("decode indexes: %w", err)
+	}
+
+	h := header{
+		sequence: int64(binary.BigEndian.Uint64(buf[headerSequenceOffset:])),
+		scheme:   scheme,
+		indexes:  indexes,
+	}
+
+	return &dataFile{file: file, pages: int(info.Size() / PageSize)}, h, nil
+}
+
+// writeMeta replaces the scheme and index definitions in the header page.
+func (f *dataFile) writeMeta(scheme sql.Scheme, indexes []indexDef) error {
+	meta, err := encodeMeta(scheme, indexes)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.file.WriteAt(meta, headerSchemeLengthOffset); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	return nil
+}
+
+// encodeMeta encodes the part of the header that follows the sequence.
+func encodeMeta(scheme sql.Scheme, indexes []indexDef) ([]byte, error) {
+	meta := make([]byte, headerSchemeOffset-headerSchemeLengthOffset)
+
+	meta, err := codec.AppendScheme(meta, scheme)
+	if err != nil {
+		return nil, fmt.Errorf("encode scheme: %w", err)
+	}
+
+	schemeLength := len(meta) - (headerSchemeOffset - headerSchemeLengthOffset)
+	binary.BigEndian.PutUint32(meta, uint32(schemeLength))
+
+	meta = binary.AppendUvarint(meta, uint64(len(indexes)))
+
+	for _, def := range indexes {
+		meta = appendIndexDef(meta, def)
+	}
+
+	if len(meta) > PageSize-headerSchemeLengthOffset {
+		return nil, errors.New("scheme is too large")
+	}
+
+	return meta, nil
+}
+
+func decodeIndexDefs(buf []byte) ([]indexDef, error) {
+	count, offset := binary.Uvarint(buf)
+	if offset <= 0 {
+		return nil, errors.New("unexpected end of header")
+	}
+
+	indexes := make([]indexDef, 0, count)
+
+	for i := uint64(0); i < count; i++ {
+		def, n, err := decodeIndexDef(buf[offset:])
+		if err != nil {
+			return nil, err
+		}
+
+		offset += n
+		indexes = append(indexes, def)
+	}
+
+	return indexes, nil
+}
+
+func appendIndexDef(buf []byte, def indexDef) []byte {
+	buf = appendString(buf, def.name)
+
+	if def.unique {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(def.columns)))
+
+	for _, column := range def.columns {
+		buf = appendString(buf, column)
+	}
+
+	return buf
+}
+
+func decodeIndexDef(buf []byte) (indexDef, int, error) {
+	var def indexDef
+
+	name, offset, err := decodeString(buf)
+	if err != nil {
+		return indexDef{}, 0, err
+	}
+
+	if offset >= len(buf) {
+		return indexDef{}, 0, errors.New("unexpected end of index definition")
+	}
+
+	def.name = name
+	def.unique = buf[offset] == 1
+	offset++
+
+	columns, n := binary.Uvarint(buf[offset:])
+	if n <= 0 {
+		return indexDef{}, 0, errors.New("unexpected end of index definition")
+	}
+
+	offset += n
+
+	for i := uint64(0); i < columns; i++ {
+		column, n, err := decodeString(buf[offset:])
+		if err != nil {
+			return indexDef{}, 0, err
+		}
+
+		offset += n
+		def.columns = append(def.columns, column)
+	}
+
+	return def, offset, nil
+}
+
+// ReadPage reads the page into the buffer, it implements buffer.File.
+func (f *dataFile) ReadPage(n int, buf []byte) error {
+	if _, err := f.file.ReadAt(buf[:PageSize], int64(n)*PageSize); err != nil {
+		return fmt.Errorf("read page %d: %w", n, err)
+	}
+
+	return nil
+}
+
+// WritePage writes the page from the buffer, it implements buffer.File.
+func (f *dataFile) WritePage(n int, buf []byte) error {
+	if _, err := f.file.WriteAt(buf[:PageSize], int64(n)*PageSize); err != nil {
+		return fmt.Errorf("write page %d: %w", n, err)
+	}
+
+	return nil
+}
+
+// allocatePage appends an empty page to the file and returns its number.
+func (f *dataFile) allocatePage() (int, error) {
+	n := f.pages
+
+	if err := f.WritePage(n, newPage()); err != nil {
+		return 0, err
+	}
+
+	f.pages++
+
+	return n, nil
+}
+
+func (f *dataFile) writeSequence(value int64) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(value))
+
+	if _, err := f.file.WriteAt(buf, headerSequenceOffset); err != nil {
+		return fmt.Errorf("write sequence: %w", err)
+	}
+
+	return nil
+}
+
+func (f *dataFile) sync() error {
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("sync data file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *dataFile) close() error {
+	if err := f.file.Sync(); err != nil {
+		_ = f.file.Close()
+
+		return err
+	}
+
+	return f.file.Close()
+}
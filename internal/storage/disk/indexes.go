@@ -0,0 +1,237 @@
+package disk
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/index"
+)
+
+// indexedTable is a table of any storage engine as seen by its indexes.
+type indexedTable interface {
+	Scheme() sql.Scheme
+	// entry returns the journal entry of the change of the table.
+	entry(op operation, key sql.Key, row sql.Row) entry
+	// scanAll calls fn for every row of the table. The caller must hold the lock.
+	scanAll(fn func(key sql.Key, row sql.Row) error) error
+	// readRows returns rows with the keys, keys of removed rows are skipped. The caller must hold the lock.
+	readRows(keys []sql.Key) ([]sql.Row, error)
+}
+
+// tableIndexes manages secondary indexes of a table, tables of all storage engines embed it.
+// Index definitions are stored in the header page of the table, the content of indexes is kept in memory,
+// it's built from rows of the table when an index is created and kept up to date by changes of the table.
+type tableIndexes struct {
+	table   indexedTable
+	lock    *sync.RWMutex // the lock of the table
+	log     *journal
+	meta    *dataFile // the file with the header page of the table
+	indexes map[string]*index.Index
+}
+
+func newTableIndexes(table indexedTable, lock *sync.RWMutex, log *journal, meta *dataFile) tableIndexes {
+	return tableIndexes{
+		table:   table,
+		lock:    lock,
+		log:     log,
+		meta:    meta,
+		indexes: make(map[string]*index.Index),
+	}
+}
+
+// loadIndexes creates empty indexes of the definitions read from the header, the table fills them when it's loaded.
+func (x *tableIndexes) loadIndexes(defs []indexDef) error {
+	for _, def := range defs {
+		idx, err := index.New(def.name, x.table.Scheme(), def.columns, def.unique)
+		if err != nil {
+			return fmt.Errorf("load index %q: %w", def.name, err)
+		}
+
+		x.indexes[def.name] = idx
+	}
+
+	return nil
+}
+
+// checkIndexes checks that the row doesn't violate unique indexes.
+func (x *tableIndexes) checkIndexes(key sql.Key, row sql.Row) error {
+	for _, idx := range x.indexes {
+		if err := idx.Check(key, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *tableIndexes) Indexes() []sql.Index {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	indexes := make([]sql.Index, 0, len(x.indexes))
+
+	for _, idx := range x.indexes {
+		indexes = append(indexes, &tableIndex{Index: idx, owner: x})
+	}
+
+	return indexes
+}
+
+func (x *tableIndexes) CreateIndex(name string, columns []string, unique bool) (sql.Index, error) {
+	x.log.mu.RLock()
+	defer x.log.mu.RUnlock()
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if _, ok := x.indexes[name]; ok {
+		return nil, fmt.Errorf("index %q already exist", name)
+	}
+
+	def := indexDef{
+		name:    name,
+		columns: columns,
+		unique:  unique,
+	}
+
+	idx, err := x.buildIndex(def, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e := x.table.entry(opCreateIndex, "", nil)
+	e.index = def
+
+	if err = x.log.write(e); err != nil {
+		return nil, err
+	}
+
+	if err = x.addIndex(idx); err != nil {
+		return nil, err
+	}
+
+	return &tableIndex{Index: idx, owner: x}, nil
+}
+
+func (x *tableIndexes) DropIndex(name string) error {
+	x.log.mu.RLock()
+	defer x.log.mu.RUnlock()
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	if _, ok := x.indexes[name]; !ok {
+		return fmt.Errorf("index %q not found", name)
+	}
+
+	e := x.table.entry(opDropIndex, "", nil)
+	e.index = indexDef{name: name}
+
+	if err := x.log.write(e); err != nil {
+		return err
+	}
+
+	return x.dropIndex(name)
+}
+
+// createIndex builds the index without checking uniqueness, it's used while the journal is replayed.
+func (x *tableIndexes) createIndex(def indexDef) error {
+	if _, ok := x.indexes[def.name]; ok {
+		return nil
+	}
+
+	idx, err := x.buildIndex(def, false)
+	if err != nil {
+		return err
+	}
+
+	return x.addIndex(idx)
+}
+
+// buildIndex creates the index and fills it with all rows of the table.
+func (x *tableIndexes) buildIndex(def indexDef, checkUnique bool) (*index.Index, error) {
+	idx, err := index.New(def.name, x.table.Scheme(), def.columns, def.unique)
+	if err != nil {
+		return nil, err
+	}
+
+	err = x.table.scanAll(func(key sql.Key, row sql.Row) error {
+		if checkUnique {
+			if err := idx.Check(key, row); err != nil {
+				return err
+			}
+		}
+
+		idx.Insert(key, row)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// addIndex registers the index and stores its definition in the header page.
+func (x *tableIndexes) addIndex(idx *index.Index) error {
+	x.indexes[idx.Name()] = idx
+
+	return x.meta.writeMeta(x.table.Scheme(), x.indexDefs())
+}
+
+// dropIndex removes the index if it exists.
+func (x *tableIndexes) dropIndex(name string) error {
+	if _, ok := x.indexes[name]; !ok {
+		return nil
+	}
+
+	delete(x.indexes, name)
+
+	return x.meta.writeMeta(x.table.Scheme(), x.indexDefs())
+}
+
+// indexDefs returns definitions of all indexes ordered by name.
+func (x *tableIndexes) indexDefs() []indexDef {
+	defs := make([]indexDef, 0, len(x.indexes))
+
+	for _, idx := range x.indexes {
+		defs = append(defs, indexDef{
+			name:    idx.Name(),
+			columns: idx.Columns(),
+			unique:  idx.Unique(),
+		})
+	}
+
+	slices.SortFunc(defs, func(a, b indexDef) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	return defs
+}
+
+// tableIndex reads rows of the table found by the index.
+type tableIndex struct {
+	*index.Index
+	owner *tableIndexes
+}
+
+func (i *tableIndex) Scan(r sql.IndexRange) (sql.RowIter, error) {
+	i.owner.lock.RLock()
+	defer i.owner.lock.RUnlock()
+
+	keys, err := i.Keys(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := i.owner.table.readRows(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.RowsIter(rows...), nil
+}
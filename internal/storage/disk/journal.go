@@ -0,0 +1,263 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/codec"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/wal"
+)
+
+// walFileName is the name of the write-ahead log file in the data directory.
+const walFileName = "wal.log"
+
+type operation byte
+
+const (
+	opCreateDatabase operation = iota + 1
+	opDropDatabase
+	opCreateTable
+	opDropTable
+	opInsert
+	opUpdate
+	opDelete
+	opCreateIndex
+	opDropIndex
+	opCreateSequence
+	opDropSequence
+	opSetSequence
+	opSetStatistics
+)
+
+// entry is a record of the write-ahead log. It describes a change with the full image of the row,
+// so applying the same sequence of entries again always leads to the same state.
+type entry struct {
+	op         operation
+	database   string
+	table      string
+	scheme     sql.Scheme
+	key        sql.Key
+	row        sql.Row
+	index      indexDef
+	engine     string // storage engine of a created database or table
+	sequence   sequenceState
+	statistics sql.Statistics // statistics of a table collected by ANALYZE
+}
+
+func (e entry) encode() ([]byte, error) {
+	var err error
+
+	buf := []byte{byte(e.op)}
+	buf = appendString(buf, e.database)
+
+	switch e.op {
+	case opCreateDatabase:
+		return appendString(buf, e.engine), nil
+	case opDropDatabase:
+		return buf, nil
+	case opCreateSequence, opSetSequence:
+		return appendSequenceState(buf, e.sequence), nil
+	case opDropSequence:
+		return appendString(buf, e.sequence.name), nil
+	case opCreateTable:
+		buf = appendString(buf, e.table)
+
+		if buf, err = codec.AppendScheme(buf, e.scheme); err != nil {
+			return nil, err
+		}
+
+		return appendString(buf, e.engine), nil
+	case opDropTable:
+		return appendString(buf, e.table), nil
+	case opInsert, opUpdate:
+		buf = appendString(buf, e.table)
+		buf = appendString(buf, string(e.key))
+
+		if buf, err = codec.AppendRow(buf, e.row); err != nil {
+			return nil, fmt.Errorf("encode row: %w", err)
+		}
+
+		return buf, nil
+	case opDelete:
+		buf = appendString(buf, e.table)
+
+		return appendString(buf, string(e.key)), nil
+	case opCreateIndex:
+		buf = appendString(buf, e.table)
+
+		return appendIndexDef(buf, e.index), nil
+	case opDropIndex:
+		buf = appendString(buf, e.table)
+
+		return appendString(buf, e.index.name), nil
+	case opSetStatistics:
+		buf = appendString(buf, e.table)
+
+		if buf, err = appendStatistics(buf, e.statistics); err != nil {
+			return nil, fmt.Errorf("encode statistics: %w", err)
+		}
+
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %d", e.op)
+	}
+}
+
+func decodeEntry(buf []byte) (entry, error) {
+	var (
+		e      entry
+		offset int
+		n      int
+		err    error
+	)
+
+	if len(buf) == 0 {
+		return entry{}, errors.New("empty entry")
+	}
+
+	e.op = operation(buf[0])
+	offset++
+
+	if e.database, n, err = decodeString(buf[offset:]); err != nil {
+		return entry{}, fmt.Errorf("decode database: %w", err)
+	}
+
+	offset += n
+
+	// Entries written before storage engines were introduced end without the engine.
+	if e.op == opCreateDatabase && offset < len(buf) {
+		if e.engine, _, err = decodeString(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode engine: %w", err)
+		}
+	}
+
+	switch e.op {
+	case opCreateDatabase, opDropDatabase:
+		return e, nil
+	case opCreateSequence, opSetSequence:
+		if e.sequence, _, err = decodeSequenceState(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode sequence: %w", err)
+		}
+
+		return e, nil
+	case opDropSequence:
+		if e.sequence.name, _, err = decodeString(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode sequence: %w", err)
+		}
+
+		return e, nil
+	}
+
+	if e.table, n, err = decodeString(buf[offset:]); err != nil {
+		return entry{}, fmt.Errorf("decode table: %w", err)
+	}
+
+	offset += n
+
+	switch e.op {
+	case opCreateTable:
+		if e.scheme, n, err = codec.DecodeScheme(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode scheme: %w", err)
+		}
+
+		offset += n
+
+		if offset < len(buf) {
+			if e.engine, _, err = decodeString(buf[offset:]); err != nil {
+				return entry{}, fmt.Errorf("decode engine: %w", err)
+			}
+		}
+	case opDropTable:
+	case opInsert, opUpdate, opDelete:
+		var key string
+
+		if key, n, err = decodeString(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode key: %w", err)
+		}
+
+		e.key = sql.Key(key)
+		offset += n
+
+		if e.op == opDelete {
+			break
+		}
+
+		if e.row, _, err = codec.DecodeRow(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode row: %w", err)
+		}
+	case opCreateIndex:
+		if e.index, _, err = decodeIndexDef(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode index: %w", err)
+		}
+	case opDropIndex:
+		if e.index.name, _, err = decodeString(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode index: %w", err)
+		}
+	case opSetStatistics:
+		if e.statistics, _, err = decodeStatistics(buf[offset:]); err != nil {
+			return entry{}, fmt.Errorf("decode statistics: %w", err)
+		}
+	default:
+		return entry{}, fmt.Errorf("unknown operation %d", e.op)
+	}
+
+	return e, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+
+	return append(buf, s...)
+}
+
+func decodeString(buf []byte) (string, int, error) {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf[n:])) < length {
+		return "", 0, errors.New("unexpected end of entry")
+	}
+
+	return string(buf[n : n+int(length)]), n + int(length), nil
+}
+
+// journal writes changes to the write-ahead log before they are applied to data files.
+//
+// Every change holds the read lock from the moment it's logged until it's applied,
+// a checkpoint holds the write lock, so it never sees a logged but not yet applied change.
+type journal struct {
+	mu             sync.RWMutex
+	log            *wal.Log
+	checkpointSize int64
+	checkpointCh   chan struct{}
+}
+
+func newJournal(log *wal.Log, checkpointSize int64) *journal {
+	return &journal{
+		log:            log,
+		checkpointSize: checkpointSize,
+		checkpointCh:   make(chan struct{}, 1),
+	}
+}
+
+// write appends the entry to the log and waits until it's durable.
+func (j *journal) write(e entry) error {
+	record, err := e.encode()
+	if err != nil {
+		return fmt.Errorf("encode log entry: %w", err)
+	}
+
+	if err = j.log.Append(record); err != nil {
+		return fmt.Errorf("write log entry: %w", err)
+	}
+
+	if j.log.Size() >= j.checkpointSize {
+		select {
+		case j.checkpointCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
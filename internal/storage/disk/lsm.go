@@ -0,0 +1,466 @@
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+)
+
+// lsmTableExt is the extension of directories of tables stored in LSM trees.
+const lsmTableExt = ".lsm"
+
+// LSMTable stores its rows in a log-structured merge tree: changes go to the memtable and reach
+// immutable SSTable files in bulk, which suits write-heavy tables. Rows are kept in the primary key order,
+// so point lookups and range scans don't need an in-memory map of locations; bloom filters of SSTables
+// make lookups of missing keys cheap, e.g. the duplicate check of an insert.
+//
+// The scheme, the sequence value and index definitions are stored in the header file of the table directory,
+// the content of secondary indexes is rebuilt when the table is opened.
+//
+// Every change is written to the journal before it's applied to the tree, the memtable reaches
+// the disk when the table is synced.
+type LSMTable struct {
+	mu         sync.RWMutex
+	database   string
+	name       string
+	dir        string
+	scheme     sql.Scheme
+	primaryKey []sql.Column
+	header     *dataFile
+	journal    *journal
+	tree       *lsm.Tree
+	seq        *Sequence
+	tableIndexes
+}
+
+func newLSMTable(
+	dir, database, name string,
+	scheme sql.Scheme,
+	header *dataFile,
+	tree *lsm.Tree,
+	journal *journal,
+	seq int64,
+) *LSMTable {
+	t := &LSMTable{
+		database:   database,
+		name:       name,
+		dir:        dir,
+		scheme:     scheme,
+		primaryKey: scheme.PrimaryKey(),
+		header:     header,
+		journal:    journal,
+		tree:       tree,
+		seq: &Sequence{
+			mu:    sync.RWMutex{},
+			value: seq,
+		},
+	}
+
+	t.tableIndexes = newTableIndexes(t, &t.mu, journal, header)
+
+	return t
+}
+
+func createLSMTable(
+	dir, database, name string,
+	scheme sql.Scheme,
+	journal *journal,
+	opts lsm.Options,
+) (*LSMTable, error) {
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	header, err := createDataFile(filepath.Join(dir, headerFileName), scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := lsm.Open(dir, opts)
+	if err != nil {
+		_ = header.close()
+
+		return nil, err
+	}
+
+	return newLSMTable(dir, database, name, scheme, header, tree, journal, 0), nil
+}
+
+func openLSMTable(dir, database, name string, journal *journal, opts lsm.Options) (*LSMTable, error) {
+	header, h, err := openDataFile(filepath.Join(dir, headerFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := lsm.Open(dir, opts)
+	if err != nil {
+		_ = header.close()
+
+		return nil, err
+	}
+
+	table := newLSMTable(dir, database, name, h.scheme, header, tree, journal, h.sequence)
+
+	if err = table.loadIndexes(h.indexes); err != nil {
+		_ = tree.Close()
+		_ = header.close()
+
+		return nil, err
+	}
+
+	if err = table.load(); err != nil {
+		_ = tree.Close()
+		_ = header.close()
+
+		return nil, fmt.Errorf("load table %q: %w", name, err)
+	}
+
+	return table, nil
+}
+
+// load restores the sequence value and the content of indexes.
+func (t *LSMTable) load() error {
+	return t.scanAll(func(key sql.Key, row sql.Row) error {
+		t.seq.advance(key)
+
+		for _, idx := range t.indexes {
+			idx.Insert(key, row)
+		}
+
+		return nil
+	})
+}
+
+func (t *LSMTable) Name() string {
+	return t.name
+}
+
+func (t *LSMTable) Scheme() sql.Scheme {
+	return t.scheme
+}
+
+func (t *LSMTable) PrimaryKey() []sql.Column {
+	return t.primaryKey
+}
+
+func (t *LSMTable) Sequence() sql.Sequence {
+	return t.seq
+}
+
+func (t *LSMTable) Scan() (sql.RowIter, error) {
+	i := &rangeIter{
+		read: t.readRange,
+		from: "",
+		to:   "",
+		rows: nil,
+	}
+
+	return i, nil
+}
+
+func (t *LSMTable) Get(key sql.Key) (sql.Row, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.get(key)
+}
+
+func (t *LSMTable) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	low, high, ok, err := sql.KeyRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	i := &rangeIter{
+		read: t.readRange,
+		from: low,
+		to:   high,
+		rows: nil,
+	}
+
+	return i, nil
+}
+
+func (t *LSMTable) Insert(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, exists, err := t.tree.Get(string(key))
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("duplicate primary key: %s", key)
+	}
+
+	if err = t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	record, err := encodeRecord(key, row)
+	if err != nil {
+		return err
+	}
+
+	if err = t.journal.write(t.entry(opInsert, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, record)
+}
+
+func (t *LSMTable) Delete(key sql.Key) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, exists, err := t.tree.Get(string(key))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err = t.journal.write(t.entry(opDelete, key, nil)); err != nil {
+		return err
+	}
+
+	return t.remove(key)
+}
+
+func (t *LSMTable) Update(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, exists, err := t.tree.Get(string(key))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err = t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	record, err := encodeRecord(key, row)
+	if err != nil {
+		return err
+	}
+
+	if err = t.journal.write(t.entry(opUpdate, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, record)
+}
+
+func (t *LSMTable) entry(op operation, key sql.Key, row sql.Row) entry {
+	return entry{
+		op:       op,
+		database: t.database,
+		table:    t.name,
+		key:      key,
+		row:      row,
+	}
+}
+
+// put inserts the record of the row or replaces the existing one with the same key.
+// Uniqueness of indexes isn't checked, see Table.put.
+func (t *LSMTable) put(key sql.Key, row sql.Row, record []byte) error {
+	var previous sql.Row
+
+	if len(t.indexes) > 0 {
+		var err error
+
+		if previous, _, err = t.get(key); err != nil {
+			return err
+		}
+	}
+
+	if err := t.tree.Put(string(key), record); err != nil {
+		return err
+	}
+
+	t.seq.advance(key)
+
+	for _, idx := range t.indexes {
+		if previous != nil {
+			idx.Delete(key, previous)
+		}
+
+		idx.Insert(key, row)
+	}
+
+	return nil
+}
+
+// remove deletes the row with the key if it exists.
+func (t *LSMTable) remove(key sql.Key) error {
+	var previous sql.Row
+
+	if len(t.indexes) > 0 {
+		var err error
+
+		if previous, _, err = t.get(key); err != nil {
+			return err
+		}
+	}
+
+	if err := t.tree.Delete(string(key)); err != nil {
+		return err
+	}
+
+	for _, idx := range t.indexes {
+		if previous != nil {
+			idx.Delete(key, previous)
+		}
+	}
+
+	return nil
+}
+
+// get returns the row with the key. The caller must hold the lock.
+func (t *LSMTable) get(key sql.Key) (sql.Row, bool, error) {
+	record, ok, err := t.tree.Get(string(key))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	_, row, err := decodeRecord(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode record of key %s: %w", key, err)
+	}
+
+	return row, true, nil
+}
+
+// readRows returns rows with the keys, keys of removed rows are skipped. The caller must hold the lock.
+func (t *LSMTable) readRows(keys []sql.Key) ([]sql.Row, error) {
+	rows := make([]sql.Row, 0, len(keys))
+
+	for _, key := range keys {
+		row, ok, err := t.get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// readRange returns up to limit rows with keys in the range [low, high) in the key order
+// and the key of the last returned row.
+func (t *LSMTable) readRange(low, high sql.Key, limit int) ([]sql.Row, sql.Key, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var (
+		rows    = make([]sql.Row, 0)
+		last    sql.Key
+		readErr error
+	)
+
+	err := t.tree.Scan(string(low), string(high), func(k string, record []byte) bool {
+		key := sql.Key(k)
+
+		_, row, err := decodeRecord(record)
+		if err != nil {
+			readErr = fmt.Errorf("decode record of key %s: %w", key, err)
+
+			return false
+		}
+
+		rows = append(rows, row)
+		last = key
+
+		return len(rows) < limit
+	})
+
+	if err = errors.Join(err, readErr); err != nil {
+		return nil, "", err
+	}
+
+	return rows, last, nil
+}
+
+// scanAll calls fn for every row of the table. The caller must hold the lock.
+func (t *LSMTable) scanAll(fn func(key sql.Key, row sql.Row) error) error {
+	var fnErr error
+
+	err := t.tree.Scan("", "", func(k string, record []byte) bool {
+		key := sql.Key(k)
+
+		_, row, err := decodeRecord(record)
+		if err != nil {
+			fnErr = fmt.Errorf("decode record of key %s: %w", key, err)
+
+			return false
+		}
+
+		fnErr = fn(key, row)
+
+		return fnErr == nil
+	})
+
+	return errors.Join(err, fnErr)
+}
+
+// sync flushes the memtable to a new SSTable and writes the sequence value to the header file.
+func (t *LSMTable) sync() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.tree.Flush(); err != nil {
+		return err
+	}
+
+	if err := t.header.writeSequence(t.seq.Value()); err != nil {
+		return err
+	}
+
+	if err := t.header.sync(); err != nil {
+		return err
+	}
+
+	return syncDir(t.dir)
+}
+
+// Close flushes the memtable and closes the tree and the header file.
+func (t *LSMTable) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.tree.Close()
+	if err == nil {
+		err = t.header.writeSequence(t.seq.Value())
+	}
+
+	return errors.Join(err, t.header.close())
+}
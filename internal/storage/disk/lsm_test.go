@@ -0,0 +1,255 @@
+package disk_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+)
+
+func TestLSMTable(t *testing.T) {
+	t.Parallel()
+
+	table := createLSMTable(t, openCatalog(t, t.TempDir()))
+	require.IsType(t, &disk.LSMTable{}, table)
+
+	// More rows than a scan reads at once.
+	for key := int64(1); key <= 600; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, "Max")))
+	}
+
+	require.Error(t, table.Insert(sql.IntegerKey(1), userRow(1, "Vlad")))
+
+	for key := int64(2); key <= 600; key += 2 {
+		require.NoError(t, table.Delete(sql.IntegerKey(key)))
+	}
+
+	require.Error(t, table.Delete(sql.IntegerKey(2)))
+	require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "Vlad")))
+	require.Error(t, table.Update(sql.IntegerKey(2), userRow(2, "Vlad")))
+
+	rows := scanRows(t, table)
+	require.Len(t, rows, 300)
+	assert.Equal(t, userRow(1, "Vlad"), rows[0])
+	assert.Equal(t, userRow(599, "Max"), rows[299])
+
+	row, ok, err := table.Get(sql.IntegerKey(3))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, userRow(3, "Max"), row)
+
+	_, ok, err = table.Get(sql.IntegerKey(4))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	iter, err := table.ScanRange(
+		&sql.Bound{Value: datatype.NewInteger(2), Inclusive: true},
+		&sql.Bound{Value: datatype.NewInteger(7), Inclusive: false},
+	)
+	require.NoError(t, err)
+
+	rows = make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, []sql.Row{userRow(3, "Max"), userRow(5, "Max")}, rows)
+}
+
+func TestLSMTable_Indexes(t *testing.T) {
+	t.Parallel()
+
+	table := createLSMTable(t, openCatalog(t, t.TempDir()))
+	require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+	require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Max")))
+
+	_, err := table.CreateIndex("users_name", []string{"name"}, true)
+	require.Error(t, err)
+
+	index, err := table.CreateIndex("users_name", []string{"name"}, false)
+	require.NoError(t, err)
+
+	require.NoError(t, table.Insert(sql.IntegerKey(3), userRow(3, "Vlad")))
+	require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "Vlad")))
+	assert.Equal(t, []sql.Row{userRow(2, "Max")}, scanIndex(t, index, "Max"))
+	assert.Equal(t, []sql.Row{userRow(1, "Vlad"), userRow(3, "Vlad")}, scanIndex(t, index, "Vlad"))
+
+	require.NoError(t, table.Delete(sql.IntegerKey(3)))
+	assert.Equal(t, []sql.Row{userRow(1, "Vlad")}, scanIndex(t, index, "Vlad"))
+
+	require.NoError(t, table.DropIndex("users_name"))
+	assert.Empty(t, table.Indexes())
+}
+
+func TestLSMTable_Persistence(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	opts := disk.WithLSMOptions(lsm.Options{MemtableSize: 1 << 10, CompactionThreshold: 2})
+
+	catalog, err := disk.Open(dir, opts)
+	require.NoError(t, err)
+
+	table := createLSMTable(t, catalog)
+
+	_, err = table.CreateIndex("users_name", []string{"name"}, false)
+	require.NoError(t, err)
+
+	for key := int64(1); key <= 200; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, "Max")))
+	}
+
+	for key := int64(1); key <= 200; key += 2 {
+		require.NoError(t, table.Delete(sql.IntegerKey(key)))
+	}
+
+	require.NoError(t, table.Update(sql.IntegerKey(200), userRow(200, "Vlad")))
+
+	table.Sequence().Next()
+
+	expected := scanRows(t, table)
+	require.Len(t, expected, 100)
+	require.NoError(t, catalog.Close())
+
+	catalog = openCatalog(t, dir, opts)
+
+	database, err := catalog.GetDatabase("events")
+	require.NoError(t, err)
+
+	table, err = database.GetTable("users")
+	require.NoError(t, err)
+	require.IsType(t, &disk.LSMTable{}, table)
+	require.Equal(t, expected, scanRows(t, table))
+	require.Equal(t, int64(202), table.Sequence().Next())
+
+	indexes := table.Indexes()
+	require.Len(t, indexes, 1)
+	assert.Equal(t, []sql.Row{userRow(200, "Vlad")}, scanIndex(t, indexes[0], "Vlad"))
+}
+
+func TestCatalog_Engines(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tables inherit the engine of the database", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog, err := disk.Open(dir, disk.WithEngine(disk.EngineLSM))
+		require.NoError(t, err)
+
+		database, err := catalog.CreateDatabase("events")
+		require.NoError(t, err)
+
+		events, err := database.CreateTable("events", usersScheme())
+		require.NoError(t, err)
+		require.IsType(t, &disk.LSMTable{}, events)
+
+		users, err := database.(sql.EngineDatabase).CreateTableUsing("users", usersScheme(), disk.EngineHeap)
+		require.NoError(t, err)
+		require.IsType(t, &disk.Table{}, users)
+
+		_, err = database.(sql.EngineDatabase).CreateTableUsing("other", usersScheme(), "unknown")
+		require.Error(t, err)
+		require.NoError(t, catalog.Close())
+
+		// The engine of the database doesn't depend on the default of the catalog.
+		catalog = openCatalog(t, dir)
+
+		database, err = catalog.GetDatabase("events")
+		require.NoError(t, err)
+		assert.Equal(t, disk.EngineLSM, database.(*disk.Database).Engine())
+
+		events, err = database.GetTable("events")
+		require.NoError(t, err)
+		require.IsType(t, &disk.LSMTable{}, events)
+
+		users, err = database.GetTable("users")
+		require.NoError(t, err)
+		require.IsType(t, &disk.Table{}, users)
+
+		tickets, err := database.CreateTable("tickets", usersScheme())
+		require.NoError(t, err)
+		require.IsType(t, &disk.LSMTable{}, tickets)
+	})
+
+	t.Run("drops tables", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog := openCatalog(t, dir)
+		table := createLSMTable(t, catalog)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+
+		database, err := catalog.GetDatabase("events")
+		require.NoError(t, err)
+		require.NoError(t, database.DropTable("users"))
+		require.NoDirExists(t, filepath.Join(dir, "events", "users.lsm"))
+
+		table, err = database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+		assert.Empty(t, scanRows(t, table))
+	})
+
+	t.Run("removes interrupted tables", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		catalog, err := disk.Open(dir)
+		require.NoError(t, err)
+
+		createLSMTable(t, catalog)
+		require.NoError(t, catalog.Close())
+
+		// A table directory without the header is left by an interrupted creation or removal.
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "events", "tickets.lsm"), 0o755))
+
+		catalog = openCatalog(t, dir)
+
+		database, err := catalog.GetDatabase("events")
+		require.NoError(t, err)
+		require.Len(t, database.ListTables(), 1)
+		require.NoDirExists(t, filepath.Join(dir, "events", "tickets.lsm"))
+	})
+
+	t.Run("rejects unknown engines", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := disk.Open(t.TempDir(), disk.WithEngine("unknown"))
+		require.Error(t, err)
+
+		catalog := openCatalog(t, t.TempDir())
+
+		_, err = catalog.CreateDatabaseUsing("events", "unknown")
+		require.Error(t, err)
+	})
+}
+
+// createLSMTable creates the users table in the events database stored by the LSM engine.
+func createLSMTable(t *testing.T, catalog *disk.Catalog) sql.Table {
+	t.Helper()
+
+	database, err := catalog.CreateDatabaseUsing("events", disk.EngineLSM)
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	return table
+}
@@ -0,0 +1,196 @@
+package disk
+
+import (
+	"encoding/binary"
+)
+
+// PageSize is the size of a single page of the data file.
+const PageSize = 4096
+
+const (
+	pageHeaderSize = 4
+	slotSize       = 4
+)
+
+// maxRecordSize is the size of the largest record that fits into an empty page.
+const maxRecordSize = PageSize - pageHeaderSize - slotSize
+
+// page is a slotted page. The header holds the number of slots and the offset of the records area,
+// the slot directory grows forward right after the header and the records grow backward from the end of the page:
+//
+//	+-------+-------+-----+-------+-------------+----------+-----+----------+
+//	| slots | upper | s0  | s1    | free space  | record 1 | ... | record 0 |
+//	+-------+-------+-----+-------+-------------+----------+-----+----------+
+//
+// Every slot keeps the offset and the length of its record, a slot with zero length is dead and can be reused.
+type page []byte
+
+func newPage() page {
+	p := make(page, PageSize)
+	p.setUpper(PageSize)
+
+	return p
+}
+
+func (p page) slots() int {
+	return int(binary.BigEndian.Uint16(p[0:2]))
+}
+
+func (p page) setSlots(n int) {
+	binary.BigEndian.PutUint16(p[0:2], uint16(n))
+}
+
+func (p page) upper() int {
+	return int(binary.BigEndian.Uint16(p[2:4]))
+}
+
+func (p page) setUpper(offset int) {
+	// PageSize doesn't fit into uint16, so an empty records area is stored as zero.
+	binary.BigEndian.PutUint16(p[2:4], uint16(offset%PageSize))
+}
+
+func (p page) upperOrEnd() int {
+	if upper := p.upper(); upper != 0 {
+		return upper
+	}
+
+	return PageSize
+}
+
+func (p page) slot(n int) (offset, length int) {
+	pos := pageHeaderSize + n*slotSize
+
+	return int(binary.BigEndian.Uint16(p[pos : pos+2])), int(binary.BigEndian.Uint16(p[pos+2 : pos+4]))
+}
+
+func (p page) setSlot(n, offset, length int) {
+	pos := pageHeaderSize + n*slotSize
+
+	binary.BigEndian.PutUint16(p[pos:pos+2], uint16(offset))
+	binary.BigEndian.PutUint16(p[pos+2:pos+4], uint16(length))
+}
+
+// record returns the record stored in the slot or nil if the slot is dead.
+func (p page) record(n int) []byte {
+	if n >= p.slots() {
+		return nil
+	}
+
+	offset, length := p.slot(n)
+	if length == 0 {
+		return nil
+	}
+
+	return p[offset : offset+length]
+}
+
+// freeSpace returns the number of bytes available for a new record, including the space of dead records.
+func (p page) freeSpace() int {
+	used := 0
+
+	for i := 0; i < p.slots(); i++ {
+		_, length := p.slot(i)
+		used += length
+	}
+
+	free := PageSize - pageHeaderSize - p.slots()*slotSize - used
+
+	if p.deadSlot() < 0 {
+		free -= slotSize
+	}
+
+	return max(free, 0)
+}
+
+// insert puts the record into the page and returns its slot or false if there is not enough space.
+func (p page) insert(record []byte) (int, bool) {
+	if len(record) > p.freeSpace() {
+		return 0, false
+	}
+
+	n := p.deadSlot()
+	if n < 0 {
+		n = p.slots()
+		p.setSlots(n + 1)
+		p.setSlot(n, 0, 0)
+	}
+
+	p.place(n, record)
+
+	return n, true
+}
+
+// update replaces the record in the slot and returns false if the new record doesn't fit into the page.
+func (p page) update(n int, record []byte) bool {
+	offset, length := p.slot(n)
+
+	if len(record) <= length {
+		copy(p[offset:], record)
+		p.setSlot(n, offset, len(record))
+
+		return true
+	}
+
+	if len(record) > p.freeSpace()+length {
+		return false
+	}
+
+	p.setSlot(n, 0, 0)
+	p.place(n, record)
+
+	return true
+}
+
+// delete marks the slot as dead.
+func (p page) delete(n int) {
+	p.setSlot(n, 0, 0)
+}
+
+// place writes the record into the records area, compacting the page if there is no contiguous space.
+func (p page) place(n int, record []byte) {
+	lower := pageHeaderSize + p.slots()*slotSize
+
+	if p.upperOrEnd()-lower < len(record) {
+		p.compact()
+	}
+
+	offset := p.upperOrEnd() - len(record)
+	copy(p[offset:], record)
+	p.setSlot(n, offset, len(record))
+	p.setUpper(offset)
+}
+
+// compact moves all live records to the end of the page, so the free space becomes contiguous.
+func (p page) compact() {
+	records := make([][]byte, p.slots())
+
+	for i := range records {
+		if record := p.record(i); record != nil {
+			records[i] = append([]byte(nil), record...)
+		}
+	}
+
+	upper := PageSize
+
+	for i := range records {
+		if records[i] == nil {
+			continue
+		}
+
+		upper -= len(records[i])
+		copy(p[upper:], records[i])
+		p.setSlot(i, upper, len(records[i]))
+	}
+
+	p.setUpper(upper)
+}
+
+func (p page) deadSlot() int {
+	for i := 0; i < p.slots(); i++ {
+		if _, length := p.slot(i); length == 0 {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,283 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+)
+
+// Sequence is the primary key generator of a table, its value is persisted in the header of the data file.
+type Sequence struct {
+	mu    sync.RWMutex
+	value int64
+	// pending tells that the value was returned by Next and no row has been stored since then.
+	pending bool
+}
+
+func (s *Sequence) Next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.value++
+	s.pending = true
+
+	return s.value
+}
+
+func (s *Sequence) Release(value int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending && s.value == value {
+		s.value--
+		s.pending = false
+	}
+}
+
+func (s *Sequence) SetValue(value int64) {
+	s.mu.Lock()
+	s.value = value
+	s.pending = false
+	s.mu.Unlock()
+}
+
+// advance moves the sequence forward to the key if it's a single integer,
+// a sequence that is already past it is left as is.
+func (s *Sequence) advance(key sql.Key) {
+	value, ok := key.Integer()
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A stored row may hold the pending value, so it can't be given back anymore.
+	s.value = max(s.value, value)
+	s.pending = false
+}
+
+func (s *Sequence) Value() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.value
+}
+
+// sequencesFileName is the file in the database directory that holds named sequences of the database.
+const sequencesFileName = "SEQUENCES"
+
+// sequenceCache is the number of values a named sequence hands out per write to the log.
+const sequenceCache = 32
+
+// errSequenceDropped is returned by a sequence obtained before it was dropped.
+var errSequenceDropped = errors.New("sequence is dropped")
+
+// sequenceState is a named sequence as it's written to the log and to the sequences file.
+type sequenceState struct {
+	name    string
+	options sql.SequenceOptions
+	value   int64
+	called  bool
+}
+
+// NamedSequence is a sequence created by CREATE SEQUENCE. Next logs a value a few increments ahead
+// and hands out values up to it without writing to the log, so after a crash the sequence continues
+// from the logged value: values may be skipped, but never handed out twice.
+type NamedSequence struct {
+	mu       sync.Mutex
+	database string
+	journal  *journal
+	state    sequenceState
+	cached   int // values that Next hands out before it writes to the log again
+	dropped  bool
+}
+
+func newNamedSequence(database string, state sequenceState, journal *journal) *NamedSequence {
+	return &NamedSequence{
+		database: database,
+		journal:  journal,
+		state:    state,
+	}
+}
+
+func (s *NamedSequence) Name() string {
+	return s.state.name
+}
+
+func (s *NamedSequence) Options() sql.SequenceOptions {
+	return s.state.options
+}
+
+func (s *NamedSequence) Next() (int64, error) {
+	s.journal.mu.RLock()
+	defer s.journal.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dropped {
+		return 0, errSequenceDropped
+	}
+
+	value, err := s.state.options.NextValue(s.state.value, s.state.called)
+	if err != nil {
+		return 0, fmt.Errorf("nextval of %q: %w", s.state.name, err)
+	}
+
+	if s.cached == 0 {
+		logged := s.state
+		logged.value, logged.called = value, true
+
+		// The sequence may reach its limit before the cache is full, then it logs fewer values.
+		for s.cached = 1; s.cached < sequenceCache; s.cached++ {
+			ahead, limitErr := logged.options.NextValue(logged.value, true)
+			if limitErr != nil {
+				break
+			}
+
+			logged.value = ahead
+		}
+
+		if err = s.journal.write(entry{op: opSetSequence, database: s.database, sequence: logged}); err != nil {
+			s.cached = 0
+
+			return 0, err
+		}
+	}
+
+	s.state.value, s.state.called = value, true
+	s.cached--
+
+	return value, nil
+}
+
+func (s *NamedSequence) Current() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.value, s.state.called
+}
+
+func (s *NamedSequence) SetValue(value int64) error {
+	s.journal.mu.RLock()
+	defer s.journal.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dropped {
+		return errSequenceDropped
+	}
+
+	state := s.state
+	state.value, state.called = value, true
+
+	if err := s.journal.write(entry{op: opSetSequence, database: s.database, sequence: state}); err != nil {
+		return err
+	}
+
+	s.set(value, true)
+
+	return nil
+}
+
+// set changes the value, so the next call of Next writes to the log. The caller must hold the lock.
+func (s *NamedSequence) set(value int64, called bool) {
+	s.state.value, s.state.called = value, called
+	s.cached = 0
+}
+
+// snapshot returns the state of the sequence. Changes of the sequence are logged,
+// so from now on Next writes to the log before it hands out a value.
+func (s *NamedSequence) snapshot() sequenceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = 0
+
+	return s.state
+}
+
+func appendSequenceState(buf []byte, state sequenceState) []byte {
+	buf = appendString(buf, state.name)
+	buf = binary.AppendVarint(buf, state.options.Start)
+	buf = binary.AppendVarint(buf, state.options.Increment)
+	buf = binary.AppendVarint(buf, state.value)
+
+	if state.called {
+		return append(buf, 1)
+	}
+
+	return append(buf, 0)
+}
+
+func decodeSequenceState(buf []byte) (sequenceState, int, error) {
+	var state sequenceState
+
+	name, offset, err := decodeString(buf)
+	if err != nil {
+		return sequenceState{}, 0, err
+	}
+
+	state.name = name
+
+	for _, value := range []*int64{&state.options.Start, &state.options.Increment, &state.value} {
+		v, n := binary.Varint(buf[offset:])
+		if n <= 0 {
+			return sequenceState{}, 0, errors.New("unexpected end of sequence")
+		}
+
+		*value = v
+		offset += n
+	}
+
+	if offset >= len(buf) {
+		return sequenceState{}, 0, errors.New("unexpected end of sequence")
+	}
+
+	state.called = buf[offset] == 1
+
+	return state, offset + 1, nil
+}
+
+// readSequences reads named sequences from the sequences file in the directory, the file may not exist.
+func readSequences(dir string) ([]sequenceState, error) {
+	buf, err := readMetaFile(filepath.Join(dir, sequencesFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read sequences: %w", err)
+	}
+
+	var states []sequenceState
+
+	for offset := 0; offset < len(buf); {
+		state, n, err := decodeSequenceState(buf[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("read sequences: %w", err)
+		}
+
+		states = append(states, state)
+		offset += n
+	}
+
+	return states, nil
+}
+
+// writeSequences replaces the sequences file in the directory, the file is removed if there are no sequences.
+func writeSequences(dir string, states []sequenceState) error {
+	var buf []byte
+
+	for _, state := range states {
+		buf = appendSequenceState(buf, state)
+	}
+
+	if err := writeMetaFile(filepath.Join(dir, sequencesFileName), buf); err != nil {
+		return fmt.Errorf("write sequences: %w", err)
+	}
+
+	return nil
+}
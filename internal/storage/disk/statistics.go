@@ -0,0 +1,137 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/codec"
+)
+
+// statisticsFileName is the file in the database directory that holds statistics of tables collected by ANALYZE.
+const statisticsFileName = "STATISTICS"
+
+var errShortStatistics = errors.New("unexpected end of statistics")
+
+func appendStatistics(buf []byte, statistics sql.Statistics) ([]byte, error) {
+	var err error
+
+	buf = binary.AppendVarint(buf, statistics.Rows)
+	buf = binary.AppendUvarint(buf, uint64(len(statistics.Columns)))
+
+	for name, column := range statistics.Columns {
+		buf = appendString(buf, name)
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(column.NullFraction))
+		buf = binary.AppendVarint(buf, column.Distinct)
+
+		if buf, err = codec.AppendRow(buf, column.Histogram); err != nil {
+			return nil, fmt.Errorf("encode histogram of %q: %w", name, err)
+		}
+	}
+
+	return buf, nil
+}
+
+func decodeStatistics(buf []byte) (sql.Statistics, int, error) {
+	rows, offset := binary.Varint(buf)
+	if offset <= 0 {
+		return sql.Statistics{}, 0, errShortStatistics
+	}
+
+	count, n := binary.Uvarint(buf[offset:])
+	if n <= 0 || count > uint64(len(buf)) {
+		return sql.Statistics{}, 0, errShortStatistics
+	}
+
+	offset += n
+
+	statistics := sql.Statistics{
+		Rows:    rows,
+		Columns: make(map[string]sql.ColumnStatistics, count),
+	}
+
+	for range count {
+		name, n, err := decodeString(buf[offset:])
+		if err != nil {
+			return sql.Statistics{}, 0, err
+		}
+
+		offset += n
+
+		if len(buf[offset:]) < 8 {
+			return sql.Statistics{}, 0, errShortStatistics
+		}
+
+		var column sql.ColumnStatistics
+
+		column.NullFraction = math.Float64frombits(binary.BigEndian.Uint64(buf[offset:]))
+		offset += 8
+
+		if column.Distinct, n = binary.Varint(buf[offset:]); n <= 0 {
+			return sql.Statistics{}, 0, errShortStatistics
+		}
+
+		offset += n
+
+		if column.Histogram, n, err = codec.DecodeRow(buf[offset:]); err != nil {
+			return sql.Statistics{}, 0, fmt.Errorf("decode histogram of %q: %w", name, err)
+		}
+
+		offset += n
+		statistics.Columns[name] = column
+	}
+
+	return statistics, offset, nil
+}
+
+// readStatistics reads statistics of tables from the statistics file in the directory, the file may not exist.
+func readStatistics(dir string) (map[string]sql.Statistics, error) {
+	buf, err := readMetaFile(filepath.Join(dir, statisticsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read statistics: %w", err)
+	}
+
+	tables := make(map[string]sql.Statistics)
+
+	for offset := 0; offset < len(buf); {
+		table, n, err := decodeString(buf[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("read statistics: %w", err)
+		}
+
+		offset += n
+
+		if tables[table], n, err = decodeStatistics(buf[offset:]); err != nil {
+			return nil, fmt.Errorf("read statistics of %q: %w", table, err)
+		}
+
+		offset += n
+	}
+
+	return tables, nil
+}
+
+// writeStatistics replaces the statistics file in the directory, the file is removed if there are no statistics.
+func writeStatistics(dir string, tables map[string]sql.Statistics) error {
+	var (
+		buf []byte
+		err error
+	)
+
+	for table, statistics := range tables {
+		buf = appendString(buf, table)
+
+		if buf, err = appendStatistics(buf, statistics); err != nil {
+			return fmt.Errorf("write statistics of %q: %w", table, err)
+		}
+	}
+
+	if err = writeMetaFile(filepath.Join(dir, statisticsFileName), buf); err != nil {
+		return fmt.Errorf("write statistics: %w", err)
+	}
+
+	return nil
+}
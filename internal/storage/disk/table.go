@@ -0,0 +1,781 @@
+package disk
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/codec"
+)
+
+// scanBatch is the number of rows a scan in the key order reads at once.
+const scanBatch = 256
+
+// rid is the physical location of a row in the data file.
+type rid struct {
+	page int
+	slot int
+}
+
+// Table stores its rows in a page-structured data file.
+// The location of every row is kept in memory in the primary key order,
+// it is restored by scanning the file when the table is opened.
+//
+// Secondary indexes are kept in memory as well: their definitions are stored in the header page,
+// and their content is rebuilt when the table is opened.
+//
+// Every change is written to the journal before it's applied to the data file.
+// Data pages are read and changed through the buffer pool, dirty pages reach the file when they are evicted
+// or when the table is synced.
+type Table struct {
+	mu         sync.RWMutex
+	database   string
+	name       string
+	scheme     sql.Scheme
+	primaryKey []sql.Column
+	file       *dataFile
+	journal    *journal
+	pool       *buffer.Pool
+	seq        *Sequence
+	locations  *btree.Tree[sql.Key, rid]
+	free       map[int]int
+	tableIndexes
+}
+
+func newTable(
+	database, name string,
+	scheme sql.Scheme,
+	file *dataFile,
+	journal *journal,
+	pool *buffer.Pool,
+	seq int64,
+) *Table {
+	t := &Table{
+		database:   database,
+		name:       name,
+		scheme:     scheme,
+		primaryKey: scheme.PrimaryKey(),
+		file:       file,
+		journal:    journal,
+		pool:       pool,
+		seq: &Sequence{
+			mu:    sync.RWMutex{},
+			value: seq,
+		},
+		locations: btree.New[sql.Key, rid](cmp.Compare[sql.Key]),
+		free:      make(map[int]int),
+	}
+
+	t.tableIndexes = newTableIndexes(t, &t.mu, journal, file)
+
+	return t
+}
+
+func createTable(path, database, name string, scheme sql.Scheme, journal *journal, pool *buffer.Pool) (*Table, error) {
+	file, err := createDataFile(path, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTable(database, name, scheme, file, journal, pool, 0), nil
+}
+
+func openTable(path, database, name string, journal *journal, pool *buffer.Pool) (*Table, error) {
+	file, h, err := openDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	table := newTable(database, name, h.scheme, file, journal, pool, h.sequence)
+
+	if err = table.loadIndexes(h.indexes); err != nil {
+		_ = file.close()
+
+		return nil, err
+	}
+
+	if err = table.load(); err != nil {
+		pool.Discard(file)
+		_ = file.close()
+
+		return nil, fmt.Errorf("load table %q: %w", name, err)
+	}
+
+	return table, nil
+}
+
+// load restores the location of every row, the free space of every page and the content of indexes.
+func (t *Table) load() error {
+	for n := 1; n < t.file.pages; n++ {
+		if err := t.loadPage(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Table) loadPage(n int) error {
+	frame, p, err := t.fetchPage(n)
+	if err != nil {
+		return err
+	}
+
+	dirty := false
+
+	defer func() {
+		t.pool.Unpin(frame, dirty)
+	}()
+
+	for slot := 0; slot < p.slots(); slot++ {
+		record := p.record(slot)
+		if record == nil {
+			continue
+		}
+
+		key, _, err := decodeRecordKey(record)
+		if err != nil {
+			return fmt.Errorf("decode record %d:%d: %w", n, slot, err)
+		}
+
+		// A crash while a grown row was moving to another page leaves two copies of it.
+		// Keep only one, the write-ahead log restores the latest version of the row anyway.
+		if _, ok := t.locations.Get(key); ok {
+			p.delete(slot)
+			dirty = true
+
+			continue
+		}
+
+		t.locations.Put(key, rid{page: n, slot: slot})
+
+		t.seq.advance(key)
+
+		if len(t.indexes) == 0 {
+			continue
+		}
+
+		_, row, err := decodeRecord(record)
+		if err != nil {
+			return fmt.Errorf("decode record %d:%d: %w", n, slot, err)
+		}
+
+		for _, idx := range t.indexes {
+			idx.Insert(key, row)
+		}
+	}
+
+	t.free[n] = p.freeSpace()
+
+	return nil
+}
+
+func (t *Table) Name() string {
+	return t.name
+}
+
+func (t *Table) Scheme() sql.Scheme {
+	return t.scheme
+}
+
+func (t *Table) PrimaryKey() []sql.Column {
+	return t.primaryKey
+}
+
+func (t *Table) Sequence() sql.Sequence {
+	return t.seq
+}
+
+func (t *Table) Scan() (sql.RowIter, error) {
+	i := &iter{
+		table: t,
+		page:  0,
+		rows:  nil,
+	}
+
+	return i, nil
+}
+
+func (t *Table) Get(key sql.Key) (sql.Row, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows, err := t.readRows([]sql.Key{key})
+	if err != nil || len(rows) == 0 {
+		return nil, false, err
+	}
+
+	return rows[0], true, nil
+}
+
+func (t *Table) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	low, high, ok, err := sql.KeyRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	i := &rangeIter{
+		read: t.readRange,
+		from: low,
+		to:   high,
+		rows: nil,
+	}
+
+	return i, nil
+}
+
+func (t *Table) Insert(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.locations.Get(key); ok {
+		return fmt.Errorf("duplicate primary key: %s", key)
+	}
+
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	record, err := encodeRecord(key, row)
+	if err != nil {
+		return err
+	}
+
+	if err = t.journal.write(t.entry(opInsert, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, record)
+}
+
+func (t *Table) Delete(key sql.Key) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.locations.Get(key); !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err := t.journal.write(t.entry(opDelete, key, nil)); err != nil {
+		return err
+	}
+
+	return t.remove(key)
+}
+
+func (t *Table) Update(key sql.Key, row sql.Row) error {
+	t.journal.mu.RLock()
+	defer t.journal.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.locations.Get(key); !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	record, err := encodeRecord(key, row)
+	if err != nil {
+		return err
+	}
+
+	if err = t.journal.write(t.entry(opUpdate, key, row)); err != nil {
+		return err
+	}
+
+	return t.put(key, row, record)
+}
+
+func (t *Table) entry(op operation, key sql.Key, row sql.Row) entry {
+	return entry{
+		op:       op,
+		database: t.database,
+		table:    t.name,
+		key:      key,
+		row:      row,
+	}
+}
+
+// put inserts the record of the row or replaces the existing one with the same key.
+// Uniqueness of indexes isn't checked: while the journal is replayed, the table may temporarily
+// contain rows from the future.
+func (t *Table) put(key sql.Key, row sql.Row, record []byte) error {
+	location, ok := t.locations.Get(key)
+	if !ok {
+		placed, err := t.place(record)
+		if err != nil {
+			return err
+		}
+
+		t.locations.Put(key, placed)
+
+		t.seq.advance(key)
+
+		for _, idx := range t.indexes {
+			idx.Insert(key, row)
+		}
+
+		return nil
+	}
+
+	frame, p, err := t.fetchPage(location.page)
+	if err != nil {
+		return err
+	}
+
+	if err = t.unindex(key, p.record(location.slot)); err != nil {
+		t.pool.Unpin(frame, false)
+
+		return err
+	}
+
+	for _, idx := range t.indexes {
+		idx.Insert(key, row)
+	}
+
+	if p.update(location.slot, record) {
+		t.free[location.page] = p.freeSpace()
+		t.pool.Unpin(frame, true)
+
+		return nil
+	}
+
+	t.pool.Unpin(frame, false)
+
+	// The row has grown and doesn't fit into its page anymore, so move it to another one.
+	moved, err := t.place(record)
+	if err != nil {
+		return err
+	}
+
+	if frame, p, err = t.fetchPage(location.page); err != nil {
+		return err
+	}
+
+	p.delete(location.slot)
+
+	t.free[location.page] = p.freeSpace()
+	t.pool.Unpin(frame, true)
+	t.locations.Put(key, moved)
+
+	return nil
+}
+
+// remove deletes the record with the key if it exists.
+func (t *Table) remove(key sql.Key) error {
+	location, ok := t.locations.Get(key)
+	if !ok {
+		return nil
+	}
+
+	frame, p, err := t.fetchPage(location.page)
+	if err != nil {
+		return err
+	}
+
+	if err = t.unindex(key, p.record(location.slot)); err != nil {
+		t.pool.Unpin(frame, false)
+
+		return err
+	}
+
+	p.delete(location.slot)
+	t.pool.Unpin(frame, true)
+
+	t.locations.Delete(key)
+	t.free[location.page] = p.freeSpace()
+
+	return nil
+}
+
+// unindex removes entries of the stored record from indexes.
+func (t *Table) unindex(key sql.Key, record []byte) error {
+	if len(t.indexes) == 0 {
+		return nil
+	}
+
+	_, row, err := decodeRecord(record)
+	if err != nil {
+		return fmt.Errorf("decode record of key %s: %w", key, err)
+	}
+
+	for _, idx := range t.indexes {
+		idx.Delete(key, row)
+	}
+
+	return nil
+}
+
+// readRows returns rows with the keys, keys of removed rows are skipped. The caller must hold the lock.
+func (t *Table) readRows(keys []sql.Key) ([]sql.Row, error) {
+	var (
+		frame   *buffer.Frame
+		p       page
+		current = -1
+		err     error
+	)
+
+	defer func() {
+		if frame != nil {
+			t.pool.Unpin(frame, false)
+		}
+	}()
+
+	rows := make([]sql.Row, 0, len(keys))
+
+	for _, key := range keys {
+		location, ok := t.locations.Get(key)
+		if !ok {
+			continue
+		}
+
+		if location.page != current {
+			if frame != nil {
+				t.pool.Unpin(frame, false)
+				frame = nil
+			}
+
+			if frame, p, err = t.fetchPage(location.page); err != nil {
+				return nil, err
+			}
+
+			current = location.page
+		}
+
+		_, row, err := decodeRecord(p.record(location.slot))
+		if err != nil {
+			return nil, fmt.Errorf("decode record %d:%d: %w", location.page, location.slot, err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readRange returns up to limit rows with keys in the range [low, high) in the key order
+// and the key of the last returned row.
+func (t *Table) readRange(low, high sql.Key, limit int) ([]sql.Row, sql.Key, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	keys := make([]sql.Key, 0, limit)
+
+	t.locations.AscendGreaterOrEqual(low, func(key sql.Key, _ rid) bool {
+		if !sql.InRange(key, low, high) {
+			return false
+		}
+
+		keys = append(keys, key)
+
+		return len(keys) < limit
+	})
+
+	if len(keys) == 0 {
+		return nil, "", nil
+	}
+
+	rows, err := t.readRows(keys)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rows, keys[len(keys)-1], nil
+}
+
+// scanAll calls fn for every row of the table. The caller must hold the lock.
+func (t *Table) scanAll(fn func(key sql.Key, row sql.Row) error) error {
+	for n := 1; n < t.file.pages; n++ {
+		keys, rows, err := t.pageRows(n)
+		if err != nil {
+			return err
+		}
+
+		for i, row := range rows {
+			if err = fn(keys[i], row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchPage pins the data page in the buffer pool, the frame must be unpinned when the page isn't needed anymore.
+func (t *Table) fetchPage(n int) (*buffer.Frame, page, error) {
+	frame, err := t.pool.Fetch(t.file, n)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch page %d: %w", n, err)
+	}
+
+	return frame, page(frame.Data()), nil
+}
+
+// pageRows returns keys and rows of all records stored in the page. The caller must hold the lock.
+func (t *Table) pageRows(n int) ([]sql.Key, []sql.Row, error) {
+	frame, p, err := t.fetchPage(n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer t.pool.Unpin(frame, false)
+
+	keys := make([]sql.Key, 0, p.slots())
+	rows := make([]sql.Row, 0, p.slots())
+
+	for slot := 0; slot < p.slots(); slot++ {
+		record := p.record(slot)
+		if record == nil {
+			continue
+		}
+
+		key, row, err := decodeRecord(record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode record %d:%d: %w", n, slot, err)
+		}
+
+		keys = append(keys, key)
+		rows = append(rows, row)
+	}
+
+	return keys, rows, nil
+}
+
+// sync writes dirty pages from the buffer pool and the sequence value to the data file
+// and flushes it to the disk.
+func (t *Table) sync() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.pool.Flush(t.file); err != nil {
+		return err
+	}
+
+	if err := t.file.writeSequence(t.seq.Value()); err != nil {
+		return err
+	}
+
+	return t.file.sync()
+}
+
+// Close flushes the data file to the disk and closes it.
+func (t *Table) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer t.pool.Discard(t.file)
+
+	err := t.pool.Flush(t.file)
+	if err == nil {
+		err = t.file.writeSequence(t.seq.Value())
+	}
+
+	if err != nil {
+		_ = t.file.close()
+
+		return err
+	}
+
+	return t.file.close()
+}
+
+// place writes the record to the first page with enough free space, allocating a new page if there is none.
+func (t *Table) place(record []byte) (rid, error) {
+	for n := 1; n < t.file.pages; n++ {
+		if t.free[n] < len(record) {
+			continue
+		}
+
+		if location, ok, err := t.insertRecord(n, record); err != nil || ok {
+			return location, err
+		}
+	}
+
+	n, err := t.file.allocatePage()
+	if err != nil {
+		return rid{}, fmt.Errorf("allocate page: %w", err)
+	}
+
+	location, _, err := t.insertRecord(n, record)
+
+	return location, err
+}
+
+// insertRecord inserts the record into the page, false is returned if there is not enough space in it.
+func (t *Table) insertRecord(n int, record []byte) (rid, bool, error) {
+	frame, p, err := t.fetchPage(n)
+	if err != nil {
+		return rid{}, false, err
+	}
+
+	slot, ok := p.insert(record)
+
+	t.free[n] = p.freeSpace()
+	t.pool.Unpin(frame, ok)
+
+	return rid{page: n, slot: slot}, ok, nil
+}
+
+// readPageRows returns all rows stored in the page, false is returned if there is no such page.
+func (t *Table) readPageRows(n int) ([]sql.Row, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if n >= t.file.pages {
+		return nil, false, nil
+	}
+
+	_, rows, err := t.pageRows(n)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return rows, true, nil
+}
+
+// encodeRecord encodes the length-prefixed primary key followed by the row.
+func encodeRecord(key sql.Key, row sql.Row) ([]byte, error) {
+	record := binary.AppendUvarint(nil, uint64(len(key)))
+	record = append(record, key...)
+
+	record, err := codec.AppendRow(record, row)
+	if err != nil {
+		return nil, fmt.Errorf("encode row: %w", err)
+	}
+
+	if len(record) > maxRecordSize {
+		return nil, fmt.Errorf("row size %d exceeds maximum %d", len(record), maxRecordSize)
+	}
+
+	return record, nil
+}
+
+func decodeRecord(record []byte) (sql.Key, sql.Row, error) {
+	key, n, err := decodeRecordKey(record)
+	if err != nil {
+		return "", nil, err
+	}
+
+	row, _, err := codec.DecodeRow(record[n:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, row, nil
+}
+
+// decodeRecordKey returns the primary key of the record and the number of bytes it takes.
+func decodeRecordKey(record []byte) (sql.Key, int, error) {
+	length, n := binary.Uvarint(record)
+	if n <= 0 || uint64(len(record[n:])) < length {
+		return "", 0, errors.New("record is too short")
+	}
+
+	return sql.Key(record[n : n+int(length)]), n + int(length), nil
+}
+
+// iter reads the table page by page.
+type iter struct {
+	table *Table
+	page  int
+	rows  []sql.Row
+}
+
+func (i *iter) Next() (sql.Row, error) {
+	for len(i.rows) == 0 {
+		if i.table == nil {
+			return nil, io.EOF
+		}
+
+		i.page++
+
+		rows, ok, err := i.table.readPageRows(i.page)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			i.table = nil
+
+			return nil, io.EOF
+		}
+
+		i.rows = rows
+	}
+
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+
+	return row, nil
+}
+
+func (i *iter) Close() error {
+	i.table = nil
+	i.rows = nil
+
+	return nil
+}
+
+// rangeIter reads rows with keys in the range [from, to) in the key order, a batch of rows at a time,
+// so a scan that is stopped early reads only the pages of its first batches. Each batch is read under the lock
+// of the table, and the iterator resumes after the last key it has read: rows changed ahead of it are observed.
+type rangeIter struct {
+	read func(low, high sql.Key, limit int) ([]sql.Row, sql.Key, error)
+	from sql.Key
+	to   sql.Key // the empty key is unbounded
+	rows []sql.Row
+}
+
+func (i *rangeIter) Next() (sql.Row, error) {
+	for len(i.rows) == 0 {
+		if i.read == nil {
+			return nil, io.EOF
+		}
+
+		rows, last, err := i.read(i.from, i.to, scanBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		// The batch is the last one if it's not full.
+		if len(rows) < scanBatch {
+			i.read = nil
+		}
+
+		// The least key greater than the last one.
+		i.rows = rows
+		i.from = last + "\x00"
+	}
+
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+
+	return row, nil
+}
+
+func (i *rangeIter) Close() error {
+	i.read = nil
+	i.rows = nil
+
+	return nil
+}
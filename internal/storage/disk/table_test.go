@@ -0,0 +1,633 @@
+package disk_test
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/buffer"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/disk"
+)
+
+func TestTable_Name(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+	assert.Equal(t, "users", table.Name())
+}
+
+func TestTable_Scheme(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+	assert.Equal(t, usersScheme(), table.Scheme())
+}
+
+func TestTable_PrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+	assert.Equal(t, []sql.Column{usersScheme()["id"]}, table.PrimaryKey())
+}
+
+func TestTable_Sequence(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+	assert.NotNil(t, table.Sequence())
+	assert.Equal(t, int64(1), table.Sequence().Next())
+
+	table.Sequence().Release(1)
+	assert.Equal(t, int64(1), table.Sequence().Next())
+}
+
+func TestTable_Insert(t *testing.T) {
+	t.Parallel()
+
+	t.Run("insert without errors", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		expected := userRow(1, "Max")
+
+		err := table.Insert(sql.IntegerKey(1), expected)
+		require.NoError(t, err)
+		require.Equal(t, []sql.Row{expected}, scanRows(t, table))
+	})
+
+	t.Run("returns error on duplicate key", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+
+		err := table.Insert(sql.IntegerKey(1), userRow(1, "Max"))
+		require.NoError(t, err)
+
+		err = table.Insert(sql.IntegerKey(1), userRow(1, "Vlad"))
+		require.Error(t, err)
+	})
+
+	t.Run("returns error if row doesn't fit into a page", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+
+		err := table.Insert(sql.IntegerKey(1), userRow(1, strings.Repeat("x", disk.PageSize)))
+		require.Error(t, err)
+	})
+
+	t.Run("rows span multiple pages", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		expected := make([]sql.Row, 0)
+
+		for key := int64(1); key <= 100; key++ {
+			row := userRow(key, strings.Repeat("x", 500))
+			expected = append(expected, row)
+
+			err := table.Insert(sql.IntegerKey(key), row)
+			require.NoError(t, err)
+		}
+
+		require.ElementsMatch(t, expected, scanRows(t, table))
+	})
+}
+
+func TestTable_Delete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deletes rows", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+
+		for key := int64(1); key <= 3; key++ {
+			err := table.Insert(sql.IntegerKey(key), userRow(key, "Max"))
+			require.NoError(t, err)
+		}
+
+		err := table.Delete(sql.IntegerKey(2))
+		require.NoError(t, err)
+		require.Equal(t, []sql.Row{userRow(1, "Max"), userRow(3, "Max")}, scanRows(t, table))
+
+		err = table.Delete(sql.IntegerKey(1))
+		require.NoError(t, err)
+
+		err = table.Delete(sql.IntegerKey(3))
+		require.NoError(t, err)
+		require.Empty(t, scanRows(t, table))
+	})
+
+	t.Run("returns error if key not found", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		err := table.Delete(sql.IntegerKey(1))
+		require.Error(t, err)
+	})
+}
+
+func TestTable_Update(t *testing.T) {
+	t.Parallel()
+
+	t.Run("update without errors", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+
+		err := table.Insert(sql.IntegerKey(1), userRow(1, "Max"))
+		require.NoError(t, err)
+
+		err = table.Update(sql.IntegerKey(1), userRow(1, "Vlad"))
+		require.NoError(t, err)
+		require.Equal(t, []sql.Row{userRow(1, "Vlad")}, scanRows(t, table))
+	})
+
+	t.Run("moves grown row to another page", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		expected := make([]sql.Row, 0)
+
+		for key := int64(1); key <= 8; key++ {
+			row := userRow(key, strings.Repeat("x", 500))
+			expected = append(expected, row)
+
+			err := table.Insert(sql.IntegerKey(key), row)
+			require.NoError(t, err)
+		}
+
+		expected[0] = userRow(1, strings.Repeat("y", 3000))
+
+		err := table.Update(sql.IntegerKey(1), expected[0])
+		require.NoError(t, err)
+		require.ElementsMatch(t, expected, scanRows(t, table))
+	})
+
+	t.Run("returns error if key not found", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		err := table.Update(sql.IntegerKey(1), userRow(1, "Max"))
+		require.Error(t, err)
+	})
+}
+
+func TestTable_Persistence(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	catalog, err := disk.Open(dir)
+	require.NoError(t, err)
+
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	for key := int64(1); key <= 50; key++ {
+		err = table.Insert(sql.IntegerKey(key), userRow(key, strings.Repeat("x", int(key)*10)))
+		require.NoError(t, err)
+	}
+
+	for key := int64(1); key <= 50; key += 2 {
+		err = table.Delete(sql.IntegerKey(key))
+		require.NoError(t, err)
+	}
+
+	err = table.Update(sql.IntegerKey(50), userRow(50, "Max"))
+	require.NoError(t, err)
+
+	// Values allocated by the sequence must not be reused after restart.
+	table.Sequence().Next()
+	table.Sequence().Next()
+
+	expected := scanRows(t, table)
+	require.Len(t, expected, 25)
+	require.NoError(t, catalog.Close())
+
+	catalog = openCatalog(t, dir)
+
+	database, err = catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	table, err = database.GetTable("users")
+	require.NoError(t, err)
+	require.Equal(t, usersScheme(), table.Scheme())
+	require.Equal(t, expected, scanRows(t, table))
+	require.Equal(t, int64(53), table.Sequence().Next())
+
+	err = table.Insert(sql.IntegerKey(1), userRow(1, "Vlad"))
+	require.NoError(t, err)
+
+	err = table.Insert(sql.IntegerKey(2), userRow(2, "Vlad"))
+	require.Error(t, err)
+}
+
+func TestTable_BufferPool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pool := buffer.New(2, disk.PageSize, buffer.NewLRU())
+
+	catalog, err := disk.Open(dir, disk.WithBufferPool(pool))
+	require.NoError(t, err)
+
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	// Rows take more pages than the pool holds, so pages are evicted and read again.
+	for key := int64(1); key <= 40; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, strings.Repeat("x", 500))))
+	}
+
+	for key := int64(1); key <= 40; key += 3 {
+		require.NoError(t, table.Update(sql.IntegerKey(key), userRow(key, "Max")))
+	}
+
+	expected := scanRows(t, table)
+	require.Len(t, expected, 40)
+
+	row, ok, err := table.Get(sql.IntegerKey(1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, userRow(1, "Max"), row)
+
+	stats := catalog.BufferStats()
+	assert.Positive(t, stats.Hits)
+	assert.Positive(t, stats.Misses)
+	assert.Positive(t, stats.Evictions)
+	assert.Positive(t, stats.Writes)
+
+	require.NoError(t, catalog.Close())
+
+	catalog = openCatalog(t, dir)
+
+	database, err = catalog.GetDatabase("playground")
+	require.NoError(t, err)
+
+	table, err = database.GetTable("users")
+	require.NoError(t, err)
+	require.Equal(t, expected, scanRows(t, table))
+}
+
+func createTable(t *testing.T) sql.Table {
+	t.Helper()
+
+	catalog := openCatalog(t, t.TempDir())
+
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	return table
+}
+
+func userRow(id int64, name string) sql.Row {
+	return sql.Row{
+		datatype.NewInteger(id),
+		datatype.NewText(name),
+	}
+}
+
+func scanRows(t *testing.T, table sql.Table) []sql.Row {
+	t.Helper()
+
+	iter, err := table.Scan()
+	require.NoError(t, err)
+
+	rows := make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	return rows
+}
+
+func TestTable_Indexes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps index up to date", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+
+		index, err := table.CreateIndex("users_name", []string{"name"}, false)
+		require.NoError(t, err)
+
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Vlad")))
+		require.NoError(t, table.Insert(sql.IntegerKey(3), userRow(3, "Max")))
+		assert.Equal(t, []sql.Row{userRow(1, "Max"), userRow(3, "Max")}, scanIndex(t, index, "Max"))
+
+		require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, strings.Repeat("V", 3000))))
+		require.NoError(t, table.Update(sql.IntegerKey(1), userRow(1, "Vlad")))
+		assert.Equal(t, []sql.Row{userRow(3, "Max")}, scanIndex(t, index, "Max"))
+		assert.Equal(t, []sql.Row{userRow(1, "Vlad"), userRow(2, "Vlad")}, scanIndex(t, index, "Vlad"))
+
+		require.NoError(t, table.Delete(sql.IntegerKey(2)))
+		assert.Equal(t, []sql.Row{userRow(1, "Vlad")}, scanIndex(t, index, "Vlad"))
+
+		require.NoError(t, table.DropIndex("users_name"))
+		assert.Empty(t, table.Indexes())
+		require.Error(t, table.DropIndex("users_name"))
+	})
+
+	t.Run("unique index rejects duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		table := createTable(t)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Max")))
+
+		_, err := table.CreateIndex("users_name", []string{"name"}, true)
+		require.Error(t, err)
+
+		require.NoError(t, table.Delete(sql.IntegerKey(2)))
+
+		_, err = table.CreateIndex("users_name", []string{"name"}, true)
+		require.NoError(t, err)
+
+		require.Error(t, table.Insert(sql.IntegerKey(2), userRow(2, "Max")))
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Vlad")))
+		require.Error(t, table.Update(sql.IntegerKey(2), userRow(2, "Max")))
+	})
+
+	t.Run("survives restart", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		catalog, err := disk.Open(dir)
+		require.NoError(t, err)
+
+		database, err := catalog.CreateDatabase("playground")
+		require.NoError(t, err)
+
+		table, err := database.CreateTable("users", usersScheme())
+		require.NoError(t, err)
+
+		_, err = table.CreateIndex("users_name", []string{"name"}, true)
+		require.NoError(t, err)
+
+		_, err = table.CreateIndex("users_id_name", []string{"id", "name"}, false)
+		require.NoError(t, err)
+
+		require.NoError(t, table.DropIndex("users_id_name"))
+		require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+		require.NoError(t, table.Insert(sql.IntegerKey(2), userRow(2, "Vlad")))
+		require.NoError(t, catalog.Close())
+
+		catalog = openCatalog(t, dir)
+
+		database, err = catalog.GetDatabase("playground")
+		require.NoError(t, err)
+
+		table, err = database.GetTable("users")
+		require.NoError(t, err)
+
+		indexes := table.Indexes()
+		require.Len(t, indexes, 1)
+		assert.Equal(t, "users_name", indexes[0].Name())
+		assert.Equal(t, []string{"name"}, indexes[0].Columns())
+		assert.True(t, indexes[0].Unique())
+		assert.Equal(t, []sql.Row{userRow(2, "Vlad")}, scanIndex(t, indexes[0], "Vlad"))
+		require.Error(t, table.Insert(sql.IntegerKey(3), userRow(3, "Max")))
+	})
+}
+
+func scanIndex(t *testing.T, index sql.Index, name string) []sql.Row {
+	t.Helper()
+
+	iter, err := index.Scan(sql.IndexRange{Prefix: []sql.Value{datatype.NewText(name)}})
+	require.NoError(t, err)
+
+	rows := make([]sql.Row, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	require.NoError(t, iter.Close())
+
+	return rows
+}
+
+func TestTable_Get(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+	require.NoError(t, table.Insert(sql.IntegerKey(1), userRow(1, "Max")))
+
+	row, ok, err := table.Get(sql.IntegerKey(1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, userRow(1, "Max"), row)
+
+	row, ok, err = table.Get(sql.IntegerKey(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+	assert.Nil(t, row)
+}
+
+func TestTable_ScanRange(t *testing.T) {
+	t.Parallel()
+
+	table := createTable(t)
+
+	for key := int64(100); key > 0; key-- {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, strings.Repeat("x", 100))))
+	}
+
+	iter, err := table.ScanRange(
+		&sql.Bound{Value: datatype.NewInteger(40), Inclusive: true},
+		&sql.Bound{Value: datatype.NewInteger(45), Inclusive: false},
+	)
+	require.NoError(t, err)
+
+	keys := make([]int64, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		keys = append(keys, row[0].Raw().(int64))
+	}
+
+	assert.Equal(t, []int64{40, 41, 42, 43, 44}, keys)
+}
+
+func TestTable_ScanRangeBatches(t *testing.T) {
+	t.Parallel()
+
+	catalog := openCatalog(t, t.TempDir())
+
+	database, err := catalog.CreateDatabase("playground")
+	require.NoError(t, err)
+
+	table, err := database.CreateTable("users", usersScheme())
+	require.NoError(t, err)
+
+	// About seven rows fit a page, so the table takes more than a hundred pages.
+	for key := int64(1); key <= 1000; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), userRow(key, strings.Repeat("x", 500))))
+	}
+
+	fetches := func() uint64 {
+		stats := catalog.BufferStats()
+
+		return stats.Hits + stats.Misses
+	}
+
+	iter, err := table.ScanRange(&sql.Bound{Value: datatype.NewInteger(1), Inclusive: true}, nil)
+	require.NoError(t, err)
+
+	// The first row is read with its batch only.
+	before := fetches()
+
+	row, err := iter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), row[0].Raw())
+	assert.Less(t, fetches()-before, uint64(50))
+
+	// Rows changed ahead of the iterator are observed, the rest of rows is read in order.
+	require.NoError(t, table.Delete(sql.IntegerKey(999)))
+
+	keys := []int64{1}
+
+	for {
+		row, err = iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		keys = append(keys, row[0].Raw().(int64))
+	}
+
+	require.NoError(t, iter.Close())
+	assert.Len(t, keys, 999)
+	assert.True(t, slices.IsSorted(keys))
+	assert.NotContains(t, keys, int64(999))
+}
+
+func TestTable_CompositeKey(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"tenant": sql.Column{
+			Position:    0,
+			Name:        "tenant",
+			DataType:    sql.Text,
+			PrimaryKey:  true,
+			KeyPosition: 0,
+		},
+		"id": sql.Column{
+			Position:    1,
+			Name:        "id",
+			DataType:    sql.Integer,
+			PrimaryKey:  true,
+			KeyPosition: 1,
+		},
+	}
+
+	row := func(tenant string, id int64) sql.Row {
+		return sql.Row{datatype.NewText(tenant), datatype.NewInteger(id)}
+	}
+
+	rowKey := func(row sql.Row) sql.Key {
+		key, err := sql.RowKey(row, []uint8{0, 1})
+		require.NoError(t, err)
+
+		return key
+	}
+
+	for _, engine := range []string{disk.EngineHeap, disk.EngineLSM, disk.EngineColumnar} {
+		t.Run(engine, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			catalog, err := disk.Open(dir)
+			require.NoError(t, err)
+
+			database, err := catalog.CreateDatabaseUsing("playground", engine)
+			require.NoError(t, err)
+
+			table, err := database.CreateTable("accounts", scheme)
+			require.NoError(t, err)
+
+			for _, r := range []sql.Row{row("b", 1), row("a", 10), row("a\x00", 1), row("a", 2), row("b", -1)} {
+				require.NoError(t, table.Insert(rowKey(r), r))
+			}
+
+			require.Error(t, table.Insert(rowKey(row("a", 2)), row("a", 2)))
+			require.NoError(t, catalog.Close())
+
+			catalog = openCatalog(t, dir)
+
+			database, err = catalog.GetDatabase("playground")
+			require.NoError(t, err)
+
+			table, err = database.GetTable("accounts")
+			require.NoError(t, err)
+			require.Equal(t, scheme, table.Scheme())
+
+			// Heap tables are scanned in the page order, ranges are read in the key order.
+			expected := []sql.Row{row("a", 2), row("a", 10), row("a\x00", 1), row("b", -1), row("b", 1)}
+			assert.ElementsMatch(t, expected, scanRows(t, table))
+
+			iter, err := table.ScanRange(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, expected, readIter(t, iter))
+
+			found, ok, err := table.Get(rowKey(row("a\x00", 1)))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, row("a\x00", 1), found)
+
+			bound := &sql.Bound{Value: datatype.NewText("a"), Inclusive: true}
+
+			iter, err = table.ScanRange(bound, bound)
+			require.NoError(t, err)
+			assert.Equal(t, []sql.Row{row("a", 2), row("a", 10)}, readIter(t, iter))
+		})
+	}
+}
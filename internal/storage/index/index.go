@@ -0,0 +1,247 @@
+// Package index implements secondary indexes: ordered sets of (column values, primary key) entries.
+package index
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/expr/comparison"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+)
+
+// entry is a key of the index tree. The primary key makes entries with equal values distinct.
+type entry struct {
+	values sql.Row
+	key    sql.Key
+}
+
+// Index keeps entries in a B+tree ordered by the indexed columns, it's not safe for concurrent use.
+type Index struct {
+	name      string
+	columns   []string
+	positions []uint8
+	unique    bool
+	entries   *btree.Tree[entry, struct{}]
+}
+
+// New returns an empty index over the columns of the scheme.
+func New(name string, scheme sql.Scheme, columns []string, unique bool) (*Index, error) {
+	if name == "" {
+		return nil, errors.New("index name should not be empty")
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("index should have at least one column")
+	}
+
+	positions := make([]uint8, 0, len(columns))
+	seen := make(map[string]struct{}, len(columns))
+
+	for _, name := range columns {
+		column, ok := scheme[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+
+		if _, ok = seen[name]; ok {
+			return nil, fmt.Errorf("column %q is listed more than once", name)
+		}
+
+		seen[name] = struct{}{}
+		positions = append(positions, column.Position)
+	}
+
+	return &Index{
+		name:      name,
+		columns:   append([]string(nil), columns...),
+		positions: positions,
+		unique:    unique,
+		entries:   btree.New[entry, struct{}](compareEntries),
+	}, nil
+}
+
+func (i *Index) Name() string {
+	return i.name
+}
+
+func (i *Index) Columns() []string {
+	return append([]string(nil), i.columns...)
+}
+
+func (i *Index) Unique() bool {
+	return i.unique
+}
+
+// Len returns the number of entries in the index.
+func (i *Index) Len() int {
+	return i.entries.Len()
+}
+
+// Check returns an error if storing the row with the key violates the uniqueness of the index.
+// The entry of the same key is ignored, so the check works for updates as well.
+// Rows with NULL in any of the indexed columns never conflict.
+func (i *Index) Check(key sql.Key, row sql.Row) error {
+	if !i.unique {
+		return nil
+	}
+
+	values := i.values(row)
+
+	for _, value := range values {
+		if value.DataType() == sql.Null {
+			return nil
+		}
+	}
+
+	var err error
+
+	i.entries.AscendGreaterOrEqual(entry{values: values, key: ""}, func(e entry, _ struct{}) bool {
+		if compareValues(e.values, values) != 0 {
+			return false
+		}
+
+		if e.key != key {
+			err = fmt.Errorf("duplicate key value violates unique index %q", i.name)
+
+			return false
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// Insert adds the entry of the row.
+func (i *Index) Insert(key sql.Key, row sql.Row) {
+	i.entries.Put(entry{values: i.values(row), key: key}, struct{}{})
+}
+
+// Delete removes the entry of the row.
+func (i *Index) Delete(key sql.Key, row sql.Row) {
+	i.entries.Delete(entry{values: i.values(row), key: key})
+}
+
+// Keys returns primary keys of rows that fall into the range, in the index order.
+// Rows with NULL in the bounded column never match.
+func (i *Index) Keys(r sql.IndexRange) ([]sql.Key, error) {
+	column := len(r.Prefix)
+
+	if column > len(i.positions) || (column == len(i.positions) && (r.From != nil || r.To != nil)) {
+		return nil, fmt.Errorf("range doesn't match columns of index %q", i.name)
+	}
+
+	from := entry{values: r.Prefix, key: ""}
+
+	if r.From != nil {
+		from.values = append(append(sql.Row(nil), r.Prefix...), r.From.Value)
+	}
+
+	keys := make([]sql.Key, 0)
+
+	i.entries.AscendGreaterOrEqual(from, func(e entry, _ struct{}) bool {
+		if compareValues(e.values[:len(r.Prefix)], r.Prefix) != 0 {
+			return false
+		}
+
+		if r.To != nil && e.values[column].DataType() != sql.Null {
+			if c := compareValue(e.values[column], r.To.Value); c > 0 || (c == 0 && !r.To.Inclusive) {
+				return false
+			}
+		}
+
+		if matchValues(e.values, r) {
+			keys = append(keys, e.key)
+		}
+
+		return true
+	})
+
+	return keys, nil
+}
+
+// Compare orders rows with the primary keys the way their entries are ordered in the index.
+func (i *Index) Compare(a sql.Row, aKey sql.Key, b sql.Row, bKey sql.Key) int {
+	return compareEntries(entry{values: i.values(a), key: aKey}, entry{values: i.values(b), key: bKey})
+}
+
+// Match reports whether the row falls into the range. The range is expected to match columns of the index.
+func (i *Index) Match(r sql.IndexRange, row sql.Row) bool {
+	return matchValues(i.values(row), r)
+}
+
+func (i *Index) values(row sql.Row) sql.Row {
+	values := make(sql.Row, len(i.positions))
+
+	for n, position := range i.positions {
+		values[n] = row[position]
+	}
+
+	return values
+}
+
+// matchValues reports whether the indexed values fall into the range.
+func matchValues(values sql.Row, r sql.IndexRange) bool {
+	if compareValues(values[:len(r.Prefix)], r.Prefix) != 0 {
+		return false
+	}
+
+	if r.From == nil && r.To == nil {
+		return true
+	}
+
+	value := values[len(r.Prefix)]
+
+	if value.DataType() == sql.Null {
+		return false
+	}
+
+	if r.From != nil {
+		if c := compareValue(value, r.From.Value); c < 0 || (c == 0 && !r.From.Inclusive) {
+			return false
+		}
+	}
+
+	if r.To != nil {
+		if c := compareValue(value, r.To.Value); c > 0 || (c == 0 && !r.To.Inclusive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareEntries orders entries by values, then by the primary key.
+// A prefix of values sorts before all entries that start with it.
+func compareEntries(a, b entry) int {
+	if c := compareValues(a.values, b.values); c != 0 {
+		return c
+	}
+
+	if len(a.values) != len(b.values) {
+		return cmp.Compare(len(a.values), len(b.values))
+	}
+
+	return cmp.Compare(a.key, b.key)
+}
+
+// compareValues compares the common prefix of the rows.
+func compareValues(a, b sql.Row) int {
+	for n := 0; n < len(a) && n < len(b); n++ {
+		if c := compareValue(a[n], b[n]); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// compareValue orders NULL before any other value. Values of a column always have the same type,
+// the planner converts range bounds to the column type.
+func compareValue(a, b sql.Value) int {
+	c, _ := comparison.Compare(a, b)
+
+	return int(c)
+}
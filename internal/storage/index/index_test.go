@@ -0,0 +1,239 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/index"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	scheme := usersScheme()
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+
+		idx, err := index.New("users_name", scheme, []string{"name", "age"}, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, "users_name", idx.Name())
+		assert.Equal(t, []string{"name", "age"}, idx.Columns())
+		assert.True(t, idx.Unique())
+		assert.Zero(t, idx.Len())
+	})
+
+	t.Run("returns error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := index.New("", scheme, []string{"name"}, false)
+		require.Error(t, err)
+
+		_, err = index.New("users_name", scheme, nil, false)
+		require.Error(t, err)
+
+		_, err = index.New("users_name", scheme, []string{"email"}, false)
+		require.Error(t, err)
+
+		_, err = index.New("users_name", scheme, []string{"name", "name"}, false)
+		require.Error(t, err)
+	})
+}
+
+func TestIndex_Check(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.New("users_name", usersScheme(), []string{"name"}, true)
+	require.NoError(t, err)
+
+	idx.Insert(sql.IntegerKey(1), userRow(1, "bob", 30))
+	idx.Insert(sql.IntegerKey(2), userRow(2, "alice", 25))
+	idx.Insert(sql.IntegerKey(3), sql.Row{datatype.NewInteger(3), datatype.NewNull(), datatype.NewInteger(40)})
+
+	require.NoError(t, idx.Check(sql.IntegerKey(4), userRow(4, "tom", 30)))
+	require.Error(t, idx.Check(sql.IntegerKey(4), userRow(4, "bob", 30)))
+	require.NoError(t, idx.Check(sql.IntegerKey(1), userRow(1, "bob", 31)), "row doesn't conflict with itself")
+	require.NoError(t, idx.Check(sql.IntegerKey(4), sql.Row{datatype.NewInteger(4), datatype.NewNull(), datatype.NewInteger(40)}))
+
+	idx.Delete(sql.IntegerKey(1), userRow(1, "bob", 30))
+	require.NoError(t, idx.Check(sql.IntegerKey(4), userRow(4, "bob", 30)))
+
+	nonUnique, err := index.New("users_age", usersScheme(), []string{"age"}, false)
+	require.NoError(t, err)
+
+	nonUnique.Insert(sql.IntegerKey(1), userRow(1, "bob", 30))
+	require.NoError(t, nonUnique.Check(sql.IntegerKey(2), userRow(2, "alice", 30)))
+}
+
+func TestIndex_Keys(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.New("users_name_age", usersScheme(), []string{"name", "age"}, false)
+	require.NoError(t, err)
+
+	idx.Insert(sql.IntegerKey(1), userRow(1, "bob", 30))
+	idx.Insert(sql.IntegerKey(2), userRow(2, "alice", 25))
+	idx.Insert(sql.IntegerKey(3), userRow(3, "bob", 20))
+	idx.Insert(sql.IntegerKey(4), userRow(4, "carol", 30))
+	idx.Insert(sql.IntegerKey(5), userRow(5, "bob", 40))
+	idx.Insert(sql.IntegerKey(6), sql.Row{datatype.NewInteger(6), datatype.NewText("bob"), datatype.NewNull()})
+	idx.Insert(sql.IntegerKey(7), sql.Row{datatype.NewInteger(7), datatype.NewNull(), datatype.NewInteger(30)})
+
+	tests := []struct {
+		name     string
+		rng      sql.IndexRange
+		expected []int64
+	}{
+		{
+			name:     "all entries",
+			rng:      sql.IndexRange{},
+			expected: []int64{7, 2, 6, 3, 1, 5, 4},
+		},
+		{
+			name: "equal prefix",
+			rng: sql.IndexRange{
+				Prefix: []sql.Value{datatype.NewText("bob")},
+			},
+			expected: []int64{6, 3, 1, 5},
+		},
+		{
+			name: "equal columns",
+			rng: sql.IndexRange{
+				Prefix: []sql.Value{datatype.NewText("bob"), datatype.NewInteger(30)},
+			},
+			expected: []int64{1},
+		},
+		{
+			name: "prefix and range",
+			rng: sql.IndexRange{
+				Prefix: []sql.Value{datatype.NewText("bob")},
+				From:   &sql.Bound{Value: datatype.NewInteger(20), Inclusive: false},
+				To:     &sql.Bound{Value: datatype.NewInteger(40), Inclusive: true},
+			},
+			expected: []int64{1, 5},
+		},
+		{
+			name: "prefix and upper bound skips nulls",
+			rng: sql.IndexRange{
+				Prefix: []sql.Value{datatype.NewText("bob")},
+				To:     &sql.Bound{Value: datatype.NewInteger(30), Inclusive: false},
+			},
+			expected: []int64{3},
+		},
+		{
+			name: "range on the leading column",
+			rng: sql.IndexRange{
+				From: &sql.Bound{Value: datatype.NewText("alice"), Inclusive: false},
+				To:   &sql.Bound{Value: datatype.NewText("carol"), Inclusive: false},
+			},
+			expected: []int64{6, 3, 1, 5},
+		},
+		{
+			name: "empty range",
+			rng: sql.IndexRange{
+				Prefix: []sql.Value{datatype.NewText("dave")},
+			},
+			expected: []int64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := idx.Keys(test.rng)
+			require.NoError(t, err)
+			assert.Equal(t, integerKeys(test.expected...), keys)
+		})
+	}
+
+	t.Run("returns error if range has too many columns", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := idx.Keys(sql.IndexRange{
+			Prefix: []sql.Value{datatype.NewText("bob"), datatype.NewInteger(30)},
+			From:   &sql.Bound{Value: datatype.NewInteger(1)},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestIndex_Match(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.New("users_name_age", usersScheme(), []string{"name", "age"}, false)
+	require.NoError(t, err)
+
+	rng := sql.IndexRange{
+		Prefix: []sql.Value{datatype.NewText("bob")},
+		From:   &sql.Bound{Value: datatype.NewInteger(20), Inclusive: false},
+		To:     &sql.Bound{Value: datatype.NewInteger(40), Inclusive: true},
+	}
+
+	assert.True(t, idx.Match(rng, userRow(1, "bob", 30)))
+	assert.True(t, idx.Match(rng, userRow(1, "bob", 40)))
+	assert.False(t, idx.Match(rng, userRow(1, "bob", 20)))
+	assert.False(t, idx.Match(rng, userRow(1, "alice", 30)))
+	assert.False(t, idx.Match(rng, sql.Row{datatype.NewInteger(1), datatype.NewText("bob"), datatype.NewNull()}))
+	assert.True(t, idx.Match(sql.IndexRange{}, userRow(1, "alice", 30)))
+}
+
+func TestIndex_Compare(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.New("users_name", usersScheme(), []string{"name"}, false)
+	require.NoError(t, err)
+
+	nullName := sql.Row{datatype.NewInteger(3), datatype.NewNull(), datatype.NewInteger(30)}
+
+	assert.Negative(t, idx.Compare(userRow(2, "alice", 30), sql.IntegerKey(2), userRow(1, "bob", 20), sql.IntegerKey(1)))
+	assert.Positive(t, idx.Compare(userRow(2, "bob", 30), sql.IntegerKey(2), userRow(1, "bob", 20), sql.IntegerKey(1)))
+	assert.Zero(t, idx.Compare(userRow(1, "bob", 30), sql.IntegerKey(1), userRow(1, "bob", 20), sql.IntegerKey(1)))
+	assert.Negative(t, idx.Compare(nullName, sql.IntegerKey(3), userRow(1, "alice", 20), sql.IntegerKey(1)))
+}
+
+func usersScheme() sql.Scheme {
+	return sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+			Nullable: true,
+		},
+		"age": sql.Column{
+			Position: 2,
+			Name:     "age",
+			DataType: sql.Integer,
+			Nullable: true,
+		},
+	}
+}
+
+func userRow(id int64, name string, age int64) sql.Row {
+	return sql.Row{
+		datatype.NewInteger(id),
+		datatype.NewText(name),
+		datatype.NewInteger(age),
+	}
+}
+
+func integerKeys(ids ...int64) []sql.Key {
+	keys := make([]sql.Key, 0, len(ids))
+
+	for _, id := range ids {
+		keys = append(keys, sql.IntegerKey(id))
+	}
+
+	return keys
+}
@@ -0,0 +1,94 @@
+package lsm
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// bitsPerKey gives about 1% of false positives with hashesPerKey hash functions.
+const (
+	bitsPerKey   = 10
+	hashesPerKey = 7
+)
+
+// bloom is a bloom filter of primary keys: a key that is not in the filter is not in the table for sure.
+type bloom struct {
+	bits []uint64
+}
+
+func newBloom(keys int) *bloom {
+	words := max((keys*bitsPerKey+63)/64, 1)
+
+	return &bloom{
+		bits: make([]uint64, words),
+	}
+}
+
+func (b *bloom) add(key string) {
+	h1, h2 := hashKey(key)
+	size := uint32(len(b.bits) * 64)
+
+	for i := uint32(0); i < hashesPerKey; i++ {
+		bit := (h1 + i*h2) % size
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloom) mayContain(key string) bool {
+	h1, h2 := hashKey(key)
+	size := uint32(len(b.bits) * 64)
+
+	for i := uint32(0); i < hashesPerKey; i++ {
+		bit := (h1 + i*h2) % size
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b *bloom) encode() []byte {
+	buf := make([]byte, 0, len(b.bits)*8)
+
+	for _, word := range b.bits {
+		buf = binary.BigEndian.AppendUint64(buf, word)
+	}
+
+	return buf
+}
+
+func decodeBloom(buf []byte) (*bloom, error) {
+	if len(buf) == 0 || len(buf)%8 != 0 {
+		return nil, errors.New("corrupted bloom filter")
+	}
+
+	b := &bloom{
+		bits: make([]uint64, len(buf)/8),
+	}
+
+	for i := range b.bits {
+		b.bits[i] = binary.BigEndian.Uint64(buf[i*8:])
+	}
+
+	return b, nil
+}
+
+// hashKey returns two hashes of the key for double hashing: the FNV-1a hash of the key
+// mixed with the splitmix64 finalizer.
+func hashKey(key string) (uint32, uint32) {
+	h := uint64(14695981039346656037)
+
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	return uint32(h), uint32(h>>32) | 1
+}
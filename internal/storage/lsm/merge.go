@@ -0,0 +1,94 @@
+package lsm
+
+import (
+	"errors"
+	"io"
+)
+
+// source is a sorted sequence of entries, next returns io.EOF after the last one.
+type source interface {
+	next() (entry, error)
+}
+
+// sliceIter iterates over entries copied from the memtable.
+type sliceIter struct {
+	entries []entry
+	pos     int
+}
+
+func (it *sliceIter) next() (entry, error) {
+	if it.pos == len(it.entries) {
+		return entry{}, io.EOF
+	}
+
+	e := it.entries[it.pos]
+	it.pos++
+
+	return e, nil
+}
+
+// mergeIter merges sorted sources ordered from the newest to the oldest. When several sources
+// have an entry of the same key, the entry of the newest source wins and the others are skipped.
+type mergeIter struct {
+	sources []source
+	heads   []entry
+	live    []bool
+}
+
+func newMergeIter(sources []source) (*mergeIter, error) {
+	it := &mergeIter{
+		sources: sources,
+		heads:   make([]entry, len(sources)),
+		live:    make([]bool, len(sources)),
+	}
+
+	for i := range sources {
+		if err := it.advance(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return it, nil
+}
+
+func (it *mergeIter) next() (entry, error) {
+	newest := -1
+
+	for i := range it.sources {
+		if it.live[i] && (newest < 0 || it.heads[i].key < it.heads[newest].key) {
+			newest = i
+		}
+	}
+
+	if newest < 0 {
+		return entry{}, io.EOF
+	}
+
+	e := it.heads[newest]
+
+	for i := range it.sources {
+		if it.live[i] && it.heads[i].key == e.key {
+			if err := it.advance(i); err != nil {
+				return entry{}, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+func (it *mergeIter) advance(i int) error {
+	e, err := it.sources[i].next()
+
+	switch {
+	case errors.Is(err, io.EOF):
+		it.live[i] = false
+	case err != nil:
+		return err
+	default:
+		it.heads[i] = e
+		it.live[i] = true
+	}
+
+	return nil
+}
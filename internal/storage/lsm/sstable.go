@@ -0,0 +1,333 @@
+package lsm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// An SSTable is an immutable file of entries sorted by key:
+//
+//	+---------+----------------+--------------+--------+
+//	| entries | index (sparse) | bloom filter | footer |
+//	+---------+----------------+--------------+--------+
+//
+// Every entry is a length-prefixed key, a kind and a length-prefixed value. The index holds the length-prefixed key
+// and the offset of every indexInterval-th entry, so a lookup reads a single block of entries. The footer holds
+// offsets of the index and of the bloom filter, the number of entries and the magic.
+const (
+	indexInterval = 16
+	footerSize    = 32
+)
+
+var sstMagic = []byte("NANOSST2")
+
+type kind byte
+
+const (
+	kindDelete kind = iota
+	kindPut
+)
+
+// entry is a change of the key: a new value or a tombstone.
+type entry struct {
+	key   string
+	kind  kind
+	value []byte
+}
+
+type indexEntry struct {
+	key    string
+	offset int64
+}
+
+type sstable struct {
+	path    string
+	file    *os.File
+	index   []indexEntry
+	filter  *bloom
+	dataEnd int64
+	count   int
+}
+
+// writeSSTable writes entries produced by next in the key order to the file and flushes it to the disk.
+// next returns false when there are no more entries.
+func writeSSTable(path string, keys int, next func() (entry, bool)) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err = writeEntries(file, keys, next); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return fmt.Errorf("write sstable %s: %w", path, err)
+	}
+
+	return file.Close()
+}
+
+func writeEntries(file *os.File, keys int, next func() (entry, bool)) error {
+	var (
+		w      = bufio.NewWriter(file)
+		filter = newBloom(keys)
+		index  []indexEntry
+		offset int64
+		count  int
+		buf    []byte
+	)
+
+	for e, ok := next(); ok; e, ok = next() {
+		if count%indexInterval == 0 {
+			index = append(index, indexEntry{key: e.key, offset: offset})
+		}
+
+		filter.add(e.key)
+
+		buf = binary.AppendUvarint(buf[:0], uint64(len(e.key)))
+		buf = append(buf, e.key...)
+		buf = append(buf, byte(e.kind))
+		buf = binary.AppendUvarint(buf, uint64(len(e.value)))
+		buf = append(buf, e.value...)
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+
+		offset += int64(len(buf))
+		count++
+	}
+
+	indexOffset := offset
+
+	for _, ie := range index {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(ie.key)))
+		buf = append(buf, ie.key...)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(ie.offset))
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+
+		offset += int64(len(buf))
+	}
+
+	bloomOffset := offset
+
+	if _, err := w.Write(filter.encode()); err != nil {
+		return err
+	}
+
+	buf = binary.BigEndian.AppendUint64(buf[:0], uint64(indexOffset))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(bloomOffset))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(count))
+	buf = append(buf, sstMagic...)
+
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func openSSTable(path string) (*sstable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := readSSTable(path, file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("open sstable %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+func readSSTable(path string, file *os.File) (*sstable, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if size < footerSize {
+		return nil, errors.New("file is too short")
+	}
+
+	footer := make([]byte, footerSize)
+
+	if _, err = file.ReadAt(footer, size-footerSize); err != nil {
+		return nil, fmt.Errorf("read footer: %w", err)
+	}
+
+	if !bytes.Equal(footer[24:], sstMagic) {
+		return nil, errors.New("not an sstable")
+	}
+
+	indexOffset := int64(binary.BigEndian.Uint64(footer))
+	bloomOffset := int64(binary.BigEndian.Uint64(footer[8:]))
+	count := int(binary.BigEndian.Uint64(footer[16:]))
+
+	if indexOffset > bloomOffset || bloomOffset > size-footerSize {
+		return nil, errors.New("corrupted footer")
+	}
+
+	meta := make([]byte, size-footerSize-indexOffset)
+
+	if _, err = file.ReadAt(meta, indexOffset); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	index, err := decodeIndex(meta[:bloomOffset-indexOffset])
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := decodeBloom(meta[bloomOffset-indexOffset:])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &sstable{
+		path:    path,
+		file:    file,
+		index:   index,
+		filter:  filter,
+		dataEnd: indexOffset,
+		count:   count,
+	}
+
+	return t, nil
+}
+
+// get returns the entry of the key, false is returned if the table has no entry of the key.
+func (t *sstable) get(key string) (entry, bool, error) {
+	// The last block that starts at or before the key.
+	block := sort.Search(len(t.index), func(i int) bool {
+		return t.index[i].key > key
+	}) - 1
+
+	if block < 0 {
+		return entry{}, false, nil
+	}
+
+	it := t.iterFrom(block)
+
+	for {
+		e, err := it.next()
+		if errors.Is(err, io.EOF) || (err == nil && e.key > key) {
+			return entry{}, false, nil
+		}
+
+		if err != nil {
+			return entry{}, false, err
+		}
+
+		if e.key == key {
+			return e, true, nil
+		}
+	}
+}
+
+// seek returns an iterator over entries with keys greater than or equal to the key.
+func (t *sstable) seek(key string) *sstIter {
+	block := sort.Search(len(t.index), func(i int) bool {
+		return t.index[i].key > key
+	}) - 1
+
+	return t.iterFrom(max(block, 0))
+}
+
+func (t *sstable) iterFrom(block int) *sstIter {
+	var offset int64
+
+	if block < len(t.index) {
+		offset = t.index[block].offset
+	} else {
+		offset = t.dataEnd
+	}
+
+	return &sstIter{
+		reader: bufio.NewReader(io.NewSectionReader(t.file, offset, t.dataEnd-offset)),
+	}
+}
+
+func decodeIndex(buf []byte) ([]indexEntry, error) {
+	index := make([]indexEntry, 0)
+
+	for offset := 0; offset < len(buf); {
+		length, n := binary.Uvarint(buf[offset:])
+		if n <= 0 || uint64(len(buf[offset+n:])) < length+8 {
+			return nil, errors.New("corrupted index")
+		}
+
+		offset += n
+
+		index = append(index, indexEntry{
+			key:    string(buf[offset : offset+int(length)]),
+			offset: int64(binary.BigEndian.Uint64(buf[offset+int(length):])),
+		})
+
+		offset += int(length) + 8
+	}
+
+	return index, nil
+}
+
+func (t *sstable) close() error {
+	return t.file.Close()
+}
+
+// sstIter reads entries of an SSTable one by one.
+type sstIter struct {
+	reader *bufio.Reader
+}
+
+func (it *sstIter) next() (entry, error) {
+	keyLength, err := binary.ReadUvarint(it.reader)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return entry{}, errors.New("truncated entry")
+		}
+
+		return entry{}, err
+	}
+
+	head := make([]byte, keyLength+1)
+
+	if _, err = io.ReadFull(it.reader, head); err != nil {
+		return entry{}, fmt.Errorf("read key: %w", err)
+	}
+
+	length, err := binary.ReadUvarint(it.reader)
+	if err != nil {
+		return entry{}, fmt.Errorf("read value length: %w", err)
+	}
+
+	value := make([]byte, length)
+
+	if _, err = io.ReadFull(it.reader, value); err != nil {
+		return entry{}, fmt.Errorf("read value: %w", err)
+	}
+
+	e := entry{
+		key:   string(head[:keyLength]),
+		kind:  kind(head[keyLength]),
+		value: value,
+	}
+
+	return e, nil
+}
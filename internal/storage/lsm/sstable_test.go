@@ -0,0 +1,142 @@
+package lsm
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// intKey encodes the integer so that keys compare as the integers.
+func intKey(i int64) string {
+	return string(binary.BigEndian.AppendUint64(nil, uint64(i)^1<<63))
+}
+
+func TestSSTable(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "table.sst")
+	entries := make([]entry, 0, 100)
+
+	for i := int64(0); i < 100; i++ {
+		e := entry{key: intKey(i * 3), kind: kindPut, value: []byte{byte(i)}}
+		if i%10 == 0 {
+			e = entry{key: intKey(i * 3), kind: kindDelete}
+		}
+
+		entries = append(entries, e)
+	}
+
+	it := &sliceIter{entries: entries}
+
+	next := func() (entry, bool) {
+		e, err := it.next()
+
+		return e, err == nil
+	}
+
+	require.NoError(t, writeSSTable(path, len(entries), next))
+
+	table, err := openSSTable(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = table.close()
+	})
+
+	assert.Equal(t, 100, table.count)
+
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+
+		for _, expected := range entries {
+			e, ok, err := table.get(expected.key)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, expected.kind, e.kind)
+			assert.Equal(t, len(expected.value), len(e.value))
+			assert.True(t, table.filter.mayContain(expected.key))
+		}
+
+		for _, key := range []int64{-1, 1, 100, 1000} {
+			_, ok, err := table.get(intKey(key))
+			require.NoError(t, err)
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		t.Parallel()
+
+		it := table.seek(intKey(100))
+
+		var keys []string
+
+		for {
+			e, err := it.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			if e.key >= intKey(100) {
+				keys = append(keys, e.key)
+			}
+		}
+
+		assert.Len(t, keys, 66)
+		assert.Equal(t, intKey(102), keys[0])
+	})
+}
+
+func TestSSTable_Corrupted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	short := filepath.Join(dir, "short.sst")
+	require.NoError(t, os.WriteFile(short, []byte("short"), 0o644))
+
+	_, err := openSSTable(short)
+	require.Error(t, err)
+
+	garbage := filepath.Join(dir, "garbage.sst")
+	require.NoError(t, os.WriteFile(garbage, make([]byte, 64), 0o644))
+
+	_, err = openSSTable(garbage)
+	require.Error(t, err)
+}
+
+func TestBloom(t *testing.T) {
+	t.Parallel()
+
+	filter := newBloom(1000)
+
+	for i := int64(0); i < 1000; i++ {
+		filter.add(intKey(i))
+	}
+
+	decoded, err := decodeBloom(filter.encode())
+	require.NoError(t, err)
+
+	positives := 0
+
+	for i := int64(0); i < 1000; i++ {
+		assert.True(t, decoded.mayContain(intKey(i)))
+
+		if decoded.mayContain(intKey(i + 1_000_000)) {
+			positives++
+		}
+	}
+
+	assert.Less(t, positives, 50)
+
+	_, err = decodeBloom([]byte{1, 2, 3})
+	require.Error(t, err)
+}
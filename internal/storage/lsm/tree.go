@@ -0,0 +1,571 @@
+// Package lsm implements a log-structured merge tree of values with byte string keys.
+//
+// Changes go to the memtable, a sorted in-memory buffer. When the memtable grows over the limit, it's written
+// to a new immutable SSTable file. Lookups check the memtable and then SSTables from the newest to the oldest,
+// skipping files whose bloom filter doesn't contain the key. When there are too many SSTables, a background
+// compaction merges them into one, dropping overwritten values and deletion markers.
+//
+// The memtable is not logged: the owner of the tree keeps changes durable until Flush returns.
+package lsm
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+)
+
+const (
+	manifestName = "MANIFEST"
+	sstExt       = ".sst"
+)
+
+// Default options of the tree.
+const (
+	DefaultMemtableSize        = 4 << 20
+	DefaultCompactionThreshold = 4
+)
+
+// Options configure the tree.
+type Options struct {
+	// MemtableSize is the size of the memtable in bytes that triggers a flush to a new SSTable.
+	MemtableSize int
+	// CompactionThreshold is the number of SSTables that triggers a compaction.
+	CompactionThreshold int
+}
+
+// Stats are counters of the tree.
+type Stats struct {
+	Tables      int    // number of SSTables
+	Flushes     uint64 // memtables written to SSTables
+	Compactions uint64 // merges of SSTables
+	BloomSkips  uint64 // SSTable lookups skipped by bloom filters
+}
+
+// Tree is a log-structured merge tree. It is safe for concurrent use.
+type Tree struct {
+	mu          sync.RWMutex
+	compactMu   sync.Mutex // serializes compactions
+	dir         string
+	opts        Options
+	memtable    *btree.Tree[string, entry]
+	memSize     int
+	tables      []*sstable // from the newest to the oldest
+	nextID      uint64
+	flushes     uint64
+	compactions uint64
+	bloomSkips  atomic.Uint64
+	compactCh   chan struct{}
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// Open opens the tree stored in the directory, the directory is created if it doesn't exist.
+// Files of the tree may share the directory with other files.
+func Open(dir string, opts Options) (*Tree, error) {
+	if opts.MemtableSize <= 0 {
+		opts.MemtableSize = DefaultMemtableSize
+	}
+
+	if opts.CompactionThreshold < 2 {
+		opts.CompactionThreshold = DefaultCompactionThreshold
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create tree directory: %w", err)
+	}
+
+	t := &Tree{
+		dir:       dir,
+		opts:      opts,
+		memtable:  btree.New[string, entry](cmp.Compare[string]),
+		nextID:    1,
+		compactCh: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	if err := t.load(); err != nil {
+		t.closeTables()
+
+		return nil, err
+	}
+
+	t.wg.Add(1)
+
+	go t.compactor()
+
+	return t, nil
+}
+
+// load opens SSTables listed in the manifest and removes files left by interrupted flushes and compactions.
+func (t *Tree) load() error {
+	live := make(map[string]bool)
+
+	names, err := readManifest(filepath.Join(t.dir, manifestName))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		table, err := openSSTable(filepath.Join(t.dir, name))
+		if err != nil {
+			return err
+		}
+
+		t.tables = append(t.tables, table)
+		live[name] = true
+
+		if id := tableID(name); id >= t.nextID {
+			t.nextID = id + 1
+		}
+	}
+
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return fmt.Errorf("read tree directory: %w", err)
+	}
+
+	// The directory may hold files of the owner, only SSTables and the manifest belong to the tree.
+	for _, e := range entries {
+		stale := strings.HasSuffix(e.Name(), sstExt) || e.Name() == manifestName+".tmp"
+		if !stale || live[e.Name()] {
+			continue
+		}
+
+		if id := tableID(e.Name()); id >= t.nextID {
+			t.nextID = id + 1
+		}
+
+		if err = os.Remove(filepath.Join(t.dir, e.Name())); err != nil {
+			return fmt.Errorf("remove stale file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the value of the key, false is returned if there is no such key.
+func (t *Tree) Get(key string) ([]byte, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if e, ok := t.memtable.Get(key); ok {
+		return e.value, e.kind == kindPut, nil
+	}
+
+	for _, table := range t.tables {
+		if !table.filter.mayContain(key) {
+			t.bloomSkips.Add(1)
+
+			continue
+		}
+
+		e, ok, err := table.get(key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if ok {
+			return e.value, e.kind == kindPut, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// Scan calls fn for every key in [from, to) in the key order, until fn returns false. The empty to key is unbounded.
+// The tree must not be changed by fn.
+func (t *Tree) Scan(from, to string, fn func(key string, value []byte) bool) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	sources := make([]source, 0, len(t.tables)+1)
+	sources = append(sources, t.memtableRange(from, to))
+
+	for _, table := range t.tables {
+		sources = append(sources, table.seek(from))
+	}
+
+	it, err := newMergeIter(sources)
+	if err != nil {
+		return err
+	}
+
+	for {
+		e, err := it.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if e.key < from || e.kind == kindDelete {
+			continue
+		}
+
+		if (to != "" && e.key >= to) || !fn(e.key, e.value) {
+			return nil
+		}
+	}
+}
+
+// Put sets the value of the key. The tree keeps the value, it must not be modified afterwards.
+func (t *Tree) Put(key string, value []byte) error {
+	return t.write(entry{key: key, kind: kindPut, value: value})
+}
+
+// Delete removes the key.
+func (t *Tree) Delete(key string) error {
+	return t.write(entry{key: key, kind: kindDelete})
+}
+
+func (t *Tree) write(e entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.memtable.Put(e.key, e)
+	t.memSize += len(e.key) + 1 + len(e.value)
+
+	if t.memSize < t.opts.MemtableSize {
+		return nil
+	}
+
+	return t.flush()
+}
+
+// Flush writes the memtable to a new SSTable, so all changes made so far are durable.
+func (t *Tree) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.flush()
+}
+
+// Stats returns counters of the tree.
+func (t *Tree) Stats() Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return Stats{
+		Tables:      len(t.tables),
+		Flushes:     t.flushes,
+		Compactions: t.compactions,
+		BloomSkips:  t.bloomSkips.Load(),
+	}
+}
+
+// Close flushes the memtable and closes SSTables, the tree must not be used after that.
+func (t *Tree) Close() error {
+	close(t.done)
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.flush()
+	t.closeTables()
+
+	return err
+}
+
+func (t *Tree) closeTables() {
+	for _, table := range t.tables {
+		_ = table.close()
+	}
+
+	t.tables = nil
+}
+
+// flush writes the memtable to a new SSTable. The caller must hold the lock.
+func (t *Tree) flush() error {
+	if t.memtable.Len() == 0 {
+		return nil
+	}
+
+	name := tableName(t.nextID)
+	path := filepath.Join(t.dir, name)
+	it := t.memtableRange("", "")
+
+	next := func() (entry, bool) {
+		e, err := it.next()
+
+		return e, err == nil
+	}
+
+	if err := writeSSTable(path, t.memtable.Len(), next); err != nil {
+		return err
+	}
+
+	table, err := openSSTable(path)
+	if err != nil {
+		return err
+	}
+
+	tables := append([]*sstable{table}, t.tables...)
+
+	if err = t.writeManifest(tables); err != nil {
+		_ = table.close()
+		_ = os.Remove(path)
+
+		return err
+	}
+
+	t.nextID++
+	t.tables = tables
+	t.memtable = btree.New[string, entry](cmp.Compare[string])
+	t.memSize = 0
+	t.flushes++
+
+	if len(t.tables) >= t.opts.CompactionThreshold {
+		select {
+		case t.compactCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// compactor merges SSTables every time there are too many of them.
+func (t *Tree) compactor() {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.compactCh:
+			// If the compaction fails, the next flush triggers another attempt.
+			_ = t.Compact()
+		}
+	}
+}
+
+// Compact merges all SSTables into one. Readers and writers are not blocked while tables are merged.
+func (t *Tree) Compact() error {
+	t.compactMu.Lock()
+	defer t.compactMu.Unlock()
+
+	t.mu.Lock()
+
+	if len(t.tables) < 2 {
+		t.mu.Unlock()
+
+		return nil
+	}
+
+	inputs := t.tables
+	name := tableName(t.nextID)
+	t.nextID++
+
+	t.mu.Unlock()
+
+	// Input tables are immutable and only compactions remove them, so they are read without the lock.
+	path := filepath.Join(t.dir, name)
+
+	if err := t.merge(path, inputs); err != nil {
+		return err
+	}
+
+	merged, err := openSSTable(path)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Tables flushed during the merge are newer than the merged one.
+	flushed := t.tables[:len(t.tables)-len(inputs)]
+	tables := append(append([]*sstable{}, flushed...), merged)
+
+	if err = t.writeManifest(tables); err != nil {
+		_ = merged.close()
+		_ = os.Remove(path)
+
+		return err
+	}
+
+	t.tables = tables
+	t.compactions++
+
+	for _, table := range inputs {
+		_ = table.close()
+		_ = os.Remove(table.path)
+	}
+
+	return nil
+}
+
+// merge writes entries of the tables to a new SSTable. The tables include the oldest one,
+// so deletion markers have nothing left to hide and are dropped.
+func (t *Tree) merge(path string, tables []*sstable) error {
+	sources := make([]source, 0, len(tables))
+	keys := 0
+
+	for _, table := range tables {
+		sources = append(sources, table.iterFrom(0))
+		keys += table.count
+	}
+
+	it, err := newMergeIter(sources)
+	if err != nil {
+		return err
+	}
+
+	var mergeErr error
+
+	next := func() (entry, bool) {
+		for {
+			e, err := it.next()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					mergeErr = err
+				}
+
+				return entry{}, false
+			}
+
+			if e.kind == kindPut {
+				return e, true
+			}
+		}
+	}
+
+	if err = writeSSTable(path, keys, next); err != nil {
+		return err
+	}
+
+	if mergeErr != nil {
+		_ = os.Remove(path)
+
+		return fmt.Errorf("merge sstables: %w", mergeErr)
+	}
+
+	return nil
+}
+
+// memtableRange returns entries of the memtable in [from, to). The caller must hold the lock.
+func (t *Tree) memtableRange(from, to string) *sliceIter {
+	entries := make([]entry, 0)
+
+	t.memtable.AscendGreaterOrEqual(from, func(key string, e entry) bool {
+		if to != "" && key >= to {
+			return false
+		}
+
+		entries = append(entries, e)
+
+		return true
+	})
+
+	return &sliceIter{entries: entries}
+}
+
+// writeManifest replaces the list of live SSTables, from the newest to the oldest.
+func (t *Tree) writeManifest(tables []*sstable) error {
+	var b strings.Builder
+
+	for _, table := range tables {
+		b.WriteString(filepath.Base(table.path))
+		b.WriteByte('\n')
+	}
+
+	path := filepath.Join(t.dir, manifestName)
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	if _, err = file.WriteString(b.String()); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace manifest: %w", err)
+	}
+
+	return syncDir(t.dir)
+}
+
+func readManifest(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	defer file.Close()
+
+	var names []string
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	return names, nil
+}
+
+func tableName(id uint64) string {
+	return fmt.Sprintf("%020d%s", id, sstExt)
+}
+
+// tableID returns the number of the SSTable file, zero is returned for other files.
+func tableID(name string) uint64 {
+	id, err := strconv.ParseUint(strings.TrimSuffix(name, sstExt), 10, 64)
+	if err != nil || !strings.HasSuffix(name, sstExt) {
+		return 0
+	}
+
+	return id
+}
+
+// syncDir flushes the directory entries, so created and renamed files survive a crash.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("sync directory %s: %w", dir, err)
+	}
+
+	return f.Close()
+}
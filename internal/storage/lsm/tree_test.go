@@ -0,0 +1,290 @@
+package lsm_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/lsm"
+)
+
+const (
+	minKey = -1 << 63
+	maxKey = 1<<63 - 1
+)
+
+func openTree(t *testing.T, dir string, opts lsm.Options) *lsm.Tree {
+	t.Helper()
+
+	tree, err := lsm.Open(dir, opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = tree.Close()
+	})
+
+	return tree
+}
+
+// intKey encodes the integer so that keys compare as the integers.
+func intKey(i int64) string {
+	return string(binary.BigEndian.AppendUint64(nil, uint64(i)^1<<63))
+}
+
+func keyInt(key string) int64 {
+	return int64(binary.BigEndian.Uint64([]byte(key)) ^ 1<<63)
+}
+
+// scan returns values of keys between from and to inclusive.
+func scan(t *testing.T, tree *lsm.Tree, from, to int64) map[int64]string {
+	t.Helper()
+
+	values := make(map[int64]string)
+	last := ""
+	high := ""
+
+	if to < maxKey {
+		high = intKey(to + 1)
+	}
+
+	err := tree.Scan(intKey(from), high, func(key string, value []byte) bool {
+		assert.Greater(t, key, last)
+		last = key
+		values[keyInt(key)] = string(value)
+
+		return true
+	})
+	require.NoError(t, err)
+
+	return values
+}
+
+func TestTree_PutGetDelete(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{})
+
+	require.NoError(t, tree.Put(intKey(1), []byte("one")))
+	require.NoError(t, tree.Put(intKey(2), []byte("two")))
+	require.NoError(t, tree.Put(intKey(1), []byte("uno")))
+	require.NoError(t, tree.Delete(intKey(2)))
+
+	value, ok, err := tree.Get(intKey(1))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "uno", string(value))
+
+	_, ok, err = tree.Get(intKey(2))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = tree.Get(intKey(3))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestTree_Flush(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{CompactionThreshold: 100})
+
+	require.NoError(t, tree.Put(intKey(1), []byte("one")))
+	require.NoError(t, tree.Put(intKey(2), []byte("two")))
+	require.NoError(t, tree.Put(intKey(3), []byte("three")))
+	require.NoError(t, tree.Flush())
+
+	// Newer tables and the memtable shadow older values and deleted keys.
+	require.NoError(t, tree.Put(intKey(2), []byte("dos")))
+	require.NoError(t, tree.Flush())
+	require.NoError(t, tree.Delete(intKey(3)))
+	require.NoError(t, tree.Put(intKey(4), []byte("four")))
+
+	assert.Equal(t, 2, tree.Stats().Tables)
+	assert.Equal(t, map[int64]string{1: "one", 2: "dos", 4: "four"}, scan(t, tree, 0, 10))
+	assert.Equal(t, map[int64]string{2: "dos"}, scan(t, tree, 2, 3))
+
+	value, ok, err := tree.Get(intKey(2))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "dos", string(value))
+
+	_, ok, err = tree.Get(intKey(3))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestTree_Scan(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{MemtableSize: 256, CompactionThreshold: 100})
+	expected := make(map[int64]string)
+
+	for i := int64(0); i < 200; i++ {
+		require.NoError(t, tree.Put(intKey(i), []byte(fmt.Sprint(i))))
+		expected[i] = fmt.Sprint(i)
+	}
+
+	assert.Greater(t, tree.Stats().Tables, 1)
+	assert.Equal(t, expected, scan(t, tree, -10, 1000))
+
+	var keys []int64
+
+	err := tree.Scan(intKey(50), intKey(100), func(key string, _ []byte) bool {
+		keys = append(keys, keyInt(key))
+
+		return len(keys) < 3
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{50, 51, 52}, keys)
+}
+
+func TestTree_Reopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	tree, err := lsm.Open(dir, lsm.Options{CompactionThreshold: 100})
+	require.NoError(t, err)
+
+	require.NoError(t, tree.Put(intKey(1), []byte("one")))
+	require.NoError(t, tree.Flush())
+	require.NoError(t, tree.Put(intKey(2), []byte("two")))
+	require.NoError(t, tree.Delete(intKey(1)))
+	require.NoError(t, tree.Close())
+
+	// A table left by an interrupted flush is not in the manifest.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000099.sst"), []byte("partial"), 0o644))
+	// Files of the owner are left as is.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "header"), []byte("header"), 0o644))
+
+	tree = openTree(t, dir, lsm.Options{CompactionThreshold: 100})
+
+	assert.Equal(t, map[int64]string{2: "two"}, scan(t, tree, 0, 10))
+	assert.NoFileExists(t, filepath.Join(dir, "00000000000000000099.sst"))
+	assert.FileExists(t, filepath.Join(dir, "header"))
+
+	require.NoError(t, tree.Put(intKey(3), []byte("three")))
+	require.NoError(t, tree.Flush())
+	assert.Equal(t, map[int64]string{2: "two", 3: "three"}, scan(t, tree, 0, 10))
+}
+
+func TestTree_Compact(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tree := openTree(t, dir, lsm.Options{CompactionThreshold: 100})
+
+	for i := int64(0); i < 4; i++ {
+		require.NoError(t, tree.Put(intKey(i), []byte("old")))
+		require.NoError(t, tree.Put(intKey(i+10), []byte("new")))
+		require.NoError(t, tree.Flush())
+	}
+
+	require.NoError(t, tree.Delete(intKey(10)))
+	require.NoError(t, tree.Put(intKey(0), []byte("updated")))
+	require.NoError(t, tree.Flush())
+	require.NoError(t, tree.Compact())
+
+	stats := tree.Stats()
+	assert.Equal(t, 1, stats.Tables)
+	assert.Equal(t, uint64(1), stats.Compactions)
+
+	expected := map[int64]string{0: "updated", 1: "old", 2: "old", 3: "old", 11: "new", 12: "new", 13: "new"}
+	assert.Equal(t, expected, scan(t, tree, minKey, maxKey))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sst"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestTree_BackgroundCompaction(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{MemtableSize: 64, CompactionThreshold: 3})
+
+	for i := int64(0); i < 500; i++ {
+		require.NoError(t, tree.Put(intKey(i%50), []byte(fmt.Sprint(i))))
+	}
+
+	assert.Eventually(t, func() bool {
+		return tree.Stats().Compactions > 0
+	}, 5*time.Second, time.Millisecond)
+
+	values := scan(t, tree, 0, 100)
+	assert.Len(t, values, 50)
+
+	for i := int64(0); i < 50; i++ {
+		assert.Equal(t, fmt.Sprint(450+i), values[i])
+	}
+}
+
+func TestTree_Bloom(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{CompactionThreshold: 100})
+
+	for i := int64(0); i < 1000; i++ {
+		require.NoError(t, tree.Put(intKey(i*2), []byte("even")))
+	}
+
+	require.NoError(t, tree.Flush())
+
+	for i := int64(0); i < 1000; i++ {
+		_, ok, err := tree.Get(intKey(i*2 + 1))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+
+	// The filter has about 1% of false positives.
+	assert.Greater(t, tree.Stats().BloomSkips, uint64(950))
+}
+
+func TestTree_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	tree := openTree(t, t.TempDir(), lsm.Options{MemtableSize: 512, CompactionThreshold: 2})
+
+	const (
+		writers = 4
+		keys    = 200
+	)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < keys; i++ {
+				key := int64(w*keys + i)
+				assert.NoError(t, tree.Put(intKey(key), []byte(fmt.Sprint(key))))
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < keys; i++ {
+				value, ok, err := tree.Get(intKey(int64(w*keys + i)))
+				assert.NoError(t, err)
+
+				if ok {
+					assert.Equal(t, fmt.Sprint(w*keys+i), string(value))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, scan(t, tree, 0, writers*keys), writers*keys)
+}
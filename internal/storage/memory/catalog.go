@@ -2,11 +2,14 @@ package memory
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 )
 
+// Catalog keeps databases in memory. It is safe for concurrent use.
 type Catalog struct {
+	mu        sync.RWMutex
 	databases map[string]sql.Database
 }
 
@@ -17,6 +20,9 @@ func NewCatalog() *Catalog {
 }
 
 func (c *Catalog) GetDatabase(name string) (sql.Database, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if database, ok := c.databases[name]; ok {
 		return database, nil
 	}
@@ -25,6 +31,9 @@ func (c *Catalog) GetDatabase(name string) (sql.Database, error) {
 }
 
 func (c *Catalog) ListDatabases() ([]sql.Database, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	databases := make([]sql.Database, 0, len(c.databases))
 
 	for name := range c.databases {
@@ -35,6 +44,9 @@ func (c *Catalog) ListDatabases() ([]sql.Database, error) {
 }
 
 func (c *Catalog) CreateDatabase(name string) (sql.Database, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if _, ok := c.databases[name]; ok {
 		return nil, fmt.Errorf("database %q already exist", name)
 	}
@@ -46,6 +58,9 @@ func (c *Catalog) CreateDatabase(name string) (sql.Database, error) {
 }
 
 func (c *Catalog) DropDatabase(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if _, ok := c.databases[name]; ok {
 		delete(c.databases, name)
 
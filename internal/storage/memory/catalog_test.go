@@ -1,8 +1,11 @@
 package memory_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
@@ -123,3 +126,42 @@ func TestCatalog_DropDatabase(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestCatalog_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 8
+		rounds  = 100
+	)
+
+	catalog := memory.NewCatalog()
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("db%d", w%2)
+
+			for i := 0; i < rounds; i++ {
+				_, _ = catalog.CreateDatabase(name)
+				_, _ = catalog.GetDatabase(name)
+
+				databases, err := catalog.ListDatabases()
+				assert.NoError(t, err)
+
+				for _, db := range databases {
+					_ = db.Name()
+				}
+
+				_ = catalog.DropDatabase(name)
+			}
+		}(w)
+	}
+
+	wg.Wait()
+}
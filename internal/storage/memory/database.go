@@ -3,19 +3,26 @@ package memory
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
 )
 
+// Database keeps tables in memory. It is safe for concurrent use.
 type Database struct {
-	name   string
-	tables map[string]*Table
+	mu         sync.RWMutex
+	name       string
+	tables     map[string]*Table
+	sequences  map[string]*NamedSequence
+	statistics map[string]sql.Statistics
 }
 
 func NewDatabase(name string) *Database {
 	return &Database{
-		name:   name,
-		tables: make(map[string]*Table),
+		name:       name,
+		tables:     make(map[string]*Table),
+		sequences:  make(map[string]*NamedSequence),
+		statistics: make(map[string]sql.Statistics),
 	}
 }
 
@@ -24,6 +31,9 @@ func (d *Database) Name() string {
 }
 
 func (d *Database) ListTables() []sql.Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	tables := make([]sql.Table, 0, len(d.tables))
 
 	for _, t := range d.tables {
@@ -34,6 +44,9 @@ func (d *Database) ListTables() []sql.Table {
 }
 
 func (d *Database) GetTable(name string) (sql.Table, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	if table, ok := d.tables[name]; ok {
 		return table, nil
 	}
@@ -42,6 +55,9 @@ func (d *Database) GetTable(name string) (sql.Table, error) {
 }
 
 func (d *Database) CreateTable(name string, scheme sql.Scheme) (sql.Table, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if _, ok := d.tables[name]; ok {
 		return nil, errors.New("table already exist")
 	}
@@ -57,11 +73,92 @@ func (d *Database) CreateTable(name string, scheme sql.Scheme) (sql.Table, error
 }
 
 func (d *Database) DropTable(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if _, ok := d.tables[name]; !ok {
 		return fmt.Errorf("table %s not found", name)
 	}
 
 	delete(d.tables, name)
+	delete(d.statistics, name)
+
+	return nil
+}
+
+func (d *Database) ListSequences() []sql.NamedSequence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	sequences := make([]sql.NamedSequence, 0, len(d.sequences))
+
+	for _, s := range d.sequences {
+		sequences = append(sequences, s)
+	}
+
+	return sequences
+}
+
+func (d *Database) GetSequence(name string) (sql.NamedSequence, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if sequence, ok := d.sequences[name]; ok {
+		return sequence, nil
+	}
+
+	return nil, fmt.Errorf("sequence %q not found", name)
+}
+
+func (d *Database) CreateSequence(name string, options sql.SequenceOptions) (sql.NamedSequence, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sequences[name]; ok {
+		return nil, fmt.Errorf("sequence %q already exist", name)
+	}
+
+	sequence := NewNamedSequence(name, options)
+	d.sequences[name] = sequence
+
+	return sequence, nil
+}
+
+func (d *Database) DropSequence(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sequences[name]; !ok {
+		return fmt.Errorf("sequence %q not found", name)
+	}
+
+	delete(d.sequences, name)
+
+	return nil
+}
+
+func (d *Database) Statistics(table string) (sql.Statistics, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	statistics, ok := d.statistics[table]
+
+	return statistics, ok
+}
+
+func (d *Database) SetStatistics(table string, statistics sql.Statistics) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[table]; !ok {
+		return fmt.Errorf("table %q not found", table)
+	}
+
+	d.statistics[table] = statistics
 
 	return nil
 }
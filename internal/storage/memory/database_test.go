@@ -1,12 +1,15 @@
 package memory_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
 	"github.com/i-sevostyanov/NanoDB/internal/storage/memory"
 )
 
@@ -180,6 +183,66 @@ func TestDatabase_DropTable(t *testing.T) {
 	})
 }
 
+func TestDatabase_Sequences(t *testing.T) {
+	t.Parallel()
+
+	database := memory.NewDatabase("playground")
+
+	sequence, err := database.CreateSequence("orders_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "orders_seq", sequence.Name())
+
+	_, err = database.CreateSequence("orders_seq", sql.SequenceOptions{Start: 1, Increment: 1})
+	require.Error(t, err)
+
+	_, err = database.CreateSequence("broken_seq", sql.SequenceOptions{Start: 1})
+	require.Error(t, err)
+
+	found, err := database.GetSequence("orders_seq")
+	require.NoError(t, err)
+	assert.Equal(t, sequence, found)
+	assert.Equal(t, []sql.NamedSequence{sequence}, database.ListSequences())
+
+	require.NoError(t, database.DropSequence("orders_seq"))
+	require.Error(t, database.DropSequence("orders_seq"))
+
+	_, err = database.GetSequence("orders_seq")
+	require.Error(t, err)
+	assert.Empty(t, database.ListSequences())
+}
+
+func TestDatabase_Statistics(t *testing.T) {
+	t.Parallel()
+
+	database := memory.NewDatabase("playground")
+	scheme := sql.Scheme{
+		"id": sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+	}
+	statistics := sql.Statistics{
+		Rows: 1,
+		Columns: map[string]sql.ColumnStatistics{
+			"id": {Distinct: 1, Histogram: []sql.Value{datatype.NewInteger(1)}},
+		},
+	}
+
+	_, err := database.CreateTable("users", scheme)
+	require.NoError(t, err)
+
+	_, ok := database.Statistics("users")
+	require.False(t, ok)
+	require.Error(t, database.SetStatistics("unknown", statistics))
+	require.NoError(t, database.SetStatistics("users", statistics))
+
+	found, ok := database.Statistics("users")
+	require.True(t, ok)
+	assert.Equal(t, statistics, found)
+
+	require.NoError(t, database.DropTable("users"))
+
+	_, ok = database.Statistics("users")
+	require.False(t, ok)
+}
+
 func TestDatabase_Name(t *testing.T) {
 	t.Parallel()
 
@@ -187,3 +250,53 @@ func TestDatabase_Name(t *testing.T) {
 	database := memory.NewDatabase(name)
 	assert.Equal(t, name, database.Name())
 }
+
+func TestDatabase_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers = 8
+		rounds  = 100
+	)
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+	}
+
+	database := memory.NewDatabase("playground")
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("table%d", w%2)
+
+			for i := 0; i < rounds; i++ {
+				if table, err := database.CreateTable(name, scheme); err == nil {
+					assert.NoError(t, table.Insert(sql.IntegerKey(int64(i)), sql.Row{datatype.NewInteger(int64(i))}))
+				}
+
+				if table, err := database.GetTable(name); err == nil {
+					_, _ = table.Scan()
+				}
+
+				for _, table := range database.ListTables() {
+					_ = table.Name()
+				}
+
+				_ = database.DropTable(name)
+			}
+		}(w)
+	}
+
+	wg.Wait()
+}
@@ -1,31 +1,118 @@
 package memory
 
 import (
+	"fmt"
 	"sync"
+
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
 )
 
 type Sequence struct {
 	mu    sync.RWMutex
 	value int64
+	// pending tells that the value was returned by Next and no row has been stored since then.
+	pending bool
 }
 
 func (s *Sequence) Next() int64 {
 	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.value++
-	s.mu.Unlock()
+	s.pending = true
 
 	return s.value
 }
 
+func (s *Sequence) Release(value int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending && s.value == value {
+		s.value--
+		s.pending = false
+	}
+}
+
 func (s *Sequence) SetValue(value int64) {
 	s.mu.Lock()
 	s.value = value
+	s.pending = false
 	s.mu.Unlock()
 }
 
+// advance moves the sequence forward to the key if it's a single integer,
+// a sequence that is already past it is left as is.
+func (s *Sequence) advance(key sql.Key) {
+	value, ok := key.Integer()
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A stored row may hold the pending value, so it can't be given back anymore.
+	s.value = max(s.value, value)
+	s.pending = false
+}
+
 func (s *Sequence) Value() int64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	return s.value
 }
+
+// NamedSequence is a sequence created by CREATE SEQUENCE.
+type NamedSequence struct {
+	mu      sync.Mutex
+	name    string
+	options sql.SequenceOptions
+	value   int64
+	called  bool
+}
+
+func NewNamedSequence(name string, options sql.SequenceOptions) *NamedSequence {
+	return &NamedSequence{
+		name:    name,
+		options: options,
+	}
+}
+
+func (s *NamedSequence) Name() string {
+	return s.name
+}
+
+func (s *NamedSequence) Options() sql.SequenceOptions {
+	return s.options
+}
+
+func (s *NamedSequence) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, err := s.options.NextValue(s.value, s.called)
+	if err != nil {
+		return 0, fmt.Errorf("nextval of %q: %w", s.name, err)
+	}
+
+	s.value, s.called = value, true
+
+	return value, nil
+}
+
+func (s *NamedSequence) Current() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value, s.called
+}
+
+func (s *NamedSequence) SetValue(value int64) error {
+	s.mu.Lock()
+	s.value, s.called = value, true
+	s.mu.Unlock()
+
+	return nil
+}
@@ -1,10 +1,14 @@
 package memory_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/sql/datatype"
 	"github.com/i-sevostyanov/NanoDB/internal/storage/memory"
 )
 
@@ -30,6 +34,43 @@ func TestSequence_SetValue(t *testing.T) {
 	assert.Equal(t, int64(100), seq.Value())
 }
 
+func TestSequence_Release(t *testing.T) {
+	t.Parallel()
+
+	t.Run("gives back the last value", func(t *testing.T) {
+		t.Parallel()
+
+		seq := &memory.Sequence{}
+		seq.Release(seq.Next())
+		assert.Equal(t, int64(0), seq.Value())
+		assert.Equal(t, int64(1), seq.Next())
+	})
+
+	t.Run("skips the value if a later one is taken", func(t *testing.T) {
+		t.Parallel()
+
+		seq := &memory.Sequence{}
+		first := seq.Next()
+		seq.Next()
+		seq.Release(first)
+		assert.Equal(t, int64(3), seq.Next())
+	})
+
+	t.Run("skips the value if a row is stored after it was taken", func(t *testing.T) {
+		t.Parallel()
+
+		table := memory.NewTable("users", sql.Scheme{
+			"id": sql.Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		})
+
+		value := table.Sequence().Next()
+		require.NoError(t, table.Insert(sql.IntegerKey(value), sql.Row{datatype.NewInteger(value)}))
+
+		table.Sequence().Release(value)
+		assert.Equal(t, int64(2), table.Sequence().Next())
+	})
+}
+
 func TestSequence_Value(t *testing.T) {
 	t.Parallel()
 
@@ -40,3 +81,32 @@ func TestSequence_Value(t *testing.T) {
 	seq.Next()
 	assert.Equal(t, int64(3), seq.Value())
 }
+
+func TestNamedSequence(t *testing.T) {
+	t.Parallel()
+
+	seq := memory.NewNamedSequence("orders_seq", sql.SequenceOptions{Start: 100, Increment: 10})
+	assert.Equal(t, "orders_seq", seq.Name())
+	assert.Equal(t, sql.SequenceOptions{Start: 100, Increment: 10}, seq.Options())
+
+	_, ok := seq.Current()
+	assert.False(t, ok)
+
+	for _, expected := range []int64{100, 110, 120} {
+		value, err := seq.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	current, ok := seq.Current()
+	require.True(t, ok)
+	assert.Equal(t, int64(120), current)
+
+	require.NoError(t, seq.SetValue(math.MaxInt64-5))
+
+	_, err := seq.Next()
+	require.Error(t, err)
+
+	current, _ = seq.Current()
+	assert.Equal(t, int64(math.MaxInt64-5), current)
+}
@@ -1,43 +1,44 @@
 package memory
 
 import (
+	"cmp"
 	"fmt"
 	"io"
 	"sync"
 
 	"github.com/i-sevostyanov/NanoDB/internal/sql"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/btree"
+	"github.com/i-sevostyanov/NanoDB/internal/storage/index"
 )
 
+// scanBatch is the number of rows a table scan reads at once.
+const scanBatch = 128
+
+// Table keeps rows in a B+tree ordered by the primary key.
+// Secondary indexes are updated together with rows. Table is safe for concurrent use:
+// scans walk the tree lazily and observe later changes of rows they haven't reached yet (see cursor),
+// index scans copy the matching rows, so their iterators are not affected by later changes.
 type Table struct {
+	mu         sync.RWMutex
 	name       string
 	scheme     sql.Scheme
-	keys       []int64
-	rows       map[int64]sql.Row
+	rows       *btree.Tree[sql.Key, sql.Row]
+	indexes    map[string]*index.Index
 	seq        *Sequence
-	primaryKey sql.Column
+	primaryKey []sql.Column
 }
 
 func NewTable(name string, scheme sql.Scheme) *Table {
-	var primaryKey sql.Column
-
-	for column := range scheme {
-		if scheme[column].PrimaryKey {
-			primaryKey = scheme[column]
-
-			break
-		}
-	}
-
 	return &Table{
-		name:   name,
-		scheme: scheme,
-		keys:   nil,
-		rows:   make(map[int64]sql.Row),
+		name:    name,
+		scheme:  scheme,
+		rows:    btree.New[sql.Key, sql.Row](cmp.Compare[sql.Key]),
+		indexes: make(map[string]*index.Index),
 		seq: &Sequence{
 			mu:    sync.RWMutex{},
 			value: 0,
 		},
-		primaryKey: primaryKey,
+		primaryKey: scheme.PrimaryKey(),
 	}
 }
 
@@ -49,69 +50,284 @@ func (t *Table) Scheme() sql.Scheme {
 	return t.scheme
 }
 
-func (t *Table) PrimaryKey() sql.Column {
+func (t *Table) PrimaryKey() []sql.Column {
 	return t.primaryKey
 }
 
 func (t *Table) Scan() (sql.RowIter, error) {
-	rows := make([]sql.Row, 0, len(t.rows))
+	c := &cursor{
+		table: t,
+		from:  "",
+		to:    "",
+		rows:  nil,
+	}
+
+	return c, nil
+}
+
+func (t *Table) Get(key sql.Key) (sql.Row, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	row, ok := t.rows.Get(key)
+
+	return row, ok, nil
+}
 
-	for _, key := range t.keys {
-		rows = append(rows, t.rows[key])
+func (t *Table) ScanRange(from, to *sql.Bound) (sql.RowIter, error) {
+	low, high, ok, err := sql.KeyRange(from, to)
+	if err != nil {
+		return nil, err
 	}
 
-	i := &iter{
-		index: 0,
-		rows:  rows,
+	if !ok {
+		return sql.RowsIter(), nil
+	}
+
+	c := &cursor{
+		table: t,
+		from:  low,
+		to:    high,
+		rows:  nil,
 	}
 
-	return i, nil
+	return c, nil
 }
 
 func (t *Table) Sequence() sql.Sequence {
 	return t.seq
 }
 
-func (t *Table) Insert(key int64, row sql.Row) error {
-	if _, ok := t.rows[key]; ok {
-		return fmt.Errorf("duplicate primary key: %d", key)
+func (t *Table) Insert(key sql.Key, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows.Get(key); ok {
+		return fmt.Errorf("duplicate primary key: %s", key)
 	}
 
-	t.rows[key] = row
-	t.keys = append(t.keys, key)
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
 
-	if t.seq.Value() < key {
-		t.seq.SetValue(key)
+	// Index entries are added first, so the row is never stored without them.
+	for _, idx := range t.indexes {
+		idx.Insert(key, row)
 	}
 
+	t.rows.Put(key, row)
+	t.seq.advance(key)
+
 	return nil
 }
 
-func (t *Table) Delete(key int64) error {
-	if _, ok := t.rows[key]; !ok {
-		return fmt.Errorf("row with key %d not found", key)
+func (t *Table) Delete(key sql.Key) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	row, ok := t.rows.Delete(key)
+	if !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	for _, idx := range t.indexes {
+		idx.Delete(key, row)
 	}
 
-	// O(n), fix it in the next release
-	for index := range t.keys {
-		if t.keys[index] == key {
-			t.keys = append(t.keys[:index], t.keys[index+1:]...)
+	return nil
+}
+
+func (t *Table) Update(key sql.Key, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-			break
+	old, ok := t.rows.Get(key)
+	if !ok {
+		return fmt.Errorf("row with key %s not found", key)
+	}
+
+	if err := t.checkIndexes(key, row); err != nil {
+		return err
+	}
+
+	for _, idx := range t.indexes {
+		idx.Delete(key, old)
+		idx.Insert(key, row)
+	}
+
+	t.rows.Put(key, row)
+
+	return nil
+}
+
+func (t *Table) Indexes() []sql.Index {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	indexes := make([]sql.Index, 0, len(t.indexes))
+
+	for _, idx := range t.indexes {
+		indexes = append(indexes, &tableIndex{Index: idx, table: t})
+	}
+
+	return indexes
+}
+
+func (t *Table) CreateIndex(name string, columns []string, unique bool) (sql.Index, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.indexes[name]; ok {
+		return nil, fmt.Errorf("index %q already exist", name)
+	}
+
+	idx, err := index.New(name, t.scheme, columns, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkErr error
+
+	t.rows.Ascend(func(key sql.Key, row sql.Row) bool {
+		if checkErr = idx.Check(key, row); checkErr != nil {
+			return false
 		}
+
+		idx.Insert(key, row)
+
+		return true
+	})
+
+	if checkErr != nil {
+		return nil, checkErr
 	}
 
-	delete(t.rows, key)
+	t.indexes[name] = idx
+
+	return &tableIndex{Index: idx, table: t}, nil
+}
+
+func (t *Table) DropIndex(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.indexes[name]; !ok {
+		return fmt.Errorf("index %q not found", name)
+	}
+
+	delete(t.indexes, name)
 
 	return nil
 }
 
-func (t *Table) Update(key int64, row sql.Row) error {
-	if _, ok := t.rows[key]; !ok {
-		return fmt.Errorf("row with key %d not found", key)
+// checkIndexes checks that the row doesn't violate unique indexes.
+func (t *Table) checkIndexes(key sql.Key, row sql.Row) error {
+	for _, idx := range t.indexes {
+		if err := idx.Check(key, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableIndex reads rows of the table found by the index.
+type tableIndex struct {
+	*index.Index
+	table *Table
+}
+
+func (i *tableIndex) Scan(r sql.IndexRange) (sql.RowIter, error) {
+	i.table.mu.RLock()
+	defer i.table.mu.RUnlock()
+
+	keys, err := i.Keys(r)
+	if err != nil {
+		return nil, err
 	}
 
-	t.rows[key] = row
+	rows := make([]sql.Row, 0, len(keys))
+
+	for _, key := range keys {
+		if row, ok := i.table.rows.Get(key); ok {
+			rows = append(rows, row)
+		}
+	}
+
+	it := &iter{
+		index: 0,
+		rows:  rows,
+	}
+
+	return it, nil
+}
+
+// cursor walks rows of the table with keys in [from, to) in the key order, reading a batch of rows
+// under the lock at a time. Changes made during the iteration are observed for keys the cursor hasn't read yet:
+// every key is returned at most once, in the state of the moment its batch was read,
+// and rows inserted behind the cursor are not returned.
+type cursor struct {
+	table *Table
+	from  sql.Key
+	to    sql.Key // the empty key is unbounded
+	rows  []sql.Row
+}
+
+func (c *cursor) Next() (sql.Row, error) {
+	if len(c.rows) == 0 {
+		if c.table == nil {
+			return nil, io.EOF
+		}
+
+		c.readBatch()
+
+		if len(c.rows) == 0 {
+			c.table = nil
+
+			return nil, io.EOF
+		}
+	}
+
+	row := c.rows[0]
+	c.rows = c.rows[1:]
+
+	return row, nil
+}
+
+// readBatch reads the next batch of rows and moves the cursor past them.
+func (c *cursor) readBatch() {
+	c.table.mu.RLock()
+	defer c.table.mu.RUnlock()
+
+	c.rows = make([]sql.Row, 0, scanBatch)
+
+	var last sql.Key
+
+	c.table.rows.AscendGreaterOrEqual(c.from, func(key sql.Key, row sql.Row) bool {
+		if c.to != "" && key >= c.to {
+			return false
+		}
+
+		c.rows = append(c.rows, row)
+		last = key
+
+		return len(c.rows) < scanBatch
+	})
+
+	// The batch is the last one if it's not full.
+	if len(c.rows) < scanBatch {
+		c.table = nil
+
+		return
+	}
+
+	// The least key greater than the last one.
+	c.from = last + "\x00"
+}
+
+func (c *cursor) Close() error {
+	c.table = nil
+	c.rows = nil
 
 	return nil
 }
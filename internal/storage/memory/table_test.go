@@ -1,7 +1,10 @@
 package memory_test
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -78,7 +81,7 @@ func TestTable_PrimaryKey(t *testing.T) {
 	database := memory.NewDatabase("playground")
 	table, err := database.CreateTable("users", scheme)
 	require.NoError(t, err)
-	assert.Equal(t, scheme["id"], table.PrimaryKey())
+	assert.Equal(t, []sql.Column{scheme["id"]}, table.PrimaryKey())
 }
 
 func TestTable_RowIter(t *testing.T) {
@@ -122,6 +125,69 @@ func TestTable_RowIter(t *testing.T) {
 	})
 }
 
+func TestTable_ScanChanges(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	row := func(key int64, name string) sql.Row {
+		return sql.Row{datatype.NewInteger(key), datatype.NewText(name)}
+	}
+
+	table := memory.NewTable("users", scheme)
+
+	// More rows than a scan reads at once.
+	for key := int64(1); key <= 1000; key++ {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), row(key, "bob")))
+	}
+
+	iter, err := table.Scan()
+	require.NoError(t, err)
+
+	first, err := iter.Next()
+	require.NoError(t, err)
+	assert.Equal(t, row(1, "bob"), first)
+
+	// Changes of rows the scan hasn't reached yet are observed, rows behind it are not returned again.
+	require.NoError(t, table.Update(sql.IntegerKey(1), row(1, "alice")))
+	require.NoError(t, table.Insert(sql.IntegerKey(0), row(0, "alice")))
+	require.NoError(t, table.Update(sql.IntegerKey(900), row(900, "alice")))
+	require.NoError(t, table.Delete(sql.IntegerKey(901)))
+	require.NoError(t, table.Insert(sql.IntegerKey(1001), row(1001, "alice")))
+
+	rows := []sql.Row{first}
+
+	for {
+		r, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		rows = append(rows, r)
+	}
+
+	require.NoError(t, iter.Close())
+	require.Len(t, rows, 1000)
+	assert.Equal(t, row(1, "bob"), rows[0])
+	assert.Equal(t, row(900, "alice"), rows[899])
+	assert.Equal(t, row(902, "bob"), rows[900])
+	assert.Equal(t, row(1001, "alice"), rows[999])
+}
+
 func TestTable_Sequence(t *testing.T) {
 	t.Parallel()
 
@@ -177,7 +243,7 @@ func TestTable_Insert(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Insert(key, expected)
+		err = table.Insert(sql.IntegerKey(key), expected)
 		require.NoError(t, err)
 
 		iter, err := table.Scan()
@@ -192,6 +258,43 @@ func TestTable_Insert(t *testing.T) {
 		assert.Nil(t, row)
 	})
 
+	t.Run("scans rows in primary key order", func(t *testing.T) {
+		t.Parallel()
+
+		scheme := sql.Scheme{
+			"id": sql.Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+				Nullable:   false,
+				Default:    nil,
+			},
+		}
+
+		database := memory.NewDatabase("playground")
+		table, err := database.CreateTable("users", scheme)
+		require.NoError(t, err)
+
+		for _, key := range []int64{3, 1, 2} {
+			err = table.Insert(sql.IntegerKey(key), sql.Row{datatype.NewInteger(key)})
+			require.NoError(t, err)
+		}
+
+		iter, err := table.Scan()
+		require.NoError(t, err)
+
+		for _, key := range []int64{1, 2, 3} {
+			row, err := iter.Next()
+			require.NoError(t, err)
+			assert.Equal(t, sql.Row{datatype.NewInteger(key)}, row)
+		}
+
+		row, err := iter.Next()
+		require.ErrorIs(t, io.EOF, err)
+		assert.Nil(t, row)
+	})
+
 	t.Run("returns error on duplicate key", func(t *testing.T) {
 		t.Parallel()
 
@@ -215,10 +318,10 @@ func TestTable_Insert(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Insert(key, expected)
+		err = table.Insert(sql.IntegerKey(key), expected)
 		require.NoError(t, err)
 
-		err = table.Insert(key, nil)
+		err = table.Insert(sql.IntegerKey(key), nil)
 		require.Error(t, err)
 	})
 }
@@ -249,10 +352,10 @@ func TestTable_Delete(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Insert(key, expected)
+		err = table.Insert(sql.IntegerKey(key), expected)
 		require.NoError(t, err)
 
-		err = table.Delete(key)
+		err = table.Delete(sql.IntegerKey(key))
 		require.NoError(t, err)
 
 		iter, err := table.Scan()
@@ -300,12 +403,12 @@ func TestTable_Delete(t *testing.T) {
 		require.NoError(t, err)
 
 		for _, r := range rows {
-			err = table.Insert(r.key, r.row)
+			err = table.Insert(sql.IntegerKey(r.key), r.row)
 			require.NoError(t, err)
 		}
 
 		for _, r := range rows {
-			err = table.Delete(r.key)
+			err = table.Delete(sql.IntegerKey(r.key))
 			require.NoError(t, err)
 		}
 
@@ -335,7 +438,7 @@ func TestTable_Delete(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Delete(1)
+		err = table.Delete(sql.IntegerKey(1))
 		require.Error(t, err)
 	})
 }
@@ -379,10 +482,10 @@ func TestTable_Update(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Insert(1, row)
+		err = table.Insert(sql.IntegerKey(1), row)
 		require.NoError(t, err)
 
-		err = table.Update(1, updated)
+		err = table.Update(sql.IntegerKey(1), updated)
 		require.NoError(t, err)
 
 		iter, err := table.Scan()
@@ -415,7 +518,385 @@ func TestTable_Update(t *testing.T) {
 		table, err := database.CreateTable("users", scheme)
 		require.NoError(t, err)
 
-		err = table.Update(1, nil)
+		err = table.Update(sql.IntegerKey(1), nil)
 		require.Error(t, err)
 	})
 }
+
+func TestTable_Indexes(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+		},
+		"name": sql.Column{
+			Position:   1,
+			Name:       "name",
+			DataType:   sql.Text,
+			PrimaryKey: false,
+			Nullable:   false,
+			Default:    nil,
+		},
+	}
+
+	row := func(id int64, name string) sql.Row {
+		return sql.Row{
+			datatype.NewInteger(id),
+			datatype.NewText(name),
+		}
+	}
+
+	scan := func(t *testing.T, index sql.Index, name string) []sql.Row {
+		t.Helper()
+
+		iter, err := index.Scan(sql.IndexRange{Prefix: []sql.Value{datatype.NewText(name)}})
+		require.NoError(t, err)
+
+		rows := make([]sql.Row, 0)
+
+		for {
+			r, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			rows = append(rows, r)
+		}
+
+		return rows
+	}
+
+	t.Run("keeps index up to date", func(t *testing.T) {
+		t.Parallel()
+
+		table := memory.NewTable("users", scheme)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), row(1, "Max")))
+
+		index, err := table.CreateIndex("users_name", []string{"name"}, false)
+		require.NoError(t, err)
+		require.Len(t, table.Indexes(), 1)
+		assert.Equal(t, "users_name", table.Indexes()[0].Name())
+
+		require.NoError(t, table.Insert(sql.IntegerKey(2), row(2, "Vlad")))
+		require.NoError(t, table.Insert(sql.IntegerKey(3), row(3, "Max")))
+		assert.Equal(t, []sql.Row{row(1, "Max"), row(3, "Max")}, scan(t, index, "Max"))
+
+		require.NoError(t, table.Update(sql.IntegerKey(1), row(1, "Vlad")))
+		assert.Equal(t, []sql.Row{row(3, "Max")}, scan(t, index, "Max"))
+		assert.Equal(t, []sql.Row{row(1, "Vlad"), row(2, "Vlad")}, scan(t, index, "Vlad"))
+
+		require.NoError(t, table.Delete(sql.IntegerKey(2)))
+		assert.Equal(t, []sql.Row{row(1, "Vlad")}, scan(t, index, "Vlad"))
+
+		require.NoError(t, table.DropIndex("users_name"))
+		assert.Empty(t, table.Indexes())
+		require.Error(t, table.DropIndex("users_name"))
+	})
+
+	t.Run("unique index rejects duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		table := memory.NewTable("users", scheme)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), row(1, "Max")))
+
+		_, err := table.CreateIndex("users_name", []string{"name"}, true)
+		require.NoError(t, err)
+
+		_, err = table.CreateIndex("users_name", []string{"name"}, true)
+		require.Error(t, err)
+
+		require.Error(t, table.Insert(sql.IntegerKey(2), row(2, "Max")))
+		require.NoError(t, table.Insert(sql.IntegerKey(2), row(2, "Vlad")))
+		require.Error(t, table.Update(sql.IntegerKey(2), row(2, "Max")))
+		require.NoError(t, table.Update(sql.IntegerKey(1), row(1, "Max")))
+	})
+
+	t.Run("unique index can't be created over duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		table := memory.NewTable("users", scheme)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), row(1, "Max")))
+		require.NoError(t, table.Insert(sql.IntegerKey(2), row(2, "Max")))
+
+		_, err := table.CreateIndex("users_name", []string{"name"}, true)
+		require.Error(t, err)
+		assert.Empty(t, table.Indexes())
+	})
+
+	t.Run("rows with NULL in the indexed column", func(t *testing.T) {
+		t.Parallel()
+
+		nullable := sql.Scheme{
+			"id":   scheme["id"],
+			"name": sql.Column{Position: 1, Name: "name", DataType: sql.Text, Nullable: true},
+		}
+
+		table := memory.NewTable("users", nullable)
+		require.NoError(t, table.Insert(sql.IntegerKey(1), sql.Row{datatype.NewInteger(1), datatype.NewNull()}))
+
+		// The index is built over the row and kept up to date.
+		index, err := table.CreateIndex("users_name", []string{"name"}, true)
+		require.NoError(t, err)
+
+		require.NoError(t, table.Insert(sql.IntegerKey(2), sql.Row{datatype.NewInteger(2), datatype.NewNull()}))
+		require.NoError(t, table.Insert(sql.IntegerKey(3), row(3, "Max")))
+		assert.Equal(t, []sql.Row{row(3, "Max")}, scan(t, index, "Max"))
+
+		iter, err := index.Scan(sql.IndexRange{})
+		require.NoError(t, err)
+
+		keys := 0
+
+		for {
+			_, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			keys++
+		}
+
+		assert.Equal(t, 3, keys)
+
+		require.NoError(t, table.Update(sql.IntegerKey(1), row(1, "Vlad")))
+		assert.Equal(t, []sql.Row{row(1, "Vlad")}, scan(t, index, "Vlad"))
+		require.NoError(t, table.Delete(sql.IntegerKey(2)))
+	})
+}
+
+func TestTable_Get(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+		},
+	}
+
+	table := memory.NewTable("users", scheme)
+	expected := sql.Row{datatype.NewInteger(1)}
+	require.NoError(t, table.Insert(sql.IntegerKey(1), expected))
+
+	row, ok, err := table.Get(sql.IntegerKey(1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, expected, row)
+
+	row, ok, err = table.Get(sql.IntegerKey(2))
+	require.NoError(t, err)
+	require.False(t, ok)
+	assert.Nil(t, row)
+}
+
+func TestTable_ScanRange(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+			Default:    nil,
+		},
+	}
+
+	table := memory.NewTable("users", scheme)
+
+	for key := int64(10); key > 0; key-- {
+		require.NoError(t, table.Insert(sql.IntegerKey(key), sql.Row{datatype.NewInteger(key)}))
+	}
+
+	iter, err := table.ScanRange(
+		&sql.Bound{Value: datatype.NewInteger(3), Inclusive: false},
+		&sql.Bound{Value: datatype.NewInteger(6), Inclusive: true},
+	)
+	require.NoError(t, err)
+
+	keys := make([]int64, 0)
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		keys = append(keys, row[0].Raw().(int64))
+	}
+
+	assert.Equal(t, []int64{4, 5, 6}, keys)
+
+	_, err = table.ScanRange(&sql.Bound{Value: datatype.NewNull()}, nil)
+	require.Error(t, err)
+}
+
+func TestTable_TextKey(t *testing.T) {
+	t.Parallel()
+
+	scheme := sql.Scheme{
+		"code": sql.Column{
+			Position:   0,
+			Name:       "code",
+			DataType:   sql.Text,
+			PrimaryKey: true,
+		},
+	}
+
+	table := memory.NewTable("aircrafts", scheme)
+	expected := make([]string, 0, 300)
+
+	// Codes share prefixes, and more of them than a scan reads at once.
+	for i := 0; i < 300; i++ {
+		code := fmt.Sprintf("%03d", i)
+		key, err := sql.NewKey(datatype.NewText(code))
+		require.NoError(t, err)
+		require.NoError(t, table.Insert(key, sql.Row{datatype.NewText(code)}))
+
+		expected = append(expected, code)
+	}
+
+	codes := func(iter sql.RowIter) []string {
+		result := make([]string, 0)
+
+		for {
+			row, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			result = append(result, row[0].Raw().(string))
+		}
+
+		require.NoError(t, iter.Close())
+
+		return result
+	}
+
+	iter, err := table.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, expected, codes(iter))
+
+	iter, err = table.ScanRange(
+		&sql.Bound{Value: datatype.NewText("1"), Inclusive: true},
+		&sql.Bound{Value: datatype.NewText("102"), Inclusive: false},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100", "101"}, codes(iter))
+}
+
+func TestTable_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		writers = 8
+		readers = 4
+		rows    = 100
+	)
+
+	scheme := sql.Scheme{
+		"id": sql.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": sql.Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+	}
+
+	table := memory.NewTable("users", scheme)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < rows; i++ {
+				key := int64(w*rows + i)
+
+				assert.NoError(t, table.Insert(sql.IntegerKey(key), sql.Row{datatype.NewInteger(key), datatype.NewText("bob")}))
+				assert.NoError(t, table.Update(sql.IntegerKey(key), sql.Row{datatype.NewInteger(key), datatype.NewText("alice")}))
+
+				if i%2 == 0 {
+					assert.NoError(t, table.Delete(sql.IntegerKey(key)))
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+
+		go func(r int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("users_name_%d", r)
+
+			for i := 0; i < rows; i++ {
+				_, _, err := table.Get(sql.IntegerKey(int64(i)))
+				assert.NoError(t, err)
+
+				iter, err := table.ScanRange(&sql.Bound{Value: datatype.NewInteger(int64(i)), Inclusive: true}, nil)
+				assert.NoError(t, err)
+				assert.NoError(t, iter.Close())
+
+				if _, err = table.CreateIndex(name, []string{"name"}, false); err == nil {
+					for _, idx := range table.Indexes() {
+						iter, err = idx.Scan(sql.IndexRange{Prefix: []sql.Value{datatype.NewText("alice")}})
+						assert.NoError(t, err)
+						assert.NoError(t, iter.Close())
+					}
+
+					assert.NoError(t, table.DropIndex(name))
+				}
+			}
+		}(r)
+	}
+
+	wg.Wait()
+
+	iter, err := table.Scan()
+	require.NoError(t, err)
+
+	count := 0
+
+	for {
+		_, err = iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		count++
+	}
+
+	assert.Equal(t, writers*rows/2, count)
+	assert.Equal(t, int64(writers*rows-1), table.Sequence().Next()-1)
+}
@@ -0,0 +1,223 @@
+// Package wal implements a write-ahead log: an append-only file of checksummed records.
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Every record is prefixed with the header:
+//
+//	+----------------+---------------+---------+
+//	| length, uint32 | crc32, uint32 | payload |
+//	+----------------+---------------+---------+
+const headerSize = 8
+
+// MaxRecordSize is the size of the largest payload that can be appended to the log.
+const MaxRecordSize = 64 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Log is a write-ahead log. Append returns only after the record is flushed to the stable storage.
+type Log struct {
+	mu          sync.Mutex // guards file writes and size
+	syncMu      sync.Mutex // serializes fsync calls
+	file        *os.File
+	size        int64
+	synced      int64
+	groupCommit time.Duration
+}
+
+// Open opens the log file, creating it if it doesn't exist.
+//
+// If groupCommit is greater than zero, Append waits up to that duration before calling fsync,
+// so records appended concurrently by other goroutines are flushed by a single fsync.
+func Open(path string, groupCommit time.Duration) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("stat log: %w", err)
+	}
+
+	l := &Log{
+		file:        file,
+		size:        info.Size(),
+		synced:      info.Size(),
+		groupCommit: groupCommit,
+	}
+
+	return l, nil
+}
+
+// Append writes the record to the end of the log and waits until it's flushed to the disk.
+func (l *Log) Append(record []byte) error {
+	if len(record) > MaxRecordSize {
+		return fmt.Errorf("record size %d exceeds maximum %d", len(record), MaxRecordSize)
+	}
+
+	buf := make([]byte, headerSize, headerSize+len(record))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(record, crcTable))
+	buf = append(buf, record...)
+
+	l.mu.Lock()
+
+	if _, err := l.file.WriteAt(buf, l.size); err != nil {
+		l.mu.Unlock()
+
+		return fmt.Errorf("write record: %w", err)
+	}
+
+	l.size += int64(len(buf))
+	end := l.size
+
+	l.mu.Unlock()
+
+	return l.sync(end)
+}
+
+// sync flushes the log at least up to the given offset.
+func (l *Log) sync(offset int64) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	if l.synced >= offset {
+		// Somebody else has already flushed our record.
+		return nil
+	}
+
+	if l.groupCommit > 0 {
+		time.Sleep(l.groupCommit)
+	}
+
+	l.mu.Lock()
+	size := l.size
+	l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("sync log: %w", err)
+	}
+
+	l.synced = size
+
+	return nil
+}
+
+// Replay reads all records from the beginning of the log and passes them to fn.
+// A torn or corrupted record at the tail of the log (a write interrupted by a crash) ends the replay
+// and is cut off, so new records are appended right after the last valid one.
+func (l *Log) Replay(fn func(record []byte) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var offset int64
+
+	header := make([]byte, headerSize)
+
+	for {
+		if _, err := l.file.ReadAt(header, offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("read record header: %w", err)
+		}
+
+		length := binary.BigEndian.Uint32(header[0:4])
+		checksum := binary.BigEndian.Uint32(header[4:8])
+
+		if length > MaxRecordSize || offset+headerSize+int64(length) > l.size {
+			break
+		}
+
+		record := make([]byte, length)
+
+		if _, err := l.file.ReadAt(record, offset+headerSize); err != nil {
+			return fmt.Errorf("read record: %w", err)
+		}
+
+		if crc32.Checksum(record, crcTable) != checksum {
+			break
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+
+		offset += headerSize + int64(length)
+	}
+
+	if offset < l.size {
+		if err := l.file.Truncate(offset); err != nil {
+			return fmt.Errorf("truncate torn tail: %w", err)
+		}
+
+		if err := l.file.Sync(); err != nil {
+			return fmt.Errorf("sync log: %w", err)
+		}
+
+		l.size = offset
+		l.synced = offset
+	}
+
+	return nil
+}
+
+// Size returns the size of the log in bytes.
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.size
+}
+
+// Reset discards all records. It must be called only after the changes described by the records are durable.
+func (l *Log) Reset() error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate log: %w", err)
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("sync log: %w", err)
+	}
+
+	l.size = 0
+	l.synced = 0
+
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		_ = l.file.Close()
+
+		return err
+	}
+
+	return l.file.Close()
+}
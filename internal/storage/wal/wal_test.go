@@ -0,0 +1,182 @@
+package wal_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/i-sevostyanov/NanoDB/internal/storage/wal"
+)
+
+func TestLog_AppendReplay(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "wal.log")
+	expected := [][]byte{
+		[]byte("first"),
+		[]byte(""),
+		[]byte("third"),
+	}
+
+	log, err := wal.Open(path, 0)
+	require.NoError(t, err)
+
+	for _, record := range expected {
+		require.NoError(t, log.Append(record))
+	}
+
+	require.NoError(t, log.Close())
+
+	log, err = wal.Open(path, 0)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, log.Close())
+	}()
+
+	assert.Equal(t, expected, replay(t, log))
+}
+
+func TestLog_Replay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("cuts off torn tail", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, err := wal.Open(path, 0)
+		require.NoError(t, err)
+		require.NoError(t, log.Append([]byte("first")))
+		require.NoError(t, log.Append([]byte("second")))
+		require.NoError(t, log.Close())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(path, info.Size()-3))
+
+		log, err = wal.Open(path, 0)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, log.Close())
+		}()
+
+		assert.Equal(t, [][]byte{[]byte("first")}, replay(t, log))
+		assert.Equal(t, int64(8+len("first")), log.Size())
+
+		require.NoError(t, log.Append([]byte("third")))
+		assert.Equal(t, [][]byte{[]byte("first"), []byte("third")}, replay(t, log))
+	})
+
+	t.Run("stops at corrupted record", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "wal.log")
+
+		log, err := wal.Open(path, 0)
+		require.NoError(t, err)
+		require.NoError(t, log.Append([]byte("first")))
+		require.NoError(t, log.Append([]byte("second")))
+		require.NoError(t, log.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+
+		log, err = wal.Open(path, 0)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, log.Close())
+		}()
+
+		assert.Equal(t, [][]byte{[]byte("first")}, replay(t, log))
+	})
+
+	t.Run("returns callback error", func(t *testing.T) {
+		t.Parallel()
+
+		log, err := wal.Open(filepath.Join(t.TempDir(), "wal.log"), 0)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, log.Close())
+		}()
+
+		require.NoError(t, log.Append([]byte("first")))
+
+		expectedErr := errors.New("something went wrong")
+		err = log.Replay(func([]byte) error {
+			return expectedErr
+		})
+		require.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func TestLog_Reset(t *testing.T) {
+	t.Parallel()
+
+	log, err := wal.Open(filepath.Join(t.TempDir(), "wal.log"), 0)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, log.Close())
+	}()
+
+	require.NoError(t, log.Append([]byte("first")))
+	require.NoError(t, log.Reset())
+	assert.Zero(t, log.Size())
+	assert.Empty(t, replay(t, log))
+}
+
+func TestLog_GroupCommit(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "wal.log")
+
+	log, err := wal.Open(path, time.Millisecond)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			assert.NoError(t, log.Append([]byte(fmt.Sprintf("record-%d", i))))
+		}(i)
+	}
+
+	wg.Wait()
+
+	records := replay(t, log)
+	require.Len(t, records, 50)
+	require.NoError(t, log.Close())
+}
+
+func replay(t *testing.T, log *wal.Log) [][]byte {
+	t.Helper()
+
+	records := make([][]byte, 0)
+
+	err := log.Replay(func(record []byte) error {
+		records = append(records, record)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return records
+}